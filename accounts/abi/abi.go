@@ -17,6 +17,7 @@
 package abi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,6 +26,7 @@ import (
 	"strings"
 
 	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/crypto"
 )
 
 // The ABI holds information about a contract's context and available
@@ -361,3 +363,21 @@ func (abi *ABI) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// revertSelector is the selector of Error(string), the revert reason Solidity
+// encodes for failing require and revert statements.
+var revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+
+// UnpackRevert returns the reason of the return data of a reverted execution,
+// failing if it isn't an ABI encoded Error(string).
+func UnpackRevert(data []byte) (string, error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], revertSelector) {
+		return "", fmt.Errorf("abi: revert data isn't an Error(string)")
+	}
+	typ, _ := NewType("string")
+	reason, err := toGoType(0, Argument{Type: typ}, data[4:])
+	if err != nil {
+		return "", err
+	}
+	return reason.(string), nil
+}
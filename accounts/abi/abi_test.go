@@ -1150,3 +1150,29 @@ func TestUnmarshal(t *testing.T) {
 		t.Fatal("expected error:", err)
 	}
 }
+
+func TestUnpackRevert(t *testing.T) {
+	// revert("Not enough Ether provided.")
+	data := common.FromHex("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000001a" +
+		"4e6f7420656e6f7567682045746865722070726f76696465642e000000000000")
+	reason, err := UnpackRevert(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason != "Not enough Ether provided." {
+		t.Errorf("reason mismatch: have %q", reason)
+	}
+
+	for i, data := range [][]byte{
+		nil,
+		{0x08, 0xc3, 0x79},
+		common.FromHex("0x4e487b710000000000000000000000000000000000000000000000000000000000000001"),
+		data[:40],
+	} {
+		if reason, err := UnpackRevert(data); err == nil {
+			t.Errorf("test %d: unpacked malformed revert data as %q", i, reason)
+		}
+	}
+}
@@ -17,7 +17,10 @@
 package backends
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/webchain-network/webchaind/accounts/abi/bind"
 	"github.com/webchain-network/webchaind/common"
@@ -39,6 +42,17 @@ type SimulatedBackend struct {
 
 	pendingBlock *types.Block   // Currently pending block that will be imported on request
 	pendingState *state.StateDB // Currently pending state that will be the active on on request
+
+	timeOffset int64               // Seconds the pending block's time is moved ahead by AdjustTime
+	autoCommit bool                // Whether sent transactions are imported immediately
+	snapshots  []simulatedSnapshot // Chain states to revert to, by snapshot id
+}
+
+// simulatedSnapshot is the chain state recorded by SimulatedBackend.Snapshot.
+type simulatedSnapshot struct {
+	head       uint64
+	txs        types.Transactions
+	timeOffset int64
 }
 
 // NewSimulatedBackend creates a new binding backend using a simulated blockchain
@@ -63,17 +77,95 @@ func (b *SimulatedBackend) Commit() {
 	if res := b.blockchain.InsertChain([]*types.Block{b.pendingBlock}); res.Error != nil {
 		panic(res.Error) // This cannot happen unless the simulator is wrong, fail in that case
 	}
+	b.timeOffset = 0
 	b.Rollback()
 }
 
 // Rollback aborts all pending transactions, reverting to the last committed state.
 func (b *SimulatedBackend) Rollback() {
-	blocks, _ := core.GenerateChain(core.DefaultConfigMorden.ChainConfig, b.blockchain.CurrentBlock(), b.database, 1, func(int, *core.BlockGen) {})
+	b.setPending(nil)
+}
+
+// SetAutoCommit sets whether each sent transaction is imported immediately in
+// a block of its own, without having to call Commit.
+func (b *SimulatedBackend) SetAutoCommit(auto bool) {
+	b.autoCommit = auto
+}
+
+// AdjustTime moves the time of the pending block ahead by d, on top of the
+// regular block time. Blocks committed later follow on from the moved time.
+func (b *SimulatedBackend) AdjustTime(d time.Duration) error {
+	if d < 0 {
+		return errors.New("time can't be moved backwards")
+	}
+	b.timeOffset += int64(d / time.Second)
+	b.setPending(b.pendingBlock.Transactions())
+	return nil
+}
 
+// Snapshot records the current chain head and pending transactions, returning
+// an id to revert to them with RevertToSnapshot.
+func (b *SimulatedBackend) Snapshot() int {
+	b.snapshots = append(b.snapshots, simulatedSnapshot{
+		head:       b.blockchain.CurrentBlock().NumberU64(),
+		txs:        b.pendingBlock.Transactions(),
+		timeOffset: b.timeOffset,
+	})
+	return len(b.snapshots) - 1
+}
+
+// RevertToSnapshot discards all blocks and pending transactions since the
+// snapshot with the given id was taken. The snapshot and all later ones are
+// invalidated.
+func (b *SimulatedBackend) RevertToSnapshot(id int) error {
+	if id < 0 || id >= len(b.snapshots) {
+		return fmt.Errorf("unknown snapshot %d", id)
+	}
+	snap := b.snapshots[id]
+	b.snapshots = b.snapshots[:id]
+
+	if err := b.blockchain.SetHead(snap.head); err != nil {
+		return err
+	}
+	b.timeOffset = snap.timeOffset
+	b.setPending(snap.txs)
+	return nil
+}
+
+// setPending regenerates the pending block and state on top of the current
+// head with the given transactions.
+func (b *SimulatedBackend) setPending(txs types.Transactions) {
+	blocks, _ := core.GenerateChain(core.DefaultConfigMorden.ChainConfig, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
+		if b.timeOffset != 0 {
+			block.OffsetTime(b.timeOffset)
+		}
+		for _, tx := range txs {
+			block.AddTx(tx)
+		}
+	})
 	b.pendingBlock = blocks[0]
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), state.NewDatabase(b.database))
 }
 
+// BalanceAt returns the balance of an account in the pending or the latest
+// committed state.
+func (b *SimulatedBackend) BalanceAt(account common.Address, pending bool) (*big.Int, error) {
+	if pending {
+		return b.pendingState.GetBalance(account), nil
+	}
+	statedb, err := b.blockchain.State()
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetBalance(account), nil
+}
+
+// TransactionReceipt returns the receipt of a committed transaction, or nil if
+// it isn't known.
+func (b *SimulatedBackend) TransactionReceipt(txHash common.Hash) *types.Receipt {
+	return core.GetReceipt(b.database, txHash)
+}
+
 // HasCode implements ContractVerifier.HasCode, checking whether there is any
 // code associated with a certain account in the blockchain.
 func (b *SimulatedBackend) HasCode(contract common.Address, pending bool) (bool, error) {
@@ -174,18 +266,14 @@ func (b *SimulatedBackend) EstimateGasLimit(sender common.Address, contract *com
 	return gas, err
 }
 
-// SendTransaction implements ContractTransactor.SendTransaction, delegating the raw
-// transaction injection to the remote node.
+// SendTransaction implements ContractTransactor.SendTransaction, adding the
+// transaction to the pending block, which is committed right away if auto
+// commit is enabled.
 func (b *SimulatedBackend) SendTransaction(tx *types.Transaction) error {
-	blocks, _ := core.GenerateChain(core.DefaultConfigMorden.ChainConfig, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
-		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTx(tx)
-		}
-		block.AddTx(tx)
-	})
-	b.pendingBlock = blocks[0]
-	b.pendingState, _ = state.New(b.pendingBlock.Root(), state.NewDatabase(b.database))
-
+	b.setPending(append(b.pendingBlock.Transactions(), tx))
+	if b.autoCommit {
+		b.Commit()
+	}
 	return nil
 }
 
@@ -207,3 +295,5 @@ func (m callmsg) GasPrice() *big.Int                    { return m.gasPrice }
 func (m callmsg) Gas() *big.Int                         { return m.gasLimit }
 func (m callmsg) Value() *big.Int                       { return m.value }
 func (m callmsg) Data() []byte                          { return m.data }
+func (m callmsg) GasTipCap() *big.Int                   { return m.gasPrice }
+func (m callmsg) AccessList() types.AccessList          { return nil }
@@ -0,0 +1,160 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testTo      = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	testBalance = big.NewInt(1000000000)
+)
+
+// sendTransfer sends value to testTo from the funded test account.
+func sendTransfer(t *testing.T, sim *SimulatedBackend, value int64) *types.Transaction {
+	nonce, err := sim.PendingAccountNonce(testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := core.DefaultConfigMorden.ChainConfig.GetSigner(sim.pendingBlock.Number())
+	tx, err := types.NewTransaction(nonce, testTo, big.NewInt(value), core.TxGas, nil, nil).WithSigner(signer).SignECDSA(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.SendTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func checkBalance(t *testing.T, sim *SimulatedBackend, pending bool, want int64) {
+	balance, err := sim.BalanceAt(testTo, pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Cmp(big.NewInt(want)) != 0 {
+		t.Errorf("balance mismatch (pending %v): have %v, want %d", pending, balance, want)
+	}
+}
+
+func TestSimulatedCommit(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAccount{Address: testAddr, Balance: testBalance})
+
+	tx := sendTransfer(t, sim, 1000)
+	checkBalance(t, sim, true, 1000)
+	checkBalance(t, sim, false, 0)
+	if sim.TransactionReceipt(tx.Hash()) != nil {
+		t.Error("receipt of pending transaction found")
+	}
+	sim.Commit()
+
+	checkBalance(t, sim, false, 1000)
+	if receipt := sim.TransactionReceipt(tx.Hash()); receipt == nil {
+		t.Error("receipt of committed transaction missing")
+	} else if receipt.GasUsed.Cmp(core.TxGas) != 0 {
+		t.Errorf("gas used mismatch: have %v, want %v", receipt.GasUsed, core.TxGas)
+	}
+	if head := sim.blockchain.CurrentBlock().NumberU64(); head != 1 {
+		t.Errorf("head mismatch: have %d, want 1", head)
+	}
+}
+
+func TestSimulatedAdjustTime(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAccount{Address: testAddr, Balance: testBalance})
+	parent := sim.blockchain.CurrentBlock().Time().Int64()
+
+	if err := sim.AdjustTime(-time.Second); err == nil {
+		t.Error("negative adjustment accepted")
+	}
+	tx := sendTransfer(t, sim, 1000)
+	if err := sim.AdjustTime(100 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if txs := sim.pendingBlock.Transactions(); len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+		t.Error("pending transactions lost by time adjustment")
+	}
+	sim.Commit()
+
+	head := sim.blockchain.CurrentBlock()
+	if have, want := head.Time().Int64(), parent+10+100; have != want {
+		t.Errorf("block time mismatch: have %d, want %d", have, want)
+	}
+	// The adjustment only applies to the block it was made for.
+	sim.Commit()
+	if have, want := sim.blockchain.CurrentBlock().Time().Int64(), head.Time().Int64()+10; have != want {
+		t.Errorf("next block time mismatch: have %d, want %d", have, want)
+	}
+}
+
+func TestSimulatedSnapshot(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAccount{Address: testAddr, Balance: testBalance})
+
+	sendTransfer(t, sim, 1000)
+	sim.Commit()
+	pending := sendTransfer(t, sim, 2000)
+
+	id := sim.Snapshot()
+	sendTransfer(t, sim, 4000)
+	sim.Commit()
+	sendTransfer(t, sim, 8000)
+	sim.Commit()
+	checkBalance(t, sim, false, 15000)
+
+	if err := sim.RevertToSnapshot(id); err != nil {
+		t.Fatal(err)
+	}
+	if head := sim.blockchain.CurrentBlock().NumberU64(); head != 1 {
+		t.Errorf("head mismatch: have %d, want 1", head)
+	}
+	checkBalance(t, sim, false, 1000)
+	checkBalance(t, sim, true, 3000)
+	if txs := sim.pendingBlock.Transactions(); len(txs) != 1 || txs[0].Hash() != pending.Hash() {
+		t.Error("pending transactions not restored")
+	}
+	if err := sim.RevertToSnapshot(id); err == nil {
+		t.Error("reverted to invalidated snapshot")
+	}
+
+	// The chain continues normally after a revert.
+	sim.Commit()
+	checkBalance(t, sim, false, 3000)
+}
+
+func TestSimulatedAutoCommit(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAccount{Address: testAddr, Balance: testBalance})
+	sim.SetAutoCommit(true)
+
+	tx := sendTransfer(t, sim, 1000)
+	if sim.TransactionReceipt(tx.Hash()) == nil {
+		t.Error("transaction not committed")
+	}
+	sendTransfer(t, sim, 2000)
+	checkBalance(t, sim, false, 3000)
+	if head := sim.blockchain.CurrentBlock().NumberU64(); head != 2 {
+		t.Errorf("head mismatch: have %d, want 2", head)
+	}
+}
@@ -67,6 +67,7 @@ func newCacheDB(keydir string) *cacheDB {
 		db: bdb,
 	}
 	cdb.keydir = keydir
+	cdb.watcher = newWatcher(cdb)
 
 	if e := cdb.db.Update(func(tx *bolt.Tx) error {
 		if _, e := tx.CreateBucketIfNotExists(addrBucketName); e != nil {
@@ -107,12 +108,83 @@ func (cdb *cacheDB) getThrottle() *time.Timer {
 	return cdb.throttle
 }
 
+// maybeReload makes sure the keystore directory is watched, so key files added or
+// removed by other processes are reflected in the index.
 func (cdb *cacheDB) maybeReload() {
-	// do nothing (implements caching interface)
+	cdb.mu.Lock()
+	defer cdb.mu.Unlock()
+	if cdb.watcher.running {
+		return // A watcher is running and will keep the index up-to-date.
+	}
+	if cdb.throttle == nil {
+		cdb.throttle = time.NewTimer(0)
+	} else {
+		select {
+		case <-cdb.throttle.C:
+		default:
+			return // The index was reloaded recently.
+		}
+	}
+	cdb.watcher.start()
+	cdb.reload()
+	cdb.throttle.Reset(minReloadInterval)
 }
 
+// reload brings the index in line with the keystore directory, indexing new key
+// files and dropping those which were removed. Unlike Syncfs2db it leaves files
+// already indexed alone.
+// Callers must hold cdb.mu.
 func (cdb *cacheDB) reload() {
-	// do nothing (implements caching interface)
+	files, err := ioutil.ReadDir(cdb.keydir)
+	if err != nil {
+		glog.V(logger.Debug).Errorf("can't read keystore directory %s: %v", cdb.keydir, err)
+		return
+	}
+	indexed := make(map[string]Account)
+	if err := cdb.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(fileBucketName).ForEach(func(k, v []byte) error {
+			a := bytesToAccount(v)
+			a.File = string(k)
+			indexed[a.File] = a
+			return nil
+		})
+	}); err != nil {
+		glog.V(logger.Debug).Errorf("can't read accounts index: %v", err)
+		return
+	}
+
+	var added []Account
+	for i, fi := range files {
+		if _, ok := indexed[fi.Name()]; ok {
+			delete(indexed, fi.Name())
+			continue
+		}
+		if skipKeyFile(fi) {
+			continue
+		}
+		var (
+			wg    = new(sync.WaitGroup)
+			achan = make(chan Account, 1)
+			echan = make(chan error, 1)
+		)
+		wg.Add(1)
+		processKeyFile(wg, filepath.Join(cdb.keydir, fi.Name()), fi, i, len(files), achan, echan)
+		select {
+		case a := <-achan:
+			added = append(added, a)
+		case <-echan:
+		}
+	}
+	for _, e := range cdb.setBatchAccounts(added) {
+		glog.V(logger.Debug).Errorf("can't index key file: %v", e)
+	}
+	// Whatever remains indexed has no key file anymore.
+	for _, a := range indexed {
+		cdb.delete(a)
+	}
+	if len(added) > 0 || len(indexed) > 0 {
+		glog.V(logger.Debug).Infof("reloaded keys, indexed %d and removed %d accounts", len(added), len(indexed))
+	}
 }
 
 // Gets all accounts _byFile_, which contains and possibly exceed byAddr content
@@ -259,6 +331,10 @@ func (cdb *cacheDB) find(a Account) (Account, error) {
 
 func (cdb *cacheDB) close() {
 	cdb.mu.Lock()
+	cdb.watcher.close()
+	if cdb.throttle != nil {
+		cdb.throttle.Stop()
+	}
 	cdb.db.Close()
 	cdb.mu.Unlock()
 }
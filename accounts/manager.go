@@ -79,6 +79,8 @@ type Manager struct {
 	keyStore keyStore
 	mu       sync.RWMutex
 	unlocked map[common.Address]*unlocked
+
+	watchOnly map[common.Address]bool // addresses followed without a key, protected by mu
 }
 
 type unlocked struct {
@@ -116,6 +118,9 @@ func NewManager(keydir string, scryptN, scryptP int, wantCacheDB bool) (*Manager
 	} else {
 		am.ac = newAddrCache(keydir)
 	}
+	if err := am.loadWatchOnly(); err != nil {
+		return nil, fmt.Errorf("loading watch-only accounts: %v", err)
+	}
 
 	// TODO: In order for this finalizer to work, there must be no references
 	// to am. addrCache doesn't keep a reference but unlocked keys do,
@@ -140,11 +145,14 @@ func (am *Manager) BuildIndexDB() []error {
 
 // HasAddress reports whether a key with the given address is present.
 func (am *Manager) HasAddress(addr common.Address) bool {
+	am.ac.maybeReload()
 	return am.ac.hasAddress(addr)
 }
 
-// Accounts returns all key files present in the directory.
+// Accounts returns all key files present in the directory. Key files added or
+// removed while running are picked up as the directory is watched.
 func (am *Manager) Accounts() []Account {
+	am.ac.maybeReload()
 	return am.ac.accounts()
 }
 
@@ -184,6 +192,9 @@ func (am *Manager) Sign(addr common.Address, hash []byte) (signature []byte, err
 
 	unlockedKey, found := am.unlocked[addr]
 	if !found {
+		if am.watchOnly[addr] {
+			return nil, ErrWatchOnly
+		}
 		return nil, ErrLocked
 	}
 	return crypto.Sign(hash, unlockedKey.PrivateKey)
@@ -256,6 +267,9 @@ func (am *Manager) TimedUnlock(a Account, passphrase string, timeout time.Durati
 }
 
 func (am *Manager) getDecryptedKey(a Account, auth string) (Account, *key, error) {
+	if a.File == "" && am.IsWatchOnly(a.Address) {
+		return Account{}, nil, ErrWatchOnly
+	}
 	am.ac.maybeReload()
 	am.ac.muLock()
 	a, err := am.ac.find(a)
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/logger/glog"
 )
 
@@ -127,6 +128,50 @@ func TestSignWithPassphrase_Mem(t *testing.T) {
 	}
 }
 
+func TestWatchOnly_Mem(t *testing.T) {
+	dir, am := tmpManager(t)
+	defer os.RemoveAll(dir)
+
+	acc, err := am.NewAccount("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := am.AddWatchOnly(acc.Address); err != ErrKeyPresent {
+		t.Errorf("watching an account with a key: got error %v", err)
+	}
+	cold := common.HexToAddress("0x3f4e0668c20e100d7c2a27d4b177ac65b2875d26")
+	if err := am.AddWatchOnly(cold); err != nil {
+		t.Fatal(err)
+	}
+	if addrs := am.Addresses(); !reflect.DeepEqual(addrs, []common.Address{acc.Address, cold}) {
+		t.Errorf("addresses mismatch: got %x", addrs)
+	}
+	if _, err := am.Sign(cold, testSigData); err != ErrWatchOnly {
+		t.Errorf("signing with a watch-only account: got error %v", err)
+	}
+	if err := am.Unlock(Account{Address: cold}, "foo"); err != ErrWatchOnly {
+		t.Errorf("unlocking a watch-only account: got error %v", err)
+	}
+
+	// The watch-only accounts are kept across restarts.
+	am, err = NewManager(dir, veryLightScryptN, veryLightScryptP, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !am.IsWatchOnly(cold) || len(am.Accounts()) != 1 {
+		t.Fatalf("watch-only account not restored: %x, %d accounts", am.WatchOnly(), len(am.Accounts()))
+	}
+	if err := am.RemoveWatchOnly(cold); err != nil {
+		t.Fatal(err)
+	}
+	if err := am.RemoveWatchOnly(cold); err != ErrNoMatch {
+		t.Errorf("removing an unknown watch-only account: got error %v", err)
+	}
+	if watched := am.WatchOnly(); len(watched) != 0 {
+		t.Errorf("watch-only account not removed: %x", watched)
+	}
+}
+
 // unlocks newly created account in temp dir
 func TestTimedUnlock_Mem(t *testing.T) {
 	dir, am := tmpManager(t)
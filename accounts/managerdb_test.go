@@ -67,6 +67,49 @@ func TestManager_DB(t *testing.T) {
 	am = nil
 }
 
+func TestWatchNewFile_CacheDB(t *testing.T) {
+	dir, am := tmpManager_CacheDB(t)
+	defer os.RemoveAll(dir)
+	defer am.ac.close()
+
+	// Ensure the watcher is started before adding any files.
+	am.Accounts()
+	time.Sleep(5 * time.Second)
+	if w := am.ac.getWatcher(); !w.running {
+		t.Fatalf("watcher not running after %v: %v", 5*time.Second, spew.Sdump(w))
+	}
+
+	// Copy in the key files, the index should pick them up.
+	for _, a := range cachedbtestAccounts {
+		if err := ioutil.WriteFile(filepath.Join(dir, a.File), []byte(a.EncryptedKey), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var list []Account
+	for d := 200 * time.Millisecond; d < 5*time.Second; d *= 2 {
+		if list = am.Accounts(); reflect.DeepEqual(list, cachedbtestAccounts) {
+			break
+		}
+		time.Sleep(d)
+	}
+	if !reflect.DeepEqual(list, cachedbtestAccounts) {
+		t.Fatalf("got %s, want %s", spew.Sdump(list), spew.Sdump(cachedbtestAccounts))
+	}
+
+	// Remove one, it should disappear from the index.
+	if err := os.Remove(filepath.Join(dir, cachedbtestAccounts[0].File)); err != nil {
+		t.Fatal(err)
+	}
+	want := cachedbtestAccounts[1:]
+	for d := 200 * time.Millisecond; d < 5*time.Second; d *= 2 {
+		if list = am.Accounts(); reflect.DeepEqual(list, want) {
+			return
+		}
+		time.Sleep(d)
+	}
+	t.Errorf("got %s, want %s", spew.Sdump(list), spew.Sdump(want))
+}
+
 func TestManager_Accounts_CacheDB(t *testing.T) {
 	// bug(whilei): I don't know why you have to do rm.
 	// Running the file as a standalone test is no problem.
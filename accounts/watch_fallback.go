@@ -23,6 +23,6 @@ package accounts
 
 type watcher struct{ running bool }
 
-func newWatcher(*addrCache) *watcher { return new(watcher) }
-func (*watcher) start()              {}
-func (*watcher) close()              {}
+func newWatcher(caching) *watcher { return new(watcher) }
+func (*watcher) start()           {}
+func (*watcher) close()           {}
@@ -0,0 +1,145 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package accounts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+// watchOnlyFile is the file in the key directory the watch-only addresses are
+// kept in. Hidden files aren't scanned for keys.
+const watchOnlyFile = ".watch-only.json"
+
+var (
+	ErrWatchOnly  = errors.New("watch-only account has no key")
+	ErrKeyPresent = errors.New("account has a key")
+)
+
+// loadWatchOnly reads the watch-only addresses saved in the key directory.
+func (am *Manager) loadWatchOnly() error {
+	am.watchOnly = make(map[common.Address]bool)
+
+	data, err := ioutil.ReadFile(filepath.Join(am.keyStore.baseDir, watchOnlyFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var addrs []common.Address
+	if err := json.Unmarshal(data, &addrs); err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		am.watchOnly[addr] = true
+	}
+	return nil
+}
+
+// saveWatchOnly writes the watch-only addresses to the key directory. The
+// caller must hold am.mu.
+func (am *Manager) saveWatchOnly() error {
+	data, err := json.MarshalIndent(sortedAddresses(am.watchOnly), "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(am.keyStore.baseDir, watchOnlyFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// AddWatchOnly adds an address the node has no key for, e.g. of a cold wallet,
+// to the accounts it follows. Watch-only accounts are listed along with the
+// accounts with keys but can't sign.
+func (am *Manager) AddWatchOnly(addr common.Address) error {
+	if am.HasAddress(addr) {
+		return ErrKeyPresent
+	}
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	if am.watchOnly[addr] {
+		return nil
+	}
+	am.watchOnly[addr] = true
+	if err := am.saveWatchOnly(); err != nil {
+		delete(am.watchOnly, addr)
+		return err
+	}
+	return nil
+}
+
+// RemoveWatchOnly stops following a watch-only address.
+func (am *Manager) RemoveWatchOnly(addr common.Address) error {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	if !am.watchOnly[addr] {
+		return ErrNoMatch
+	}
+	delete(am.watchOnly, addr)
+	if err := am.saveWatchOnly(); err != nil {
+		am.watchOnly[addr] = true
+		return err
+	}
+	return nil
+}
+
+// WatchOnly returns the watch-only addresses, sorted.
+func (am *Manager) WatchOnly() []common.Address {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	return sortedAddresses(am.watchOnly)
+}
+
+// IsWatchOnly reports whether the address was added with AddWatchOnly.
+func (am *Manager) IsWatchOnly(addr common.Address) bool {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	return am.watchOnly[addr]
+}
+
+// Addresses returns the addresses of the keys followed by the watch-only ones.
+func (am *Manager) Addresses() []common.Address {
+	var addrs []common.Address
+	for _, acc := range am.Accounts() {
+		addrs = append(addrs, acc.Address)
+	}
+	return append(addrs, am.WatchOnly()...)
+}
+
+func sortedAddresses(set map[common.Address]bool) []common.Address {
+	addrs := make([]common.Address, 0, len(set))
+	for addr := range set {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+	return addrs
+}
@@ -47,6 +47,14 @@ var (
 		Name:  "debug",
 		Usage: "output full trace logs",
 	}
+	NoGasFlag = cli.BoolFlag{
+		Name:  "nogas",
+		Usage: "disables gas metering of the instructions",
+	}
+	NoRecursionFlag = cli.BoolFlag{
+		Name:  "norecursion",
+		Usage: "makes calls and creations from the code succeed without running anything",
+	}
 	ForceJitFlag = cli.BoolFlag{
 		Name:  "forcejit",
 		Usage: "forces jit compilation",
@@ -107,6 +115,8 @@ func init() {
 	app.Flags = []cli.Flag{
 		CreateFlag,
 		DebugFlag,
+		NoGasFlag,
+		NoRecursionFlag,
 		VerbosityFlag,
 		ForceJitFlag,
 		DisableJitFlag,
@@ -132,7 +142,14 @@ func run(ctx *cli.Context) error {
 	if valueFlag == nil {
 		log.Fatalf("malformed %s flag value %q", ValueFlag.Name, ctx.GlobalString(ValueFlag.Name))
 	}
-	vmenv := NewEnv(statedb, common.StringToAddress("evmuser"), valueFlag)
+	cfg := vm.Config{
+		DisableGasMetering: ctx.GlobalBool(NoGasFlag.Name),
+		NoRecursion:        ctx.GlobalBool(NoRecursionFlag.Name),
+	}
+	if ctx.GlobalBool(DebugFlag.Name) {
+		cfg.Trace = os.Stderr
+	}
+	vmenv := NewEnv(statedb, common.StringToAddress("evmuser"), valueFlag, cfg)
 
 	tstart := time.Now()
 
@@ -142,7 +159,7 @@ func run(ctx *cli.Context) error {
 	)
 
 	gasFlag, _ := new(big.Int).SetString(ctx.GlobalString(GasFlag.Name), 0)
-	if gasFlag == nil {
+	if gasFlag == nil || !gasFlag.IsUint64() {
 		log.Fatalf("malformed %s flag value %q", GasFlag.Name, ctx.GlobalString(GasFlag.Name))
 	}
 	priceFlag, _ := new(big.Int).SetString(ctx.GlobalString(PriceFlag.Name), 0)
@@ -152,13 +169,13 @@ func run(ctx *cli.Context) error {
 
 	if ctx.GlobalBool(CreateFlag.Name) {
 		input := append(common.Hex2Bytes(ctx.GlobalString(CodeFlag.Name)), common.Hex2Bytes(ctx.GlobalString(InputFlag.Name))...)
-		ret, _, err = vmenv.Create(sender, input, gasFlag, priceFlag, valueFlag)
+		ret, _, _, err = vmenv.Create(sender, input, gasFlag.Uint64(), priceFlag, valueFlag)
 	} else {
 		receiver := statedb.CreateAccount(common.StringToAddress("receiver"))
 
 		code := common.Hex2Bytes(ctx.GlobalString(CodeFlag.Name))
 		receiver.SetCode(crypto.Keccak256Hash(code), code)
-		ret, err = vmenv.Call(sender, receiver.Address(), common.Hex2Bytes(ctx.GlobalString(InputFlag.Name)), gasFlag, priceFlag, valueFlag)
+		ret, _, err = vmenv.Call(sender, receiver.Address(), common.Hex2Bytes(ctx.GlobalString(InputFlag.Name)), gasFlag.Uint64(), priceFlag, valueFlag)
 	}
 	vmdone := time.Since(tstart)
 
@@ -205,13 +222,13 @@ type VMEnv struct {
 
 	depth      int
 	returnData []byte
-	Gas        *big.Int
+	Gas        uint64
 	time       *big.Int
 
 	evm *vm.EVM
 }
 
-func NewEnv(state *state.StateDB, transactor common.Address, value *big.Int) *VMEnv {
+func NewEnv(state *state.StateDB, transactor common.Address, value *big.Int, cfg vm.Config) *VMEnv {
 	env := &VMEnv{
 		state:      state,
 		transactor: &transactor,
@@ -219,7 +236,7 @@ func NewEnv(state *state.StateDB, transactor common.Address, value *big.Int) *VM
 		time:       big.NewInt(time.Now().Unix()),
 	}
 
-	env.evm = vm.New(env)
+	env.evm = vm.NewWithConfig(env, cfg)
 	return env
 }
 
@@ -237,14 +254,14 @@ func (ruleSet) IsAtlantis(*big.Int) bool {
 
 func (ruleSet) GasTable(*big.Int) *vm.GasTable {
 	return &vm.GasTable{
-		ExtcodeSize:     big.NewInt(700),
-		ExtcodeCopy:     big.NewInt(700),
-		Balance:         big.NewInt(400),
-		SLoad:           big.NewInt(200),
-		Calls:           big.NewInt(700),
-		Suicide:         big.NewInt(5000),
-		ExpByte:         big.NewInt(10),
-		CreateBySuicide: big.NewInt(25000),
+		ExtcodeSize:     700,
+		ExtcodeCopy:     700,
+		Balance:         400,
+		SLoad:           200,
+		Calls:           700,
+		Suicide:         5000,
+		ExpByte:         10,
+		CreateBySuicide: 25000,
 	}
 }
 
@@ -262,7 +279,7 @@ func (self *VMEnv) BlockHash() []byte         { return make([]byte, 32) }
 func (self *VMEnv) Value() *big.Int           { return self.value }
 func (self *VMEnv) GasLimit() *big.Int        { return big.NewInt(1000000000) }
 func (self *VMEnv) VmType() vm.Type           { return vm.StdVmTy }
-func (self *VMEnv) Depth() int                { return 0 }
+func (self *VMEnv) Depth() int                { return self.depth }
 func (self *VMEnv) SetDepth(i int)            { self.depth = i }
 func (self *VMEnv) ReturnData() []byte        { return self.returnData }
 func (self *VMEnv) SetReturnData(data []byte) { self.returnData = data }
@@ -282,23 +299,27 @@ func (self *VMEnv) Transfer(from, to vm.Account, amount *big.Int) {
 	core.Transfer(from, to, amount)
 }
 
-func (self *VMEnv) Call(caller vm.ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error) {
+func (self *VMEnv) Call(caller vm.ContractRef, addr common.Address, data []byte, gas uint64, price, value *big.Int) ([]byte, uint64, error) {
 	self.Gas = gas
 	return core.Call(self, caller, addr, data, gas, price, value)
 }
 
-func (self *VMEnv) CallCode(caller vm.ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error) {
+func (self *VMEnv) CallCode(caller vm.ContractRef, addr common.Address, data []byte, gas uint64, price, value *big.Int) ([]byte, uint64, error) {
 	return core.CallCode(self, caller, addr, data, gas, price, value)
 }
 
-func (self *VMEnv) DelegateCall(caller vm.ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error) {
+func (self *VMEnv) DelegateCall(caller vm.ContractRef, addr common.Address, data []byte, gas uint64, price *big.Int) ([]byte, uint64, error) {
 	return core.DelegateCall(self, caller, addr, data, gas, price)
 }
 
-func (self *VMEnv) StaticCall(caller vm.ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error) {
+func (self *VMEnv) StaticCall(caller vm.ContractRef, addr common.Address, data []byte, gas uint64, price *big.Int) ([]byte, uint64, error) {
 	return core.StaticCall(self, caller, addr, data, gas, price)
 }
 
-func (self *VMEnv) Create(caller vm.ContractRef, data []byte, gas, price, value *big.Int) ([]byte, common.Address, error) {
+func (self *VMEnv) Create(caller vm.ContractRef, data []byte, gas uint64, price, value *big.Int) ([]byte, common.Address, uint64, error) {
 	return core.Create(self, caller, data, gas, price, value)
 }
+
+func (self *VMEnv) Create2(caller vm.ContractRef, data []byte, gas uint64, price, value, salt *big.Int) ([]byte, common.Address, uint64, error) {
+	return core.Create2(self, caller, data, gas, price, value, salt)
+}
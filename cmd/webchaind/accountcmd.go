@@ -24,6 +24,7 @@ import (
 	"os"
 
 	"github.com/webchain-network/webchaind/accounts"
+	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/console"
 	"github.com/webchain-network/webchaind/crypto"
 	"github.com/webchain-network/webchaind/logger"
@@ -103,6 +104,35 @@ webchaind account list
 
 		Account #0: {7fa65f0395f5ee0bcbae969d711823ab4353beae} /Users/ia/Library/EthereumClassic/mainnet/keystore/UTC--2017-10-31T13-53-59.993482857Z--7fa65f0395f5ee0bcbae969d711823ab4353beae
 		Account #1: {b5c694a4cdbc1820ba4ee8fd6f5ab71a25782534} /Users/ia/Library/EthereumClassic/mainnet/keystore/my_other_key
+
+	followed by the watch-only accounts:
+
+		Watch-only #0: {3f4e0668c20e100d7c2a27d4b177ac65b2875d26}
+				`,
+			},
+			{
+				Action: accountWatch,
+				Name:   "watch",
+				Usage:  "Follow an address without its key",
+				Description: `
+
+webchaind account watch <address>
+
+	Adds a watch-only account, an address the node has no key for, e.g. of a
+	cold wallet. Watch-only accounts are listed with the other accounts and
+	included in their balances, transaction history and balance notifications,
+	but can't sign. They are saved in the keystore directory.
+				`,
+			},
+			{
+				Action: accountUnwatch,
+				Name:   "unwatch",
+				Usage:  "Stop following a watch-only address",
+				Description: `
+
+webchaind account unwatch <address>
+
+	Removes a watch-only account.
 				`,
 			},
 			{
@@ -225,6 +255,36 @@ func accountList(ctx *cli.Context) error {
 
 		fmt.Printf("Account #%d: {%x} %s\n", i, acct.Address, acct.File)
 	}
+	for i, addr := range accman.WatchOnly() {
+		fmt.Printf("Watch-only #%d: {%x}\n", i, addr)
+	}
+	return nil
+}
+
+// watchOnlyAddress returns the address given as argument to the watch and
+// unwatch commands.
+func watchOnlyAddress(ctx *cli.Context) common.Address {
+	addr := ctx.Args().First()
+	if !common.IsHexAddress(addr) {
+		log.Fatalf("invalid address %q", addr)
+	}
+	return common.HexToAddress(addr)
+}
+
+func accountWatch(ctx *cli.Context) error {
+	addr := watchOnlyAddress(ctx)
+	if err := MakeAccountManager(ctx).AddWatchOnly(addr); err != nil {
+		log.Fatal("Could not add the watch-only account: ", err)
+	}
+	fmt.Printf("Watching: 0x%x\n", addr)
+	return nil
+}
+
+func accountUnwatch(ctx *cli.Context) error {
+	addr := watchOnlyAddress(ctx)
+	if err := MakeAccountManager(ctx).RemoveWatchOnly(addr); err != nil {
+		log.Fatal("Could not remove the watch-only account: ", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,97 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webchain-network/webchaind/core"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var exportAnalyticsCommand = cli.Command{
+	Action:    exportAnalytics,
+	Name:      "export-analytics",
+	Usage:     "Export blocks, transactions, receipts and logs of a block range for analytics",
+	ArgsUsage: "<dir> <firstBlock> <lastBlock>",
+	Description: `
+	Writes the canonical blocks of the given range, with their transactions,
+	receipts and logs, as one file per table into <dir>, replacing existing files.
+	Hashes, addresses and binary data are 0x-prefixed hex, amounts and quantities
+	are decimal, and empty values mean "none".
+
+	Use "$ webchaind export-analytics ./analytics 0 100000" to export the first
+	100001 blocks as CSV, add "--format parquet" to write Parquet files instead.
+	Parquet columns are required UTF-8 strings holding the same values as the
+	CSV ones, so warehouses cast them like they would the CSV columns.
+
+	Tables and columns:
+` + analyticsSchemaDoc(),
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Output file format (csv or parquet)",
+			Value: core.AnalyticsCSV,
+		},
+	},
+}
+
+// analyticsSchemaDoc lists the tables of the analytics export with their columns.
+func analyticsSchemaDoc() string {
+	var doc string
+	for _, table := range core.AnalyticsTables {
+		doc += fmt.Sprintf("\n\t%s: %s\n", table.Name, strings.Join(table.Columns, ", "))
+	}
+	return doc
+}
+
+func exportAnalytics(ctx *cli.Context) error {
+	if ctx.NArg() != 3 {
+		return fmt.Errorf("%v: use: $ webchaind export-analytics <dir> <firstBlock> <lastBlock>", ErrInvalidFlag)
+	}
+	format := ctx.String("format")
+	if format != core.AnalyticsCSV && format != core.AnalyticsParquet {
+		return fmt.Errorf("%v: unsupported format %q, want csv or parquet", ErrInvalidFlag, format)
+	}
+	first, err := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%v: invalid first block: %v", ErrInvalidFlag, err)
+	}
+	last, err := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%v: invalid last block: %v", ErrInvalidFlag, err)
+	}
+	chain, chainDb := MakeChain(ctx)
+	defer chainDb.Close()
+
+	start := time.Now()
+	summary, err := core.ExportAnalytics(chain, ctx.Args().First(), format, first, last)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/logger/glog"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var migrateAncientCommand = cli.Command{
+	Action: migrateAncientCmd,
+	Name:   "ancient-migrate",
+	Usage:  "Move ancient chain data to the --datadir.ancient database",
+	Description: `
+	Moves the headers, bodies, total difficulties and receipts of all canonical blocks
+	older than the most recent --keep ones from the chain database to the database
+	set with --datadir.ancient, e.g. on a cheaper and larger volume.
+	The command is idempotent and picks up where an interrupted run left off.
+	Running nodes freeze newly aged blocks on their own; from now on --datadir.ancient
+	must be passed whenever the chain data is used.
+			`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "keep",
+			Usage: "Number of most recent blocks whose data stays in the chain database",
+			Value: int(core.FreezeThreshold),
+		},
+	},
+}
+
+func migrateAncientCmd(ctx *cli.Context) error {
+	if MakeAncientDir(ctx) == "" {
+		glog.Fatalf("no ancient data directory set (--%v)", AncientDirFlag.Name)
+	}
+	keep := ctx.Int("keep")
+	if keep < 0 {
+		glog.Fatalf("--keep must not be negative")
+	}
+	bc, chainDb := MakeChain(ctx)
+	defer chainDb.Close()
+
+	head := bc.CurrentBlock().NumberU64()
+	if head <= uint64(keep) {
+		fmt.Printf("Chain head #%d isn't older than the %d blocks to keep, nothing to move\n", head, keep)
+		return nil
+	}
+	n, err := core.FreezeAncient(chainDb.(*ethdb.AncientDatabase), head-uint64(keep))
+	fmt.Printf("Moved the data of %d blocks to the ancient database\n", n)
+	return err
+}
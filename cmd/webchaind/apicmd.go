@@ -105,11 +105,16 @@ func callRPC(ctx *cli.Context, client rpc.Client) (interface{}, error) {
 		method = ctx.Args()[1]
 		args   = ctx.Args()[2:]
 	)
+	return sendRPC(client, module+"_"+method, json.RawMessage("["+strings.Join(args, ",")+"]"))
+}
+
+// sendRPC calls method with the given JSON encoded parameter list.
+func sendRPC(client rpc.Client, method string, params json.RawMessage) (interface{}, error) {
 	req := rpc.JSONRequest{
 		Id:      json.RawMessage(strconv.Itoa(rand.Int())),
-		Method:  module + "_" + method,
+		Method:  method,
 		Version: "2.0",
-		Payload: json.RawMessage("[" + strings.Join(args, ",") + "]"),
+		Payload: params,
 	}
 
 	if err := client.Send(req); err != nil {
@@ -121,8 +126,8 @@ func callRPC(ctx *cli.Context, client rpc.Client) (interface{}, error) {
 		return nil, err
 	}
 	if res.Error != nil {
-		return nil, fmt.Errorf("error in %s_%s: %s (code: %d)",
-			module, method, res.Error.Message, res.Error.Code)
+		return nil, fmt.Errorf("error in %s: %s (code: %d)",
+			method, res.Error.Message, res.Error.Code)
 	}
 	if res.Result != nil {
 		return res.Result, nil
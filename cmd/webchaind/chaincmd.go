@@ -19,6 +19,8 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -67,6 +69,34 @@ var (
 	Use "$ webchaind dump 0" to dump the genesis block.
 		`,
 	}
+	profileBlockCommand = cli.Command{
+		Action:  profileBlock,
+		Name:    "profile-block",
+		Aliases: []string{"profileblock"},
+		Usage:   "Replay a block and report the time and gas spent per opcode, contract and transaction",
+		Description: `
+	The argument is interpreted as a block number or hash. The block's parent state must be available.
+	Use "$ webchaind profile-block 1000000" to profile block 1000000.
+		`,
+	}
+	stateStatsCommand = cli.Command{
+		Action:    stateStats,
+		Name:      "state-stats",
+		Usage:     "Report account, contract code and storage statistics of the state",
+		ArgsUsage: "[blockNum]",
+		Description: `
+	Walks the whole state at the given block (default: current head) and reports the
+	number of accounts and contracts, the contract code size distribution, and the
+	contracts with the most storage slots as JSON. Walking the state takes a while.
+		`,
+		Flags: []cli.Flag{
+			cli.IntFlag{
+				Name:  "top",
+				Usage: "Number of contracts with the largest storage to list",
+				Value: 20,
+			},
+		},
+	}
 	dumpChainConfigCommand = cli.Command{
 		Action:  dumpChainConfig,
 		Name:    "dump-chain-config",
@@ -292,6 +322,65 @@ func dump(ctx *cli.Context) error {
 	return nil
 }
 
+func profileBlock(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("%v: use: $ webchaind profile-block [blockHash|blockNum]", ErrInvalidFlag)
+	}
+	chain, chainDb := MakeChain(ctx)
+	defer chainDb.Close()
+
+	var block *types.Block
+	if b := strings.TrimSpace(ctx.Args().First()); hashish(b) {
+		block = chain.GetBlock(common.HexToHash(b))
+	} else {
+		num, _ := strconv.Atoi(b)
+		block = chain.GetBlockByNumber(uint64(num))
+	}
+	if block == nil {
+		return errors.New("block not found")
+	}
+	profile, err := core.ProfileBlock(chain, block)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func stateStats(ctx *cli.Context) error {
+	chain, chainDb := MakeChain(ctx)
+	defer chainDb.Close()
+
+	block := chain.CurrentBlock()
+	if ctx.NArg() > 0 {
+		n, err := strconv.ParseUint(ctx.Args().First(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%v: invalid block number: %v", ErrInvalidFlag, err)
+		}
+		if block = chain.GetBlockByNumber(n); block == nil {
+			return fmt.Errorf("block #%d not found", n)
+		}
+	}
+	statedb, err := chain.StateAt(block.Root())
+	if err != nil {
+		return err
+	}
+	stats, err := statedb.Stats(ctx.Int("top"))
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
 // hashish returns true for strings that look like hashes.
 func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
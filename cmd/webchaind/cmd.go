@@ -42,6 +42,7 @@ import (
 	"github.com/webchain-network/webchaind/logger/glog"
 	"github.com/webchain-network/webchaind/node"
 	"github.com/webchain-network/webchaind/pow"
+	"github.com/webchain-network/webchaind/release"
 	"github.com/webchain-network/webchaind/rlp"
 	"gopkg.in/urfave/cli.v1"
 	"math"
@@ -383,6 +384,10 @@ func formatEthConfigPretty(ethConfig *eth.Config) (s []string) {
 	ss = append(ss, printable{0, "Blockchain version", ethConfig.BlockChainVersion})
 	// DatabaseCache
 	ss = append(ss, printable{0, "Database cache (MB)", ethConfig.DatabaseCache})
+	ss = append(ss, printable{0, "Trie clean cache (MB)", ethConfig.TrieCleanCache})
+	ss = append(ss, printable{0, "Trie dirty cache (MB)", ethConfig.TrieDirtyCache})
+	ss = append(ss, printable{0, "Snapshot cache (MB)", ethConfig.SnapshotCache})
+	ss = append(ss, printable{0, "State prefetch workers", ethConfig.StatePrefetchWorkers})
 	// DatabaseHandles
 	ss = append(ss, printable{0, "Database file handles", ethConfig.DatabaseHandles})
 	// NatSpec?
@@ -393,6 +398,12 @@ func formatEthConfigPretty(ethConfig *eth.Config) (s []string) {
 	ss = append(ss, printable{0, "Pow shared", ethConfig.PowShared})
 	// SolcPath
 	ss = append(ss, printable{0, "Solc path", ethConfig.SolcPath})
+	if ethConfig.SideBlockRetention > 0 {
+		ss = append(ss, printable{0, "Side block retention", ethConfig.SideBlockRetention})
+	}
+	if len(ethConfig.CallDenyList) > 0 {
+		ss = append(ss, printable{0, "Denied call contracts", len(ethConfig.CallDenyList)})
+	}
 
 	// Account Manager
 	lenAccts := len(ethConfig.AccountManager.Accounts())
@@ -412,6 +423,9 @@ func formatEthConfigPretty(ethConfig *eth.Config) (s []string) {
 	ss = append(ss, printable{0, "GPO max gas price", ethConfig.GpoMaxGasPrice})
 	// MinerThreads
 	ss = append(ss, printable{0, "Miner threads", ethConfig.MinerThreads})
+	if ethConfig.MinerEmptyBlocks.Wait {
+		ss = append(ss, printable{0, "Miner empty block deadline", ethConfig.MinerEmptyBlocks.Deadline})
+	}
 
 	for _, v := range ss {
 		if v.val != nil {
@@ -458,6 +472,10 @@ func formatStackConfigPretty(stackConfig *node.Config) (s []string) {
 	ss = append(ss, printable{0, "Max peers", stackConfig.MaxPeers})
 	// MaxPendingPeers
 	ss = append(ss, printable{0, "Max pending peers", stackConfig.MaxPendingPeers})
+	// DialRatio
+	ss = append(ss, printable{0, "Dial ratio", stackConfig.DialRatio})
+	// DialHistoryExpiry
+	ss = append(ss, printable{0, "Dial history expiry", stackConfig.DialHistoryExpiry})
 	// HTTP
 	ss = append(ss, printable{0, "HTTP", nil})
 	// HTTPHost
@@ -775,6 +793,19 @@ func version(ctx *cli.Context) error {
 	fmt.Printf("GOPATH=%s\n", os.Getenv("GOPATH"))
 	fmt.Printf("GOROOT=%s\n", runtime.GOROOT())
 
+	info := release.ReadInfo(Version, Commit, BuildFlags)
+	if info.Release() {
+		fmt.Println("Commit:", info.Commit)
+		fmt.Println("Build Flags:", info.Flags)
+	}
+	fmt.Println("Dependencies Hash:", info.DepsHash())
+
+	if ctx.Bool("verify") {
+		if !info.Release() {
+			fmt.Println("Warning: not a release build, the binary can't match the release checksums")
+		}
+		return verifyRunningBinary(ctx)
+	}
 	return nil
 }
 
@@ -0,0 +1,243 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var crossCheckCommand = cli.Command{
+	Action:    crossCheck,
+	Name:      "cross-check",
+	Aliases:   []string{"crosscheck"},
+	Usage:     "Replay a block range and compare state roots and receipts against a reference node",
+	ArgsUsage: "<fromBlock> <toBlock>",
+	Description: `
+	Replays every block of the range on top of its parent state from the local
+	database and compares the resulting state root, receipts root and receipts
+	with the values returned by the reference node over RPC. It stops at the first
+	divergence and reports the block, the transaction and the field differing.
+
+	This is meant for validating VM changes, like new fork implementations, against
+	a trusted client version:
+
+		$ webchaind cross-check --reference http://localhost:39573 1000000 1010000
+		`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "reference",
+			Usage: "RPC endpoint of the reference node (ipc:, rpc:, http:// or ws:)",
+		},
+	},
+}
+
+// refBlock is the part of a reference block compared during a cross-check.
+type refBlock struct {
+	Hash         common.Hash   `json:"hash"`
+	StateRoot    common.Hash   `json:"stateRoot"`
+	ReceiptsRoot common.Hash   `json:"receiptsRoot"`
+	Transactions []common.Hash `json:"transactions"`
+}
+
+// refReceipt is the part of a reference receipt compared during a cross-check.
+type refReceipt struct {
+	Status            *hexutil.Uint64 `json:"status"`
+	GasUsed           *hexutil.Big    `json:"gasUsed"`
+	CumulativeGasUsed *hexutil.Big    `json:"cumulativeGasUsed"`
+	Logs              []refLog        `json:"logs"`
+}
+
+type refLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// divergence describes the first difference found between the local replay and
+// the reference node.
+type divergence struct {
+	Block     uint64
+	Tx        int // index of the diverging transaction, -1 for block fields
+	TxHash    common.Hash
+	Field     string
+	Local     interface{}
+	Reference interface{}
+}
+
+func (d *divergence) String() string {
+	s := fmt.Sprintf("block #%d", d.Block)
+	if d.Tx >= 0 {
+		s += fmt.Sprintf(", tx %d (%x)", d.Tx, d.TxHash)
+	}
+	return fmt.Sprintf("%s: %s differs\n  local:     %v\n  reference: %v", s, d.Field, d.Local, d.Reference)
+}
+
+func crossCheck(ctx *cli.Context) error {
+	if ctx.NArg() != 2 || ctx.String("reference") == "" {
+		return fmt.Errorf("%v: use: $ webchaind cross-check --reference <endpoint> <fromBlock> <toBlock>", ErrInvalidFlag)
+	}
+	from, err := strconv.ParseUint(ctx.Args()[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%v: invalid block number: %v", ErrInvalidFlag, err)
+	}
+	to, err := strconv.ParseUint(ctx.Args()[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%v: invalid block number: %v", ErrInvalidFlag, err)
+	}
+	if from == 0 || from > to {
+		return fmt.Errorf("%v: invalid block range %d-%d", ErrInvalidFlag, from, to)
+	}
+	client, err := rpc.NewClient(ctx.String("reference"))
+	if err != nil {
+		return err
+	}
+	chain, chainDb := MakeChain(ctx)
+	defer chainDb.Close()
+
+	for n := from; n <= to; n++ {
+		block := chain.GetBlockByNumber(n)
+		if block == nil {
+			return fmt.Errorf("block #%d not found", n)
+		}
+		d, err := crossCheckBlock(chain, client, block)
+		if err != nil {
+			return fmt.Errorf("block #%d: %v", n, err)
+		}
+		if d != nil {
+			fmt.Println(d)
+			return fmt.Errorf("divergence found at block #%d", n)
+		}
+		if n%1000 == 0 {
+			fmt.Printf("block #%d matches\n", n)
+		}
+	}
+	fmt.Printf("blocks #%d-#%d match the reference\n", from, to)
+	return nil
+}
+
+// crossCheckBlock replays block on its parent state and returns the first
+// divergence from the reference node, or nil if the block matches.
+func crossCheckBlock(chain *core.BlockChain, client rpc.Client, block *types.Block) (*divergence, error) {
+	parent := chain.GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, fmt.Errorf("parent %x not found", block.ParentHash())
+	}
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	receipts, _, _, err := chain.Processor().Process(block, statedb)
+	if err != nil {
+		return nil, err
+	}
+	root := statedb.IntermediateRoot(chain.Config().IsStateClear(block.Number()))
+
+	var ref refBlock
+	if err := crossCheckCall(client, "eth_getBlockByNumber", &ref, hexutil.EncodeUint64(block.NumberU64()), false); err != nil {
+		return nil, err
+	}
+	n := block.NumberU64()
+	if ref.Hash != block.Hash() {
+		return &divergence{Block: n, Tx: -1, Field: "hash", Local: block.Hash().Hex(), Reference: ref.Hash.Hex()}, nil
+	}
+	// Receipts tell which transaction went wrong, so they're compared first.
+	for i, tx := range block.Transactions() {
+		var r refReceipt
+		if err := crossCheckCall(client, "eth_getTransactionReceipt", &r, tx.Hash()); err != nil {
+			return nil, err
+		}
+		if field, local, reference := diffReceipt(receipts[i], &r); field != "" {
+			return &divergence{Block: n, Tx: i, TxHash: tx.Hash(), Field: field, Local: local, Reference: reference}, nil
+		}
+	}
+	if rr := types.DeriveSha(receipts); rr != ref.ReceiptsRoot {
+		return &divergence{Block: n, Tx: -1, Field: "receiptsRoot", Local: rr.Hex(), Reference: ref.ReceiptsRoot.Hex()}, nil
+	}
+	if root != ref.StateRoot {
+		return &divergence{Block: n, Tx: -1, Field: "stateRoot", Local: root.Hex(), Reference: ref.StateRoot.Hex()}, nil
+	}
+	return nil, nil
+}
+
+// diffReceipt returns the first field differing between a locally computed
+// receipt and the reference one, along with both values.
+func diffReceipt(local *types.Receipt, ref *refReceipt) (string, interface{}, interface{}) {
+	if ref.Status != nil && local.Status != types.TxStatusUnknown && uint64(*ref.Status) != uint64(local.Status) {
+		return "status", local.Status, uint64(*ref.Status)
+	}
+	if ref.GasUsed != nil && local.GasUsed.Cmp((*big.Int)(ref.GasUsed)) != 0 {
+		return "gasUsed", local.GasUsed, (*big.Int)(ref.GasUsed)
+	}
+	if ref.CumulativeGasUsed != nil && local.CumulativeGasUsed.Cmp((*big.Int)(ref.CumulativeGasUsed)) != 0 {
+		return "cumulativeGasUsed", local.CumulativeGasUsed, (*big.Int)(ref.CumulativeGasUsed)
+	}
+	if len(local.Logs) != len(ref.Logs) {
+		return "logs", fmt.Sprintf("%d logs", len(local.Logs)), fmt.Sprintf("%d logs", len(ref.Logs))
+	}
+	for i, l := range local.Logs {
+		if !sameLog(l, &ref.Logs[i]) {
+			return fmt.Sprintf("logs[%d]", i), fmtLog(l.Address, l.Topics, l.Data), fmtLog(ref.Logs[i].Address, ref.Logs[i].Topics, ref.Logs[i].Data)
+		}
+	}
+	return "", nil, nil
+}
+
+func sameLog(l *vm.Log, ref *refLog) bool {
+	if l.Address != ref.Address || len(l.Topics) != len(ref.Topics) || !bytes.Equal(l.Data, ref.Data) {
+		return false
+	}
+	for i := range l.Topics {
+		if l.Topics[i] != ref.Topics[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func fmtLog(addr common.Address, topics []common.Hash, data []byte) string {
+	return fmt.Sprintf("address=%x topics=%x data=%x", addr, topics, data)
+}
+
+// crossCheckCall calls method of the reference node and decodes its result
+// into res.
+func crossCheckCall(client rpc.Client, method string, res interface{}, params ...interface{}) error {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	out, err := sendRPC(client, method, payload)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, res)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+)
+
+func TestDiffReceipt(t *testing.T) {
+	local := &types.Receipt{
+		Status:            types.TxSuccess,
+		GasUsed:           big.NewInt(21000),
+		CumulativeGasUsed: big.NewInt(42000),
+		Logs: vm.Logs{{
+			Address: common.HexToAddress("0x01"),
+			Topics:  []common.Hash{common.HexToHash("0x02")},
+			Data:    []byte{3},
+		}},
+	}
+	tests := []struct {
+		ref   string
+		field string
+	}{
+		{`{"status":"0x1","gasUsed":"0x5208","cumulativeGasUsed":"0xa410","logs":[{"address":"0x0000000000000000000000000000000000000001","topics":["0x0000000000000000000000000000000000000000000000000000000000000002"],"data":"0x03"}]}`, ""},
+		{`{"status":null,"gasUsed":"0x5208","cumulativeGasUsed":"0xa410","logs":[{"address":"0x0000000000000000000000000000000000000001","topics":["0x0000000000000000000000000000000000000000000000000000000000000002"],"data":"0x03"}]}`, ""},
+		{`{"status":"0x0","gasUsed":"0x5208","cumulativeGasUsed":"0xa410","logs":[]}`, "status"},
+		{`{"status":"0x1","gasUsed":"0x5209","cumulativeGasUsed":"0xa410","logs":[]}`, "gasUsed"},
+		{`{"status":"0x1","gasUsed":"0x5208","cumulativeGasUsed":"0xa411","logs":[]}`, "cumulativeGasUsed"},
+		{`{"status":"0x1","gasUsed":"0x5208","cumulativeGasUsed":"0xa410","logs":[]}`, "logs"},
+		{`{"status":"0x1","gasUsed":"0x5208","cumulativeGasUsed":"0xa410","logs":[{"address":"0x0000000000000000000000000000000000000001","topics":["0x0000000000000000000000000000000000000000000000000000000000000002"],"data":"0x04"}]}`, "logs[0]"},
+	}
+	for i, test := range tests {
+		var ref refReceipt
+		if err := json.Unmarshal([]byte(test.ref), &ref); err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if field, _, _ := diffReceipt(local, &ref); field != test.field {
+			t.Errorf("test %d: diverging field mismatch: have %q, want %q", i, field, test.field)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/webchain-network/webchaind/core"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var emissionCommand = cli.Command{
+	Action:    emission,
+	Name:      "emission",
+	Aliases:   []string{"supply"},
+	Usage:     "Report circulating supply, emission and reward eras",
+	ArgsUsage: "[blockNum]",
+	Description: `
+	Computes the circulating supply and cumulative emission at the given block
+	(default: current head) from the genesis allocation and the configured reward
+	schedule, and lists the current and upcoming reward-era boundaries as JSON.
+	Uncle rewards depend on the uncles actually mined; they are only included
+	with the --uncles flag, which reads every block body.
+			`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "uncles",
+			Usage: "Count uncle rewards (slow)",
+		},
+		cli.IntFlag{
+			Name:  "eras",
+			Usage: "Number of upcoming reward eras to list",
+			Value: 5,
+		},
+	},
+}
+
+func emission(ctx *cli.Context) error {
+	bc, chainDb := MakeChain(ctx)
+	defer chainDb.Close()
+
+	num := bc.CurrentBlock().Number()
+	if ctx.NArg() > 0 {
+		n, err := strconv.ParseUint(ctx.Args().First(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%v: invalid block number: %v", ErrInvalidFlag, err)
+		}
+		if bc.GetHeaderByNumber(n) == nil {
+			return fmt.Errorf("block #%d not found", n)
+		}
+		num = new(big.Int).SetUint64(n)
+	}
+
+	e, err := core.GetEmission(bc, num, ctx.Bool("uncles"), ctx.Int("eras"))
+	if err != nil {
+		return fmt.Errorf("could not compute emission: %v", err)
+	}
+	out, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
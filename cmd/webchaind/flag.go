@@ -23,6 +23,7 @@ import (
 	"io/ioutil"
 	"log"
 	"math/big"
+	"net"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -37,11 +38,14 @@ import (
 	"github.com/webchain-network/webchaind/core"
 	"github.com/webchain-network/webchaind/core/state"
 	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm/evmc"
 	"github.com/webchain-network/webchaind/crypto"
 	"github.com/webchain-network/webchaind/eth"
 	"github.com/webchain-network/webchaind/eth/downloader"
 	"github.com/webchain-network/webchaind/ethdb"
 	"github.com/webchain-network/webchaind/event"
+	"github.com/webchain-network/webchaind/faucet"
+	"github.com/webchain-network/webchaind/forkcheck"
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
 	"github.com/webchain-network/webchaind/miner"
@@ -49,6 +53,7 @@ import (
 	"github.com/webchain-network/webchaind/p2p/discover"
 	"github.com/webchain-network/webchaind/p2p/nat"
 	"github.com/webchain-network/webchaind/pow"
+	"github.com/webchain-network/webchaind/rpc"
 	"github.com/webchain-network/webchaind/whisper"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -349,6 +354,16 @@ func MakeHTTPRpcHost(ctx *cli.Context) string {
 	return ctx.GlobalString(aliasableName(RPCListenAddrFlag.Name, ctx))
 }
 
+// MakeWSSubscriptionPolicy parses the handling of slow WS-RPC subscribers from the
+// command line flags, aborting on an unknown policy.
+func MakeWSSubscriptionPolicy(ctx *cli.Context) rpc.SubscriptionPolicy {
+	policy, err := rpc.ParseSubscriptionPolicy(ctx.GlobalString(aliasableName(WSSubscriptionPolicyFlag.Name, ctx)))
+	if err != nil {
+		glog.Fatalf("--%s: %v", WSSubscriptionPolicyFlag.Name, err)
+	}
+	return policy
+}
+
 // MakeWSRpcHost creates the WebSocket RPC listener interface string from the set
 // command line flags, returning empty if the HTTP endpoint is disabled.
 func MakeWSRpcHost(ctx *cli.Context) string {
@@ -479,6 +494,28 @@ func MakeSystemNode(version string, ctx *cli.Context) *node.Node {
 		}
 		miner.HeaderExtra = []byte(s)
 	}
+	if path := ctx.GlobalString(aliasableName(EVMCFlag.Name, ctx)); path != "" {
+		v, err := evmc.Load(path)
+		if err != nil {
+			log.Fatalf("Option %q: %v", EVMCFlag.Name, err)
+		}
+		core.SetExternalVM(v.NewVm)
+		glog.V(logger.Info).Infof("Running contracts in EVMC VM %s %s", v.Name(), v.Version())
+	}
+	if path := ctx.GlobalString(aliasableName(EWASMFlag.Name, ctx)); path != "" {
+		if id := mustMakeChainIdentity(ctx); core.ChainIdentitiesMain[id] || core.ChainIdentitiesMorden[id] {
+			log.Fatalf("Option %q: WebAssembly contracts are only allowed on private networks", EWASMFlag.Name)
+		}
+		v, err := evmc.LoadEWASM(path)
+		if err != nil {
+			log.Fatalf("Option %q: %v", EWASMFlag.Name, err)
+		}
+		core.SetWasmVM(v.NewVm)
+		glog.V(logger.Warn).Warnf("Running WebAssembly contracts in EVMC VM %s %s (experimental)", v.Name(), v.Version())
+	}
+	if ctx.GlobalBool(aliasableName(MetricsOpcodesFlag.Name, ctx)) {
+		core.SetOpMetrics(true)
+	}
 
 	// Makes sufficient configuration from JSON file or DB pending flags.
 	// Delegates flag usage.
@@ -503,10 +540,44 @@ func MakeSystemNode(version string, ctx *cli.Context) *node.Node {
 		glog.Fatalf("%v: failed to register the Ethereum service: %v", ErrStackFail, err)
 	}
 	if shhEnable {
-		if err := stack.Register(func(*node.ServiceContext) (node.Service, error) { return whisper.New(), nil }); err != nil {
+		minPoW := ctx.GlobalFloat64(aliasableName(WhisperMinPoWFlag.Name, ctx))
+		if err := stack.Register(func(*node.ServiceContext) (node.Service, error) {
+			shh := whisper.New()
+			if err := shh.SetMinimumPoW(minPoW); err != nil {
+				return nil, err
+			}
+			return shh, nil
+		}); err != nil {
 			glog.Fatalf("%v: failed to register the Whisper service: %v", ErrStackFail, err)
 		}
 	}
+	if ctx.GlobalBool(FaucetEnabledFlag.Name) {
+		if core.ChainIdentitiesMain[mustMakeChainIdentity(ctx)] {
+			glog.Fatalf("%v: the faucet cannot be enabled on mainnet", ErrStackFail)
+		}
+		faucetConf := mustMakeFaucetConf(ctx, ethConf)
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			var ethereum *eth.Ethereum
+			if err := ctx.Service(&ethereum); err != nil {
+				return nil, err
+			}
+			return faucet.New(ethereum, faucetConf)
+		}); err != nil {
+			glog.Fatalf("%v: failed to register the faucet service: %v", ErrStackFail, err)
+		}
+	}
+	if ctx.GlobalString(ForkCheckURLFlag.Name) != "" {
+		forkCheckConf := mustMakeForkCheckConf(ctx, version)
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			var ethereum *eth.Ethereum
+			if err := ctx.Service(&ethereum); err != nil {
+				return nil, err
+			}
+			return forkcheck.New(ethereum, forkCheckConf)
+		}); err != nil {
+			glog.Fatalf("%v: failed to register the fork check service: %v", ErrStackFail, err)
+		}
+	}
 
 	// If --mlog enabled, configure and create mlog dir and file
 	if ctx.GlobalString(MLogFlag.Name) != "off" {
@@ -534,24 +605,61 @@ func shouldAttemptDirMigration(ctx *cli.Context) bool {
 func mustMakeStackConf(ctx *cli.Context, name string, config *core.SufficientChainConfig) (stackConf *node.Config, shhEnable bool) {
 	// Configure the node's service container
 	stackConf = &node.Config{
-		DataDir:         MustMakeChainDataDir(ctx),
-		PrivateKey:      MakeNodeKey(ctx),
-		Name:            name,
-		NoDiscovery:     ctx.GlobalBool(aliasableName(NoDiscoverFlag.Name, ctx)),
-		BootstrapNodes:  config.ParsedBootstrap,
-		ListenAddr:      MakeListenAddress(ctx),
-		NAT:             MakeNAT(ctx),
-		MaxPeers:        ctx.GlobalInt(aliasableName(MaxPeersFlag.Name, ctx)),
-		MaxPendingPeers: ctx.GlobalInt(aliasableName(MaxPendingPeersFlag.Name, ctx)),
-		IPCPath:         MakeIPCPath(ctx),
-		HTTPHost:        MakeHTTPRpcHost(ctx),
-		HTTPPort:        ctx.GlobalInt(aliasableName(RPCPortFlag.Name, ctx)),
-		HTTPCors:        ctx.GlobalString(aliasableName(RPCCORSDomainFlag.Name, ctx)),
-		HTTPModules:     MakeRPCModules(ctx.GlobalString(aliasableName(RPCApiFlag.Name, ctx))),
-		WSHost:          MakeWSRpcHost(ctx),
-		WSPort:          ctx.GlobalInt(aliasableName(WSPortFlag.Name, ctx)),
-		WSOrigins:       ctx.GlobalString(aliasableName(WSAllowedOriginsFlag.Name, ctx)),
-		WSModules:       MakeRPCModules(ctx.GlobalString(aliasableName(WSApiFlag.Name, ctx))),
+		DataDir:           MustMakeChainDataDir(ctx),
+		PrivateKey:        MakeNodeKey(ctx),
+		Name:              name,
+		NoDiscovery:       ctx.GlobalBool(aliasableName(NoDiscoverFlag.Name, ctx)),
+		BootstrapNodes:    config.ParsedBootstrap,
+		ListenAddr:        MakeListenAddress(ctx),
+		NAT:               MakeNAT(ctx),
+		MaxPeers:          ctx.GlobalInt(aliasableName(MaxPeersFlag.Name, ctx)),
+		MaxPendingPeers:   ctx.GlobalInt(aliasableName(MaxPendingPeersFlag.Name, ctx)),
+		DialRatio:         ctx.GlobalInt(aliasableName(DialRatioFlag.Name, ctx)),
+		DialHistoryExpiry: ctx.GlobalDuration(aliasableName(DialHistoryExpiryFlag.Name, ctx)),
+		IPCPath:           MakeIPCPath(ctx),
+		HTTPHost:          MakeHTTPRpcHost(ctx),
+		HTTPPort:          ctx.GlobalInt(aliasableName(RPCPortFlag.Name, ctx)),
+		HTTPCors:          ctx.GlobalString(aliasableName(RPCCORSDomainFlag.Name, ctx)),
+		HTTPModules:       MakeRPCModules(ctx.GlobalString(aliasableName(RPCApiFlag.Name, ctx))),
+		HTTPTimeouts: rpc.HTTPTimeouts{
+			ReadTimeout:    ctx.GlobalDuration(aliasableName(RPCReadTimeoutFlag.Name, ctx)),
+			WriteTimeout:   ctx.GlobalDuration(aliasableName(RPCWriteTimeoutFlag.Name, ctx)),
+			IdleTimeout:    ctx.GlobalDuration(aliasableName(RPCIdleTimeoutFlag.Name, ctx)),
+			MaxHeaderBytes: ctx.GlobalInt(aliasableName(RPCMaxHeaderBytesFlag.Name, ctx)),
+		},
+		WSHost:    MakeWSRpcHost(ctx),
+		WSPort:    ctx.GlobalInt(aliasableName(WSPortFlag.Name, ctx)),
+		WSOrigins: ctx.GlobalString(aliasableName(WSAllowedOriginsFlag.Name, ctx)),
+		WSModules: MakeRPCModules(ctx.GlobalString(aliasableName(WSApiFlag.Name, ctx))),
+
+		WSSubscriptionBuffer: ctx.GlobalInt(aliasableName(WSSubscriptionBufferFlag.Name, ctx)),
+		WSSubscriptionPolicy: MakeWSSubscriptionPolicy(ctx),
+		WSMessageLimits: rpc.MessageLimits{
+			Request:  ctx.GlobalInt(aliasableName(WSMaxRequestFlag.Name, ctx)),
+			Response: ctx.GlobalInt(aliasableName(WSMaxResponseFlag.Name, ctx)),
+		},
+
+		IPCMessageLimits: rpc.MessageLimits{
+			Request:  ctx.GlobalInt(aliasableName(IPCMaxRequestFlag.Name, ctx)),
+			Response: ctx.GlobalInt(aliasableName(IPCMaxResponseFlag.Name, ctx)),
+		},
+
+		RPCConcurrencyQueue:   ctx.GlobalInt(aliasableName(RPCConcurrencyQueueFlag.Name, ctx)),
+		RPCConcurrencyTimeout: ctx.GlobalDuration(aliasableName(RPCConcurrencyTimeoutFlag.Name, ctx)),
+
+		InsecureUnlockAllowed: ctx.GlobalBool(aliasableName(AllowInsecureUnlockFlag.Name, ctx)),
+	}
+	limits, err := rpc.ParseConcurrencyLimits(ctx.GlobalString(aliasableName(RPCConcurrencyFlag.Name, ctx)))
+	if err != nil {
+		log.Fatalf("invalid %s flag: %v", aliasableName(RPCConcurrencyFlag.Name, ctx), err)
+	}
+	stackConf.RPCConcurrencyLimits = limits
+	if path := ctx.GlobalString(aliasableName(RPCAPIKeysFlag.Name, ctx)); path != "" {
+		keys, err := rpc.LoadAPIKeys(path)
+		if err != nil {
+			log.Fatalf("invalid %s flag: %v", aliasableName(RPCAPIKeysFlag.Name, ctx), err)
+		}
+		stackConf.RPCAPIKeys = keys
 	}
 
 	// Configure the Whisper service
@@ -582,9 +690,18 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 	accman := MakeAccountManager(ctx)
 	passwords := MakePasswordList(ctx)
 
+	// Unlocked accounts can be spent by anyone reaching an exposed RPC interface.
+	rpcConf := &node.Config{
+		HTTPHost:              MakeHTTPRpcHost(ctx),
+		WSHost:                MakeWSRpcHost(ctx),
+		InsecureUnlockAllowed: ctx.GlobalBool(aliasableName(AllowInsecureUnlockFlag.Name, ctx)),
+	}
 	accounts := strings.Split(ctx.GlobalString(aliasableName(UnlockedAccountFlag.Name, ctx)), ",")
 	for i, account := range accounts {
 		if trimmed := strings.TrimSpace(account); trimmed != "" {
+			if !rpcConf.AccountUnlockAllowed() {
+				glog.Fatalf("Account unlock with HTTP/WS access on a public interface is forbidden, use --%v", AllowInsecureUnlockFlag.Name)
+			}
 			unlockAccount(ctx, accman, trimmed, i, passwords)
 		}
 	}
@@ -593,9 +710,15 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 		ChainConfig:             sconf.ChainConfig,
 		Genesis:                 sconf.Genesis,
 		UseAddrTxIndex:          ctx.GlobalBool(aliasableName(AddrTxIndexFlag.Name, ctx)),
+		UseTokenIndex:           ctx.GlobalBool(aliasableName(TokenIndexFlag.Name, ctx)),
 		BlockChainVersion:       ctx.GlobalInt(aliasableName(BlockchainVersionFlag.Name, ctx)),
-		DatabaseCache:           ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx)),
+		DatabaseCache:           cacheAllowance(ctx, CacheDatabaseFlag),
 		DatabaseHandles:         MakeDatabaseHandles(),
+		AncientDir:              MakeAncientDir(ctx),
+		TrieCleanCache:          cacheAllowance(ctx, CacheTrieFlag),
+		TrieDirtyCache:          cacheAllowance(ctx, CacheTrieDirtyFlag),
+		SnapshotCache:           cacheAllowance(ctx, CacheSnapshotFlag),
+		StatePrefetchWorkers:    ctx.GlobalInt(aliasableName(CachePrefetchFlag.Name, ctx)),
 		NetworkId:               sconf.Network,
 		MaxPeers:                ctx.GlobalInt(aliasableName(MaxPeersFlag.Name, ctx)),
 		AccountManager:          accman,
@@ -604,6 +727,7 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 		NatSpec:                 ctx.GlobalBool(aliasableName(NatspecEnabledFlag.Name, ctx)),
 		DocRoot:                 ctx.GlobalString(aliasableName(DocRootFlag.Name, ctx)),
 		GasPrice:                new(big.Int),
+		TxPoolPriceLimit:        new(big.Int),
 		GpoMinGasPrice:          new(big.Int),
 		GpoMaxGasPrice:          new(big.Int),
 		GpoFullBlockRatio:       ctx.GlobalInt(aliasableName(GpoFullBlockRatioFlag.Name, ctx)),
@@ -611,6 +735,58 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 		GpobaseStepUp:           ctx.GlobalInt(aliasableName(GpobaseStepUpFlag.Name, ctx)),
 		GpobaseCorrectionFactor: ctx.GlobalInt(aliasableName(GpobaseCorrectionFactorFlag.Name, ctx)),
 		SolcPath:                ctx.GlobalString(aliasableName(SolcPathFlag.Name, ctx)),
+		NewBlockExec:            ctx.GlobalString(aliasableName(NewBlockExecFlag.Name, ctx)),
+		FilterTimeout:           ctx.GlobalDuration(aliasableName(FilterTimeoutFlag.Name, ctx)),
+		FilterPersist:           ctx.GlobalBool(aliasableName(FilterPersistFlag.Name, ctx)),
+		StrictForkID:            ctx.GlobalBool(aliasableName(ProtocolStrictForksFlag.Name, ctx)),
+		TxPropagation: eth.TxPropagationPolicy{
+			Fraction: ctx.GlobalFloat64(aliasableName(TxPoolBroadcastFractionFlag.Name, ctx)),
+			Interval: ctx.GlobalDuration(aliasableName(TxPoolBroadcastIntervalFlag.Name, ctx)),
+			Private:  ctx.GlobalBool(aliasableName(TxPoolPrivateFlag.Name, ctx)),
+		},
+	}
+	switch policy := ctx.GlobalString(aliasableName(MinerEmptyBlocksFlag.Name, ctx)); policy {
+	case "seal":
+	case "wait":
+		ethConf.MinerEmptyBlocks = miner.EmptyBlockPolicy{
+			Wait:     true,
+			Deadline: ctx.GlobalDuration(aliasableName(MinerDeadlineFlag.Name, ctx)),
+		}
+	default:
+		log.Fatalf("%s must be 'seal' or 'wait', got %q", aliasableName(MinerEmptyBlocksFlag.Name, ctx), policy)
+	}
+	if n := ctx.GlobalInt(aliasableName(SideBlocksRetainFlag.Name, ctx)); n >= 0 {
+		ethConf.SideBlockRetention = uint64(n)
+	} else {
+		log.Fatalf("%s must not be negative, got %d", aliasableName(SideBlocksRetainFlag.Name, ctx), n)
+	}
+	if n := ctx.GlobalInt(aliasableName(TxPoolNonceGapFlag.Name, ctx)); n >= 0 {
+		ethConf.TxPoolMaxNonceGap = uint64(n)
+	} else {
+		log.Fatalf("%s must not be negative, got %d", aliasableName(TxPoolNonceGapFlag.Name, ctx), n)
+	}
+	for _, addr := range strings.Split(ctx.GlobalString(aliasableName(RPCDenyContractsFlag.Name, ctx)), ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr) {
+			log.Fatalf("Option %q: invalid address %q", RPCDenyContractsFlag.Name, addr)
+		}
+		ethConf.CallDenyList = append(ethConf.CallDenyList, common.HexToAddress(addr))
+	}
+	if v := ctx.GlobalInt(aliasableName(ProtocolMinVersionFlag.Name, ctx)); v != 0 {
+		supported := false
+		for _, version := range eth.ProtocolVersions {
+			supported = supported || uint(v) == version
+		}
+		if !supported {
+			log.Fatalf("%s must be one of the supported protocol versions %v, got %d", aliasableName(ProtocolMinVersionFlag.Name, ctx), eth.ProtocolVersions, v)
+		}
+		ethConf.MinProtocolVersion = uint(v)
+	}
+	ethConf.CallTimeout = ctx.GlobalDuration(aliasableName(RPCCallTimeoutFlag.Name, ctx))
+	if f := ethConf.TxPropagation.Fraction; f <= 0 || f > 1 {
+		log.Fatalf("%s must be within (0, 1], got %v", aliasableName(TxPoolBroadcastFractionFlag.Name, ctx), f)
 	}
 
 	if ctx.GlobalBool(aliasableName(FastSyncFlag.Name, ctx)) {
@@ -619,10 +795,22 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 	if ctx.GlobalBool(aliasableName(SlowSyncFlag.Name, ctx)) {
 		ethConf.SyncMode = downloader.ForceFullSync
 	}
+	if !ctx.GlobalBool(aliasableName(NoCheckpointFlag.Name, ctx)) {
+		ethConf.Checkpoint = sconf.ChainConfig.Checkpoint
+	}
+	if p := ctx.GlobalString(aliasableName(SignAuditLogFlag.Name, ctx)); p != "" {
+		ethConf.SignAuditLog = common.EnsurePathAbsoluteOrRelativeTo(MustMakeChainDataDir(ctx), p)
+	}
+	if p := ctx.GlobalString(aliasableName(TxPoolJournalFlag.Name, ctx)); p != "" {
+		ethConf.TxPoolJournal = common.EnsurePathAbsoluteOrRelativeTo(MustMakeChainDataDir(ctx), p)
+	}
 
 	if _, ok := ethConf.GasPrice.SetString(ctx.GlobalString(aliasableName(GasPriceFlag.Name, ctx)), 0); !ok {
 		log.Fatalf("malformed %s flag value %q", aliasableName(GasPriceFlag.Name, ctx), ctx.GlobalString(aliasableName(GasPriceFlag.Name, ctx)))
 	}
+	if _, ok := ethConf.TxPoolPriceLimit.SetString(ctx.GlobalString(aliasableName(TxPoolPriceLimitFlag.Name, ctx)), 0); !ok {
+		log.Fatalf("malformed %s flag value %q", aliasableName(TxPoolPriceLimitFlag.Name, ctx), ctx.GlobalString(aliasableName(TxPoolPriceLimitFlag.Name, ctx)))
+	}
 	if _, ok := ethConf.GpoMinGasPrice.SetString(ctx.GlobalString(aliasableName(GpoMinGasPriceFlag.Name, ctx)), 0); !ok {
 		log.Fatalf("malformed %s flag value %q", aliasableName(GpoMinGasPriceFlag.Name, ctx), ctx.GlobalString(aliasableName(GpoMinGasPriceFlag.Name, ctx)))
 	}
@@ -646,6 +834,70 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 	return ethConf
 }
 
+func mustMakeFaucetConf(ctx *cli.Context, ethConf *eth.Config) faucet.Config {
+	account, err := MakeAddress(ethConf.AccountManager, ctx.GlobalString(FaucetAccountFlag.Name))
+	if err != nil {
+		log.Fatalf("Option %q: %v", FaucetAccountFlag.Name, err)
+	}
+	conf := faucet.Config{
+		ListenAddr: ctx.GlobalString(FaucetListenAddrFlag.Name),
+		Account:    account.Address,
+		Amount:     new(big.Int),
+		GasPrice:   ethConf.GasPrice,
+		Interval:   ctx.GlobalDuration(FaucetIntervalFlag.Name),
+	}
+	if _, ok := conf.Amount.SetString(ctx.GlobalString(FaucetAmountFlag.Name), 0); !ok {
+		log.Fatalf("malformed %s flag value %q", FaucetAmountFlag.Name, ctx.GlobalString(FaucetAmountFlag.Name))
+	}
+	if secret := ctx.GlobalString(FaucetCaptchaSecretFlag.Name); secret != "" {
+		conf.Verifiers = append(conf.Verifiers, &faucet.CaptchaVerifier{URL: ctx.GlobalString(FaucetCaptchaURLFlag.Name), Secret: secret})
+	}
+	if hook := ctx.GlobalString(FaucetWebhookFlag.Name); hook != "" {
+		conf.Verifiers = append(conf.Verifiers, &faucet.WebhookVerifier{URL: hook})
+	}
+	for _, proxy := range strings.Split(ctx.GlobalString(FaucetTrustedProxiesFlag.Name), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			log.Fatalf("Option %q: %v", FaucetTrustedProxiesFlag.Name, err)
+		}
+		conf.TrustedProxies = append(conf.TrustedProxies, *network)
+	}
+	return conf
+}
+
+func mustMakeForkCheckConf(ctx *cli.Context, version string) forkcheck.Config {
+	conf := forkcheck.Config{
+		URL:      ctx.GlobalString(ForkCheckURLFlag.Name),
+		Interval: ctx.GlobalDuration(ForkCheckIntervalFlag.Name),
+		Webhook:  ctx.GlobalString(ForkCheckWebhookFlag.Name),
+		Version:  version,
+		Chain:    mustMakeChainIdentity(ctx),
+	}
+	for _, signer := range strings.Split(ctx.GlobalString(ForkCheckSignersFlag.Name), ",") {
+		if signer = strings.TrimSpace(signer); signer == "" {
+			continue
+		}
+		if !common.IsHexAddress(signer) {
+			log.Fatalf("Option %q: invalid address %q", ForkCheckSignersFlag.Name, signer)
+		}
+		conf.Signers = append(conf.Signers, common.HexToAddress(signer))
+	}
+	if len(conf.Signers) == 0 {
+		log.Fatalf("Option %q is required with %q", ForkCheckSignersFlag.Name, ForkCheckURLFlag.Name)
+	}
+	return conf
+}
+
 // mustMakeSufficientChainConfig makes a sufficent chain configuration (id, chainconfig, nodes,...)
 // based on --chain or defaults or fails hard.
 // - User must provide a full and complete config file if any is specified located at /custom/chain.json
@@ -782,6 +1034,15 @@ func logChainConfiguration(ctx *cli.Context, config *core.SufficientChainConfig)
 		glog.D(logger.Warn).Infof("State starting nonce: %s", logger.ColorGreen(sn))
 	}
 
+	if chainIsCustom {
+		for _, def := range []*core.SufficientChainConfig{core.DefaultConfigMainnet, core.DefaultConfigMorden} {
+			if shared := config.ChainConfig.SharedChainIDs(def.ChainConfig); len(shared) > 0 {
+				glog.V(logger.Warn).Warnf("Custom chain reuses EIP-155 chain id(s) %v of %s: transactions are replayable across both networks", shared, def.Name)
+				glog.D(logger.Warn).Warnf("Custom chain reuses EIP-155 chain id(s) %v of %s: transactions are replayable across both networks", shared, logger.ColorRed(def.Name))
+			}
+		}
+	}
+
 	glog.V(logger.Info).Infof("Using %d configured bootnodes", len(config.ParsedBootstrap))
 	glog.D(logger.Warn).Infof("Using %d configured bootnodes", len(config.ParsedBootstrap))
 
@@ -803,11 +1064,42 @@ func MustMakeChainConfigFromDefaults(ctx *cli.Context) *core.ChainConfig {
 	return c
 }
 
+// cacheAllowance returns the megabytes of the --cache allowance assigned by the
+// given percentage flag. It fatals if the percentages exceed 100 in total.
+func cacheAllowance(ctx *cli.Context, flag cli.IntFlag) int {
+	total := 0
+	for _, f := range []cli.IntFlag{CacheDatabaseFlag, CacheTrieFlag, CacheTrieDirtyFlag, CacheSnapshotFlag} {
+		percent := ctx.GlobalInt(aliasableName(f.Name, ctx))
+		if percent < 0 {
+			glog.Fatalf("--%s must not be negative", f.Name)
+		}
+		total += percent
+	}
+	if total > 100 {
+		glog.Fatalf("--%s, --%s, --%s and --%s add up to %d%%, must be at most 100%%",
+			CacheDatabaseFlag.Name, CacheTrieFlag.Name, CacheTrieDirtyFlag.Name, CacheSnapshotFlag.Name, total)
+	}
+	return ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx)) * ctx.GlobalInt(aliasableName(flag.Name, ctx)) / 100
+}
+
+// makeCacheConfig returns the memory allowances of the chain given by the --cache
+// flags, in the same way the node configures its chain.
+func makeCacheConfig(ctx *cli.Context) *core.CacheConfig {
+	c := &core.CacheConfig{
+		TrieCleanLimit: cacheAllowance(ctx, CacheTrieFlag) * 1024 * 1024,
+		ImportBatch:    ethdb.ImportBatchSize,
+	}
+	if dirty := cacheAllowance(ctx, CacheTrieDirtyFlag); dirty > 0 {
+		c.ImportBatch = dirty * 1024 * 1024
+	}
+	return c
+}
+
 // MakeChainDatabase open an LevelDB using the flags passed to the client and will hard crash if it fails.
 func MakeChainDatabase(ctx *cli.Context) ethdb.Database {
 	var (
 		chaindir = MustMakeChainDataDir(ctx)
-		cache    = ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx))
+		cache    = cacheAllowance(ctx, CacheDatabaseFlag)
 		handles  = MakeDatabaseHandles()
 	)
 
@@ -815,13 +1107,38 @@ func MakeChainDatabase(ctx *cli.Context) ethdb.Database {
 	if err != nil {
 		glog.Fatal("Could not open database: ", err)
 	}
-	return chainDb
+	ancientDir := MakeAncientDir(ctx)
+	if ancientDir == "" {
+		if _, frozen := core.GetFrozenBlock(chainDb); frozen {
+			glog.Fatalf("Chain data was moved to an ancient store, please set its directory (--%v)", AncientDirFlag.Name)
+		}
+		return chainDb
+	}
+	ancientDb, err := ethdb.NewLDBDatabase(ancientDir, cache, handles)
+	if err != nil {
+		glog.Fatal("Could not open ancient database: ", err)
+	}
+	return ethdb.NewAncientDatabase(chainDb, ancientDb, core.IsAncientKey)
+}
+
+// MakeAncientDir returns the absolute path of the ancient chain data database
+// set with --datadir.ancient, or "" if ancient data is kept with the chain data.
+func MakeAncientDir(ctx *cli.Context) string {
+	dir := ctx.GlobalString(aliasableName(AncientDirFlag.Name, ctx))
+	if dir == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		glog.Fatalf("cannot make absolute path for ancient data dir: %v: %v", dir, err)
+	}
+	return abs
 }
 
 func MakeIndexDatabase(ctx *cli.Context) ethdb.Database {
 	var (
 		chaindir = MustMakeChainDataDir(ctx)
-		cache    = ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx))
+		cache    = cacheAllowance(ctx, CacheDatabaseFlag)
 		handles  = MakeDatabaseHandles()
 	)
 
@@ -846,7 +1163,7 @@ func MakeChain(ctx *cli.Context) (chain *core.BlockChain, chainDb ethdb.Database
 		glog.D(logger.Warn).Warnln("Consensus: fake")
 	}
 
-	chain, err = core.NewBlockChain(chainDb, sconf.ChainConfig, pow, new(event.TypeMux))
+	chain, err = core.NewBlockChainWithCache(chainDb, sconf.ChainConfig, makeCacheConfig(ctx), pow, new(event.TypeMux))
 	if err != nil {
 		glog.Fatal("Could not start chainmanager: ", err)
 	}
@@ -5,14 +5,19 @@ import (
 	"runtime"
 
 	"strings"
+	"time"
 
 	"path/filepath"
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core"
 	"github.com/webchain-network/webchaind/eth"
+	"github.com/webchain-network/webchaind/eth/filters"
+	"github.com/webchain-network/webchaind/faucet"
+	"github.com/webchain-network/webchaind/forkcheck"
 	"github.com/webchain-network/webchaind/logger/glog"
 	"github.com/webchain-network/webchaind/rpc"
+	"github.com/webchain-network/webchaind/whisper"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -44,6 +49,10 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: DirectoryString{common.DefaultDataDir()},
 	}
+	AncientDirFlag = DirectoryFlag{
+		Name:  "datadir.ancient",
+		Usage: "Directory of the database keeping ancient chain data, e.g. on a cheaper volume (default = kept with the chain data)",
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory path for the keystore",
@@ -82,9 +91,63 @@ var (
 	}
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
-		Usage: "Megabytes of memory allocated to internal caching (min 16MB / database forced)",
+		Usage: "Megabytes of memory allocated to internal caching, divided according to the --cache.* percentages (LevelDB gets only the --cache.database share)",
 		Value: 1024,
 	}
+	CacheDatabaseFlag = cli.IntFlag{
+		Name:  "cache.database",
+		Usage: "Percentage of cache memory allowance to use for the LevelDB block cache (min 16MB)",
+		Value: 40,
+	}
+	CacheTrieFlag = cli.IntFlag{
+		Name:  "cache.trie",
+		Usage: "Percentage of cache memory allowance to use for recently read (clean) trie nodes",
+		Value: 20,
+	}
+	CacheTrieDirtyFlag = cli.IntFlag{
+		Name:  "cache.trie.dirty",
+		Usage: "Percentage of cache memory allowance to use for buffering (dirty) state and chain writes during sync",
+		Value: 25,
+	}
+	CacheSnapshotFlag = cli.IntFlag{
+		Name:  "cache.snapshot",
+		Usage: "Percentage of cache memory allowance to use for the flat state snapshot of recent blocks",
+		Value: 15,
+	}
+	CachePrefetchFlag = cli.IntFlag{
+		Name:  "cache.prefetch",
+		Usage: "Number of goroutines loading the state of upcoming transactions during block import (0 = disabled)",
+		Value: 4,
+	}
+	TxPoolJournalFlag = cli.StringFlag{
+		Name:  "txpool-journal",
+		Usage: "Save all pending and queued transactions to this file on shutdown and restore them on start (relative to the chain data directory)",
+		Value: "",
+	}
+	TxPoolPriceLimitFlag = cli.StringFlag{
+		Name:  "txpool.pricelimit",
+		Usage: "Minimum gas price of transactions accepted into the transaction pool and relayed, unless submitted locally",
+		Value: "0",
+	}
+	TxPoolNonceGapFlag = cli.IntFlag{
+		Name:  "txpool.nonce-gap",
+		Usage: "Maximum number of nonces a transaction may skip ahead of its sender's next nonce to be accepted (0 = unlimited)",
+		Value: 0,
+	}
+	TxPoolBroadcastFractionFlag = cli.Float64Flag{
+		Name:  "txpool.broadcast-fraction",
+		Usage: "Fraction of peers receiving full transactions, the other eth/65 peers only get their hashes announced (0-1)",
+		Value: 1,
+	}
+	TxPoolBroadcastIntervalFlag = cli.DurationFlag{
+		Name:  "txpool.broadcast-interval",
+		Usage: "Interval to batch transactions for before broadcasting them (0 = broadcast immediately)",
+		Value: 0,
+	}
+	TxPoolPrivateFlag = cli.BoolFlag{
+		Name:  "txpool.private",
+		Usage: "Only relay locally submitted transactions to trusted peers",
+	}
 	BlockchainVersionFlag = cli.IntFlag{
 		Name:  "blockchain-version,blockchainversion",
 		Usage: "Blockchain version (integer)",
@@ -98,6 +161,10 @@ var (
 		Name:  "slow",
 		Usage: "Force full sync, even if fast sync is in progress",
 	}
+	NoCheckpointFlag = cli.BoolFlag{
+		Name:  "no-checkpoint,nocheckpoint",
+		Usage: "Ignore the chain configuration's trusted sync checkpoint and verify the full header chain (trustless sync)",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "light-kdf,lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -110,11 +177,23 @@ var (
 		Name:  "atxi.autobuild,atxi.auto-build",
 		Usage: "Begins automatic concurrent indexes building process that runs alongside a normally running geth.",
 	}
+	TokenIndexFlag = cli.BoolFlag{
+		Name:  "token-index",
+		Usage: "Index ERC20/ERC721 token transfers of imported blocks by address, for token balances and transfer history over RPC",
+	}
+	SideBlocksRetainFlag = cli.IntFlag{
+		Name:  "sideblocks.retain",
+		Usage: "Number of blocks non-canonical blocks are kept for and listed by debug_sideBlocks (0 = not indexed, kept indefinitely)",
+	}
 	// Network Split settings
 	ETFChain = cli.BoolFlag{
 		Name:  "etf",
 		Usage: "Updates the chain rules to use the ETF hard-fork blockchain",
 	}
+	NewBlockExecFlag = cli.StringFlag{
+		Name:  "newblock.exec",
+		Usage: "Shell command run on every new chain head, with the block in WEBCHAIN_BLOCK_NUMBER, WEBCHAIN_BLOCK_HASH, WEBCHAIN_BLOCK_PARENT_HASH, WEBCHAIN_BLOCK_TIME and WEBCHAIN_BLOCK_TXS",
+	}
 	// Miner settings
 	// TODO Refactor CPU vs GPU mining flags
 	MiningEnabledFlag = cli.BoolFlag{
@@ -131,6 +210,16 @@ var (
 		Usage: "List of GPUs to use for mining (e.g. '0,1' will use the first two GPUs found)",
 		Value: "",
 	}
+	MinerEmptyBlocksFlag = cli.StringFlag{
+		Name:  "miner-empty-blocks",
+		Usage: "Policy for blocks without transactions: 'seal' them immediately or 'wait' for transactions up to the miner deadline",
+		Value: "seal",
+	}
+	MinerDeadlineFlag = cli.DurationFlag{
+		Name:  "miner-deadline",
+		Usage: "Maximum time to wait for transactions before sealing an empty block under the 'wait' policy",
+		Value: 5 * time.Second,
+	}
 	TargetGasLimitFlag = cli.StringFlag{
 		Name:  "target-gas-limit,targetgaslimit",
 		Usage: "Target gas limit sets the artificial target gas floor for the blocks to mine",
@@ -142,8 +231,8 @@ var (
 		Value: "0",
 	}
 	GasPriceFlag = cli.StringFlag{
-		Name:  "gas-price,gasprice",
-		Usage: "Minimal gas price to accept for mining a transactions",
+		Name:  "gas-price,gasprice,miner.gasprice",
+		Usage: "Minimum gas price of transactions included in mined blocks and accepted into the transaction pool",
 		Value: new(big.Int).Mul(big.NewInt(20), common.Shannon).String(),
 	}
 	ExtraDataFlag = cli.StringFlag{
@@ -161,6 +250,15 @@ var (
 		Usage: "Password file to use for non-inteactive password input",
 		Value: "",
 	}
+	AllowInsecureUnlockFlag = cli.BoolFlag{
+		Name:  "allow-insecure-unlock",
+		Usage: "Allow account unlocking while HTTP/WS RPC is exposed on a non-loopback interface",
+	}
+	SignAuditLogFlag = cli.StringFlag{
+		Name:  "sign-audit-log",
+		Usage: "Append every signing operation to this file (relative to the chain data directory)",
+		Value: "",
+	}
 	// logging and debug settings
 	NeckbeardFlag = cli.BoolFlag{
 		Name:  "neckbeard",
@@ -251,6 +349,10 @@ var (
 		Usage: "Enables metrics reporting. When the value is a path, either relative or absolute, then a log is written to the respective file.",
 		Value: "",
 	}
+	MetricsOpcodesFlag = cli.BoolFlag{
+		Name:  "metrics.opcodes",
+		Usage: "Report the executions, gas and time of every opcode in processed blocks to the metrics (vm/op/<OPCODE>)",
+	}
 	FakePoWFlag = cli.BoolFlag{
 		Name:  "fake-pow, fakepow",
 		Usage: "Disables proof-of-work verification",
@@ -281,6 +383,54 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: rpc.DefaultHTTPApis,
 	}
+	RPCReadTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc-read-timeout",
+		Usage: "Maximum duration for reading an entire HTTP-RPC request",
+		Value: rpc.DefaultHTTPTimeouts.ReadTimeout,
+	}
+	RPCWriteTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc-write-timeout",
+		Usage: "Maximum duration for writing an HTTP-RPC response",
+		Value: rpc.DefaultHTTPTimeouts.WriteTimeout,
+	}
+	RPCIdleTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc-idle-timeout",
+		Usage: "Maximum time an idle HTTP-RPC keep-alive connection is kept open",
+		Value: rpc.DefaultHTTPTimeouts.IdleTimeout,
+	}
+	RPCMaxHeaderBytesFlag = cli.IntFlag{
+		Name:  "rpc-max-header-bytes",
+		Usage: "Maximum size in bytes of HTTP-RPC request headers",
+		Value: rpc.DefaultHTTPTimeouts.MaxHeaderBytes,
+	}
+	RPCConcurrencyFlag = cli.StringFlag{
+		Name:  "rpc-concurrency",
+		Usage: "Comma separated caps on concurrently executing IPC/HTTP/WS requests per namespace or method (e.g. debug=4,eth_call=16)",
+		Value: "debug=4,eth_call=16",
+	}
+	RPCConcurrencyQueueFlag = cli.IntFlag{
+		Name:  "rpc-concurrency-queue",
+		Usage: "Number of requests per concurrency cap waiting for a free slot before further ones are rejected as busy",
+		Value: 64,
+	}
+	RPCConcurrencyTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc-concurrency-timeout",
+		Usage: "Maximum time a request waits for a free slot before it's rejected as busy",
+		Value: rpc.DefaultConcurrencyQueueTimeout,
+	}
+	RPCAPIKeysFlag = cli.StringFlag{
+		Name:  "rpc-apikeys",
+		Usage: "JSON file of API keys required from HTTP/WS clients, with the methods and daily requests they allow",
+	}
+	RPCDenyContractsFlag = cli.StringFlag{
+		Name:  "rpc-deny-contracts",
+		Usage: "Comma separated contract addresses whose code is never run by eth_call, eth_estimateGas and traces",
+	}
+	RPCCallTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc-call-timeout",
+		Usage: "Maximum time eth_call and eth_estimateGas execute for before they're aborted (0 = unlimited)",
+		Value: 5 * time.Second,
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipc-disable,ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -295,6 +445,16 @@ var (
 		Usage: "Filename for IPC socket/pipe within the datadir (explicit paths escape it)",
 		Value: DirectoryString{common.DefaultIPCSocket},
 	}
+	IPCMaxRequestFlag = cli.IntFlag{
+		Name:  "ipc-max-request",
+		Usage: "Maximum size in bytes of IPC-RPC requests, the connection is closed above it (negative for no limit)",
+		Value: rpc.DefaultMessageLimits.Request,
+	}
+	IPCMaxResponseFlag = cli.IntFlag{
+		Name:  "ipc-max-response",
+		Usage: "Maximum size in bytes of IPC-RPC responses, an error is returned instead above it (negative for no limit)",
+		Value: rpc.DefaultMessageLimits.Response,
+	}
 	WSEnabledFlag = cli.BoolFlag{
 		Name:  "ws",
 		Usage: "Enable the WS-RPC server",
@@ -319,6 +479,35 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	WSSubscriptionBufferFlag = cli.IntFlag{
+		Name:  "ws-sub-buffer",
+		Usage: "Maximum notifications queued per WS-RPC subscription",
+		Value: rpc.DefaultSubscriptionBuffer,
+	}
+	WSSubscriptionPolicyFlag = cli.StringFlag{
+		Name:  "ws-sub-policy",
+		Usage: `Handling of WS-RPC subscriptions exceeding their buffer ("disconnect" or "drop")`,
+		Value: rpc.SubscriptionDisconnect.String(),
+	}
+	WSMaxRequestFlag = cli.IntFlag{
+		Name:  "ws-max-request",
+		Usage: "Maximum size in bytes of WS-RPC requests, the connection is closed above it (negative for no limit)",
+		Value: rpc.DefaultMessageLimits.Request,
+	}
+	WSMaxResponseFlag = cli.IntFlag{
+		Name:  "ws-max-response",
+		Usage: "Maximum size in bytes of WS-RPC responses, an error is returned instead above it (negative for no limit)",
+		Value: rpc.DefaultMessageLimits.Response,
+	}
+	FilterTimeoutFlag = cli.DurationFlag{
+		Name:  "filter-timeout",
+		Usage: "Time after which a filter that isn't polled is uninstalled",
+		Value: filters.DefaultFilterTimeout,
+	}
+	FilterPersistFlag = cli.BoolFlag{
+		Name:  "filter-persist",
+		Usage: "Keep installed log filters across a restart",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement (only in combination with console/attach)",
@@ -341,6 +530,24 @@ var (
 		Usage: "Maximum number of pending connection attempts (defaults used if set to 0)",
 		Value: 0,
 	}
+	DialRatioFlag = cli.IntFlag{
+		Name:  "dial-ratio",
+		Usage: "Ratio of peer slots to those filled by dialing out, e.g. 2 dials half of the peers (defaults used if set to 0)",
+		Value: 0,
+	}
+	DialHistoryExpiryFlag = cli.DurationFlag{
+		Name:  "dial-history-expiry",
+		Usage: "Time to wait before redialing a node (defaults used if set to 0)",
+		Value: 0,
+	}
+	ProtocolMinVersionFlag = cli.IntFlag{
+		Name:  "protocol.min-version",
+		Usage: "Lowest eth protocol version peers are accepted with, refusing older clients (0 = any supported version)",
+	}
+	ProtocolStrictForksFlag = cli.BoolFlag{
+		Name:  "protocol.strict-forks",
+		Usage: "Refuse peers whose fork ID doesn't announce the next fork of the chain configuration (protocol version 64 and later)",
+	}
 	ListenPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -372,6 +579,11 @@ var (
 		Name:  "shh",
 		Usage: "Enable Whisper",
 	}
+	WhisperMinPoWFlag = cli.Float64Flag{
+		Name:  "shh-minpow",
+		Usage: "Minimum proof of work of Whisper envelopes accepted by the node",
+		Value: whisper.DefaultMinimumPoW,
+	}
 	// ATM the url is left to the user and deployment to
 	JSpathFlag = cli.StringFlag{
 		Name:  "js-path,jspath",
@@ -383,6 +595,76 @@ var (
 		Usage: "Solidity compiler command to be used",
 		Value: "solc",
 	}
+	EVMCFlag = cli.StringFlag{
+		Name:  "evmc",
+		Usage: "Shared library of an EVMC virtual machine executing the contracts (requires a build with the evmc tag)",
+	}
+	EWASMFlag = cli.StringFlag{
+		Name:  "ewasm",
+		Usage: "Shared library of an EVMC ewasm virtual machine executing the WebAssembly contracts, whose code starts with \\0asm (experimental, private networks only, requires a build with the evmc tag)",
+	}
+
+	// Faucet settings
+	FaucetEnabledFlag = cli.BoolFlag{
+		Name:  "faucet",
+		Usage: "Enable the HTTP faucet dispensing test network coins from --faucet-account (not allowed on mainnet)",
+	}
+	FaucetListenAddrFlag = cli.StringFlag{
+		Name:  "faucet-addr",
+		Usage: "Faucet HTTP server listening interface and port",
+		Value: "127.0.0.1:8548",
+	}
+	FaucetAccountFlag = cli.StringFlag{
+		Name:  "faucet-account",
+		Usage: "Account (address or keystore index) funding faucet requests; it must be unlocked",
+		Value: "0",
+	}
+	FaucetAmountFlag = cli.StringFlag{
+		Name:  "faucet-amount",
+		Usage: "Amount of wei dispensed per faucet request",
+		Value: faucet.DefaultAmount.String(),
+	}
+	FaucetIntervalFlag = cli.DurationFlag{
+		Name:  "faucet-interval",
+		Usage: "Minimum time between faucet payouts to the same address or client",
+		Value: faucet.DefaultInterval,
+	}
+	FaucetCaptchaSecretFlag = cli.StringFlag{
+		Name:  "faucet-captcha-secret",
+		Usage: "Captcha secret key; if set, faucet requests must include a valid 'captcha' response token",
+	}
+	FaucetCaptchaURLFlag = cli.StringFlag{
+		Name:  "faucet-captcha-url",
+		Usage: "Captcha siteverify endpoint (reCAPTCHA compatible)",
+		Value: faucet.DefaultCaptchaURL,
+	}
+	FaucetWebhookFlag = cli.StringFlag{
+		Name:  "faucet-webhook",
+		Usage: "URL receiving each faucet request's address and IP as JSON; a non-2xx response rejects the request",
+	}
+	FaucetTrustedProxiesFlag = cli.StringFlag{
+		Name:  "faucet-trusted-proxies",
+		Usage: "Comma separated IPs or CIDR networks of reverse proxies whose X-Forwarded-For header identifies faucet clients",
+	}
+
+	// Fork readiness check settings
+	ForkCheckURLFlag = cli.StringFlag{
+		Name:  "forkcheck.url",
+		Usage: "URL of a signed manifest of scheduled hard forks; if set, the node warns when it isn't ready for an upcoming fork",
+	}
+	ForkCheckSignersFlag = cli.StringFlag{
+		Name:  "forkcheck.signers",
+		Usage: "Comma separated addresses of the keys trusted to sign the fork manifest",
+	}
+	ForkCheckIntervalFlag = cli.DurationFlag{
+		Name:  "forkcheck.interval",
+		Usage: "Time between two fork manifest checks",
+		Value: forkcheck.DefaultInterval,
+	}
+	ForkCheckWebhookFlag = cli.StringFlag{
+		Name:  "forkcheck.webhook",
+		Usage: "URL the problems found by the fork check are posted to as JSON whenever they change",
+	}
 
 	// Gas price oracle settings
 	GpoMinGasPriceFlag = cli.StringFlag{
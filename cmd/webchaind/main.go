@@ -40,6 +40,15 @@ import (
 // as in: go build -ldflags "-X main.Version="`git describe --tags`
 var Version = "source"
 
+// Build metadata set with the linker by release builds, see the Makefile:
+// the commit built, the tags and flags it was built with, and the comma
+// separated addresses of the keys signing the release checksums.
+var (
+	Commit         = ""
+	BuildFlags     = ""
+	ReleaseSigners = ""
+)
+
 func init() {
 	rand.Seed(time.Now().UTC().UnixNano())
 	common.SetClientVersion(Version)
@@ -71,7 +80,14 @@ var versionCommand = cli.Command{
 	Usage:  "Print webchain version numbers",
 	Description: `
 	The output of this command is supposed to be machine-readable.
+	With --verify, the running binary is verified against the signed release checksums.
 			`,
+	Flags: append([]cli.Flag{
+		cli.BoolFlag{
+			Name:  "verify",
+			Usage: "Verify the running binary against the signed release checksums",
+		},
+	}, releaseVerifyFlags...),
 }
 
 var makeMlogDocCommand = cli.Command{
@@ -104,25 +120,34 @@ func makeCLIApp() (app *cli.App) {
 	app.Commands = []cli.Command{
 		importCommand,
 		exportCommand,
+		exportAnalyticsCommand,
 		dumpChainConfigCommand,
 		upgradedbCommand,
 		dumpCommand,
+		profileBlockCommand,
+		crossCheckCommand,
+		stateStatsCommand,
 		rollbackCommand,
 		recoverCommand,
 		resetCommand,
 		monitorCommand,
 		accountCommand,
 		walletCommand,
+		signTxCommand,
 		consoleCommand,
 		attachCommand,
 		javascriptCommand,
 		statusCommand,
 		apiCommand,
+		txpoolCommand,
 		gpuInfoCommand,
 		gpuBenchCommand,
 		versionCommand,
+		verifyReleaseCommand,
 		makeMlogDocCommand,
 		buildAddrTxIndexCommand,
+		migrateAncientCommand,
+		emissionCommand,
 	}
 
 	app.Flags = []cli.Flag{
@@ -132,28 +157,52 @@ func makeCLIApp() (app *cli.App) {
 		NodeNameFlag,
 		UnlockedAccountFlag,
 		PasswordFileFlag,
+		AllowInsecureUnlockFlag,
+		SignAuditLogFlag,
 		AccountsIndexFlag,
 		BootnodesFlag,
 		DataDirFlag,
+		AncientDirFlag,
 		DocRootFlag,
 		KeyStoreDirFlag,
 		ChainIdentityFlag,
 		BlockchainVersionFlag,
 		FastSyncFlag,
 		SlowSyncFlag,
+		NoCheckpointFlag,
 		AddrTxIndexFlag,
 		AddrTxIndexAutoBuildFlag,
+		TokenIndexFlag,
+		SideBlocksRetainFlag,
 		CacheFlag,
+		CacheDatabaseFlag,
+		CacheTrieFlag,
+		CacheTrieDirtyFlag,
+		CacheSnapshotFlag,
+		CachePrefetchFlag,
+		TxPoolJournalFlag,
+		TxPoolPriceLimitFlag,
+		TxPoolNonceGapFlag,
+		TxPoolBroadcastFractionFlag,
+		TxPoolBroadcastIntervalFlag,
+		TxPoolPrivateFlag,
+		NewBlockExecFlag,
 		LightKDFFlag,
 		JSpathFlag,
 		ListenPortFlag,
 		MaxPeersFlag,
 		MaxPendingPeersFlag,
+		DialRatioFlag,
+		DialHistoryExpiryFlag,
+		ProtocolMinVersionFlag,
+		ProtocolStrictForksFlag,
 		EtherbaseFlag,
 		GasPriceFlag,
 		MinerThreadsFlag,
 		MiningEnabledFlag,
 		MiningGPUFlag,
+		MinerEmptyBlocksFlag,
+		MinerDeadlineFlag,
 		TargetGasLimitFlag,
 		NATFlag,
 		NatspecEnabledFlag,
@@ -164,17 +213,36 @@ func makeCLIApp() (app *cli.App) {
 		RPCListenAddrFlag,
 		RPCPortFlag,
 		RPCApiFlag,
+		RPCReadTimeoutFlag,
+		RPCWriteTimeoutFlag,
+		RPCIdleTimeoutFlag,
+		RPCMaxHeaderBytesFlag,
+		RPCConcurrencyFlag,
+		RPCConcurrencyQueueFlag,
+		RPCConcurrencyTimeoutFlag,
+		RPCAPIKeysFlag,
+		RPCDenyContractsFlag,
+		RPCCallTimeoutFlag,
 		WSEnabledFlag,
 		WSListenAddrFlag,
 		WSPortFlag,
 		WSApiFlag,
 		WSAllowedOriginsFlag,
+		WSSubscriptionBufferFlag,
+		WSSubscriptionPolicyFlag,
+		WSMaxRequestFlag,
+		WSMaxResponseFlag,
+		FilterTimeoutFlag,
+		FilterPersistFlag,
 		IPCDisabledFlag,
 		IPCApiFlag,
 		IPCPathFlag,
+		IPCMaxRequestFlag,
+		IPCMaxResponseFlag,
 		ExecFlag,
 		PreloadJSFlag,
 		WhisperEnabledFlag,
+		WhisperMinPoWFlag,
 		DevModeFlag,
 		TestNetFlag,
 		NetworkIdFlag,
@@ -197,8 +265,24 @@ func makeCLIApp() (app *cli.App) {
 		MLogComponentsFlag,
 		BacktraceAtFlag,
 		MetricsFlag,
+		MetricsOpcodesFlag,
 		FakePoWFlag,
 		SolcPathFlag,
+		EVMCFlag,
+		EWASMFlag,
+		FaucetEnabledFlag,
+		FaucetListenAddrFlag,
+		FaucetAccountFlag,
+		FaucetAmountFlag,
+		FaucetIntervalFlag,
+		FaucetCaptchaSecretFlag,
+		FaucetCaptchaURLFlag,
+		FaucetWebhookFlag,
+		FaucetTrustedProxiesFlag,
+		ForkCheckURLFlag,
+		ForkCheckSignersFlag,
+		ForkCheckIntervalFlag,
+		ForkCheckWebhookFlag,
 		GpoMinGasPriceFlag,
 		GpoMaxGasPriceFlag,
 		GpoFullBlockRatioFlag,
@@ -0,0 +1,132 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/release"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// releaseVerifyFlags select the signed checksums binaries are verified against.
+var releaseVerifyFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "checksums",
+		Usage: "Path or URL of the release checksum file (sha256sum format)",
+	},
+	cli.StringFlag{
+		Name:  "signature",
+		Usage: "Path or URL of the checksum file signature (default: checksums location + \".sig\")",
+	},
+	cli.StringFlag{
+		Name:  "signers",
+		Usage: "Comma separated addresses trusted to sign release checksums (default: the build's release signers)",
+	},
+}
+
+var verifyReleaseCommand = cli.Command{
+	Action:    verifyRelease,
+	Name:      "verify-release",
+	Usage:     "Verify a release binary against the signed release checksums",
+	ArgsUsage: "<binary>",
+	Description: `
+	Checks that the SHA256 of the binary is listed in the release checksum file and that the
+	file is signed by a trusted release key, e.g.
+
+	$ webchaind verify-release --checksums https://example.org/v4.2.0/SHA256SUMS webchaind-linux-amd64
+		`,
+	Flags: releaseVerifyFlags,
+}
+
+// verifyRelease verifies the binary given as argument.
+func verifyRelease(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		return errors.New("expected the path of the binary to verify")
+	}
+	return verifyBinary(ctx, ctx.Args().First())
+}
+
+// verifyBinary checks the binary at path against the checksums selected by the
+// command flags.
+func verifyBinary(ctx *cli.Context, path string) error {
+	location := ctx.String("checksums")
+	if location == "" {
+		return errors.New("option \"checksums\" is required to verify a binary")
+	}
+	sigLocation := ctx.String("signature")
+	if sigLocation == "" {
+		sigLocation = location + ".sig"
+	}
+	signers, err := releaseSigners(ctx.String("signers"))
+	if err != nil {
+		return err
+	}
+	data, err := release.Fetch(location)
+	if err != nil {
+		return fmt.Errorf("reading checksums: %v", err)
+	}
+	sig, err := release.Fetch(sigLocation)
+	if err != nil {
+		return fmt.Errorf("reading checksums signature: %v", err)
+	}
+	sums, err := release.VerifyChecksums(data, sig, signers)
+	if err != nil {
+		return err
+	}
+	name, err := sums.Verify(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Verified: %s is the released %s\n", path, name)
+	return nil
+}
+
+// releaseSigners parses the trusted signer addresses, the ones embedded in the
+// build if none are given.
+func releaseSigners(list string) ([]common.Address, error) {
+	if list == "" {
+		list = ReleaseSigners
+	}
+	var signers []common.Address
+	for _, signer := range strings.Split(list, ",") {
+		if signer = strings.TrimSpace(signer); signer == "" {
+			continue
+		}
+		if !common.IsHexAddress(signer) {
+			return nil, fmt.Errorf("invalid release signer address %q", signer)
+		}
+		signers = append(signers, common.HexToAddress(signer))
+	}
+	if len(signers) == 0 {
+		return nil, errors.New("no release signers built in, option \"signers\" is required")
+	}
+	return signers, nil
+}
+
+// verifyRunningBinary checks the executable of the running process.
+func verifyRunningBinary(ctx *cli.Context) error {
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return verifyBinary(ctx, path)
+}
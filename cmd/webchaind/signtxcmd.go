@@ -0,0 +1,168 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+
+	"github.com/webchain-network/webchaind/accounts"
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var signTxCommand = cli.Command{
+	Action:    signTx,
+	Name:      "signtx",
+	Usage:     "Sign a transaction offline with a key file",
+	ArgsUsage: "<txfile>",
+	Description: `
+	Signs the unsigned transaction in <txfile> ("-" or none for stdin) with a key
+	file and prints it RLP encoded, to be broadcast from another machine with
+	eth_sendRawTransaction. It doesn't use the network or a running node, so it
+	can be run on an air-gapped machine.
+
+	The transaction is a JSON object, numbers in hex or decimal:
+
+		{"nonce": "0x0", "gasPrice": "20000000000", "gas": "21000",
+		 "to": "0x3f4e0668c20e100d7c2a27d4b177ac65b2875d26", "value": "0xde0b6b3a7640000",
+		 "data": "0x", "from": "0x7fa65f0395f5ee0bcbae969d711823ab4353beae", "chainId": "24484"}
+
+	"to" is left out to create a contract. "from" is optional and checked
+	against the key. "chainId" defaults to the chain id of the --chain network,
+	it must be given for networks without EIP-155 replay protection.
+
+	The key file password is read from --password or prompted for, e.g.
+
+	$ webchaind --password pass.txt signtx --keyfile UTC--2018-...--7fa65f03... tx.json
+		`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "keyfile",
+			Usage: "Key file to sign the transaction with",
+		},
+	},
+}
+
+// unsignedTx is the JSON of a transaction given to signtx.
+type unsignedTx struct {
+	Nonce    *rpc.HexNumber  `json:"nonce"`
+	GasPrice *rpc.HexNumber  `json:"gasPrice"`
+	Gas      *rpc.HexNumber  `json:"gas"`
+	To       *common.Address `json:"to"`
+	Value    *rpc.HexNumber  `json:"value"`
+	Data     string          `json:"data"`
+	From     *common.Address `json:"from"`
+	ChainId  *rpc.HexNumber  `json:"chainId"`
+}
+
+// transaction checks that the required fields are given and returns the
+// transaction they describe.
+func (args *unsignedTx) transaction() (*types.Transaction, error) {
+	if args.Nonce == nil {
+		return nil, errors.New("transaction has no nonce")
+	}
+	if args.GasPrice == nil {
+		return nil, errors.New("transaction has no gas price")
+	}
+	if args.Gas == nil {
+		return nil, errors.New("transaction has no gas limit")
+	}
+	value := new(big.Int)
+	if args.Value != nil {
+		value = args.Value.BigInt()
+	}
+	data := common.FromHex(args.Data)
+	if args.To == nil {
+		return types.NewContractCreation(args.Nonce.Uint64(), value, args.Gas.BigInt(), args.GasPrice.BigInt(), data), nil
+	}
+	return types.NewTransaction(args.Nonce.Uint64(), *args.To, value, args.Gas.BigInt(), args.GasPrice.BigInt(), data), nil
+}
+
+// sign signs the transaction with key for the given chain, which is the
+// default when the transaction has no chain id of its own.
+func (args *unsignedTx) sign(chainId *big.Int, key *ecdsa.PrivateKey) (*types.Transaction, error) {
+	tx, err := args.transaction()
+	if err != nil {
+		return nil, err
+	}
+	if args.ChainId != nil {
+		chainId = args.ChainId.BigInt()
+	}
+	if chainId == nil || chainId.Sign() <= 0 {
+		return nil, errors.New("transaction has no chain id and the network has no EIP-155 chain id")
+	}
+	if from := crypto.PubkeyToAddress(key.PublicKey); args.From != nil && *args.From != from {
+		return nil, fmt.Errorf("key file is of %s, not the sender %s", from.Hex(), args.From.Hex())
+	}
+	return types.NewChainIdSigner(chainId).SignECDSA(tx, key)
+}
+
+func signTx(ctx *cli.Context) error {
+	keyfile := ctx.String("keyfile")
+	if keyfile == "" {
+		return errors.New("option \"keyfile\" is required")
+	}
+	var (
+		input []byte
+		err   error
+	)
+	if path := ctx.Args().First(); path == "" || path == "-" {
+		input, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		input, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("reading transaction: %v", err)
+	}
+	var args unsignedTx
+	if err := json.Unmarshal(input, &args); err != nil {
+		return fmt.Errorf("invalid transaction: %v", err)
+	}
+	// Check the fields before asking for the password
+	if _, err := args.transaction(); err != nil {
+		return err
+	}
+
+	keyJSON, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		return fmt.Errorf("reading key file: %v", err)
+	}
+	passphrase := getPassPhrase("Key file password", false, 0, MakePasswordList(ctx))
+	key, err := accounts.Web3PrivateKey(keyJSON, passphrase)
+	if err != nil {
+		return fmt.Errorf("decrypting key file: %v", err)
+	}
+	signed, err := args.sign(MustMakeChainConfigFromDefaults(ctx).GetChainID(nil), key)
+	if err != nil {
+		return err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	fmt.Println(common.ToHex(raw))
+	return nil
+}
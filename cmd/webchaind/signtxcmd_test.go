@@ -0,0 +1,108 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+)
+
+func TestSignTxRoundTrip(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	input := `{"nonce": "0x5", "gasPrice": "20000000000", "gas": "21000",
+		"to": "0x3f4e0668c20e100d7c2a27d4b177ac65b2875d26", "value": "0xde0b6b3a7640000",
+		"data": "0x01ff", "from": "` + from.Hex() + `"}`
+	var args unsignedTx
+	if err := json.Unmarshal([]byte(input), &args); err != nil {
+		t.Fatal(err)
+	}
+	chainId := big.NewInt(24484)
+	signed, err := args.sign(chainId, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Decode the transaction as a node receiving it would
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		t.Fatal(err)
+	}
+	sender, err := types.Sender(types.NewChainIdSigner(chainId), tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != from {
+		t.Errorf("sender mismatch: have %x, want %x", sender, from)
+	}
+	if tx.ChainId().Cmp(chainId) != 0 {
+		t.Errorf("chain id mismatch: have %v, want %v", tx.ChainId(), chainId)
+	}
+	if tx.Nonce() != 5 || tx.Gas().Cmp(big.NewInt(21000)) != 0 || tx.GasPrice().Cmp(big.NewInt(20000000000)) != 0 {
+		t.Errorf("nonce/gas/price mismatch: have %d/%v/%v", tx.Nonce(), tx.Gas(), tx.GasPrice())
+	}
+	if want := common.HexToAddress("0x3f4e0668c20e100d7c2a27d4b177ac65b2875d26"); tx.To() == nil || *tx.To() != want {
+		t.Errorf("recipient mismatch: have %v, want %x", tx.To(), want)
+	}
+	if tx.Value().Cmp(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)) != 0 {
+		t.Errorf("value mismatch: have %v", tx.Value())
+	}
+	if data := common.ToHex(tx.Data()); data != "0x01ff" {
+		t.Errorf("data mismatch: have %s, want 0x01ff", data)
+	}
+}
+
+func TestSignTxChainId(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	tests := []struct {
+		input   string
+		chainId *big.Int // of the network
+		ok      bool
+	}{
+		{`{"nonce": "0", "gasPrice": "1", "gas": "21000"}`, big.NewInt(24484), true},
+		{`{"nonce": "0", "gasPrice": "1", "gas": "21000"}`, new(big.Int), false},
+		{`{"nonce": "0", "gasPrice": "1", "gas": "21000"}`, nil, false},
+		{`{"nonce": "0", "gasPrice": "1", "gas": "21000", "chainId": "61"}`, new(big.Int), true},
+		{`{"nonce": "0", "gasPrice": "1", "gas": "21000", "chainId": "0"}`, big.NewInt(24484), false},
+		{`{"nonce": "0", "gasPrice": "1", "gas": "21000", "from": "` + crypto.PubkeyToAddress(other.PublicKey).Hex() + `"}`, big.NewInt(24484), false},
+	}
+	for i, tt := range tests {
+		var args unsignedTx
+		if err := json.Unmarshal([]byte(tt.input), &args); err != nil {
+			t.Fatal(err)
+		}
+		_, err := args.sign(tt.chainId, key)
+		if tt.ok && err != nil {
+			t.Errorf("test %d: signing failed: %v", i, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("test %d: signed without error", i)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/webchain-network/webchaind/common"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var txpoolCommand = cli.Command{
+	Name:  "txpool",
+	Usage: "Inspect and manage the transaction pool of a running node",
+	Description: `
+	The txpool commands talk to a running webchaind instance over IPC. Drop and
+	replace use the admin API of the node.
+
+	Examples:
+
+		$ webchaind txpool list
+		$ webchaind txpool inspect 0x396599f365093186742c17aab158bf515e978bc7
+		$ webchaind txpool drop 0x5e0d…
+		$ webchaind txpool replace 0x5e0d… 0xf86b…
+		`,
+	Subcommands: []cli.Command{
+		{
+			Action:    txpoolList,
+			Name:      "list",
+			Usage:     "Print a summary line of every pending and queued transaction",
+			ArgsUsage: "[address]",
+		},
+		{
+			Action:    txpoolInspect,
+			Name:      "inspect",
+			Usage:     "Print the pending and queued transactions in full",
+			ArgsUsage: "[address]",
+		},
+		{
+			Action:    txpoolDrop,
+			Name:      "drop",
+			Usage:     "Remove a transaction from the pool",
+			ArgsUsage: "<txHash>",
+		},
+		{
+			Action:    txpoolReplace,
+			Name:      "replace",
+			Usage:     "Replace a pooled transaction with a signed raw transaction of the same sender and nonce",
+			ArgsUsage: "<txHash> <rawTx>",
+		},
+	},
+}
+
+// txpoolCall calls an RPC method of the node with the given parameters.
+func txpoolCall(ctx *cli.Context, method string, params ...interface{}) (interface{}, error) {
+	client, err := getClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	return sendRPC(client, method, payload)
+}
+
+// txpoolContent retrieves the result of a txpool content method, keeping only
+// the transactions of the account given as the first argument, if any.
+func txpoolContent(ctx *cli.Context, method string) (map[string]map[string]map[string]interface{}, error) {
+	res, err := txpoolCall(ctx, "txpool_"+method)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	var content map[string]map[string]map[string]interface{}
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return nil, err
+	}
+	if ctx.NArg() > 0 {
+		if !common.IsHexAddress(ctx.Args().First()) {
+			return nil, fmt.Errorf("%v: invalid address %q", ErrInvalidFlag, ctx.Args().First())
+		}
+		account := common.HexToAddress(ctx.Args().First()).Hex()
+		for pool, accounts := range content {
+			content[pool] = map[string]map[string]interface{}{}
+			if txs, ok := accounts[account]; ok {
+				content[pool][account] = txs
+			}
+		}
+	}
+	return content, nil
+}
+
+func txpoolList(ctx *cli.Context) error {
+	content, err := txpoolContent(ctx, "inspect")
+	if err != nil {
+		return err
+	}
+	for _, pool := range []string{"pending", "queued"} {
+		var accounts []string
+		for account := range content[pool] {
+			accounts = append(accounts, account)
+		}
+		sort.Strings(accounts)
+
+		fmt.Printf("%s: %d accounts\n", pool, len(accounts))
+		for _, account := range accounts {
+			var nonces []int
+			for nonce := range content[pool][account] {
+				n, _ := strconv.Atoi(nonce)
+				nonces = append(nonces, n)
+			}
+			sort.Ints(nonces)
+			for _, n := range nonces {
+				txs, _ := content[pool][account][strconv.Itoa(n)].([]interface{})
+				for _, tx := range txs {
+					fmt.Printf("  %s #%d %v\n", account, n, tx)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func txpoolInspect(ctx *cli.Context) error {
+	content, err := txpoolContent(ctx, "content")
+	if err != nil {
+		return err
+	}
+	return prettyPrint(content)
+}
+
+func txpoolDrop(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("%v: use: $ webchaind txpool drop <txHash>", ErrInvalidFlag)
+	}
+	if _, err := txpoolCall(ctx, "admin_dropTransaction", common.HexToHash(ctx.Args().First())); err != nil {
+		return err
+	}
+	fmt.Println("Dropped", ctx.Args().First())
+	return nil
+}
+
+func txpoolReplace(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return fmt.Errorf("%v: use: $ webchaind txpool replace <txHash> <rawTx>", ErrInvalidFlag)
+	}
+	hash, raw := common.HexToHash(ctx.Args()[0]), strings.TrimSpace(ctx.Args()[1])
+	res, err := txpoolCall(ctx, "admin_replaceTransaction", hash, raw)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Replaced", hash.Hex(), "by", res)
+	return nil
+}
@@ -67,19 +67,37 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			exportCommand,
 			dumpChainConfigCommand,
 			dumpCommand,
+			profileBlockCommand,
+			crossCheckCommand,
 			rollbackCommand,
 			recoverCommand,
 			resetCommand,
+			migrateAncientCommand,
+			emissionCommand,
 		},
 		Flags: []cli.Flag{
 			DataDirFlag,
+			AncientDirFlag,
 			ChainIdentityFlag,
 			NetworkIdFlag,
 			DevModeFlag,
 			NodeNameFlag,
 			FastSyncFlag,
 			SlowSyncFlag,
+			NoCheckpointFlag,
+			SideBlocksRetainFlag,
 			CacheFlag,
+			CacheDatabaseFlag,
+			CacheTrieFlag,
+			CacheTrieDirtyFlag,
+			CacheSnapshotFlag,
+			CachePrefetchFlag,
+			TxPoolJournalFlag,
+			TxPoolPriceLimitFlag,
+			TxPoolNonceGapFlag,
+			TxPoolBroadcastFractionFlag,
+			TxPoolBroadcastIntervalFlag,
+			TxPoolPrivateFlag,
 			LightKDFFlag,
 			SputnikVMFlag,
 			BlockchainVersionFlag,
@@ -90,15 +108,19 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 		Commands: []cli.Command{
 			accountCommand,
 			walletCommand,
+			signTxCommand,
 			buildAddrTxIndexCommand,
 		},
 		Flags: []cli.Flag{
 			KeyStoreDirFlag,
 			UnlockedAccountFlag,
 			PasswordFileFlag,
+			AllowInsecureUnlockFlag,
+			SignAuditLogFlag,
 			AccountsIndexFlag,
 			AddrTxIndexFlag,
 			AddrTxIndexAutoBuildFlag,
+			TokenIndexFlag,
 		},
 	},
 	{
@@ -108,20 +130,39 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			attachCommand,
 			javascriptCommand,
 			apiCommand,
+			txpoolCommand,
 		},
 		Flags: []cli.Flag{
 			RPCEnabledFlag,
 			RPCListenAddrFlag,
 			RPCPortFlag,
 			RPCApiFlag,
+			RPCReadTimeoutFlag,
+			RPCWriteTimeoutFlag,
+			RPCIdleTimeoutFlag,
+			RPCMaxHeaderBytesFlag,
+			RPCConcurrencyFlag,
+			RPCConcurrencyQueueFlag,
+			RPCConcurrencyTimeoutFlag,
+			RPCAPIKeysFlag,
+			RPCDenyContractsFlag,
+			RPCCallTimeoutFlag,
 			WSEnabledFlag,
 			WSListenAddrFlag,
 			WSPortFlag,
 			WSApiFlag,
 			WSAllowedOriginsFlag,
+			WSSubscriptionBufferFlag,
+			WSSubscriptionPolicyFlag,
+			WSMaxRequestFlag,
+			WSMaxResponseFlag,
+			FilterTimeoutFlag,
+			FilterPersistFlag,
 			IPCDisabledFlag,
 			IPCApiFlag,
 			IPCPathFlag,
+			IPCMaxRequestFlag,
+			IPCMaxResponseFlag,
 			RPCCORSDomainFlag,
 			JSpathFlag,
 			ExecFlag,
@@ -135,6 +176,10 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			ListenPortFlag,
 			MaxPeersFlag,
 			MaxPendingPeersFlag,
+			DialRatioFlag,
+			DialHistoryExpiryFlag,
+			ProtocolMinVersionFlag,
+			ProtocolStrictForksFlag,
 			NATFlag,
 			NoDiscoverFlag,
 			NodeKeyFileFlag,
@@ -148,11 +193,27 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			MinerThreadsFlag,
 			MiningGPUFlag,
 			EtherbaseFlag,
+			MinerEmptyBlocksFlag,
+			MinerDeadlineFlag,
 			TargetGasLimitFlag,
 			GasPriceFlag,
 			ExtraDataFlag,
 		},
 	},
+	{
+		Name: "FAUCET",
+		Flags: []cli.Flag{
+			FaucetEnabledFlag,
+			FaucetListenAddrFlag,
+			FaucetAccountFlag,
+			FaucetAmountFlag,
+			FaucetIntervalFlag,
+			FaucetCaptchaSecretFlag,
+			FaucetCaptchaURLFlag,
+			FaucetWebhookFlag,
+			FaucetTrustedProxiesFlag,
+		},
+	},
 	{
 		Name: "GAS PRICE ORACLE",
 		Flags: []cli.Flag{
@@ -193,6 +254,7 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			MLogComponentsFlag,
 			BacktraceAtFlag,
 			MetricsFlag,
+			MetricsOpcodesFlag,
 			FakePoWFlag,
 		},
 	},
@@ -200,6 +262,7 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 		Name: "EXPERIMENTAL",
 		Flags: []cli.Flag{
 			WhisperEnabledFlag,
+			WhisperMinPoWFlag,
 			NatspecEnabledFlag,
 			DisplayFlag,
 			DisplayFormatFlag,
@@ -220,6 +283,13 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 		Name: "MISCELLANEOUS",
 		Flags: []cli.Flag{
 			SolcPathFlag,
+			EVMCFlag,
+			EWASMFlag,
+			NewBlockExecFlag,
+			ForkCheckURLFlag,
+			ForkCheckSignersFlag,
+			ForkCheckIntervalFlag,
+			ForkCheckWebhookFlag,
 		},
 	},
 }
@@ -146,6 +146,12 @@ func (m callmsg) Value() *big.Int {
 func (m callmsg) Data() []byte {
 	return m.data
 }
+func (m callmsg) GasTipCap() *big.Int {
+	return m.gasPrice
+}
+func (m callmsg) AccessList() types.AccessList {
+	return nil
+}
 
 // Call forms a transaction from the given arguments and tries to execute it on
 // a private VM with a copy of the state. Any changes are therefore only temporary
@@ -0,0 +1,269 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+)
+
+// AnalyticsTable is a table written by ExportAnalytics, one file per table.
+type AnalyticsTable struct {
+	Name    string
+	Columns []string
+}
+
+// AnalyticsTables is the schema of the analytics export. Hashes, addresses
+// and binary data are 0x-prefixed hex, amounts and quantities are decimal.
+// Empty values mean "none": no recipient for contract creations, no contract
+// address for calls, no status before Byzantium, and unused topics.
+var AnalyticsTables = []AnalyticsTable{
+	{Name: "blocks", Columns: []string{
+		"number", "hash", "parent_hash", "timestamp", "miner", "difficulty",
+		"gas_limit", "gas_used", "tx_count", "uncle_count", "size",
+	}},
+	{Name: "transactions", Columns: []string{
+		"block_number", "block_hash", "tx_index", "hash", "from", "to",
+		"value", "gas", "gas_price", "nonce", "input",
+	}},
+	{Name: "receipts", Columns: []string{
+		"block_number", "tx_hash", "tx_index", "status", "gas_used",
+		"cumulative_gas_used", "contract_address", "log_count",
+	}},
+	{Name: "logs", Columns: []string{
+		"block_number", "tx_hash", "tx_index", "log_index", "address",
+		"topic0", "topic1", "topic2", "topic3", "data",
+	}},
+}
+
+// Analytics export file formats, also used as the file extensions.
+const (
+	AnalyticsCSV     = "csv"
+	AnalyticsParquet = "parquet"
+)
+
+// analyticsWriter writes the rows of an analytics table to its file.
+type analyticsWriter interface {
+	Write(row []string) error
+	Close() error
+}
+
+// csvWriter writes an analytics table as CSV, header first.
+type csvWriter struct {
+	f *os.File
+	w *csv.Writer
+}
+
+func newCSVWriter(path string, columns []string) (*csvWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &csvWriter{f: f, w: csv.NewWriter(f)}
+	if err := w.w.Write(columns); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *csvWriter) Write(row []string) error { return w.w.Write(row) }
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	if err := w.w.Error(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
+
+// AnalyticsSummary counts the rows written by ExportAnalytics.
+type AnalyticsSummary struct {
+	Blocks       int `json:"blocks"`
+	Transactions int `json:"transactions"`
+	Receipts     int `json:"receipts"`
+	Logs         int `json:"logs"`
+}
+
+// ExportAnalytics writes the canonical blocks first through last, with their
+// transactions, receipts and logs, as AnalyticsCSV or AnalyticsParquet files
+// named after the tables of AnalyticsTables into dir, replacing any existing
+// ones. Parquet columns are UTF-8 strings holding the CSV values.
+func ExportAnalytics(bc *BlockChain, dir, format string, first, last uint64) (*AnalyticsSummary, error) {
+	if format != AnalyticsCSV && format != AnalyticsParquet {
+		return nil, fmt.Errorf("unsupported analytics format %q", format)
+	}
+	if first > last {
+		return nil, fmt.Errorf("invalid block range %d-%d", first, last)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	writers := make([]analyticsWriter, len(AnalyticsTables))
+	defer func() {
+		for _, w := range writers {
+			if w != nil {
+				w.Close()
+			}
+		}
+	}()
+	for i, table := range AnalyticsTables {
+		var (
+			path = filepath.Join(dir, table.Name+"."+format)
+			w    analyticsWriter
+			err  error
+		)
+		if format == AnalyticsParquet {
+			w, err = newParquetWriter(path, table.Columns)
+		} else {
+			w, err = newCSVWriter(path, table.Columns)
+		}
+		if err != nil {
+			return nil, err
+		}
+		writers[i] = w
+	}
+	blocks, txs, receipts, logs := writers[0], writers[1], writers[2], writers[3]
+
+	var (
+		config  = bc.Config()
+		summary = new(AnalyticsSummary)
+	)
+	for n := first; n <= last; n++ {
+		block := bc.GetBlockByNumber(n)
+		if block == nil {
+			return summary, fmt.Errorf("block #%d not found", n)
+		}
+		number := strconv.FormatUint(n, 10)
+		if err := blocks.Write([]string{
+			number,
+			block.Hash().Hex(),
+			block.ParentHash().Hex(),
+			block.Time().String(),
+			block.Coinbase().Hex(),
+			block.Difficulty().String(),
+			block.GasLimit().String(),
+			block.GasUsed().String(),
+			strconv.Itoa(len(block.Transactions())),
+			strconv.Itoa(len(block.Uncles())),
+			strconv.FormatFloat(float64(block.Size()), 'f', 0, 64),
+		}); err != nil {
+			return summary, err
+		}
+		summary.Blocks++
+
+		blockReceipts := GetBlockReceipts(bc.chainDb, block.Hash())
+		if len(blockReceipts) != len(block.Transactions()) {
+			return summary, fmt.Errorf("receipts of block #%d missing", n)
+		}
+		signer := config.GetSigner(block.Number())
+		logIndex := 0
+		for i, tx := range block.Transactions() {
+			txIndex := strconv.Itoa(i)
+			tx.SetSigner(signer)
+			from, err := tx.From()
+			if err != nil {
+				return summary, fmt.Errorf("tx %x: %v", tx.Hash(), err)
+			}
+			var to string
+			if tx.To() != nil {
+				to = tx.To().Hex()
+			}
+			if err := txs.Write([]string{
+				number,
+				block.Hash().Hex(),
+				txIndex,
+				tx.Hash().Hex(),
+				from.Hex(),
+				to,
+				tx.Value().String(),
+				tx.Gas().String(),
+				tx.GasPrice().String(),
+				strconv.FormatUint(tx.Nonce(), 10),
+				common.ToHex(tx.Data()),
+			}); err != nil {
+				return summary, err
+			}
+			summary.Transactions++
+
+			receipt := blockReceipts[i]
+			var status, contract string
+			if receipt.Status != types.TxStatusUnknown {
+				status = strconv.Itoa(int(receipt.Status))
+			}
+			if tx.To() == nil {
+				// Receipts don't store the address, derive it like the EVM does.
+				contract = crypto.CreateAddress(from, tx.Nonce()).Hex()
+			}
+			if err := receipts.Write([]string{
+				number,
+				tx.Hash().Hex(),
+				txIndex,
+				status,
+				receipt.GasUsed.String(),
+				receipt.CumulativeGasUsed.String(),
+				contract,
+				strconv.Itoa(len(receipt.Logs)),
+			}); err != nil {
+				return summary, err
+			}
+			summary.Receipts++
+
+			for _, log := range receipt.Logs {
+				row := []string{
+					number,
+					tx.Hash().Hex(),
+					txIndex,
+					strconv.Itoa(logIndex),
+					log.Address.Hex(),
+					"", "", "", "",
+					common.ToHex(log.Data),
+				}
+				for j, topic := range log.Topics {
+					if j < 4 {
+						row[5+j] = topic.Hex()
+					}
+				}
+				if err := logs.Write(row); err != nil {
+					return summary, err
+				}
+				summary.Logs++
+				logIndex++
+			}
+		}
+		if n%10000 == 0 && n != first {
+			glog.V(logger.Info).Infof("Exported analytics up to block #%d", n)
+		}
+	}
+	for i, w := range writers {
+		writers[i] = nil
+		if err := w.Close(); err != nil {
+			return summary, err
+		}
+	}
+	return summary, nil
+}
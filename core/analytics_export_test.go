@@ -0,0 +1,157 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+)
+
+func TestExportAnalytics(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	var (
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		to     = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+		config = DefaultConfigMorden.ChainConfig
+		signer = config.GetSigner(big.NewInt(2))
+	)
+	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000)})
+	blocks, _ := GenerateChain(config, genesis, db, 3, func(i int, gen *BlockGen) {
+		if i == 1 {
+			tx, _ := types.NewTransaction(gen.TxNonce(addr), to, big.NewInt(1000), TxGas, nil, nil).WithSigner(signer).SignECDSA(key)
+			gen.AddTx(tx)
+		}
+		if i == 2 {
+			tx, _ := types.NewContractCreation(gen.TxNonce(addr), new(big.Int), big.NewInt(100000), new(big.Int), nil).WithSigner(signer).SignECDSA(key)
+			gen.AddTx(tx)
+		}
+	})
+	bc, err := NewBlockChain(db, config, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := bc.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to insert block %d: %v", res.Index, res.Error)
+	}
+	// Receipts don't keep the contract address in the database.
+	receipts := GetBlockReceipts(db, blocks[2].Hash())
+	receipts[0].ContractAddress = common.Address{}
+	if err := WriteBlockReceipts(db, blocks[2].Hash(), receipts); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "analytics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := ExportAnalytics(bc, dir, "json", 1, 3); err == nil {
+		t.Error("unknown format accepted")
+	}
+	if _, err := ExportAnalytics(bc, dir, AnalyticsCSV, 2, 1); err == nil {
+		t.Error("inverted range accepted")
+	}
+	if _, err := ExportAnalytics(bc, dir, AnalyticsCSV, 0, 4); err == nil {
+		t.Error("range past the head accepted")
+	}
+	summary, err := ExportAnalytics(bc, dir, AnalyticsCSV, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (AnalyticsSummary{Blocks: 3, Transactions: 2, Receipts: 2}); *summary != want {
+		t.Errorf("summary mismatch: have %+v, want %+v", *summary, want)
+	}
+
+	for _, table := range AnalyticsTables {
+		f, err := os.Open(filepath.Join(dir, table.Name+".csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", table.Name, err)
+		}
+		if strings.Join(rows[0], ",") != strings.Join(table.Columns, ",") {
+			t.Errorf("%s: header mismatch: %v", table.Name, rows[0])
+		}
+		switch table.Name {
+		case "blocks":
+			if len(rows) != 4 || rows[1][0] != "1" || rows[2][8] != "1" {
+				t.Errorf("blocks mismatch: %v", rows)
+			}
+		case "transactions":
+			tx := blocks[1].Transactions()[0]
+			if len(rows) != 3 || rows[1][3] != tx.Hash().Hex() || rows[1][4] != addr.Hex() || rows[1][5] != to.Hex() || rows[1][6] != "1000" || rows[2][5] != "" {
+				t.Errorf("transactions mismatch: %v", rows)
+			}
+		case "receipts":
+			contract := crypto.CreateAddress(addr, 1)
+			if len(rows) != 3 || rows[1][4] != TxGas.String() || rows[1][6] != "" || rows[2][6] != contract.Hex() {
+				t.Errorf("receipts mismatch: %v", rows)
+			}
+		case "logs":
+			if len(rows) != 1 {
+				t.Errorf("logs mismatch: %v", rows)
+			}
+		}
+	}
+
+	// The Parquet export holds the same rows as the CSV one.
+	if _, err := ExportAnalytics(bc, dir, AnalyticsParquet, 1, 3); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range AnalyticsTables {
+		f, err := os.Open(filepath.Join(dir, table.Name+".csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := csv.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, table.Name+".parquet"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		columns, rows, err := readParquet(data)
+		if err != nil {
+			t.Fatalf("%s: %v", table.Name, err)
+		}
+		if !reflect.DeepEqual(columns, want[0]) {
+			t.Errorf("%s: columns mismatch: have %v, want %v", table.Name, columns, want[0])
+		}
+		if len(rows) != len(want)-1 || (len(rows) > 0 && !reflect.DeepEqual(rows, want[1:])) {
+			t.Errorf("%s: rows mismatch: have %v, want %v", table.Name, rows, want[1:])
+		}
+	}
+}
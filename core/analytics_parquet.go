@@ -0,0 +1,285 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"os"
+)
+
+// This file holds a minimal Parquet encoder for the analytics export, see
+// https://github.com/apache/parquet-format. Every column is a required UTF-8
+// string column holding the same value as the CSV export, plain encoded and
+// uncompressed, with a single data page per column chunk.
+
+const (
+	parquetMagic = "PAR1"
+
+	// parquetGroupRows is the number of rows buffered per row group.
+	parquetGroupRows = 10000
+
+	// Parquet enum values, from parquet.thrift.
+	parquetByteArray    = 6 // Type
+	parquetRequired     = 0 // FieldRepetitionType
+	parquetUTF8         = 0 // ConvertedType
+	parquetPlain        = 0 // Encoding
+	parquetRLE          = 3 // Encoding
+	parquetUncompressed = 0 // CompressionCodec
+	parquetDataPage     = 0 // PageType
+
+	// Thrift compact protocol types.
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// parquetChunk locates the page of a column chunk.
+type parquetChunk struct {
+	offset int64 // of the page header
+	size   int64 // of the page, header included
+}
+
+// parquetGroup is a row group written to the file.
+type parquetGroup struct {
+	chunks []parquetChunk
+	rows   int64
+}
+
+// parquetWriter writes the rows of an analytics table as a Parquet file.
+type parquetWriter struct {
+	f       *os.File
+	columns []string
+	rows    [][]string // rows of the pending row group
+	offset  int64      // bytes written so far
+	groups  []parquetGroup
+}
+
+func newParquetWriter(path string, columns []string) (*parquetWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &parquetWriter{f: f, columns: columns}
+	if err := w.write([]byte(parquetMagic)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *parquetWriter) write(b []byte) error {
+	n, err := w.f.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+// Write buffers a row, flushing the row group once it's full.
+func (w *parquetWriter) Write(row []string) error {
+	w.rows = append(w.rows, row)
+	if len(w.rows) < parquetGroupRows {
+		return nil
+	}
+	return w.flush()
+}
+
+// flush writes the pending rows as a row group.
+func (w *parquetWriter) flush() error {
+	if len(w.rows) == 0 {
+		return nil
+	}
+	group := parquetGroup{rows: int64(len(w.rows))}
+	for i := range w.columns {
+		var values []byte
+		for _, row := range w.rows {
+			var size [4]byte
+			binary.LittleEndian.PutUint32(size[:], uint32(len(row[i])))
+			values = append(values, size[:]...)
+			values = append(values, row[i]...)
+		}
+		// PageHeader with its DataPageHeader. Required flat columns carry
+		// no repetition or definition levels.
+		header := new(thriftWriter)
+		header.begin()
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(values)))
+		header.i32(3, int32(len(values)))
+		header.structField(5)
+		header.i32(1, int32(len(w.rows)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.end()
+		header.end()
+
+		chunk := parquetChunk{offset: w.offset, size: int64(len(header.buf) + len(values))}
+		if err := w.write(header.buf); err != nil {
+			return err
+		}
+		if err := w.write(values); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+	}
+	w.groups = append(w.groups, group)
+	w.rows = w.rows[:0]
+	return nil
+}
+
+// Close flushes the pending rows, writes the footer and closes the file.
+func (w *parquetWriter) Close() error {
+	if err := w.flush(); err != nil {
+		w.f.Close()
+		return err
+	}
+	footer := w.metadata()
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(footer)))
+	footer = append(footer, size[:]...)
+	footer = append(footer, parquetMagic...)
+	if err := w.write(footer); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
+
+// metadata encodes the FileMetaData of the file.
+func (w *parquetWriter) metadata() []byte {
+	var rows int64
+	for _, group := range w.groups {
+		rows += group.rows
+	}
+	meta := new(thriftWriter)
+	meta.begin()
+	meta.i32(1, 1) // version
+
+	meta.list(2, thriftStruct, len(w.columns)+1)
+	meta.begin()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(w.columns)))
+	meta.end()
+	for _, name := range w.columns {
+		meta.begin()
+		meta.i32(1, parquetByteArray)
+		meta.i32(3, parquetRequired)
+		meta.binary(4, name)
+		meta.i32(6, parquetUTF8)
+		meta.end()
+	}
+	meta.i64(3, rows)
+
+	meta.list(4, thriftStruct, len(w.groups))
+	for _, group := range w.groups {
+		var total int64
+		meta.begin()
+		meta.list(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			meta.begin()
+			meta.i64(2, chunk.offset)
+			meta.structField(3) // ColumnMetaData
+			meta.i32(1, parquetByteArray)
+			meta.list(2, thriftI32, 1)
+			meta.zigzag(parquetPlain)
+			meta.list(3, thriftBinary, 1)
+			meta.bytes(w.columns[i])
+			meta.i32(4, parquetUncompressed)
+			meta.i64(5, group.rows)
+			meta.i64(6, chunk.size)
+			meta.i64(7, chunk.size)
+			meta.i64(9, chunk.offset)
+			meta.end()
+			meta.end()
+			total += chunk.size
+		}
+		meta.i64(2, total)
+		meta.i64(3, group.rows)
+		meta.end()
+	}
+	meta.binary(6, "webchaind")
+	meta.end()
+	return meta.buf
+}
+
+// thriftWriter encodes structs in the Thrift compact protocol.
+type thriftWriter struct {
+	buf []byte
+	ids []int16 // last field id of each open struct
+}
+
+// begin opens a struct, end writes its stop field and closes it.
+func (w *thriftWriter) begin() { w.ids = append(w.ids, 0) }
+func (w *thriftWriter) end() {
+	w.buf = append(w.buf, 0)
+	w.ids = w.ids[:len(w.ids)-1]
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.buf = append(w.buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func (w *thriftWriter) zigzag(v int64) { w.varint(uint64((v << 1) ^ (v >> 63))) }
+
+func (w *thriftWriter) bytes(s string) {
+	w.varint(uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	last := &w.ids[len(w.ids)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(id, thriftI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(id, thriftI64)
+	w.zigzag(v)
+}
+
+func (w *thriftWriter) binary(id int16, s string) {
+	w.field(id, thriftBinary)
+	w.bytes(s)
+}
+
+// structField opens a struct valued field.
+func (w *thriftWriter) structField(id int16) {
+	w.field(id, thriftStruct)
+	w.begin()
+}
+
+// list writes the header of a list field, its n elements follow.
+func (w *thriftWriter) list(id int16, elem byte, n int) {
+	w.field(id, thriftList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elem)
+	} else {
+		w.buf = append(w.buf, 0xf0|elem)
+		w.varint(uint64(n))
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// thriftReader decodes the Thrift compact protocol into int64s, strings,
+// slices and field id maps, for checking the files of parquetWriter.
+type thriftReader struct {
+	buf []byte
+	err error
+}
+
+func (r *thriftReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+	r.buf = nil
+}
+
+func (r *thriftReader) byte() byte {
+	if len(r.buf) == 0 {
+		r.fail(errors.New("unexpected end of thrift data"))
+		return 0
+	}
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 {
+		r.fail(errors.New("invalid varint"))
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := r.varint()
+		if n > uint64(len(r.buf)) {
+			r.fail(errors.New("binary overflows thrift data"))
+			return ""
+		}
+		s := string(r.buf[:n])
+		r.buf = r.buf[n:]
+		return s
+	case thriftList:
+		header := r.byte()
+		n := int(header >> 4)
+		if n == 15 {
+			n = int(r.varint())
+		}
+		var list []interface{}
+		for i := 0; i < n && r.err == nil; i++ {
+			list = append(list, r.value(header&0x0f))
+		}
+		return list
+	case thriftStruct:
+		fields := make(map[int16]interface{})
+		for id := int16(0); r.err == nil; {
+			header := r.byte()
+			if header == 0 {
+				break
+			}
+			if delta := int16(header >> 4); delta != 0 {
+				id += delta
+			} else {
+				id = int16(r.zigzag())
+			}
+			fields[id] = r.value(header & 0x0f)
+		}
+		return fields
+	}
+	r.fail(fmt.Errorf("unsupported thrift type %d", typ))
+	return nil
+}
+
+// readParquet returns the column names and rows of a parquetWriter file.
+func readParquet(data []byte) ([]string, [][]string, error) {
+	if len(data) < 12 || string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		return nil, nil, errors.New("missing magic")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if size > len(data)-12 {
+		return nil, nil, errors.New("footer overflows file")
+	}
+	r := &thriftReader{buf: data[len(data)-8-size : len(data)-8]}
+	meta := r.value(thriftStruct).(map[int16]interface{})
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	if len(r.buf) != 0 {
+		return nil, nil, errors.New("trailing footer data")
+	}
+	schema := meta[2].([]interface{})
+	if root := schema[0].(map[int16]interface{}); root[5] != int64(len(schema)-1) {
+		return nil, nil, fmt.Errorf("root has %v children, want %d", root[5], len(schema)-1)
+	}
+	var columns []string
+	for _, elem := range schema[1:] {
+		column := elem.(map[int16]interface{})
+		if column[1] != int64(parquetByteArray) || column[3] != int64(parquetRequired) || column[6] != int64(parquetUTF8) {
+			return nil, nil, fmt.Errorf("column %v isn't a required UTF-8 byte array", column[4])
+		}
+		columns = append(columns, column[4].(string))
+	}
+	var rows [][]string
+	groups, _ := meta[4].([]interface{})
+	for _, g := range groups {
+		group := g.(map[int16]interface{})
+		base, count := len(rows), int(group[3].(int64))
+		for i := 0; i < count; i++ {
+			rows = append(rows, make([]string, len(columns)))
+		}
+		chunks := group[1].([]interface{})
+		if len(chunks) != len(columns) {
+			return nil, nil, fmt.Errorf("row group has %d chunks, want %d", len(chunks), len(columns))
+		}
+		for i, c := range chunks {
+			md := c.(map[int16]interface{})[3].(map[int16]interface{})
+			if md[3].([]interface{})[0] != columns[i] || md[5] != int64(count) {
+				return nil, nil, fmt.Errorf("chunk %d metadata mismatch: %v", i, md)
+			}
+			offset := int(md[9].(int64))
+			r := &thriftReader{buf: data[offset:]}
+			page := r.value(thriftStruct).(map[int16]interface{})
+			if r.err != nil {
+				return nil, nil, r.err
+			}
+			header := len(data) - offset - len(r.buf)
+			if page[1] != int64(parquetDataPage) || page[5].(map[int16]interface{})[1] != int64(count) {
+				return nil, nil, fmt.Errorf("chunk %d page mismatch: %v", i, page)
+			}
+			values := r.buf[:page[3].(int64)]
+			if md[7] != int64(header+len(values)) {
+				return nil, nil, fmt.Errorf("chunk %d size %v, want %d", i, md[7], header+len(values))
+			}
+			for j := 0; j < count; j++ {
+				if len(values) < 4 || len(values)-4 < int(binary.LittleEndian.Uint32(values)) {
+					return nil, nil, fmt.Errorf("chunk %d value %d truncated", i, j)
+				}
+				n := int(binary.LittleEndian.Uint32(values))
+				rows[base+j][i] = string(values[4 : 4+n])
+				values = values[4+n:]
+			}
+			if len(values) != 0 {
+				return nil, nil, fmt.Errorf("chunk %d has trailing values", i)
+			}
+		}
+	}
+	if meta[3] != int64(len(rows)) {
+		return nil, nil, fmt.Errorf("file has %v rows, want %d", meta[3], len(rows))
+	}
+	return columns, rows, nil
+}
+
+// Tests that tables spanning row groups, wide schemas and empty tables
+// are written as valid Parquet files.
+func TestParquetWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var columns []string
+	for i := 0; i < 20; i++ {
+		columns = append(columns, "c"+strconv.Itoa(i))
+	}
+	for _, count := range []int{0, 1, parquetGroupRows, 2*parquetGroupRows + 1} {
+		path := filepath.Join(dir, strconv.Itoa(count)+".parquet")
+		w, err := newParquetWriter(path, columns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want [][]string
+		for i := 0; i < count; i++ {
+			row := make([]string, len(columns))
+			for j := range row {
+				if (i+j)%3 != 0 {
+					row[j] = fmt.Sprintf("%d-%d", i, j)
+				}
+			}
+			want = append(want, row)
+			if err := w.Write(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		have, rows, err := readParquet(data)
+		if err != nil {
+			t.Fatalf("%d rows: %v", count, err)
+		}
+		if !reflect.DeepEqual(have, columns) {
+			t.Errorf("%d rows: columns mismatch: %v", count, have)
+		}
+		if !reflect.DeepEqual(rows, want) {
+			t.Errorf("%d rows: rows mismatch", count)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+)
+
+// OpProfile is the execution cost of a single opcode within a block.
+type OpProfile struct {
+	Op    string        `json:"op"`
+	Count int           `json:"count"`
+	Gas   uint64        `json:"gas"`
+	Time  time.Duration `json:"time"` // Nanoseconds
+}
+
+// ContractProfile is the execution cost of the code of a single contract
+// within a block.
+type ContractProfile struct {
+	Address common.Address `json:"address"`
+	Steps   int            `json:"steps"`
+	Gas     uint64         `json:"gas"`
+	Time    time.Duration  `json:"time"` // Nanoseconds
+}
+
+// TxProfile is the execution cost of a single transaction.
+type TxProfile struct {
+	Hash    common.Hash     `json:"hash"`
+	To      *common.Address `json:"to"`
+	Steps   int             `json:"steps"`
+	GasUsed *big.Int        `json:"gasUsed"`
+	Time    time.Duration   `json:"time"` // Nanoseconds
+}
+
+// BlockProfile is the execution cost of the transactions of a block, broken
+// down by opcode, contract and transaction, each sorted by time descending.
+type BlockProfile struct {
+	Number       uint64             `json:"number"`
+	Hash         common.Hash        `json:"hash"`
+	GasUsed      *big.Int           `json:"gasUsed"`
+	Time         time.Duration      `json:"time"` // Nanoseconds
+	Ops          []*OpProfile       `json:"ops"`
+	Contracts    []*ContractProfile `json:"contracts"`
+	Transactions []*TxProfile       `json:"transactions"`
+}
+
+// ProfileBlock replays the transactions of block on top of its parent state
+// with an instrumented VM. Time spent in a step is measured up to the start
+// of the next one, so it includes the interpreter's own overhead.
+func ProfileBlock(bc *BlockChain, block *types.Block) (*BlockProfile, error) {
+	parent := bc.GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	statedb, err := bc.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		config = bc.Config()
+		gp     = new(GasPool).AddGas(block.GasLimit())
+		p      = newProfiler()
+		res    = &BlockProfile{Number: block.NumberU64(), Hash: block.Hash(), GasUsed: new(big.Int)}
+	)
+	for _, tx := range block.Transactions() {
+		tx.SetSigner(config.GetSigner(block.Number()))
+		env := NewEnv(statedb, config, bc, tx, block.Header())
+		env.SetTracer(p)
+
+		steps := p.steps
+		start := time.Now()
+		_, gas, _, err := ApplyMessage(env, tx, gp)
+		p.finish()
+		if err != nil {
+			return nil, fmt.Errorf("tx %x failed: %v", tx.Hash(), err)
+		}
+		elapsed := time.Since(start)
+		res.Transactions = append(res.Transactions, &TxProfile{
+			Hash:    tx.Hash(),
+			To:      tx.To(),
+			Steps:   p.steps - steps,
+			GasUsed: gas,
+			Time:    elapsed,
+		})
+		res.GasUsed.Add(res.GasUsed, gas)
+		res.Time += elapsed
+
+		if config.IsReceiptStatus(block.Number()) {
+			statedb.Finalise(config.IsStateClear(block.Number()))
+		} else {
+			statedb.IntermediateRoot(config.IsStateClear(block.Number()))
+		}
+	}
+	for _, op := range p.ops {
+		res.Ops = append(res.Ops, op)
+	}
+	for _, c := range p.contracts {
+		res.Contracts = append(res.Contracts, c)
+	}
+	sort.Slice(res.Ops, func(i, j int) bool { return res.Ops[i].Time > res.Ops[j].Time })
+	sort.Slice(res.Contracts, func(i, j int) bool { return res.Contracts[i].Time > res.Contracts[j].Time })
+	sort.Slice(res.Transactions, func(i, j int) bool { return res.Transactions[i].Time > res.Transactions[j].Time })
+	return res, nil
+}
+
+// profiler is a vm.Tracer accumulating the gas and time of every step.
+type profiler struct {
+	ops       map[vm.OpCode]*OpProfile
+	contracts map[common.Address]*ContractProfile
+	steps     int
+
+	// Step being executed, charged its time when the next one starts.
+	lastOp       *OpProfile
+	lastContract *ContractProfile
+	lastTime     time.Time
+}
+
+func newProfiler() *profiler {
+	return &profiler{
+		ops:       make(map[vm.OpCode]*OpProfile),
+		contracts: make(map[common.Address]*ContractProfile),
+	}
+}
+
+func (p *profiler) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack []*big.Int, contract *vm.Contract, err error) {
+	p.finish()
+
+	o := p.ops[op]
+	if o == nil {
+		o = &OpProfile{Op: op.String()}
+		p.ops[op] = o
+	}
+	addr := contract.Address()
+	if contract.CodeAddr != nil {
+		addr = *contract.CodeAddr
+	}
+	c := p.contracts[addr]
+	if c == nil {
+		c = &ContractProfile{Address: addr}
+		p.contracts[addr] = c
+	}
+	o.Count++
+	c.Steps++
+	o.Gas += cost
+	c.Gas += cost
+	p.steps++
+	p.lastOp, p.lastContract, p.lastTime = o, c, time.Now()
+}
+
+// finish charges the time since the last step started to it.
+func (p *profiler) finish() {
+	if p.lastOp == nil {
+		return
+	}
+	elapsed := time.Since(p.lastTime)
+	p.lastOp.Time += elapsed
+	p.lastContract.Time += elapsed
+	p.lastOp, p.lastContract = nil, nil
+}
@@ -32,9 +32,9 @@ import (
 )
 
 var (
-	MinGasLimit            = big.NewInt(5000)    // Minimum the gas limit may ever be.
+	MinGasLimit            = big.NewInt(5000)    // Minimum the gas limit may ever be, unless configured by the chain.
 	TargetGasLimit         = big.NewInt(4712388) // The artificial target
-	GasLimitBoundDivisor   = big.NewInt(1024)    // The bound divisor of the gas limit, used in update calculations.
+	GasLimitBoundDivisor   = big.NewInt(1024)    // The bound divisor of the gas limit, used in update calculations, unless configured by the chain.
 )
 
 var (
@@ -131,8 +131,9 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 		return validateError(fmt.Sprintf("gas used error (%v / %v)", block.GasUsed(), usedGas))
 	}
 	// Validate the received block's bloom with the one derived from the generated receipts.
-	// For valid blocks this should always validate to true.
-	rbloom := types.CreateBloom(receipts)
+	// For valid blocks this should always validate to true. The receipt blooms themselves
+	// were derived by the processor and are covered by the receipt root below.
+	rbloom := types.MergeBlooms(receipts)
 	if rbloom != header.Bloom {
 		return fmt.Errorf("unable to replicate block's bloom=%x vs calculated bloom=%x", header.Bloom, rbloom)
 	}
@@ -143,7 +144,7 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 	}
 	// Validate the state root against the received state root and throw
 	// an error if they don't match.
-	if root := statedb.IntermediateRoot(v.config.IsAtlantis(header.Number)); header.Root != root {
+	if root := statedb.IntermediateRoot(v.config.IsStateClear(header.Number)); header.Root != root {
 		return fmt.Errorf("invalid merkle root: header=%x computed=%x", header.Root, root)
 	}
 	return nil
@@ -241,14 +242,21 @@ func ValidateHeader(config *ChainConfig, pow pow.PoW, header *types.Header, pare
 		return fmt.Errorf("Difficulty check failed for header %v != %v at %v", header.Difficulty, expd, header.Number)
 	}
 
+	minGasLimit, boundDivisor := config.GasLimitBounds(header.Number)
 	a := new(big.Int).Set(parent.GasLimit)
 	a = a.Sub(a, header.GasLimit)
 	a.Abs(a)
 	b := new(big.Int).Set(parent.GasLimit)
-	b = b.Div(b, GasLimitBoundDivisor)
-	if !(a.Cmp(b) < 0) || (header.GasLimit.Cmp(MinGasLimit) == -1) {
+	b = b.Div(b, boundDivisor)
+	if !(a.Cmp(b) < 0) {
 		return fmt.Errorf("GasLimit check failed for header %v (%v > %v)", header.GasLimit, a, b)
 	}
+	if header.GasLimit.Cmp(minGasLimit) < 0 {
+		return fmt.Errorf("GasLimit check failed for header %v (below minimum %v)", header.GasLimit, minGasLimit)
+	}
+	if err := verifyBaseFee(config, header, parent); err != nil {
+		return err
+	}
 
 	num := new(big.Int).Set(parent.Number)
 	num.Sub(header.Number, num)
@@ -375,17 +383,20 @@ func calcDifficultyDefused(time, parentTime uint64, parentNumber, parentDiff *bi
 	return x
 }
 
-// CalcGasLimit computes the gas limit of the next block after parent.
+// CalcGasLimit computes the gas limit of the next block after parent, within
+// the gas limit bounds of the chain.
 // The result may be modified by the caller.
 // This is miner strategy, not consensus protocol.
-func CalcGasLimit(parent *types.Block) *big.Int {
+func CalcGasLimit(config *ChainConfig, parent *types.Block) *big.Int {
+	minGasLimit, boundDivisor := config.GasLimitBounds(new(big.Int).Add(parent.Number(), common.Big1))
+
 	// contrib = (parentGasUsed * 3 / 2) / 1024
 	contrib := new(big.Int).Mul(parent.GasUsed(), big.NewInt(3))
 	contrib = contrib.Div(contrib, big.NewInt(2))
-	contrib = contrib.Div(contrib, GasLimitBoundDivisor)
+	contrib = contrib.Div(contrib, boundDivisor)
 
 	// decay = parentGasLimit / 1024 -1
-	decay := new(big.Int).Div(parent.GasLimit(), GasLimitBoundDivisor)
+	decay := new(big.Int).Div(parent.GasLimit(), boundDivisor)
 	decay.Sub(decay, big.NewInt(1))
 
 	/*
@@ -397,7 +408,7 @@ func CalcGasLimit(parent *types.Block) *big.Int {
 	*/
 	gl := new(big.Int).Sub(parent.GasLimit(), decay)
 	gl = gl.Add(gl, contrib)
-	gl.Set(common.BigMax(gl, MinGasLimit))
+	gl.Set(common.BigMax(gl, minGasLimit))
 
 	// however, if we're now below the target (TargetGasLimit) we increase the
 	// limit as much as we can (parentGasLimit / 1024 -1)
@@ -0,0 +1,152 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+)
+
+// BlockWitness is what executing a block reads besides the block itself: the
+// trie nodes and contract code of the parent state, and the headers of the
+// ancestors whose hashes are accessed. It lets the block be verified without
+// holding the state.
+type BlockWitness struct {
+	Number  uint64          `json:"number"`
+	Hash    common.Hash     `json:"hash"`
+	Headers []*types.Header `json:"headers"` // Parent first, down to the oldest ancestor accessed
+	Nodes   []hexutil.Bytes `json:"nodes"`
+	Codes   []hexutil.Bytes `json:"codes"`
+}
+
+// BuildWitness executes block on top of its parent state and records the
+// witness of the execution.
+func BuildWitness(bc *BlockChain, block *types.Block) (*BlockWitness, error) {
+	parent := bc.GetHeader(block.ParentHash())
+	if parent == nil {
+		return nil, fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	witness := state.NewWitness()
+	statedb, err := state.New(parent.Root, state.NewWitnessDatabase(bc.chainDb, witness))
+	if err != nil {
+		return nil, err
+	}
+	// Remember the oldest ancestor accessed, the headers down to it are needed
+	// to link its hash to the block.
+	oldest := parent.Number.Uint64()
+	chainHash := GetHashFn(block.ParentHash(), bc)
+	getHash := func(n uint64) common.Hash {
+		if n < oldest {
+			oldest = n
+		}
+		return chainHash(n)
+	}
+	if err := executeWitness(bc.Config(), block, statedb, getHash); err != nil {
+		return nil, err
+	}
+
+	res := &BlockWitness{Number: block.NumberU64(), Hash: block.Hash()}
+	for h := parent; ; h = bc.GetHeader(h.ParentHash) {
+		if h == nil {
+			return nil, fmt.Errorf("ancestor #%d of block %x not found", oldest, block.Hash())
+		}
+		res.Headers = append(res.Headers, h)
+		if h.Number.Uint64() <= oldest {
+			break
+		}
+	}
+	for _, node := range witness.Nodes() {
+		res.Nodes = append(res.Nodes, node)
+	}
+	for _, code := range witness.Codes() {
+		res.Codes = append(res.Codes, code)
+	}
+	return res, nil
+}
+
+// VerifyWitness executes block using only the state and the headers of w and
+// checks it arrives at the state root of the block.
+func VerifyWitness(config *ChainConfig, block *types.Block, w *BlockWitness) error {
+	if len(w.Headers) == 0 || w.Headers[0].Hash() != block.ParentHash() {
+		return fmt.Errorf("witness doesn't start with the parent of block %x", block.Hash())
+	}
+	hashes := make(map[uint64]common.Hash)
+	for i, h := range w.Headers {
+		if i > 0 && h.Hash() != w.Headers[i-1].ParentHash {
+			return fmt.Errorf("witness header #%d isn't the parent of #%d", h.Number, w.Headers[i-1].Number)
+		}
+		hashes[h.Number.Uint64()] = h.Hash()
+	}
+	witness := state.NewWitness()
+	for _, node := range w.Nodes {
+		witness.AddNode(node)
+	}
+	for _, code := range w.Codes {
+		witness.AddCode(code)
+	}
+	statedb, err := state.New(w.Headers[0].Root, state.NewWitnessDatabase(witness, nil))
+	if err != nil {
+		return err
+	}
+	return executeWitness(config, block, statedb, func(n uint64) common.Hash { return hashes[n] })
+}
+
+// executeWitness executes the transactions of block and its rewards, and
+// checks the gas used and the resulting state root.
+func executeWitness(config *ChainConfig, block *types.Block, statedb *state.StateDB, getHash func(uint64) common.Hash) error {
+	var (
+		header  = block.Header()
+		gp      = new(GasPool).AddGas(block.GasLimit())
+		usedGas = new(big.Int)
+	)
+	for i, tx := range block.Transactions() {
+		tx.SetSigner(config.GetSigner(block.Number()))
+		statedb.StartRecord(tx.Hash(), block.Hash(), i)
+
+		env := NewEnv(statedb, config, nil, tx, header)
+		env.getHashFn = getHash
+		_, gas, _, err := ApplyMessage(env, tx, gp)
+		if err != nil {
+			return fmt.Errorf("tx %x failed: %v", tx.Hash(), err)
+		}
+		usedGas.Add(usedGas, gas)
+
+		if config.IsReceiptStatus(block.Number()) {
+			statedb.Finalise(config.IsStateClear(block.Number()))
+		} else {
+			statedb.IntermediateRoot(config.IsStateClear(block.Number()))
+		}
+	}
+	AccumulateRewards(config, statedb, header, block.Uncles())
+
+	root := statedb.IntermediateRoot(config.IsStateClear(block.Number()))
+	if err := statedb.Error(); err != nil {
+		return err
+	}
+	if block.GasUsed().Cmp(usedGas) != 0 {
+		return fmt.Errorf("gas used mismatch: have %v, want %v", usedGas, block.GasUsed())
+	}
+	if root != block.Root() {
+		return fmt.Errorf("state root mismatch: have %x, want %x", root, block.Root())
+	}
+	return nil
+}
@@ -60,6 +60,7 @@ const (
 	bodyCacheLimit      = 256
 	tdCacheLimit        = 1024
 	blockCacheLimit     = 256
+	commitCacheLimit    = 256 * 1024
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
 	// must be bumped when consensus algorithm is changed, this forces the upgradedb
@@ -67,6 +68,17 @@ const (
 	BlockChainVersion = 3
 )
 
+// CacheConfig holds the memory allowances of a BlockChain.
+type CacheConfig struct {
+	TrieCleanLimit int // Bytes of recently read trie nodes and code kept in memory (0 = disabled)
+	ImportBatch    int // Bytes of chain and state data buffered before flushing during sync
+}
+
+// DefaultCacheConfig are the allowances of chains created by NewBlockChain.
+var DefaultCacheConfig = &CacheConfig{
+	ImportBatch: ethdb.ImportBatchSize,
+}
+
 // BlockChain represents the canonical chain given a database with a genesis
 // block. The Blockchain manages chain imports, reverts, chain reorganisations.
 //
@@ -82,7 +94,8 @@ const (
 // included in the canonical one where as GetBlockByNumber always represents the
 // canonical chain.
 type BlockChain struct {
-	config *ChainConfig // chain & network configuration
+	config      *ChainConfig // chain & network configuration
+	cacheConfig *CacheConfig // memory allowances of the caches and import batches
 
 	hc           *HeaderChain
 	chainDb      ethdb.Database
@@ -96,11 +109,13 @@ type BlockChain struct {
 	currentBlock     *types.Block // Current head of the block chain
 	currentFastBlock *types.Block // Current head of the fast-sync chain (may be above the block chain!)
 
-	stateCache   *state.StateDB // State database to reuse between imports (contains state cache)
-	bodyCache    *lru.Cache     // Cache for the most recent block bodies
-	bodyRLPCache *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
-	blockCache   *lru.Cache     // Cache for the most recent entire blocks
-	futureBlocks *lru.Cache     // future blocks are blocks added for later processing
+	cleanCache   *state.CleanCache  // Recently read trie nodes and code, shared by the states of the chain
+	stateCache   *state.StateDB     // State database to reuse between imports (contains state cache)
+	bodyCache    *lru.Cache         // Cache for the most recent block bodies
+	bodyRLPCache *lru.Cache         // Cache for the most recent block bodies in RLP encoded format
+	blockCache   *lru.Cache         // Cache for the most recent entire blocks
+	futureBlocks *lru.Cache         // future blocks are blocks added for later processing
+	commitCache  *state.CommitCache // Recently committed trie nodes, not written again
 
 	quit    chan struct{} // blockchain quit channel
 	running int32         // running must be called atomically
@@ -112,7 +127,14 @@ type BlockChain struct {
 	processor Processor // block processor interface
 	validator Validator // block and state validator interface
 
-	atxi *AtxiT
+	atxi       *AtxiT
+	tokenIndex ethdb.Database // Database token transfers are indexed in (not indexed if nil)
+
+	sideMu        sync.Mutex // Protects the side block index
+	sideRetention uint64     // Number of blocks below the head side blocks are kept for (not indexed if 0)
+
+	availMu     sync.Mutex // Protects the cached oldest state
+	oldestState uint64     // Oldest canonical block above the genesis with state (not known if 0)
 }
 
 type ChainInsertResult struct {
@@ -145,6 +167,12 @@ func (bc *BlockChain) GetBlockByHash(h common.Hash) *types.Block {
 // available in the database. It initialises the default Ethereum Validator and
 // Processor.
 func NewBlockChain(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux *event.TypeMux) (*BlockChain, error) {
+	return NewBlockChainWithCache(chainDb, config, DefaultCacheConfig, pow, mux)
+}
+
+// NewBlockChainWithCache returns a block chain like NewBlockChain, which uses the
+// given memory allowances instead of the defaults.
+func NewBlockChainWithCache(chainDb ethdb.Database, config *ChainConfig, cacheConfig *CacheConfig, pow pow.PoW, mux *event.TypeMux) (*BlockChain, error) {
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
@@ -152,13 +180,16 @@ func NewBlockChain(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux
 
 	bc := &BlockChain{
 		config:       config,
+		cacheConfig:  cacheConfig,
 		chainDb:      chainDb,
+		cleanCache:   state.NewCleanCache(cacheConfig.TrieCleanLimit),
 		eventMux:     mux,
 		quit:         make(chan struct{}),
 		bodyCache:    bodyCache,
 		bodyRLPCache: bodyRLPCache,
 		blockCache:   blockCache,
 		futureBlocks: futureBlocks,
+		commitCache:  state.NewCommitCache(commitCacheLimit),
 		pow:          pow,
 	}
 	bc.SetValidator(NewBlockValidator(config, bc, pow))
@@ -188,6 +219,8 @@ func NewBlockChain(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux
 		}
 	}
 	// Take ownership of this particular state
+	bc.registerAvailabilityMetrics()
+
 	go bc.update()
 	return bc, nil
 }
@@ -200,6 +233,7 @@ func NewBlockChainDryrun(chainDb ethdb.Database, config *ChainConfig, pow pow.Po
 
 	bc := &BlockChain{
 		config:       config,
+		cacheConfig:  DefaultCacheConfig,
 		chainDb:      chainDb,
 		eventMux:     mux,
 		quit:         make(chan struct{}),
@@ -207,6 +241,7 @@ func NewBlockChainDryrun(chainDb ethdb.Database, config *ChainConfig, pow pow.Po
 		bodyRLPCache: bodyRLPCache,
 		blockCache:   blockCache,
 		futureBlocks: futureBlocks,
+		commitCache:  state.NewCommitCache(commitCacheLimit),
 		pow:          pow,
 	}
 	bc.SetValidator(NewBlockValidator(config, bc, pow))
@@ -255,6 +290,17 @@ func (bc *BlockChain) GetAtxi() *AtxiT {
 	return bc.atxi
 }
 
+// SetTokenIndex sets the database the token transfers of imported blocks are
+// indexed in, disabling the index if nil.
+func (bc *BlockChain) SetTokenIndex(db ethdb.Database) {
+	bc.tokenIndex = db
+}
+
+// GetTokenIndex returns the token transfer index database, nil if disabled.
+func (bc *BlockChain) GetTokenIndex() ethdb.Database {
+	return bc.tokenIndex
+}
+
 func (bc *BlockChain) getProcInterrupt() bool {
 	return atomic.LoadInt32(&bc.procInterrupt) == 1
 }
@@ -725,7 +771,7 @@ func (bc *BlockChain) LoadLastState(dryrun bool) error {
 	}
 
 	// Initialize a statedb cache to ensure singleton account bloom filter generation
-	statedb, err := state.New(bc.currentBlock.Root(), state.NewDatabase(bc.chainDb))
+	statedb, err := state.New(bc.currentBlock.Root(), state.NewDatabaseWithCache(bc.chainDb, bc.cleanCache))
 	if err != nil {
 		return err
 	}
@@ -783,6 +829,13 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.hc.SetHead(head, delFn)
 	currentHeader := bc.hc.CurrentHeader()
 
+	// Blocks above the new head are no longer frozen, they were deleted
+	if frozen, ok := GetFrozenBlock(bc.chainDb); ok && frozen > currentHeader.Number.Uint64() {
+		if err := WriteFrozenBlock(bc.chainDb, currentHeader.Number.Uint64()); err != nil {
+			glog.Fatalf("failed to rewind frozen block number: %v", err)
+		}
+	}
+
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
@@ -866,7 +919,14 @@ func (bc *BlockChain) SetHead(head uint64) error {
 		}
 	}
 
+	if bc.tokenIndex != nil {
+		if err := deleteTokenTransfersAbove(bc.tokenIndex, head); err != nil {
+			glog.Fatalf("failed to rewind token transfer index: %v", err)
+		}
+	}
+
 	bc.mu.Unlock()
+	bc.resetOldestState()
 	return bc.LoadLastState(false)
 }
 
@@ -886,6 +946,11 @@ func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
 	bc.currentBlock = block
 	bc.mu.Unlock()
 
+	if err := WriteFastSyncPivot(bc.chainDb, block.NumberU64()); err != nil {
+		return err
+	}
+	bc.resetOldestState()
+
 	glog.V(logger.Info).Infof("committed block #%d [%x…] as new head", block.Number(), hash[:4])
 	return nil
 }
@@ -970,7 +1035,7 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 
 // StateAt returns a new mutable state based on a particular point in time.
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	return state.New(root, state.NewDatabase(bc.chainDb))
+	return state.New(root, state.NewDatabaseWithCache(bc.chainDb, bc.cleanCache))
 }
 
 // Reset purges the entire blockchain, restoring it to its genesis state.
@@ -1056,6 +1121,7 @@ func (bc *BlockChain) insert(block *types.Block) {
 		glog.Fatalf("failed to insert head block hash: %v", err)
 	}
 	bc.currentBlock = block
+	bc.freeze(block.NumberU64())
 
 	// If the block is better than our head or is on a different chain, force update heads
 	if updateHeads {
@@ -1245,6 +1311,17 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 
 	errs, failed := make([]error, len(tasks)), int32(0)
 	process := func(worker int) {
+		// Buffer the block data of each worker, flushing it in large batches
+		// which share the import batch allowance
+		batch, limit := bc.chainDb.NewBatch(), bc.cacheConfig.ImportBatch/runtime.GOMAXPROCS(0)
+		defer func() {
+			if atomic.LoadInt32(&bc.procInterrupt) == 1 || batch.ValueSize() == 0 {
+				return
+			}
+			if err := batch.Write(); err != nil {
+				glog.Fatalf("failed to write block data: %v", err)
+			}
+		}()
 		for index := range tasks {
 			block, receipts := blockChain[index], receiptChain[index]
 
@@ -1273,10 +1350,10 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 				// The transaction hash can be retrieved from the transaction itbc
 				receipts[j].TxHash = transactions[j].Hash()
 				tx := transactions[j]
-				from, _ := types.Sender(signer, tx)
 
 				// The contract address can be derived from the transaction itbc
-				if MessageCreatesContract(transactions[j]) {
+				if MessageCreatesContract(tx) {
+					from, _ := types.Sender(signer, tx)
 					receipts[j].ContractAddress = crypto.CreateAddress(from, tx.Nonce())
 				}
 				// The used gas can be calculated based on previous receipts
@@ -1296,13 +1373,13 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 				}
 			}
 			// Write all the data out into the database
-			if err := WriteBody(bc.chainDb, block.Hash(), block.Body()); err != nil {
+			if err := WriteBody(batch, block.Hash(), block.Body()); err != nil {
 				errs[index] = fmt.Errorf("failed to write block body: %v", err)
 				atomic.AddInt32(&failed, 1)
 				glog.Fatal(errs[index])
 				return
 			}
-			if err := WriteBlockReceipts(bc.chainDb, block.Hash(), receipts); err != nil {
+			if err := WriteBlockReceipts(batch, block.Hash(), receipts); err != nil {
 				errs[index] = fmt.Errorf("failed to write block receipts: %v", err)
 				atomic.AddInt32(&failed, 1)
 				glog.Fatal(errs[index])
@@ -1314,18 +1391,27 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 				glog.Fatal(errs[index])
 				return
 			}
-			if err := WriteTransactions(bc.chainDb, block); err != nil {
+			if err := PutTransactions(batch, block); err != nil {
 				errs[index] = fmt.Errorf("failed to write individual transactions: %v", err)
 				atomic.AddInt32(&failed, 1)
 				glog.Fatal(errs[index])
 				return
 			}
-			if err := WriteReceipts(bc.chainDb, receipts); err != nil {
+			if err := PutReceipts(batch, receipts); err != nil {
 				errs[index] = fmt.Errorf("failed to write individual receipts: %v", err)
 				atomic.AddInt32(&failed, 1)
 				glog.Fatal(errs[index])
 				return
 			}
+			if batch.ValueSize() >= limit {
+				if err := batch.Write(); err != nil {
+					errs[index] = fmt.Errorf("failed to write block data: %v", err)
+					atomic.AddInt32(&failed, 1)
+					glog.Fatal(errs[index])
+					return
+				}
+				batch = bc.chainDb.NewBatch()
+			}
 			// Store the addr-tx indexes if enabled
 			if bc.atxi != nil {
 				if err := WriteBlockAddTxIndexes(bc.atxi.Db, block); err != nil {
@@ -1340,6 +1426,11 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 					}
 				}
 			}
+			if bc.tokenIndex != nil {
+				if err := WriteBlockTokenTransfers(bc.tokenIndex, receipts); err != nil {
+					glog.Fatalf("failed to write block token transfers, err: %v", err)
+				}
+			}
 			atomic.AddInt32(&stats.processed, 1)
 		}
 	}
@@ -1440,7 +1531,18 @@ func (bc *BlockChain) WriteBlockAddrTxIndexesBatch(indexDb ethdb.Database, start
 }
 
 // WriteBlock writes the block to the chain.
-func (bc *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err error) {
+func (bc *BlockChain) WriteBlock(block *types.Block) (WriteStatus, error) {
+	status, removedLogs, rebirthLogs, err := bc.writeBlock(block)
+	if len(removedLogs) > 0 || len(rebirthLogs) > 0 {
+		go bc.postReorgLogs(removedLogs, rebirthLogs)
+	}
+	return status, err
+}
+
+// writeBlock writes the block to the chain, returning the logs a reorganisation
+// removed from the canonical chain and those it made canonical again, which the
+// caller has to post.
+func (bc *BlockChain) writeBlock(block *types.Block) (status WriteStatus, removedLogs, rebirthLogs vm.Logs, err error) {
 
 	if logger.MlogEnabled() {
 		defer func() {
@@ -1482,7 +1584,7 @@ func (bc *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err er
 	// Calculate the total difficulty of the block
 	ptd := bc.GetTd(block.ParentHash())
 	if ptd == nil {
-		return NonStatTy, ParentError(block.ParentHash())
+		return NonStatTy, nil, nil, ParentError(block.ParentHash())
 	}
 	// Make sure no inconsistent state is leaked during insertion
 	bc.mu.Lock()
@@ -1509,8 +1611,8 @@ func (bc *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err er
 	if reorg {
 		// Reorganise the chain if the parent is not the head block
 		if block.ParentHash() != bc.currentBlock.Hash() {
-			if err := bc.reorg(bc.currentBlock, block); err != nil {
-				return NonStatTy, err
+			if removedLogs, rebirthLogs, err = bc.reorg(bc.currentBlock, block); err != nil {
+				return NonStatTy, nil, nil, err
 			}
 		}
 		bc.insert(block) // Insert the block as the new head of the chain
@@ -1525,6 +1627,11 @@ func (bc *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err er
 	if err := WriteBlock(bc.chainDb, block); err != nil {
 		glog.Fatalf("failed to write block contents: %v", err)
 	}
+	if status == SideStatTy {
+		bc.indexSideBlock(block)
+	} else {
+		bc.pruneSideBlocks(block.NumberU64())
+	}
 
 	bc.futureBlocks.Remove(block.Hash())
 
@@ -1561,6 +1668,7 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 		stats         struct{ queued, processed, ignored int }
 		events        = make([]interface{}, 0, len(chain))
 		coalescedLogs vm.Logs
+		removedLogs   vm.Logs
 		tstart        = time.Now()
 
 		nonceChecked = make([]bool, len(chain))
@@ -1570,6 +1678,18 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 	nonceAbort, nonceResults := verifyNoncesFromBlocks(bc.pow, chain)
 	defer close(nonceAbort)
 
+	// Transactions and receipts of canonical blocks are only read by lookups,
+	// so they're buffered across blocks and flushed in large batches.
+	lookupBatch := bc.chainDb.NewBatch()
+	defer func() {
+		if lookupBatch.ValueSize() == 0 {
+			return
+		}
+		if err := lookupBatch.Write(); err != nil && res.Error == nil {
+			res.Error = err
+		}
+	}()
+
 	txcount := 0
 	for i, block := range chain {
 		res.Index = i
@@ -1654,46 +1774,71 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 			res.Error = err
 			return
 		}
-		// Write state changes to database
-		_, err = bc.stateCache.CommitTo(bc.chainDb, bc.config.IsAtlantis(block.Number()))
+		// Write state changes and receipts to database in a single batch, the
+		// next block's state is opened from it
+		blockBatch := bc.chainDb.NewBatch()
+		stateWriter := bc.commitCache.NewWriter(blockBatch)
+		_, err = bc.stateCache.CommitTo(stateWriter, bc.config.IsStateClear(block.Number()))
 		if err != nil {
 			res.Error = err
 			return
 		}
 
-		// coalesce logs for later processing
-		coalescedLogs = append(coalescedLogs, logs...)
-
-		if err := WriteBlockReceipts(bc.chainDb, block.Hash(), receipts); err != nil {
+		if err := WriteBlockReceipts(blockBatch, block.Hash(), receipts); err != nil {
 			res.Error = err
 			return
 		}
+		if err := WriteBlockContractCreations(blockBatch, block, bc.stateCache); err != nil {
+			res.Error = err
+			return
+		}
+		if err := WriteBlockRevertData(blockBatch, block.Hash(), receipts); err != nil {
+			res.Error = err
+			return
+		}
+		if err := blockBatch.Write(); err != nil {
+			res.Error = err
+			return
+		}
+		stateWriter.Flush()
 
 		txcount += len(block.Transactions())
 		// write the block to the chain and get the status
-		status, err := bc.WriteBlock(block)
+		status, removed, rebirth, err := bc.writeBlock(block)
 		if err != nil {
 			res.Error = err
 			return
 		}
+		// coalesce logs for later processing, those of blocks a reorg brought
+		// back onto the canonical chain ahead of the block's own
+		removedLogs = append(removedLogs, removed...)
+		coalescedLogs = append(coalescedLogs, rebirth...)
 
 		switch status {
 		case CanonStatTy:
+			coalescedLogs = append(coalescedLogs, logs...)
 			if glog.V(logger.Debug) {
 				glog.Infof("[%v] inserted block #%d (%d TXs %v G %d UNCs) [%s]. Took %v\n", time.Now().UnixNano(), block.Number(), len(block.Transactions()), block.GasUsed(), len(block.Uncles()), block.Hash().Hex(), time.Since(bstart))
 			}
 			events = append(events, ChainEvent{block, block.Hash(), logs})
 
 			// This puts transactions in a extra db for rpc
-			if err := WriteTransactions(bc.chainDb, block); err != nil {
+			if err := PutTransactions(lookupBatch, block); err != nil {
 				res.Error = err
 				return
 			}
 			// store the receipts
-			if err := WriteReceipts(bc.chainDb, receipts); err != nil {
+			if err := PutReceipts(lookupBatch, receipts); err != nil {
 				res.Error = err
 				return
 			}
+			if lookupBatch.ValueSize() >= bc.cacheConfig.ImportBatch {
+				if err := lookupBatch.Write(); err != nil {
+					res.Error = err
+					return
+				}
+				lookupBatch = bc.chainDb.NewBatch()
+			}
 			// Write map map bloom filters
 			if err := WriteMipmapBloom(bc.chainDb, block.NumberU64(), receipts); err != nil {
 				res.Error = err
@@ -1715,6 +1860,12 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 					}
 				}
 			}
+			if bc.tokenIndex != nil {
+				if err := WriteBlockTokenTransfers(bc.tokenIndex, receipts); err != nil {
+					res.Error = fmt.Errorf("failed to write block token transfers: %v", err)
+					return
+				}
+			}
 		case SideStatTy:
 			if glog.V(logger.Detail) {
 				glog.Infof("inserted forked block #%d (TD=%v) (%d TXs %d UNCs) [%s]. Took %v\n", block.Number(), block.Difficulty(), len(block.Transactions()), len(block.Uncles()), block.Hash().Hex(), time.Since(bstart))
@@ -1766,15 +1917,24 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 			start.Hash().Hex(),
 			end.Hash().Hex())
 	}
-	go bc.postChainEvents(events, coalescedLogs)
+	// Make the inserted transactions available before announcing them
+	if lookupBatch.ValueSize() > 0 {
+		if err := lookupBatch.Write(); err != nil {
+			r.Error = err
+			return r
+		}
+		lookupBatch = bc.chainDb.NewBatch()
+	}
+	go bc.postChainEvents(events, removedLogs, coalescedLogs)
 
 	return r
 }
 
 // reorgs takes two blocks, an old chain and a new chain and will reconstruct the blocks and inserts them
 // to be part of the new canonical chain and accumulates potential missing transactions and post an
-// event about them
-func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
+// event about them. It returns the logs of the old chain, newest block first, and those of the new
+// chain up to but excluding newBlock, oldest block first.
+func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) (deletedLogs, rebirthLogs vm.Logs, err error) {
 	var (
 		newChain          types.Blocks
 		oldChain          types.Blocks
@@ -1782,7 +1942,6 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		oldStart          = oldBlock
 		newStart          = newBlock
 		deletedTxs        types.Transactions
-		deletedLogs       vm.Logs
 		deletedLogsByHash = make(map[common.Hash]vm.Logs)
 		// collectLogs collects the logs that were generated during the
 		// processing of the block that corresponds with the given hash.
@@ -1793,7 +1952,7 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			for _, receipt := range receipts {
 				deletedLogs = append(deletedLogs, receipt.Logs...)
 
-				deletedLogsByHash[h] = receipt.Logs
+				deletedLogsByHash[h] = append(deletedLogsByHash[h], receipt.Logs...)
 			}
 		}
 	)
@@ -1814,10 +1973,10 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		}
 	}
 	if oldBlock == nil {
-		return fmt.Errorf("Invalid old chain")
+		return nil, nil, fmt.Errorf("Invalid old chain")
 	}
 	if newBlock == nil {
-		return fmt.Errorf("Invalid new chain")
+		return nil, nil, fmt.Errorf("Invalid new chain")
 	}
 
 	numSplit := newBlock.Number()
@@ -1834,10 +1993,10 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 
 		oldBlock, newBlock = bc.GetBlock(oldBlock.ParentHash()), bc.GetBlock(newBlock.ParentHash())
 		if oldBlock == nil {
-			return fmt.Errorf("Invalid old chain")
+			return nil, nil, fmt.Errorf("Invalid old chain")
 		}
 		if newBlock == nil {
-			return fmt.Errorf("Invalid new chain")
+			return nil, nil, fmt.Errorf("Invalid new chain")
 		}
 	}
 
@@ -1860,12 +2019,21 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		for _, block := range oldChain {
 			for _, tx := range block.Transactions() {
 				if err := RmAddrTx(bc.atxi.Db, tx); err != nil {
-					return err
+					return nil, nil, err
 				}
 			}
 		}
 	}
 
+	// Likewise the token transfers of the old chain
+	if bc.tokenIndex != nil {
+		for _, block := range oldChain {
+			if err := DeleteBlockTokenTransfers(bc.tokenIndex, GetBlockReceipts(bc.chainDb, block.Hash())); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
 	var addedTxs types.Transactions
 	// insert blocks. Order does not matter. Last block will be written in ImportChain itbc which creates the new head properly
 	for _, block := range newChain {
@@ -1873,30 +2041,35 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		bc.insert(block)
 		// write canonical receipts and transactions
 		if err := WriteTransactions(bc.chainDb, block); err != nil {
-			return err
+			return nil, nil, err
 		}
 		// Store the addr-tx indexes if enabled
 		if bc.atxi != nil {
 			if err := WriteBlockAddTxIndexes(bc.atxi.Db, block); err != nil {
-				return err
+				return nil, nil, err
 			}
 			// if buildATXI has been in use (via RPC) and is NOT finished, current < stop
 			// if buildATXI has been in use (via RPC) and IS finished, current == stop
 			// else if builtATXI has not been in use (via RPC), then current == stop == 0
 			if bc.atxi.AutoMode && bc.atxi.Progress.Current == bc.atxi.Progress.Stop {
 				if err := bc.atxi.SetATXIBookmark(block.NumberU64()); err != nil {
-					return err
+					return nil, nil, err
 				}
 			}
 		}
 		receipts := GetBlockReceipts(bc.chainDb, block.Hash())
 		// write receipts
 		if err := WriteReceipts(bc.chainDb, receipts); err != nil {
-			return err
+			return nil, nil, err
+		}
+		if bc.tokenIndex != nil {
+			if err := WriteBlockTokenTransfers(bc.tokenIndex, receipts); err != nil {
+				return nil, nil, err
+			}
 		}
 		// Write map map bloom filters
 		if err := WriteMipmapBloom(bc.chainDb, block.NumberU64(), receipts); err != nil {
-			return err
+			return nil, nil, err
 		}
 		addedTxs = append(addedTxs, block.Transactions()...)
 	}
@@ -1914,10 +2087,9 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	if len(diff) > 0 {
 		go bc.eventMux.Post(RemovedTransactionEvent{diff})
 	}
-	if len(deletedLogs) > 0 {
-		go bc.eventMux.Post(RemovedLogsEvent{deletedLogs})
+	for _, block := range oldChain {
+		bc.indexSideBlock(block)
 	}
-
 	if len(oldChain) > 0 {
 		go func() {
 			for _, block := range oldChain {
@@ -1925,14 +2097,23 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 			}
 		}()
 	}
-
-	return nil
+	// The logs of newBlock itself are announced by whoever writes it.
+	for i := len(newChain) - 1; i > 0; i-- {
+		for _, receipt := range GetBlockReceipts(bc.chainDb, newChain[i].Hash()) {
+			rebirthLogs = append(rebirthLogs, receipt.Logs...)
+		}
+	}
+	return deletedLogs, rebirthLogs, nil
 }
 
 // postChainEvents iterates over the events generated by a chain insertion and
 // posts them into the event mux.
-func (bc *BlockChain) postChainEvents(events []interface{}, logs vm.Logs) {
-	// post event logs for further processing
+func (bc *BlockChain) postChainEvents(events []interface{}, removedLogs, logs vm.Logs) {
+	// post event logs for further processing, removals first so subscribers
+	// see logs of a reorganised chain revoked before the new ones
+	if len(removedLogs) > 0 {
+		bc.eventMux.Post(RemovedLogsEvent{removedLogs})
+	}
 	bc.eventMux.Post(logs)
 	for _, event := range events {
 		if event, ok := event.(ChainEvent); ok {
@@ -1947,6 +2128,17 @@ func (bc *BlockChain) postChainEvents(events []interface{}, logs vm.Logs) {
 	}
 }
 
+// postReorgLogs announces the logs removed by a reorganisation followed by the
+// logs of the blocks that replaced them.
+func (bc *BlockChain) postReorgLogs(removedLogs, rebirthLogs vm.Logs) {
+	if len(removedLogs) > 0 {
+		bc.eventMux.Post(RemovedLogsEvent{removedLogs})
+	}
+	if len(rebirthLogs) > 0 {
+		bc.eventMux.Post(rebirthLogs)
+	}
+}
+
 func (bc *BlockChain) update() {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
@@ -2001,6 +2193,17 @@ func (bc *BlockChain) InsertHeaderChain(chain []*types.Header, checkFreq int) *H
 	return bc.hc.InsertHeaderChain(chain, checkFreq, whFunc)
 }
 
+// ValidateHeaderChain verifies a header chain attached to parent without
+// importing it, checking one seal out of every checkFreq headers. If parent is
+// nil, it is looked up locally. It returns the index of the first invalid
+// header.
+func (bc *BlockChain) ValidateHeaderChain(parent *types.Header, chain []*types.Header, checkFreq int) (int, error) {
+	bc.wg.Add(1)
+	defer bc.wg.Done()
+
+	return bc.hc.ValidateHeaderChain(parent, chain, checkFreq)
+}
+
 // CurrentHeader retrieves the current head header of the canonical chain. The
 // header is retrieved from the HeaderChain's internal cache.
 func (bc *BlockChain) CurrentHeader() *types.Header {
@@ -70,7 +70,8 @@ func GenesisBlockForTesting(db ethdb.Database, addr common.Address, balance *big
 }
 
 func theBlockChain(db ethdb.Database, t *testing.T) *BlockChain {
-	pow, err := cryptonight.NewForTesting()
+	morden := DefaultConfigMorden.ChainConfig
+	pow, err := cryptonight.NewForTesting(morden.GetLYRA2Block(), morden.GetLYRA2v2Block())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -212,7 +213,7 @@ func loadChain(fn string, t *testing.T) (types.Blocks, error) {
 func insertChain(done chan bool, blockchain *BlockChain, chain types.Blocks, t *testing.T) {
 	res := blockchain.InsertChain(chain)
 	if res.Error != nil {
-		t.Fatal(res.Error)
+		t.Error(res.Error)
 	}
 	done <- true
 }
@@ -502,6 +503,8 @@ func chm(t testing.TB, genesis *types.Block, db ethdb.Database) *BlockChain {
 		eventMux:     &eventMux,
 		pow:          FakePow{},
 		config:       config,
+		cacheConfig:  DefaultCacheConfig,
+		commitCache:  state.NewCommitCache(commitCacheLimit),
 	}
 	valFn := func() HeaderValidator { return bc.Validator() }
 	var err error
@@ -889,6 +892,44 @@ func TestFastVsFullChains(t *testing.T) {
 	}
 }
 
+// Tests that fast importing receipts derives the contract addresses of contract
+// creations, which aren't sent over the network.
+func TestReceiptChainContractAddress(t *testing.T) {
+	gendb, _ := ethdb.NewMemDatabase()
+	key, _ := crypto.GenerateKey()
+	var (
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		funds   = big.NewInt(1000000000)
+		genesis = WriteGenesisBlockForTesting(gendb, GenesisAccount{address, funds})
+		config  = DefaultConfigMorden.ChainConfig
+	)
+	blocks, receipts := GenerateChain(config, genesis, gendb, 1, func(i int, block *BlockGen) {
+		tx, _ := types.NewContractCreation(block.TxNonce(address), new(big.Int), big.NewInt(100000), new(big.Int), []byte{0x00}).SignECDSA(key)
+		block.AddTx(tx)
+	})
+	want := crypto.CreateAddress(address, 0)
+	receipts[0][0].ContractAddress = common.Address{}
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+	fast, err := NewBlockChain(db, config, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := fast.InsertHeaderChain([]*types.Header{blocks[0].Header()}, 1); res.Error != nil {
+		t.Fatalf("failed to insert header: %v", res.Error)
+	}
+	if res := fast.InsertReceiptChain(blocks, receipts); res.Error != nil {
+		t.Fatalf("failed to insert receipts: %v", res.Error)
+	}
+	if have := GetBlockReceipts(db, blocks[0].Hash())[0].ContractAddress; have != want {
+		t.Errorf("contract address mismatch: have %x, want %x", have, want)
+	}
+	if have := GetReceipt(db, blocks[0].Transactions()[0].Hash()).ContractAddress; have != want {
+		t.Errorf("stored receipt contract address mismatch: have %x, want %x", have, want)
+	}
+}
+
 func TestFastVsFullChainsATXI(t *testing.T) {
 	archiveDir, e := ioutil.TempDir("", "archive-")
 	if e != nil {
@@ -1477,6 +1518,119 @@ func TestLogReorgs(t *testing.T) {
 	}
 }
 
+func TestLogRebirthDeepReorg(t *testing.T) {
+	// See TestLogReorgs.
+	if UseSputnikVM == "true" {
+		return
+	}
+
+	MinGasLimit = big.NewInt(125000)
+
+	key1, err := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr1 := crypto.PubkeyToAddress(key1.PublicKey)
+	// this code generates a log
+	code := common.Hex2Bytes("60606040525b7f24ec1d3ff24c2f6ff210738839dbc339cd45a5294d85c79361016243157aae7b60405180905060405180910390a15b600a8060416000396000f360606040526008565b00")
+	db, err := ethdb.NewMemDatabase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis := WriteGenesisBlockForTesting(db,
+		GenesisAccount{addr1, big.NewInt(10000000000000)},
+	)
+	chainConfig := testChainConfig()
+
+	evmux := &event.TypeMux{}
+	blockchain, err := NewBlockChain(db, chainConfig, FakePow{}, evmux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs := evmux.Subscribe(RemovedLogsEvent{}, vm.Logs(nil))
+	defer subs.Unsubscribe()
+
+	makeChain := func(n int, coinbase common.Address) types.Blocks {
+		chain, _ := GenerateChain(chainConfig, genesis, db, n, func(i int, gen *BlockGen) {
+			gen.SetCoinbase(coinbase)
+			tx, err := types.NewContractCreation(gen.TxNonce(addr1), new(big.Int), big.NewInt(1000000), new(big.Int), code).WithSigner(chainConfig.GetSigner(gen.Number())).SignECDSA(key1)
+			if err != nil {
+				t.Fatalf("failed to create tx: %v", err)
+			}
+			gen.AddTx(tx)
+		})
+		return chain
+	}
+	// nextLogs returns the logs of the next event, and whether they were removed.
+	nextLogs := func() (vm.Logs, bool) {
+		for {
+			select {
+			case ev := <-subs.Chan():
+				switch ev := ev.Data.(type) {
+				case RemovedLogsEvent:
+					return ev.Logs, true
+				case vm.Logs:
+					if len(ev) > 0 {
+						return ev, false
+					}
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for logs")
+			}
+		}
+	}
+
+	oldChain := makeChain(3, common.Address{0x01})
+	if res := blockchain.InsertChain(oldChain); res.Error != nil {
+		t.Fatalf("failed to insert chain: %v", res.Error)
+	}
+	if logs, removed := nextLogs(); removed || len(logs) != len(oldChain) {
+		t.Fatalf("old chain: got %d logs (removed %v), want %d", len(logs), removed, len(oldChain))
+	}
+
+	// Import the start of the new chain as a side chain first, its logs must
+	// only be announced once it becomes canonical.
+	newChain := makeChain(6, common.Address{0x02})
+	if res := blockchain.InsertChain(newChain[:2]); res.Error != nil {
+		t.Fatalf("failed to insert side chain: %v", res.Error)
+	}
+	if res := blockchain.InsertChain(newChain[2:]); res.Error != nil {
+		t.Fatalf("failed to insert forked chain: %v", res.Error)
+	}
+	// All logs of the old chain must be revoked before any log of the new
+	// chain is announced.
+	logs, removed := nextLogs()
+	if !removed {
+		t.Fatal("new chain logs announced before the old chain's were removed")
+	}
+	if len(logs) != len(oldChain) {
+		t.Fatalf("removed %d logs, want %d", len(logs), len(oldChain))
+	}
+	for _, log := range logs {
+		if block := oldChain[log.BlockNumber-1]; log.BlockHash != block.Hash() {
+			t.Errorf("removed log of block %x, want old chain block %x", log.BlockHash, block.Hash())
+		}
+	}
+	// Every block of the new chain must have its log announced exactly once,
+	// in chain order.
+	var rebirth vm.Logs
+	for len(rebirth) < len(newChain) {
+		logs, removed := nextLogs()
+		if removed {
+			t.Fatalf("unexpected removal of %d logs", len(logs))
+		}
+		rebirth = append(rebirth, logs...)
+	}
+	if len(rebirth) != len(newChain) {
+		t.Fatalf("announced %d logs, want %d", len(rebirth), len(newChain))
+	}
+	for i, log := range rebirth {
+		if block := newChain[i]; log.BlockHash != block.Hash() || log.BlockNumber != block.NumberU64() {
+			t.Errorf("log %d: have block #%d %x, want #%d %x", i, log.BlockNumber, log.BlockHash, block.NumberU64(), block.Hash())
+		}
+	}
+}
+
 func TestReorgSideEvent(t *testing.T) {
 	// This test itself is a little bit incorrect. Below,
 	// MakeDiehardChainConfig would make a chain configuration that
@@ -1601,14 +1755,17 @@ func TestCanonicalBlockRetrieval(t *testing.T) {
 					continue // busy wait for canonical hash to be written
 				}
 				if ch != block.Hash() {
-					t.Fatalf("unknown canonical hash, want %s, got %s", block.Hash().Hex(), ch.Hex())
+					t.Errorf("unknown canonical hash, want %s, got %s", block.Hash().Hex(), ch.Hex())
+					return
 				}
 				fb := GetBlock(db, ch)
 				if fb == nil {
-					t.Fatalf("unable to retrieve block %d for canonical hash: %s", block.NumberU64(), ch.Hex())
+					t.Errorf("unable to retrieve block %d for canonical hash: %s", block.NumberU64(), ch.Hex())
+					return
 				}
 				if fb.Hash() != block.Hash() {
-					t.Fatalf("invalid block hash for block %d, want %s, got %s", block.NumberU64(), block.Hash().Hex(), fb.Hash().Hex())
+					t.Errorf("invalid block hash for block %d, want %s, got %s", block.NumberU64(), block.Hash().Hex(), fb.Hash().Hex())
+					return
 				}
 				return
 			}
@@ -1641,13 +1798,13 @@ func TestEIP155Transition(t *testing.T) {
 						{
 							ID: "difficulty",
 							Options: ChainFeatureConfigOptions{
-								"type": "homestead",
+								"type": "defused",
 							},
 						},
 						{
 							ID: "gastable",
 							Options: ChainFeatureConfigOptions{
-								"type": "homestead",
+								"type": "eip160",
 							},
 						},
 					},
@@ -1662,17 +1819,16 @@ func TestEIP155Transition(t *testing.T) {
 								"chainID": 1,
 							},
 						},
-						{ // ecip1010 bomb delay
+						{
 							ID: "gastable",
 							Options: ChainFeatureConfigOptions{
 								"type": "eip160",
 							},
 						},
-						{ // ecip1010 bomb delay
+						{
 							ID: "difficulty",
 							Options: ChainFeatureConfigOptions{
-								"type":   "ecip1010",
-								"length": 2000000,
+								"type": "defused",
 							},
 						},
 					},
@@ -1710,7 +1866,7 @@ func TestEIP155Transition(t *testing.T) {
 			}
 			block.AddTx(tx)
 
-			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID()))
+			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID(nil)))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1722,7 +1878,7 @@ func TestEIP155Transition(t *testing.T) {
 			}
 			block.AddTx(tx)
 
-			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID()))
+			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID(nil)))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1759,13 +1915,13 @@ func TestEIP155Transition(t *testing.T) {
 					{
 						ID: "difficulty",
 						Options: ChainFeatureConfigOptions{
-							"type": "homestead",
+							"type": "defused",
 						},
 					},
 					{
 						ID: "gastable",
 						Options: ChainFeatureConfigOptions{
-							"type": "homestead",
+							"type": "eip160",
 						},
 					},
 				},
@@ -1780,17 +1936,16 @@ func TestEIP155Transition(t *testing.T) {
 							"chainID": 2,
 						},
 					},
-					{ // ecip1010 bomb delay
+					{
 						ID: "gastable",
 						Options: ChainFeatureConfigOptions{
 							"type": "eip160",
 						},
 					},
-					{ // ecip1010 bomb delay
+					{
 						ID: "difficulty",
 						Options: ChainFeatureConfigOptions{
-							"type":   "ecip1010",
-							"length": 2000000,
+							"type": "defused",
 						},
 					},
 				},
@@ -1809,7 +1964,7 @@ func TestEIP155Transition(t *testing.T) {
 		)
 		switch i {
 		case 0:
-			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID()))
+			tx, err = basicTx(types.NewChainIdSigner(config.GetChainID(nil)))
 			if err != nil {
 				t.Fatal(err)
 			}
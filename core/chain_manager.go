@@ -44,7 +44,46 @@ func MakeChainConfig() *ChainConfig {
 					{
 						ID: "difficulty",
 						Options: ChainFeatureConfigOptions{
-							"type": "homestead",
+							"type": "defused",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// MakeDiehardChainConfig returns a new ChainConfig with EIP-155 replay
+// protection for chain id 63 and the EIP-160 gas table from the genesis block.
+func MakeDiehardChainConfig() *ChainConfig {
+	return &ChainConfig{
+		Forks: []*Fork{
+			{
+				Name:  "Homestead",
+				Block: big.NewInt(0),
+				Features: []*ForkFeature{
+					{
+						ID: "difficulty",
+						Options: ChainFeatureConfigOptions{
+							"type": "defused",
+						},
+					},
+				},
+			},
+			{
+				Name:  "Diehard",
+				Block: big.NewInt(0),
+				Features: []*ForkFeature{
+					{
+						ID: "eip155",
+						Options: ChainFeatureConfigOptions{
+							"chainID": 63,
+						},
+					},
+					{
+						ID: "gastable",
+						Options: ChainFeatureConfigOptions{
+							"type": "eip160",
 						},
 					},
 				},
@@ -174,7 +213,7 @@ func (b *BlockGen) OffsetTime(seconds int64) {
 	if b.header.Time.Cmp(b.parent.Header().Time) <= 0 {
 		panic("block time out of range")
 	}
-	b.header.Difficulty = CalcDifficulty(MakeChainConfig(), b.header.Time.Uint64(), b.parent.Header())
+	b.header.Difficulty = CalcDifficulty(b.config, b.header.Time.Uint64(), b.parent.Header())
 }
 
 // GenerateChain creates a chain of n blocks. The first block's
@@ -192,18 +231,18 @@ func (b *BlockGen) OffsetTime(seconds int64) {
 func GenerateChain(config *ChainConfig, parent *types.Block, db ethdb.Database, n int, gen func(int, *BlockGen)) ([]*types.Block, []types.Receipts) {
 	blocks, receipts := make(types.Blocks, n), make([]types.Receipts, n)
 	genblock := func(i int, h *types.Header, statedb *state.StateDB) (*types.Block, types.Receipts) {
-		b := &BlockGen{parent: parent, i: i, chain: blocks, header: h, statedb: statedb, config: config}
-
 		// Mutate the state and block according to any hard-fork specs
 		if config == nil {
 			config = DefaultConfigMainnet.ChainConfig // MakeChainConfig()
 		}
+		b := &BlockGen{parent: parent, i: i, chain: blocks, header: h, statedb: statedb, config: config}
+
 		// Execute any user modifications to the block and finalize it
 		if gen != nil {
 			gen(i, b)
 		}
 		AccumulateRewards(config, statedb, h, b.uncles)
-		root, err := statedb.CommitTo(db, config.IsAtlantis(b.header.Number))
+		root, err := statedb.CommitTo(db, config.IsStateClear(b.header.Number))
 		if err != nil {
 			panic(fmt.Sprintf("state write error: %v", err))
 		}
@@ -232,14 +271,15 @@ func makeHeader(config *ChainConfig, parent *types.Block, state *state.StateDB)
 		time = new(big.Int).Add(parent.Time(), big.NewInt(10)) // block time is fixed at 10 seconds
 	}
 	return &types.Header{
-		Root:       state.IntermediateRoot(config.IsAtlantis(parent.Number())),
+		Root:       state.IntermediateRoot(config.IsStateClear(parent.Number())),
 		ParentHash: parent.Hash(),
 		Coinbase:   parent.Coinbase(),
 		Difficulty: CalcDifficulty(config, time.Uint64(), parent.Header()),
-		GasLimit:   CalcGasLimit(parent),
+		GasLimit:   CalcGasLimit(config, parent),
 		GasUsed:    new(big.Int),
 		Number:     new(big.Int).Add(parent.Number(), common.Big1),
 		Time:       time,
+		BaseFee:    CalcBaseFee(config, parent.Header()),
 	}
 }
 
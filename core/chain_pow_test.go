@@ -34,8 +34,8 @@ type failPow struct {
 	failing uint64
 }
 
-func (pow failPow) Search(pow.Block, <-chan struct{}, int) (uint64, []byte) {
-	return 0, nil
+func (pow failPow) Search(pow.Block, <-chan struct{}, int) uint64 {
+	return 0
 }
 func (pow failPow) Verify(block pow.Block) bool { return block.NumberU64() != pow.failing }
 func (pow failPow) GetHashrate() int64          { return 0 }
@@ -47,8 +47,8 @@ type delayedPow struct {
 	delay time.Duration
 }
 
-func (pow delayedPow) Search(pow.Block, <-chan struct{}, int) (uint64, []byte) {
-	return 0, nil
+func (pow delayedPow) Search(pow.Block, <-chan struct{}, int) uint64 {
+	return 0
 }
 func (pow delayedPow) Verify(block pow.Block) bool { time.Sleep(pow.delay); return true }
 func (pow delayedPow) GetHashrate() int64          { return 0 }
@@ -231,3 +231,24 @@ func testPowConcurrentAbortion(t *testing.T, threads int) {
 		}
 	}
 }
+
+// Tests that seal checks are sampled from every window, at the periodic
+// checkpoints and at both ends of a header chain.
+func TestSealChecks(t *testing.T) {
+	chain := make([]*types.Header, 3000)
+	for i := range chain {
+		chain[i] = &types.Header{Number: big.NewInt(int64(1000 + i))}
+	}
+	verify := sealChecks(chain, 100, func(n int) int { return n - 1 })
+
+	for i, header := range chain {
+		number := header.Number.Uint64()
+		want := i == 0 || i == len(chain)-1 || i%100 == 99 || number%fullSealCheckInterval == 0
+		if verify[i] != want {
+			t.Errorf("header #%d: seal check mismatch: have %v, want %v", number, verify[i], want)
+		}
+	}
+	if verify := sealChecks(chain[:1], 100, func(n int) int { return 0 }); !verify[0] {
+		t.Error("single header not verified")
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+)
+
+var (
+	ErrCheckpointIncomplete = errors.New("checkpoint requires number, hash and td")
+	ErrCheckpointUnsigned   = errors.New("checkpoint is not signed by enough trusted signers")
+	ErrCheckpointMismatch   = validateError("header does not match trusted checkpoint")
+)
+
+// Checkpoint is a trusted block that a new node may sync from without verifying
+// the proof-of-work of the headers preceding it. Checkpoints are either embedded
+// in a release's chain configuration or signed by the configured checkpoint signers.
+type Checkpoint struct {
+	Number *big.Int    `json:"number"`
+	Hash   common.Hash `json:"hash"`
+	TD     *big.Int    `json:"td"`
+	// Root is the optional state root of the checkpoint block.
+	Root common.Hash `json:"root,omitempty"`
+	// Signatures are secp256k1 signatures of SigHash by trusted signers.
+	Signatures []hexutil.Bytes `json:"signatures,omitempty"`
+}
+
+// SigHash returns the hash which checkpoint signers sign.
+func (cp *Checkpoint) SigHash() common.Hash {
+	return crypto.Keccak256Hash(
+		common.BigToHash(cp.Number).Bytes(),
+		cp.Hash.Bytes(),
+		common.BigToHash(cp.TD).Bytes(),
+		cp.Root.Bytes(),
+	)
+}
+
+// Sign appends a signature by the given key to the checkpoint.
+func (cp *Checkpoint) Sign(prv *ecdsa.PrivateKey) error {
+	sig, err := crypto.Sign(cp.SigHash().Bytes(), prv)
+	if err != nil {
+		return err
+	}
+	cp.Signatures = append(cp.Signatures, sig)
+	return nil
+}
+
+// Signers returns the distinct addresses which signed the checkpoint.
+// Malformed signatures are ignored.
+func (cp *Checkpoint) Signers() []common.Address {
+	var signers []common.Address
+	seen := make(map[common.Address]bool)
+	hash := cp.SigHash().Bytes()
+	for _, sig := range cp.Signatures {
+		pub, err := crypto.SigToPub(hash, sig)
+		if err != nil {
+			continue
+		}
+		addr := crypto.PubkeyToAddress(*pub)
+		if !seen[addr] {
+			seen[addr] = true
+			signers = append(signers, addr)
+		}
+	}
+	return signers
+}
+
+// Verify checks that the checkpoint is complete and, if trusted signers are given,
+// that at least threshold of them signed it. A checkpoint without configured signers
+// is trusted as embedded in the release.
+func (cp *Checkpoint) Verify(trusted []common.Address, threshold int) error {
+	if cp.Number == nil || cp.TD == nil || cp.Hash.IsEmpty() {
+		return ErrCheckpointIncomplete
+	}
+	if len(trusted) == 0 {
+		return nil
+	}
+	if threshold < 1 {
+		threshold = 1
+	}
+	count := 0
+	for _, signer := range cp.Signers() {
+		for _, t := range trusted {
+			if signer == t {
+				count++
+				break
+			}
+		}
+	}
+	if count < threshold {
+		return fmt.Errorf("%v: have %d, want %d", ErrCheckpointUnsigned, count, threshold)
+	}
+	return nil
+}
+
+// Covers returns whether the header at the given number is at or below the checkpoint.
+func (cp *Checkpoint) Covers(num *big.Int) bool {
+	return num != nil && num.Cmp(cp.Number) <= 0
+}
+
+// CheckHeader returns ErrCheckpointMismatch if the header is the checkpoint
+// block's height but does not match its hash or (optional) state root.
+func (cp *Checkpoint) CheckHeader(h *types.Header) error {
+	if h.Number.Cmp(cp.Number) != 0 {
+		return nil
+	}
+	if h.Hash() != cp.Hash {
+		return ErrCheckpointMismatch
+	}
+	if !cp.Root.IsEmpty() && h.Root != cp.Root {
+		return ErrCheckpointMismatch
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+)
+
+func TestCheckpointVerify(t *testing.T) {
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	addr1 := crypto.PubkeyToAddress(key1.PublicKey)
+	addr2 := crypto.PubkeyToAddress(key2.PublicKey)
+
+	cp := &Checkpoint{
+		Number: big.NewInt(1000),
+		Hash:   common.HexToHash("0x01"),
+		TD:     big.NewInt(123456),
+	}
+
+	// Release-embedded checkpoints need no signatures.
+	if err := cp.Verify(nil, 0); err != nil {
+		t.Fatalf("unsigned embedded checkpoint: %v", err)
+	}
+	if err := cp.Verify([]common.Address{addr1}, 1); err == nil {
+		t.Fatal("expected error for unsigned checkpoint with configured signers")
+	}
+
+	if err := cp.Sign(key1); err != nil {
+		t.Fatal(err)
+	}
+	// Duplicate signatures count once.
+	if err := cp.Sign(key1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Verify([]common.Address{addr1, addr2}, 1); err != nil {
+		t.Errorf("threshold 1: %v", err)
+	}
+	if err := cp.Verify([]common.Address{addr1, addr2}, 2); err == nil {
+		t.Error("threshold 2: expected error with a single distinct signer")
+	}
+	if err := cp.Sign(key2); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.Verify([]common.Address{addr1, addr2}, 2); err != nil {
+		t.Errorf("threshold 2: %v", err)
+	}
+
+	// Tampering invalidates signatures.
+	cp.TD = big.NewInt(1)
+	if err := cp.Verify([]common.Address{addr1, addr2}, 1); err == nil {
+		t.Error("expected error for tampered checkpoint")
+	}
+
+	if err := (&Checkpoint{Number: big.NewInt(1)}).Verify(nil, 0); err != ErrCheckpointIncomplete {
+		t.Errorf("got %v, want %v", err, ErrCheckpointIncomplete)
+	}
+}
+
+func TestCheckpointCheckHeader(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(10), Difficulty: big.NewInt(1)}
+	cp := &Checkpoint{Number: big.NewInt(10), Hash: header.Hash(), TD: big.NewInt(10)}
+
+	if err := cp.CheckHeader(header); err != nil {
+		t.Errorf("matching header: %v", err)
+	}
+	if err := cp.CheckHeader(&types.Header{Number: big.NewInt(9)}); err != nil {
+		t.Errorf("header below checkpoint: %v", err)
+	}
+	if err := cp.CheckHeader(&types.Header{Number: big.NewInt(10), Extra: []byte("x")}); err != ErrCheckpointMismatch {
+		t.Errorf("got %v, want %v", err, ErrCheckpointMismatch)
+	}
+	cp.Root = common.HexToHash("0x02")
+	if err := cp.CheckHeader(header); err != ErrCheckpointMismatch {
+		t.Errorf("root mismatch: got %v, want %v", err, ErrCheckpointMismatch)
+	}
+	if !cp.Covers(big.NewInt(10)) || cp.Covers(big.NewInt(11)) {
+		t.Error("unexpected Covers result")
+	}
+}
@@ -150,6 +150,13 @@ type ChainConfig struct {
 
 	// BadHashes holds well known blocks with consensus issues. See ErrHashKnownBad.
 	BadHashes []*BadHash `json:"badHashes"`
+
+	// Checkpoint is an optional trusted block to fast sync from. See Checkpoint.
+	Checkpoint *Checkpoint `json:"checkpoint,omitempty"`
+	// CheckpointSigners, if set, are the addresses allowed to sign Checkpoint,
+	// of which at least CheckpointThreshold (default 1) signatures are required.
+	CheckpointSigners   []common.Address `json:"checkpointSigners,omitempty"`
+	CheckpointThreshold int              `json:"checkpointThreshold,omitempty"`
 }
 
 type Fork struct {
@@ -239,6 +246,81 @@ func (c *SufficientChainConfig) IsValid() (string, bool) {
 		return "forks", false
 	}
 
+	for _, f := range c.ChainConfig.Forks {
+		for _, feat := range f.Features {
+			switch feat.ID {
+			case "eip155":
+				if id, ok := feat.GetBigInt("chainID"); !ok || id.Sign() <= 0 {
+					return "forks." + f.Name + ".eip155.chainID", false
+				}
+			case "selfdestruct":
+				if refund, ok := feat.GetBigInt("refund"); !ok || refund.Sign() < 0 {
+					return "forks." + f.Name + ".selfdestruct.refund", false
+				}
+			case "stateclear":
+				if name, _ := feat.GetString("type"); name != "eip161" && name != "none" {
+					return "forks." + f.Name + ".stateclear.type", false
+				}
+			case "receipts":
+				if name, _ := feat.GetString("type"); name != "status" && name != "root" {
+					return "forks." + f.Name + ".receipts.type", false
+				}
+			case "accesslist":
+				if name, _ := feat.GetString("type"); name != "eip2930" && name != "none" {
+					return "forks." + f.Name + ".accesslist.type", false
+				}
+			case "feemarket":
+				if name, _ := feat.GetString("type"); name != "eip1559" && name != "none" {
+					return "forks." + f.Name + ".feemarket.type", false
+				}
+				if fee, ok := feat.GetBigInt("initialBaseFee"); ok && fee.Sign() < 0 {
+					return "forks." + f.Name + ".feemarket.initialBaseFee", false
+				}
+				if elasticity, ok := feat.GetBigInt("elasticity"); ok && elasticity.Sign() <= 0 {
+					return "forks." + f.Name + ".feemarket.elasticity", false
+				}
+				if denominator, ok := feat.GetBigInt("changeDenominator"); ok && denominator.Sign() <= 0 {
+					return "forks." + f.Name + ".feemarket.changeDenominator", false
+				}
+			case "gaslimit":
+				if min, ok := feat.GetBigInt("min"); ok && min.Sign() <= 0 {
+					return "forks." + f.Name + ".gaslimit.min", false
+				}
+				if divisor, ok := feat.GetBigInt("boundDivisor"); ok && divisor.Sign() <= 0 {
+					return "forks." + f.Name + ".gaslimit.boundDivisor", false
+				}
+			case "rent":
+				if rate, ok := feat.GetBigInt("rate"); !ok || rate.Sign() < 0 {
+					return "forks." + f.Name + ".rent.rate", false
+				}
+				if grace, ok := feat.GetBigInt("grace"); ok && (grace.Sign() < 0 || !grace.IsUint64()) {
+					return "forks." + f.Name + ".rent.grace", false
+				}
+			case "opcodes":
+				for name := range feat.Options {
+					if _, ok := feat.GetBool(name); !ok || !vm.IsSchedulable(vm.StringToOp(name)) {
+						return "forks." + f.Name + ".opcodes." + name, false
+					}
+				}
+			case "precompiles":
+				for name := range feat.Options {
+					if !common.IsHexAddress(name) {
+						return "forks." + f.Name + ".precompiles." + name, false
+					}
+					if _, err := feat.GetPrecompile(name); err != nil {
+						return "forks." + f.Name + ".precompiles." + name + ": " + err.Error(), false
+					}
+				}
+			}
+		}
+	}
+
+	if cp := c.ChainConfig.Checkpoint; cp != nil {
+		if err := cp.Verify(c.ChainConfig.CheckpointSigners, c.ChainConfig.CheckpointThreshold); err != nil {
+			return "chainConfig.checkpoint: " + err.Error(), false
+		}
+	}
+
 	return "", true
 }
 
@@ -299,6 +381,38 @@ func (c *ChainConfig) GetChainID(num *big.Int) *big.Int {
 	return n
 }
 
+// ChainIDs returns every EIP-155 chain id configured across the chain's forks,
+// in fork order.
+func (c *ChainConfig) ChainIDs() []*big.Int {
+	var ids []*big.Int
+	for _, f := range c.Forks {
+		for _, feat := range f.Features {
+			if feat.ID != "eip155" {
+				continue
+			}
+			if id, ok := feat.GetBigInt("chainID"); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// SharedChainIDs returns the EIP-155 chain ids configured in both c and other.
+// Transactions signed for a shared chain id can be replayed across both networks.
+func (c *ChainConfig) SharedChainIDs(other *ChainConfig) []*big.Int {
+	var shared []*big.Int
+	for _, id := range c.ChainIDs() {
+		for _, oid := range other.ChainIDs() {
+			if id.Cmp(oid) == 0 {
+				shared = append(shared, id)
+				break
+			}
+		}
+	}
+	return shared
+}
+
 func (c *ChainConfig) GetLYRA2Block() uint64 {
 	return c.ForkByName("LYRA2").Block.Uint64()
 }
@@ -360,6 +474,154 @@ func (c *ChainConfig) IsAtlantis(num *big.Int) bool {
 	return num.Cmp(fork.Block) >= 0
 }
 
+// IsStateClear returns whether empty accounts touched during a block are
+// removed from the state (EIP-161) at block num. It's configured by the
+// 'stateclear' feature, without one state clearing starts with Atlantis.
+func (c *ChainConfig) IsStateClear(num *big.Int) bool {
+	f, _, configured := c.GetFeature(num, "stateclear")
+	if !configured {
+		return c.IsAtlantis(num)
+	}
+	name, _ := f.GetString("type")
+	switch name {
+	case "eip161":
+		return true
+	case "none":
+		return false
+	default:
+		panic(fmt.Errorf("Unsupported stateclear value '%v' at block: %v", name, num))
+	}
+}
+
+// IsReceiptStatus returns whether receipts at block num carry the status code
+// of their transaction (EIP-658) instead of the intermediate state root. It's
+// configured by the 'receipts' feature, without one status receipts start
+// with Atlantis.
+func (c *ChainConfig) IsReceiptStatus(num *big.Int) bool {
+	f, _, configured := c.GetFeature(num, "receipts")
+	if !configured {
+		return c.IsAtlantis(num)
+	}
+	name, _ := f.GetString("type")
+	switch name {
+	case "status":
+		return true
+	case "root":
+		return false
+	default:
+		panic(fmt.Errorf("Unsupported receipts value '%v' at block: %v", name, num))
+	}
+}
+
+// IsAccessList implements vm.AccessListScheduler. Access list transactions
+// (EIP-2930) and the warm and cold access gas costs (EIP-2929) are enabled by
+// the 'accesslist' feature, e.g. {"id": "accesslist", "options": {"type": "eip2930"}}.
+func (c *ChainConfig) IsAccessList(num *big.Int) bool {
+	f, _, configured := c.GetFeature(num, "accesslist")
+	if !configured {
+		return false
+	}
+	name, _ := f.GetString("type")
+	switch name {
+	case "eip2930":
+		return true
+	case "none":
+		return false
+	default:
+		panic(fmt.Errorf("Unsupported accesslist value '%v' at block: %v", name, num))
+	}
+}
+
+// IsTxTypeSupported returns whether transactions of type typ are valid in
+// block num.
+func (c *ChainConfig) IsTxTypeSupported(typ byte, num *big.Int) bool {
+	switch typ {
+	case types.LegacyTxType:
+		return true
+	case types.AccessListTxType:
+		return c.IsAccessList(num)
+	case types.DynamicFeeTxType:
+		return c.IsDynamicFee(num)
+	}
+	return false
+}
+
+// GasLimitBounds returns the minimum gas limit of block num and the bound
+// divisor limiting its change from the parent's. They're configured by the
+// 'gaslimit' feature, e.g. {"id": "gaslimit", "options": {"min": 5000,
+// "boundDivisor": 1024}}, and default to MinGasLimit and GasLimitBoundDivisor.
+func (c *ChainConfig) GasLimitBounds(num *big.Int) (min, boundDivisor *big.Int) {
+	min, boundDivisor = MinGasLimit, GasLimitBoundDivisor
+	if f, _, configured := c.GetFeature(num, "gaslimit"); configured {
+		if v, ok := f.GetBigInt("min"); ok {
+			min = v
+		}
+		if v, ok := f.GetBigInt("boundDivisor"); ok {
+			boundDivisor = v
+		}
+	}
+	return min, boundDivisor
+}
+
+// OpcodeEnabled implements vm.OpcodeScheduler. Instructions are scheduled by
+// 'opcodes' features mapping opcode names to whether they're enabled, e.g.
+// {"id": "opcodes", "options": {"STATICCALL": true, "REVERT": false}}. An
+// opcode configured by several forks takes the setting of the latest one up
+// to num.
+func (c *ChainConfig) OpcodeEnabled(op vm.OpCode, num *big.Int) (enabled, scheduled bool) {
+	if num == nil {
+		return false, false
+	}
+	name := op.String()
+	for _, f := range c.Forks {
+		if f.Block == nil || f.Block.Cmp(num) > 0 {
+			continue
+		}
+		for _, ff := range f.Features {
+			if ff.ID != "opcodes" {
+				continue
+			}
+			if on, ok := ff.GetBool(name); ok {
+				enabled, scheduled = on, true
+			}
+		}
+	}
+	return enabled, scheduled
+}
+
+// Precompiles implements vm.PrecompileScheduler. Contracts are added by
+// 'precompiles' features mapping addresses to PrecompileSpecs, e.g.
+// {"id": "precompiles", "options": {"0x0000000000000000000000000000000000000100": {"builtin": "sha256", "baseGas": 60, "wordGas": 12}}}.
+// A contract configured by several forks takes the setting of the latest one
+// up to num.
+func (c *ChainConfig) Precompiles(num *big.Int) map[common.Address]*vm.PrecompiledAccount {
+	if num == nil {
+		return nil
+	}
+	var precompiles map[common.Address]*vm.PrecompiledAccount
+	for _, f := range c.Forks {
+		if f.Block == nil || f.Block.Cmp(num) > 0 {
+			continue
+		}
+		for _, ff := range f.Features {
+			if ff.ID != "precompiles" {
+				continue
+			}
+			for name := range ff.Options {
+				p, err := ff.GetPrecompile(name)
+				if err != nil {
+					panic(fmt.Errorf("Unsupported precompile %v at block %v: %v", name, num, err))
+				}
+				if precompiles == nil {
+					precompiles = make(map[common.Address]*vm.PrecompiledAccount)
+				}
+				precompiles[common.HexToAddress(name)] = p
+			}
+		}
+	}
+	return precompiles
+}
+
 // ForkByName looks up a Fork by its name, assumed to be unique
 func (c *ChainConfig) ForkByName(name string) *Fork {
 	for i := range c.Forks {
@@ -370,7 +632,7 @@ func (c *ChainConfig) ForkByName(name string) *Fork {
 	return &Fork{}
 }
 
-// GetFeature looks up fork features by id, where id can (currently) be [difficulty, gastable, eip155].
+// GetFeature looks up fork features by id, where id can (currently) be [difficulty, gastable, eip155, selfdestruct, stateclear, receipts, rent, gaslimit, accesslist, feemarket].
 // GetFeature returns the feature|nil, the latest fork configuring a given id, and if the given feature id was found at all
 // If queried feature is not found, returns ForkFeature{}, Fork{}, false.
 // If queried block number and/or feature is a zero-value, returns ForkFeature{}, Fork{}, false.
@@ -473,20 +735,28 @@ func (c *ChainConfig) GetSigner(blockNumber *big.Int) types.Signer {
 // GasTable returns the gas table corresponding to the current fork
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
 func (c *ChainConfig) GasTable(num *big.Int) *vm.GasTable {
-	f, _, configured := c.GetFeature(num, "gastable")
-	if !configured {
-		return DefaultDiehardGasTable
+	table := DefaultDiehardGasTable
+	if f, _, configured := c.GetFeature(num, "gastable"); configured {
+		name, ok := f.GetString("type")
+		if !ok {
+			name = ""
+		} // will wall to default panic
+		switch name {
+		case "eip160":
+			table = DefaultDiehardGasTable
+		default:
+			panic(fmt.Errorf("Unsupported gastable value '%v' at block: %v", name, num))
+		}
 	}
-	name, ok := f.GetString("type")
-	if !ok {
-		name = ""
-	} // will wall to default panic
-	switch name {
-	case "eip160":
-		return DefaultDiehardGasTable
-	default:
-		panic(fmt.Errorf("Unsupported gastable value '%v' at block: %v", name, num))
+	// The 'selfdestruct' feature overrides the SUICIDE refund of the table
+	if f, _, configured := c.GetFeature(num, "selfdestruct"); configured {
+		if refund, ok := f.GetBigInt("refund"); ok {
+			cpy := *table
+			cpy.SuicideRefund = refund
+			return &cpy
+		}
 	}
+	return table
 }
 
 // WriteToJSONFile writes a given config to a specified file path.
@@ -696,6 +966,27 @@ func (o *ForkFeature) GetString(name string) (string, bool) {
 	return val, ok
 }
 
+// GetBool gets and option value for an options with key 'name',
+// returning value as a bool and ok if it exists.
+func (o *ForkFeature) GetBool(name string) (bool, bool) {
+	o.parsedOptionsLock.Lock()
+	defer o.parsedOptionsLock.Unlock()
+
+	if o.ParsedOptions == nil {
+		o.ParsedOptions = make(map[string]interface{})
+	} else if val, ok := o.ParsedOptions[name].(bool); ok {
+		return val, true
+	}
+	o.optionsLock.RLock()
+	defer o.optionsLock.RUnlock()
+
+	val, ok := o.Options[name].(bool)
+	if ok {
+		o.ParsedOptions[name] = val
+	}
+	return val, ok
+}
+
 // GetBigInt gets and option value for an options with key 'name',
 // returning value as a *big.Int and ok if it exists.
 func (o *ForkFeature) GetBigInt(name string) (*big.Int, bool) {
@@ -750,6 +1041,48 @@ func (o *ForkFeature) GetBigInt(name string) (*big.Int, bool) {
 	return nil, false
 }
 
+// PrecompileSpec configures a precompiled contract of a 'precompiles' feature:
+// a builtin function, see vm.RegisterBuiltin, and its linear pricing.
+type PrecompileSpec struct {
+	Builtin string `json:"builtin"`
+	BaseGas uint64 `json:"baseGas"` // Gas charged for any call
+	WordGas uint64 `json:"wordGas"` // Gas charged per 32 byte word of input
+}
+
+// GetPrecompile gets the option value with key 'name' as a PrecompileSpec,
+// returning the contract it configures.
+func (o *ForkFeature) GetPrecompile(name string) (*vm.PrecompiledAccount, error) {
+	o.parsedOptionsLock.Lock()
+	defer o.parsedOptionsLock.Unlock()
+
+	if o.ParsedOptions == nil {
+		o.ParsedOptions = make(map[string]interface{})
+	} else if p, ok := o.ParsedOptions[name].(*vm.PrecompiledAccount); ok {
+		return p, nil
+	}
+	o.optionsLock.RLock()
+	value, ok := o.Options[name]
+	o.optionsLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no option %q", name)
+	}
+	// Options are decoded from JSON as generic values, decode them again.
+	enc, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var spec PrecompileSpec
+	if err := json.Unmarshal(enc, &spec); err != nil {
+		return nil, err
+	}
+	p, err := vm.NewPrecompiledAccount(spec.Builtin, spec.BaseGas, spec.WordGas)
+	if err != nil {
+		return nil, err
+	}
+	o.ParsedOptions[name] = p
+	return p, nil
+}
+
 // WriteGenesisBlock writes the genesis block to the database as block number 0
 func WriteGenesisBlock(chainDb ethdb.Database, genesis *GenesisDump) (*types.Block, error) {
 	statedb, err := state.New(common.Hash{}, state.NewDatabase(chainDb))
@@ -11,6 +11,7 @@ import (
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
 	"github.com/webchain-network/webchaind/ethdb"
 	"reflect"
 )
@@ -25,94 +26,72 @@ func TestConfigErrorProperties(t *testing.T) {
 }
 
 func TestChainConfig_IsHomestead(t *testing.T) {
+	// The default networks have no Homestead fork
 	config := DefaultConfigMainnet.ChainConfig
-
-	if config.IsHomestead(big.NewInt(10000)) {
-		t.Errorf("Unexpected for %d", 10000)
+	for _, n := range []int64{0, 1, 1920000, 3300001, 5000001} {
+		if config.IsHomestead(big.NewInt(n)) {
+			t.Errorf("Unexpected for %d", n)
+		}
 	}
 
-	if !config.IsHomestead(big.NewInt(1920000)) {
-		t.Errorf("Expected for %d", 1920000)
-	}
-	if !config.IsHomestead(big.NewInt(2325166)) {
-		t.Errorf("Expected for %d", 2325166)
-	}
-	if !config.IsHomestead(big.NewInt(3000000)) {
-		t.Errorf("Expected for %d", 3000000)
-	}
-	if !config.IsHomestead(big.NewInt(3000001)) {
-		t.Errorf("Expected for %d", 3000001)
-	}
-	if !config.IsHomestead(big.NewInt(4000000)) {
-		t.Errorf("Expected for %d", 3000000)
-	}
-	if !config.IsHomestead(big.NewInt(5000000)) {
-		t.Errorf("Expected for %d", 5000000)
-	}
-	if !config.IsHomestead(big.NewInt(5000001)) {
-		t.Errorf("Expected for %d", 5000001)
+	config = testChainConfig()
+	for _, n := range []int64{0, 1, 5, 5000001} {
+		if !config.IsHomestead(big.NewInt(n)) {
+			t.Errorf("Expected for %d", n)
+		}
 	}
 }
 
 func TestChainConfig_IsDiehard(t *testing.T) {
 	config := DefaultConfigMainnet.ChainConfig
 
-	if config.IsDiehard(big.NewInt(1920000)) {
-		t.Errorf("Unexpected for %d", 1920000)
+	if config.IsDiehard(big.NewInt(0)) {
+		t.Errorf("Unexpected for %d", 0)
 	}
-
-	if config.IsDiehard(big.NewInt(2325166)) {
-		t.Errorf("Unexpected for %d", 2325166)
-	}
-
-	if !config.IsDiehard(big.NewInt(3000000)) {
-		t.Errorf("Expected for %d", 3000000)
-	}
-	if !config.IsDiehard(big.NewInt(3000001)) {
-		t.Errorf("Expected for %d", 3000001)
-	}
-	if !config.IsDiehard(big.NewInt(4000000)) {
-		t.Errorf("Expected for %d", 3000000)
-	}
-
-	if !config.IsDiehard(big.NewInt(5000000)) {
-		t.Errorf("Expected for %d", 5000000)
-	}
-	if !config.IsDiehard(big.NewInt(5000001)) {
-		t.Errorf("Expected for %d", 5000001)
+	for _, n := range []int64{1, 2, 2022222, 3300001, 5000001} {
+		if !config.IsDiehard(big.NewInt(n)) {
+			t.Errorf("Expected for %d", n)
+		}
 	}
 }
 
 func TestChainConfig_IsExplosion(t *testing.T) {
+	// The default networks have no difficulty bomb
 	config := DefaultConfigMainnet.ChainConfig
-
-	if config.IsExplosion(big.NewInt(1920000)) {
-		t.Errorf("Unexpected for %d", 1920000)
-	}
-
-	if config.IsExplosion(big.NewInt(2325166)) {
-		t.Errorf("Unexpected for %d", 2325166)
+	for _, n := range []int64{0, 3000000, 5000000, 5000001} {
+		if config.IsExplosion(big.NewInt(n)) {
+			t.Errorf("Unexpected for %d", n)
+		}
 	}
 
-	// Default Diehard block is 3000000
-	if config.IsExplosion(big.NewInt(3000000)) {
-		t.Errorf("Unxpected for %d", 3000000)
-	}
-	if config.IsExplosion(big.NewInt(3000001)) {
-		t.Errorf("Unxpected for %d", 3000001)
-	}
-	if config.IsExplosion(big.NewInt(4000000)) {
-		t.Errorf("Unxpected for %d", 3000000)
+	// An ecip1010 bomb delayed by 2000000 blocks from 3000000
+	config = &ChainConfig{
+		Forks: []*Fork{
+			{
+				Name:  "Diehard",
+				Block: big.NewInt(3000000),
+				Features: []*ForkFeature{
+					{
+						ID: "difficulty",
+						Options: ChainFeatureConfigOptions{
+							"type":   "ecip1010",
+							"length": 2000000,
+						},
+					},
+				},
+			},
+		},
 	}
-
-	// Default BombDelay length is 2000000.
-	if !config.IsExplosion(big.NewInt(5000000)) {
-		t.Errorf("Expected for %d", 5000000)
+	for _, n := range []int64{1920000, 3000000, 3000001, 4999999} {
+		if config.IsExplosion(big.NewInt(n)) {
+			t.Errorf("Unexpected for %d", n)
+		}
 	}
-	if !config.IsExplosion(big.NewInt(5000001)) {
-		t.Errorf("Expected for %d", 5000001)
+	for _, n := range []int64{5000000, 5000001} {
+		if !config.IsExplosion(big.NewInt(n)) {
+			t.Errorf("Expected for %d", n)
+		}
 	}
-
 }
 
 func sameGenesisDumpAllocationsBalances(gd1, gd2 *GenesisDump) bool {
@@ -289,14 +268,11 @@ func TestChainConfig_GetFeature(t *testing.T) {
 
 var allAvailableDefaultConfigKeys = []string{
 	"difficulty",
-	"gastable",
 	"eip155",
 }
 var allAvailableTestnetConfigKeys = []string{
 	"difficulty",
-	"gastable",
 	"eip155",
-	"reward",
 }
 var unavailableConfigKeys = []string{
 	"foo",
@@ -338,16 +314,16 @@ func TestChainConfig_GetFeature4_WorkForHighNumbers(t *testing.T) {
 
 func TestChainConfig_GetChainID(t *testing.T) {
 	// Test default hardcoded configs.
-	if DefaultConfigMainnet.ChainConfig.GetChainID().Cmp(DefaultConfigMainnet.ChainConfig.GetChainID()) != 0 {
-		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.ChainConfig.GetChainID(), DefaultConfigMainnet.ChainConfig.GetChainID())
+	if DefaultConfigMainnet.ChainConfig.GetChainID(nil).Cmp(DefaultConfigMainnet.ChainConfig.GetChainID(nil)) != 0 {
+		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.ChainConfig.GetChainID(nil), DefaultConfigMainnet.ChainConfig.GetChainID(nil))
 	}
-	if DefaultConfigMorden.ChainConfig.GetChainID().Cmp(DefaultConfigMorden.ChainConfig.GetChainID()) != 0 {
-		t.Errorf("got: %v, want: %v", DefaultConfigMorden.ChainConfig.GetChainID(), DefaultConfigMorden.ChainConfig.GetChainID())
+	if DefaultConfigMorden.ChainConfig.GetChainID(nil).Cmp(DefaultConfigMorden.ChainConfig.GetChainID(nil)) != 0 {
+		t.Errorf("got: %v, want: %v", DefaultConfigMorden.ChainConfig.GetChainID(nil), DefaultConfigMorden.ChainConfig.GetChainID(nil))
 	}
 
 	// If no chainID (config is empty) returns 0.
 	c := &ChainConfig{}
-	cid := c.GetChainID()
+	cid := c.GetChainID(nil)
 	// check is zero
 	if cid.Cmp(new(big.Int)) != 0 {
 		t.Errorf("got: %v, want: %v", cid, new(big.Int))
@@ -355,8 +331,8 @@ func TestChainConfig_GetChainID(t *testing.T) {
 
 	// Test parsing default external mainnet config.
 	cases := map[string]*big.Int{
-		"../core/config/mainnet.json": DefaultConfigMainnet.ChainConfig.GetChainID(),
-		"../core/config/morden.json":  DefaultConfigMorden.ChainConfig.GetChainID(),
+		"../core/config/mainnet.json": DefaultConfigMainnet.ChainConfig.GetChainID(nil),
+		"../core/config/morden.json":  DefaultConfigMorden.ChainConfig.GetChainID(nil),
 	}
 	for extConfigPath, wantInt := range cases {
 		p, e := filepath.Abs(extConfigPath)
@@ -367,8 +343,8 @@ func TestChainConfig_GetChainID(t *testing.T) {
 		if err != nil {
 			t.Fatalf("could not decode file: %v", err)
 		}
-		if extConfig.ChainConfig.GetChainID().Cmp(wantInt) != 0 {
-			t.Errorf("got: %v, want: %v", extConfig.ChainConfig.GetChainID(), wantInt)
+		if extConfig.ChainConfig.GetChainID(nil).Cmp(wantInt) != 0 {
+			t.Errorf("got: %v, want: %v", extConfig.ChainConfig.GetChainID(nil), wantInt)
 		}
 	}
 }
@@ -380,28 +356,28 @@ func TestChainConfig_GetChainID(t *testing.T) {
 // TestChainConfig_GetFeature_DefaultEIP155 should get the eip155 feature for (only and above) its default implemented block.
 func TestChainConfig_GetFeature5_DefaultEIP155(t *testing.T) {
 	c := getDefaultChainConfigSorted()
+	diehard := DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block
+	hardfork1 := DefaultConfigMainnet.ChainConfig.ForkByName("Hardfork1").Block
 	var tables = map[*big.Int]*big.Int{
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): nil,
-		DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block:                                   nil,
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): nil,
-
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): nil,
-		DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block:                                   nil,
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): nil,
+		big.NewInt(0).Sub(diehard, big.NewInt(1)): nil,
+		diehard: big.NewInt(101),
+		big.NewInt(0).Add(diehard, big.NewInt(1)): big.NewInt(101),
 
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): nil,
-		DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block:                                   big.NewInt(61),
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): big.NewInt(61),
+		big.NewInt(0).Sub(hardfork1, big.NewInt(1)): big.NewInt(101),
+		hardfork1: big.NewInt(24484),
+		big.NewInt(0).Add(hardfork1, big.NewInt(1)): big.NewInt(24484),
 	}
 	for block, expected := range tables {
 		feat, fork, ok := c.GetFeature(block, "eip155")
 		if expected != nil {
 			if !ok {
 				t.Errorf("Expected eip155 feature to exist. feat: %v, fork: %v, block: %v", feat, fork, block)
+				continue
 			}
 			val, ok := feat.GetBigInt("chainID")
 			if !ok {
 				t.Errorf("failed to get value for eip155 feature. feat: %v, fork: %v, block: %v", feat, fork, block)
+				continue
 			}
 			if val.Cmp(expected) != 0 {
 				t.Errorf("want: %v, got: %v", expected, val)
@@ -414,64 +390,37 @@ func TestChainConfig_GetFeature5_DefaultEIP155(t *testing.T) {
 	}
 }
 
-// TestChainConfig_GetFeature_DefaultGasTables sets that GetFeatures gets expected feature values for default fork configs.
+// TestChainConfig_GetFeature_DefaultGasTables checks that the default fork configs leave the gas table at its default.
 func TestChainConfig_GetFeature6_DefaultGasTables(t *testing.T) {
 	c := getDefaultChainConfigSorted()
-	var tables = map[*big.Int]string{
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): "",
-		DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block:                                   "homestead",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): "homestead",
-
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): "homestead",
-		DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block:                                   "eip150",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): "eip150",
-
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): "eip150",
-		DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block:                                   "eip160",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): "eip160",
-	}
-	for block, expected := range tables {
-		feat, fork, ok := c.GetFeature(block, "gastable")
-		if expected != "" {
-			if !ok {
-				t.Errorf("Expected gastable feature to exist. feat: %v, fork: %v, block: %v", feat, fork, block)
-			}
-			val, ok := feat.GetString("type")
-			if !ok {
-				t.Errorf("failed to get value for gastable feature. feat: %v, fork: %v, block: %v", feat, fork, block)
-			}
-			if val != expected {
-				t.Errorf("want: %v, got: %v", expected, val)
-			}
-		} else {
-			if ok {
+	for _, fork := range c.Forks {
+		for _, block := range []*big.Int{fork.Block, big.NewInt(0).Add(fork.Block, big.NewInt(1))} {
+			if feat, _, ok := c.GetFeature(block, "gastable"); ok {
 				t.Errorf("Unexpected gastable feature exists. feat: %v, fork: %v, block: %v", feat, fork, block)
 			}
+			if table := c.GasTable(block); table != DefaultDiehardGasTable {
+				t.Errorf("block %v: unexpected gas table %v", block, table)
+			}
 		}
 	}
 }
 
-// TestChainConfig_GetFeature_DefaultGasTables sets that GetFeatures gets expected feature values for default fork configs.
+// TestChainConfig_GetFeature_DefaultDifficulty checks that GetFeature gets expected feature values for default fork configs.
 func TestChainConfig_GetFeature7_DefaultDifficulty(t *testing.T) {
 	c := getDefaultChainConfigSorted()
+	atlantis := DefaultConfigMainnet.ChainConfig.ForkByName("Atlantis").Block
 	var tables = map[*big.Int]string{
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): "",
-		DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block:                                   "homestead",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Homestead").Block, big.NewInt(1)): "homestead",
-
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): "homestead",
-		DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block:                                   "homestead",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice").Block, big.NewInt(1)): "homestead",
-
-		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): "homestead",
-		DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block:                                   "ecip1010",
-		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): "ecip1010",
+		big.NewInt(1): "",
+		big.NewInt(0).Sub(atlantis, big.NewInt(1)): "",
+		atlantis: "atlantis",
+		big.NewInt(0).Add(atlantis, big.NewInt(1)): "atlantis",
 	}
 	for block, expected := range tables {
 		feat, fork, ok := c.GetFeature(block, "difficulty")
 		if expected != "" {
 			if !ok {
 				t.Errorf("Expected difficulty feature to exist. feat: %v, fork: %v, block: %v", feat, fork, block)
+				continue
 			}
 			val, ok := feat.GetString("type")
 			if !ok {
@@ -515,7 +464,7 @@ func TestChainConfig_SortForks(t *testing.T) {
 }
 
 func TestChainConfigGetSet(t *testing.T) {
-	c := getDefaultChainConfigSorted()
+	c := &ChainConfig{Forks: append(Forks{}, getDefaultChainConfigSorted().Forks...)}
 	set := SetCacheChainConfig(&SufficientChainConfig{ChainConfig: c})
 
 	if set == nil {
@@ -547,24 +496,11 @@ func TestChainConfigGetSet(t *testing.T) {
 }
 
 func TestChainConfig_GetLastRequiredHashFork(t *testing.T) {
-	c := getDefaultChainConfigSorted()
-
-	daoFork := c.ForkByName("The DAO Hard Fork")
-	got, want := c.GetLatestRequiredHashFork(big.NewInt(1920000)), daoFork
-
-	// sanity check
-	if want == nil {
-		t.Fatal("nil want hard fork")
-	}
+	c := &ChainConfig{Forks: append(Forks{}, getDefaultChainConfigSorted().Forks...)}
 
-	if got == nil {
-		t.Fatalf("got: %v, want: %s", got, want.Name)
-	}
-	if got.RequiredHash.Hex() != "0x94365e3a8c0b35089c1d1195081fe7489b528a84b22199c916180db8b28ade7f" {
-		t.Errorf("got: %v, want: %s", got, got.RequiredHash.Hex())
-	}
-	if got.Block.Cmp(big.NewInt(1920000)) != 0 {
-		t.Errorf("got: %d, want: %d", got.Block, 1920000)
+	// The default forks require no hashes
+	if got := c.GetLatestRequiredHashFork(big.NewInt(5000001)); got != nil {
+		t.Fatalf("got: %v, want: nil", got)
 	}
 
 	// create new "checkpoint" fork for testing
@@ -578,16 +514,19 @@ func TestChainConfig_GetLastRequiredHashFork(t *testing.T) {
 	// Noting that config forks do not have to be sorted for this function to work.
 	//c.SortForks()
 
-	got, want = c.GetLatestRequiredHashFork(big.NewInt(1930000)), checkpoint
+	got, want := c.GetLatestRequiredHashFork(big.NewInt(1930000)), checkpoint
 	if !reflect.DeepEqual(got, want) {
 		t.Errorf("got: %v, want: %v", got, want)
 	}
-
-	// should use dao fork, not checkpoint since block n has not reached checkpoint
-	got, want = c.GetLatestRequiredHashFork(big.NewInt(1920001)), daoFork
+	got, want = c.GetLatestRequiredHashFork(big.NewInt(5000001)), checkpoint
 	if !reflect.DeepEqual(got, want) {
 		t.Errorf("got: %v, want: %v", got, want)
 	}
+
+	// no fork is required before the checkpoint is reached
+	if got := c.GetLatestRequiredHashFork(big.NewInt(1929999)); got != nil {
+		t.Errorf("got: %v, want: nil", got)
+	}
 }
 
 func TestChainConfig_GetSigner(t *testing.T) {
@@ -847,3 +786,359 @@ func TestGenesisAllocationError(t *testing.T) {
 		t.Error("invalid error message")
 	}
 }
+
+func TestChainConfig_SharedChainIDs(t *testing.T) {
+	mainnet := DefaultConfigMainnet.ChainConfig
+	morden := DefaultConfigMorden.ChainConfig
+
+	if ids := mainnet.ChainIDs(); len(ids) != 2 || ids[0].Cmp(big.NewInt(101)) != 0 || ids[1].Cmp(big.NewInt(24484)) != 0 {
+		t.Errorf("unexpected mainnet chain ids: %v", ids)
+	}
+	if shared := mainnet.SharedChainIDs(morden); len(shared) != 0 {
+		t.Errorf("mainnet and morden unexpectedly share chain ids: %v", shared)
+	}
+	if shared := mainnet.SharedChainIDs(mainnet); len(shared) != 2 {
+		t.Errorf("expected mainnet to share its chain ids with itself, got: %v", shared)
+	}
+}
+
+func TestSufficientChainConfig_IsValidChainID(t *testing.T) {
+	config := &SufficientChainConfig{
+		Identity:  "custom",
+		Network:   3,
+		Consensus: "cryptonight",
+		Genesis:   DefaultConfigMorden.Genesis,
+		ChainConfig: &ChainConfig{
+			Forks: []*Fork{{
+				Name:  "Diehard",
+				Block: big.NewInt(1),
+				Features: []*ForkFeature{{
+					ID:      "eip155",
+					Options: ChainFeatureConfigOptions{"chainID": float64(0)},
+				}},
+			}},
+		},
+	}
+	if invalid, ok := config.IsValid(); ok || invalid != "forks.Diehard.eip155.chainID" {
+		t.Errorf("expected invalid chain id, got %q (valid: %v)", invalid, ok)
+	}
+	config.ChainConfig.Forks[0].Features[0] = &ForkFeature{
+		ID:      "eip155",
+		Options: ChainFeatureConfigOptions{"chainID": float64(1337)},
+	}
+	if invalid, ok := config.IsValid(); !ok {
+		t.Errorf("unexpected invalid config: %s", invalid)
+	}
+}
+
+func TestChainConfig_IsStateClear(t *testing.T) {
+	config := &ChainConfig{
+		Forks: []*Fork{
+			{
+				Name:  "Cleanup",
+				Block: big.NewInt(5),
+				Features: []*ForkFeature{{
+					ID:      "stateclear",
+					Options: ChainFeatureConfigOptions{"type": "eip161"},
+				}},
+			},
+			{Name: "Atlantis", Block: big.NewInt(10)},
+			{
+				Name:  "Legacy",
+				Block: big.NewInt(20),
+				Features: []*ForkFeature{{
+					ID:      "stateclear",
+					Options: ChainFeatureConfigOptions{"type": "none"},
+				}},
+			},
+		},
+	}
+	for num, want := range map[int64]bool{4: false, 5: true, 10: true, 19: true, 20: false} {
+		if have := config.IsStateClear(big.NewInt(num)); have != want {
+			t.Errorf("block %d: state clear %v, want %v", num, have, want)
+		}
+	}
+	// Without the feature, state clearing starts with Atlantis.
+	mainnet := DefaultConfigMainnet.ChainConfig
+	atlantis := mainnet.ForkByName("Atlantis").Block
+	if mainnet.IsStateClear(new(big.Int).Sub(atlantis, big.NewInt(1))) || !mainnet.IsStateClear(atlantis) {
+		t.Errorf("mainnet state clearing doesn't start at Atlantis block %v", atlantis)
+	}
+}
+
+func TestChainConfig_SuicideRefund(t *testing.T) {
+	config := &ChainConfig{
+		Forks: []*Fork{{
+			Name:  "NoRefund",
+			Block: big.NewInt(10),
+			Features: []*ForkFeature{{
+				ID:      "selfdestruct",
+				Options: ChainFeatureConfigOptions{"refund": float64(0)},
+			}},
+		}},
+	}
+	if refund := config.GasTable(big.NewInt(9)).SuicideRefund; refund != nil {
+		t.Errorf("unexpected refund override before fork: %v", refund)
+	}
+	if refund := config.GasTable(big.NewInt(10)).SuicideRefund; refund == nil || refund.Sign() != 0 {
+		t.Errorf("refund mismatch: have %v, want 0", refund)
+	}
+	if DefaultDiehardGasTable.SuicideRefund != nil {
+		t.Error("default gas table modified")
+	}
+}
+
+func TestSufficientChainConfig_IsValidSelfdestruct(t *testing.T) {
+	config := &SufficientChainConfig{
+		Identity:  "custom",
+		Network:   3,
+		Consensus: "cryptonight",
+		Genesis:   DefaultConfigMorden.Genesis,
+		ChainConfig: &ChainConfig{
+			Forks: []*Fork{{
+				Name:  "Cleanup",
+				Block: big.NewInt(1),
+				Features: []*ForkFeature{{
+					ID:      "selfdestruct",
+					Options: ChainFeatureConfigOptions{"refund": float64(-1)},
+				}},
+			}},
+		},
+	}
+	if invalid, ok := config.IsValid(); ok || invalid != "forks.Cleanup.selfdestruct.refund" {
+		t.Errorf("expected invalid refund, got %q (valid: %v)", invalid, ok)
+	}
+	config.ChainConfig.Forks[0].Features[0] = &ForkFeature{
+		ID:      "stateclear",
+		Options: ChainFeatureConfigOptions{"type": "eip158"},
+	}
+	if invalid, ok := config.IsValid(); ok || invalid != "forks.Cleanup.stateclear.type" {
+		t.Errorf("expected invalid state clearing, got %q (valid: %v)", invalid, ok)
+	}
+	config.ChainConfig.Forks[0].Features[0] = &ForkFeature{
+		ID:      "stateclear",
+		Options: ChainFeatureConfigOptions{"type": "eip161"},
+	}
+	if invalid, ok := config.IsValid(); !ok {
+		t.Errorf("unexpected invalid config: %s", invalid)
+	}
+}
+
+func TestChainConfig_OpcodeEnabled(t *testing.T) {
+	config := &ChainConfig{
+		Forks: []*Fork{
+			{
+				Name:  "Static",
+				Block: big.NewInt(5),
+				Features: []*ForkFeature{{
+					ID:      "opcodes",
+					Options: ChainFeatureConfigOptions{"STATICCALL": true, "REVERT": true},
+				}},
+			},
+			{
+				Name:  "NoRevert",
+				Block: big.NewInt(10),
+				Features: []*ForkFeature{{
+					ID:      "opcodes",
+					Options: ChainFeatureConfigOptions{"REVERT": false},
+				}},
+			},
+		},
+	}
+	tests := []struct {
+		op                 vm.OpCode
+		num                int64
+		enabled, scheduled bool
+	}{
+		{vm.STATICCALL, 4, false, false},
+		{vm.STATICCALL, 5, true, true},
+		{vm.STATICCALL, 10, true, true},
+		{vm.REVERT, 9, true, true},
+		{vm.REVERT, 10, false, true},
+		{vm.RETURNDATASIZE, 10, false, false},
+	}
+	for _, tt := range tests {
+		enabled, scheduled := config.OpcodeEnabled(tt.op, big.NewInt(tt.num))
+		if enabled != tt.enabled || scheduled != tt.scheduled {
+			t.Errorf("%v at %d: have enabled %v, scheduled %v, want %v, %v", tt.op, tt.num, enabled, scheduled, tt.enabled, tt.scheduled)
+		}
+	}
+}
+
+func TestSufficientChainConfig_IsValidOpcodes(t *testing.T) {
+	config := &SufficientChainConfig{
+		Identity:  "custom",
+		Network:   3,
+		Consensus: "cryptonight",
+		Genesis:   DefaultConfigMorden.Genesis,
+		ChainConfig: &ChainConfig{
+			Forks: []*Fork{{
+				Name:  "Byzantium",
+				Block: big.NewInt(1),
+				Features: []*ForkFeature{{
+					ID:      "opcodes",
+					Options: ChainFeatureConfigOptions{"STATICCALL": true, "ADD": false},
+				}},
+			}},
+		},
+	}
+	if invalid, ok := config.IsValid(); ok || invalid != "forks.Byzantium.opcodes.ADD" {
+		t.Errorf("expected unschedulable opcode, got %q (valid: %v)", invalid, ok)
+	}
+	config.ChainConfig.Forks[0].Features[0] = &ForkFeature{
+		ID:      "opcodes",
+		Options: ChainFeatureConfigOptions{"STATICCALL": "yes"},
+	}
+	if invalid, ok := config.IsValid(); ok || invalid != "forks.Byzantium.opcodes.STATICCALL" {
+		t.Errorf("expected invalid opcode setting, got %q (valid: %v)", invalid, ok)
+	}
+	config.ChainConfig.Forks[0].Features[0] = &ForkFeature{
+		ID:      "opcodes",
+		Options: ChainFeatureConfigOptions{"STATICCALL": true, "RETURNDATASIZE": true},
+	}
+	if invalid, ok := config.IsValid(); !ok {
+		t.Errorf("unexpected invalid config: %s", invalid)
+	}
+}
+
+func TestChainConfig_Precompiles(t *testing.T) {
+	// Options as decoded from a JSON configuration file.
+	spec := func(builtin string, base, word float64) map[string]interface{} {
+		return map[string]interface{}{"builtin": builtin, "baseGas": base, "wordGas": word}
+	}
+	config := &SufficientChainConfig{
+		Identity:  "custom",
+		Network:   3,
+		Consensus: "cryptonight",
+		Genesis:   DefaultConfigMorden.Genesis,
+		ChainConfig: &ChainConfig{
+			Forks: []*Fork{
+				{
+					Name:  "Native",
+					Block: big.NewInt(5),
+					Features: []*ForkFeature{{
+						ID: "precompiles",
+						Options: ChainFeatureConfigOptions{
+							"0x0000000000000000000000000000000000000100": spec("sha256", 100, 10),
+							"0x0000000000000000000000000000000000000101": spec("identity", 1, 1),
+						},
+					}},
+				},
+				{
+					Name:  "Repriced",
+					Block: big.NewInt(10),
+					Features: []*ForkFeature{{
+						ID: "precompiles",
+						Options: ChainFeatureConfigOptions{
+							"0x0000000000000000000000000000000000000100": spec("sha256", 200, 10),
+						},
+					}},
+				},
+			},
+		},
+	}
+	if invalid, ok := config.IsValid(); !ok {
+		t.Fatalf("unexpected invalid config: %s", invalid)
+	}
+	sha := common.HexToAddress("0x100")
+	if p := config.ChainConfig.Precompiles(big.NewInt(4)); p != nil {
+		t.Errorf("precompiles before fork: %v", p)
+	}
+	for num, want := range map[int64]int64{5: 110, 10: 210} {
+		p := config.ChainConfig.Precompiles(big.NewInt(num))
+		if len(p) != 2 || p[sha] == nil {
+			t.Fatalf("block %d: precompiles mismatch: %v", num, p)
+		}
+		if gas := p[sha].Gas(make([]byte, 32)); gas.Int64() != want {
+			t.Errorf("block %d: gas mismatch: have %v, want %d", num, gas, want)
+		}
+	}
+	config.ChainConfig.Forks[1].Features[0].Options = ChainFeatureConfigOptions{"0x0100": spec("sha256", 0, 0)}
+	if invalid, ok := config.IsValid(); ok || invalid != "forks.Repriced.precompiles.0x0100" {
+		t.Errorf("expected invalid address, got %q (valid: %v)", invalid, ok)
+	}
+	config.ChainConfig.Forks[1].Features[0] = &ForkFeature{
+		ID:      "precompiles",
+		Options: ChainFeatureConfigOptions{"0x0000000000000000000000000000000000000100": spec("unknown", 0, 0)},
+	}
+	if _, ok := config.IsValid(); ok {
+		t.Error("expected unknown builtin to be invalid")
+	}
+}
+
+func TestChainConfig_IsReceiptStatus(t *testing.T) {
+	config := &ChainConfig{
+		Forks: []*Fork{
+			{
+				Name:  "Byzantium",
+				Block: big.NewInt(5),
+				Features: []*ForkFeature{{
+					ID:      "receipts",
+					Options: ChainFeatureConfigOptions{"type": "status"},
+				}},
+			},
+			{Name: "Atlantis", Block: big.NewInt(10)},
+		},
+	}
+	for num, want := range map[int64]bool{4: false, 5: true, 10: true} {
+		if have := config.IsReceiptStatus(big.NewInt(num)); have != want {
+			t.Errorf("block %d: status receipts %v, want %v", num, have, want)
+		}
+	}
+	// Without the feature, status receipts start with Atlantis.
+	mainnet := DefaultConfigMainnet.ChainConfig
+	atlantis := mainnet.ForkByName("Atlantis").Block
+	if mainnet.IsReceiptStatus(new(big.Int).Sub(atlantis, big.NewInt(1))) || !mainnet.IsReceiptStatus(atlantis) {
+		t.Errorf("mainnet status receipts don't start at Atlantis block %v", atlantis)
+	}
+
+	sconfig := &SufficientChainConfig{
+		Identity:    "custom",
+		Network:     3,
+		Consensus:   "cryptonight",
+		Genesis:     DefaultConfigMorden.Genesis,
+		ChainConfig: config,
+	}
+	if invalid, ok := sconfig.IsValid(); !ok {
+		t.Errorf("unexpected invalid config: %s", invalid)
+	}
+	config.Forks[0].Features[0] = &ForkFeature{
+		ID:      "receipts",
+		Options: ChainFeatureConfigOptions{"type": "eip658"},
+	}
+	if invalid, ok := sconfig.IsValid(); ok || invalid != "forks.Byzantium.receipts.type" {
+		t.Errorf("expected invalid receipt type, got %q (valid: %v)", invalid, ok)
+	}
+}
+
+func TestChainConfig_GasLimitBounds(t *testing.T) {
+	config := &ChainConfig{
+		Forks: []*Fork{
+			{Name: "Homestead", Block: big.NewInt(0)},
+			{
+				Name:  "Larger",
+				Block: big.NewInt(10),
+				Features: []*ForkFeature{{
+					ID:      "gaslimit",
+					Options: ChainFeatureConfigOptions{"min": 8000000, "boundDivisor": 2048},
+				}},
+			},
+		},
+	}
+	if min, divisor := config.GasLimitBounds(big.NewInt(9)); min.Cmp(MinGasLimit) != 0 || divisor.Cmp(GasLimitBoundDivisor) != 0 {
+		t.Errorf("block 9: bounds %v/%v, want defaults", min, divisor)
+	}
+	if min, divisor := config.GasLimitBounds(big.NewInt(10)); min.Int64() != 8000000 || divisor.Int64() != 2048 {
+		t.Errorf("block 10: bounds %v/%v, want 8000000/2048", min, divisor)
+	}
+
+	// Mined gas limits respect the configured bounds.
+	parent := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(9), GasLimit: big.NewInt(8000000), GasUsed: new(big.Int)})
+	if gl := CalcGasLimit(config, parent); gl.Cmp(big.NewInt(8000000)) != 0 {
+		t.Errorf("gas limit %v, want the minimum 8000000", gl)
+	}
+	parent = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(9), GasLimit: big.NewInt(10000000), GasUsed: big.NewInt(10000000)})
+	if gl, want := CalcGasLimit(config, parent), big.NewInt(10000000+10000000*3/2/2048-(10000000/2048-1)); gl.Cmp(want) != 0 {
+		t.Errorf("gas limit %v, want %v", gl, want)
+	}
+}
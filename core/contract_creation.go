@@ -0,0 +1,74 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+var contractCreationPrefix = []byte("contract-creation-") // contractCreationPrefix + address -> creation tx and creator
+
+// ContractCreation is the contract creation index entry of a contract: the
+// transaction which created it and the account, or contract, creating it.
+type ContractCreation struct {
+	TxHash  common.Hash
+	Creator common.Address
+}
+
+// GetContractCreation retrieves the creation of the contract at addr, or nil
+// if it isn't indexed. A contract created again at the same address, after
+// self-destructing, has its latest creation indexed.
+func GetContractCreation(db ethdb.Database, addr common.Address) *ContractCreation {
+	data, _ := db.Get(append(contractCreationPrefix, addr.Bytes()...))
+	if len(data) == 0 {
+		return nil
+	}
+	creation := new(ContractCreation)
+	if err := rlp.DecodeBytes(data, creation); err != nil {
+		glog.V(logger.Error).Infof("invalid contract creation RLP for %x: %v", addr, err)
+		return nil
+	}
+	return creation
+}
+
+// WriteContractCreation stores the creation of the contract at addr.
+func WriteContractCreation(db ethdb.Putter, addr common.Address, creation *ContractCreation) error {
+	data, err := rlp.EncodeToBytes(creation)
+	if err != nil {
+		return err
+	}
+	return db.Put(append(contractCreationPrefix, addr.Bytes()...), data)
+}
+
+// WriteBlockContractCreations indexes the contracts created by the
+// transactions of block, as recorded by statedb while processing it.
+func WriteBlockContractCreations(db ethdb.Putter, block *types.Block, statedb *state.StateDB) error {
+	for _, tx := range block.Transactions() {
+		for _, c := range statedb.GetContractCreations(tx.Hash()) {
+			if err := WriteContractCreation(db, c.Address, &ContractCreation{TxHash: tx.Hash(), Creator: c.Creator}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
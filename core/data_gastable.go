@@ -20,18 +20,16 @@
 package core
 
 import (
-	"math/big"
-
 	"github.com/webchain-network/webchaind/core/vm"
 )
 
 var DefaultDiehardGasTable = &vm.GasTable{
-	ExtcodeSize:     big.NewInt(700),
-	ExtcodeCopy:     big.NewInt(700),
-	Balance:         big.NewInt(400),
-	SLoad:           big.NewInt(200),
-	Calls:           big.NewInt(700),
-	Suicide:         big.NewInt(5000),
-	ExpByte:         big.NewInt(50),
-	CreateBySuicide: big.NewInt(25000),
+	ExtcodeSize:     700,
+	ExtcodeCopy:     700,
+	Balance:         400,
+	SLoad:           200,
+	Calls:           700,
+	Suicide:         5000,
+	ExpByte:         50,
+	CreateBySuicide: 25000,
 }
@@ -52,6 +52,11 @@ var (
 
 	preimagePrefix = "secure-key-" // preimagePrefix + hash -> preimage
 	lookupPrefix   = []byte("l")   // lookupPrefix + hash -> transaction/receipt lookup metadata
+
+	sideBlocksPrefix = []byte("side-blocks-") // sideBlocksPrefix + num -> hashes of non-canonical blocks
+
+	fastSyncPivotKey = []byte("FastSyncPivot") // number of the block fast sync committed as head
+	frozenKey        = []byte("LastFrozen")    // number of the last block moved to the ancient store
 )
 
 // TxLookupEntry is a positional metadata to help looking up the data content of
@@ -297,7 +302,7 @@ func WriteHeader(db ethdb.Database, header *types.Header) error {
 }
 
 // WriteBody serializes the body of a block into the database.
-func WriteBody(db ethdb.Database, hash common.Hash, body *types.Body) error {
+func WriteBody(db ethdb.Putter, hash common.Hash, body *types.Body) error {
 	data, err := rlp.EncodeToBytes(body)
 	if err != nil {
 		return err
@@ -343,7 +348,7 @@ func WriteBlock(db ethdb.Database, block *types.Block) error {
 // WriteBlockReceipts stores all the transaction receipts belonging to a block
 // as a single receipt slice. This is used during chain reorganisations for
 // rescheduling dropped transactions.
-func WriteBlockReceipts(db ethdb.Database, hash common.Hash, receipts types.Receipts) error {
+func WriteBlockReceipts(db ethdb.Putter, hash common.Hash, receipts types.Receipts) error {
 	// Convert the receipts into their storage form and serialize them
 	storageReceipts := make([]*types.ReceiptForStorage, len(receipts))
 	for i, receipt := range receipts {
@@ -368,7 +373,20 @@ func WriteBlockReceipts(db ethdb.Database, hash common.Hash, receipts types.Rece
 // of this within the blockchain.
 func WriteTransactions(db ethdb.Database, block *types.Block) error {
 	batch := db.NewBatch()
+	if err := PutTransactions(batch, block); err != nil {
+		return err
+	}
+	// Write the scheduled data into the database
+	if err := batch.Write(); err != nil {
+		glog.Fatalf("failed to store transactions into database: %v", err)
+		return err
+	}
+	return nil
+}
 
+// PutTransactions queues the transactions of a block and their metadata for
+// storage, see WriteTransactions. It is used to add them to a larger batch.
+func PutTransactions(db ethdb.Putter, block *types.Block) error {
 	// Iterate over each transaction and encode it with its metadata
 	for i, tx := range block.Transactions() {
 		// Encode and queue up the transaction for storage
@@ -376,7 +394,7 @@ func WriteTransactions(db ethdb.Database, block *types.Block) error {
 		if err != nil {
 			return err
 		}
-		if err := batch.Put(tx.Hash().Bytes(), data); err != nil {
+		if err := db.Put(tx.Hash().Bytes(), data); err != nil {
 			return err
 		}
 		// Encode and queue up the transaction metadata for storage
@@ -393,22 +411,29 @@ func WriteTransactions(db ethdb.Database, block *types.Block) error {
 		if err != nil {
 			return err
 		}
-		if err := batch.Put(append(tx.Hash().Bytes(), txMetaSuffix...), data); err != nil {
+		if err := db.Put(append(tx.Hash().Bytes(), txMetaSuffix...), data); err != nil {
 			return err
 		}
 	}
-	// Write the scheduled data into the database
-	if err := batch.Write(); err != nil {
-		glog.Fatalf("failed to store transactions into database: %v", err)
-		return err
-	}
 	return nil
 }
 
 // WriteReceipts stores a batch of transaction receipts into the database.
 func WriteReceipts(db ethdb.Database, receipts types.Receipts) error {
 	batch := db.NewBatch()
+	if err := PutReceipts(batch, receipts); err != nil {
+		return err
+	}
+	// Write the scheduled data into the database
+	if err := batch.Write(); err != nil {
+		glog.Fatalf("failed to store receipts into database: %v", err)
+		return err
+	}
+	return nil
+}
 
+// PutReceipts queues transaction receipts for storage, see WriteReceipts.
+func PutReceipts(db ethdb.Putter, receipts types.Receipts) error {
 	// Iterate over all the receipts and queue them for database injection
 	for _, receipt := range receipts {
 		storageReceipt := (*types.ReceiptForStorage)(receipt)
@@ -416,16 +441,73 @@ func WriteReceipts(db ethdb.Database, receipts types.Receipts) error {
 		if err != nil {
 			return err
 		}
-		if err := batch.Put(append(receiptsPrefix, receipt.TxHash.Bytes()...), data); err != nil {
+		if err := db.Put(append(receiptsPrefix, receipt.TxHash.Bytes()...), data); err != nil {
 			return err
 		}
 	}
-	// Write the scheduled data into the database
-	if err := batch.Write(); err != nil {
-		glog.Fatalf("failed to store receipts into database: %v", err)
+	return nil
+}
+
+// GetSideBlockHashes retrieves the hashes of the non-canonical blocks indexed
+// at a block number.
+func GetSideBlockHashes(db ethdb.Database, number uint64) []common.Hash {
+	data, _ := db.Get(append(sideBlocksPrefix, big.NewInt(int64(number)).Bytes()...))
+	if len(data) == 0 {
+		return nil
+	}
+	var hashes []common.Hash
+	if err := rlp.DecodeBytes(data, &hashes); err != nil {
+		glog.V(logger.Error).Infof("invalid side block hashes RLP for #%d: %v", number, err)
+		return nil
+	}
+	return hashes
+}
+
+// WriteSideBlockHashes stores the hashes of the non-canonical blocks at a
+// block number.
+func WriteSideBlockHashes(db ethdb.Database, number uint64, hashes []common.Hash) error {
+	data, err := rlp.EncodeToBytes(hashes)
+	if err != nil {
 		return err
 	}
-	return nil
+	return db.Put(append(sideBlocksPrefix, big.NewInt(int64(number)).Bytes()...), data)
+}
+
+// DeleteSideBlockHashes removes the side block index of a block number.
+func DeleteSideBlockHashes(db ethdb.Database, number uint64) {
+	db.Delete(append(sideBlocksPrefix, big.NewInt(int64(number)).Bytes()...))
+}
+
+// GetFastSyncPivot retrieves the number of the block the last fast sync
+// committed as the head, reporting false if the chain was never fast synced.
+func GetFastSyncPivot(db ethdb.Database) (uint64, bool) {
+	data, _ := db.Get(fastSyncPivotKey)
+	if len(data) == 0 {
+		return 0, false
+	}
+	return new(big.Int).SetBytes(data).Uint64(), true
+}
+
+// WriteFastSyncPivot stores the number of the block fast sync committed as the
+// head.
+func WriteFastSyncPivot(db ethdb.Database, number uint64) error {
+	return db.Put(fastSyncPivotKey, new(big.Int).SetUint64(number).Bytes())
+}
+
+// GetFrozenBlock retrieves the number of the last block whose data was moved
+// to the ancient store, reporting false if nothing was frozen yet.
+func GetFrozenBlock(db ethdb.Database) (uint64, bool) {
+	data, _ := db.Get(frozenKey)
+	if len(data) == 0 {
+		return 0, false
+	}
+	return new(big.Int).SetBytes(data).Uint64(), true
+}
+
+// WriteFrozenBlock stores the number of the last block whose data was moved to
+// the ancient store.
+func WriteFrozenBlock(db ethdb.Database, number uint64) error {
+	return db.Put(frozenKey, new(big.Int).SetUint64(number).Bytes())
 }
 
 // DeleteCanonicalHash removes the number to hash canonical mapping.
@@ -477,6 +559,13 @@ func PreimageTable(db ethdb.Database) ethdb.Database {
 	return ethdb.NewTable(db, preimagePrefix)
 }
 
+// GetPreimage retrieves the preimage of a hashed trie key, or nil if it isn't
+// known. Secure tries record the preimages of their keys when committed.
+func GetPreimage(db ethdb.Database, hash common.Hash) []byte {
+	data, _ := PreimageTable(db).Get(hash.Bytes())
+	return data
+}
+
 // WritePreimages writes the provided set of preimages to the database. `number` is the
 // current block number, and is used for debug messages only.
 func WritePreimages(db ethdb.Database, number uint64, preimages map[common.Hash][]byte) error {
@@ -18,12 +18,9 @@ package core
 
 import (
 	"bytes"
-	"encoding/json"
-	"fmt"
 	"io/ioutil"
 	"math/big"
 	"os"
-	"strconv"
 	"testing"
 
 	"crypto/ecdsa"
@@ -38,77 +35,6 @@ import (
 	"strings"
 )
 
-type diffTest struct {
-	ParentTimestamp    uint64
-	ParentDifficulty   *big.Int
-	CurrentTimestamp   uint64
-	CurrentBlocknumber *big.Int
-	CurrentDifficulty  *big.Int
-}
-
-func (d *diffTest) UnmarshalJSON(b []byte) (err error) {
-	var ext struct {
-		ParentTimestamp    string
-		ParentDifficulty   string
-		CurrentTimestamp   string
-		CurrentBlocknumber string
-		CurrentDifficulty  string
-	}
-	if err := json.Unmarshal(b, &ext); err != nil {
-		return err
-	}
-
-	d.ParentTimestamp, err = strconv.ParseUint(ext.ParentTimestamp, 0, 64)
-	if err != nil {
-		return fmt.Errorf("malformed parent timestamp: %s", err)
-	}
-
-	d.ParentDifficulty = new(big.Int)
-	if _, ok := d.ParentDifficulty.SetString(ext.ParentDifficulty, 0); !ok {
-		return fmt.Errorf("malformed parent difficulty %q", ext.ParentDifficulty)
-	}
-
-	d.CurrentTimestamp, err = strconv.ParseUint(ext.CurrentTimestamp, 0, 64)
-	if err != nil {
-		return fmt.Errorf("malformed current timestamp: %s", err)
-	}
-
-	d.CurrentBlocknumber = new(big.Int)
-	if _, ok := d.CurrentBlocknumber.SetString(ext.CurrentBlocknumber, 0); !ok {
-		return fmt.Errorf("malformed current blocknumber %q", ext.CurrentBlocknumber)
-	}
-
-	d.CurrentDifficulty = new(big.Int)
-	if _, ok := d.CurrentDifficulty.SetString(ext.CurrentDifficulty, 0); !ok {
-		return fmt.Errorf("malformed current difficulty %q", ext.CurrentDifficulty)
-	}
-
-	return nil
-}
-
-func TestDifficultyFrontier(t *testing.T) {
-	file, err := os.Open("../tests/files/BasicTests/difficulty.json")
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer file.Close()
-
-	tests := make(map[string]diffTest)
-	err = json.NewDecoder(file).Decode(&tests)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	for name, test := range tests {
-		number := new(big.Int).Sub(test.CurrentBlocknumber, big.NewInt(1))
-		diff := calcDifficultyFrontier(test.CurrentTimestamp, test.ParentTimestamp, number, test.ParentDifficulty)
-		if diff.Cmp(test.CurrentDifficulty) != 0 {
-			t.Error(name, "failed. Expected", test.CurrentDifficulty, "and calculated", diff)
-		}
-	}
-}
-
-// Tests block header storage and retrieval operations.
 func TestHeaderStorage(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 
@@ -971,3 +897,19 @@ func TestMipmapChain(t *testing.T) {
 		t.Error("address was included in bloom and should not have")
 	}
 }
+
+func TestPreimageStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	key := []byte("preimage")
+	hash := crypto.Keccak256Hash(key)
+	if preimage := GetPreimage(db, hash); preimage != nil {
+		t.Fatalf("non existent preimage returned: %x", preimage)
+	}
+	if err := WritePreimages(db, 0, map[common.Hash][]byte{hash: key}); err != nil {
+		t.Fatalf("failed to write preimage: %v", err)
+	}
+	if preimage := GetPreimage(db, hash); !bytes.Equal(preimage, key) {
+		t.Fatalf("preimage mismatch: have %x, want %x", preimage, key)
+	}
+}
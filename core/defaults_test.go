@@ -17,58 +17,43 @@ func TestDefaultChainConfigurationVariablesExist(t *testing.T) {
 		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.Identity, "mainnet")
 	}
 	if DefaultConfigMorden.Identity != "morden" {
-		t.Errorf("got: %v, want: %v", DefaultConfigMorden.Identity, "mainnet")
+		t.Errorf("got: %v, want: %v", DefaultConfigMorden.Identity, "morden")
 	}
 
-	if DefaultConfigMainnet.Name != "Ethereum Classic Mainnet" {
-		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.Name, "Ethereum Classic Mainnet")
+	if DefaultConfigMainnet.Name != "Webchain Mainnet" {
+		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.Name, "Webchain Mainnet")
 	}
-	if DefaultConfigMorden.Name != "Morden Testnet" {
-		t.Errorf("got: %v, want: %v", DefaultConfigMorden.Name, "Morden Testnet")
+	if DefaultConfigMorden.Name != "Webchain Testnet" {
+		t.Errorf("got: %v, want: %v", DefaultConfigMorden.Name, "Webchain Testnet")
 	}
 
-	if DefaultConfigMainnet.ChainConfig.GetChainID().Cmp(big.NewInt(61)) != 0 {
-		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.ChainConfig.GetChainID(), big.NewInt(61))
+	if DefaultConfigMainnet.ChainConfig.GetChainID(nil).Cmp(big.NewInt(24484)) != 0 {
+		t.Errorf("got: %v, want: %v", DefaultConfigMainnet.ChainConfig.GetChainID(nil), big.NewInt(24484))
 	}
-	if DefaultConfigMorden.ChainConfig.GetChainID().Cmp(big.NewInt(62)) != 0 {
-		t.Errorf("got: %v, want: %v", DefaultConfigMorden.ChainConfig.GetChainID(), big.NewInt(62))
+	if DefaultConfigMorden.ChainConfig.GetChainID(nil).Cmp(big.NewInt(24485)) != 0 {
+		t.Errorf("got: %v, want: %v", DefaultConfigMorden.ChainConfig.GetChainID(nil), big.NewInt(24485))
 	}
 
 	// Test forks existence and block numbers
-	// Homestead
-	if fork := DefaultConfigMainnet.ChainConfig.ForkByName("Homestead"); fork.Block.Cmp(big.NewInt(1150000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	if fork := DefaultConfigMorden.ChainConfig.ForkByName("Homestead"); fork.Block.Cmp(big.NewInt(494000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	// The DAO Hard Fork
-	if fork := DefaultConfigMainnet.ChainConfig.ForkByName("The DAO Hard Fork"); fork.Block.Cmp(big.NewInt(1920000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	if fork := DefaultConfigMorden.ChainConfig.ForkByName("The DAO Hard Fork"); fork.Block.Cmp(big.NewInt(1885000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	// GasReprice
-	if fork := DefaultConfigMainnet.ChainConfig.ForkByName("GasReprice"); fork.Block.Cmp(big.NewInt(2500000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	if fork := DefaultConfigMorden.ChainConfig.ForkByName("GasReprice"); fork.Block.Cmp(big.NewInt(1783000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	// Diehard
-	if fork := DefaultConfigMainnet.ChainConfig.ForkByName("Diehard"); fork.Block.Cmp(big.NewInt(3000000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	if fork := DefaultConfigMorden.ChainConfig.ForkByName("Diehard"); fork.Block.Cmp(big.NewInt(1915000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	// Gotham
-	if fork := DefaultConfigMainnet.ChainConfig.ForkByName("Gotham"); fork.Block.Cmp(big.NewInt(5000000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
-	}
-	if fork := DefaultConfigMorden.ChainConfig.ForkByName("Gotham"); fork.Block.Cmp(big.NewInt(2000000)) != 0 {
-		t.Errorf("Unexpected fork: %v", fork)
+	forks := []struct {
+		Name            string
+		Mainnet, Morden int64
+	}{
+		{"Diehard", 1, 1},
+		{"Hardfork1", 2022222, 1},
+		{"LYRA2", 2022222, 1},
+		{"Hardfork2", 2619000, 1},
+		{"LYRA2v2", 2619000, 1},
+		{"Atlantis", 3300001, 10},
+		{"Halving", 3300001, 3300001},
+	}
+	for _, f := range forks {
+		if fork := DefaultConfigMainnet.ChainConfig.ForkByName(f.Name); fork.Block == nil || fork.Block.Cmp(big.NewInt(f.Mainnet)) != 0 {
+			t.Errorf("Unexpected mainnet fork %s: %v", f.Name, fork)
+		}
+		if fork := DefaultConfigMorden.ChainConfig.ForkByName(f.Name); fork.Block == nil || fork.Block.Cmp(big.NewInt(f.Morden)) != 0 {
+			t.Errorf("Unexpected morden fork %s: %v", f.Name, fork)
+		}
 	}
 
 	checks := []struct {
@@ -77,156 +62,67 @@ func TestDefaultChainConfigurationVariablesExist(t *testing.T) {
 		Name     string
 		Features []*ForkFeature
 	}{
-		{
-			Config: DefaultConfigMorden,
-			Block:  big.NewInt(494000),
-			Name:   "Homestead",
-			Features: []*ForkFeature{
-				{
-					ID: "difficulty",
-					Options: ChainFeatureConfigOptions{
-						"type": "homestead",
-					},
-				},
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "homestead",
-					},
-				},
-			},
-		},
-		{
-			Config: DefaultConfigMainnet,
-			Block:  big.NewInt(1150000),
-			Name:   "Homestead",
-			Features: []*ForkFeature{
-				{
-					ID: "difficulty",
-					Options: ChainFeatureConfigOptions{
-						"type": "homestead",
-					},
-				},
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "homestead",
-					},
-				},
-			},
-		},
-		{
-			Config: DefaultConfigMorden,
-			Block:  big.NewInt(1885000),
-			Name:   "The DAO Hard Fork",
-		},
 		{
 			Config: DefaultConfigMainnet,
-			Block:  big.NewInt(1920000),
-			Name:   "The DAO Hard Fork",
-		},
-		{
-			Config: DefaultConfigMorden,
-			Block:  big.NewInt(1783000),
-			Name:   "GasReprice",
+			Block:  big.NewInt(1),
+			Name:   "Diehard",
 			Features: []*ForkFeature{
 				{
-					ID: "gastable",
+					ID: "eip155",
 					Options: ChainFeatureConfigOptions{
-						"type": "eip150",
+						"chainID": 101,
 					},
 				},
 			},
 		},
 		{
 			Config: DefaultConfigMainnet,
-			Block:  big.NewInt(2500000),
-			Name:   "GasReprice",
+			Block:  big.NewInt(2022222),
+			Name:   "Hardfork1",
 			Features: []*ForkFeature{
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "eip150",
-					},
-				},
-			},
-		},
-		{
-			Config: DefaultConfigMorden,
-			Block:  big.NewInt(1915000),
-			Name:   "Diehard",
-			Features: []*ForkFeature{
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "eip160",
-					},
-				},
 				{
 					ID: "eip155",
 					Options: ChainFeatureConfigOptions{
-						"chainID": big.NewInt(62),
-					},
-				},
-				{
-					ID: "difficulty",
-					Options: ChainFeatureConfigOptions{
-						"length": big.NewInt(2000000),
-						"type":   "ecip1010",
+						"chainID": 24484,
 					},
 				},
 			},
 		},
 		{
 			Config: DefaultConfigMainnet,
-			Block:  big.NewInt(3000000),
-			Name:   "Diehard",
+			Block:  big.NewInt(3300001),
+			Name:   "Atlantis",
 			Features: []*ForkFeature{
-				{
-					ID: "gastable",
-					Options: ChainFeatureConfigOptions{
-						"type": "eip160",
-					},
-				},
-				{
-					ID: "eip155",
-					Options: ChainFeatureConfigOptions{
-						"chainID": big.NewInt(61),
-					},
-				},
 				{
 					ID: "difficulty",
 					Options: ChainFeatureConfigOptions{
-						"length": big.NewInt(2000000),
-						"type":   "ecip1010",
+						"type": "atlantis",
 					},
 				},
 			},
 		},
 		{
 			Config: DefaultConfigMorden,
-			Block:  big.NewInt(2000000),
-			Name:   "Gotham",
+			Block:  big.NewInt(1),
+			Name:   "Hardfork1",
 			Features: []*ForkFeature{
 				{
-					ID: "reward",
+					ID: "eip155",
 					Options: ChainFeatureConfigOptions{
-						"era":  big.NewInt(2000000),
-						"type": "ecip1017",
+						"chainID": 24485,
 					},
 				},
 			},
 		},
 		{
-			Config: DefaultConfigMainnet,
-			Block:  big.NewInt(5000000),
-			Name:   "Gotham",
+			Config: DefaultConfigMorden,
+			Block:  big.NewInt(10),
+			Name:   "Atlantis",
 			Features: []*ForkFeature{
 				{
-					ID: "reward",
+					ID: "difficulty",
 					Options: ChainFeatureConfigOptions{
-						"era":  big.NewInt(5000000),
-						"type": "ecip1017",
+						"type": "atlantis",
 					},
 				},
 			},
@@ -234,26 +130,26 @@ func TestDefaultChainConfigurationVariablesExist(t *testing.T) {
 	}
 	for _, check := range checks {
 		// Ensure fork exists at correct block
-		if fork := check.Config.ChainConfig.ForkByName(check.Name); fork.Block.Cmp(check.Block) != 0 {
+		if fork := check.Config.ChainConfig.ForkByName(check.Name); fork.Block == nil || fork.Block.Cmp(check.Block) != 0 {
 			t.Errorf("got: %v, want: %v", fork.Block, check.Block)
 		}
 		for _, feat := range check.Features {
 			ff, f, ok := check.Config.ChainConfig.GetFeature(check.Block, feat.ID)
 			if !ok {
 				t.Errorf("unfound fork feat: %s", feat.ID)
+				continue
 			}
-			for k, v := range ff.Options {
-				switch v.(type) {
-				case *big.Int:
-					if big.NewInt(feat.Options[k].(int64)).Cmp(big.NewInt(v.(int64))) != 0 {
-						t.Errorf("mismatch for feature options: got: %v/%v, want: %v/%v", k, feat.Options[k], k, v)
+			for k, v := range feat.Options {
+				switch v := v.(type) {
+				case int:
+					if got, ok := ff.GetBigInt(k); !ok || got.Cmp(big.NewInt(int64(v))) != 0 {
+						t.Errorf("mismatch for feature options: got: %v/%v, want: %v/%v", k, got, k, v)
 					}
 				case string:
-					if feat.Options[k] != v {
-						t.Errorf("mismatch for feature options: got: %v/%v, want: %v/%v", k, feat.Options[k], k, v)
+					if got, ok := ff.GetString(k); !ok || got != v {
+						t.Errorf("mismatch for feature options: got: %v/%v, want: %v/%v", k, got, k, v)
 					}
 				}
-
 			}
 			if f.Block.Cmp(check.Block) != 0 {
 				t.Errorf("feature fork block wrong: got: %v, want: %v", f.Block, check.Block)
@@ -262,11 +158,11 @@ func TestDefaultChainConfigurationVariablesExist(t *testing.T) {
 	}
 
 	// Number of bootstrap nodes
-	if l := len(DefaultConfigMainnet.ParsedBootstrap); l != 10 {
-		t.Errorf("got: %v, want: %v", l, 10)
+	if l := len(DefaultConfigMainnet.ParsedBootstrap); l != 2 {
+		t.Errorf("got: %v, want: %v", l, 2)
 	}
-	if l := len(DefaultConfigMorden.ParsedBootstrap); l != 21 {
-		t.Errorf("got: %v, want: %v", l, 21)
+	if l := len(DefaultConfigMorden.ParsedBootstrap); l != 0 {
+		t.Errorf("got: %v, want: %v", l, 0)
 	}
 
 	// Config validity checks.
@@ -0,0 +1,153 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/webchain-network/webchaind/core/types"
+)
+
+// EraLength is the number of blocks per reward era.
+var EraLength = big.NewInt(100000)
+
+// RewardEra describes the block range and rewards of a single reward era.
+type RewardEra struct {
+	Era         *big.Int `json:"era"`
+	FirstBlock  *big.Int `json:"firstBlock"`
+	LastBlock   *big.Int `json:"lastBlock"`
+	BlockReward *big.Int `json:"blockReward"`
+	UncleReward *big.Int `json:"uncleReward"` // Paid to both the uncle miner and the including miner
+}
+
+// GetRewardEra returns the reward era with the given (zero-indexed) number.
+// Era n spans blocks n*EraLength+1 through (n+1)*EraLength; the genesis block
+// pays no reward.
+func GetRewardEra(era *big.Int) *RewardEra {
+	first := new(big.Int).Mul(era, EraLength)
+	first.Add(first, big1)
+	last := new(big.Int).Add(first, EraLength)
+	last.Sub(last, big1)
+
+	return &RewardEra{
+		Era:         new(big.Int).Set(era),
+		FirstBlock:  first,
+		LastBlock:   last,
+		BlockReward: GetBlockWinnerRewardByEra(era),
+		UncleReward: getEraUncleBlockReward(era),
+	}
+}
+
+// GetRewardEras returns count consecutive reward eras, starting with the era
+// of the given block.
+func GetRewardEras(blockNum *big.Int, count int) []*RewardEra {
+	eras := make([]*RewardEra, 0, count)
+	era := GetBlockEra(blockNum, EraLength)
+	for i := 0; i < count; i++ {
+		eras = append(eras, GetRewardEra(era))
+		era = new(big.Int).Add(era, big1)
+	}
+	return eras
+}
+
+// BlockEmission returns the cumulative static block rewards paid to block winners
+// from genesis through the given block. Uncle rewards depend on the uncles actually
+// included and are not part of the schedule; see UncleEmission.
+func BlockEmission(blockNum *big.Int) *big.Int {
+	total := new(big.Int)
+	if blockNum.Sign() < 1 {
+		return total
+	}
+	current := GetBlockEra(blockNum, EraLength)
+	for era := new(big.Int); era.Cmp(current) < 0; era.Add(era, big1) {
+		total.Add(total, new(big.Int).Mul(GetBlockWinnerRewardByEra(era), EraLength))
+	}
+	// Partial current era.
+	first := GetRewardEra(current).FirstBlock
+	blocks := new(big.Int).Sub(blockNum, first)
+	blocks.Add(blocks, big1)
+	return total.Add(total, blocks.Mul(blocks, GetBlockWinnerRewardByEra(current)))
+}
+
+// UncleEmission returns the cumulative uncle rewards paid from genesis through the
+// given block, including the share paid to the including miners. It reads every
+// block body with uncles and is therefore slow on long chains.
+func UncleEmission(bc *BlockChain, blockNum *big.Int) *big.Int {
+	total := new(big.Int)
+	for n := uint64(1); n <= blockNum.Uint64(); n++ {
+		header := bc.GetHeaderByNumber(n)
+		if header == nil {
+			break
+		}
+		if header.UncleHash == types.EmptyUncleHash {
+			continue
+		}
+		body := bc.GetBody(header.Hash())
+		if body == nil || len(body.Uncles) == 0 {
+			continue
+		}
+		reward := getEraUncleBlockReward(GetBlockEra(header.Number, EraLength))
+		total.Add(total, reward.Mul(reward, big.NewInt(int64(2*len(body.Uncles)))))
+	}
+	return total
+}
+
+// GenesisAllocation returns the sum of all balances allocated in the genesis block.
+func GenesisAllocation(bc *BlockChain) (*big.Int, error) {
+	statedb, err := bc.StateAt(bc.Genesis().Root())
+	if err != nil {
+		return nil, err
+	}
+	return statedb.TotalBalance()
+}
+
+// Emission summarises the coin supply at a given block.
+type Emission struct {
+	Block *big.Int `json:"block"`
+	// Genesis is the genesis allocation.
+	Genesis *big.Int `json:"genesis"`
+	// BlockRewards are the cumulative static block rewards.
+	BlockRewards *big.Int `json:"blockRewards"`
+	// UncleRewards are the cumulative uncle rewards, nil if not counted.
+	UncleRewards *big.Int `json:"uncleRewards,omitempty"`
+	// Supply is the circulating supply: genesis allocation, block rewards and,
+	// if counted, uncle rewards.
+	Supply *big.Int `json:"supply"`
+	// Eras are the current and upcoming reward eras.
+	Eras []*RewardEra `json:"eras"`
+}
+
+// GetEmission computes the emission at the given block, optionally counting uncle
+// rewards. The current reward era and the given number of upcoming eras are listed.
+func GetEmission(bc *BlockChain, blockNum *big.Int, uncles bool, eras int) (*Emission, error) {
+	genesis, err := GenesisAllocation(bc)
+	if err != nil {
+		return nil, err
+	}
+	e := &Emission{
+		Block:        new(big.Int).Set(blockNum),
+		Genesis:      genesis,
+		BlockRewards: BlockEmission(blockNum),
+		Eras:         GetRewardEras(blockNum, eras+1),
+	}
+	e.Supply = new(big.Int).Add(e.Genesis, e.BlockRewards)
+	if uncles {
+		e.UncleRewards = UncleEmission(bc, blockNum)
+		e.Supply.Add(e.Supply, e.UncleRewards)
+	}
+	return e, nil
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestGetRewardEra(t *testing.T) {
+	era := GetRewardEra(big.NewInt(2))
+	if era.FirstBlock.Int64() != 200001 || era.LastBlock.Int64() != 300000 {
+		t.Errorf("era 2 spans %v-%v, want 200001-300000", era.FirstBlock, era.LastBlock)
+	}
+	for _, n := range []*big.Int{era.FirstBlock, era.LastBlock} {
+		if GetBlockEra(n, EraLength).Cmp(era.Era) != 0 {
+			t.Errorf("block %v not in era %v", n, era.Era)
+		}
+	}
+	if want := new(big.Int).Div(era.BlockReward, big32); era.UncleReward.Cmp(want) != 0 {
+		t.Errorf("uncle reward %v, want %v", era.UncleReward, want)
+	}
+}
+
+func TestBlockEmission(t *testing.T) {
+	r0 := GetBlockWinnerRewardByEra(big.NewInt(0))
+	r1 := GetBlockWinnerRewardByEra(big.NewInt(1))
+
+	if e := BlockEmission(big.NewInt(0)); e.Sign() != 0 {
+		t.Errorf("genesis emission %v, want 0", e)
+	}
+	if e, want := BlockEmission(big.NewInt(10)), new(big.Int).Mul(r0, big.NewInt(10)); e.Cmp(want) != 0 {
+		t.Errorf("block 10 emission %v, want %v", e, want)
+	}
+	want := new(big.Int).Mul(r0, EraLength)
+	want.Add(want, new(big.Int).Mul(r1, big.NewInt(5)))
+	if e := BlockEmission(big.NewInt(100005)); e.Cmp(want) != 0 {
+		t.Errorf("block 100005 emission %v, want %v", e, want)
+	}
+
+	// Emission must equal the sum of per-block rewards across skipped eras.
+	sum := new(big.Int)
+	for era := int64(0); era < 60; era++ {
+		sum.Add(sum, new(big.Int).Mul(GetBlockWinnerRewardByEra(big.NewInt(era)), EraLength))
+	}
+	if e := BlockEmission(big.NewInt(60 * 100000)); e.Cmp(sum) != 0 {
+		t.Errorf("emission through era 59 %v, want %v", e, sum)
+	}
+}
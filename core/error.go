@@ -50,6 +50,36 @@ func IsParentErr(err error) bool {
 	return ok
 }
 
+// ChainIdErr is returned when a replay-protected transaction was signed for
+// a chain other than the one this node is configured for.
+type ChainIdErr struct {
+	Have, Want *big.Int
+}
+
+func (err *ChainIdErr) Error() string {
+	return fmt.Sprintf("Invalid chain id: transaction signed for chain id %v, but this network requires chain id %v", err.Have, err.Want)
+}
+
+func IsChainIdErr(err error) bool {
+	_, ok := err.(*ChainIdErr)
+	return ok
+}
+
+// NonceGapErr is returned when a transaction's nonce is further ahead of the
+// sender's next nonce than the pool allows.
+type NonceGapErr struct {
+	Nonce, Next, Max uint64
+}
+
+func (err *NonceGapErr) Error() string {
+	return fmt.Sprintf("Nonce too high: transaction nonce %d skips %d nonces after the next nonce %d, at most %d allowed", err.Nonce, err.Nonce-err.Next, err.Next, err.Max)
+}
+
+func IsNonceGapErr(err error) bool {
+	_, ok := err.(*NonceGapErr)
+	return ok
+}
+
 type UncleErr struct {
 	Message string
 }
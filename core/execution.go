@@ -40,22 +40,25 @@ var (
 	errContractAddressCollision = errors.New("contract address collision")
 )
 
+// creationRecorder is implemented by databases indexing the contracts created
+// by each transaction.
+type creationRecorder interface {
+	AddContractCreation(addr, creator common.Address)
+}
+
 // Call executes the contract associated with the addr with the given input as
 // parameters. It also handles any necessary value transfer required and takes
 // the necessary steps to create accounts and reverses the state in case of an
-// execution error or failed value transfer.
-func Call(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice, value *big.Int) (ret []byte, err error) {
+// execution error or failed value transfer. The gas the execution leaves is
+// returned, for the caller to take back.
+func Call(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas uint64, gasPrice, value *big.Int) (ret []byte, leftOverGas uint64, err error) {
 	// Depth check execution. Fail if we're trying to execute above the limit.
 	if env.Depth() > callCreateDepthMax {
-		caller.ReturnGas(gas, gasPrice)
-
-		return nil, errCallCreateDepth
+		return nil, gas, errCallCreateDepth
 	}
 
 	if !env.CanTransfer(caller.Address(), value) {
-		caller.ReturnGas(gas, gasPrice)
-
-		return nil, ValueTransferErr("insufficient funds to transfer value. Req %v, has %v", value, env.Db().GetBalance(caller.Address()))
+		return nil, gas, ValueTransferErr("insufficient funds to transfer value. Req %v, has %v", value, env.Db().GetBalance(caller.Address()))
 	}
 
 	var (
@@ -65,13 +68,9 @@ func Call(env vm.Environment, caller vm.ContractRef, addr common.Address, input
 		isHardfork2 = env.RuleSet().IsHardfork2(env.BlockNumber())
 	)
 	if !env.Db().Exist(addr) {
-		precompiles := vm.PrecompiledPreAtlantis
-		if isHardfork2 {
-			precompiles = vm.PrecompiledAtlantis
-		}
+		precompiles := vm.ActivePrecompiles(env.RuleSet(), env.BlockNumber())
 		if precompiles[addr.Str()] == nil && isHardfork2 && value.BitLen() == 0 {
-			caller.ReturnGas(gas, gasPrice)
-			return nil, nil
+			return nil, gas, nil
 		}
 		to = env.Db().CreateAccount(addr)
 	} else {
@@ -82,7 +81,6 @@ func Call(env vm.Environment, caller vm.ContractRef, addr common.Address, input
 	// The contract is a scoped environment for this execution context only.
 	contract := vm.NewContract(caller, to, value, gas, gasPrice)
 	contract.SetCallCode(&addr, env.Db().GetCodeHash(addr), env.Db().GetCode(addr))
-	defer contract.Finalise()
 
 	// Even if the account has no code, we need to continue because it might be a precompile
 	ret, err = env.Vm().Run(contract, input, false)
@@ -96,22 +94,18 @@ func Call(env vm.Environment, caller vm.ContractRef, addr common.Address, input
 			contract.UseGas(contract.Gas)
 		}
 	}
-	return ret, err
+	return ret, contract.Gas, err
 }
 
 // CallCode executes the given address' code as the given contract address
-func CallCode(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice, value *big.Int) (ret []byte, err error) {
+func CallCode(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas uint64, gasPrice, value *big.Int) (ret []byte, leftOverGas uint64, err error) {
 	// Depth check execution. Fail if we're trying to execute above the limit.
 	if env.Depth() > callCreateDepthMax {
-		caller.ReturnGas(gas, gasPrice)
-
-		return nil, errCallCreateDepth
+		return nil, gas, errCallCreateDepth
 	}
 
 	if !env.CanTransfer(caller.Address(), value) {
-		caller.ReturnGas(gas, gasPrice)
-
-		return nil, ValueTransferErr("insufficient funds to transfer value. Req %v, has %v", value, env.Db().GetBalance(caller.Address()))
+		return nil, gas, ValueTransferErr("insufficient funds to transfer value. Req %v, has %v", value, env.Db().GetBalance(caller.Address()))
 	}
 
 	var (
@@ -122,7 +116,6 @@ func CallCode(env vm.Environment, caller vm.ContractRef, addr common.Address, in
 	// The contract is a scoped environment for this execution context only.
 	contract := vm.NewContract(caller, to, value, gas, gasPrice)
 	contract.SetCallCode(&addr, env.Db().GetCodeHash(addr), env.Db().GetCode(addr))
-	defer contract.Finalise()
 
 	// Even if the account has no code, we need to continue because it might be a precompile
 	ret, err = env.Vm().Run(contract, input, false)
@@ -133,16 +126,14 @@ func CallCode(env vm.Environment, caller vm.ContractRef, addr common.Address, in
 			contract.UseGas(contract.Gas)
 		}
 	}
-	return ret, err
+	return ret, contract.Gas, err
 }
 
 // DelegateCall is equivalent to CallCode except that sender and value propagates from parent scope to child scope
-func DelegateCall(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice *big.Int) (ret []byte, err error) {
+func DelegateCall(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas uint64, gasPrice *big.Int) (ret []byte, leftOverGas uint64, err error) {
 	// Depth check execution. Fail if we're trying to execute above the limit.
 	if env.Depth() > callCreateDepthMax {
-		caller.ReturnGas(gas, gasPrice)
-
-		return nil, errCallCreateDepth
+		return nil, gas, errCallCreateDepth
 	}
 
 	var (
@@ -159,7 +150,6 @@ func DelegateCall(env vm.Environment, caller vm.ContractRef, addr common.Address
 	// The contract is a scoped environment for this execution context only.
 	contract := vm.NewContract(caller, to, caller.Value(), gas, gasPrice).AsDelegate()
 	contract.SetCallCode(&addr, env.Db().GetCodeHash(addr), env.Db().GetCode(addr))
-	defer contract.Finalise()
 
 	// Even if the account has no code, we need to continue because it might be a precompile
 	ret, err = env.Vm().Run(contract, input, false)
@@ -173,16 +163,14 @@ func DelegateCall(env vm.Environment, caller vm.ContractRef, addr common.Address
 			contract.UseGas(contract.Gas)
 		}
 	}
-	return ret, err
+	return ret, contract.Gas, err
 }
 
 // StaticCall executes within the given contract and throws exception if state is attempted to be changed
-func StaticCall(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice *big.Int) (ret []byte, err error) {
+func StaticCall(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas uint64, gasPrice *big.Int) (ret []byte, leftOverGas uint64, err error) {
 	// Depth check execution. Fail if we're trying to execute above the limit.
 	if env.Depth() > callCreateDepthMax {
-		caller.ReturnGas(gas, gasPrice)
-
-		return nil, errCallCreateDepth
+		return nil, gas, errCallCreateDepth
 	}
 
 	var (
@@ -199,7 +187,6 @@ func StaticCall(env vm.Environment, caller vm.ContractRef, addr common.Address,
 	// The contract is a scoped environment for this execution context only.
 	contract := vm.NewContract(caller, to, new(big.Int), gas, gasPrice)
 	contract.SetCallCode(&addr, env.Db().GetCodeHash(addr), env.Db().GetCode(addr))
-	defer contract.Finalise()
 
 	// We do an AddBalance of zero here, just in order to trigger a touch.
 	// This is done to keep consensus with other clients since empty objects
@@ -218,33 +205,47 @@ func StaticCall(env vm.Environment, caller vm.ContractRef, addr common.Address,
 			contract.UseGas(contract.Gas)
 		}
 	}
-	return ret, err
+	return ret, contract.Gas, err
 }
 
 // Create creates a new contract with the given code
-func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPrice, value *big.Int) (ret []byte, address common.Address, err error) {
+func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas uint64, gasPrice, value *big.Int) (ret []byte, address common.Address, leftOverGas uint64, err error) {
+	return create(env, caller, code, gas, gasPrice, value, nil)
+}
+
+// Create2 creates a new contract with the given code at an address derived
+// from the caller, the salt and the code instead of the caller's nonce.
+func Create2(env vm.Environment, caller vm.ContractRef, code []byte, gas uint64, gasPrice, value, salt *big.Int) (ret []byte, address common.Address, leftOverGas uint64, err error) {
+	return create(env, caller, code, gas, gasPrice, value, salt)
+}
+
+func create(env vm.Environment, caller vm.ContractRef, code []byte, gas uint64, gasPrice, value, salt *big.Int) (ret []byte, address common.Address, leftOverGas uint64, err error) {
 	// Depth check execution. Fail if we're trying to execute above the limit.
 	if env.Depth() > callCreateDepthMax {
-		caller.ReturnGas(gas, gasPrice)
-
-		return nil, common.Address{}, errCallCreateDepth
+		return nil, common.Address{}, gas, errCallCreateDepth
 	}
 
 	if !env.CanTransfer(caller.Address(), value) {
-		caller.ReturnGas(gas, gasPrice)
-
-		return nil, common.Address{}, ValueTransferErr("insufficient funds to transfer value. Req %v, has %v", value, env.Db().GetBalance(caller.Address()))
+		return nil, common.Address{}, gas, ValueTransferErr("insufficient funds to transfer value. Req %v, has %v", value, env.Db().GetBalance(caller.Address()))
 	}
 
 	// Create a new account on the state
 	nonce := env.Db().GetNonce(caller.Address())
 	env.Db().SetNonce(caller.Address(), nonce+1)
-	address = crypto.CreateAddress(caller.Address(), nonce)
+	if salt == nil {
+		address = crypto.CreateAddress(caller.Address(), nonce)
+	} else {
+		address = crypto.CreateAddress2(caller.Address(), common.BigToHash(salt), crypto.Keccak256(code))
+	}
+	// The address is warm even if the creation fails
+	if al := vm.AccessListOf(env); al != nil {
+		al.AddAddressToAccessList(address)
+	}
 
 	// Ensure there's no existing contract already at the designated address
 	contractHash := env.Db().GetCodeHash(address)
 	if env.Db().GetNonce(address) != state.StartingNonce || (contractHash != (common.Hash{}) && contractHash != emptyCodeHash) {
-		return nil, common.Address{}, errContractAddressCollision
+		return nil, common.Address{}, 0, errContractAddressCollision
 	}
 
 	var (
@@ -264,7 +265,6 @@ func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPric
 	// only.
 	contract := vm.NewContract(caller, to, value, gas, gasPrice)
 	contract.SetCallCode(nil, crypto.Keccak256Hash(code), code)
-	defer contract.Finalise()
 
 	ret, err = env.Vm().Run(contract, nil, false)
 
@@ -274,9 +274,7 @@ func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPric
 	// be stored due to not enough gas set an error and let it be handled
 	// by the error checking condition below.
 	if err == nil && !maxCodeSizeExceeded {
-		dataGas := big.NewInt(int64(len(ret)))
-		// create data gas
-		dataGas.Mul(dataGas, params.CreateDataGas)
+		dataGas := uint64(len(ret)) * params.CreateDataGas
 		if contract.UseGas(dataGas) {
 			env.Db().SetCode(address, ret)
 		} else {
@@ -298,12 +296,15 @@ func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPric
 	if maxCodeSizeExceeded && err == nil {
 		err = errMaxCodeSizeExceeded
 	}
+	if r, ok := env.Db().(creationRecorder); ok && err == nil {
+		r.AddContractCreation(address, caller.Address())
+	}
 
 	//if there's an error we return nothing
 	if err != nil && err != vm.ErrRevert {
-		return nil, address, err
+		return nil, address, contract.Gas, err
 	}
-	return ret, address, err
+	return ret, address, contract.Gas, err
 }
 
 // generic transfer method
@@ -0,0 +1,343 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+// create2Factory deploys the 5 byte init code 0x60016000f3, which returns a
+// single STOP, with CREATE2 and salt 42, storing the result in slot 0.
+var create2Factory = common.FromHex("6460016000f3600052602a6005601b6000f5600055")
+
+// applyCall applies a transaction from the key's account to the given address
+// on top of statedb, returning whether execution failed.
+func applyCall(t *testing.T, statedb *state.StateDB, config *ChainConfig, key *ecdsa.PrivateKey, to common.Address) bool {
+	header := &types.Header{Number: big.NewInt(1), GasLimit: big.NewInt(1000000)}
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	tx, _ := types.NewTransaction(statedb.GetNonce(sender), to, new(big.Int), big.NewInt(200000), new(big.Int), nil).SignECDSA(key)
+	env := NewEnv(statedb, config, nil, tx, header)
+	_, _, failed, err := ApplyMessage(env, tx, new(GasPool).AddGas(header.GasLimit))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return failed
+}
+
+// opcodeConfig returns a chain config enabling the given opcodes from block 1.
+func opcodeConfig(ops ...string) *ChainConfig {
+	options := make(ChainFeatureConfigOptions)
+	for _, op := range ops {
+		options[op] = true
+	}
+	return &ChainConfig{
+		Forks: []*Fork{{
+			Name:     "Opcodes",
+			Block:    big.NewInt(1),
+			Features: []*ForkFeature{{ID: "opcodes", Options: options}},
+		}},
+	}
+}
+
+func TestCreate2(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		key, _  = crypto.GenerateKey()
+		factory = common.HexToAddress("0xfac")
+		config  = opcodeConfig("CREATE2")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.SetBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1))
+	statedb.SetCode(factory, create2Factory)
+
+	// CREATE2 is invalid unless scheduled.
+	if !applyCall(t, statedb, MakeChainConfig(), key, factory) {
+		t.Fatal("unscheduled CREATE2 succeeded")
+	}
+	if applyCall(t, statedb, config, key, factory) {
+		t.Fatal("CREATE2 failed")
+	}
+	want := crypto.CreateAddress2(factory, common.BigToHash(big.NewInt(42)), crypto.Keccak256(common.FromHex("60016000f3")))
+	if have := common.BytesToAddress(statedb.GetState(factory, common.Hash{}).Bytes()); have != want {
+		t.Errorf("address mismatch: have %x, want %x", have, want)
+	}
+	if code := statedb.GetCode(want); !bytes.Equal(code, []byte{0x00}) {
+		t.Errorf("code mismatch: have %x", code)
+	}
+	// Deploying with the same salt again collides, consuming the gas passed
+	// to CREATE2 and leaving too little to store the result.
+	if !applyCall(t, statedb, config, key, factory) {
+		t.Fatal("colliding CREATE2 succeeded")
+	}
+	if code := statedb.GetCode(want); !bytes.Equal(code, []byte{0x00}) {
+		t.Errorf("code mismatch after collision: have %x", code)
+	}
+}
+
+func TestContractCreations(t *testing.T) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		factory = common.HexToAddress("0xfac")
+		header  = &types.Header{Number: big.NewInt(1), GasLimit: big.NewInt(1000000)}
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	// Deploys the init code 0x60016000f3 with CREATE
+	statedb.SetCode(factory, common.FromHex("6460016000f36000526005601b6000f0600055"))
+
+	apply := func(tx *types.Transaction) common.Hash {
+		tx, _ = tx.SignECDSA(key)
+		statedb.StartRecord(tx.Hash(), common.Hash{}, 0)
+		env := NewEnv(statedb, MakeChainConfig(), nil, tx, header)
+		if _, _, failed, err := ApplyMessage(env, tx, new(GasPool).AddGas(header.GasLimit)); err != nil || failed {
+			t.Fatalf("execution failed: %v", err)
+		}
+		return tx.Hash()
+	}
+	hash := apply(types.NewContractCreation(0, new(big.Int), big.NewInt(200000), new(big.Int), common.FromHex("60016000f3")))
+	want := []state.ContractCreation{{Address: crypto.CreateAddress(sender, 0), Creator: sender}}
+	if have := statedb.GetContractCreations(hash); !reflect.DeepEqual(have, want) {
+		t.Errorf("direct creation mismatch: have %v, want %v", have, want)
+	}
+	hash = apply(types.NewTransaction(1, factory, new(big.Int), big.NewInt(200000), new(big.Int), nil))
+	want = []state.ContractCreation{{Address: crypto.CreateAddress(factory, 0), Creator: factory}}
+	if have := statedb.GetContractCreations(hash); !reflect.DeepEqual(have, want) {
+		t.Errorf("internal creation mismatch: have %v, want %v", have, want)
+	}
+}
+
+func TestExtCodeHash(t *testing.T) {
+	var (
+		db, _    = ethdb.NewMemDatabase()
+		key, _   = crypto.GenerateKey()
+		contract = common.HexToAddress("0xc0de")
+		funded   = common.HexToAddress("0xf00d")
+		missing  = common.HexToAddress("0xdead")
+		code     = []byte{0x00}
+	)
+	// The probe stores the EXTCODEHASH of each account in slots 0 to 2.
+	var probe []byte
+	for i, addr := range []common.Address{contract, funded, missing} {
+		probe = append(probe, 0x73) // PUSH20
+		probe = append(probe, addr[:]...)
+		probe = append(probe, 0x3f, 0x60, byte(i), 0x55) // EXTCODEHASH PUSH1 i SSTORE
+	}
+	probeAddr := common.HexToAddress("0x9806e")
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.SetBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1))
+	statedb.SetCode(probeAddr, probe)
+	statedb.SetCode(contract, code)
+	statedb.SetNonce(contract, 1)
+	statedb.SetBalance(funded, big.NewInt(1))
+
+	if !applyCall(t, statedb, MakeChainConfig(), key, probeAddr) {
+		t.Fatal("unscheduled EXTCODEHASH succeeded")
+	}
+	if applyCall(t, statedb, opcodeConfig("EXTCODEHASH"), key, probeAddr) {
+		t.Fatal("EXTCODEHASH failed")
+	}
+	for i, want := range []common.Hash{crypto.Keccak256Hash(code), crypto.Keccak256Hash(nil), {}} {
+		if have := statedb.GetState(probeAddr, common.BigToHash(big.NewInt(int64(i)))); have != want {
+			t.Errorf("slot %d: hash mismatch: have %x, want %x", i, have, want)
+		}
+	}
+}
+
+func TestSelfBalance(t *testing.T) {
+	var (
+		db, _    = ethdb.NewMemDatabase()
+		key, _   = crypto.GenerateKey()
+		contract = common.HexToAddress("0xc0de")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.SetBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1))
+	statedb.SetBalance(contract, big.NewInt(42))
+	statedb.SetCode(contract, common.FromHex("47600055")) // SELFBALANCE PUSH1 0 SSTORE
+
+	if !applyCall(t, statedb, MakeChainConfig(), key, contract) {
+		t.Fatal("unscheduled SELFBALANCE succeeded")
+	}
+	if applyCall(t, statedb, opcodeConfig("SELFBALANCE"), key, contract) {
+		t.Fatal("SELFBALANCE failed")
+	}
+	if have := statedb.GetState(contract, common.Hash{}); have != common.BigToHash(big.NewInt(42)) {
+		t.Errorf("balance mismatch: have %x", have)
+	}
+}
+
+func TestVmConfig(t *testing.T) {
+	var (
+		db, _  = ethdb.NewMemDatabase()
+		key, _ = crypto.GenerateKey()
+		caller = common.HexToAddress("0xca11")
+		callee = common.HexToAddress("0x0b0b")
+		header = &types.Header{Number: big.NewInt(1), GasLimit: big.NewInt(1000000)}
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	// CALL(0xffff, callee, 0, 0, 0, 0, 0), storing its success in slot 0
+	statedb.SetCode(caller, common.FromHex("60006000600060006000610b0b61ffff"+"f160005500"))
+	statedb.SetCode(callee, common.FromHex("600160005500"))
+
+	run := func(cfg vm.Config, to common.Address, gas int64) bool {
+		sender := crypto.PubkeyToAddress(key.PublicKey)
+		tx, _ := types.NewTransaction(statedb.GetNonce(sender), to, new(big.Int), big.NewInt(gas), new(big.Int), nil).SignECDSA(key)
+		env := NewEnv(statedb, MakeChainConfig(), nil, tx, header)
+		env.SetVmConfig(cfg)
+		_, _, failed, err := ApplyMessage(env, tx, new(GasPool).AddGas(header.GasLimit))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return failed
+	}
+	slot := func(addr common.Address) common.Hash {
+		return statedb.GetState(addr, common.Hash{})
+	}
+
+	// The callee can't pay for its SSTORE unless gas isn't metered
+	if !run(vm.Config{}, callee, 21100) {
+		t.Fatal("underpriced SSTORE succeeded")
+	}
+	if run(vm.Config{DisableGasMetering: true}, callee, 21100) || slot(callee) != common.BigToHash(common.Big1) {
+		t.Fatal("SSTORE without gas metering failed")
+	}
+	statedb.SetState(callee, common.Hash{}, common.Hash{})
+
+	// Calls succeed without running the callee
+	var trace bytes.Buffer
+	if run(vm.Config{NoRecursion: true, Trace: &trace}, caller, 200000) {
+		t.Fatal("call without recursion failed")
+	}
+	if slot(caller) != common.BigToHash(common.Big1) || slot(callee) != (common.Hash{}) {
+		t.Errorf("slots after call without recursion: caller %x, callee %x", slot(caller), slot(callee))
+	}
+	if lines := bytes.Count(trace.Bytes(), []byte("\n")); lines != 11 {
+		t.Errorf("trace: got %d steps, want 11", lines)
+	}
+}
+
+func TestAccessListGas(t *testing.T) {
+	var (
+		db, _    = ethdb.NewMemDatabase()
+		key, _   = crypto.GenerateKey()
+		contract = common.HexToAddress("0xc0de")
+		header   = &types.Header{Number: big.NewInt(1), GasLimit: big.NewInt(1000000)}
+		chainId  = big.NewInt(1)
+		config   = &ChainConfig{
+			Forks: []*Fork{{
+				Name:     "AccessList",
+				Block:    big.NewInt(1),
+				Features: []*ForkFeature{{ID: "accesslist", Options: ChainFeatureConfigOptions{"type": "eip2930"}}},
+			}},
+		}
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	// SLOAD(0) twice, then BALANCE(0xbeef)
+	statedb.SetCode(contract, common.FromHex("60005460005461beef3100"))
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	run := func(config *ChainConfig, tx *types.Transaction) uint64 {
+		tx, _ = tx.SignECDSA(key)
+		env := NewEnv(statedb, config, nil, tx, header)
+		_, gas, failed, err := ApplyMessage(env, tx, new(GasPool).AddGas(header.GasLimit))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed {
+			t.Fatal("execution failed")
+		}
+		return gas.Uint64()
+	}
+	legacy := func() *types.Transaction {
+		return types.NewTransaction(statedb.GetNonce(sender), contract, new(big.Int), big.NewInt(100000), new(big.Int), nil)
+	}
+	withList := func(al types.AccessList) *types.Transaction {
+		return types.NewAccessListTransaction(chainId, statedb.GetNonce(sender), &contract, new(big.Int), big.NewInt(100000), new(big.Int), nil, al)
+	}
+
+	if have, want := run(MakeChainConfig(), legacy()), uint64(21000+6+2*200+3+400); have != want {
+		t.Errorf("gas without access lists: have %d, want %d", have, want)
+	}
+	// The first access of the slot and the account is cold
+	if have, want := run(config, legacy()), uint64(21000+6+2100+100+3+2600); have != want {
+		t.Errorf("gas with cold accesses: have %d, want %d", have, want)
+	}
+	if have, want := run(config, withList(nil)), uint64(21000+6+2100+100+3+2600); have != want {
+		t.Errorf("gas of an empty access list: have %d, want %d", have, want)
+	}
+	// Listed slots and accounts are paid for upfront and warm
+	al := types.AccessList{
+		{Address: contract, StorageKeys: []common.Hash{{}}},
+		{Address: common.HexToAddress("0xbeef")},
+	}
+	if have, want := run(config, withList(al)), uint64(21000+2*2400+1900+6+2*100+3+100); have != want {
+		t.Errorf("gas with access list: have %d, want %d", have, want)
+	}
+}
+
+// storingVm is a wasm VM storing 1 in slot 0 of the contracts it runs.
+type storingVm struct {
+	env vm.Environment
+}
+
+func (v storingVm) Run(contract *vm.Contract, input []byte, readOnly bool) ([]byte, error) {
+	v.env.Db().SetState(contract.Address(), common.Hash{}, common.BigToHash(common.Big1))
+	return nil, nil
+}
+
+func TestWasmVM(t *testing.T) {
+	var (
+		db, _  = ethdb.NewMemDatabase()
+		key, _ = crypto.GenerateKey()
+		caller = common.HexToAddress("0xca11")
+		wasm   = common.HexToAddress("0x0a53")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	// CALL(gas, wasm, 0, 0, 0, 0, 0), storing its success in slot 0
+	statedb.SetCode(caller, common.FromHex("6000600060006000600061"+"0a53"+"5af160005500"))
+	statedb.SetCode(wasm, append(vm.WasmMagic, 0x01, 0x00, 0x00, 0x00))
+
+	slot := func(addr common.Address) common.Hash {
+		return statedb.GetState(addr, common.Hash{})
+	}
+	// Without a wasm VM the code is EVM code, stopping right away
+	if applyCall(t, statedb, MakeChainConfig(), key, wasm) || slot(wasm) != (common.Hash{}) {
+		t.Fatalf("wasm code run without a wasm VM: slot %x", slot(wasm))
+	}
+
+	SetWasmVM(func(env vm.Environment, evm *vm.EVM) vm.Vm { return storingVm{env} })
+	defer SetWasmVM(nil)
+
+	if applyCall(t, statedb, MakeChainConfig(), key, caller) {
+		t.Fatal("call of the wasm contract failed")
+	}
+	if slot(caller) != common.BigToHash(common.Big1) || slot(wasm) != common.BigToHash(common.Big1) {
+		t.Errorf("slots after call: caller %x, wasm %x", slot(caller), slot(wasm))
+	}
+}
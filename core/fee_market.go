@@ -0,0 +1,161 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+)
+
+var (
+	ErrFeeCapTooLow   = errors.New("max fee per gas less than block base fee")
+	ErrTipAboveFeeCap = errors.New("max priority fee per gas higher than max fee per gas")
+)
+
+// Fee market defaults, those of EIP-1559.
+var (
+	DefaultInitialBaseFee    = big.NewInt(1000000000)
+	DefaultElasticity        = big.NewInt(2)
+	DefaultChangeDenominator = big.NewInt(8)
+)
+
+// FeeMarket is the EIP-1559 fee market configured at some block. Blocks carry
+// a base fee per gas, which is burnt, adjusting by at most 1/ChangeDenominator
+// per block towards the usage of a gas target of 1/Elasticity of the gas limit.
+// Transactions pay the base fee plus a priority fee to the miner, capped by
+// the dynamic fee transactions' fee caps.
+//
+// It's configured by a 'feemarket' feature, e.g. {"id": "feemarket",
+// "options": {"type": "eip1559", "initialBaseFee": 1000000000}}, where the
+// optional elasticity and changeDenominator options default to 2 and 8.
+type FeeMarket struct {
+	InitialBaseFee    *big.Int
+	Elasticity        *big.Int
+	ChangeDenominator *big.Int
+}
+
+// FeeMarket returns the fee market active at block num, or nil if none is.
+func (c *ChainConfig) FeeMarket(num *big.Int) *FeeMarket {
+	f, _, configured := c.GetFeature(num, "feemarket")
+	if !configured {
+		return nil
+	}
+	name, _ := f.GetString("type")
+	switch name {
+	case "eip1559":
+	case "none":
+		return nil
+	default:
+		panic(fmt.Errorf("Unsupported feemarket value '%v' at block: %v", name, num))
+	}
+	fm := &FeeMarket{
+		InitialBaseFee:    DefaultInitialBaseFee,
+		Elasticity:        DefaultElasticity,
+		ChangeDenominator: DefaultChangeDenominator,
+	}
+	if fee, ok := f.GetBigInt("initialBaseFee"); ok {
+		fm.InitialBaseFee = fee
+	}
+	if elasticity, ok := f.GetBigInt("elasticity"); ok {
+		fm.Elasticity = elasticity
+	}
+	if denominator, ok := f.GetBigInt("changeDenominator"); ok {
+		fm.ChangeDenominator = denominator
+	}
+	return fm
+}
+
+// IsDynamicFee returns whether dynamic fee transactions are accepted at block
+// num, which they are while the fee market is active. Their access lists only
+// warm up accounts and slots with the 'accesslist' feature.
+func (c *ChainConfig) IsDynamicFee(num *big.Int) bool {
+	return c.FeeMarket(num) != nil
+}
+
+// CalcBaseFee returns the base fee per gas of the child of parent, or nil if
+// the fee market isn't active at the child. The first block of the fee market
+// has its initial base fee.
+func CalcBaseFee(config *ChainConfig, parent *types.Header) *big.Int {
+	fm := config.FeeMarket(new(big.Int).Add(parent.Number, common.Big1))
+	if fm == nil {
+		return nil
+	}
+	if parent.BaseFee == nil {
+		return new(big.Int).Set(fm.InitialBaseFee)
+	}
+	target := new(big.Int).Div(parent.GasLimit, fm.Elasticity)
+	if target.Sign() == 0 {
+		return new(big.Int).Set(parent.BaseFee)
+	}
+	switch parent.GasUsed.Cmp(target) {
+	case 0:
+		return new(big.Int).Set(parent.BaseFee)
+	case 1:
+		// parentBaseFee * (gasUsed - target) / target / denominator, at least 1
+		delta := new(big.Int).Sub(parent.GasUsed, target)
+		delta.Mul(delta, parent.BaseFee)
+		delta.Div(delta, target)
+		delta.Div(delta, fm.ChangeDenominator)
+		if delta.Sign() == 0 {
+			delta.SetInt64(1)
+		}
+		return delta.Add(delta, parent.BaseFee)
+	default:
+		// parentBaseFee * (target - gasUsed) / target / denominator
+		delta := new(big.Int).Sub(target, parent.GasUsed)
+		delta.Mul(delta, parent.BaseFee)
+		delta.Div(delta, target)
+		delta.Div(delta, fm.ChangeDenominator)
+		fee := delta.Sub(parent.BaseFee, delta)
+		if fee.Sign() < 0 {
+			fee.SetInt64(0)
+		}
+		return fee
+	}
+}
+
+// verifyBaseFee checks the base fee of header, the child of parent.
+func verifyBaseFee(config *ChainConfig, header, parent *types.Header) error {
+	expected := CalcBaseFee(config, parent)
+	switch {
+	case expected == nil && header.BaseFee != nil:
+		return fmt.Errorf("unexpected base fee %v before the fee market", header.BaseFee)
+	case expected != nil && header.BaseFee == nil:
+		return fmt.Errorf("missing base fee, expected %v", expected)
+	case expected != nil && header.BaseFee.Cmp(expected) != 0:
+		return fmt.Errorf("invalid base fee: have %v, want %v", header.BaseFee, expected)
+	}
+	return nil
+}
+
+// baseFeeEnv is implemented by environments of blocks having a base fee.
+type baseFeeEnv interface {
+	BaseFee() *big.Int
+}
+
+// baseFeeOf returns the base fee of the block env runs in, nil if it has none.
+func baseFeeOf(env vm.Environment) *big.Int {
+	if e, ok := env.(baseFeeEnv); ok {
+		return e.BaseFee()
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func feeMarketConfig(block int64) *ChainConfig {
+	return &ChainConfig{
+		Forks: []*Fork{{
+			Name:  "FeeMarket",
+			Block: big.NewInt(block),
+			Features: []*ForkFeature{{
+				ID:      "feemarket",
+				Options: ChainFeatureConfigOptions{"type": "eip1559", "initialBaseFee": 1000},
+			}},
+		}},
+	}
+}
+
+func TestCalcBaseFee(t *testing.T) {
+	config := feeMarketConfig(10)
+
+	tests := []struct {
+		number           int64
+		baseFee, gasUsed *big.Int
+		want             *big.Int
+	}{
+		{8, nil, big.NewInt(0), nil},                                  // before the fee market
+		{9, nil, big.NewInt(0), big.NewInt(1000)},                     // first block of the fee market
+		{10, big.NewInt(1000), big.NewInt(500000), big.NewInt(1000)},  // at the target
+		{10, big.NewInt(1000), big.NewInt(1000000), big.NewInt(1125)}, // full block
+		{10, big.NewInt(1000), big.NewInt(0), big.NewInt(875)},        // empty block
+		{10, big.NewInt(1000), big.NewInt(750000), big.NewInt(1062)},  // halfway above the target
+		{10, big.NewInt(1), big.NewInt(500001), big.NewInt(2)},        // increases by at least 1
+	}
+	for i, test := range tests {
+		parent := &types.Header{
+			Number:   big.NewInt(test.number),
+			GasLimit: big.NewInt(1000000),
+			GasUsed:  test.gasUsed,
+			BaseFee:  test.baseFee,
+		}
+		have := CalcBaseFee(config, parent)
+		if (have == nil) != (test.want == nil) || have != nil && have.Cmp(test.want) != 0 {
+			t.Errorf("test %d: base fee mismatch: have %v, want %v", i, have, test.want)
+		}
+	}
+}
+
+func TestVerifyBaseFee(t *testing.T) {
+	config := feeMarketConfig(1)
+	parent := &types.Header{Number: big.NewInt(1), GasLimit: big.NewInt(1000000), GasUsed: big.NewInt(0), BaseFee: big.NewInt(1000)}
+
+	if err := verifyBaseFee(config, &types.Header{BaseFee: big.NewInt(875)}, parent); err != nil {
+		t.Errorf("valid base fee: %v", err)
+	}
+	if err := verifyBaseFee(config, &types.Header{BaseFee: big.NewInt(1000)}, parent); err == nil {
+		t.Error("expected an error for an invalid base fee")
+	}
+	if err := verifyBaseFee(config, &types.Header{}, parent); err == nil {
+		t.Error("expected an error for a missing base fee")
+	}
+	if err := verifyBaseFee(MakeChainConfig(), &types.Header{BaseFee: big.NewInt(875)}, parent); err == nil {
+		t.Error("expected an error for a base fee before the fee market")
+	}
+}
+
+func TestDynamicFeePayment(t *testing.T) {
+	var (
+		db, _    = ethdb.NewMemDatabase()
+		key, _   = crypto.GenerateKey()
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		coinbase = common.HexToAddress("0xc0ffee")
+		to       = common.HexToAddress("0xbeef")
+		chainId  = big.NewInt(1)
+		header   = &types.Header{Number: big.NewInt(1), GasLimit: big.NewInt(1000000), Coinbase: coinbase, BaseFee: big.NewInt(1000)}
+		config   = feeMarketConfig(1)
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.AddBalance(sender, big.NewInt(1000000000))
+
+	apply := func(tx *types.Transaction) error {
+		tx, _ = tx.SignECDSA(key)
+		env := NewEnv(statedb, config, nil, tx, header)
+		_, _, _, err := ApplyMessage(env, tx, new(GasPool).AddGas(header.GasLimit))
+		return err
+	}
+	dynamicFee := func(tipCap, feeCap int64) *types.Transaction {
+		return types.NewDynamicFeeTransaction(chainId, statedb.GetNonce(sender), &to, new(big.Int), big.NewInt(21000), big.NewInt(tipCap), big.NewInt(feeCap), nil, nil)
+	}
+
+	if err := apply(dynamicFee(10, 999)); !IsInvalidTxErr(err) {
+		t.Errorf("fee cap below the base fee: have %v, want an invalid transaction", err)
+	}
+	if err := apply(dynamicFee(2000, 1500)); !IsInvalidTxErr(err) {
+		t.Errorf("tip above the fee cap: have %v, want an invalid transaction", err)
+	}
+
+	// The base fee is burnt, the miner earns the tip, capped by the fee cap
+	before := statedb.GetBalance(sender)
+	if err := apply(dynamicFee(100, 1050)); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := new(big.Int).Sub(before, statedb.GetBalance(sender)), big.NewInt(21000*1050); have.Cmp(want) != 0 {
+		t.Errorf("sender paid %v, want %v", have, want)
+	}
+	if have, want := statedb.GetBalance(coinbase), big.NewInt(21000*50); have.Cmp(want) != 0 {
+		t.Errorf("miner earned %v, want %v", have, want)
+	}
+
+	// Legacy transactions pay their gas price, the base fee included
+	before = statedb.GetBalance(sender)
+	if err := apply(types.NewTransaction(statedb.GetNonce(sender), to, new(big.Int), big.NewInt(21000), big.NewInt(1200), nil)); err != nil {
+		t.Fatal(err)
+	}
+	if have, want := new(big.Int).Sub(before, statedb.GetBalance(sender)), big.NewInt(21000*1200); have.Cmp(want) != 0 {
+		t.Errorf("legacy sender paid %v, want %v", have, want)
+	}
+	if have, want := statedb.GetBalance(coinbase), big.NewInt(21000*(50+200)); have.Cmp(want) != 0 {
+		t.Errorf("miner earned %v in total, want %v", have, want)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+// Package forkid implements the fork identifier of EIP-2124, which lets peers
+// tell whether they follow the same chain rules before syncing.
+package forkid
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math"
+	"sort"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+)
+
+var (
+	// ErrRemoteStale is returned by the filter if the remote fork ID is a subset
+	// of the local forks, but the remote node doesn't know about the next fork.
+	ErrRemoteStale = errors.New("remote needs update")
+
+	// ErrLocalIncompatibleOrStale is returned by the filter if the remote fork ID
+	// matches none of the local forks, or the remote announces a fork the local
+	// node already passed without knowing about it.
+	ErrLocalIncompatibleOrStale = errors.New("local incompatible or needs update")
+)
+
+// ID is a fork identifier as defined by EIP-2124.
+type ID struct {
+	Hash [4]byte // CRC32 checksum of the genesis block hash and passed fork block numbers
+	Next uint64  // Block number of the next upcoming fork, or 0 if no forks are known
+}
+
+// Filter validates a remote fork ID against the local chain.
+type Filter func(id ID) error
+
+// Blockchain is the chain state needed to validate remote fork IDs.
+type Blockchain interface {
+	Config() *core.ChainConfig
+	Genesis() *types.Block
+	CurrentHeader() *types.Header
+}
+
+// NewID calculates the fork ID of a chain at the given head block number.
+func NewID(config *core.ChainConfig, genesis common.Hash, head uint64) ID {
+	hash := crc32.ChecksumIEEE(genesis[:])
+	var next uint64
+	for _, fork := range gatherForks(config) {
+		if fork <= head {
+			hash = checksumUpdate(hash, fork)
+			continue
+		}
+		next = fork
+		break
+	}
+	return ID{Hash: checksumToBytes(hash), Next: next}
+}
+
+// NewFilter creates a filter accepting the fork IDs of peers compatible with
+// the chain at its current head.
+func NewFilter(chain Blockchain) Filter {
+	return newFilter(chain.Config(), chain.Genesis().Hash(), func() uint64 {
+		return chain.CurrentHeader().Number.Uint64()
+	})
+}
+
+// NewStrictFilter creates a filter which, on top of the fork IDs NewFilter
+// rejects, rejects those of peers on the local fork not announcing the next
+// fork scheduled locally, running releases unaware of the upcoming upgrade.
+func NewStrictFilter(chain Blockchain) Filter {
+	return newStrictFilter(chain.Config(), chain.Genesis().Hash(), func() uint64 {
+		return chain.CurrentHeader().Number.Uint64()
+	})
+}
+
+// newStrictFilter creates a strict filter for the given chain rules, evaluated
+// at the head returned by headfn.
+func newStrictFilter(config *core.ChainConfig, genesis common.Hash, headfn func() uint64) Filter {
+	filter := newFilter(config, genesis, headfn)
+	return func(id ID) error {
+		if err := filter(id); err != nil {
+			return err
+		}
+		local := NewID(config, genesis, headfn())
+		if id.Hash == local.Hash && local.Next > 0 && id.Next != local.Next {
+			return ErrRemoteStale
+		}
+		return nil
+	}
+}
+
+// newFilter creates a filter for the given chain rules, evaluated at the head
+// returned by headfn, following the rules of EIP-2124.
+func newFilter(config *core.ChainConfig, genesis common.Hash, headfn func() uint64) Filter {
+	forks := gatherForks(config)
+	sums := make([][4]byte, len(forks)+1) // 0th is the genesis
+	hash := crc32.ChecksumIEEE(genesis[:])
+	sums[0] = checksumToBytes(hash)
+	for i, fork := range forks {
+		hash = checksumUpdate(hash, fork)
+		sums[i+1] = checksumToBytes(hash)
+	}
+	forks = append(forks, math.MaxUint64) // the last fork is never passed
+
+	return func(id ID) error {
+		head := headfn()
+		for i, fork := range forks {
+			if head >= fork {
+				continue
+			}
+			// The first unpassed fork, the remote checksum should match ours here.
+			if sums[i] == id.Hash {
+				// Reject if the remote's next fork is already passed locally
+				// without the local node knowing about it.
+				if id.Next > 0 && head >= id.Next {
+					return ErrLocalIncompatibleOrStale
+				}
+				return nil
+			}
+			// A remote behind us must be on a subset of our forks and know the
+			// next one it has to pass.
+			for j := 0; j < i; j++ {
+				if sums[j] == id.Hash {
+					if forks[j] != id.Next {
+						return ErrRemoteStale
+					}
+					return nil
+				}
+			}
+			// A remote ahead of us must be on a superset of our forks, we are
+			// just out of sync.
+			for j := i + 1; j < len(sums); j++ {
+				if sums[j] == id.Hash {
+					return nil
+				}
+			}
+			return ErrLocalIncompatibleOrStale
+		}
+		glog.V(logger.Error).Errorf("Passed the final fork block at #%d", head)
+		return nil
+	}
+}
+
+// gatherForks returns the distinct fork block numbers of the chain config in
+// ascending order, leaving out those activated at genesis.
+func gatherForks(config *core.ChainConfig) []uint64 {
+	var forks []uint64
+	for _, fork := range config.Forks {
+		if fork.Block == nil || fork.Block.Sign() == 0 {
+			continue
+		}
+		forks = append(forks, fork.Block.Uint64())
+	}
+	sort.Slice(forks, func(i, j int) bool { return forks[i] < forks[j] })
+	for i := 1; i < len(forks); i++ {
+		if forks[i] == forks[i-1] {
+			forks = append(forks[:i], forks[i+1:]...)
+			i--
+		}
+	}
+	return forks
+}
+
+// checksumUpdate extends a fork checksum with the block number of a passed fork.
+func checksumUpdate(hash uint32, fork uint64) uint32 {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], fork)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
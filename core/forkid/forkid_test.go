@@ -0,0 +1,139 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+)
+
+// The Ethereum mainnet genesis and forks up to Petersburg, for which EIP-2124
+// publishes the fork IDs.
+var (
+	testGenesis = common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+	testConfig  = &core.ChainConfig{Forks: core.Forks{
+		{Name: "Frontier", Block: big.NewInt(0)},
+		{Name: "Homestead", Block: big.NewInt(1150000)},
+		{Name: "DAO", Block: big.NewInt(1920000)},
+		{Name: "Tangerine", Block: big.NewInt(2463000)},
+		{Name: "Spurious", Block: big.NewInt(2675000)},
+		{Name: "Byzantium", Block: big.NewInt(4370000)},
+		{Name: "Constantinople", Block: big.NewInt(7280000)},
+		{Name: "Petersburg", Block: big.NewInt(7280000)},
+	}}
+)
+
+func TestCreation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		want ID
+	}{
+		{0, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},
+		{1149999, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},
+		{1150000, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}},
+		{1920000, ID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}},
+		{2463000, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}},
+		{2675000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}},
+		{4370000, ID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}},
+		{7279999, ID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}},
+		{7280000, ID{Hash: checksumToBytes(0x668db0af), Next: 0}},
+		{7987396, ID{Hash: checksumToBytes(0x668db0af), Next: 0}},
+	}
+	for i, tt := range tests {
+		if have := NewID(testConfig, testGenesis, tt.head); have != tt.want {
+			t.Errorf("test %d: fork ID mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
+
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		id   ID
+		err  error
+	}{
+		// Same fork, no next fork announced or one in the future.
+		{7987396, ID{Hash: checksumToBytes(0x668db0af), Next: 0}, nil},
+		{7987396, ID{Hash: checksumToBytes(0x668db0af), Next: math.MaxUint64}, nil},
+		{4370000, ID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}, nil},
+
+		// Same fork, but the remote announces a fork already passed locally.
+		{7987396, ID{Hash: checksumToBytes(0x668db0af), Next: 7280000}, ErrLocalIncompatibleOrStale},
+
+		// Remote behind, knowing about the next fork or not.
+		{7987396, ID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}, nil},
+		{7987396, ID{Hash: checksumToBytes(0xa00bc324), Next: 0}, ErrRemoteStale},
+		{7987396, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}, nil},
+
+		// Remote ahead, the local node is just syncing.
+		{7279999, ID{Hash: checksumToBytes(0x668db0af), Next: 0}, nil},
+		{0, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}, nil},
+
+		// Unknown chain.
+		{7987396, ID{Hash: checksumToBytes(0xafec6b27), Next: 0}, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		filter := newFilter(testConfig, testGenesis, func() uint64 { return tt.head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestStrictValidation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		id   ID
+		err  error
+	}{
+		// Same fork, announcing the next local fork or with none left.
+		{4370000, ID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}, nil},
+		{7987396, ID{Hash: checksumToBytes(0x668db0af), Next: 0}, nil},
+
+		// Same fork, unaware of the next local fork or announcing another.
+		{4370000, ID{Hash: checksumToBytes(0xa00bc324), Next: 0}, ErrRemoteStale},
+		{4370000, ID{Hash: checksumToBytes(0xa00bc324), Next: 8000000}, ErrRemoteStale},
+
+		// Remotes behind or ahead are left to the regular rules.
+		{7987396, ID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}, nil},
+		{7987396, ID{Hash: checksumToBytes(0xa00bc324), Next: 0}, ErrRemoteStale},
+		{7279999, ID{Hash: checksumToBytes(0x668db0af), Next: 0}, nil},
+	}
+	for i, tt := range tests {
+		filter := newStrictFilter(testConfig, testGenesis, func() uint64 { return tt.head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestGatherForks(t *testing.T) {
+	have := gatherForks(testConfig)
+	want := []uint64{1150000, 1920000, 2463000, 2675000, 4370000, 7280000}
+	if len(have) != len(want) {
+		t.Fatalf("fork count mismatch: have %v, want %v", have, want)
+	}
+	for i := range want {
+		if have[i] != want[i] {
+			t.Errorf("fork %d mismatch: have %d, want %d", i, have[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+)
+
+// FreezeThreshold is the number of most recent blocks whose data stays in the
+// hot database. Older canonical blocks are deep enough not to be reorged away
+// and have their data moved to the ancient store, if the node has one.
+var FreezeThreshold uint64 = 90000
+
+// freezeBatch is the most blocks frozen while inserting a single block, so
+// that a node enabling the ancient store catches up gradually.
+const freezeBatch = 64
+
+// ancientKeys returns the keys of the immutable data of the block hash: its
+// header, body, total difficulty and receipts.
+func ancientKeys(hash common.Hash) [][]byte {
+	return [][]byte{
+		append(append(append([]byte{}, blockPrefix...), hash[:]...), headerSuffix...),
+		append(append(append([]byte{}, blockPrefix...), hash[:]...), bodySuffix...),
+		append(append(append([]byte{}, blockPrefix...), hash[:]...), tdSuffix...),
+		append(append([]byte{}, blockReceiptsPrefix...), hash[:]...),
+	}
+}
+
+// IsAncientKey reports whether key is one of the keys ancientKeys returns for
+// some block, so its data may have been moved to the ancient store.
+func IsAncientKey(key []byte) bool {
+	if bytes.HasPrefix(key, blockReceiptsPrefix) {
+		return len(key) == len(blockReceiptsPrefix)+common.HashLength
+	}
+	if !bytes.HasPrefix(key, blockPrefix) || len(key) <= len(blockPrefix)+common.HashLength {
+		return false
+	}
+	suffix := key[len(blockPrefix)+common.HashLength:]
+	return bytes.Equal(suffix, headerSuffix) || bytes.Equal(suffix, bodySuffix) || bytes.Equal(suffix, tdSuffix)
+}
+
+// FreezeAncient moves the data of the canonical blocks up to limit, which
+// weren't frozen yet, from the hot part of db to its ancient store. The genesis
+// block always stays hot. It returns the number of blocks frozen.
+func FreezeAncient(db *ethdb.AncientDatabase, limit uint64) (int, error) {
+	frozen, _ := GetFrozenBlock(db)
+	n := 0
+	for num := frozen + 1; num <= limit; num++ {
+		hash := GetCanonicalHash(db, num)
+		if hash == (common.Hash{}) {
+			return n, fmt.Errorf("missing canonical block #%d", num)
+		}
+		if err := db.Freeze(ancientKeys(hash)...); err != nil {
+			return n, err
+		}
+		if err := WriteFrozenBlock(db, num); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// freeze moves the data of blocks which fell FreezeThreshold behind the new
+// head number to the ancient store, if the chain database has one.
+func (bc *BlockChain) freeze(number uint64) {
+	db, ok := bc.chainDb.(*ethdb.AncientDatabase)
+	if !ok || number <= FreezeThreshold {
+		return
+	}
+	limit := number - FreezeThreshold
+	if frozen, _ := GetFrozenBlock(db); limit > frozen+freezeBatch {
+		limit = frozen + freezeBatch
+	}
+	if _, err := FreezeAncient(db, limit); err != nil {
+		glog.V(logger.Error).Infof("Failed to freeze ancient blocks: %v", err)
+	}
+}
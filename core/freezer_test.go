@@ -0,0 +1,148 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+)
+
+// newFreezerTestChain generates n blocks on top of a fresh genesis in db, the
+// second one including a transfer.
+func newFreezerTestChain(t *testing.T, db ethdb.Database, n int) *BlockChain {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	var (
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		to     = common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+		config = DefaultConfigMorden.ChainConfig
+		signer = config.GetSigner(big.NewInt(2))
+	)
+	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{addr, big.NewInt(1000000)})
+	blocks, _ := GenerateChain(config, genesis, db, n, func(i int, gen *BlockGen) {
+		if i == 1 {
+			tx, _ := types.NewTransaction(gen.TxNonce(addr), to, big.NewInt(1000), TxGas, nil, nil).WithSigner(signer).SignECDSA(key)
+			gen.AddTx(tx)
+		}
+	})
+	bc, err := NewBlockChain(db, config, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := bc.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to insert block %d: %v", res.Index, res.Error)
+	}
+	return bc
+}
+
+// checkFrozen checks that the data of the canonical blocks up to limit lives in
+// the cold store only, the data of the later ones in the hot store only.
+func checkFrozen(t *testing.T, db *ethdb.AncientDatabase, limit, head uint64) {
+	for num := uint64(1); num <= head; num++ {
+		for _, key := range ancientKeys(GetCanonicalHash(db, num)) {
+			hot, _ := db.Database.Has(key)
+			cold, _ := db.Cold().Has(key)
+			if frozen := num <= limit; hot == frozen || cold != frozen {
+				t.Errorf("block #%d key %q: hot %v, cold %v, want frozen %v", num, key[:6], hot, cold, frozen)
+			}
+		}
+		hash := GetCanonicalHash(db, num)
+		if GetBlock(db, hash) == nil || GetTd(db, hash) == nil {
+			t.Errorf("block #%d unreadable", num)
+		}
+	}
+	if frozen, _ := GetFrozenBlock(db); frozen != limit {
+		t.Errorf("frozen block mismatch: have #%d, want #%d", frozen, limit)
+	}
+}
+
+func TestFreezeOnInsert(t *testing.T) {
+	defer func(threshold uint64) { FreezeThreshold = threshold }(FreezeThreshold)
+	FreezeThreshold = 4
+
+	hot, _ := ethdb.NewMemDatabase()
+	cold, _ := ethdb.NewMemDatabase()
+	db := ethdb.NewAncientDatabase(hot, cold, IsAncientKey)
+	bc := newFreezerTestChain(t, db, 10)
+
+	checkFrozen(t, db, 6, 10)
+	if receipts := GetBlockReceipts(db, GetCanonicalHash(db, 2)); len(receipts) != 1 {
+		t.Errorf("frozen receipts mismatch: have %d, want 1", len(receipts))
+	}
+
+	// Rewinding below the frozen blocks deletes them from the cold store too
+	frozen := GetCanonicalHash(db, 5)
+	if err := bc.SetHead(3); err != nil {
+		t.Fatal(err)
+	}
+	if num, _ := GetFrozenBlock(db); num != 3 {
+		t.Errorf("frozen block not rewound: have #%d, want #3", num)
+	}
+	if GetHeader(db, frozen) != nil {
+		t.Error("rewound frozen header still readable")
+	}
+}
+
+func TestFreezeAncient(t *testing.T) {
+	hot, _ := ethdb.NewMemDatabase()
+	newFreezerTestChain(t, hot, 10)
+	if _, frozen := GetFrozenBlock(hot); frozen {
+		t.Fatal("blocks frozen without an ancient store")
+	}
+
+	cold, _ := ethdb.NewMemDatabase()
+	db := ethdb.NewAncientDatabase(hot, cold, IsAncientKey)
+	if n, err := FreezeAncient(db, 8); err != nil || n != 8 {
+		t.Fatalf("first migration: have %d blocks (%v), want 8", n, err)
+	}
+	checkFrozen(t, db, 8, 10)
+
+	// Migrating again picks up where the last run stopped
+	if n, err := FreezeAncient(db, 9); err != nil || n != 1 {
+		t.Fatalf("second migration: have %d blocks (%v), want 1", n, err)
+	}
+	checkFrozen(t, db, 9, 10)
+	if _, err := FreezeAncient(db, 11); err == nil {
+		t.Error("froze blocks past the head")
+	}
+}
+
+func TestIsAncientKey(t *testing.T) {
+	hash := common.HexToHash("0x0102")
+	for _, key := range ancientKeys(hash) {
+		if !IsAncientKey(key) {
+			t.Errorf("key %q not ancient", key)
+		}
+	}
+	for _, key := range [][]byte{
+		append(append([]byte{}, blockNumPrefix...), big.NewInt(1).Bytes()...),
+		append(append([]byte{}, receiptsPrefix...), hash[:]...),
+		append(append([]byte{}, lookupPrefix...), hash[:]...),
+		headBlockKey,
+		frozenKey,
+		hash[:],
+	} {
+		if IsAncientKey(key) {
+			t.Errorf("key %q ancient", key)
+		}
+	}
+}
@@ -36,6 +36,10 @@ import (
 	"github.com/hashicorp/golang-lru"
 )
 
+// fullSealCheckInterval is the block number interval at which header seals are
+// always verified, regardless of the sampling frequency.
+const fullSealCheckInterval = 1024
+
 // HeaderChain implements the basic block header chain logic that is shared by
 // core.BlockChain and light.LightChain. It is not usable in itself, only as
 // a part of either structure.
@@ -248,17 +252,114 @@ func (hc *HeaderChain) InsertHeaderChain(chain []*types.Header, checkFreq int, w
 	stats := struct{ processed, ignored int }{}
 	start := time.Now()
 
-	// Generate the list of headers that should be POW verified
+	// Verify the headers, checking a sample of the seals
+	if index, err := hc.verifyHeaders(hc.GetHeader(chain[0].ParentHash), chain, sealChecks(chain, checkFreq, hc.rand.Intn)); err != nil {
+		res.Index = index
+		res.Error = err
+		return
+	}
+	// All headers passed verification, import them into the database
+	for i, header := range chain {
+		// Short circuit insertion if shutting down
+		if hc.procInterrupt() {
+			glog.V(logger.Debug).Infoln("premature abort during header chain processing")
+			break
+		}
+		hash := header.Hash()
+
+		// If the header's already known, skip it, otherwise store
+		if hc.HasHeader(hash) {
+			stats.ignored++
+			continue
+		}
+		if err := writeHeader(header); err != nil {
+			res.Index = i
+			res.Error = err
+			return
+		}
+		stats.processed++
+	}
+	// Report some public statistics so the user has a clue what's going on
+	first, last := chain[0], chain[len(chain)-1]
+	elapsed := time.Since(start)
+
+	hv := HeaderChainInsertEvent{
+		Processed:  stats.processed,
+		Ignored:    stats.ignored,
+		LastNumber: last.Number.Uint64(),
+		LastHash:   last.Hash(),
+		Elasped:    elapsed,
+	}
+	res.HeaderChainInsertEvent = hv
+
+	glog.V(logger.Info).Infof("imported %d header(s) (%d ignored) in %v. #%v [%x… / %x…]", res.Processed, res.Ignored,
+		res.Elasped, res.LastNumber, first.Hash().Bytes()[:4], res.LastHash.Bytes()[:4])
+
+	if logger.MlogEnabled() {
+		mlogHeaderchainInsertHeaders.AssignDetails(
+			stats.processed,
+			stats.ignored,
+			last.Number,
+			first.Hash().Hex(),
+			last.Hash().Hex(),
+			elapsed,
+		).Send(mlogHeaderchain)
+	}
+	events = append(events, hv)
+	go hc.postChainEvents(events)
+
+	return res
+}
+
+// ValidateHeaderChain verifies a header chain attached to parent, which is
+// looked up locally if nil, without importing it. One seal out of every
+// checkFreq headers is verified, as well as periodic checkpoints and the chain
+// ends. It returns the index of the first invalid header.
+func (hc *HeaderChain) ValidateHeaderChain(parent *types.Header, chain []*types.Header, checkFreq int) (int, error) {
+	if len(chain) == 0 {
+		return 0, nil
+	}
+	if parent == nil {
+		parent = hc.GetHeader(chain[0].ParentHash)
+	}
+	return hc.verifyHeaders(parent, chain, sealChecks(chain, checkFreq, mrand.Intn))
+}
+
+// sealChecks selects the headers of chain whose seal should be verified: one
+// at random out of every checkFreq headers, every header whose number is a
+// multiple of fullSealCheckInterval, and the first and last headers, which
+// link the chain to its ancestors and to whatever follows.
+func sealChecks(chain []*types.Header, checkFreq int, intn func(int) int) []bool {
 	verify := make([]bool, len(chain))
+	if len(chain) == 0 {
+		return verify
+	}
+	if checkFreq < 1 {
+		checkFreq = 1
+	}
 	for i := 0; i < len(verify)/checkFreq; i++ {
-		index := i*checkFreq + hc.rand.Intn(checkFreq)
+		index := i*checkFreq + intn(checkFreq)
 		if index >= len(verify) {
 			index = len(verify) - 1
 		}
 		verify[index] = true
 	}
+	for i, header := range chain {
+		if header.Number != nil && header.Number.Uint64()%fullSealCheckInterval == 0 {
+			verify[i] = true
+		}
+	}
+	verify[0] = true
 	verify[len(verify)-1] = true // Last should always be verified to avoid junk
 
+	return verify
+}
+
+// verifyHeaders validates chain against the chain parameters on a pool of
+// workers, checking the seals of the headers selected by verify. The first
+// header is validated against parent. It returns the index of the first
+// invalid header.
+func (hc *HeaderChain) verifyHeaders(parent *types.Header, chain []*types.Header, verify []bool) (int, error) {
 	// Create the header verification task queue and worker functions
 	tasks := make(chan int, len(chain))
 	for i := 0; i < len(chain); i++ {
@@ -293,7 +394,7 @@ func (hc *HeaderChain) InsertHeaderChain(chain []*types.Header, checkFreq int, w
 
 			var err error
 			if index == 0 {
-				err = hc.getValidator().ValidateHeader(header, hc.GetHeader(header.ParentHash), checkPow)
+				err = hc.getValidator().ValidateHeader(header, parent, checkPow)
 			} else {
 				err = hc.getValidator().ValidateHeader(header, chain[index-1], checkPow)
 			}
@@ -320,63 +421,11 @@ func (hc *HeaderChain) InsertHeaderChain(chain []*types.Header, checkFreq int, w
 	if failed > 0 {
 		for i, err := range errs {
 			if err != nil {
-				res.Index = i
-				res.Error = err
-				return
+				return i, err
 			}
 		}
 	}
-	// All headers passed verification, import them into the database
-	for i, header := range chain {
-		// Short circuit insertion if shutting down
-		if hc.procInterrupt() {
-			glog.V(logger.Debug).Infoln("premature abort during header chain processing")
-			break
-		}
-		hash := header.Hash()
-
-		// If the header's already known, skip it, otherwise store
-		if hc.HasHeader(hash) {
-			stats.ignored++
-			continue
-		}
-		if err := writeHeader(header); err != nil {
-			res.Index = i
-			res.Error = err
-			return
-		}
-		stats.processed++
-	}
-	// Report some public statistics so the user has a clue what's going on
-	first, last := chain[0], chain[len(chain)-1]
-	elapsed := time.Since(start)
-
-	hv := HeaderChainInsertEvent{
-		Processed:  stats.processed,
-		Ignored:    stats.ignored,
-		LastNumber: last.Number.Uint64(),
-		LastHash:   last.Hash(),
-		Elasped:    elapsed,
-	}
-	res.HeaderChainInsertEvent = hv
-
-	glog.V(logger.Info).Infof("imported %d header(s) (%d ignored) in %v. #%v [%x… / %x…]", res.Processed, res.Ignored,
-		res.Elasped, res.LastNumber, first.Hash().Bytes()[:4], res.LastHash.Bytes()[:4])
-
-	if logger.MlogEnabled() {
-		mlogHeaderchainInsertHeaders.AssignDetails(
-			stats.processed,
-			stats.ignored,
-			last.Number,
-			first.Hash().Hex(),
-			last.Hash().Hex(),
-			elapsed,
-		).Send(mlogHeaderchain)
-	}
-	events = append(events, hv)
-	go hc.postChainEvents(events)
-
-	return res
+	return 0, nil
 }
 
 // GetBlockHashesFromHash retrieves a number of block hashes starting at a given
@@ -3,6 +3,7 @@
 package core
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/webchain-network/webchaind/common"
@@ -27,6 +28,13 @@ var UseSputnikVM string = "false"
 // config to determine which hard fork to use so ClassicVM's gas table
 // would not be used.
 func ApplyMultiVmTransaction(config *ChainConfig, bc *BlockChain, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, totalUsedGas *big.Int) (*types.Receipt, evm.Logs, *big.Int, error) {
+	// SputnikVM doesn't support access lists nor the fee market
+	if tx.Type() != types.LegacyTxType {
+		return nil, nil, nil, ErrTxTypeNotSupported
+	}
+	if header.BaseFee != nil {
+		return nil, nil, nil, fmt.Errorf("fee market not supported by SputnikVM at block number %v", header.Number)
+	}
 	tx.SetSigner(config.GetSigner(header.Number))
 
 	from, err := tx.From()
@@ -179,7 +187,13 @@ Loop:
 	usedGas := vm.UsedGas()
 	totalUsedGas.Add(totalUsedGas, usedGas)
 
-	receipt := types.NewReceipt(statedb.IntermediateRoot(config.IsAtlantis(header.Number)).Bytes(), totalUsedGas)
+	var root []byte
+	if config.IsReceiptStatus(header.Number) {
+		statedb.Finalise(config.IsStateClear(header.Number))
+	} else {
+		root = statedb.IntermediateRoot(config.IsStateClear(header.Number)).Bytes()
+	}
+	receipt := types.NewReceipt(root, totalUsedGas)
 	receipt.TxHash = tx.Hash()
 	receipt.GasUsed = new(big.Int).Set(totalUsedGas)
 	if vm.Failed() {
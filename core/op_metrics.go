@@ -0,0 +1,48 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/metrics"
+)
+
+// opMetricsEnabled makes the state processor record the opcodes executed by
+// every block it processes.
+var opMetricsEnabled bool
+
+// SetOpMetrics enables reporting the opcodes executed by processed blocks to
+// the metrics subsystem. Per block, the meters vm/op/<OPCODE>/count and
+// vm/op/<OPCODE>/gas are marked with the executions and the gas of every
+// opcode, and the timer vm/op/<OPCODE>/time is updated with their cumulative
+// execution time.
+func SetOpMetrics(enabled bool) {
+	opMetricsEnabled = enabled
+}
+
+// reportOpMetrics reports the opcodes executed by a block.
+func reportOpMetrics(m *vm.OpMetrics) {
+	for i, count := range m.Count {
+		if count == 0 {
+			continue
+		}
+		prefix := "vm/op/" + vm.OpCode(i).String()
+		metrics.GetOrRegisterMeter(prefix + "/count").Mark(int64(count))
+		metrics.GetOrRegisterMeter(prefix + "/gas").Mark(int64(m.Gas[i]))
+		metrics.GetOrRegisterTimer(prefix + "/time").Update(m.Time[i])
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func TestOpMetrics(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	statedb.AddBalance(sender, big.NewInt(1000000))
+
+	// PUSH1 1 PUSH1 2 ADD POP STOP
+	contract := common.HexToAddress("0xc0de")
+	statedb.SetCode(contract, common.Hex2Bytes("60016002015000"))
+
+	tx, _ := types.NewTransaction(0, contract, new(big.Int), big.NewInt(100000), big.NewInt(1), nil).SignECDSA(key)
+	config := &ChainConfig{Forks: []*Fork{{Name: "Homestead", Block: big.NewInt(0)}}}
+	header := &types.Header{Number: big.NewInt(1), GasLimit: big.NewInt(1000000), Difficulty: big.NewInt(1), Time: big.NewInt(1)}
+
+	var m vm.OpMetrics
+	env := NewEnv(statedb, config, nil, tx, header)
+	env.SetOpMetrics(&m)
+	if _, _, _, err := ApplyMessage(env, tx, new(GasPool).AddGas(header.GasLimit)); err != nil {
+		t.Fatal(err)
+	}
+	for op, want := range map[vm.OpCode]uint64{vm.PUSH1: 2, vm.ADD: 1, vm.POP: 1, vm.STOP: 1, vm.MUL: 0} {
+		if have := m.Count[op]; have != want {
+			t.Errorf("%v: count %d, want %d", op, have, want)
+		}
+	}
+	if have := m.Gas[vm.PUSH1]; have != 2*vm.GasFastestStep {
+		t.Errorf("PUSH1: gas %d, want %d", have, 2*vm.GasFastestStep)
+	}
+}
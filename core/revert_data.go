@@ -0,0 +1,54 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+var revertDataPrefix = []byte("revert-data-") // revertDataPrefix + block hash + tx hash -> return data of a reverted transaction
+
+// GetRevertData retrieves the return data the transaction txHash reverted with
+// in the block blockHash, nil if none is stored. It's only known for blocks the
+// node executed, not for fast synced ones.
+func GetRevertData(db ethdb.Database, blockHash, txHash common.Hash) []byte {
+	data, _ := db.Get(revertDataKey(blockHash, txHash))
+	return data
+}
+
+// WriteBlockRevertData stores the return data of the reverted transactions of
+// the block blockHash, given its receipts. It isn't part of stored receipts.
+func WriteBlockRevertData(db ethdb.Putter, blockHash common.Hash, receipts types.Receipts) error {
+	for _, receipt := range receipts {
+		if receipt.Status != types.TxFailure || len(receipt.RevertData) == 0 {
+			continue
+		}
+		if err := db.Put(revertDataKey(blockHash, receipt.TxHash), receipt.RevertData); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func revertDataKey(blockHash, txHash common.Hash) []byte {
+	key := make([]byte, 0, len(revertDataPrefix)+2*common.HashLength)
+	key = append(key, revertDataPrefix...)
+	key = append(key, blockHash.Bytes()...)
+	return append(key, txHash.Bytes()...)
+}
@@ -0,0 +1,94 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+)
+
+// SetSideBlockRetention enables the index of non-canonical blocks, both those
+// imported on a side chain and those a reorganisation removed from the
+// canonical chain. Side blocks more than window blocks below the head are
+// deleted from the database. Zero disables the index, leaving side blocks in
+// the database indefinitely without a way to find them by number.
+func (bc *BlockChain) SetSideBlockRetention(window uint64) {
+	bc.sideMu.Lock()
+	defer bc.sideMu.Unlock()
+
+	bc.sideRetention = window
+}
+
+// SideBlocks returns the retained non-canonical blocks with the given number.
+func (bc *BlockChain) SideBlocks(number uint64) []*types.Block {
+	canonical := GetCanonicalHash(bc.chainDb, number)
+
+	var blocks []*types.Block
+	for _, hash := range GetSideBlockHashes(bc.chainDb, number) {
+		// A later reorganisation may have made the block canonical again.
+		if hash == canonical {
+			continue
+		}
+		if block := bc.GetBlock(hash); block != nil {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// indexSideBlock adds a non-canonical block to the side block index.
+func (bc *BlockChain) indexSideBlock(block *types.Block) {
+	bc.sideMu.Lock()
+	defer bc.sideMu.Unlock()
+
+	if bc.sideRetention == 0 {
+		return
+	}
+	number, hash := block.NumberU64(), block.Hash()
+	hashes := GetSideBlockHashes(bc.chainDb, number)
+	for _, h := range hashes {
+		if h == hash {
+			return
+		}
+	}
+	if err := WriteSideBlockHashes(bc.chainDb, number, append(hashes, hash)); err != nil {
+		glog.V(logger.Error).Errorf("failed to index side block #%d [%s]: %v", number, hash.Hex(), err)
+	}
+}
+
+// pruneSideBlocks deletes the side blocks which fall out of the retention
+// window once head is the canonical head.
+func (bc *BlockChain) pruneSideBlocks(head uint64) {
+	bc.sideMu.Lock()
+	defer bc.sideMu.Unlock()
+
+	if bc.sideRetention == 0 || head <= bc.sideRetention {
+		return
+	}
+	number := head - bc.sideRetention - 1
+	canonical := GetCanonicalHash(bc.chainDb, number)
+	for _, hash := range GetSideBlockHashes(bc.chainDb, number) {
+		if hash != canonical {
+			DeleteBlock(bc.chainDb, hash)
+			bc.blockCache.Remove(hash)
+			bc.bodyCache.Remove(hash)
+			bc.bodyRLPCache.Remove(hash)
+		}
+	}
+	DeleteSideBlockHashes(bc.chainDb, number)
+}
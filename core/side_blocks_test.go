@@ -0,0 +1,106 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+)
+
+// newSideBlocksChain creates a chain retaining side blocks for window blocks,
+// together with a canonical and a side chain of the given lengths.
+func newSideBlocksChain(t *testing.T, window uint64, canonical, side int) (*BlockChain, []*types.Block, []*types.Block) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(db)
+	config := DefaultConfigMorden.ChainConfig
+	bc, err := NewBlockChain(db, config, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc.SetSideBlockRetention(window)
+	chain, _ := GenerateChain(config, genesis, db, canonical, nil)
+	forked, _ := GenerateChain(config, genesis, db, side, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{1})
+	})
+	return bc, chain, forked
+}
+
+func mustInsertChain(t *testing.T, bc *BlockChain, blocks []*types.Block) {
+	if res := bc.InsertChain(blocks); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+}
+
+func TestSideBlockRetention(t *testing.T) {
+	bc, chain, side := newSideBlocksChain(t, 2, 5, 1)
+	mustInsertChain(t, bc, chain[:2])
+	mustInsertChain(t, bc, side)
+	if blocks := bc.SideBlocks(1); len(blocks) != 1 || blocks[0].Hash() != side[0].Hash() {
+		t.Fatalf("side blocks mismatch: have %v", blocks)
+	}
+	if blocks := bc.SideBlocks(2); len(blocks) != 0 {
+		t.Errorf("unexpected side blocks: %v", blocks)
+	}
+	// The side block is kept until the head is more than 2 blocks above it.
+	mustInsertChain(t, bc, chain[2:3])
+	if len(bc.SideBlocks(1)) != 1 {
+		t.Fatal("side block pruned early")
+	}
+	mustInsertChain(t, bc, chain[3:])
+	if blocks := bc.SideBlocks(1); len(blocks) != 0 {
+		t.Errorf("side block not pruned: %v", blocks)
+	}
+	if bc.GetBlock(side[0].Hash()) != nil {
+		t.Error("pruned side block still in the database")
+	}
+	if bc.GetBlockByNumber(1).Hash() != chain[0].Hash() {
+		t.Error("canonical block pruned")
+	}
+}
+
+func TestSideBlockRetentionReorg(t *testing.T) {
+	bc, chain, side := newSideBlocksChain(t, 10, 2, 3)
+	mustInsertChain(t, bc, chain)
+	mustInsertChain(t, bc, side)
+	if bc.CurrentBlock().Hash() != side[2].Hash() {
+		t.Fatal("side chain not canonical")
+	}
+	// The blocks removed from the canonical chain are side blocks now, those
+	// which became canonical aren't.
+	for i, block := range chain {
+		if blocks := bc.SideBlocks(uint64(i + 1)); len(blocks) != 1 || blocks[0].Hash() != block.Hash() {
+			t.Errorf("block %d: side blocks mismatch: have %v", i+1, blocks)
+		}
+	}
+}
+
+func TestSideBlockRetentionDisabled(t *testing.T) {
+	bc, chain, side := newSideBlocksChain(t, 0, 5, 1)
+	mustInsertChain(t, bc, chain[:2])
+	mustInsertChain(t, bc, side)
+	mustInsertChain(t, bc, chain[2:])
+	if blocks := bc.SideBlocks(1); len(blocks) != 0 {
+		t.Errorf("side blocks indexed while disabled: %v", blocks)
+	}
+	if bc.GetBlock(side[0].Hash()) == nil {
+		t.Error("side block deleted while disabled")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/webchain-network/webchaind/common"
+)
+
+// accessList keeps the addresses and storage slots accessed in a transaction,
+// which are warm for EIP-2929 gas costs.
+type accessList struct {
+	addresses map[common.Address]int // Index of the address' slots, -1 if none accessed
+	slots     []map[common.Hash]struct{}
+}
+
+func newAccessList() *accessList {
+	return &accessList{
+		addresses: make(map[common.Address]int),
+	}
+}
+
+// ContainsAddress returns whether addr is in the access list.
+func (al *accessList) ContainsAddress(addr common.Address) bool {
+	_, ok := al.addresses[addr]
+	return ok
+}
+
+// Contains returns whether addr, and the slot of addr, are in the access list.
+func (al *accessList) Contains(addr common.Address, slot common.Hash) (addressOk, slotOk bool) {
+	idx, ok := al.addresses[addr]
+	if !ok || idx == -1 {
+		return ok, false
+	}
+	_, slotOk = al.slots[idx][slot]
+	return true, slotOk
+}
+
+// Copy returns an independent copy of the access list.
+func (al *accessList) Copy() *accessList {
+	cp := newAccessList()
+	for addr, idx := range al.addresses {
+		cp.addresses[addr] = idx
+	}
+	cp.slots = make([]map[common.Hash]struct{}, len(al.slots))
+	for i, slots := range al.slots {
+		cp.slots[i] = make(map[common.Hash]struct{}, len(slots))
+		for slot := range slots {
+			cp.slots[i][slot] = struct{}{}
+		}
+	}
+	return cp
+}
+
+// AddAddress adds addr to the access list, returning whether it wasn't in it
+// yet.
+func (al *accessList) AddAddress(addr common.Address) bool {
+	if _, ok := al.addresses[addr]; ok {
+		return false
+	}
+	al.addresses[addr] = -1
+	return true
+}
+
+// AddSlot adds slot of addr, and addr, to the access list. It returns whether
+// each of them was added.
+func (al *accessList) AddSlot(addr common.Address, slot common.Hash) (addrChange, slotChange bool) {
+	idx, addrOk := al.addresses[addr]
+	if !addrOk || idx == -1 {
+		al.addresses[addr] = len(al.slots)
+		al.slots = append(al.slots, map[common.Hash]struct{}{slot: {}})
+		return !addrOk, true
+	}
+	if _, ok := al.slots[idx][slot]; ok {
+		return false, false
+	}
+	al.slots[idx][slot] = struct{}{}
+	return false, true
+}
+
+// DeleteSlot removes slot of addr from the access list. Slots are removed in
+// the reverse order they were added in, reverting the journal.
+func (al *accessList) DeleteSlot(addr common.Address, slot common.Hash) {
+	idx, ok := al.addresses[addr]
+	if !ok || idx == -1 {
+		panic("reverting slot change, address not present in list")
+	}
+	delete(al.slots[idx], slot)
+	// The slots of addr were added last, drop them along with the last slot
+	if len(al.slots[idx]) == 0 {
+		al.slots = al.slots[:idx]
+		al.addresses[addr] = -1
+	}
+}
+
+// DeleteAddress removes addr from the access list. It must not have slots.
+func (al *accessList) DeleteAddress(addr common.Address) {
+	delete(al.addresses, addr)
+}
+
+// ResetAccessList empties the access list, as every transaction starts with
+// its own.
+func (self *StateDB) ResetAccessList() {
+	self.accessList = newAccessList()
+}
+
+// AddAddressToAccessList adds addr to the access list of the transaction.
+func (self *StateDB) AddAddressToAccessList(addr common.Address) {
+	if self.accessList.AddAddress(addr) {
+		self.journal.append(accessListAddAccountChange{address: &addr})
+	}
+}
+
+// AddSlotToAccessList adds slot of addr, and addr, to the access list of the
+// transaction.
+func (self *StateDB) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	addrMod, slotMod := self.accessList.AddSlot(addr, slot)
+	if addrMod {
+		self.journal.append(accessListAddAccountChange{address: &addr})
+	}
+	if slotMod {
+		self.journal.append(accessListAddSlotChange{address: &addr, slot: &slot})
+	}
+}
+
+// AddressInAccessList returns whether addr is in the access list of the
+// transaction.
+func (self *StateDB) AddressInAccessList(addr common.Address) bool {
+	return self.accessList.ContainsAddress(addr)
+}
+
+// SlotInAccessList returns whether addr, and slot of addr, are in the access
+// list of the transaction.
+func (self *StateDB) SlotInAccessList(addr common.Address, slot common.Hash) (addressOk, slotOk bool) {
+	return self.accessList.Contains(addr, slot)
+}
@@ -0,0 +1,134 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/metrics"
+	"github.com/webchain-network/webchaind/trie"
+)
+
+// CleanCache keeps recently read trie nodes and contract code in memory for the
+// state databases sharing it. Both are keyed by the hash of their content, so
+// cached entries never go stale.
+type CleanCache struct {
+	cache *byteCache
+}
+
+// NewCleanCache creates a clean cache holding up to size bytes. A size of zero
+// disables the cache.
+func NewCleanCache(size int) *CleanCache {
+	return &CleanCache{newByteCache(size)}
+}
+
+// byteCache is an LRU cache bounded by the total size of its keys and values.
+type byteCache struct {
+	mu    sync.Mutex
+	limit int
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type byteCacheEntry struct {
+	key   string
+	value []byte
+}
+
+func newByteCache(limit int) *byteCache {
+	return &byteCache{limit: limit, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *byteCache) get(key []byte) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.limit == 0 {
+		return nil, false
+	}
+	if el, ok := c.items[string(key)]; ok {
+		c.ll.MoveToFront(el)
+		metrics.CacheTrieCleanHits.Mark(1)
+		return common.CopyBytes(el.Value.(*byteCacheEntry).value), true
+	}
+	metrics.CacheTrieCleanMisses.Mark(1)
+	return nil, false
+}
+
+func (c *byteCache) add(key, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	size := len(key) + len(value)
+	if size > c.limit {
+		return
+	}
+	if _, ok := c.items[string(key)]; ok {
+		return
+	}
+	c.items[string(key)] = c.ll.PushFront(&byteCacheEntry{string(key), common.CopyBytes(value)})
+	c.size += size
+	c.evict()
+}
+
+func (c *byteCache) resize(limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.limit = limit
+	c.evict()
+}
+
+// evict drops the least recently used entries until the cache fits its limit.
+func (c *byteCache) evict() {
+	for c.size > c.limit {
+		el := c.ll.Back()
+		entry := el.Value.(*byteCacheEntry)
+		c.ll.Remove(el)
+		delete(c.items, entry.key)
+		c.size -= len(entry.key) + len(entry.value)
+	}
+	metrics.CacheTrieCleanSize.Update(int64(c.size))
+}
+
+// cleanReader serves trie node reads from the clean cache, falling back to
+// the database.
+type cleanReader struct {
+	trie.Database
+	cache *byteCache
+}
+
+func (r cleanReader) Get(key []byte) ([]byte, error) {
+	if value, ok := r.cache.get(key); ok {
+		return value, nil
+	}
+	value, err := r.Database.Get(key)
+	if err == nil {
+		r.cache.add(key, value)
+	}
+	return value, err
+}
+
+func (r cleanReader) Has(key []byte) (bool, error) {
+	if _, ok := r.cache.get(key); ok {
+		return true, nil
+	}
+	return r.Database.Has(key)
+}
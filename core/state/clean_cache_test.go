@@ -0,0 +1,77 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func TestByteCacheEviction(t *testing.T) {
+	c := newByteCache(10)
+	c.add([]byte("a"), []byte("1234"))
+	c.add([]byte("b"), []byte("1234"))
+	if _, ok := c.get([]byte("a")); !ok {
+		t.Fatal("a missing")
+	}
+	// Adding c evicts the least recently used entry, b.
+	c.add([]byte("c"), []byte("1234"))
+	if _, ok := c.get([]byte("b")); ok {
+		t.Error("b not evicted")
+	}
+	if v, ok := c.get([]byte("a")); !ok || !bytes.Equal(v, []byte("1234")) {
+		t.Errorf("a: got %q, %v", v, ok)
+	}
+	if c.size != 10 {
+		t.Errorf("size %d, want 10", c.size)
+	}
+	// Oversized entries are not cached.
+	c.add([]byte("d"), make([]byte, 20))
+	if _, ok := c.get([]byte("d")); ok {
+		t.Error("oversized entry cached")
+	}
+	c.resize(0)
+	if c.size != 0 || len(c.items) != 0 {
+		t.Errorf("resize to zero left %d bytes, %d items", c.size, len(c.items))
+	}
+}
+
+func TestCleanCacheStateReads(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	clean := NewCleanCache(1024 * 1024)
+	statedb, _ := New(common.Hash{}, NewDatabaseWithCache(db, clean))
+	statedb.SetBalance(addr, big.NewInt(1))
+	statedb.SetCode(addr, []byte{1, 2, 3})
+	root, _ := statedb.CommitTo(db, false)
+
+	for i := 0; i < 2; i++ {
+		reopened, err := New(root, NewDatabaseWithCache(db, clean))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reopened.GetBalance(addr).Cmp(big.NewInt(1)) != 0 || !bytes.Equal(reopened.GetCode(addr), []byte{1, 2, 3}) {
+			t.Fatalf("read %d: unexpected state", i)
+		}
+	}
+	if clean.cache.size == 0 {
+		t.Error("clean cache not populated")
+	}
+}
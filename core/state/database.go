@@ -61,13 +61,24 @@ type Trie interface {
 	Hash() common.Hash
 	NodeIterator(startKey []byte) trie.NodeIterator
 	GetKey([]byte) []byte // TODO(fjl): remove this when SecureTrie is removed
+	ProveHashed(shaKey []byte, fromLevel uint, proofDb trie.DatabaseWriter) error
 }
 
 // NewDatabase creates a backing store for state. The returned database is safe for
 // concurrent use and retains cached trie nodes in memory.
 func NewDatabase(db ethdb.Database) Database {
+	return NewDatabaseWithCache(db, nil)
+}
+
+// NewDatabaseWithCache creates a backing store for state like NewDatabase, which
+// serves trie node and contract code reads from the given clean cache. A nil
+// cache disables caching.
+func NewDatabaseWithCache(db ethdb.Database, clean *CleanCache) Database {
 	csc, _ := lru.New(codeSizeCacheSize)
-	return &cachingDB{db: db, codeSizeCache: csc}
+	if clean == nil {
+		clean = NewCleanCache(0)
+	}
+	return &cachingDB{db: db, codeSizeCache: csc, clean: clean.cache}
 }
 
 type cachingDB struct {
@@ -75,6 +86,7 @@ type cachingDB struct {
 	mu            sync.Mutex
 	pastTries     []*trie.SecureTrie
 	codeSizeCache *lru.Cache
+	clean         *byteCache // recently read trie nodes and code
 }
 
 func (db *cachingDB) OpenTrie(root common.Hash) (Trie, error) {
@@ -86,7 +98,7 @@ func (db *cachingDB) OpenTrie(root common.Hash) (Trie, error) {
 			return cachedTrie{db.pastTries[i].Copy(), db}, nil
 		}
 	}
-	tr, err := trie.NewSecure(root, db.db, MaxTrieCacheGen)
+	tr, err := trie.NewSecure(root, cleanReader{db.db, db.clean}, MaxTrieCacheGen)
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +118,7 @@ func (db *cachingDB) pushTrie(t *trie.SecureTrie) {
 }
 
 func (db *cachingDB) OpenStorageTrie(addrHash, root common.Hash) (Trie, error) {
-	return trie.NewSecure(root, db.db, 0)
+	return trie.NewSecure(root, cleanReader{db.db, db.clean}, 0)
 }
 
 func (db *cachingDB) CopyTrie(t Trie) Trie {
@@ -121,7 +133,7 @@ func (db *cachingDB) CopyTrie(t Trie) Trie {
 }
 
 func (db *cachingDB) ContractCode(addrHash, codeHash common.Hash) ([]byte, error) {
-	code, err := db.db.Get(codeHash[:])
+	code, err := cleanReader{db.db, db.clean}.Get(codeHash[:])
 	if err == nil {
 		db.codeSizeCache.Add(codeHash, len(code))
 	}
@@ -0,0 +1,136 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/metrics"
+	"github.com/webchain-network/webchaind/trie"
+)
+
+// CommitCache remembers the hashes of recently committed trie nodes and
+// contract code. Both are keyed by the hash of their content, so once written
+// they never need to be written again. Hot contracts whose storage flips
+// between the same values keep recreating identical nodes block after block.
+//
+// The cache counts the references to each hash. When full, it evicts the least
+// recently referenced hashes, but gives hashes referenced more than once
+// another chance. It must only be used with a single database.
+type CommitCache struct {
+	mu    sync.Mutex
+	limit int
+	ll    *list.List
+	items map[common.Hash]*list.Element
+}
+
+type commitCacheEntry struct {
+	hash common.Hash
+	refs uint32
+}
+
+// NewCommitCache creates a cache remembering up to limit hashes.
+func NewCommitCache(limit int) *CommitCache {
+	return &CommitCache{limit: limit, ll: list.New(), items: make(map[common.Hash]*list.Element)}
+}
+
+// ref adds a reference to hash, returning false if it isn't in the cache.
+func (c *CommitCache) ref(hash common.Hash) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[hash]
+	if !ok {
+		return false
+	}
+	el.Value.(*commitCacheEntry).refs++
+	c.ll.MoveToFront(el)
+	return true
+}
+
+// add inserts the given hashes with a single reference each.
+func (c *CommitCache) add(hashes []common.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, hash := range hashes {
+		if el, ok := c.items[hash]; ok {
+			c.ll.MoveToFront(el)
+			continue
+		}
+		c.items[hash] = c.ll.PushFront(&commitCacheEntry{hash: hash, refs: 1})
+	}
+	for c.ll.Len() > c.limit {
+		el := c.ll.Back()
+		entry := el.Value.(*commitCacheEntry)
+		if entry.refs > 1 {
+			entry.refs /= 2
+			c.ll.MoveToFront(el)
+			continue
+		}
+		c.ll.Remove(el)
+		delete(c.items, entry.hash)
+	}
+	metrics.TrieCommitDedupSize.Update(int64(c.ll.Len()))
+}
+
+// DedupWriter commits state through a database writer, leaving out trie nodes
+// and contract code which were committed before.
+//
+// The nodes written through it are only assumed to be in the database once
+// Flush is called, so nodes of a batch that's discarded are written again by
+// the next commit.
+type DedupWriter struct {
+	db      trie.DatabaseWriter
+	cache   *CommitCache
+	written []common.Hash
+}
+
+// NewWriter wraps the given database or batch, which must belong to the
+// database the cache is used with.
+func (c *CommitCache) NewWriter(db trie.DatabaseWriter) *DedupWriter {
+	return &DedupWriter{db: db, cache: c}
+}
+
+// Put implements trie.DatabaseWriter.
+func (w *DedupWriter) Put(key, value []byte) error {
+	// Only nodes and code are keyed by their hash, other entries (e.g. the
+	// preimages of secure tries) are always written.
+	if len(key) != common.HashLength {
+		return w.db.Put(key, value)
+	}
+	hash := common.BytesToHash(key)
+	if w.cache.ref(hash) {
+		metrics.TrieCommitDedupHits.Mark(1)
+		return nil
+	}
+	metrics.TrieCommitDedupMisses.Mark(1)
+	if err := w.db.Put(key, value); err != nil {
+		return err
+	}
+	w.written = append(w.written, hash)
+	return nil
+}
+
+// Flush remembers the nodes written so far as committed. It must only be
+// called once they're persisted, i.e. after writing the underlying batch.
+func (w *DedupWriter) Flush() {
+	w.cache.add(w.written)
+	w.written = nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+// countingWriter counts the hash keyed entries written.
+type countingWriter struct {
+	db     *ethdb.MemDatabase
+	hashed int
+}
+
+func (w *countingWriter) Put(key, value []byte) error {
+	if len(key) == common.HashLength {
+		w.hashed++
+	}
+	return w.db.Put(key, value)
+}
+
+func TestDedupWriter(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	cache := NewCommitCache(1024)
+	contract := common.HexToAddress("0x1000")
+
+	commit := func(root common.Hash, value int64, flush bool) (common.Hash, int) {
+		statedb, err := New(root, NewDatabase(db))
+		if err != nil {
+			t.Fatal(err)
+		}
+		statedb.SetBalance(contract, big.NewInt(1))
+		statedb.SetCode(contract, []byte{0x60, 0x00, 0x54})
+		statedb.SetState(contract, common.Hash{1}, common.BigToHash(big.NewInt(value)))
+
+		counter := &countingWriter{db: db}
+		w := cache.NewWriter(counter)
+		root, err = statedb.CommitTo(w, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if flush {
+			w.Flush()
+		}
+		return root, counter.hashed
+	}
+	// Nodes of an unflushed commit are written again.
+	root, first := commit(common.Hash{}, 1, false)
+	if first == 0 {
+		t.Fatal("nothing written")
+	}
+	if _, written := commit(common.Hash{}, 1, true); written != first {
+		t.Fatalf("unflushed nodes skipped: wrote %d, want %d", written, first)
+	}
+	// Flipping the storage slot back and forth only writes new nodes once.
+	root2, written := commit(root, 2, true)
+	if written == 0 {
+		t.Fatal("new nodes not written")
+	}
+	if _, written := commit(root2, 1, true); written != 0 {
+		t.Errorf("rewrote %d committed nodes", written)
+	}
+	if _, written := commit(root, 2, true); written != 0 {
+		t.Errorf("rewrote %d committed nodes", written)
+	}
+	// The skipped nodes are in the database.
+	statedb, err := New(root2, NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := statedb.GetState(contract, common.Hash{1}); have != common.BigToHash(big.NewInt(2)) {
+		t.Errorf("storage mismatch: have %x", have)
+	}
+}
+
+func TestCommitCacheEviction(t *testing.T) {
+	c := NewCommitCache(2)
+	c.add([]common.Hash{{1}, {2}})
+	c.ref(common.Hash{2})
+	c.ref(common.Hash{1})
+	c.ref(common.Hash{2})
+
+	// Hash 1 is the least recently referenced one, but was referenced more
+	// often than hash 3 which was just added.
+	c.add([]common.Hash{{3}})
+	if c.ll.Len() != 2 {
+		t.Fatalf("cache size %d, want 2", c.ll.Len())
+	}
+	if c.ref(common.Hash{3}) {
+		t.Error("hash 3 not evicted")
+	}
+	if !c.ref(common.Hash{1}) || !c.ref(common.Hash{2}) {
+		t.Error("expected hashes missing")
+	}
+}
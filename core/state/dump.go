@@ -22,6 +22,7 @@ import (
 	"compress/zlib"
 	"encoding/json"
 	"io"
+	"math/big"
 	"sort"
 	"sync"
 
@@ -434,3 +435,17 @@ func (self *StateDB) Dump(addresses []common.Address) []byte {
 	}
 	return bf.Bytes()
 }
+
+// TotalBalance sums the balances of all accounts in the state trie.
+func (self *StateDB) TotalBalance() (*big.Int, error) {
+	total := new(big.Int)
+	it := trie.NewIterator(self.trie.NodeIterator(nil))
+	for it.Next() {
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return nil, err
+		}
+		total.Add(total, data.Balance)
+	}
+	return total, it.Err
+}
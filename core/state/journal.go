@@ -123,6 +123,9 @@ type (
 	addLogChange struct {
 		txhash common.Hash
 	}
+	addCreationChange struct {
+		txhash common.Hash
+	}
 	addPreimageChange struct {
 		hash common.Hash
 	}
@@ -131,6 +134,15 @@ type (
 		prev      bool
 		prevDirty bool
 	}
+
+	// Changes to the access list.
+	accessListAddAccountChange struct {
+		address *common.Address
+	}
+	accessListAddSlotChange struct {
+		address *common.Address
+		slot    *common.Hash
+	}
 )
 
 func (ch createObjectChange) revert(s *StateDB) {
@@ -225,6 +237,19 @@ func (ch addLogChange) dirtied() *common.Address {
 	return nil
 }
 
+func (ch addCreationChange) revert(s *StateDB) {
+	creations := s.creations[ch.txhash]
+	if len(creations) == 1 {
+		delete(s.creations, ch.txhash)
+	} else {
+		s.creations[ch.txhash] = creations[:len(creations)-1]
+	}
+}
+
+func (ch addCreationChange) dirtied() *common.Address {
+	return nil
+}
+
 func (ch addPreimageChange) revert(s *StateDB) {
 	delete(s.preimages, ch.hash)
 }
@@ -232,3 +257,19 @@ func (ch addPreimageChange) revert(s *StateDB) {
 func (ch addPreimageChange) dirtied() *common.Address {
 	return nil
 }
+
+func (ch accessListAddAccountChange) revert(s *StateDB) {
+	s.accessList.DeleteAddress(*ch.address)
+}
+
+func (ch accessListAddAccountChange) dirtied() *common.Address {
+	return nil
+}
+
+func (ch accessListAddSlotChange) revert(s *StateDB) {
+	s.accessList.DeleteSlot(*ch.address, *ch.slot)
+}
+
+func (ch accessListAddSlotChange) dirtied() *common.Address {
+	return nil
+}
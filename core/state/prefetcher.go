@@ -0,0 +1,154 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+const (
+	// prefetchQueue is the maximum number of addresses waiting to be
+	// prefetched, further ones are dropped.
+	prefetchQueue = 1024
+
+	// prefetchStorageDepth is the length of the key path up to which the
+	// nodes of a storage trie are prefetched. The top levels of the trie are
+	// shared by all slots, so loading them speeds up every storage access
+	// without knowing which slots will be read.
+	prefetchStorageDepth = 2
+)
+
+// prefetchWorkers is the number of goroutines loading state per prefetcher.
+var prefetchWorkers int32 = 4
+
+// SetPrefetchWorkers sets the number of goroutines prefetching state ahead of
+// transaction execution. Zero disables prefetching.
+func SetPrefetchWorkers(n int) {
+	atomic.StoreInt32(&prefetchWorkers, int32(n))
+}
+
+// Prefetcher loads accounts along with their storage tries and code in
+// background goroutines, so that the trie nodes and code are in the clean
+// cache (or at least the OS page cache) by the time a transaction executing
+// on the state touches them.
+//
+// A nil Prefetcher is valid and does nothing.
+type Prefetcher struct {
+	db    Database
+	seen  map[common.Address]struct{}
+	tasks chan common.Address
+	quit  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewPrefetcher starts prefetching from the state the StateDB was opened at.
+// It must be called before the state is modified and closed when the state
+// transition is done. It returns nil if prefetching is disabled.
+func (self *StateDB) NewPrefetcher() *Prefetcher {
+	workers := int(atomic.LoadInt32(&prefetchWorkers))
+	if workers <= 0 {
+		return nil
+	}
+	p := &Prefetcher{
+		db:    self.db,
+		seen:  make(map[common.Address]struct{}),
+		tasks: make(chan common.Address, prefetchQueue),
+		quit:  make(chan struct{}),
+	}
+	// Every worker reads from its own copy of the account trie, the copies are
+	// taken here since the StateDB isn't safe for concurrent use.
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.loop(self.db.CopyTrie(self.trie))
+	}
+	return p
+}
+
+// Prefetch schedules the given accounts for loading. It never blocks, if the
+// queue is full the addresses are dropped.
+func (p *Prefetcher) Prefetch(addrs ...common.Address) {
+	if p == nil {
+		return
+	}
+	for _, addr := range addrs {
+		if _, ok := p.seen[addr]; ok {
+			continue
+		}
+		p.seen[addr] = struct{}{}
+		select {
+		case p.tasks <- addr:
+		default:
+			return
+		}
+	}
+}
+
+// Close stops prefetching and waits for the workers to exit.
+func (p *Prefetcher) Close() {
+	if p == nil {
+		return
+	}
+	close(p.quit)
+	p.wg.Wait()
+}
+
+func (p *Prefetcher) loop(tr Trie) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case addr := <-p.tasks:
+			p.fetch(tr, addr)
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// fetch loads the account of addr, the top levels of its storage trie and its
+// code. Errors are ignored, execution will hit and report them anyway.
+func (p *Prefetcher) fetch(tr Trie, addr common.Address) {
+	enc, err := tr.TryGet(addr[:])
+	if err != nil || len(enc) == 0 {
+		return
+	}
+	var data Account
+	if err := rlp.DecodeBytes(enc, &data); err != nil {
+		return
+	}
+	addrHash := crypto.Keccak256Hash(addr[:])
+
+	if storage, err := p.db.OpenStorageTrie(addrHash, data.Root); err == nil {
+		it := storage.NodeIterator(nil)
+		for it.Next(len(it.Path()) < prefetchStorageDepth) {
+			select {
+			case <-p.quit:
+				return
+			default:
+			}
+		}
+	}
+	if !bytes.Equal(data.CodeHash, emptyCodeHash) {
+		p.db.ContractCode(addrHash, common.BytesToHash(data.CodeHash))
+	}
+}
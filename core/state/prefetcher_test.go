@@ -0,0 +1,90 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func TestPrefetcherWarmsCleanCache(t *testing.T) {
+	var (
+		db, _    = ethdb.NewMemDatabase()
+		contract = common.HexToAddress("0x1000")
+		code     = []byte{0x60, 0x00, 0x54}
+	)
+	statedb, _ := New(common.Hash{}, NewDatabase(db))
+	statedb.SetBalance(contract, big.NewInt(1))
+	statedb.SetCode(contract, code)
+	for i := int64(0); i < 64; i++ {
+		statedb.SetState(contract, common.BigToHash(big.NewInt(i)), common.BigToHash(big.NewInt(i+1)))
+	}
+	root, _ := statedb.CommitTo(db, false)
+	storageRoot := statedb.getStateObject(contract).data.Root
+
+	clean := NewCleanCache(1024 * 1024)
+	reopened, err := New(root, NewDatabaseWithCache(db, clean))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := reopened.NewPrefetcher()
+	p.Prefetch(contract, contract, common.HexToAddress("0x2000"))
+
+	// Prefetching doesn't interfere with the state being modified meanwhile.
+	other := common.HexToAddress("0x3000")
+	reopened.SetState(other, common.Hash{1}, common.Hash{2})
+	reopened.IntermediateRoot(false)
+
+	// Prefetching the contract caches its code and storage trie root.
+	codeHash := reopened.GetCodeHash(contract)
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		_, haveCode := clean.cache.get(codeHash[:])
+		_, haveRoot := clean.cache.get(storageRoot[:])
+		if haveCode && haveRoot {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("state not prefetched: code %t, storage root %t", haveCode, haveRoot)
+		}
+	}
+	if !bytes.Equal(reopened.GetCode(contract), code) {
+		t.Error("code mismatch")
+	}
+	if have := reopened.GetState(contract, common.BigToHash(big.NewInt(5))); have != common.BigToHash(big.NewInt(6)) {
+		t.Errorf("storage mismatch: have %x", have)
+	}
+	p.Close()
+}
+
+func TestPrefetcherDisabled(t *testing.T) {
+	SetPrefetchWorkers(0)
+	defer SetPrefetchWorkers(4)
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := New(common.Hash{}, NewDatabase(db))
+	p := statedb.NewPrefetcher()
+	if p != nil {
+		t.Fatal("prefetcher created while disabled")
+	}
+	p.Prefetch(common.HexToAddress("0x1000"))
+	p.Close()
+}
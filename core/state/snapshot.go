@@ -0,0 +1,252 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"container/list"
+	"math/big"
+	"sync"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/metrics"
+)
+
+const (
+	// snapshotMaxDepth is the number of layers a lookup descends before giving
+	// up and reading the trie.
+	snapshotMaxDepth = 128
+
+	// Approximate memory used by snapshot entries.
+	snapshotAccountSize = 160
+	snapshotSlotSize    = 128
+)
+
+// snapshots holds flat account and storage values by state root, shared by all
+// state databases. Values under a root never change, so layers stay valid
+// for as long as they're kept.
+var snapshots = newSnapshotTree(0)
+
+// SetSnapshotCacheSize sets the memory allowance in bytes of the state snapshot.
+// A size of zero disables the snapshot.
+func SetSnapshotCacheSize(size int) {
+	snapshots.resize(size)
+}
+
+// snapshotTree tracks the snapshot layers of recent state roots.
+type snapshotTree struct {
+	mu     sync.RWMutex // guards the tree and all its layers
+	limit  int
+	size   int
+	layers map[common.Hash]*list.Element // layers by root, oldest first in order
+	order  *list.List
+}
+
+// snapshotLayer holds the flat values of one state root which are known
+// without reading the trie: those written by the transition which produced
+// the root, and those read since. Values not held by a layer are looked up in
+// its parent, the state the root was derived from.
+type snapshotLayer struct {
+	tree   *snapshotTree
+	root   common.Hash
+	parent *snapshotLayer
+
+	size      int
+	evicted   bool
+	accounts  map[common.Address]*Account // nil for accounts known not to exist
+	storage   map[common.Address]map[common.Hash]common.Hash
+	destructs map[common.Address]struct{} // accounts whose storage isn't inherited from the parent
+}
+
+func newSnapshotTree(limit int) *snapshotTree {
+	return &snapshotTree{limit: limit, layers: make(map[common.Hash]*list.Element), order: list.New()}
+}
+
+func (t *snapshotTree) newLayer(root common.Hash, parent *snapshotLayer) *snapshotLayer {
+	return &snapshotLayer{
+		tree:      t,
+		root:      root,
+		parent:    parent,
+		accounts:  make(map[common.Address]*Account),
+		storage:   make(map[common.Address]map[common.Hash]common.Hash),
+		destructs: make(map[common.Address]struct{}),
+	}
+}
+
+// layer returns the snapshot layer of the given root, creating an empty one
+// if the root has no layer yet. It returns nil if the snapshot is disabled.
+func (t *snapshotTree) layer(root common.Hash) *snapshotLayer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.limit == 0 {
+		return nil
+	}
+	if el, ok := t.layers[root]; ok {
+		return el.Value.(*snapshotLayer)
+	}
+	l := t.newLayer(root, nil)
+	t.layers[root] = t.order.PushBack(l)
+	return l
+}
+
+// update adds the layer of root, derived from the parent layer by the given
+// account and storage changes, and returns it. Accounts in destructs are
+// deleted or recreated, and don't inherit the parent's storage.
+func (t *snapshotTree) update(parent *snapshotLayer, root common.Hash, accounts map[common.Address]*Account, storage map[common.Address]Storage, destructs map[common.Address]struct{}) *snapshotLayer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.limit == 0 {
+		return nil
+	}
+	if el, ok := t.layers[root]; ok {
+		return el.Value.(*snapshotLayer)
+	}
+	if parent != nil && parent.evicted {
+		parent = nil
+	}
+	l := t.newLayer(root, parent)
+	for addr, acc := range accounts {
+		l.accounts[addr] = acc
+		l.size += snapshotAccountSize
+	}
+	for addr, slots := range storage {
+		values := make(map[common.Hash]common.Hash, len(slots))
+		for key, value := range slots {
+			values[key] = value
+		}
+		l.storage[addr] = values
+		l.size += len(values) * snapshotSlotSize
+	}
+	for addr := range destructs {
+		l.destructs[addr] = struct{}{}
+	}
+	t.layers[root] = t.order.PushBack(l)
+	t.size += l.size
+	t.cap()
+	return l
+}
+
+// resize changes the memory allowance, dropping all layers if disabled.
+func (t *snapshotTree) resize(limit int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.limit = limit
+	t.cap()
+}
+
+// cap evicts the oldest layers until the tree fits its memory allowance.
+// Children of evicted layers are cut off from them and fall back to the trie
+// for values they don't hold themselves.
+func (t *snapshotTree) cap() {
+	for t.order.Len() > 0 && (t.size > t.limit || t.limit == 0) {
+		oldest := t.order.Remove(t.order.Front()).(*snapshotLayer)
+		delete(t.layers, oldest.root)
+
+		oldest.evicted = true
+		t.size -= oldest.size
+
+		for _, el := range t.layers {
+			if l := el.Value.(*snapshotLayer); l.parent == oldest {
+				l.parent = nil
+			}
+		}
+	}
+	metrics.CacheSnapshotSize.Update(int64(t.size))
+}
+
+// account returns the account at the layer's root. The boolean is false if
+// the account isn't known to the snapshot; a known but nonexistent account is
+// returned as nil.
+func (l *snapshotLayer) account(addr common.Address) (*Account, bool) {
+	l.tree.mu.RLock()
+	defer l.tree.mu.RUnlock()
+
+	for layer, depth := l, 0; layer != nil && depth < snapshotMaxDepth; layer, depth = layer.parent, depth+1 {
+		if acc, ok := layer.accounts[addr]; ok {
+			metrics.CacheSnapshotHits.Mark(1)
+			if acc == nil {
+				return nil, true
+			}
+			cpy := *acc
+			cpy.Balance = new(big.Int).Set(acc.Balance)
+			return &cpy, true
+		}
+	}
+	metrics.CacheSnapshotMisses.Mark(1)
+	return nil, false
+}
+
+// storageValue returns the storage slot of the account at the layer's root,
+// and whether it's known to the snapshot.
+func (l *snapshotLayer) storageValue(addr common.Address, key common.Hash) (common.Hash, bool) {
+	l.tree.mu.RLock()
+	defer l.tree.mu.RUnlock()
+
+	for layer, depth := l, 0; layer != nil && depth < snapshotMaxDepth; layer, depth = layer.parent, depth+1 {
+		value, ok := layer.storage[addr][key]
+		_, destructed := layer.destructs[addr]
+		if ok || destructed {
+			metrics.CacheSnapshotHits.Mark(1)
+			return value, true
+		}
+	}
+	metrics.CacheSnapshotMisses.Mark(1)
+	return common.Hash{}, false
+}
+
+// addAccount remembers an account read from the trie at the layer's root.
+func (l *snapshotLayer) addAccount(addr common.Address, acc *Account) {
+	if acc != nil {
+		cpy := *acc
+		cpy.Balance = new(big.Int).Set(acc.Balance)
+		acc = &cpy
+	}
+	l.tree.mu.Lock()
+	defer l.tree.mu.Unlock()
+
+	if l.evicted {
+		return
+	}
+	l.accounts[addr] = acc
+	l.add(snapshotAccountSize)
+}
+
+// addStorage remembers a storage slot read from the trie at the layer's root.
+func (l *snapshotLayer) addStorage(addr common.Address, key, value common.Hash) {
+	l.tree.mu.Lock()
+	defer l.tree.mu.Unlock()
+
+	if l.evicted {
+		return
+	}
+	slots := l.storage[addr]
+	if slots == nil {
+		slots = make(map[common.Hash]common.Hash)
+		l.storage[addr] = slots
+	}
+	slots[key] = value
+	l.add(snapshotSlotSize)
+}
+
+// add accounts for memory added to the layer by reads.
+func (l *snapshotLayer) add(size int) {
+	l.size += size
+	l.tree.size += size
+	l.tree.cap()
+}
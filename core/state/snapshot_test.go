@@ -0,0 +1,135 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func TestSnapshotReads(t *testing.T) {
+	SetSnapshotCacheSize(1024 * 1024)
+	defer SetSnapshotCacheSize(0)
+
+	var (
+		db, _  = ethdb.NewMemDatabase()
+		a, b   = common.HexToAddress("0xaa"), common.HexToAddress("0xbb")
+		k1, k2 = common.HexToHash("0x01"), common.HexToHash("0x02")
+	)
+	commit := func(s *StateDB) common.Hash {
+		root, err := s.CommitTo(db, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return root
+	}
+	open := func(root common.Hash) *StateDB {
+		s, err := New(root, NewDatabase(db))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+
+	s := open(common.Hash{})
+	s.SetBalance(a, big.NewInt(1))
+	s.SetState(a, k1, common.HexToHash("0x11"))
+	s.SetState(a, k2, common.HexToHash("0x22"))
+	s.SetBalance(b, big.NewInt(2))
+	root1 := commit(s)
+
+	// Modify a slot and delete an account; unmodified values come from the parent.
+	s = open(root1)
+	s.SetState(a, k2, common.HexToHash("0x33"))
+	s.Suicide(b)
+	root2 := commit(s)
+
+	s = open(root2)
+	if v := s.GetState(a, k1); v != common.HexToHash("0x11") {
+		t.Errorf("root2 k1: got %x", v)
+	}
+	if v := s.GetState(a, k2); v != common.HexToHash("0x33") {
+		t.Errorf("root2 k2: got %x", v)
+	}
+	if s.Exist(b) {
+		t.Error("root2: deleted account exists")
+	}
+	if layer := snapshots.layer(root2); layer.parent == nil || layer.parent.root != root1 {
+		t.Error("root2 layer not linked to root1")
+	}
+
+	// Recreating an account drops its storage.
+	s.CreateAccount(a)
+	s.SetBalance(a, big.NewInt(5))
+	root3 := commit(s)
+
+	s = open(root3)
+	if v := s.GetState(a, k1); v != (common.Hash{}) {
+		t.Errorf("root3 k1: got %x, want empty", v)
+	}
+	if bal := s.GetBalance(a); bal.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("root3 balance: got %v", bal)
+	}
+
+	// Older states are still served correctly.
+	s = open(root1)
+	if v := s.GetState(a, k2); v != common.HexToHash("0x22") {
+		t.Errorf("root1 k2: got %x", v)
+	}
+	if bal := s.GetBalance(b); bal.Cmp(big.NewInt(2)) != 0 {
+		t.Errorf("root1 balance: got %v", bal)
+	}
+
+	// Disabling the snapshot drops all layers.
+	SetSnapshotCacheSize(0)
+	if snapshots.size != 0 || len(snapshots.layers) != 0 {
+		t.Errorf("disabled snapshot holds %d bytes in %d layers", snapshots.size, len(snapshots.layers))
+	}
+}
+
+func TestSnapshotEviction(t *testing.T) {
+	SetSnapshotCacheSize(4 * snapshotAccountSize)
+	defer SetSnapshotCacheSize(0)
+
+	db, _ := ethdb.NewMemDatabase()
+	s, _ := New(common.Hash{}, NewDatabase(db))
+	var roots []common.Hash
+	for i := byte(1); i <= 8; i++ {
+		s.SetBalance(common.BytesToAddress([]byte{i}), big.NewInt(int64(i)))
+		root, err := s.CommitTo(db, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	if snapshots.size > snapshots.limit {
+		t.Errorf("snapshot size %d exceeds limit %d", snapshots.size, snapshots.limit)
+	}
+	if _, ok := snapshots.layers[roots[0]]; ok {
+		t.Error("oldest layer not evicted")
+	}
+	// Values of evicted layers are read from the trie.
+	s, _ = New(roots[len(roots)-1], NewDatabase(db))
+	for i := byte(1); i <= 8; i++ {
+		if bal := s.GetBalance(common.BytesToAddress([]byte{i})); bal.Cmp(big.NewInt(int64(i))) != 0 {
+			t.Errorf("account %d: got balance %v", i, bal)
+		}
+	}
+}
@@ -80,8 +80,13 @@ type StateObject struct {
 	trie Trie // storage trie, which becomes non-nil on first access
 	code Code // contract bytecode, which gets set when code is loaded
 
-	cachedStorage Storage // Storage entry cache to avoid duplicate reads
-	dirtyStorage  Storage // Storage entries that need to be flushed to disk
+	cachedStorage  Storage // Storage entry cache to avoid duplicate reads
+	dirtyStorage   Storage // Storage entries that need to be flushed to disk
+	writtenStorage Storage // Storage entries written since the object was loaded
+
+	// snap is the snapshot layer of the state the object was loaded from, nil
+	// if the object was created or the snapshot is disabled.
+	snap *snapshotLayer
 
 	// Cache flags.
 	// When an object is marked suicided it will be delete from the trie
@@ -114,12 +119,13 @@ func newObject(db *StateDB, address common.Address, data Account) *StateObject {
 		data.CodeHash = emptyCodeHash
 	}
 	return &StateObject{
-		db:            db,
-		address:       address,
-		addrHash:      crypto.Keccak256Hash(address[:]),
-		data:          data,
-		cachedStorage: make(Storage),
-		dirtyStorage:  make(Storage),
+		db:             db,
+		address:        address,
+		addrHash:       crypto.Keccak256Hash(address[:]),
+		data:           data,
+		cachedStorage:  make(Storage),
+		dirtyStorage:   make(Storage),
+		writtenStorage: make(Storage),
 	}
 }
 
@@ -168,6 +174,16 @@ func (self *StateObject) GetState(db Database, key common.Hash) common.Hash {
 	if exists {
 		return value
 	}
+	// Slots not written since loading hold the value in the snapshot.
+	_, written := self.writtenStorage[key]
+	if self.snap != nil && !written {
+		if value, ok := self.snap.storageValue(self.address, key); ok {
+			if (value != common.Hash{}) {
+				self.cachedStorage[key] = value
+			}
+			return value
+		}
+	}
 	// Load from DB in case it is missing.
 	enc, err := self.getTrie(db).TryGet(key[:])
 	if err != nil {
@@ -181,6 +197,9 @@ func (self *StateObject) GetState(db Database, key common.Hash) common.Hash {
 		}
 		value.SetBytes(content)
 	}
+	if self.snap != nil && !written {
+		self.snap.addStorage(self.address, key, value)
+	}
 	if (value != common.Hash{}) {
 		self.cachedStorage[key] = value
 	}
@@ -200,6 +219,7 @@ func (self *StateObject) SetState(db Database, key, value common.Hash) {
 func (self *StateObject) setState(key, value common.Hash) {
 	self.cachedStorage[key] = value
 	self.dirtyStorage[key] = value
+	self.writtenStorage[key] = value
 }
 
 // updateTrie writes cached storage modifications into the object's storage trie.
@@ -296,9 +316,6 @@ func (self *StateObject) setBalance(amount *big.Int) {
 	self.data.Balance = amount
 }
 
-// Return the gas back to the origin. Used by the Virtual machine or Closures
-func (c *StateObject) ReturnGas(*big.Int, *big.Int) {}
-
 func (self *StateObject) deepCopy(db *StateDB) *StateObject {
 	stateObject := newObject(db, self.address, self.data)
 	if self.trie != nil {
@@ -307,6 +324,8 @@ func (self *StateObject) deepCopy(db *StateDB) *StateObject {
 	stateObject.code = self.code
 	stateObject.dirtyStorage = self.dirtyStorage.Copy()
 	stateObject.cachedStorage = self.dirtyStorage.Copy()
+	stateObject.writtenStorage = self.writtenStorage.Copy()
+	stateObject.snap = self.snap
 	stateObject.suicided = self.suicided
 	stateObject.dirtyCode = self.dirtyCode
 	stateObject.deleted = self.deleted
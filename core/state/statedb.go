@@ -56,6 +56,13 @@ type revision struct {
 	journalIndex int
 }
 
+// ContractCreation is a contract created in a transaction, either by the
+// transaction itself or by a contract it called.
+type ContractCreation struct {
+	Address common.Address
+	Creator common.Address
+}
+
 // StateDBs within the ethereum protocol are used to store anything
 // within the merkle trie. StateDBs take care of caching and storing
 // nested states. It's the general query interface to retrieve:
@@ -65,6 +72,7 @@ type StateDB struct {
 	db        Database
 	trie      Trie
 	pastTries []*trie.SecureTrie
+	snap      *snapshotLayer // flat values of the last committed state, if enabled
 
 	// DB error.
 	// State objects are used by the consensus core and VM which are
@@ -85,6 +93,12 @@ type StateDB struct {
 	logs         map[common.Hash]vm.Logs
 	logSize      uint
 
+	// Contracts created by each transaction, including by other contracts
+	creations map[common.Hash][]ContractCreation
+
+	// Addresses and slots accessed in the transaction, see EIP-2929
+	accessList *accessList
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        *journal
@@ -105,15 +119,32 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 	return &StateDB{
 		db:                db,
 		trie:              tr,
+		snap:              stateSnapshot(db, root),
 		stateObjects:      make(map[common.Address]*StateObject),
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		refund:            new(big.Int),
 		logs:              make(map[common.Hash]vm.Logs),
+		creations:         make(map[common.Hash][]ContractCreation),
 		preimages:         make(map[common.Hash][]byte),
+		accessList:        newAccessList(),
 		journal:           newJournal(),
 	}, nil
 }
 
+// stateSnapshot returns the snapshot layer of the state root read through db,
+// nil if db records its reads.
+func stateSnapshot(db Database, root common.Hash) *snapshotLayer {
+	if _, ok := db.(*witnessDB); ok {
+		return nil
+	}
+	return snapshots.layer(root)
+}
+
+// Error returns the first database error the state ran into, if any.
+func (self *StateDB) Error() error {
+	return self.dbErr
+}
+
 // setError remembers the first non-nil error it is called with.
 func (self *StateDB) setError(err error) {
 	if self.dbErr == nil {
@@ -134,6 +165,7 @@ func (self *StateDB) Reset(root common.Hash) error {
 		return err
 	}
 	self.trie = tr
+	self.snap = stateSnapshot(self.db, root)
 	self.stateObjects = make(map[common.Address]*StateObject)
 	self.stateObjectsDirty = make(map[common.Address]struct{})
 	self.thash = common.Hash{}
@@ -141,7 +173,9 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.txIndex = 0
 	self.logs = make(map[common.Hash]vm.Logs)
 	self.logSize = 0
+	self.creations = make(map[common.Hash][]ContractCreation)
 	self.preimages = make(map[common.Hash][]byte)
+	self.accessList = newAccessList()
 	self.clearJournalAndRefund()
 	return nil
 }
@@ -187,6 +221,19 @@ func (self *StateDB) Logs() vm.Logs {
 	return logs
 }
 
+// AddContractCreation records that the current transaction created the
+// contract at addr, reverted along with the creation.
+func (self *StateDB) AddContractCreation(addr, creator common.Address) {
+	self.journal.append(addCreationChange{txhash: self.thash})
+	self.creations[self.thash] = append(self.creations[self.thash], ContractCreation{Address: addr, Creator: creator})
+}
+
+// GetContractCreations returns the contracts created by a transaction, in the
+// order of their creation.
+func (self *StateDB) GetContractCreations(hash common.Hash) []ContractCreation {
+	return self.creations[hash]
+}
+
 func (self *StateDB) AddRefund(gas *big.Int) {
 	self.journal.append(refundChange{prev: new(big.Int).Set(self.refund)})
 	self.refund.Add(self.refund, gas)
@@ -366,19 +413,37 @@ func (self *StateDB) getStateObject(addr common.Address) (stateObject *StateObje
 		return obj
 	}
 
-	// Load the object from the database.
-	enc, err := self.trie.TryGet(addr[:])
-	if len(enc) == 0 {
-		self.setError(err)
-		return nil
+	// Load the object from the snapshot or the database.
+	var data *Account
+	if self.snap != nil {
+		if acc, ok := self.snap.account(addr); ok {
+			if acc == nil {
+				return nil
+			}
+			data = acc
+		}
 	}
-	var data Account
-	if err := rlp.DecodeBytes(enc, &data); err != nil {
-		glog.Error("Failed to decode state object", "addr", addr, "err", err)
-		return nil
+	if data == nil {
+		enc, err := self.trie.TryGet(addr[:])
+		if len(enc) == 0 {
+			if err == nil && self.snap != nil {
+				self.snap.addAccount(addr, nil)
+			}
+			self.setError(err)
+			return nil
+		}
+		data = new(Account)
+		if err := rlp.DecodeBytes(enc, data); err != nil {
+			glog.Error("Failed to decode state object", "addr", addr, "err", err)
+			return nil
+		}
+		if self.snap != nil {
+			self.snap.addAccount(addr, data)
+		}
 	}
 	// Insert into the live set.
-	obj = newObject(self, addr, data)
+	obj = newObject(self, addr, *data)
+	obj.snap = self.snap
 	self.setStateObject(obj)
 	return obj
 }
@@ -456,12 +521,15 @@ func (self *StateDB) Copy() *StateDB {
 	state := &StateDB{
 		db:                self.db,
 		trie:              self.db.CopyTrie(self.trie),
+		snap:              self.snap,
 		stateObjects:      make(map[common.Address]*StateObject, len(self.journal.dirties)),
 		stateObjectsDirty: make(map[common.Address]struct{}, len(self.journal.dirties)),
 		refund:            new(big.Int).Set(self.refund),
 		logs:              make(map[common.Hash]vm.Logs, len(self.logs)),
 		logSize:           self.logSize,
+		creations:         make(map[common.Hash][]ContractCreation, len(self.creations)),
 		preimages:         make(map[common.Hash][]byte),
+		accessList:        self.accessList.Copy(),
 		journal:           newJournal(),
 	}
 	// Copy the dirty states, logs, and preimages
@@ -488,6 +556,9 @@ func (self *StateDB) Copy() *StateDB {
 		}
 		state.logs[hash] = cpy
 	}
+	for hash, creations := range self.creations {
+		state.creations[hash] = append([]ContractCreation(nil), creations...)
+	}
 	for hash, preimage := range self.preimages {
 		state.preimages[hash] = preimage
 	}
@@ -576,6 +647,12 @@ func (s *StateDB) CommitTo(dbw trie.DatabaseWriter, deleteEmptyObjects bool) (ro
 		s.stateObjectsDirty[addr] = struct{}{}
 	}
 
+	// Collect the changes for the snapshot layer of the new root.
+	var (
+		accounts  = make(map[common.Address]*Account)
+		storage   = make(map[common.Address]Storage)
+		destructs = make(map[common.Address]struct{})
+	)
 	// Commit objects to the trie.
 	for addr, stateObject := range s.stateObjects {
 		_, isDirty := s.stateObjectsDirty[addr]
@@ -584,6 +661,8 @@ func (s *StateDB) CommitTo(dbw trie.DatabaseWriter, deleteEmptyObjects bool) (ro
 			// If the object has been removed, don't bother syncing it
 			// and just mark it for deletion in the trie.
 			s.deleteStateObject(stateObject)
+			accounts[addr] = nil
+			destructs[addr] = struct{}{}
 		case isDirty:
 			// Write any contract code associated with the state object
 			if stateObject.code != nil && stateObject.dirtyCode {
@@ -598,12 +677,25 @@ func (s *StateDB) CommitTo(dbw trie.DatabaseWriter, deleteEmptyObjects bool) (ro
 			}
 			// Update the object in the main account trie.
 			s.updateStateObject(stateObject)
+
+			data := stateObject.data
+			data.Balance = new(big.Int).Set(data.Balance)
+			accounts[addr] = &data
+			storage[addr] = stateObject.writtenStorage
+			if stateObject.snap == nil {
+				destructs[addr] = struct{}{}
+			}
 		}
 		delete(s.stateObjectsDirty, addr)
 	}
 	// Write trie changes.
 	root, err = s.trie.CommitTo(dbw)
 	glog.V(logger.Debug).Infoln("Trie cache stats after commit", "misses", trie.CacheMisses(), "unloads", trie.CacheUnloads())
+	if err == nil {
+		s.snap = snapshots.update(s.snap, root, accounts, storage, destructs)
+	} else {
+		s.snap = nil
+	}
 	return root, err
 }
 
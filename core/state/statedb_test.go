@@ -437,3 +437,66 @@ func (s *StateSuite) TestTouchDelete(c *check.C) {
 		c.Fatal("expected no dirty state object")
 	}
 }
+
+func TestAccessListRevert(t *testing.T) {
+	mem, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(mem))
+	var (
+		addr  = common.HexToAddress("0xaa")
+		other = common.HexToAddress("0xbb")
+		slot  = common.HexToHash("0x01")
+	)
+	state.AddAddressToAccessList(addr)
+
+	snap := state.Snapshot()
+	state.AddSlotToAccessList(addr, slot)
+	state.AddSlotToAccessList(other, slot)
+	if _, ok := state.SlotInAccessList(other, slot); !ok {
+		t.Fatal("added slot missing")
+	}
+	cpy := state.Copy()
+	state.RevertToSnapshot(snap)
+
+	if !state.AddressInAccessList(addr) {
+		t.Error("address added before the snapshot reverted")
+	}
+	if addrOk, slotOk := state.SlotInAccessList(addr, slot); !addrOk || slotOk {
+		t.Errorf("slot of address added before the snapshot: have %v %v, want true false", addrOk, slotOk)
+	}
+	if state.AddressInAccessList(other) {
+		t.Error("address added after the snapshot not reverted")
+	}
+	if _, ok := cpy.SlotInAccessList(other, slot); !ok {
+		t.Error("revert changed the access list of the copy")
+	}
+	state.ResetAccessList()
+	if state.AddressInAccessList(addr) {
+		t.Error("reset access list not empty")
+	}
+}
+
+func TestContractCreationRevert(t *testing.T) {
+	mem, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(mem))
+	var (
+		tx      = common.HexToHash("0x01")
+		creator = common.HexToAddress("0xaa")
+		outer   = common.HexToAddress("0xbb")
+		inner   = common.HexToAddress("0xcc")
+	)
+	state.StartRecord(tx, common.Hash{}, 0)
+	state.AddContractCreation(outer, creator)
+
+	snap := state.Snapshot()
+	state.AddContractCreation(inner, outer)
+	cpy := state.Copy()
+	state.RevertToSnapshot(snap)
+
+	want := []ContractCreation{{Address: outer, Creator: creator}}
+	if have := state.GetContractCreations(tx); !reflect.DeepEqual(have, want) {
+		t.Errorf("creations after revert mismatch: have %v, want %v", have, want)
+	}
+	if have := cpy.GetContractCreations(tx); len(have) != 2 || have[1] != (ContractCreation{Address: inner, Creator: outer}) {
+		t.Errorf("revert changed the creations of the copy: %v", have)
+	}
+}
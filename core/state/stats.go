@@ -0,0 +1,145 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/rlp"
+	"github.com/webchain-network/webchaind/trie"
+)
+
+// emptyStorageRoot is the root hash of an empty storage trie.
+var emptyStorageRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+// codeSizeBuckets are the upper bounds of the code size distribution buckets,
+// the last bucket holding all larger contracts.
+var codeSizeBuckets = []int{1024, 4096, 8192, 16384, 24576}
+
+// CodeSizeBucket counts the contracts whose code size is at most Max bytes and
+// larger than the previous bucket's. Max is zero for the unbounded last bucket.
+type CodeSizeBucket struct {
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// StorageStats is the storage trie size of a single contract.
+type StorageStats struct {
+	Address     common.Address `json:"address"` // Zero if the address preimage is unknown
+	AddressHash common.Hash    `json:"addressHash"`
+	Slots       int            `json:"slots"`
+	Size        int            `json:"size"` // Bytes of stored slot values
+}
+
+// StateStats summarises the accounts, code and storage of a state.
+type StateStats struct {
+	Root           common.Hash       `json:"root"`
+	Accounts       int               `json:"accounts"`
+	Contracts      int               `json:"contracts"`
+	UniqueCode     int               `json:"uniqueCode"`
+	CodeSize       int               `json:"codeSize"` // Bytes of distinct code
+	CodeSizes      []*CodeSizeBucket `json:"codeSizes"`
+	StorageSlots   int               `json:"storageSlots"`
+	StorageSize    int               `json:"storageSize"`
+	LargestStorage []*StorageStats   `json:"largestStorage"` // Largest first
+}
+
+// Stats walks the whole state, reporting its statistics and the top contracts
+// with the most storage slots.
+func (self *StateDB) Stats(top int) (*StateStats, error) {
+	stats := &StateStats{Root: self.trie.Hash()}
+	for _, max := range codeSizeBuckets {
+		stats.CodeSizes = append(stats.CodeSizes, &CodeSizeBucket{Max: max})
+	}
+	stats.CodeSizes = append(stats.CodeSizes, &CodeSizeBucket{})
+	codeSizes := make(map[common.Hash]int)
+
+	it := trie.NewIterator(self.trie.NodeIterator(nil))
+	for it.Next() {
+		var data Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return nil, err
+		}
+		stats.Accounts++
+		hasStorage := data.Root != emptyStorageRoot && data.Root != (common.Hash{})
+		if bytes.Equal(data.CodeHash, emptyCodeHash) && !hasStorage {
+			continue
+		}
+		addrHash := common.BytesToHash(it.Key)
+
+		if !bytes.Equal(data.CodeHash, emptyCodeHash) {
+			codeHash := common.BytesToHash(data.CodeHash)
+			size, ok := codeSizes[codeHash]
+			if !ok {
+				var err error
+				if size, err = self.db.ContractCodeSize(addrHash, codeHash); err != nil {
+					return nil, err
+				}
+				codeSizes[codeHash] = size
+				stats.UniqueCode++
+				stats.CodeSize += size
+			}
+			stats.Contracts++
+			bucket := sort.SearchInts(codeSizeBuckets, size)
+			stats.CodeSizes[bucket].Count++
+		}
+		if !hasStorage {
+			continue
+		}
+		storage, err := self.db.OpenStorageTrie(addrHash, data.Root)
+		if err != nil {
+			return nil, err
+		}
+		contract := &StorageStats{
+			Address:     common.BytesToAddress(self.trie.GetKey(it.Key)),
+			AddressHash: addrHash,
+		}
+		storageIt := trie.NewIterator(storage.NodeIterator(nil))
+		for storageIt.Next() {
+			contract.Slots++
+			contract.Size += len(storageIt.Value)
+		}
+		if storageIt.Err != nil {
+			return nil, storageIt.Err
+		}
+		stats.StorageSlots += contract.Slots
+		stats.StorageSize += contract.Size
+		stats.LargestStorage = insertLargestStorage(stats.LargestStorage, contract, top)
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return stats, nil
+}
+
+// insertLargestStorage inserts s into list, which is sorted by slot count
+// descending, keeping at most top entries.
+func insertLargestStorage(list []*StorageStats, s *StorageStats, top int) []*StorageStats {
+	i := sort.Search(len(list), func(i int) bool { return list[i].Slots < s.Slots })
+	if i >= top {
+		return list
+	}
+	list = append(list, nil)
+	copy(list[i+1:], list[i:])
+	list[i] = s
+	if len(list) > top {
+		list = list[:top]
+	}
+	return list
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func TestStateStats(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	// Two plain accounts, two contracts sharing small code, one large contract
+	state.AddBalance(common.Address{0x01}, big.NewInt(1))
+	state.AddBalance(common.Address{0x02}, big.NewInt(1))
+	for i, addr := range []common.Address{{0x10}, {0x11}} {
+		state.SetCode(addr, []byte{0x60, 0x00})
+		for j := 0; j <= i; j++ {
+			state.SetState(addr, common.Hash{byte(j + 1)}, common.Hash{0x01})
+		}
+	}
+	state.SetCode(common.Address{0x12}, make([]byte, 5000))
+	for j := 0; j < 5; j++ {
+		state.SetState(common.Address{0x12}, common.Hash{byte(j + 1)}, common.Hash{0xff})
+	}
+	root, err := state.CommitTo(db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _ = New(root, NewDatabase(db))
+
+	stats, err := state.Stats(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Root != root {
+		t.Errorf("root mismatch: have %x, want %x", stats.Root, root)
+	}
+	if stats.Accounts != 5 || stats.Contracts != 3 || stats.UniqueCode != 2 || stats.CodeSize != 5002 {
+		t.Errorf("account stats mismatch: %+v", stats)
+	}
+	if stats.CodeSizes[0].Count != 2 || stats.CodeSizes[2].Count != 1 {
+		t.Errorf("code size distribution mismatch: %v %v %v", stats.CodeSizes[0], stats.CodeSizes[1], stats.CodeSizes[2])
+	}
+	if stats.StorageSlots != 8 {
+		t.Errorf("storage slots mismatch: have %d, want 8", stats.StorageSlots)
+	}
+	if len(stats.LargestStorage) != 2 {
+		t.Fatalf("largest storage length mismatch: have %d, want 2", len(stats.LargestStorage))
+	}
+	if s := stats.LargestStorage[0]; s.Address != (common.Address{0x12}) || s.Slots != 5 {
+		t.Errorf("largest storage mismatch: %+v", s)
+	}
+	if s := stats.LargestStorage[1]; s.Address != (common.Address{0x11}) || s.Slots != 2 {
+		t.Errorf("second largest storage mismatch: %+v", s)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/rlp"
+	"github.com/webchain-network/webchaind/trie"
+)
+
+// StorageRangeEntry is a single storage slot of a StorageRange.
+type StorageRangeEntry struct {
+	Hash  common.Hash  `json:"hash"` // Hashed slot key, the slot's path in the storage trie
+	Key   *common.Hash `json:"key"`  // Nil if the key preimage is unknown
+	Value common.Hash  `json:"value"`
+}
+
+// StorageRange is a page of the storage of an account, ordered by hashed slot
+// key, together with merkle proofs of the account against the state root and
+// of every returned slot against the account's storage root. Each slot can be
+// checked with trie.VerifyProof(StorageRoot, Hash, StorageProof nodes).
+type StorageRange struct {
+	Root         common.Hash          `json:"root"`
+	Address      common.Address       `json:"address"`
+	StorageRoot  common.Hash          `json:"storageRoot"`
+	AccountProof []string             `json:"accountProof"`
+	Storage      []*StorageRangeEntry `json:"storage"`
+	StorageProof []string             `json:"storageProof"`
+	Next         *common.Hash         `json:"next"` // Hashed key of the next slot, nil after the last
+}
+
+// StorageRange returns up to max storage slots of addr, starting at the slot
+// with the hashed key start, with proofs. The state must be committed. A
+// missing account has an empty storage, its proof proving the absence.
+func (self *StateDB) StorageRange(addr common.Address, start common.Hash, max int) (*StorageRange, error) {
+	res := &StorageRange{
+		Root:        self.trie.Hash(),
+		Address:     addr,
+		StorageRoot: emptyStorageRoot,
+		Storage:     []*StorageRangeEntry{},
+	}
+	addrHash := crypto.Keccak256Hash(addr[:])
+	accountProof := newProofList()
+	if err := self.trie.ProveHashed(addrHash[:], 0, accountProof); err != nil {
+		return nil, err
+	}
+	res.AccountProof = accountProof.hex()
+
+	enc, err := self.trie.TryGet(addr[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 {
+		res.StorageProof = []string{}
+		return res, nil
+	}
+	var data Account
+	if err := rlp.DecodeBytes(enc, &data); err != nil {
+		return nil, err
+	}
+	if data.Root != (common.Hash{}) {
+		res.StorageRoot = data.Root
+	}
+	storage, err := self.db.OpenStorageTrie(addrHash, data.Root)
+	if err != nil {
+		return nil, err
+	}
+	storageProof := newProofList()
+	it := trie.NewIterator(storage.NodeIterator(start[:]))
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		if len(res.Storage) >= max {
+			res.Next = &hash
+			break
+		}
+		_, content, _, err := rlp.Split(it.Value)
+		if err != nil {
+			return nil, err
+		}
+		entry := &StorageRangeEntry{Hash: hash, Value: common.BytesToHash(content)}
+		if preimage := storage.GetKey(it.Key); preimage != nil {
+			key := common.BytesToHash(preimage)
+			entry.Key = &key
+		}
+		if err := storage.ProveHashed(it.Key, 0, storageProof); err != nil {
+			return nil, err
+		}
+		res.Storage = append(res.Storage, entry)
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	res.StorageProof = storageProof.hex()
+	return res, nil
+}
+
+// proofList collects the nodes of merkle proofs in order, skipping those
+// shared with an earlier proof.
+type proofList struct {
+	nodes [][]byte
+	seen  map[string]struct{}
+}
+
+func newProofList() *proofList {
+	return &proofList{seen: make(map[string]struct{})}
+}
+
+func (l *proofList) Put(key []byte, value []byte) error {
+	if _, ok := l.seen[string(key)]; !ok {
+		l.seen[string(key)] = struct{}{}
+		l.nodes = append(l.nodes, common.CopyBytes(value))
+	}
+	return nil
+}
+
+func (l *proofList) hex() []string {
+	nodes := make([]string, len(l.nodes))
+	for i, n := range l.nodes {
+		nodes[i] = common.ToHex(n)
+	}
+	return nodes
+}
@@ -0,0 +1,120 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/rlp"
+	"github.com/webchain-network/webchaind/trie"
+)
+
+// proofDb loads hex encoded proof nodes into a database for verification.
+func proofDb(t *testing.T, nodes []string) *ethdb.MemDatabase {
+	db, _ := ethdb.NewMemDatabase()
+	for _, n := range nodes {
+		blob := common.FromHex(n)
+		db.Put(crypto.Keccak256(blob), blob)
+	}
+	return db
+}
+
+func TestStorageRange(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	addr := common.Address{0x42}
+	slots := make(map[common.Hash]common.Hash)
+	for i := 1; i <= 10; i++ {
+		key, value := common.Hash{byte(i)}, common.Hash{31: byte(i)}
+		state.SetState(addr, key, value)
+		slots[key] = value
+	}
+	root, err := state.CommitTo(db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, _ = New(root, NewDatabase(db))
+
+	var (
+		start common.Hash
+		seen  = make(map[common.Hash]bool)
+		last  common.Hash
+	)
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("too many pages")
+		}
+		res, err := state.StorageRange(addr, start, 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Check the account proof against the state root
+		enc, err, _ := trie.VerifyProof(root, crypto.Keccak256(addr[:]), proofDb(t, res.AccountProof))
+		if err != nil {
+			t.Fatalf("bad account proof: %v", err)
+		}
+		var account Account
+		if err := rlp.DecodeBytes(enc, &account); err != nil || account.Root != res.StorageRoot {
+			t.Fatalf("account proof mismatch: %v %x", err, account.Root)
+		}
+		// Check every slot against its proof and the values set
+		proofs := proofDb(t, res.StorageProof)
+		for _, entry := range res.Storage {
+			if entry.Key == nil || slots[*entry.Key] != entry.Value {
+				t.Errorf("slot %x mismatch: key %v value %x", entry.Hash, entry.Key, entry.Value)
+				continue
+			}
+			if entry.Hash.Big().Cmp(last.Big()) < 0 {
+				t.Errorf("slot %x out of order", entry.Hash)
+			}
+			last = entry.Hash
+			if _, err, _ := trie.VerifyProof(res.StorageRoot, entry.Hash[:], proofs); err != nil {
+				t.Errorf("bad proof for slot %x: %v", entry.Hash, err)
+			}
+			seen[*entry.Key] = true
+		}
+		if res.Next == nil {
+			if len(res.Storage) != 2 {
+				t.Errorf("last page size mismatch: have %d, want 2", len(res.Storage))
+			}
+			break
+		}
+		if len(res.Storage) != 4 {
+			t.Errorf("page size mismatch: have %d, want 4", len(res.Storage))
+		}
+		start = *res.Next
+	}
+	if len(seen) != len(slots) {
+		t.Errorf("enumerated %d slots, want %d", len(seen), len(slots))
+	}
+
+	// A missing account has no storage and its proof proves the absence
+	res, err := state.StorageRange(common.Address{0x43}, common.Hash{}, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Storage) != 0 || res.Next != nil || res.StorageRoot != emptyStorageRoot {
+		t.Errorf("missing account has storage: %+v", res)
+	}
+	if enc, err, _ := trie.VerifyProof(root, crypto.Keccak256(common.Address{0x43}.Bytes()), proofDb(t, res.AccountProof)); err != nil || enc != nil {
+		t.Errorf("bad absence proof: %v %x", err, enc)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/trie"
+)
+
+// Witness is a set of trie nodes and contract code keyed by their hashes, the
+// state read executing some transactions. A Witness is a trie.Database itself,
+// so the state it holds can be executed again without the full state.
+type Witness struct {
+	mu    sync.Mutex
+	nodes map[common.Hash][]byte
+	codes map[common.Hash][]byte
+}
+
+// NewWitness returns an empty witness.
+func NewWitness() *Witness {
+	return &Witness{
+		nodes: make(map[common.Hash][]byte),
+		codes: make(map[common.Hash][]byte),
+	}
+}
+
+// AddNode adds a trie node to the witness.
+func (w *Witness) AddNode(blob []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nodes[crypto.Keccak256Hash(blob)] = common.CopyBytes(blob)
+}
+
+// AddCode adds contract code to the witness.
+func (w *Witness) AddCode(code []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.codes[crypto.Keccak256Hash(code)] = common.CopyBytes(code)
+}
+
+// Nodes returns the trie nodes of the witness sorted by hash.
+func (w *Witness) Nodes() [][]byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return sortedBlobs(w.nodes)
+}
+
+// Codes returns the contract code of the witness sorted by hash.
+func (w *Witness) Codes() [][]byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return sortedBlobs(w.codes)
+}
+
+func sortedBlobs(blobs map[common.Hash][]byte) [][]byte {
+	hashes := make([]common.Hash, 0, len(blobs))
+	for h := range blobs {
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+	res := make([][]byte, len(hashes))
+	for i, h := range hashes {
+		res[i] = blobs[h]
+	}
+	return res
+}
+
+// Get implements trie.DatabaseReader, failing for anything not in the witness.
+func (w *Witness) Get(key []byte) ([]byte, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	hash := common.BytesToHash(key)
+	if blob, ok := w.nodes[hash]; ok {
+		return common.CopyBytes(blob), nil
+	}
+	if code, ok := w.codes[hash]; ok {
+		return common.CopyBytes(code), nil
+	}
+	return nil, fmt.Errorf("%x missing from witness", key)
+}
+
+// Has implements trie.DatabaseReader.
+func (w *Witness) Has(key []byte) (bool, error) {
+	_, err := w.Get(key)
+	return err == nil, nil
+}
+
+// Put implements trie.DatabaseWriter, adding a trie node.
+func (w *Witness) Put(key, value []byte) error {
+	w.AddNode(value)
+	return nil
+}
+
+// witnessDB is a Database without caches recording the trie nodes and the
+// code it reads in a witness.
+type witnessDB struct {
+	db      trie.Database
+	witness *Witness
+}
+
+// NewWitnessDatabase returns a Database reading state from db, recording every
+// trie node and contract code read in witness, if set. It doesn't cache
+// anything and state on top of it bypasses the snapshot layer, so everything
+// accessed is read from db.
+func NewWitnessDatabase(db trie.Database, witness *Witness) Database {
+	return &witnessDB{db: db, witness: witness}
+}
+
+func (db *witnessDB) OpenTrie(root common.Hash) (Trie, error) {
+	return trie.NewSecure(root, witnessReader{db.db, db.witness}, 0)
+}
+
+func (db *witnessDB) OpenStorageTrie(addrHash, root common.Hash) (Trie, error) {
+	return trie.NewSecure(root, witnessReader{db.db, db.witness}, 0)
+}
+
+func (db *witnessDB) CopyTrie(t Trie) Trie {
+	switch t := t.(type) {
+	case *trie.SecureTrie:
+		return t.Copy()
+	default:
+		panic(fmt.Errorf("unknown trie type %T", t))
+	}
+}
+
+func (db *witnessDB) ContractCode(addrHash, codeHash common.Hash) ([]byte, error) {
+	code, err := db.db.Get(codeHash[:])
+	if err == nil && db.witness != nil {
+		db.witness.AddCode(code)
+	}
+	return code, err
+}
+
+func (db *witnessDB) ContractCodeSize(addrHash, codeHash common.Hash) (int, error) {
+	code, err := db.ContractCode(addrHash, codeHash)
+	return len(code), err
+}
+
+// witnessReader records the trie nodes read from a database in a witness.
+type witnessReader struct {
+	trie.Database
+	witness *Witness
+}
+
+func (r witnessReader) Get(key []byte) ([]byte, error) {
+	value, err := r.Database.Get(key)
+	if err == nil && r.witness != nil {
+		r.witness.AddNode(value)
+	}
+	return value, err
+}
@@ -0,0 +1,194 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/metrics"
+)
+
+// Availability is the data the node holds of a canonical block.
+type Availability string
+
+const (
+	AvailableState   Availability = "state"   // Header, body, receipts and state
+	AvailableBlocks  Availability = "blocks"  // Header, body and receipts, the state was never synced
+	AvailablePruned  Availability = "pruned"  // Header, body and receipts, the state was synced but is gone
+	AvailableHeaders Availability = "headers" // Header only, the rest of the block is still being synced
+)
+
+// AvailabilityRange is a range of canonical blocks of which the node holds the
+// same data.
+type AvailabilityRange struct {
+	From         uint64       `json:"from"`
+	To           uint64       `json:"to"`
+	Availability Availability `json:"availability"`
+}
+
+// StateUnavailableError is returned when the state of a block is requested
+// which the node doesn't hold.
+type StateUnavailableError struct {
+	Number       uint64
+	Availability Availability
+	OldestState  uint64 // Oldest block above the genesis with state, 0 if none
+}
+
+func (e *StateUnavailableError) Error() string {
+	var reason string
+	switch e.Availability {
+	case AvailableBlocks:
+		reason = "state not synced"
+	case AvailablePruned:
+		reason = "state pruned"
+	case AvailableHeaders:
+		reason = "block not synced yet"
+	default:
+		reason = "unknown block"
+	}
+	if e.OldestState == 0 {
+		return fmt.Sprintf("state unavailable at block %d: %s", e.Number, reason)
+	}
+	return fmt.Sprintf("state unavailable at block %d: %s, state available from block %d", e.Number, reason, e.OldestState)
+}
+
+// registerAvailabilityMetrics reports the state availability of the chain.
+func (bc *BlockChain) registerAvailabilityMetrics() {
+	metrics.RegisterGaugeFunc("chain/state/oldest", func() int64 {
+		return int64(bc.OldestState())
+	})
+	metrics.RegisterGaugeFunc("chain/state/missing", func() int64 {
+		var missing uint64
+		for _, r := range bc.StateAvailability() {
+			if r.Availability != AvailableState {
+				missing += r.To - r.From + 1
+			}
+		}
+		return int64(missing)
+	})
+}
+
+// OldestState returns the number of the oldest canonical block above the
+// genesis whose state is in the database, or 0 if the genesis is the only
+// one. The state of every block from there up to the head is expected to be
+// present, as fast sync only retrieves the state of its pivot block.
+func (bc *BlockChain) OldestState() uint64 {
+	bc.availMu.Lock()
+	defer bc.availMu.Unlock()
+
+	if bc.oldestState != 0 {
+		return bc.oldestState
+	}
+	head := bc.CurrentBlock().NumberU64()
+	oldest := uint64(sort.Search(int(head), func(i int) bool {
+		return bc.hasCanonicalState(uint64(i) + 1)
+	})) + 1
+	if oldest > head {
+		return 0
+	}
+	bc.oldestState = oldest
+	return oldest
+}
+
+// resetOldestState drops the cached oldest state, after the head was moved to
+// a block whose state may be unrelated to the previous one.
+func (bc *BlockChain) resetOldestState() {
+	bc.availMu.Lock()
+	defer bc.availMu.Unlock()
+
+	bc.oldestState = 0
+}
+
+func (bc *BlockChain) hasCanonicalState(number uint64) bool {
+	header := bc.GetHeaderByNumber(number)
+	if header == nil {
+		return false
+	}
+	_, err := state.New(header.Root, state.NewDatabase(bc.chainDb))
+	return err == nil
+}
+
+// StateAvailability returns the ranges of canonical blocks from the genesis up
+// to the head header, by the data the node holds of them.
+func (bc *BlockChain) StateAvailability() []AvailabilityRange {
+	var (
+		head   = bc.CurrentBlock().NumberU64()
+		fast   = bc.CurrentFastBlock().NumberU64()
+		header = bc.CurrentHeader().Number.Uint64()
+		oldest = bc.OldestState()
+	)
+	if oldest == 0 {
+		oldest = head + 1
+	}
+	if fast < head {
+		fast = head
+	}
+	ranges := []AvailabilityRange{{0, 0, AvailableState}}
+	add := func(from, to uint64, availability Availability) {
+		if from > to {
+			return
+		}
+		if last := &ranges[len(ranges)-1]; last.Availability == availability && last.To+1 == from {
+			last.To = to
+			return
+		}
+		ranges = append(ranges, AvailabilityRange{from, to, availability})
+	}
+	// The state of the blocks below the fast sync pivot was never retrieved,
+	// the state missing above it was lost.
+	pivot, ok := GetFastSyncPivot(bc.chainDb)
+	if !ok || pivot < 1 || pivot > oldest {
+		pivot = oldest
+	}
+	add(1, pivot-1, AvailableBlocks)
+	add(pivot, oldest-1, AvailablePruned)
+	add(oldest, head, AvailableState)
+	add(head+1, fast, AvailableBlocks)
+	add(fast+1, header, AvailableHeaders)
+	return ranges
+}
+
+// BlockAvailability returns the data the node holds of the canonical block
+// with the given number, or false if the block is above the head header.
+func (bc *BlockChain) BlockAvailability(number uint64) (Availability, bool) {
+	for _, r := range bc.StateAvailability() {
+		if r.From <= number && number <= r.To {
+			return r.Availability, true
+		}
+	}
+	return "", false
+}
+
+// StateAtHeader returns the state after the given block. If the node doesn't
+// hold it, the error is a *StateUnavailableError telling why rather than the
+// missing trie node.
+func (bc *BlockChain) StateAtHeader(header *types.Header) (*state.StateDB, error) {
+	statedb, err := bc.StateAt(header.Root)
+	if err == nil {
+		return statedb, nil
+	}
+	number := header.Number.Uint64()
+	availability, _ := bc.BlockAvailability(number)
+	if availability == AvailableState {
+		// The state of a side block, or a corrupt database.
+		return nil, err
+	}
+	return nil, &StateUnavailableError{Number: number, Availability: availability, OldestState: bc.OldestState()}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core/state"
 	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/core/vm"
@@ -30,6 +31,7 @@ import (
 )
 
 var (
+	MaximumBlockReward       = new(big.Int).Mul(big.NewInt(5e+18), big.NewInt(10)) // 50 WEB
 	big32                    = big.NewInt(32)
 	DisinflationRateQuotient = big.NewInt(249)
 	DisinflationRateDivisor  = big.NewInt(250)
@@ -67,7 +69,21 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 		header       = block.Header()
 		allLogs      vm.Logs
 		gp           = new(GasPool).AddGas(block.GasLimit())
+		opMetrics    *vm.OpMetrics
 	)
+	if opMetricsEnabled {
+		opMetrics = new(vm.OpMetrics)
+	}
+	// Load the state the transactions touch in the background while the
+	// earlier ones execute
+	prefetcher := statedb.NewPrefetcher()
+	defer prefetcher.Close()
+	prefetcher.Prefetch(header.Coinbase)
+	for _, tx := range block.Transactions() {
+		if to := tx.To(); to != nil {
+			prefetcher.Prefetch(*to)
+		}
+	}
 	// Iterate over and process the individual transactions
 	for i, tx := range block.Transactions() {
 		if tx.Protected() {
@@ -81,7 +97,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 		}
 		statedb.StartRecord(tx.Hash(), block.Hash(), i)
 		if UseSputnikVM != "true" {
-			receipt, logs, _, err := ApplyTransaction(p.config, p.bc, gp, statedb, header, tx, totalUsedGas)
+			receipt, logs, _, err := applyTransaction(p.config, p.bc, gp, statedb, header, tx, totalUsedGas, opMetrics)
 			if err != nil {
 				return nil, nil, nil, err
 			}
@@ -96,7 +112,13 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, logs...)
 	}
+	if UseSputnikVM != "true" {
+		types.DeriveBlooms(receipts)
+	}
 	AccumulateRewards(p.config, statedb, header, block.Uncles())
+	if opMetrics != nil {
+		reportOpMetrics(opMetrics)
+	}
 
 	return receipts, allLogs, totalUsedGas, err
 }
@@ -107,19 +129,38 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 // ApplyTransactions returns the generated receipts and vm logs during the
 // execution of the state transition phase.
 func ApplyTransaction(config *ChainConfig, bc *BlockChain, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int) (*types.Receipt, vm.Logs, *big.Int, error) {
+	receipt, logs, gas, err := applyTransaction(config, bc, gp, statedb, header, tx, usedGas, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	return receipt, logs, gas, nil
+}
+
+// applyTransaction is ApplyTransaction without computing the receipt's bloom,
+// which Process derives for all receipts of a block at once. The executed
+// opcodes are recorded in opMetrics, if set.
+func applyTransaction(config *ChainConfig, bc *BlockChain, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int, opMetrics *vm.OpMetrics) (*types.Receipt, vm.Logs, *big.Int, error) {
+	if !config.IsTxTypeSupported(tx.Type(), header.Number) {
+		return nil, nil, nil, fmt.Errorf("%v: type %d at block number %v", ErrTxTypeNotSupported, tx.Type(), header.Number)
+	}
 	tx.SetSigner(config.GetSigner(header.Number))
 
-	_, gas, failed, err := ApplyMessage(NewEnv(statedb, config, bc, tx, header), tx, gp)
+	env := NewEnv(statedb, config, bc, tx, header)
+	if opMetrics != nil {
+		env.SetOpMetrics(opMetrics)
+	}
+	ret, gas, failed, err := ApplyMessage(env, tx, gp)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
 	// Update the state with pending changes
 	var root []byte
-	if config.IsAtlantis(header.Number) {
-		statedb.Finalise(true)
+	if config.IsReceiptStatus(header.Number) {
+		statedb.Finalise(config.IsStateClear(header.Number))
 	} else {
-		root = statedb.IntermediateRoot(config.IsAtlantis(header.Number)).Bytes()
+		root = statedb.IntermediateRoot(config.IsStateClear(header.Number)).Bytes()
 	}
 
 	usedGas.Add(usedGas, gas)
@@ -133,9 +174,9 @@ func ApplyTransaction(config *ChainConfig, bc *BlockChain, gp *GasPool, statedb
 
 	logs := statedb.GetLogs(tx.Hash())
 	receipt.Logs = logs
-	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
 	if failed {
 		receipt.Status = types.TxFailure
+		receipt.RevertData = common.CopyBytes(ret)
 	} else {
 		receipt.Status = types.TxSuccess
 	}
@@ -158,8 +199,7 @@ func AccumulateRewards(config *ChainConfig, statedb *state.StateDB, header *type
 	// block.Number = 2,534,999 // uncles can be at same height as each other
 	// ... as uncles get older (within validation; <=n-7), reward drops
 
-	eraLen := big.NewInt(100000)
-	era := GetBlockEra(header.Number, eraLen)
+	era := GetBlockEra(header.Number, EraLength)
 
 	wr := GetBlockWinnerRewardByEra(era) // wr "winner reward".
 
@@ -200,9 +240,6 @@ func GetBlockWinnerRewardForUnclesByEra(era *big.Int, uncles []*types.Header) *b
 // GetRewardByEra gets a block reward at disinflation rate.
 // Constants MaxBlockReward, DisinflationRateQuotient, and DisinflationRateDivisor assumed.
 func GetBlockWinnerRewardByEra(eraOrig *big.Int) *big.Int {
-	MaximumBlockReward := big.NewInt(5e+18) // 5 WEB
-	MaximumBlockReward.Mul(MaximumBlockReward, big.NewInt(10)) // 50 WEB
-
 	era := new(big.Int).Set(eraOrig)
 
 	if era.Cmp(big.NewInt(0)) == 0 {
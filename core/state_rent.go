@@ -0,0 +1,113 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/crypto"
+)
+
+// StateRentRegistry is the system account keeping the block each account was
+// last touched at while the experimental 'rent' feature is active. Touch
+// blocks live in its storage under the keccak hash of the account address.
+var StateRentRegistry = common.HexToAddress("0x000000000000000000000000000000000000fee5")
+
+// StateRent is the state rent experiment configured at some block: accounts
+// idle for longer than Grace blocks pay Rate wei for every further idle block
+// when they're touched again.
+//
+// The experiment is meant for testnets and is configured by a 'rent' feature,
+// e.g. {"id": "rent", "options": {"rate": 1000000000, "grace": 100000}}.
+type StateRent struct {
+	Rate  *big.Int
+	Grace uint64
+}
+
+// rentScheduler is implemented by rule sets configuring state rent.
+type rentScheduler interface {
+	StateRent(num *big.Int) *StateRent
+}
+
+// StateRent returns the state rent experiment active at block num, or nil if
+// no 'rent' feature is configured up to it.
+func (c *ChainConfig) StateRent(num *big.Int) *StateRent {
+	f, _, configured := c.GetFeature(num, "rent")
+	if !configured {
+		return nil
+	}
+	rate, ok := f.GetBigInt("rate")
+	if !ok || rate.Sign() <= 0 {
+		return nil
+	}
+	rent := &StateRent{Rate: rate}
+	if grace, ok := f.GetBigInt("grace"); ok {
+		rent.Grace = grace.Uint64()
+	}
+	return rent
+}
+
+// rentKey returns the registry slot keeping the touch block of addr.
+func rentKey(addr common.Address) common.Hash {
+	return crypto.Keccak256Hash(addr.Bytes())
+}
+
+// LastTouched returns the block addr was last touched at since state rent
+// started, or 0 if it wasn't touched yet.
+func (r *StateRent) LastTouched(db vm.Database, addr common.Address) uint64 {
+	return db.GetState(StateRentRegistry, rentKey(addr)).Big().Uint64()
+}
+
+// Fee returns the inactivity fee addr owes when touched at block num. It's
+// capped to the balance of the account, accounts never touched before don't
+// owe anything as their idle time is unknown.
+func (r *StateRent) Fee(db vm.Database, addr common.Address, num *big.Int) *big.Int {
+	last := r.LastTouched(db, addr)
+	if last == 0 || num.Uint64() <= last+r.Grace {
+		return new(big.Int)
+	}
+	idle := new(big.Int).SetUint64(num.Uint64() - last - r.Grace)
+	fee := idle.Mul(idle, r.Rate)
+	if balance := db.GetBalance(addr); fee.Cmp(balance) > 0 {
+		fee.Set(balance)
+	}
+	return fee
+}
+
+// Touch charges addr the inactivity fee it owes at block num, which is burnt,
+// and records num as its last touch. Accounts that don't exist are skipped.
+// It returns the fee charged.
+func (r *StateRent) Touch(db vm.Database, addr common.Address, num *big.Int) *big.Int {
+	if !db.Exist(addr) {
+		return new(big.Int)
+	}
+	fee := r.Fee(db, addr, num)
+	if fee.Sign() > 0 {
+		db.GetAccount(addr).SubBalance(fee)
+	}
+	// A nonce keeps the registry from being removed as an empty account
+	if db.GetNonce(StateRentRegistry) == 0 {
+		if !db.Exist(StateRentRegistry) {
+			db.CreateAccount(StateRentRegistry)
+		}
+		db.SetNonce(StateRentRegistry, 1)
+	}
+	db.SetState(StateRentRegistry, rentKey(addr), common.BigToHash(num))
+	return fee
+}
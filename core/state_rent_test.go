@@ -0,0 +1,95 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func TestChainConfig_StateRent(t *testing.T) {
+	config := &ChainConfig{
+		Forks: []*Fork{
+			{Name: "Homestead", Block: big.NewInt(0)},
+			{
+				Name:  "Rent",
+				Block: big.NewInt(10),
+				Features: []*ForkFeature{{
+					ID:      "rent",
+					Options: ChainFeatureConfigOptions{"rate": 5, "grace": 100},
+				}},
+			},
+		},
+	}
+	if rent := config.StateRent(big.NewInt(9)); rent != nil {
+		t.Errorf("block 9: expected no state rent, got %+v", rent)
+	}
+	rent := config.StateRent(big.NewInt(10))
+	if rent == nil {
+		t.Fatal("block 10: expected state rent")
+	}
+	if rent.Rate.Cmp(big.NewInt(5)) != 0 || rent.Grace != 100 {
+		t.Errorf("block 10: have rate %v grace %d, want rate 5 grace 100", rent.Rate, rent.Grace)
+	}
+}
+
+func TestStateRentTouch(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	addr := common.HexToAddress("0x1")
+	statedb.AddBalance(addr, big.NewInt(1000))
+	rent := &StateRent{Rate: big.NewInt(3), Grace: 10}
+
+	// The first touch starts the clock without charging
+	if fee := rent.Touch(statedb, addr, big.NewInt(100)); fee.Sign() != 0 {
+		t.Errorf("first touch: charged %v, want 0", fee)
+	}
+	if last := rent.LastTouched(statedb, addr); last != 100 {
+		t.Errorf("last touched %d, want 100", last)
+	}
+	// Idle time within the grace period is free
+	if fee := rent.Touch(statedb, addr, big.NewInt(110)); fee.Sign() != 0 {
+		t.Errorf("touch within grace: charged %v, want 0", fee)
+	}
+	// 20 idle blocks past the grace period
+	if fee := rent.Touch(statedb, addr, big.NewInt(140)); fee.Cmp(big.NewInt(60)) != 0 {
+		t.Errorf("touch past grace: charged %v, want 60", fee)
+	}
+	if balance := statedb.GetBalance(addr); balance.Cmp(big.NewInt(940)) != 0 {
+		t.Errorf("balance %v, want 940", balance)
+	}
+	// Fees are capped to the balance
+	if fee := rent.Touch(statedb, addr, big.NewInt(1000)); fee.Cmp(big.NewInt(940)) != 0 {
+		t.Errorf("capped touch: charged %v, want 940", fee)
+	}
+	// The registry survives state clearing
+	statedb.Finalise(true)
+	if last := rent.LastTouched(statedb, addr); last != 1000 {
+		t.Errorf("last touched %d after finalise, want 1000", last)
+	}
+	// Unknown accounts aren't recorded
+	other := common.HexToAddress("0x2")
+	rent.Touch(statedb, other, big.NewInt(1000))
+	if last := rent.LastTouched(statedb, other); last != 0 {
+		t.Errorf("unknown account touched at %d, want 0", last)
+	}
+}
@@ -18,9 +18,11 @@ package core
 
 import (
 	"errors"
+	"math"
 	"math/big"
 
 	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/core/vm"
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
@@ -31,6 +33,8 @@ var (
 	TxGasContractCreation        = big.NewInt(53000) // Per transaction that creates a contract. NOTE: Not payable on data of calls between transactions.
 	TxDataZeroGas                = big.NewInt(4)     // Per byte of data attached to a transaction that equals zero. NOTE: Not payable on data of calls between transactions.
 	TxDataNonZeroGas             = big.NewInt(68)    // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
+	TxAccessListAddressGas       = big.NewInt(2400)  // Per address in the access list of a transaction.
+	TxAccessListStorageKeyGas    = big.NewInt(1900)  // Per storage key in the access list of a transaction.
 	errInsufficientBalanceForGas = errors.New("insufficient balance to pay for gas")
 )
 
@@ -52,13 +56,16 @@ The state transitioning model does all all the necessary work to work out a vali
 6) Derive new state root
 */
 type StateTransition struct {
-	gp            *GasPool
-	msg           Message
-	gas, gasPrice *big.Int
-	initialGas    *big.Int
-	value         *big.Int
-	data          []byte
-	state         vm.Database
+	gp         *GasPool
+	msg        Message
+	gas        uint64
+	gasPrice   *big.Int
+	baseFee    *big.Int // Burnt per gas used, nil before the fee market
+	initialGas uint64
+	excessGas  *big.Int // Gas bought above what the EVM accounts, given back unused
+	value      *big.Int
+	data       []byte
+	state      vm.Database
 
 	env vm.Environment
 }
@@ -68,12 +75,14 @@ type Message interface {
 	From() (common.Address, error)
 	To() *common.Address
 
-	GasPrice() *big.Int
+	GasPrice() *big.Int // Max fee per gas of dynamic fee transactions
+	GasTipCap() *big.Int
 	Gas() *big.Int
 	Value() *big.Int
 
 	Nonce() uint64
 	Data() []byte
+	AccessList() types.AccessList
 }
 
 func MessageCreatesContract(msg Message) bool {
@@ -81,8 +90,8 @@ func MessageCreatesContract(msg Message) bool {
 }
 
 // IntrinsicGas computes the 'intrinsic gas' for a message
-// with the given data.
-func IntrinsicGas(data []byte, contractCreation, homestead bool) *big.Int {
+// with the given data and access list.
+func IntrinsicGas(data []byte, accessList types.AccessList, contractCreation, homestead bool) *big.Int {
 	igas := new(big.Int)
 	if contractCreation && homestead {
 		igas.Set(TxGasContractCreation)
@@ -103,21 +112,25 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) *big.Int {
 		m.Mul(m, TxDataZeroGas)
 		igas.Add(igas, m)
 	}
+	if len(accessList) > 0 {
+		m := big.NewInt(int64(len(accessList)))
+		igas.Add(igas, m.Mul(m, TxAccessListAddressGas))
+		m.SetInt64(int64(accessList.StorageKeys()))
+		igas.Add(igas, m.Mul(m, TxAccessListStorageKeyGas))
+	}
 	return igas
 }
 
 // NewStateTransition initialises and returns a new state transition object.
 func NewStateTransition(env vm.Environment, msg Message, gp *GasPool) *StateTransition {
 	return &StateTransition{
-		gp:         gp,
-		env:        env,
-		msg:        msg,
-		gas:        new(big.Int),
-		gasPrice:   msg.GasPrice(),
-		initialGas: new(big.Int),
-		value:      msg.Value(),
-		data:       msg.Data(),
-		state:      env.Db(),
+		gp:       gp,
+		env:      env,
+		msg:      msg,
+		gasPrice: msg.GasPrice(),
+		value:    msg.Value(),
+		data:     msg.Data(),
+		state:    env.Db(),
 	}
 }
 
@@ -149,19 +162,15 @@ func (st *StateTransition) to() common.Address {
 	return *st.msg.To()
 }
 
-func (st *StateTransition) useGas(amount *big.Int) error {
-	if st.gas.Cmp(amount) < 0 {
+func (st *StateTransition) useGas(amount uint64) error {
+	if st.gas < amount {
 		return vm.OutOfGasError
 	}
-	st.gas.Sub(st.gas, amount)
+	st.gas -= amount
 
 	return nil
 }
 
-func (st *StateTransition) addGas(amount *big.Int) {
-	st.gas.Add(st.gas, amount)
-}
-
 func (st *StateTransition) buyGas() error {
 	mgas := st.msg.Gas()
 	mgval := new(big.Int).Mul(mgas, st.gasPrice)
@@ -172,15 +181,26 @@ func (st *StateTransition) buyGas() error {
 	}
 	sender := st.state.GetAccount(address)
 
-	if st.state.GetBalance(address).Cmp(mgval) < 0 {
+	// The sender must afford the fee cap even if the price paid is lower
+	balanceCheck := mgval
+	if st.baseFee != nil {
+		balanceCheck = new(big.Int).Mul(mgas, st.msg.GasPrice())
+	}
+	if st.state.GetBalance(address).Cmp(balanceCheck) < 0 {
 		return errInsufficientBalanceForGas
 	}
 
 	if err = st.gp.SubGas(mgas); err != nil {
 		return err
 	}
-	st.addGas(mgas)
-	st.initialGas.Set(mgas)
+	// The EVM accounts gas in 64 bits, the rest is refunded untouched.
+	gas := mgas
+	if !gas.IsUint64() {
+		gas = new(big.Int).SetUint64(math.MaxUint64)
+	}
+	st.gas += gas.Uint64()
+	st.initialGas = gas.Uint64()
+	st.excessGas = new(big.Int).Sub(mgas, gas)
 	sender.SubBalance(mgval)
 	return nil
 }
@@ -197,6 +217,12 @@ func (st *StateTransition) preCheck() (err error) {
 		return NonceError(msg.Nonce(), n)
 	}
 
+	if baseFee := baseFeeOf(st.env); baseFee != nil {
+		if err = st.applyBaseFee(baseFee); err != nil {
+			return InvalidTxError(err)
+		}
+	}
+
 	// Pre-pay gas
 	if err = st.buyGas(); err != nil {
 		if IsGasLimitErr(err) {
@@ -208,6 +234,25 @@ func (st *StateTransition) preCheck() (err error) {
 	return nil
 }
 
+// applyBaseFee sets the price per gas the message pays in a block with the
+// given base fee: the base fee plus the priority fee, within the fee cap.
+func (st *StateTransition) applyBaseFee(baseFee *big.Int) error {
+	feeCap, tipCap := st.msg.GasPrice(), st.msg.GasTipCap()
+	if tipCap.Cmp(feeCap) > 0 {
+		return ErrTipAboveFeeCap
+	}
+	if feeCap.Cmp(baseFee) < 0 {
+		return ErrFeeCapTooLow
+	}
+	price := new(big.Int).Add(baseFee, tipCap)
+	if price.Cmp(feeCap) > 0 {
+		price.Set(feeCap)
+	}
+	st.gasPrice = price
+	st.baseFee = baseFee
+	return nil
+}
+
 // TransitionDb will move the state by applying the message against the given environment.
 func (st *StateTransition) TransitionDb() (ret []byte, gas *big.Int, failed bool, err error) {
 	if err = st.preCheck(); err != nil {
@@ -227,21 +272,24 @@ func (st *StateTransition) TransitionDb() (ret []byte, gas *big.Int, failed bool
 	homestead := st.env.RuleSet().IsHomestead(st.env.BlockNumber())
 	contractCreation := MessageCreatesContract(msg)
 	// Pay intrinsic gas
-	if err = st.useGas(IntrinsicGas(st.data, contractCreation, homestead)); err != nil {
+	if err = st.useGas(IntrinsicGas(st.data, msg.AccessList(), contractCreation, homestead).Uint64()); err != nil {
 		return nil, nil, false, InvalidTxError(err)
 	}
+	if al := vm.AccessListOf(st.env); al != nil {
+		st.prepareAccessList(al, address)
+	}
 
 	vmenv := st.env
 	//var addr common.Address
 	var vmerr error
 	if contractCreation {
-		ret, _, vmerr = vmenv.Create(sender, st.data, st.gas, st.gasPrice, st.value)
+		ret, _, st.gas, vmerr = vmenv.Create(sender, st.data, st.gas, st.gasPrice, st.value)
 
 		if vmerr == errContractAddressCollision {
-			st.gas = big.NewInt(0)
+			st.gas = 0
 		}
 		if homestead && vmerr == vm.CodeStoreOutOfGasError {
-			st.gas = big.NewInt(0)
+			st.gas = 0
 		}
 
 		if vmerr != nil {
@@ -250,7 +298,7 @@ func (st *StateTransition) TransitionDb() (ret []byte, gas *big.Int, failed bool
 	} else {
 		// Increment the nonce for the next transaction
 		st.state.SetNonce(address, st.state.GetNonce(sender.Address())+1)
-		ret, vmerr = vmenv.Call(sender, st.to(), st.data, st.gas, st.gasPrice, st.value)
+		ret, st.gas, vmerr = vmenv.Call(sender, st.to(), st.data, st.gas, st.gasPrice, st.value)
 		if vmerr != nil {
 			glog.V(logger.Core).Infoln("VM call err:", vmerr)
 		}
@@ -262,10 +310,62 @@ func (st *StateTransition) TransitionDb() (ret []byte, gas *big.Int, failed bool
 		return nil, nil, false, InvalidTxError(vmerr)
 	}
 
+	// An exceptional halt consumes all the gas, including the gas above
+	// what the EVM accounts.
+	gasUsed := new(big.Int)
+	if vmerr != nil && vmerr != vm.ErrRevert {
+		gasUsed.Set(st.excessGas)
+		st.excessGas.SetUint64(0)
+	}
 	st.refundGas()
-	st.state.AddBalance(st.env.Coinbase(), new(big.Int).Mul(st.gasUsed(), st.gasPrice))
+	gasUsed.Add(gasUsed, new(big.Int).SetUint64(st.gasUsed()))
+	// The base fee is burnt, the miner only earns the priority fee
+	tip := st.gasPrice
+	if st.baseFee != nil {
+		tip = new(big.Int).Sub(st.gasPrice, st.baseFee)
+	}
+	st.state.AddBalance(st.env.Coinbase(), new(big.Int).Mul(gasUsed, tip))
+	st.chargeRent(address)
 
-	return ret, st.gasUsed(), vmerr != nil, err
+	return ret, gasUsed, vmerr != nil, err
+}
+
+// prepareAccessList starts the access list of the message, warming up the
+// sender, the recipient, the precompiled contracts and the message's own
+// access list (EIP-2929).
+func (st *StateTransition) prepareAccessList(al vm.AccessListDatabase, sender common.Address) {
+	al.ResetAccessList()
+	al.AddAddressToAccessList(sender)
+	if to := st.msg.To(); to != nil {
+		al.AddAddressToAccessList(*to)
+	}
+	for addr := range vm.ActivePrecompiles(st.env.RuleSet(), st.env.BlockNumber()) {
+		al.AddAddressToAccessList(common.StringToAddress(addr))
+	}
+	for _, tuple := range st.msg.AccessList() {
+		al.AddAddressToAccessList(tuple.Address)
+		for _, key := range tuple.StorageKeys {
+			al.AddSlotToAccessList(tuple.Address, key)
+		}
+	}
+}
+
+// chargeRent touches the sender and the recipient of the message under the
+// state rent experiment, if the rule set configures one.
+func (st *StateTransition) chargeRent(sender common.Address) {
+	rs, ok := st.env.RuleSet().(rentScheduler)
+	if !ok {
+		return
+	}
+	num := st.env.BlockNumber()
+	rent := rs.StateRent(num)
+	if rent == nil {
+		return
+	}
+	rent.Touch(st.state, sender, num)
+	if to := st.msg.To(); to != nil && *to != sender {
+		rent.Touch(st.state, *to, num)
+	}
 }
 
 func (st *StateTransition) refundGas() {
@@ -276,19 +376,20 @@ func (st *StateTransition) refundGas() {
 		return
 	}
 
-	remaining := new(big.Int).Mul(st.gas, st.gasPrice)
-	st.state.AddBalance(address, remaining)
-
 	// Apply refund counter, capped to half of the used gas.
-	uhalf := remaining.Div(st.gasUsed(), common.Big2)
-	refund := common.BigMin(uhalf, st.state.GetRefund())
-	st.gas.Add(st.gas, refund)
-	st.state.AddBalance(address, refund.Mul(refund, st.gasPrice))
+	refund := st.gasUsed() / 2
+	if counter := st.state.GetRefund(); counter.Cmp(new(big.Int).SetUint64(refund)) < 0 {
+		refund = counter.Uint64()
+	}
+	st.gas += refund
+
+	remaining := new(big.Int).Add(new(big.Int).SetUint64(st.gas), st.excessGas)
+	st.state.AddBalance(address, new(big.Int).Mul(remaining, st.gasPrice))
 	// Also return remaining gas to the block gas counter so it is
 	// available for the next transaction.
-	st.gp.AddGas(st.gas)
+	st.gp.AddGas(remaining)
 }
 
-func (st *StateTransition) gasUsed() *big.Int {
-	return new(big.Int).Sub(st.initialGas, st.gas)
+func (st *StateTransition) gasUsed() uint64 {
+	return st.initialGas - st.gas
 }
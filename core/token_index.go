@@ -0,0 +1,241 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+var (
+	errTokenIndexDb = errors.New("token index requires a level db")
+
+	// tokenTransferPrefix + holder + token + block number + log index -> transfer
+	tokenTransferPrefix = []byte("ttx-")
+
+	// transferTopic is the topic of the Transfer events of ERC20 and ERC721 tokens
+	transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+)
+
+// TokenTransfer is a transfer of ERC20 tokens, or of an ERC721 token, decoded
+// from a Transfer event.
+type TokenTransfer struct {
+	Token       common.Address
+	From, To    common.Address
+	Value       *big.Int // Amount of ERC20 tokens, id of the ERC721 token
+	NFT         bool     // Whether the token is an ERC721 one
+	TxHash      common.Hash
+	BlockNumber uint64
+	LogIndex    uint
+}
+
+// TokenBalance is the balance of an address in a token, according to the
+// indexed transfers.
+type TokenBalance struct {
+	Token    common.Address
+	NFT      bool
+	Balance  *big.Int   // Amount of ERC20 tokens, number of ERC721 tokens owned
+	TokenIds []*big.Int // Ids of the ERC721 tokens owned
+}
+
+// decodeTokenTransfer decodes log as a token transfer, nil if it isn't one.
+// ERC20 transfers have the amount as data, ERC721 ones the token id as a
+// third indexed argument.
+func decodeTokenTransfer(log *vm.Log) *TokenTransfer {
+	if len(log.Topics) == 0 || log.Topics[0] != transferTopic {
+		return nil
+	}
+	transfer := &TokenTransfer{
+		Token:       log.Address,
+		TxHash:      log.TxHash,
+		BlockNumber: log.BlockNumber,
+		LogIndex:    log.Index,
+	}
+	switch {
+	case len(log.Topics) == 3 && len(log.Data) == 32:
+		transfer.Value = new(big.Int).SetBytes(log.Data)
+	case len(log.Topics) == 4 && len(log.Data) == 0:
+		transfer.Value = log.Topics[3].Big()
+		transfer.NFT = true
+	default:
+		return nil
+	}
+	transfer.From = common.BytesToAddress(log.Topics[1][common.HashLength-common.AddressLength:])
+	transfer.To = common.BytesToAddress(log.Topics[2][common.HashLength-common.AddressLength:])
+	return transfer
+}
+
+// tokenTransferKey returns the index key of a transfer for holder, ordering
+// the transfers of a token chronologically.
+func tokenTransferKey(holder common.Address, t *TokenTransfer) []byte {
+	key := make([]byte, 0, len(tokenTransferPrefix)+2*common.AddressLength+8+4)
+	key = append(key, tokenTransferPrefix...)
+	key = append(key, holder.Bytes()...)
+	key = append(key, t.Token.Bytes()...)
+	var pos [12]byte
+	binary.BigEndian.PutUint64(pos[:8], t.BlockNumber)
+	binary.BigEndian.PutUint32(pos[8:], uint32(t.LogIndex))
+	return append(key, pos[:]...)
+}
+
+// blockTokenTransfers returns the token transfers of the receipts of a block.
+func blockTokenTransfers(receipts types.Receipts) []*TokenTransfer {
+	var transfers []*TokenTransfer
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			if t := decodeTokenTransfer(log); t != nil {
+				transfers = append(transfers, t)
+			}
+		}
+	}
+	return transfers
+}
+
+// WriteBlockTokenTransfers indexes the token transfers of a block, given its
+// receipts, for both their sender and recipient.
+func WriteBlockTokenTransfers(indexDb ethdb.Database, receipts types.Receipts) error {
+	batch := indexDb.NewBatch()
+	for _, t := range blockTokenTransfers(receipts) {
+		data, err := rlp.EncodeToBytes(t)
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(tokenTransferKey(t.From, t), data); err != nil {
+			return err
+		}
+		if err := batch.Put(tokenTransferKey(t.To, t), data); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
+
+// DeleteBlockTokenTransfers removes the token transfers of a block, given its
+// receipts, from the index.
+func DeleteBlockTokenTransfers(indexDb ethdb.Database, receipts types.Receipts) error {
+	for _, t := range blockTokenTransfers(receipts) {
+		if err := indexDb.Delete(tokenTransferKey(t.From, t)); err != nil {
+			return err
+		}
+		if err := indexDb.Delete(tokenTransferKey(t.To, t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTokenTransfersAbove removes the token transfers of blocks above head
+// from the index.
+func deleteTokenTransfersAbove(indexDb ethdb.Database, head uint64) error {
+	ldb, ok := indexDb.(*ethdb.LDBDatabase)
+	if !ok {
+		return errTokenIndexDb
+	}
+	var removals [][]byte
+	it := ldb.NewIteratorRange(ethdb.NewBytesPrefix(tokenTransferPrefix))
+	for it.Next() {
+		key := it.Key()
+		if binary.BigEndian.Uint64(key[len(key)-12:]) > head {
+			removals = append(removals, common.CopyBytes(key))
+		}
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+	for _, key := range removals {
+		if err := ldb.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetTokenTransfers returns the indexed token transfers from and to holder,
+// of a single token if token isn't nil, ordered by token and then by block.
+func GetTokenTransfers(indexDb ethdb.Database, holder common.Address, token *common.Address) ([]*TokenTransfer, error) {
+	ldb, ok := indexDb.(*ethdb.LDBDatabase)
+	if !ok {
+		return nil, errTokenIndexDb
+	}
+	prefix := append(common.CopyBytes(tokenTransferPrefix), holder.Bytes()...)
+	if token != nil {
+		prefix = append(prefix, token.Bytes()...)
+	}
+	var transfers []*TokenTransfer
+	it := ldb.NewIteratorRange(ethdb.NewBytesPrefix(prefix))
+	for it.Next() {
+		t := new(TokenTransfer)
+		if err := rlp.DecodeBytes(it.Value(), t); err != nil {
+			it.Release()
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	it.Release()
+	return transfers, it.Error()
+}
+
+// GetTokenBalances returns the balances of holder in the tokens it has
+// transfers of, replaying the indexed transfers. Balances are only complete
+// if the chain was indexed from before the first transfer of a token.
+func GetTokenBalances(indexDb ethdb.Database, holder common.Address) ([]*TokenBalance, error) {
+	transfers, err := GetTokenTransfers(indexDb, holder, nil)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		balances []*TokenBalance
+		balance  *TokenBalance
+	)
+	for _, t := range transfers {
+		if balance == nil || balance.Token != t.Token {
+			balance = &TokenBalance{Token: t.Token, NFT: t.NFT, Balance: new(big.Int)}
+			balances = append(balances, balance)
+		}
+		if !t.NFT {
+			if t.From == holder {
+				balance.Balance.Sub(balance.Balance, t.Value)
+			}
+			if t.To == holder {
+				balance.Balance.Add(balance.Balance, t.Value)
+			}
+			continue
+		}
+		if t.From == holder {
+			for i, id := range balance.TokenIds {
+				if id.Cmp(t.Value) == 0 {
+					balance.TokenIds = append(balance.TokenIds[:i], balance.TokenIds[i+1:]...)
+					break
+				}
+			}
+		}
+		if t.To == holder {
+			balance.TokenIds = append(balance.TokenIds, t.Value)
+		}
+		balance.Balance.SetInt64(int64(len(balance.TokenIds)))
+	}
+	return balances, nil
+}
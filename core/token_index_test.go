@@ -0,0 +1,149 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func erc20Transfer(token, from, to common.Address, value int64, number uint64, index uint) *vm.Log {
+	return &vm.Log{
+		Address:     token,
+		Topics:      []common.Hash{transferTopic, from.Hash(), to.Hash()},
+		Data:        common.LeftPadBytes(big.NewInt(value).Bytes(), 32),
+		BlockNumber: number,
+		Index:       index,
+	}
+}
+
+func erc721Transfer(token, from, to common.Address, id int64, number uint64, index uint) *vm.Log {
+	return &vm.Log{
+		Address:     token,
+		Topics:      []common.Hash{transferTopic, from.Hash(), to.Hash(), common.BigToHash(big.NewInt(id))},
+		BlockNumber: number,
+		Index:       index,
+	}
+}
+
+func TestTokenIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := ethdb.NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		coin   = common.HexToAddress("0xc0")
+		nft    = common.HexToAddress("0xaf")
+		alice  = common.HexToAddress("0xa1")
+		bob    = common.HexToAddress("0xb0")
+		minter = common.Address{}
+	)
+	blocks := []types.Receipts{
+		{{Logs: vm.Logs{
+			erc20Transfer(coin, minter, alice, 1000, 1, 0),
+			erc721Transfer(nft, minter, alice, 7, 1, 1),
+			erc721Transfer(nft, minter, alice, 8, 1, 2),
+		}}},
+		{
+			{Logs: vm.Logs{erc20Transfer(coin, alice, bob, 300, 2, 0)}},
+			{Logs: vm.Logs{
+				erc721Transfer(nft, alice, bob, 7, 2, 1),
+				{Address: coin, Topics: []common.Hash{transferTopic}, BlockNumber: 2, Index: 2}, // malformed
+			}},
+		},
+	}
+	for _, receipts := range blocks {
+		if err := WriteBlockTokenTransfers(db, receipts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	balances, err := GetTokenBalances(db, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 2 {
+		t.Fatalf("got %d token balances, want 2", len(balances))
+	}
+	for _, b := range balances {
+		switch b.Token {
+		case coin:
+			if b.NFT || b.Balance.Cmp(big.NewInt(700)) != 0 {
+				t.Errorf("coin balance: have %v (nft %v), want 700", b.Balance, b.NFT)
+			}
+		case nft:
+			if !b.NFT || b.Balance.Cmp(common.Big1) != 0 || len(b.TokenIds) != 1 || b.TokenIds[0].Cmp(big.NewInt(8)) != 0 {
+				t.Errorf("nft balance: have %v %v (nft %v), want token 8", b.Balance, b.TokenIds, b.NFT)
+			}
+		default:
+			t.Errorf("unexpected token %x", b.Token)
+		}
+	}
+
+	transfers, err := GetTokenTransfers(db, bob, &coin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 1 || transfers[0].From != alice || transfers[0].Value.Cmp(big.NewInt(300)) != 0 {
+		t.Errorf("bob's coin transfers: have %+v", transfers)
+	}
+	if transfers, _ := GetTokenTransfers(db, alice, nil); len(transfers) != 5 {
+		t.Errorf("got %d transfers of alice, want 5", len(transfers))
+	}
+
+	// Reorganising the second block out restores the balances
+	if err := DeleteBlockTokenTransfers(db, blocks[1]); err != nil {
+		t.Fatal(err)
+	}
+	if transfers, _ := GetTokenTransfers(db, bob, nil); len(transfers) != 0 {
+		t.Errorf("got %d transfers of bob after deletion, want none", len(transfers))
+	}
+	balances, _ = GetTokenBalances(db, alice)
+	for _, b := range balances {
+		if b.Token == coin && b.Balance.Cmp(big.NewInt(1000)) != 0 {
+			t.Errorf("coin balance after deletion: have %v, want 1000", b.Balance)
+		}
+		if b.Token == nft && len(b.TokenIds) != 2 {
+			t.Errorf("nft tokens after deletion: have %v, want 7 and 8", b.TokenIds)
+		}
+	}
+
+	// As does rewinding the head below it
+	if err := WriteBlockTokenTransfers(db, blocks[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := deleteTokenTransfersAbove(db, 1); err != nil {
+		t.Fatal(err)
+	}
+	if transfers, _ := GetTokenTransfers(db, alice, nil); len(transfers) != 3 {
+		t.Errorf("got %d transfers of alice after rewinding, want 3", len(transfers))
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"io"
+	"os"
+
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+// journalEntry is a pooled transaction as stored in the pool journal.
+type journalEntry struct {
+	Tx    *types.Transaction
+	Local bool
+}
+
+// SaveJournal writes every pending and queued transaction to the file at
+// path, replacing any previous journal. It returns the number of
+// transactions written.
+func (pool *TxPool) SaveJournal(path string) (int, error) {
+	pool.mu.RLock()
+	entries := make([]journalEntry, 0, len(pool.pending))
+	for hash, tx := range pool.pending {
+		entries = append(entries, journalEntry{Tx: tx, Local: pool.localTx.contains(hash)})
+	}
+	for _, txs := range pool.queue {
+		for hash, tx := range txs {
+			entries = append(entries, journalEntry{Tx: tx, Local: pool.localTx.contains(hash)})
+		}
+	}
+	pool.mu.RUnlock()
+
+	// Write to a temporary file first so a crash can't truncate the journal.
+	tmp := path + ".new"
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, err
+	}
+	out := bufio.NewWriter(file)
+	for i := range entries {
+		if err = rlp.Encode(out, &entries[i]); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return len(entries), os.Rename(tmp, path)
+}
+
+// LoadJournal adds the transactions of the journal at path back to the pool,
+// skipping those which are no longer valid. A missing journal is not an
+// error. It returns the number of transactions restored.
+func (pool *TxPool) LoadJournal(path string) (int, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var (
+		stream   = rlp.NewStream(bufio.NewReader(file), 0)
+		restored int
+		dropped  int
+	)
+	for {
+		var entry journalEntry
+		if err := stream.Decode(&entry); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return restored, err
+		}
+		if entry.Local {
+			pool.SetLocal(entry.Tx)
+		}
+		if err := pool.Add(entry.Tx); err != nil {
+			glog.V(logger.Debug).Infof("Discarded journaled tx(%x): %v", entry.Tx.Hash(), err)
+			dropped++
+			continue
+		}
+		restored++
+	}
+	if dropped > 0 {
+		glog.V(logger.Info).Infof("Discarded %d stale journaled transactions", dropped)
+	}
+	return restored, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/event"
+)
+
+func TestTxPoolJournal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "txpool-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "txpool.rlp")
+
+	pool, key := setupTxPool()
+	stale := transaction(0, big.NewInt(100000), key)
+	queued := transaction(2, big.NewInt(100000), key)
+	from, _ := stale.From()
+	currentState, _ := pool.currentState()
+	currentState.AddBalance(from, big.NewInt(1000000000))
+
+	pool.SetLocal(queued)
+	for _, tx := range []*types.Transaction{stale, queued} {
+		if err := pool.Add(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := pool.SaveJournal(path); n != 2 || err != nil {
+		t.Fatalf("save: got %d, %v", n, err)
+	}
+
+	// Nonce 0 is mined meanwhile, so only the queued transaction is restored.
+	currentState.SetNonce(from, 1)
+	restored := NewTxPool(testChainConfig(), new(event.TypeMux), pool.currentState, pool.gasLimit)
+	restored.lockedReset()
+	if n, err := restored.LoadJournal(path); n != 1 || err != nil {
+		t.Fatalf("load: got %d, %v", n, err)
+	}
+	if pending, queue := restored.Stats(); pending != 0 || queue != 1 {
+		t.Errorf("stats: got %d pending, %d queued", pending, queue)
+	}
+	if !restored.localTx.contains(queued.Hash()) {
+		t.Error("local transaction not restored as local")
+	}
+
+	if n, err := restored.LoadJournal(filepath.Join(dir, "missing.rlp")); n != 0 || err != nil {
+		t.Errorf("missing journal: got %d, %v", n, err)
+	}
+}
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
@@ -43,6 +44,7 @@ var (
 	ErrIntrinsicGas       = errors.New("Intrinsic gas too low")
 	ErrGasLimit           = errors.New("Exceeds block gas limit")
 	ErrNegativeValue      = errors.New("Negative value")
+	ErrTxTypeNotSupported = errors.New("Transaction type not supported")
 )
 
 const (
@@ -61,10 +63,13 @@ type stateFn func() (*state.StateDB, error)
 type TxPool struct {
 	config       *ChainConfig
 	signer       types.Signer
-	currentState stateFn // The state function which will allow us to do some pre checks
+	chainId      *big.Int // EIP-155 chain id the signer accepts
+	currentState stateFn  // The state function which will allow us to do some pre checks
 	pendingState *state.ManagedState
 	gasLimit     func() *big.Int // The current gas limit function callback
-	minGasPrice  *big.Int
+	minGasPrice  *big.Int        // Minimum gas price of the miner, following GasPriceChanged events
+	priceLimit   *big.Int        // Minimum gas price of remote transactions set by the operator
+	maxNonceGap  uint64          // Maximum number of nonces a transaction may skip (unlimited if 0)
 	eventMux     *event.TypeMux
 	events       event.Subscription
 	localTx      *txSet
@@ -74,19 +79,24 @@ type TxPool struct {
 
 	wg sync.WaitGroup // for shutdown sync
 
-	homestead bool
+	homestead  bool
+	accessList bool // whether access list transactions are accepted
+	dynamicFee bool // whether dynamic fee transactions are accepted
 }
 
 func NewTxPool(config *ChainConfig, eventMux *event.TypeMux, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
+	chainId := config.GetChainID(nil)
 	pool := &TxPool{
 		config:       config,
-		signer:       types.NewChainIdSigner(config.GetChainID(nil)),
+		signer:       types.NewChainIdSigner(chainId),
+		chainId:      chainId,
 		pending:      make(map[common.Hash]*types.Transaction),
 		queue:        make(map[common.Address]map[common.Hash]*types.Transaction),
 		eventMux:     eventMux,
 		currentState: currentStateFn,
 		gasLimit:     gasLimitFn,
 		minGasPrice:  new(big.Int),
+		priceLimit:   new(big.Int),
 		pendingState: nil,
 		localTx:      newTxSet(),
 		events:       eventMux.Subscribe(ChainHeadEvent{}, GasPriceChanged{}, RemovedTransactionEvent{}),
@@ -113,7 +123,12 @@ func (pool *TxPool) eventLoop() {
 			}
 
 			if ev.Block != nil {
-				pool.signer = types.NewChainIdSigner(pool.config.GetChainID(ev.Block.Number()))
+				pool.chainId = pool.config.GetChainID(ev.Block.Number())
+				pool.signer = types.NewChainIdSigner(pool.chainId)
+				// Transactions are pooled for the next block
+				next := new(big.Int).Add(ev.Block.Number(), common.Big1)
+				pool.accessList = pool.config.IsAccessList(next)
+				pool.dynamicFee = pool.config.IsDynamicFee(next)
 			}
 
 			pool.resetState()
@@ -121,6 +136,7 @@ func (pool *TxPool) eventLoop() {
 		case GasPriceChanged:
 			pool.mu.Lock()
 			pool.minGasPrice = ev.Price
+			pool.dropUnderpriced()
 			pool.mu.Unlock()
 		case RemovedTransactionEvent:
 			pool.AddTransactions(ev.Txs)
@@ -224,6 +240,104 @@ func (pool *TxPool) Content() (map[common.Address]map[uint64][]*types.Transactio
 	return pending, queued
 }
 
+// SetPriceLimit sets the minimum gas price of transactions received from the
+// network or submitted without being marked local, dropping those in the pool
+// paying less. Transactions below it are neither accepted nor relayed.
+func (pool *TxPool) SetPriceLimit(price *big.Int) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.priceLimit = new(big.Int).Set(price)
+	pool.dropUnderpriced()
+}
+
+// PriceLimit returns the gas price limit set with SetPriceLimit.
+func (pool *TxPool) PriceLimit() *big.Int {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return new(big.Int).Set(pool.priceLimit)
+}
+
+// SetMaxNonceGap sets the maximum number of nonces a new transaction may be
+// ahead of the next nonce of its sender, 0 allowing any. Transactions already
+// in the pool are kept.
+func (pool *TxPool) SetMaxNonceGap(gap uint64) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.maxNonceGap = gap
+}
+
+// MaxNonceGap returns the nonce gap limit set with SetMaxNonceGap.
+func (pool *TxPool) MaxNonceGap() uint64 {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.maxNonceGap
+}
+
+// GasPriceFloor returns the minimum gas price of the remote transactions the
+// pool accepts, the higher of the price limit and the miner's gas price.
+func (pool *TxPool) GasPriceFloor() *big.Int {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return new(big.Int).Set(pool.gasPriceFloor())
+}
+
+func (pool *TxPool) gasPriceFloor() *big.Int {
+	if pool.priceLimit.Cmp(pool.minGasPrice) > 0 {
+		return pool.priceLimit
+	}
+	return pool.minGasPrice
+}
+
+// dropUnderpriced removes the remote transactions paying less than the gas
+// price floor. Later pending transactions of the same senders are moved back
+// to the queue, as they can't be executed anymore.
+func (pool *TxPool) dropUnderpriced() {
+	floor := pool.gasPriceFloor()
+	cheap := func(hash common.Hash, tx *types.Transaction) bool {
+		return tx.GasPrice().Cmp(floor) < 0 && !pool.localTx.contains(hash)
+	}
+	gaps := make(map[common.Address]uint64)
+	for hash, tx := range pool.pending {
+		if !cheap(hash, tx) {
+			continue
+		}
+		if glog.V(logger.Core) {
+			glog.Infof("removed tx (%v) from pool: gas price below %v\n", tx, floor)
+		}
+		delete(pool.pending, hash)
+		sender, _ := tx.From() // err already checked
+		if prev, ok := gaps[sender]; !ok || tx.Nonce() < prev {
+			gaps[sender] = tx.Nonce()
+		}
+	}
+	for address, txs := range pool.queue {
+		for hash, tx := range txs {
+			if cheap(hash, tx) {
+				delete(txs, hash)
+			}
+		}
+		if len(txs) == 0 {
+			delete(pool.queue, address)
+		}
+	}
+	if len(gaps) == 0 {
+		return
+	}
+	for hash, tx := range pool.pending {
+		sender, _ := tx.From()
+		if gap, ok := gaps[sender]; ok && tx.Nonce() >= gap {
+			pool.queueTx(hash, tx)
+			delete(pool.pending, hash)
+		}
+	}
+	pool.resetState()
+}
+
 // SetLocal marks a transaction as local, skipping gas price
 //  check against local miner minimum in the future
 func (pool *TxPool) SetLocal(tx *types.Transaction) {
@@ -249,11 +363,20 @@ func (pool *TxPool) validateTx(tx *types.Transaction) (e error) {
 		).Send(mlogTxPool)
 	}()
 	// Drop transactions under our own minimal accepted gas price
-	if !local && pool.minGasPrice.Cmp(tx.GasPrice()) > 0 {
+	if !local && pool.gasPriceFloor().Cmp(tx.GasPrice()) > 0 {
 		e = ErrCheap
 		return
 	}
 
+	if tx.Type() == types.AccessListTxType && !pool.accessList || tx.Type() == types.DynamicFeeTxType && !pool.dynamicFee {
+		e = ErrTxTypeNotSupported
+		return
+	}
+	if tx.GasTipCap().Cmp(tx.GasFeeCap()) > 0 {
+		e = ErrTipAboveFeeCap
+		return
+	}
+
 	currentState, err := pool.currentState()
 	if err != nil {
 		e = err
@@ -261,6 +384,10 @@ func (pool *TxPool) validateTx(tx *types.Transaction) (e error) {
 	}
 
 	from, err := types.Sender(pool.signer, tx)
+	if err == types.ErrInvalidChainId {
+		e = &ChainIdErr{Have: tx.ChainId(), Want: pool.chainId}
+		return
+	}
 	if err != nil {
 		e = ErrInvalidSender
 		return
@@ -278,6 +405,14 @@ func (pool *TxPool) validateTx(tx *types.Transaction) (e error) {
 		e = ErrNonce
 		return
 	}
+	// Refuse transactions too far ahead of the sender's next nonce, they would
+	// sit in the queue until all the nonces in between arrive.
+	if pool.maxNonceGap > 0 {
+		if next := pool.nextNonce(from, currentState); tx.Nonce() > next+pool.maxNonceGap {
+			e = &NonceGapErr{Nonce: tx.Nonce(), Next: next, Max: pool.maxNonceGap}
+			return
+		}
+	}
 
 	// Check the transaction doesn't exceed the current
 	// block limit gas.
@@ -301,7 +436,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction) (e error) {
 		return
 	}
 
-	intrGas := IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)
+	intrGas := IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, pool.homestead)
 	if tx.Gas().Cmp(intrGas) < 0 {
 		e = ErrIntrinsicGas
 		return
@@ -309,6 +444,15 @@ func (pool *TxPool) validateTx(tx *types.Transaction) (e error) {
 	return // e=nil
 }
 
+// nextNonce returns the nonce of the next transaction the sender can have
+// promoted, following the pending transactions if the pool tracks them.
+func (pool *TxPool) nextNonce(addr common.Address, currentState *state.StateDB) uint64 {
+	if pool.pendingState != nil {
+		return pool.pendingState.GetNonce(addr)
+	}
+	return currentState.GetNonce(addr)
+}
+
 // validate and queue transactions.
 func (self *TxPool) add(tx *types.Transaction) error {
 	hash := tx.Hash()
@@ -448,6 +592,31 @@ func (self *TxPool) GetTransactions() (txs types.Transactions) {
 	return txs
 }
 
+// Get returns a pending or queued transaction by hash, or nil if the pool
+// doesn't contain it.
+func (self *TxPool) Get(hash common.Hash) *types.Transaction {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	if tx, ok := self.pending[hash]; ok {
+		return tx
+	}
+	for _, txs := range self.queue {
+		if tx, ok := txs[hash]; ok {
+			return tx
+		}
+	}
+	return nil
+}
+
+// IsLocal reports whether the transaction was marked local with SetLocal.
+func (self *TxPool) IsLocal(hash common.Hash) bool {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	return self.localTx.contains(hash)
+}
+
 // GetQueuedTransactions returns all non-processable transactions.
 func (self *TxPool) GetQueuedTransactions() types.Transactions {
 	self.mu.RLock()
@@ -463,6 +632,50 @@ func (self *TxPool) GetQueuedTransactions() types.Transactions {
 	return ret
 }
 
+// QueueGap describes the queued transactions of a sender that can't be
+// promoted before a transaction with a missing nonce arrives.
+type QueueGap struct {
+	Sender  common.Address
+	Nonce   uint64             // Next nonce of the sender
+	Missing uint64             // First nonce no transaction is known for
+	Blocked types.Transactions // Queued transactions after the missing nonce, by nonce
+}
+
+// QueueGaps reports, per sender, the queued transactions blocked on a missing
+// nonce, ordered by sender. Senders whose queued transactions are all
+// promotable are left out.
+func (pool *TxPool) QueueGaps() ([]*QueueGap, error) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	currentState, err := pool.currentState()
+	if err != nil {
+		return nil, err
+	}
+	var gaps []*QueueGap
+	for addr, queued := range pool.queue {
+		txs := make(types.Transactions, 0, len(queued))
+		for _, tx := range queued {
+			txs = append(txs, tx)
+		}
+		sort.Sort(types.TxByNonce(txs))
+
+		next := pool.nextNonce(addr, currentState)
+		missing, i := next, 0
+		for ; i < len(txs) && txs[i].Nonce() <= missing; i++ {
+			if txs[i].Nonce() == missing {
+				missing++
+			}
+		}
+		if i == len(txs) {
+			continue
+		}
+		gaps = append(gaps, &QueueGap{Sender: addr, Nonce: next, Missing: missing, Blocked: txs[i:]})
+	}
+	sort.Slice(gaps, func(i, j int) bool { return bytes.Compare(gaps[i].Sender[:], gaps[j].Sender[:]) < 0 })
+	return gaps, nil
+}
+
 // RemoveTransactions removes all given transactions from the pool.
 func (self *TxPool) RemoveTransactions(txs types.Transactions) {
 	self.mu.Lock()
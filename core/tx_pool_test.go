@@ -333,6 +333,117 @@ func TestTransactionDropping(t *testing.T) {
 	}
 }
 
+// pricedTransaction creates a signed transaction paying the given gas price.
+func pricedTransaction(nonce uint64, gasPrice int64, key *ecdsa.PrivateKey) *types.Transaction {
+	tx, _ := types.NewTransaction(nonce, common.Address{}, big.NewInt(100), big.NewInt(100000), big.NewInt(gasPrice), nil).SignECDSA(key)
+	return tx
+}
+
+// Tests that the price limit rejects cheap remote transactions, drops those in
+// the pool, postponing the later ones of the same sender, and spares local ones.
+func TestTransactionPriceLimit(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := deriveSender(transaction(0, big.NewInt(0), key))
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+
+	var (
+		tx0  = pricedTransaction(0, 10, key)
+		tx1  = pricedTransaction(1, 1, key)
+		tx2  = pricedTransaction(2, 10, key)
+		tx10 = pricedTransaction(10, 1, key)
+		tx11 = pricedTransaction(11, 1, key)
+	)
+	pool.addTx(tx0.Hash(), account, tx0)
+	pool.addTx(tx1.Hash(), account, tx1)
+	pool.addTx(tx2.Hash(), account, tx2)
+	pool.queueTx(tx10.Hash(), tx10)
+	pool.queueTx(tx11.Hash(), tx11)
+	pool.SetLocal(tx11)
+
+	pool.SetPriceLimit(big.NewInt(5))
+	if _, ok := pool.pending[tx0.Hash()]; !ok {
+		t.Errorf("transaction above the limit dropped: %v", tx0)
+	}
+	if _, ok := pool.pending[tx1.Hash()]; ok {
+		t.Errorf("transaction below the limit still pending: %v", tx1)
+	}
+	if _, ok := pool.queue[account][tx2.Hash()]; !ok {
+		t.Errorf("transaction after a dropped one not postponed: %v", tx2)
+	}
+	if _, ok := pool.queue[account][tx10.Hash()]; ok {
+		t.Errorf("transaction below the limit still queued: %v", tx10)
+	}
+	if _, ok := pool.queue[account][tx11.Hash()]; !ok {
+		t.Errorf("local transaction below the limit dropped: %v", tx11)
+	}
+
+	if err := pool.Add(pricedTransaction(1, 4, key)); err != ErrCheap {
+		t.Errorf("transaction below the limit: have %v, want %v", err, ErrCheap)
+	}
+	if err := pool.Add(pricedTransaction(1, 5, key)); err != nil {
+		t.Errorf("transaction at the limit rejected: %v", err)
+	}
+
+	// The floor is the higher of the price limit and the miner's price.
+	pool.mu.Lock()
+	pool.minGasPrice = big.NewInt(8)
+	pool.mu.Unlock()
+	if floor := pool.GasPriceFloor(); floor.Cmp(big.NewInt(8)) != 0 {
+		t.Errorf("gas price floor mismatch: have %v, want 8", floor)
+	}
+	if limit := pool.PriceLimit(); limit.Cmp(big.NewInt(5)) != 0 {
+		t.Errorf("price limit mismatch: have %v, want 5", limit)
+	}
+}
+
+// Tests that the nonce gap limit rejects transactions too far ahead of the
+// sender's next nonce and that the blocked queued transactions are reported
+// with the first missing nonce.
+func TestTransactionNonceGap(t *testing.T) {
+	pool, key := setupTxPool()
+	account, _ := deriveSender(transaction(0, big.NewInt(0), key))
+
+	state, _ := pool.currentState()
+	state.AddBalance(account, big.NewInt(1000000000))
+	state.SetNonce(account, 2)
+	pool.lockedReset()
+
+	pool.SetMaxNonceGap(3)
+	if gap := pool.MaxNonceGap(); gap != 3 {
+		t.Fatalf("max nonce gap mismatch: have %d, want 3", gap)
+	}
+	err := pool.Add(transaction(6, big.NewInt(100000), key))
+	if gapErr, ok := err.(*NonceGapErr); !ok || gapErr.Next != 2 || gapErr.Max != 3 {
+		t.Fatalf("transaction beyond the gap: have %v, want nonce gap error", err)
+	}
+	for _, nonce := range []uint64{2, 4, 5} {
+		if err := pool.Add(transaction(nonce, big.NewInt(100000), key)); err != nil {
+			t.Fatalf("transaction %d within the gap rejected: %v", nonce, err)
+		}
+	}
+
+	gaps, err := pool.QueueGaps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gaps) != 1 {
+		t.Fatalf("blocked senders mismatch: have %d, want 1", len(gaps))
+	}
+	if gap := gaps[0]; gap.Sender != account || gap.Nonce != 3 || gap.Missing != 3 || len(gap.Blocked) != 2 || gap.Blocked[0].Nonce() != 4 {
+		t.Errorf("queue gap mismatch: have %+v", gap)
+	}
+
+	// Filling the gap unblocks the queue.
+	if err := pool.Add(transaction(3, big.NewInt(100000), key)); err != nil {
+		t.Fatal(err)
+	}
+	if gaps, _ := pool.QueueGaps(); len(gaps) != 0 {
+		t.Errorf("queue still blocked: %+v", gaps[0])
+	}
+}
+
 // Tests that if a transaction is dropped from the current pending pool (e.g. out
 // of fund), all consecutive (still valid, but not executable) transactions are
 // postponed back into the future queue to prevent broadcasting them.
@@ -539,3 +650,30 @@ func benchmarkCheckQueue(b *testing.B, size int) {
 		pool.checkQueue()
 	}
 }
+
+func TestAccessListTransactions(t *testing.T) {
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	currentState, _ := pool.currentState()
+	currentState.AddBalance(from, big.NewInt(1000000000))
+
+	al := types.AccessList{{Address: common.Address{}, StorageKeys: []common.Hash{{}}}}
+	tx := func(gas int64) *types.Transaction {
+		tx, _ := types.NewAccessListTransaction(pool.chainId, 0, &common.Address{}, big.NewInt(100), big.NewInt(gas), big.NewInt(1), nil, al).SignECDSA(key)
+		return tx
+	}
+	if err := pool.Add(tx(100000)); err != ErrTxTypeNotSupported {
+		t.Fatalf("before access lists: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	pool.accessList = true
+
+	// The access list is paid for with the intrinsic gas
+	if err := pool.Add(tx(21000 + 2400)); err != ErrIntrinsicGas {
+		t.Fatalf("gas below the access list cost: have %v, want %v", err, ErrIntrinsicGas)
+	}
+	if err := pool.Add(tx(21000 + 2400 + 1900)); err != nil {
+		t.Fatalf("access list transaction refused: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/crypto/sha3"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+// Transaction types. Typed transactions (EIP-2718) are encoded as their type
+// byte followed by the RLP encoding of their payload, legacy transactions as
+// plain RLP lists.
+const (
+	LegacyTxType     = 0x00
+	AccessListTxType = 0x01 // EIP-2930
+	DynamicFeeTxType = 0x02 // EIP-1559
+)
+
+var (
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+	errEmptyTypedTx       = errors.New("empty typed transaction bytes")
+)
+
+// AccessTuple is an address and the storage slots of it a transaction
+// accesses.
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+// AccessList is the list of addresses and storage slots an access list
+// transaction pays to access in advance, making them warm.
+type AccessList []AccessTuple
+
+// StorageKeys returns the total number of storage keys in the access list.
+func (al AccessList) StorageKeys() int {
+	sum := 0
+	for _, tuple := range al {
+		sum += len(tuple.StorageKeys)
+	}
+	return sum
+}
+
+// accessListTxdata is the payload of an access list transaction.
+type accessListTxdata struct {
+	ChainId         *big.Int
+	AccountNonce    uint64
+	Price, GasLimit *big.Int
+	Recipient       *common.Address `rlp:"nil"` // nil means contract creation
+	Amount          *big.Int
+	Payload         []byte
+	AccessList      AccessList
+	V, R, S         *big.Int // signature, V is the y parity
+}
+
+// NewAccessListTransaction creates an unsigned access list transaction for
+// chainId. A nil to creates a contract.
+func NewAccessListTransaction(chainId *big.Int, nonce uint64, to *common.Address, amount, gasLimit, gasPrice *big.Int, data []byte, accessList AccessList) *Transaction {
+	var tx *Transaction
+	if to == nil {
+		tx = NewContractCreation(nonce, amount, gasLimit, gasPrice, data)
+	} else {
+		tx = NewTransaction(nonce, *to, amount, gasLimit, gasPrice, data)
+	}
+	tx.typ = AccessListTxType
+	tx.chainId = new(big.Int).Set(chainId)
+	tx.accessList = copyAccessList(accessList)
+	tx.signer = NewChainIdSigner(chainId)
+	return tx
+}
+
+func copyAccessList(al AccessList) AccessList {
+	if al == nil {
+		return nil
+	}
+	cpy := make(AccessList, len(al))
+	for i, tuple := range al {
+		cpy[i] = AccessTuple{Address: tuple.Address, StorageKeys: append([]common.Hash(nil), tuple.StorageKeys...)}
+	}
+	return cpy
+}
+
+// Type returns the type of the transaction, LegacyTxType for untyped ones.
+func (tx *Transaction) Type() byte {
+	return tx.typ
+}
+
+// AccessList returns the access list of the transaction, nil for legacy
+// transactions.
+func (tx *Transaction) AccessList() AccessList {
+	return copyAccessList(tx.accessList)
+}
+
+// payload returns the typed payload of the transaction.
+func (tx *Transaction) payload() interface{} {
+	if tx.typ == DynamicFeeTxType {
+		return tx.dynamicFeePayload()
+	}
+	return &accessListTxdata{
+		ChainId:      tx.chainId,
+		AccountNonce: tx.data.AccountNonce,
+		Price:        tx.data.Price,
+		GasLimit:     tx.data.GasLimit,
+		Recipient:    tx.data.Recipient,
+		Amount:       tx.data.Amount,
+		Payload:      tx.data.Payload,
+		AccessList:   tx.accessList,
+		V:            tx.data.V,
+		R:            tx.data.R,
+		S:            tx.data.S,
+	}
+}
+
+// MarshalBinary returns the consensus encoding of the transaction: the RLP
+// list of legacy transactions, and the type byte followed by the RLP payload
+// of typed ones.
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	if tx.typ == LegacyTxType {
+		return rlp.EncodeToBytes(&tx.data)
+	}
+	enc, err := rlp.EncodeToBytes(tx.payload())
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tx.typ}, enc...), nil
+}
+
+// UnmarshalBinary decodes the consensus encoding of a transaction, as returned
+// by MarshalBinary.
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] > 0x7f {
+		return rlp.DecodeBytes(b, tx)
+	}
+	return tx.decodeTyped(b)
+}
+
+// decodeTyped decodes a typed transaction from its type byte and payload.
+func (tx *Transaction) decodeTyped(b []byte) error {
+	if len(b) == 0 {
+		return errEmptyTypedTx
+	}
+	switch b[0] {
+	case AccessListTxType:
+	case DynamicFeeTxType:
+		return tx.decodeDynamicFee(b)
+	default:
+		return ErrTxTypeNotSupported
+	}
+	var payload accessListTxdata
+	if err := rlp.DecodeBytes(b[1:], &payload); err != nil {
+		return err
+	}
+	tx.typ = b[0]
+	tx.chainId = payload.ChainId
+	tx.accessList = payload.AccessList
+	tx.gasTipCap = nil
+	tx.data = txdata{
+		AccountNonce: payload.AccountNonce,
+		Price:        payload.Price,
+		GasLimit:     payload.GasLimit,
+		Recipient:    payload.Recipient,
+		Amount:       payload.Amount,
+		Payload:      payload.Payload,
+		V:            payload.V,
+		R:            payload.R,
+		S:            payload.S,
+	}
+	tx.signer = NewChainIdSigner(tx.chainId)
+	tx.size.Store(common.StorageSize(len(b)))
+	return nil
+}
+
+// prefixedRlpHash hashes the RLP encoding of x prefixed with the type byte.
+func prefixedRlpHash(prefix byte, x interface{}) (h common.Hash) {
+	hw := sha3.NewKeccak256()
+	hw.Write([]byte{prefix})
+	rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
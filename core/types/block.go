@@ -71,6 +71,82 @@ type Header struct {
 	Time        *big.Int       // Creation time
 	Extra       []byte         // Freeform descriptor
 	Nonce       BlockNonce
+	BaseFee     *big.Int // Base fee per gas (EIP-1559), nil unless the fee market is active
+}
+
+// headerRLP is the RLP encoding of a header. The base fee is only encoded,
+// as an extra last field, for headers having one.
+type headerRLP struct {
+	ParentHash  common.Hash
+	UncleHash   common.Hash
+	Coinbase    common.Address
+	Root        common.Hash
+	TxHash      common.Hash
+	ReceiptHash common.Hash
+	Bloom       Bloom
+	Difficulty  *big.Int
+	Number      *big.Int
+	GasLimit    *big.Int
+	GasUsed     *big.Int
+	Time        *big.Int
+	Extra       []byte
+	Nonce       BlockNonce
+	BaseFee     []*big.Int `rlp:"tail"`
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (h *Header) EncodeRLP(w io.Writer) error {
+	enc := headerRLP{
+		ParentHash:  h.ParentHash,
+		UncleHash:   h.UncleHash,
+		Coinbase:    h.Coinbase,
+		Root:        h.Root,
+		TxHash:      h.TxHash,
+		ReceiptHash: h.ReceiptHash,
+		Bloom:       h.Bloom,
+		Difficulty:  h.Difficulty,
+		Number:      h.Number,
+		GasLimit:    h.GasLimit,
+		GasUsed:     h.GasUsed,
+		Time:        h.Time,
+		Extra:       h.Extra,
+		Nonce:       h.Nonce,
+	}
+	if h.BaseFee != nil {
+		enc.BaseFee = []*big.Int{h.BaseFee}
+	}
+	return rlp.Encode(w, &enc)
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (h *Header) DecodeRLP(s *rlp.Stream) error {
+	var dec headerRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	if len(dec.BaseFee) > 1 {
+		return fmt.Errorf("rlp: %d extra header fields", len(dec.BaseFee))
+	}
+	*h = Header{
+		ParentHash:  dec.ParentHash,
+		UncleHash:   dec.UncleHash,
+		Coinbase:    dec.Coinbase,
+		Root:        dec.Root,
+		TxHash:      dec.TxHash,
+		ReceiptHash: dec.ReceiptHash,
+		Bloom:       dec.Bloom,
+		Difficulty:  dec.Difficulty,
+		Number:      dec.Number,
+		GasLimit:    dec.GasLimit,
+		GasUsed:     dec.GasUsed,
+		Time:        dec.Time,
+		Extra:       dec.Extra,
+		Nonce:       dec.Nonce,
+	}
+	if len(dec.BaseFee) == 1 {
+		h.BaseFee = dec.BaseFee[0]
+	}
+	return nil
 }
 
 func (h *Header) Hash() common.Hash {
@@ -78,7 +154,7 @@ func (h *Header) Hash() common.Hash {
 }
 
 func (h *Header) HashNoNonce() common.Hash {
-	return rlpHash([]interface{}{
+	fields := []interface{}{
 		h.ParentHash,
 		h.UncleHash,
 		h.Coinbase,
@@ -92,7 +168,11 @@ func (h *Header) HashNoNonce() common.Hash {
 		h.GasUsed,
 		h.Time,
 		h.Extra,
-	})
+	}
+	if h.BaseFee != nil {
+		fields = append(fields, h.BaseFee)
+	}
+	return rlpHash(fields)
 }
 
 func (h *Header) UnmarshalJSON(data []byte) error {
@@ -261,6 +341,9 @@ func CopyHeader(h *Header) *Header {
 		cpy.Extra = make([]byte, len(h.Extra))
 		copy(cpy.Extra, h.Extra)
 	}
+	if h.BaseFee != nil {
+		cpy.BaseFee = new(big.Int).Set(h.BaseFee)
+	}
 	return &cpy
 }
 
@@ -329,6 +412,15 @@ func (b *Block) GasUsed() *big.Int    { return new(big.Int).Set(b.header.GasUsed
 func (b *Block) Difficulty() *big.Int { return new(big.Int).Set(b.header.Difficulty) }
 func (b *Block) Time() *big.Int       { return new(big.Int).Set(b.header.Time) }
 
+// BaseFee returns the base fee per gas of the block, nil unless the fee market
+// is active.
+func (b *Block) BaseFee() *big.Int {
+	if b.header.BaseFee == nil {
+		return nil
+	}
+	return new(big.Int).Set(b.header.BaseFee)
+}
+
 func (b *Block) NumberU64() uint64        { return b.header.Number.Uint64() }
 func (b *Block) Nonce() uint64            { return binary.BigEndian.Uint64(b.header.Nonce[:]) }
 func (b *Block) Bloom() Bloom             { return b.header.Bloom }
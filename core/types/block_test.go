@@ -64,3 +64,29 @@ func TestBlockEncoding(t *testing.T) {
 		t.Errorf("encoded block mismatch:\ngot:  %x\nwant: %x", ourBlockEnc, blockEnc)
 	}
 }
+
+func TestHeaderBaseFeeEncoding(t *testing.T) {
+	header := &Header{Difficulty: big.NewInt(1), Number: big.NewInt(2), GasLimit: big.NewInt(3), GasUsed: big.NewInt(4), Time: big.NewInt(5)}
+	legacy, _ := rlp.EncodeToBytes(header)
+
+	header.BaseFee = big.NewInt(1000000000)
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enc) <= len(legacy) {
+		t.Fatal("base fee not encoded")
+	}
+	for _, data := range [][]byte{legacy, enc} {
+		var dec Header
+		if err := rlp.DecodeBytes(data, &dec); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(HeaderToBytes(&dec), data) {
+			t.Errorf("re-encoded header mismatch: have %x, want %x", HeaderToBytes(&dec), data)
+		}
+	}
+	if header.HashNoNonce() == CopyHeader(&Header{Difficulty: header.Difficulty, Number: header.Number, GasLimit: header.GasLimit, GasUsed: header.GasUsed, Time: header.Time}).HashNoNonce() {
+		t.Error("base fee not sealed")
+	}
+}
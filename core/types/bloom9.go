@@ -19,6 +19,8 @@ package types
 import (
 	"fmt"
 	"math/big"
+	"runtime"
+	"sync"
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core/vm"
@@ -66,6 +68,40 @@ func CreateBloom(receipts Receipts) Bloom {
 	return BytesToBloom(bin.Bytes())
 }
 
+// DeriveBlooms sets the bloom of each receipt from its logs, spreading the work
+// across all CPUs, and returns the combined block bloom.
+func DeriveBlooms(receipts Receipts) Bloom {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(receipts) {
+		workers = len(receipts)
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(receipts); i += workers {
+				receipts[i].Bloom = BytesToBloom(LogsBloom(receipts[i].Logs).Bytes())
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	return MergeBlooms(receipts)
+}
+
+// MergeBlooms returns the union of the receipts' blooms. Unlike CreateBloom it
+// doesn't recompute them from the logs.
+func MergeBlooms(receipts Receipts) Bloom {
+	var bloom Bloom
+	for _, receipt := range receipts {
+		for i := range bloom {
+			bloom[i] |= receipt.Bloom[i]
+		}
+	}
+	return bloom
+}
+
 func LogsBloom(logs vm.Logs) *big.Int {
 	bin := new(big.Int)
 	for _, log := range logs {
@@ -19,6 +19,9 @@ package types
 import (
 	"math/big"
 	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/vm"
 )
 
 func TestBloom(t *testing.T) {
@@ -79,3 +82,26 @@ func TestAddress(t *testing.T) {
 	fmt.Printf("bin = %x\n", common.LeftPadBytes(bin, 64))
 }
 */
+
+func TestDeriveBlooms(t *testing.T) {
+	var receipts Receipts
+	for i := 0; i < 20; i++ {
+		receipt := NewReceipt(nil, big.NewInt(0))
+		for j := 0; j < i%4; j++ {
+			receipt.Logs = append(receipt.Logs, &vm.Log{
+				Address: common.BigToAddress(big.NewInt(int64(i))),
+				Topics:  []common.Hash{common.BigToHash(big.NewInt(int64(j)))},
+			})
+		}
+		receipts = append(receipts, receipt)
+	}
+	bloom := DeriveBlooms(receipts)
+	if want := CreateBloom(receipts); bloom != want {
+		t.Errorf("block bloom mismatch: have %x, want %x", bloom, want)
+	}
+	for i, receipt := range receipts {
+		if want := BytesToBloom(LogsBloom(receipt.Logs).Bytes()); receipt.Bloom != want {
+			t.Errorf("receipt %d bloom mismatch", i)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package types
 
 import (
 	"bytes"
+	"fmt"
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/rlp"
@@ -30,6 +31,30 @@ type DerivableList interface {
 }
 
 func DeriveSha(list DerivableList) common.Hash {
+	return deriveTrie(list).Hash()
+}
+
+// DeriveProof writes a merkle proof of the i'th list item to proofDb and
+// returns the root hash it verifies against, the same as DeriveSha. The item
+// is at the key DeriveKey(i) of the trie.
+func DeriveProof(list DerivableList, i int, proofDb trie.DatabaseWriter) (common.Hash, error) {
+	if i < 0 || i >= list.Len() {
+		return common.Hash{}, fmt.Errorf("index %d out of range [0, %d)", i, list.Len())
+	}
+	tr := deriveTrie(list)
+	if err := tr.Prove(DeriveKey(i), 0, proofDb); err != nil {
+		return common.Hash{}, err
+	}
+	return tr.Hash(), nil
+}
+
+// DeriveKey returns the trie key of the i'th list item.
+func DeriveKey(i int) []byte {
+	key, _ := rlp.EncodeToBytes(uint(i))
+	return key
+}
+
+func deriveTrie(list DerivableList) *trie.Trie {
 	keybuf := new(bytes.Buffer)
 	trie := new(trie.Trie)
 	for i := 0; i < list.Len(); i++ {
@@ -37,5 +62,5 @@ func DeriveSha(list DerivableList) common.Hash {
 		rlp.Encode(keybuf, uint(i))
 		trie.Update(keybuf.Bytes(), list.GetRlp(i))
 	}
-	return trie.Hash()
+	return trie
 }
@@ -0,0 +1,138 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+// dynamicFeeTxdata is the payload of a dynamic fee transaction.
+type dynamicFeeTxdata struct {
+	ChainId      *big.Int
+	AccountNonce uint64
+	GasTipCap    *big.Int
+	GasFeeCap    *big.Int
+	GasLimit     *big.Int
+	Recipient    *common.Address `rlp:"nil"` // nil means contract creation
+	Amount       *big.Int
+	Payload      []byte
+	AccessList   AccessList
+	V, R, S      *big.Int // signature, V is the y parity
+}
+
+// NewDynamicFeeTransaction creates an unsigned dynamic fee transaction for
+// chainId, paying at most gasFeeCap per gas of which at most gasTipCap goes to
+// the miner, the rest being the burnt base fee. A nil to creates a contract.
+//
+// The gas price of dynamic fee transactions, as returned by GasPrice, is their
+// fee cap.
+func NewDynamicFeeTransaction(chainId *big.Int, nonce uint64, to *common.Address, amount, gasLimit, gasTipCap, gasFeeCap *big.Int, data []byte, accessList AccessList) *Transaction {
+	tx := NewAccessListTransaction(chainId, nonce, to, amount, gasLimit, gasFeeCap, data, accessList)
+	tx.typ = DynamicFeeTxType
+	tx.gasTipCap = new(big.Int).Set(gasTipCap)
+	return tx
+}
+
+// GasTipCap returns the max priority fee per gas the transaction pays to the
+// miner, which is the gas price for transactions without a dynamic fee.
+func (tx *Transaction) GasTipCap() *big.Int {
+	if tx.typ == DynamicFeeTxType {
+		return new(big.Int).Set(tx.gasTipCap)
+	}
+	return new(big.Int).Set(tx.data.Price)
+}
+
+// GasFeeCap returns the max fee per gas the transaction pays, which is the gas
+// price for transactions without a dynamic fee.
+func (tx *Transaction) GasFeeCap() *big.Int {
+	return new(big.Int).Set(tx.data.Price)
+}
+
+// EffectiveGasTip returns the fee per gas the miner earns from the transaction
+// in a block with the given base fee, or nil if the fee cap doesn't cover the
+// base fee. A nil base fee leaves the whole gas price to the miner.
+func (tx *Transaction) EffectiveGasTip(baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	tip := new(big.Int).Sub(tx.data.Price, baseFee)
+	if tip.Sign() < 0 {
+		return nil
+	}
+	if tipCap := tx.GasTipCap(); tip.Cmp(tipCap) > 0 {
+		tip = tipCap
+	}
+	return tip
+}
+
+// EffectiveGasPrice returns the price per gas the transaction pays in a block
+// with the given base fee, or nil if the fee cap doesn't cover the base fee.
+func (tx *Transaction) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	tip := tx.EffectiveGasTip(baseFee)
+	if tip == nil || baseFee == nil {
+		return tip
+	}
+	return tip.Add(tip, baseFee)
+}
+
+// dynamicFeePayload returns the typed payload of a dynamic fee transaction.
+func (tx *Transaction) dynamicFeePayload() *dynamicFeeTxdata {
+	return &dynamicFeeTxdata{
+		ChainId:      tx.chainId,
+		AccountNonce: tx.data.AccountNonce,
+		GasTipCap:    tx.gasTipCap,
+		GasFeeCap:    tx.data.Price,
+		GasLimit:     tx.data.GasLimit,
+		Recipient:    tx.data.Recipient,
+		Amount:       tx.data.Amount,
+		Payload:      tx.data.Payload,
+		AccessList:   tx.accessList,
+		V:            tx.data.V,
+		R:            tx.data.R,
+		S:            tx.data.S,
+	}
+}
+
+// decodeDynamicFee decodes a dynamic fee transaction from its type byte and
+// payload.
+func (tx *Transaction) decodeDynamicFee(b []byte) error {
+	var payload dynamicFeeTxdata
+	if err := rlp.DecodeBytes(b[1:], &payload); err != nil {
+		return err
+	}
+	tx.typ = DynamicFeeTxType
+	tx.chainId = payload.ChainId
+	tx.accessList = payload.AccessList
+	tx.gasTipCap = payload.GasTipCap
+	tx.data = txdata{
+		AccountNonce: payload.AccountNonce,
+		Price:        payload.GasFeeCap,
+		GasLimit:     payload.GasLimit,
+		Recipient:    payload.Recipient,
+		Amount:       payload.Amount,
+		Payload:      payload.Payload,
+		V:            payload.V,
+		R:            payload.R,
+		S:            payload.S,
+	}
+	tx.signer = NewChainIdSigner(tx.chainId)
+	tx.size.Store(common.StorageSize(len(b)))
+	return nil
+}
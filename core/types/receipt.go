@@ -53,6 +53,7 @@ type Receipt struct {
 	ContractAddress common.Address
 	GasUsed         *big.Int
 	Status          ReceiptStatus
+	RevertData      []byte // Return data of a reverted transaction, stored apart from the receipt
 }
 
 // storedReceiptRLP is the storage encoding of a receipt.
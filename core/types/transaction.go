@@ -35,6 +35,12 @@ var ErrInvalidSig = errors.New("invalid v, r, s values")
 type Transaction struct {
 	signer Signer
 	data   txdata
+
+	typ        byte     // LegacyTxType, or the type of a typed transaction
+	chainId    *big.Int // chain id signed in the payload of typed transactions
+	accessList AccessList
+	gasTipCap  *big.Int // max priority fee per gas of dynamic fee transactions
+
 	// caches
 	hash atomic.Value
 	size atomic.Value
@@ -104,16 +110,31 @@ func (tx *Transaction) SetSigner(s Signer) {
 
 // ChainId returns which chain id this transaction was signed for (if at all)
 func (tx *Transaction) ChainId() *big.Int {
+	if tx.typ != LegacyTxType {
+		return new(big.Int).Set(tx.chainId)
+	}
 	return deriveChainId(tx.data.V)
 }
 
 // Protected returns whether the transaction is protected from replay protection
 func (tx *Transaction) Protected() bool {
+	if tx.typ != LegacyTxType {
+		return true
+	}
 	return isProtectedV(tx.data.V)
 }
 
+// EncodeRLP encodes legacy transactions as RLP lists, and typed ones as an
+// RLP string of their consensus encoding.
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.data)
+	if tx.typ == LegacyTxType {
+		return rlp.Encode(w, &tx.data)
+	}
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, enc)
 }
 
 // DeriveSigner makes a *best* guess about which signer to use.
@@ -126,8 +147,19 @@ func deriveSigner(V *big.Int) Signer {
 }
 
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.data)
+	kind, size, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind != rlp.List {
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		return tx.decodeTyped(b)
+	}
+	tx.typ, tx.chainId, tx.accessList, tx.gasTipCap = LegacyTxType, nil, nil, nil
+	err = s.Decode(&tx.data)
 	if err == nil {
 		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
 	}
@@ -154,13 +186,18 @@ func (tx *Transaction) To() *common.Address {
 	}
 }
 
-// Hash hashes the RLP encoding of tx.
+// Hash hashes the consensus encoding of tx.
 // It uniquely identifies the transaction.
 func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
-	v := rlpHash(tx)
+	var v common.Hash
+	if tx.typ == LegacyTxType {
+		v = rlpHash(tx)
+	} else {
+		v = prefixedRlpHash(tx.typ, tx.payload())
+	}
 	tx.hash.Store(v)
 	return v
 }
@@ -176,7 +213,12 @@ func (tx *Transaction) Size() common.StorageSize {
 		return size.(common.StorageSize)
 	}
 	c := writeCounter(0)
-	rlp.Encode(&c, &tx.data)
+	if tx.typ == LegacyTxType {
+		rlp.Encode(&c, &tx.data)
+	} else {
+		c.Write([]byte{tx.typ})
+		rlp.Encode(&c, tx.payload())
+	}
 	tx.size.Store(common.StorageSize(c))
 	return common.StorageSize(c)
 }
@@ -192,7 +234,7 @@ func (tx *Transaction) Cost() *big.Int {
 	return total
 }
 
-func (tx *Transaction) SignatureValues() (v byte, r *big.Int, s *big.Int) {
+func (tx *Transaction) SignatureValues() (v byte, r *big.Int, s *big.Int, err error) {
 	return SignatureValues(tx.signer, tx)
 }
 
@@ -226,9 +268,10 @@ func (tx *Transaction) String() string {
 	} else {
 		to = fmt.Sprintf("%x", tx.data.Recipient[:])
 	}
-	enc, _ := rlp.EncodeToBytes(&tx.data)
+	enc, _ := tx.MarshalBinary()
 	return fmt.Sprintf(`
 	TX(%x)
+	Type:     %d
 	Contract: %v
 	From:     %s
 	To:       %s
@@ -243,6 +286,7 @@ func (tx *Transaction) String() string {
 	Hex:      %x
 `,
 		tx.Hash(),
+		tx.typ,
 		len(tx.data.Recipient.Bytes()) == 0,
 		from,
 		to,
@@ -267,9 +311,10 @@ func (s Transactions) Len() int { return len(s) }
 // Swap swaps the i'th and the j'th element in s
 func (s Transactions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-// GetRlp implements Rlpable and returns the i'th element of s in rlp
+// GetRlp implements Rlpable and returns the consensus encoding of the i'th
+// element of s, which is the rlp of legacy transactions
 func (s Transactions) GetRlp(i int) []byte {
-	enc, _ := rlp.EncodeToBytes(s[i])
+	enc, _ := s[i].MarshalBinary()
 	return enc
 }
 
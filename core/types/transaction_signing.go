@@ -100,8 +100,27 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 }
 
 // SignatureValues returns the ECDSA signature values contained in the transaction.
-func SignatureValues(signer Signer, tx *Transaction) (v byte, r *big.Int, s *big.Int) {
-	return normaliseV(signer, tx.data.V), new(big.Int).Set(tx.data.R), new(big.Int).Set(tx.data.S)
+// The V of a typed transaction is its y parity, which must be 0 or 1.
+func SignatureValues(signer Signer, tx *Transaction) (v byte, r *big.Int, s *big.Int, err error) {
+	if tx.typ != LegacyTxType {
+		if tx.data.V.Sign() < 0 || tx.data.V.BitLen() > 1 {
+			return 0, nil, nil, ErrInvalidSig
+		}
+		v = byte(tx.data.V.Uint64()) + 27
+	} else {
+		v = normaliseV(signer, tx.data.V)
+	}
+	return v, new(big.Int).Set(tx.data.R), new(big.Int).Set(tx.data.S), nil
+}
+
+// copyWithSignature returns a copy of tx carrying the signature values v, r
+// and s of sig.
+func copyWithSignature(tx *Transaction, sig []byte, v *big.Int) *Transaction {
+	cpy := &Transaction{signer: tx.signer, data: tx.data, typ: tx.typ, chainId: tx.chainId, accessList: tx.accessList, gasTipCap: tx.gasTipCap}
+	cpy.data.R = new(big.Int).SetBytes(sig[:32])
+	cpy.data.S = new(big.Int).SetBytes(sig[32:64])
+	cpy.data.V = v
+	return cpy
 }
 
 type Signer interface {
@@ -163,7 +182,15 @@ func (s ChainIdSigner) PublicKey(tx *Transaction) ([]byte, error) {
 		return nil, ErrInvalidChainId
 	}
 
-	V := normaliseV(s, tx.data.V)
+	var V byte
+	if tx.typ != LegacyTxType {
+		if tx.data.V.Sign() < 0 || tx.data.V.BitLen() > 1 {
+			return nil, ErrInvalidSig
+		}
+		V = byte(tx.data.V.Uint64()) + 27
+	} else {
+		V = normaliseV(s, tx.data.V)
+	}
 	if !crypto.ValidateSignatureValues(V, tx.data.R, tx.data.S, true) {
 		return nil, ErrInvalidSig
 	}
@@ -194,20 +221,43 @@ func (s ChainIdSigner) WithSignature(tx *Transaction, sig []byte) (*Transaction,
 		panic(fmt.Sprintf("wrong size for snature: got %d, want 65", len(sig)))
 	}
 
-	cpy := &Transaction{signer: tx.signer, data: tx.data}
-	cpy.data.R = new(big.Int).SetBytes(sig[:32])
-	cpy.data.S = new(big.Int).SetBytes(sig[32:64])
-	cpy.data.V = new(big.Int).SetBytes([]byte{sig[64]})
-	if s.chainId.BitLen() > 0 {
-		cpy.data.V = big.NewInt(int64(sig[64] + 35))
-		cpy.data.V.Add(cpy.data.V, s.chainIdMul)
+	// Typed transactions sign the chain id in their payload, V is the y parity
+	V := new(big.Int).SetBytes([]byte{sig[64]})
+	if tx.typ == LegacyTxType && s.chainId.BitLen() > 0 {
+		V = big.NewInt(int64(sig[64] + 35))
+		V.Add(V, s.chainIdMul)
 	}
-	return cpy, nil
+	return copyWithSignature(tx, sig, V), nil
 }
 
 // Hash returns the hash to be signed by the sender.
 // It does not uniquely identify the transaction.
 func (s ChainIdSigner) Hash(tx *Transaction) common.Hash {
+	if tx.typ == DynamicFeeTxType {
+		return prefixedRlpHash(tx.typ, []interface{}{
+			s.chainId,
+			tx.data.AccountNonce,
+			tx.gasTipCap,
+			tx.data.Price,
+			tx.data.GasLimit,
+			tx.data.Recipient,
+			tx.data.Amount,
+			tx.data.Payload,
+			tx.accessList,
+		})
+	}
+	if tx.typ != LegacyTxType {
+		return prefixedRlpHash(tx.typ, []interface{}{
+			s.chainId,
+			tx.data.AccountNonce,
+			tx.data.Price,
+			tx.data.GasLimit,
+			tx.data.Recipient,
+			tx.data.Amount,
+			tx.data.Payload,
+			tx.accessList,
+		})
+	}
 	return rlpHash([]interface{}{
 		tx.data.AccountNonce,
 		tx.data.Price,
@@ -241,11 +291,10 @@ func (fs BasicSigner) WithSignature(tx *Transaction, sig []byte) (*Transaction,
 	if len(sig) != 65 {
 		panic(fmt.Sprintf("wrong size for snature: got %d, want 65", len(sig)))
 	}
-	cpy := &Transaction{signer: tx.signer, data: tx.data}
-	cpy.data.R = new(big.Int).SetBytes(sig[:32])
-	cpy.data.S = new(big.Int).SetBytes(sig[32:64])
-	cpy.data.V = new(big.Int).SetBytes([]byte{sig[64] + 27})
-	return cpy, nil
+	if tx.typ != LegacyTxType {
+		return nil, ErrTxTypeNotSupported
+	}
+	return copyWithSignature(tx, sig, new(big.Int).SetBytes([]byte{sig[64] + 27})), nil
 }
 
 func (fs BasicSigner) SignECDSA(tx *Transaction, prv *ecdsa.PrivateKey) (*Transaction, error) {
@@ -271,6 +320,9 @@ func (fs BasicSigner) Hash(tx *Transaction) common.Hash {
 }
 
 func (fs BasicSigner) PublicKey(tx *Transaction) ([]byte, error) {
+	if tx.typ != LegacyTxType {
+		return nil, ErrTxTypeNotSupported
+	}
 	if tx.data.V.BitLen() > 8 {
 		return nil, ErrInvalidSig
 	}
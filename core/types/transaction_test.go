@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"crypto/ecdsa"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/webchain-network/webchaind/common"
@@ -174,3 +175,145 @@ func TestTransactionPriceNonceSort(t *testing.T) {
 		}
 	}
 }
+
+func TestAccessListTransactionEncode(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	to := common.HexToAddress("b94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	al := AccessList{{Address: to, StorageKeys: []common.Hash{common.HexToHash("0x01")}}}
+	tx, err := NewAccessListTransaction(big.NewInt(61), 3, &to, big.NewInt(10), big.NewInt(30000), big.NewInt(1), common.FromHex("5544"), al).SignECDSA(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc[0] != AccessListTxType {
+		t.Fatalf("encoding starts with %#x, want the transaction type", enc[0])
+	}
+	if tx.Hash() != crypto.Keccak256Hash(enc) {
+		t.Errorf("hash mismatch: have %x, want %x", tx.Hash(), crypto.Keccak256Hash(enc))
+	}
+	if int(tx.Size()) != len(enc) {
+		t.Errorf("size mismatch: have %d, want %d", int(tx.Size()), len(enc))
+	}
+
+	// Typed transactions are RLP strings within lists of transactions
+	body, err := rlp.EncodeToBytes(Transactions{tx, rightvrsTx})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var txs Transactions
+	if err := rlp.DecodeBytes(body, &txs); err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(Transaction)
+	if err := decoded.UnmarshalBinary(enc); err != nil {
+		t.Fatal(err)
+	}
+	for _, dec := range []*Transaction{txs[0], decoded} {
+		if dec.Type() != AccessListTxType || dec.Hash() != tx.Hash() {
+			t.Errorf("decoded transaction mismatch: type %d, hash %x", dec.Type(), dec.Hash())
+		}
+		if dec.ChainId().Cmp(big.NewInt(61)) != 0 || !dec.Protected() {
+			t.Errorf("decoded chain id mismatch: have %v", dec.ChainId())
+		}
+		if len(dec.AccessList()) != 1 || dec.AccessList()[0].StorageKeys[0] != al[0].StorageKeys[0] {
+			t.Errorf("decoded access list mismatch: have %v", dec.AccessList())
+		}
+		from, err := dec.From()
+		if err != nil || from != crypto.PubkeyToAddress(key.PublicKey) {
+			t.Errorf("decoded sender mismatch: have %x, %v", from, err)
+		}
+	}
+	if txs[1].Type() != LegacyTxType || txs[1].Hash() != rightvrsTx.Hash() {
+		t.Errorf("legacy transaction mismatch after a typed one")
+	}
+
+	// Only signers of the transaction's chain recover the sender
+	if _, err := Sender(BasicSigner{}, tx); err != ErrTxTypeNotSupported {
+		t.Errorf("homestead signer: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+	if _, err := Sender(NewChainIdSigner(big.NewInt(62)), tx); err != ErrInvalidChainId {
+		t.Errorf("signer of another chain: have %v, want %v", err, ErrInvalidChainId)
+	}
+}
+
+func TestAccessListTransactionSignatureValues(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	to := common.HexToAddress("b94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	tx, err := NewAccessListTransaction(big.NewInt(61), 3, &to, big.NewInt(10), big.NewInt(30000), big.NewInt(1), nil, nil).SignECDSA(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, r, s, err := tx.SignatureValues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 27 && v != 28 {
+		t.Errorf("v mismatch: have %d, want 27 or 28", v)
+	}
+	_, rawR, rawS := tx.RawSignatureValues()
+	if r.Cmp(rawR) != 0 || s.Cmp(rawS) != 0 {
+		t.Errorf("r, s mismatch: have %v, %v, want %v, %v", r, s, rawR, rawS)
+	}
+
+	// A V beyond the y parity is rejected rather than truncated to a valid one
+	for _, bad := range []*big.Int{big.NewInt(2), big.NewInt(256), big.NewInt(-1)} {
+		sig := make([]byte, 65)
+		copy(sig[:32], common.LeftPadBytes(rawR.Bytes(), 32))
+		copy(sig[32:64], common.LeftPadBytes(rawS.Bytes(), 32))
+		cpy := copyWithSignature(tx, sig, bad)
+		if _, _, _, err := cpy.SignatureValues(); err != ErrInvalidSig {
+			t.Errorf("v %v: have %v, want %v", bad, err, ErrInvalidSig)
+		}
+		if _, err := Sender(NewChainIdSigner(big.NewInt(61)), cpy); err != ErrInvalidSig {
+			t.Errorf("v %v: sender error mismatch: have %v, want %v", bad, err, ErrInvalidSig)
+		}
+	}
+}
+
+func TestDynamicFeeTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	to := common.HexToAddress("b94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	tx, err := NewDynamicFeeTransaction(big.NewInt(61), 3, &to, big.NewInt(10), big.NewInt(30000), big.NewInt(2), big.NewInt(10), nil, nil).SignECDSA(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enc[0] != DynamicFeeTxType || tx.Hash() != crypto.Keccak256Hash(enc) {
+		t.Fatalf("encoding mismatch: %x", enc)
+	}
+	decoded := new(Transaction)
+	if err := decoded.UnmarshalBinary(enc); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Hash() != tx.Hash() || decoded.GasTipCap().Cmp(big.NewInt(2)) != 0 || decoded.GasFeeCap().Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("decoded transaction mismatch: hash %x, tip cap %v, fee cap %v", decoded.Hash(), decoded.GasTipCap(), decoded.GasFeeCap())
+	}
+	if from, err := decoded.From(); err != nil || from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("decoded sender mismatch: have %x, %v", from, err)
+	}
+
+	tests := []struct {
+		baseFee   *big.Int
+		tip, paid *big.Int
+	}{
+		{nil, big.NewInt(10), big.NewInt(10)},
+		{big.NewInt(5), big.NewInt(2), big.NewInt(7)},
+		{big.NewInt(9), big.NewInt(1), big.NewInt(10)},
+		{big.NewInt(11), nil, nil},
+	}
+	for i, test := range tests {
+		if tip := tx.EffectiveGasTip(test.baseFee); !reflect.DeepEqual(tip, test.tip) {
+			t.Errorf("test %d: tip mismatch: have %v, want %v", i, tip, test.tip)
+		}
+		if paid := tx.EffectiveGasPrice(test.baseFee); !reflect.DeepEqual(paid, test.paid) {
+			t.Errorf("test %d: price mismatch: have %v, want %v", i, paid, test.paid)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+// Gas costs of accessing accounts and storage slots (EIP-2929). Accessed ones
+// are warm for the rest of the transaction, others are cold.
+const (
+	ColdAccountAccessCost uint64 = 2600
+	ColdSloadCost         uint64 = 2100
+	WarmStorageReadCost   uint64 = 100
+)
+
+// AccessListScheduler is implemented by rule sets scheduling access list
+// transactions (EIP-2930), which come with the warm and cold access gas costs.
+type AccessListScheduler interface {
+	IsAccessList(num *big.Int) bool
+}
+
+// AccessListDatabase is implemented by databases keeping the addresses and
+// storage slots accessed in a transaction. Additions are reverted along with
+// the state changes.
+type AccessListDatabase interface {
+	ResetAccessList()
+	AddAddressToAccessList(addr common.Address)
+	AddSlotToAccessList(addr common.Address, slot common.Hash)
+	AddressInAccessList(addr common.Address) bool
+	SlotInAccessList(addr common.Address, slot common.Hash) (addressOk, slotOk bool)
+}
+
+// AccessListOf returns the access list of env's database, or nil if the rule
+// set doesn't schedule access lists at the block env runs on.
+func AccessListOf(env Environment) AccessListDatabase {
+	scheduler, ok := env.RuleSet().(AccessListScheduler)
+	if !ok || !scheduler.IsAccessList(env.BlockNumber()) {
+		return nil
+	}
+	al, _ := env.Db().(AccessListDatabase)
+	return al
+}
+
+// accountAccessGas returns the gas of accessing addr, which is warm from then
+// on.
+func accountAccessGas(al AccessListDatabase, addr common.Address) uint64 {
+	if al.AddressInAccessList(addr) {
+		return WarmStorageReadCost
+	}
+	al.AddAddressToAccessList(addr)
+	return ColdAccountAccessCost
+}
+
+// coldSlotGas returns the surcharge of accessing slot of addr if it's cold,
+// and warms it up.
+func coldSlotGas(al AccessListDatabase, addr common.Address, slot common.Hash) uint64 {
+	if _, slotOk := al.SlotInAccessList(addr, slot); slotOk {
+		return 0
+	}
+	al.AddSlotToAccessList(addr, slot)
+	return ColdSloadCost
+}
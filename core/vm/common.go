@@ -20,6 +20,7 @@ import (
 	"math/big"
 
 	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/params"
 )
 
 // Type is the VM type accepted by **NewVm**
@@ -49,30 +50,26 @@ func calcMemSize(off, l *big.Int) *big.Int {
 	return new(big.Int).Add(off, l)
 }
 
-// calculates the quadratic gas
-func quadMemGas(mem *Memory, newMemSize, gas *big.Int) {
-	if newMemSize.Sign() > 0 {
-		newMemSizeWords := toWordSize(newMemSize)
-		newMemSize.Mul(newMemSizeWords, u256(32))
-
-		if newMemSize.Cmp(u256(int64(mem.Len()))) > 0 {
-			// be careful reusing variables here when changing.
-			// The order has been optimised to reduce allocation
-			oldSize := toWordSize(big.NewInt(int64(mem.Len())))
-			pow := new(big.Int).Exp(oldSize, common.Big2, new(big.Int))
-			linCoef := oldSize.Mul(oldSize, big.NewInt(3))
-			quadCoef := new(big.Int).Div(pow, big.NewInt(512))
-			oldTotalFee := new(big.Int).Add(linCoef, quadCoef)
-
-			pow.Exp(newMemSizeWords, common.Big2, new(big.Int))
-			linCoef = linCoef.Mul(newMemSizeWords, big.NewInt(3))
-			quadCoef = quadCoef.Div(pow, big.NewInt(512))
-			newTotalFee := linCoef.Add(linCoef, quadCoef)
-
-			fee := newTotalFee.Sub(newTotalFee, oldTotalFee)
-			gas.Add(gas, fee)
-		}
+// quadMemGas adds the quadratic gas of expanding mem to newMemSize bytes to
+// gas, rounding newMemSize up to the word.
+func quadMemGas(mem *Memory, newMemSize *big.Int, gas uint64) (uint64, error) {
+	if newMemSize.Sign() == 0 {
+		return gas, nil
+	}
+	// The gas of larger memories overflows 64 bits, more than can ever be paid.
+	if !newMemSize.IsUint64() || newMemSize.Uint64() > 0x1FFFFFFFE0 {
+		return 0, OutOfGasError
 	}
+	newWords := toWordSize(newMemSize.Uint64())
+	newMemSize.SetUint64(newWords * 32)
+
+	if size := uint64(mem.Len()); newWords*32 > size {
+		oldWords := toWordSize(size)
+		oldTotalFee := oldWords*params.MemoryGas + oldWords*oldWords/params.QuadCoeffDiv
+		newTotalFee := newWords*params.MemoryGas + newWords*newWords/params.QuadCoeffDiv
+		return safeAdd(gas, newTotalFee-oldTotalFee)
+	}
+	return gas, nil
 }
 
 // Simple helper
@@ -90,18 +87,6 @@ func getData(data []byte, start, size *big.Int) []byte {
 	return common.RightPadBytes(data[s.Uint64():e.Uint64()], int(size.Uint64()))
 }
 
-// useGas attempts to subtract the amount of gas and returns whether it was
-// successful
-func useGas(gas, amount *big.Int) bool {
-	if gas.Cmp(amount) < 0 {
-		return false
-	}
-
-	// Sub the amount of gas from the remaining
-	gas.Sub(gas, amount)
-	return true
-}
-
 func allZero(b []byte) bool {
 	for _, byte := range b {
 		if byte != 0 {
@@ -0,0 +1,34 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import "io"
+
+// Config holds debug switches of the EVM for tools running code outside of
+// consensus, like the evm command and fuzzers. Blocks executed with any of
+// them set are not valid.
+type Config struct {
+	// DisableGasMetering runs instructions without charging their gas, gas
+	// handed to calls and creations is still deducted.
+	DisableGasMetering bool
+	// NoRecursion makes calls and creations from contract code succeed
+	// without running any code.
+	NoRecursion bool
+	// Trace, if set, receives every step executed as a line of JSON. A tracer
+	// set on the EVM replaces it.
+	Trace io.Writer
+}
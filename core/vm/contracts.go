@@ -17,11 +17,13 @@
 package vm
 
 import (
+	"encoding/binary"
 	"errors"
 	"math/big"
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/crypto/blake2b"
 	"github.com/webchain-network/webchaind/crypto/bn256"
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
@@ -55,6 +57,7 @@ const (
 	Bn256ScalarMulGas       uint64 = 40000  // Gas needed for an elliptic curve scalar multiplication
 	Bn256PairingBaseGas     uint64 = 100000 // Base price for an elliptic curve pairing check
 	Bn256PairingPerPointGas uint64 = 80000  // Per-point price for an elliptic curve pairing check
+	Blake2FRoundGas         uint64 = 1      // Per-round price for a BLAKE2b F compression
 )
 
 // PrecompiledAccount represents a native ethereum contract
@@ -199,6 +202,16 @@ func PrecompiledContractsAtlantis() map[string]*PrecompiledAccount {
 			p.Mul(p, big.NewInt(80000))
 			return n.Add(n, p)
 		}, bn256Pairing},
+
+		// blake2F
+		string(common.LeftPadBytes([]byte{9}, 20)): {func(in []byte) *big.Int {
+			// Malformed input is rejected by the call itself
+			if len(in) != blake2FInputLength {
+				return big.NewInt(0)
+			}
+			rounds := new(big.Int).SetUint64(uint64(binary.BigEndian.Uint32(in[0:4])))
+			return rounds.Mul(rounds, new(big.Int).SetUint64(Blake2FRoundGas))
+		}, blake2F},
 	}
 }
 
@@ -359,3 +372,53 @@ func bn256Pairing(in []byte) ([]byte, error) {
 	}
 	return false32Byte, nil
 }
+
+const (
+	blake2FInputLength        = 213
+	blake2FFinalBlockBytes    = byte(1)
+	blake2FNonFinalBlockBytes = byte(0)
+)
+
+var (
+	errBlake2FInvalidInputLength = errors.New("invalid input length")
+	errBlake2FInvalidFinalFlag   = errors.New("invalid final flag")
+)
+
+// blake2F runs the BLAKE2b F compression function of EIP-152. The input is the
+// number of rounds (4 bytes), the state vector h (64 bytes), the message block
+// m (128 bytes), the offset counters t (16 bytes) and the final block flag, all
+// integers but the rounds being little endian.
+func blake2F(in []byte) ([]byte, error) {
+	if len(in) != blake2FInputLength {
+		return nil, errBlake2FInvalidInputLength
+	}
+	if in[212] != blake2FNonFinalBlockBytes && in[212] != blake2FFinalBlockBytes {
+		return nil, errBlake2FInvalidFinalFlag
+	}
+	var (
+		rounds = binary.BigEndian.Uint32(in[0:4])
+		final  = in[212] == blake2FFinalBlockBytes
+
+		h [8]uint64
+		m [16]uint64
+		t [2]uint64
+	)
+	for i := 0; i < 8; i++ {
+		offset := 4 + i*8
+		h[i] = binary.LittleEndian.Uint64(in[offset : offset+8])
+	}
+	for i := 0; i < 16; i++ {
+		offset := 68 + i*8
+		m[i] = binary.LittleEndian.Uint64(in[offset : offset+8])
+	}
+	t[0] = binary.LittleEndian.Uint64(in[196:204])
+	t[1] = binary.LittleEndian.Uint64(in[204:212])
+
+	blake2b.F(&h, m, t, final, rounds)
+
+	out := make([]byte, 64)
+	for i := 0; i < 8; i++ {
+		binary.LittleEndian.PutUint64(out[i*8:i*8+8], h[i])
+	}
+	return out, nil
+}
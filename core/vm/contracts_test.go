@@ -0,0 +1,154 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+// Test vectors from EIP-152.
+var blake2FTests = []struct {
+	input, want string
+	gas         uint64
+	err         error
+}{
+	{
+		input: "",
+		err:   errBlake2FInvalidInputLength,
+	},
+	{
+		input: "00000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		err:   errBlake2FInvalidInputLength,
+	},
+	{
+		input: "000000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		err:   errBlake2FInvalidInputLength,
+	},
+	{
+		input: "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000002",
+		gas:   12,
+		err:   errBlake2FInvalidFinalFlag,
+	},
+	{
+		input: "0000000048c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		want:  "08c9bcf367e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d282e6ad7f520e511f6c3e2b8c68059b9442be0454267ce079217e1319cde05b",
+		gas:   0,
+	},
+	{
+		input: "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		want:  "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		gas:   12,
+	},
+	{
+		input: "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000000",
+		want:  "75ab69d3190a562c51aef8d88f1c2775876944407270c42c9844252c26d2875298743e7f6d5ea2f2d3e8d226039cd31b4e426ac4f2d3d666a610c2116fde4735",
+		gas:   12,
+	},
+	{
+		input: "0000000148c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		want:  "b63a380cb2897d521994a85234ee2c181b5f844d2c624c002677e9703449d2fba551b3a8333bcdf5f2f7e08993d53923de3d64fcc68c034e717b9293fed7a421",
+		gas:   1,
+	},
+}
+
+func TestBlake2F(t *testing.T) {
+	p := PrecompiledAtlantis[string(common.LeftPadBytes([]byte{9}, 20))]
+	if p == nil {
+		t.Fatal("BLAKE2F missing from the Atlantis precompiles")
+	}
+	if PrecompiledPreAtlantis[string(common.LeftPadBytes([]byte{9}, 20))] != nil {
+		t.Error("BLAKE2F enabled before Atlantis")
+	}
+	for i, tt := range blake2FTests {
+		in := common.Hex2Bytes(tt.input)
+		if gas := p.Gas(in); gas.Uint64() != tt.gas {
+			t.Errorf("test %d: gas mismatch: have %v, want %d", i, gas, tt.gas)
+		}
+		out, err := p.Call(in)
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+			continue
+		}
+		if want := common.Hex2Bytes(tt.want); !bytes.Equal(out, want) {
+			t.Errorf("test %d: output mismatch: have %x, want %x", i, out, want)
+		}
+	}
+}
+
+// precompileRuleSet adds the given precompiled contracts from block 5.
+type precompileRuleSet struct {
+	ruleSet
+	added map[common.Address]*PrecompiledAccount
+}
+
+func (r precompileRuleSet) Precompiles(n *big.Int) map[common.Address]*PrecompiledAccount {
+	if n.Int64() < 5 {
+		return nil
+	}
+	return r.added
+}
+
+func TestActivePrecompiles(t *testing.T) {
+	if err := RegisterBuiltin("reverse", func(in []byte) ([]byte, error) {
+		out := make([]byte, len(in))
+		for i, b := range in {
+			out[len(in)-1-i] = b
+		}
+		return out, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterBuiltin("sha256", sha256Func); err == nil {
+		t.Error("builtin replaced")
+	}
+	if _, err := NewPrecompiledAccount("unknown", 0, 0); err == nil {
+		t.Error("no error for unknown builtin")
+	}
+	reverse, err := NewPrecompiledAccount("reverse", 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		custom = common.HexToAddress("0x100")
+		sha256 = common.BytesToAddress([]byte{2})
+		rules  = precompileRuleSet{
+			ruleSet: ruleSet{big.NewInt(10), big.NewInt(10)},
+			added:   map[common.Address]*PrecompiledAccount{custom: reverse, sha256: reverse},
+		}
+	)
+	if p := ActivePrecompiles(rules, big.NewInt(4)); p[custom.Str()] != nil || p[sha256.Str()] == nil {
+		t.Error("precompiles added before their block")
+	}
+	p := ActivePrecompiles(rules, big.NewInt(5))
+	if p[custom.Str()] != reverse || p[sha256.Str()] != reverse {
+		t.Fatal("precompiles not added")
+	}
+	// Added contracts don't alter the fork's set.
+	if PrecompiledPreAtlantis[custom.Str()] != nil || PrecompiledPreAtlantis[sha256.Str()] == reverse {
+		t.Error("fork precompiles modified")
+	}
+	if gas := reverse.Gas(make([]byte, 33)); gas.Int64() != 120 {
+		t.Errorf("gas mismatch: have %v, want 120", gas)
+	}
+	if out, _ := reverse.Call([]byte{1, 2, 3}); !bytes.Equal(out, []byte{3, 2, 1}) {
+		t.Errorf("output mismatch: have %x", out)
+	}
+}
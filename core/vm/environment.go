@@ -74,16 +74,19 @@ type Environment interface {
 	ReturnData() []byte
 	// Set previous return data
 	SetReturnData([]byte)
-	// Call another contract
-	Call(me ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error)
+	// Call another contract. The calls and creations return the gas they
+	// leave unused.
+	Call(me ContractRef, addr common.Address, data []byte, gas uint64, price, value *big.Int) ([]byte, uint64, error)
 	// Take another's contract code and execute within our own context
-	CallCode(me ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error)
+	CallCode(me ContractRef, addr common.Address, data []byte, gas uint64, price, value *big.Int) ([]byte, uint64, error)
 	// Same as CallCode except sender and value is propagated from parent to child scope
-	DelegateCall(me ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error)
+	DelegateCall(me ContractRef, addr common.Address, data []byte, gas uint64, price *big.Int) ([]byte, uint64, error)
 	// Call another contract and disallow any state changing operations
-	StaticCall(me ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error)
+	StaticCall(me ContractRef, addr common.Address, data []byte, gas uint64, price *big.Int) ([]byte, uint64, error)
 	// Create a new contract
-	Create(me ContractRef, data []byte, gas, price, value *big.Int) ([]byte, common.Address, error)
+	Create(me ContractRef, data []byte, gas uint64, price, value *big.Int) ([]byte, common.Address, uint64, error)
+	// Create a new contract at an address derived from the salt and code
+	Create2(me ContractRef, data []byte, gas uint64, price, value, salt *big.Int) ([]byte, common.Address, uint64, error)
 }
 
 // Vm is the basic interface for an implementation of the EVM.
@@ -133,7 +136,6 @@ type Account interface {
 	SetNonce(uint64)
 	Balance() *big.Int
 	Address() common.Address
-	ReturnGas(*big.Int, *big.Int)
 	SetCode(common.Hash, []byte)
 	ForEachStorage(cb func(key, value common.Hash) bool) error
 	Value() *big.Int
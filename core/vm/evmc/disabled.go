@@ -0,0 +1,55 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !evmc
+// +build !evmc
+
+package evmc
+
+import (
+	"errors"
+
+	"github.com/webchain-network/webchaind/core/vm"
+)
+
+// ErrNotSupported is returned by Load in builds without EVMC support.
+var ErrNotSupported = errors.New("EVMC support not compiled in (build with -tags evmc)")
+
+// VM is an EVMC virtual machine loaded from a shared library.
+type VM struct{}
+
+// Load loads the EVMC virtual machine of the shared library at path.
+func Load(path string) (*VM, error) {
+	return nil, ErrNotSupported
+}
+
+// LoadEWASM loads the EVMC virtual machine of the shared library at path, which
+// must execute ewasm contracts.
+func LoadEWASM(path string) (*VM, error) {
+	return nil, ErrNotSupported
+}
+
+// Name returns the name of the VM implementation.
+func (v *VM) Name() string { return "" }
+
+// Version returns the version of the VM implementation.
+func (v *VM) Version() string { return "" }
+
+// SetOption sets an implementation specific option of the VM.
+func (v *VM) SetOption(name, value string) error { return ErrNotSupported }
+
+// NewVm returns the vm.Vm running the contracts of env in the external VM.
+func (v *VM) NewVm(env vm.Environment, evm *vm.EVM) vm.Vm { return evm }
@@ -0,0 +1,104 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+// Package evmc runs contract code in an external EVM implementation loaded
+// from a shared library through the EVMC interface (ABI version 7), such as
+// evmone, in place of the built-in interpreter:
+//
+//	v, err := evmc.Load("/usr/lib/libevmone.so")
+//	...
+//	core.SetExternalVM(v.NewVm)
+//
+// The external VM executes the bytecode and calls back into the node for the
+// state, nested calls and contract creations. Precompiled contracts, traced
+// executions and executions with a deny list still use the built-in
+// interpreter.
+//
+// Ewasm VMs, such as Hera, are loaded with LoadEWASM to run the WebAssembly
+// contracts alongside the EVM ones:
+//
+//	w, err := evmc.LoadEWASM("/usr/lib/libhera.so")
+//	...
+//	core.SetWasmVM(w.NewVm)
+//
+// EVMC support requires cgo and is only compiled in with the evmc build tag,
+// Load fails otherwise.
+package evmc
+
+import (
+	"math/big"
+	"path/filepath"
+	"strings"
+
+	"github.com/webchain-network/webchaind/core/vm"
+)
+
+// The EVMC revisions, the sets of Ethereum rules external VMs implement.
+const (
+	Frontier = iota
+	Homestead
+	TangerineWhistle
+	SpuriousDragon
+	Byzantium
+	Constantinople
+	Petersburg
+)
+
+// petersburgOpcodes are the instructions introduced by Constantinople.
+var petersburgOpcodes = []vm.OpCode{vm.SHL, vm.SHR, vm.SAR, vm.CREATE2, vm.EXTCODEHASH}
+
+// Revision returns the latest EVMC revision whose rules are all active at
+// block num. Webchain's forks don't follow Ethereum's, the external VM may
+// thus lack instructions enabled individually on the chain, e.g. SELFBALANCE
+// without the rest of Istanbul.
+func Revision(rules vm.RuleSet, num *big.Int) int {
+	if !rules.IsHomestead(num) {
+		return Frontier
+	}
+	if rules.GasTable(num).CreateBySuicide == 0 {
+		return Homestead
+	}
+	if clearing, ok := rules.(interface {
+		IsStateClear(*big.Int) bool
+	}); !ok || !clearing.IsStateClear(num) {
+		return TangerineWhistle
+	}
+	if !rules.IsHardfork2(num) {
+		return SpuriousDragon
+	}
+	scheduler, ok := rules.(vm.OpcodeScheduler)
+	if !ok {
+		return Byzantium
+	}
+	for _, op := range petersburgOpcodes {
+		if enabled, _ := scheduler.OpcodeEnabled(op, num); !enabled {
+			return Byzantium
+		}
+	}
+	return Petersburg
+}
+
+// createFunctions returns the names of the function creating the VM a library
+// may export, e.g. evmc_create_evmone for libevmone.so, or the generic
+// evmc_create.
+func createFunctions(path string) []string {
+	name := filepath.Base(path)
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.Replace(strings.TrimPrefix(name, "lib"), "-", "_", -1)
+	return []string{"evmc_create_" + name, "evmc_create"}
+}
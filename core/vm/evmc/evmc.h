@@ -0,0 +1,128 @@
+/* The subset of the EVMC ABI version 7 used by the adapter, declared as in
+ * evmc/evmc.h of https://github.com/ethereum/evmc (Apache License 2.0). */
+
+#ifndef WEBCHAIN_EVMC_H
+#define WEBCHAIN_EVMC_H
+
+#include <stdbool.h>
+#include <stddef.h>
+#include <stdint.h>
+
+enum { EVMC_ABI_VERSION = 7 };
+
+typedef struct evmc_bytes32 { uint8_t bytes[32]; } evmc_bytes32;
+typedef struct evmc_bytes32 evmc_uint256be;
+typedef struct evmc_address { uint8_t bytes[20]; } evmc_address;
+
+enum evmc_call_kind {
+	EVMC_CALL = 0,
+	EVMC_DELEGATECALL = 1,
+	EVMC_CALLCODE = 2,
+	EVMC_CREATE = 3,
+	EVMC_CREATE2 = 4
+};
+
+enum evmc_flags { EVMC_STATIC = 1 };
+
+struct evmc_message {
+	enum evmc_call_kind kind;
+	uint32_t flags;
+	int32_t depth;
+	int64_t gas;
+	evmc_address destination;
+	evmc_address sender;
+	const uint8_t* input_data;
+	size_t input_size;
+	evmc_uint256be value;
+	evmc_bytes32 create2_salt;
+};
+
+struct evmc_tx_context {
+	evmc_uint256be tx_gas_price;
+	evmc_address tx_origin;
+	evmc_address block_coinbase;
+	int64_t block_number;
+	int64_t block_timestamp;
+	int64_t block_gas_limit;
+	evmc_uint256be block_difficulty;
+	evmc_uint256be chain_id;
+};
+
+struct evmc_host_context;
+
+enum evmc_status_code {
+	EVMC_SUCCESS = 0,
+	EVMC_FAILURE = 1,
+	EVMC_REVERT = 2,
+	EVMC_OUT_OF_GAS = 3,
+	EVMC_INTERNAL_ERROR = -1,
+	EVMC_REJECTED = -2,
+	EVMC_OUT_OF_MEMORY = -3
+};
+
+struct evmc_result;
+typedef void (*evmc_release_result_fn)(const struct evmc_result* result);
+
+struct evmc_result {
+	enum evmc_status_code status_code;
+	int64_t gas_left;
+	const uint8_t* output_data;
+	size_t output_size;
+	evmc_release_result_fn release;
+	evmc_address create_address;
+	uint8_t padding[4];
+};
+
+enum evmc_storage_status {
+	EVMC_STORAGE_UNCHANGED = 0,
+	EVMC_STORAGE_MODIFIED = 1,
+	EVMC_STORAGE_MODIFIED_AGAIN = 2,
+	EVMC_STORAGE_ADDED = 3,
+	EVMC_STORAGE_DELETED = 4
+};
+
+struct evmc_host_interface {
+	bool (*account_exists)(struct evmc_host_context* context, const evmc_address* address);
+	evmc_bytes32 (*get_storage)(struct evmc_host_context* context, const evmc_address* address, const evmc_bytes32* key);
+	enum evmc_storage_status (*set_storage)(struct evmc_host_context* context, const evmc_address* address, const evmc_bytes32* key, const evmc_bytes32* value);
+	evmc_uint256be (*get_balance)(struct evmc_host_context* context, const evmc_address* address);
+	size_t (*get_code_size)(struct evmc_host_context* context, const evmc_address* address);
+	evmc_bytes32 (*get_code_hash)(struct evmc_host_context* context, const evmc_address* address);
+	size_t (*copy_code)(struct evmc_host_context* context, const evmc_address* address, size_t code_offset, uint8_t* buffer_data, size_t buffer_size);
+	void (*selfdestruct)(struct evmc_host_context* context, const evmc_address* address, const evmc_address* beneficiary);
+	struct evmc_result (*call)(struct evmc_host_context* context, const struct evmc_message* msg);
+	struct evmc_tx_context (*get_tx_context)(struct evmc_host_context* context);
+	evmc_bytes32 (*get_block_hash)(struct evmc_host_context* context, int64_t number);
+	void (*emit_log)(struct evmc_host_context* context, const evmc_address* address, const uint8_t* data, size_t data_size, const evmc_bytes32 topics[], size_t topics_count);
+};
+
+enum evmc_set_option_result {
+	EVMC_SET_OPTION_SUCCESS = 0,
+	EVMC_SET_OPTION_INVALID_NAME = 1,
+	EVMC_SET_OPTION_INVALID_VALUE = 2
+};
+
+enum evmc_revision {
+	EVMC_FRONTIER = 0,
+	EVMC_HOMESTEAD = 1,
+	EVMC_TANGERINE_WHISTLE = 2,
+	EVMC_SPURIOUS_DRAGON = 3,
+	EVMC_BYZANTIUM = 4,
+	EVMC_CONSTANTINOPLE = 5,
+	EVMC_PETERSBURG = 6,
+	EVMC_ISTANBUL = 7
+};
+
+enum { EVMC_CAPABILITY_EVM1 = 1u << 0, EVMC_CAPABILITY_EWASM = 1u << 1 };
+
+struct evmc_vm {
+	const int abi_version;
+	const char* name;
+	const char* version;
+	void (*destroy)(struct evmc_vm* vm);
+	struct evmc_result (*execute)(struct evmc_vm* vm, const struct evmc_host_interface* host, struct evmc_host_context* context, enum evmc_revision rev, const struct evmc_message* msg, const uint8_t* code, size_t code_size);
+	uint32_t (*get_capabilities)(struct evmc_vm* vm);
+	enum evmc_set_option_result (*set_option)(struct evmc_vm* vm, const char* name, const char* value);
+};
+
+#endif
@@ -0,0 +1,65 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package evmc
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/webchain-network/webchaind/core/vm"
+)
+
+// ruleSet activates a fork at each block: Homestead at 1, the EIP-150 gas
+// table at 2, state clearing at 3, Byzantium at 4 and Constantinople at 5.
+type ruleSet struct{}
+
+func (ruleSet) IsHomestead(n *big.Int) bool  { return n.Int64() >= 1 }
+func (ruleSet) IsAtlantis(n *big.Int) bool   { return n.Int64() >= 4 }
+func (ruleSet) IsHardfork2(n *big.Int) bool  { return n.Int64() >= 4 }
+func (ruleSet) IsStateClear(n *big.Int) bool { return n.Int64() >= 3 }
+func (ruleSet) GasTable(n *big.Int) *vm.GasTable {
+	if n.Int64() >= 2 {
+		return &vm.GasTable{CreateBySuicide: 25000}
+	}
+	return &vm.GasTable{}
+}
+
+func (ruleSet) OpcodeEnabled(op vm.OpCode, n *big.Int) (bool, bool) {
+	return n.Int64() >= 5, true
+}
+
+func TestRevision(t *testing.T) {
+	for n, want := range []int{Frontier, Homestead, TangerineWhistle, SpuriousDragon, Byzantium, Petersburg} {
+		if have := Revision(ruleSet{}, big.NewInt(int64(n))); have != want {
+			t.Errorf("block %d: revision mismatch: have %d, want %d", n, have, want)
+		}
+	}
+}
+
+func TestCreateFunctions(t *testing.T) {
+	tests := map[string][]string{
+		"/usr/lib/libevmone.so":        {"evmc_create_evmone", "evmc_create"},
+		"libevmone.so.0.4":             {"evmc_create_evmone", "evmc_create"},
+		"/opt/evm/libexample-vm.dylib": {"evmc_create_example_vm", "evmc_create"},
+	}
+	for path, want := range tests {
+		if have := createFunctions(path); !reflect.DeepEqual(have, want) {
+			t.Errorf("%s: have %v, want %v", path, have, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+// +build evmc
+
+#include <stdlib.h>
+
+#include "evmc.h"
+#include "_cgo_export.h"
+
+// The host interface forwards the callbacks of the VM to the functions
+// exported by host.go, which take the host context as a handle.
+
+static bool account_exists(struct evmc_host_context* ctx, const evmc_address* addr) {
+	return goAccountExists((uintptr_t)ctx, (evmc_address*)addr);
+}
+
+static evmc_bytes32 get_storage(struct evmc_host_context* ctx, const evmc_address* addr, const evmc_bytes32* key) {
+	return goGetStorage((uintptr_t)ctx, (evmc_address*)addr, (evmc_bytes32*)key);
+}
+
+static enum evmc_storage_status set_storage(struct evmc_host_context* ctx, const evmc_address* addr, const evmc_bytes32* key, const evmc_bytes32* value) {
+	return (enum evmc_storage_status)goSetStorage((uintptr_t)ctx, (evmc_address*)addr, (evmc_bytes32*)key, (evmc_bytes32*)value);
+}
+
+static evmc_uint256be get_balance(struct evmc_host_context* ctx, const evmc_address* addr) {
+	return goGetBalance((uintptr_t)ctx, (evmc_address*)addr);
+}
+
+static size_t get_code_size(struct evmc_host_context* ctx, const evmc_address* addr) {
+	return goGetCodeSize((uintptr_t)ctx, (evmc_address*)addr);
+}
+
+static evmc_bytes32 get_code_hash(struct evmc_host_context* ctx, const evmc_address* addr) {
+	return goGetCodeHash((uintptr_t)ctx, (evmc_address*)addr);
+}
+
+static size_t copy_code(struct evmc_host_context* ctx, const evmc_address* addr, size_t offset, uint8_t* data, size_t size) {
+	return goCopyCode((uintptr_t)ctx, (evmc_address*)addr, offset, data, size);
+}
+
+static void selfdestruct(struct evmc_host_context* ctx, const evmc_address* addr, const evmc_address* beneficiary) {
+	goSelfdestruct((uintptr_t)ctx, (evmc_address*)addr, (evmc_address*)beneficiary);
+}
+
+static struct evmc_result call(struct evmc_host_context* ctx, const struct evmc_message* msg) {
+	return goCall((uintptr_t)ctx, (struct evmc_message*)msg);
+}
+
+static struct evmc_tx_context get_tx_context(struct evmc_host_context* ctx) {
+	return goGetTxContext((uintptr_t)ctx);
+}
+
+static evmc_bytes32 get_block_hash(struct evmc_host_context* ctx, int64_t number) {
+	return goGetBlockHash((uintptr_t)ctx, number);
+}
+
+static void emit_log(struct evmc_host_context* ctx, const evmc_address* addr, const uint8_t* data, size_t size, const evmc_bytes32 topics[], size_t count) {
+	goEmitLog((uintptr_t)ctx, (evmc_address*)addr, (uint8_t*)data, size, (evmc_bytes32*)topics, count);
+}
+
+static const struct evmc_host_interface host = {
+	account_exists,
+	get_storage,
+	set_storage,
+	get_balance,
+	get_code_size,
+	get_code_hash,
+	copy_code,
+	selfdestruct,
+	call,
+	get_tx_context,
+	get_block_hash,
+	emit_log,
+};
+
+struct evmc_vm* webchain_create_vm(void* create) {
+	return ((struct evmc_vm* (*)(void))create)();
+}
+
+void webchain_destroy_vm(struct evmc_vm* vm) {
+	vm->destroy(vm);
+}
+
+uint32_t webchain_capabilities(struct evmc_vm* vm) {
+	return vm->get_capabilities(vm);
+}
+
+int webchain_set_option(struct evmc_vm* vm, const char* name, const char* value) {
+	if (vm->set_option == NULL) {
+		return EVMC_SET_OPTION_INVALID_NAME;
+	}
+	return vm->set_option(vm, name, value);
+}
+
+struct evmc_result webchain_execute(struct evmc_vm* vm, uintptr_t ctx, int rev, const struct evmc_message* msg, const uint8_t* code, size_t size) {
+	return vm->execute(vm, &host, (struct evmc_host_context*)ctx, (enum evmc_revision)rev, msg, code, size);
+}
+
+void webchain_release_result(struct evmc_result* result) {
+	if (result->release != NULL) {
+		result->release(result);
+	}
+}
+
+// webchain_release_output frees the output of the results returned to the VM.
+static void webchain_release_output(const struct evmc_result* result) {
+	free((void*)result->output_data);
+}
+
+void webchain_set_output(struct evmc_result* result, uint8_t* data, size_t size) {
+	result->output_data = data;
+	result->output_size = size;
+	result->release = webchain_release_output;
+}
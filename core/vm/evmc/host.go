@@ -0,0 +1,253 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build evmc
+// +build evmc
+
+package evmc
+
+/*
+#include <stdlib.h>
+#include <string.h>
+
+#include "evmc.h"
+
+void webchain_set_output(struct evmc_result* result, uint8_t* data, size_t size);
+*/
+import "C"
+
+import (
+	"math/big"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/vm"
+)
+
+// host is the context of the host functions called by an executing contract.
+type host struct {
+	in       *instance
+	contract *vm.Contract
+}
+
+func getHost(ctx C.uintptr_t) *host {
+	return cgo.Handle(ctx).Value().(*host)
+}
+
+func goAddress(addr *C.evmc_address) common.Address {
+	return common.Address(*(*[common.AddressLength]byte)(unsafe.Pointer(&addr.bytes)))
+}
+
+func goHash(hash *C.evmc_bytes32) common.Hash {
+	return common.Hash(*(*[common.HashLength]byte)(unsafe.Pointer(&hash.bytes)))
+}
+
+func cAddress(addr common.Address) (c C.evmc_address) {
+	*(*[common.AddressLength]byte)(unsafe.Pointer(&c.bytes)) = addr
+	return c
+}
+
+func cHash(n *big.Int) (c C.evmc_bytes32) {
+	*(*[common.HashLength]byte)(unsafe.Pointer(&c.bytes)) = common.BigToHash(n)
+	return c
+}
+
+func cBytes32(hash common.Hash) (c C.evmc_bytes32) {
+	*(*[common.HashLength]byte)(unsafe.Pointer(&c.bytes)) = hash
+	return c
+}
+
+//export goAccountExists
+func goAccountExists(ctx C.uintptr_t, addr *C.evmc_address) C.bool {
+	h := getHost(ctx)
+	a := goAddress(addr)
+	if h.in.env.RuleSet().IsHardfork2(h.in.env.BlockNumber()) {
+		return C.bool(!h.in.env.Db().Empty(a))
+	}
+	return C.bool(h.in.env.Db().Exist(a))
+}
+
+//export goGetStorage
+func goGetStorage(ctx C.uintptr_t, addr *C.evmc_address, key *C.evmc_bytes32) C.evmc_bytes32 {
+	h := getHost(ctx)
+	return cBytes32(h.in.env.Db().GetState(goAddress(addr), goHash(key)))
+}
+
+//export goSetStorage
+func goSetStorage(ctx C.uintptr_t, addr *C.evmc_address, key, value *C.evmc_bytes32) C.int {
+	db := getHost(ctx).in.env.Db()
+	a, k, v := goAddress(addr), goHash(key), goHash(value)
+
+	current := db.GetState(a, k)
+	if current == v {
+		return C.EVMC_STORAGE_UNCHANGED
+	}
+	db.SetState(a, k, v)
+	switch {
+	case common.EmptyHash(current):
+		return C.EVMC_STORAGE_ADDED
+	case common.EmptyHash(v):
+		db.AddRefund(big.NewInt(15000))
+		return C.EVMC_STORAGE_DELETED
+	default:
+		return C.EVMC_STORAGE_MODIFIED
+	}
+}
+
+//export goGetBalance
+func goGetBalance(ctx C.uintptr_t, addr *C.evmc_address) C.evmc_bytes32 {
+	return cHash(getHost(ctx).in.env.Db().GetBalance(goAddress(addr)))
+}
+
+//export goGetCodeSize
+func goGetCodeSize(ctx C.uintptr_t, addr *C.evmc_address) C.size_t {
+	return C.size_t(getHost(ctx).in.env.Db().GetCodeSize(goAddress(addr)))
+}
+
+//export goGetCodeHash
+func goGetCodeHash(ctx C.uintptr_t, addr *C.evmc_address) C.evmc_bytes32 {
+	db := getHost(ctx).in.env.Db()
+	a := goAddress(addr)
+	if db.Empty(a) {
+		return C.evmc_bytes32{}
+	}
+	return cBytes32(db.GetCodeHash(a))
+}
+
+//export goCopyCode
+func goCopyCode(ctx C.uintptr_t, addr *C.evmc_address, offset C.size_t, data *C.uint8_t, size C.size_t) C.size_t {
+	code := getHost(ctx).in.env.Db().GetCode(goAddress(addr))
+	if int(offset) >= len(code) {
+		return 0
+	}
+	n := copy((*[1 << 30]byte)(unsafe.Pointer(data))[:size:size], code[offset:])
+	return C.size_t(n)
+}
+
+//export goSelfdestruct
+func goSelfdestruct(ctx C.uintptr_t, addr, beneficiary *C.evmc_address) {
+	env := getHost(ctx).in.env
+	db := env.Db()
+	a := goAddress(addr)
+
+	if !db.HasSuicided(a) {
+		if refund := env.RuleSet().GasTable(env.BlockNumber()).SuicideRefund; refund != nil {
+			db.AddRefund(refund)
+		} else {
+			db.AddRefund(vm.GasSuicideRefund)
+		}
+	}
+	db.AddBalance(goAddress(beneficiary), db.GetBalance(a))
+	db.Suicide(a)
+}
+
+//export goCall
+func goCall(ctx C.uintptr_t, msg *C.struct_evmc_message) C.struct_evmc_result {
+	var (
+		h        = getHost(ctx)
+		env      = h.in.env
+		contract = h.contract
+		addr     = goAddress(&msg.destination)
+		value    = goHash(&msg.value).Big()
+		gas      = uint64(msg.gas)
+		input    []byte
+		ret      []byte
+		created  common.Address
+		err      error
+	)
+	if msg.input_size > 0 {
+		input = C.GoBytes(unsafe.Pointer(msg.input_data), C.int(msg.input_size))
+	}
+	switch msg.kind {
+	case C.EVMC_CALL:
+		if msg.flags&C.EVMC_STATIC != 0 {
+			ret, gas, err = env.StaticCall(contract, addr, input, gas, contract.Price)
+		} else {
+			ret, gas, err = env.Call(contract, addr, input, gas, contract.Price, value)
+		}
+	case C.EVMC_CALLCODE:
+		ret, gas, err = env.CallCode(contract, addr, input, gas, contract.Price, value)
+	case C.EVMC_DELEGATECALL:
+		ret, gas, err = env.DelegateCall(contract, addr, input, gas, contract.Price)
+	case C.EVMC_CREATE:
+		ret, created, gas, err = env.Create(contract, input, gas, contract.Price, value)
+	case C.EVMC_CREATE2:
+		ret, created, gas, err = env.Create2(contract, input, gas, contract.Price, value, goHash(&msg.create2_salt).Big())
+	}
+	// Only the data of a reverted creation is returned, not the code.
+	if (msg.kind == C.EVMC_CREATE || msg.kind == C.EVMC_CREATE2) && err != vm.ErrRevert {
+		ret = nil
+	}
+	env.SetReturnData(ret)
+
+	var res C.struct_evmc_result
+	res.gas_left = C.int64_t(gas)
+	switch err {
+	case nil:
+		res.status_code = C.EVMC_SUCCESS
+		res.create_address = cAddress(created)
+	case vm.ErrRevert:
+		res.status_code = C.EVMC_REVERT
+	default:
+		res.status_code = C.EVMC_FAILURE
+		res.gas_left = 0
+	}
+	if len(ret) > 0 {
+		C.webchain_set_output(&res, (*C.uint8_t)(C.CBytes(ret)), C.size_t(len(ret)))
+	}
+	return res
+}
+
+//export goGetTxContext
+func goGetTxContext(ctx C.uintptr_t) C.struct_evmc_tx_context {
+	h := getHost(ctx)
+	env := h.in.env
+
+	tx := C.struct_evmc_tx_context{
+		tx_gas_price:     cHash(h.contract.Price),
+		tx_origin:        cAddress(env.Origin()),
+		block_coinbase:   cAddress(env.Coinbase()),
+		block_number:     C.int64_t(env.BlockNumber().Int64()),
+		block_timestamp:  C.int64_t(env.Time().Int64()),
+		block_gas_limit:  C.int64_t(env.GasLimit().Int64()),
+		block_difficulty: cHash(env.Difficulty()),
+	}
+	if rules, ok := env.RuleSet().(interface {
+		GetChainID(*big.Int) *big.Int
+	}); ok {
+		if id := rules.GetChainID(env.BlockNumber()); id != nil {
+			tx.chain_id = cHash(id)
+		}
+	}
+	return tx
+}
+
+//export goGetBlockHash
+func goGetBlockHash(ctx C.uintptr_t, number C.int64_t) C.evmc_bytes32 {
+	return cBytes32(getHost(ctx).in.env.GetHash(uint64(number)))
+}
+
+//export goEmitLog
+func goEmitLog(ctx C.uintptr_t, addr *C.evmc_address, data *C.uint8_t, size C.size_t, topics *C.evmc_bytes32, count C.size_t) {
+	env := getHost(ctx).in.env
+
+	hashes := make([]common.Hash, int(count))
+	for i, topic := range (*[1 << 20]C.evmc_bytes32)(unsafe.Pointer(topics))[:count:count] {
+		hashes[i] = goHash(&topic)
+	}
+	env.AddLog(vm.NewLog(goAddress(addr), hashes, C.GoBytes(unsafe.Pointer(data), C.int(size)), env.BlockNumber().Uint64()))
+}
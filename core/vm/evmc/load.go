@@ -0,0 +1,211 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+//go:build evmc
+// +build evmc
+
+package evmc
+
+/*
+#cgo LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <stdlib.h>
+
+#include "evmc.h"
+
+struct evmc_vm* webchain_create_vm(void* create);
+void webchain_destroy_vm(struct evmc_vm* vm);
+uint32_t webchain_capabilities(struct evmc_vm* vm);
+int webchain_set_option(struct evmc_vm* vm, const char* name, const char* value);
+struct evmc_result webchain_execute(struct evmc_vm* vm, uintptr_t ctx, int rev, const struct evmc_message* msg, const uint8_t* code, size_t size);
+void webchain_release_result(struct evmc_result* result);
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/webchain-network/webchaind/core/vm"
+)
+
+// VM is an EVMC virtual machine loaded from a shared library.
+type VM struct {
+	vm *C.struct_evmc_vm
+}
+
+// Load loads the EVMC virtual machine of the shared library at path. The
+// library stays loaded for the lifetime of the process.
+func Load(path string) (*VM, error) {
+	return load(path, C.EVMC_CAPABILITY_EVM1, "EVM1 bytecode")
+}
+
+// LoadEWASM loads the EVMC virtual machine of the shared library at path, which
+// must execute ewasm contracts, e.g. Hera.
+func LoadEWASM(path string) (*VM, error) {
+	return load(path, C.EVMC_CAPABILITY_EWASM, "ewasm")
+}
+
+// load loads the EVMC virtual machine of the shared library at path, requiring
+// it to have the given capability.
+func load(path string, capability C.uint32_t, kind string) (*VM, error) {
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+
+	handle := C.dlopen(cpath, C.RTLD_NOW)
+	if handle == nil {
+		return nil, fmt.Errorf("evmc: loading %s: %s", path, C.GoString(C.dlerror()))
+	}
+	var create unsafe.Pointer
+	for _, name := range createFunctions(path) {
+		cname := C.CString(name)
+		create = C.dlsym(handle, cname)
+		C.free(unsafe.Pointer(cname))
+		if create != nil {
+			break
+		}
+	}
+	if create == nil {
+		return nil, fmt.Errorf("evmc: %s exports no EVMC create function", path)
+	}
+	v := C.webchain_create_vm(create)
+	if v == nil {
+		return nil, errors.New("evmc: VM creation failed")
+	}
+	if v.abi_version != C.EVMC_ABI_VERSION {
+		version := int(v.abi_version)
+		C.webchain_destroy_vm(v)
+		return nil, fmt.Errorf("evmc: unsupported ABI version %d, want %d", version, C.EVMC_ABI_VERSION)
+	}
+	if v.get_capabilities != nil && C.webchain_capabilities(v)&capability == 0 {
+		C.webchain_destroy_vm(v)
+		return nil, fmt.Errorf("evmc: VM doesn't support %s", kind)
+	}
+	return &VM{v}, nil
+}
+
+// Name returns the name of the VM implementation.
+func (v *VM) Name() string { return C.GoString(v.vm.name) }
+
+// Version returns the version of the VM implementation.
+func (v *VM) Version() string { return C.GoString(v.vm.version) }
+
+// SetOption sets an implementation specific option of the VM.
+func (v *VM) SetOption(name, value string) error {
+	cname, cvalue := C.CString(name), C.CString(value)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(cvalue))
+
+	switch C.webchain_set_option(v.vm, cname, cvalue) {
+	case C.EVMC_SET_OPTION_SUCCESS:
+		return nil
+	case C.EVMC_SET_OPTION_INVALID_NAME:
+		return fmt.Errorf("evmc: unknown option %q", name)
+	default:
+		return fmt.Errorf("evmc: invalid value %q for option %q", value, name)
+	}
+}
+
+// NewVm returns the vm.Vm running the contracts of env in the external VM,
+// leaving the precompiled contracts to evm.
+func (v *VM) NewVm(env vm.Environment, evm *vm.EVM) vm.Vm {
+	return &instance{
+		vm:  v,
+		env: env,
+		evm: evm,
+		rev: Revision(env.RuleSet(), env.BlockNumber()),
+	}
+}
+
+// instance runs the contracts of an environment.
+type instance struct {
+	vm       *VM
+	env      vm.Environment
+	evm      *vm.EVM
+	rev      int
+	readOnly bool
+}
+
+// Run implements vm.Vm.
+func (in *instance) Run(contract *vm.Contract, input []byte, readOnly bool) ([]byte, error) {
+	if len(contract.Code) == 0 || (contract.CodeAddr != nil && in.evm.IsPrecompiled(*contract.CodeAddr)) {
+		return in.evm.Run(contract, input, readOnly)
+	}
+	in.env.SetDepth(in.env.Depth() + 1)
+	defer in.env.SetDepth(in.env.Depth() - 1)
+
+	if readOnly && !in.readOnly {
+		in.readOnly = true
+		defer func() { in.readOnly = false }()
+	}
+	in.env.SetReturnData(nil)
+
+	// Gas beyond what EVMC can represent is set aside, to be given back.
+	gas, excess := contract.Gas, uint64(0)
+	if gas > math.MaxInt64 {
+		gas, excess = math.MaxInt64, gas-math.MaxInt64
+	}
+	msg := C.struct_evmc_message{
+		kind:        C.EVMC_CALL,
+		depth:       C.int32_t(in.env.Depth() - 1),
+		gas:         C.int64_t(gas),
+		destination: cAddress(contract.Address()),
+		sender:      cAddress(contract.Caller()),
+		input_size:  C.size_t(len(input)),
+		value:       cHash(contract.Value()),
+	}
+	if contract.CodeAddr == nil {
+		msg.kind = C.EVMC_CREATE
+	}
+	if in.readOnly {
+		msg.flags = C.EVMC_STATIC
+	}
+	if len(input) > 0 {
+		msg.input_data = (*C.uint8_t)(C.CBytes(input))
+		defer C.free(unsafe.Pointer(msg.input_data))
+	}
+	code := C.CBytes(contract.Code)
+	defer C.free(code)
+
+	h := cgo.NewHandle(&host{in, contract})
+	defer h.Delete()
+
+	res := C.webchain_execute(in.vm.vm, C.uintptr_t(h), C.int(in.rev), &msg, (*C.uint8_t)(code), C.size_t(len(contract.Code)))
+	defer C.webchain_release_result(&res)
+
+	var ret []byte
+	if res.output_size > 0 {
+		ret = C.GoBytes(unsafe.Pointer(res.output_data), C.int(res.output_size))
+	}
+	contract.Gas = excess
+	if res.gas_left > 0 {
+		contract.Gas += uint64(res.gas_left)
+	}
+
+	switch res.status_code {
+	case C.EVMC_SUCCESS:
+		return ret, nil
+	case C.EVMC_REVERT:
+		return ret, vm.ErrRevert
+	case C.EVMC_OUT_OF_GAS:
+		return nil, vm.OutOfGasError
+	default:
+		return nil, fmt.Errorf("evmc: execution failed with status %d", int(res.status_code))
+	}
+}
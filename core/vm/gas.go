@@ -18,60 +18,98 @@ package vm
 
 import (
 	"fmt"
+	"math"
 	"math/big"
 	"reflect"
 
 	"github.com/webchain-network/webchaind/params"
 )
 
-var (
-	GasQuickStep   = big.NewInt(2)
-	GasFastestStep = big.NewInt(3)
-	GasFastStep    = big.NewInt(5)
-	GasMidStep     = big.NewInt(8)
-	GasSlowStep    = big.NewInt(10)
-	GasExtStep     = big.NewInt(20)
+const (
+	GasQuickStep   uint64 = 2
+	GasFastestStep uint64 = 3
+	GasFastStep    uint64 = 5
+	GasMidStep     uint64 = 8
+	GasSlowStep    uint64 = 10
+	GasExtStep     uint64 = 20
 
-	GasReturn = big.NewInt(0)
-	GasStop   = big.NewInt(0)
+	GasReturn uint64 = 0
+	GasStop   uint64 = 0
 
-	GasContractByte = big.NewInt(200)
-
-	n64 = big.NewInt(64)
+	GasContractByte uint64 = 200
 )
 
+// GasSuicideRefund is refunded for the first SUICIDE of a contract in a
+// transaction, unless the gas table overrides it.
+var GasSuicideRefund = big.NewInt(24000)
+
+// GasTable holds the gas prices of the operations whose price changed with
+// the forks.
 type GasTable struct {
-	ExtcodeSize *big.Int
-	ExtcodeCopy *big.Int
-	Balance     *big.Int
-	SLoad       *big.Int
-	Calls       *big.Int
-	Suicide     *big.Int
-	ExpByte     *big.Int
+	ExtcodeSize uint64
+	ExtcodeCopy uint64
+	Balance     uint64
+	SLoad       uint64
+	Calls       uint64
+	Suicide     uint64
+	ExpByte     uint64
 
 	// CreateBySuicide occurs when the
 	// refunded account is one that does
 	// not exist. This logic is similar
-	// to call. May be left nil. Nil means
-	// not charged.
-	CreateBySuicide *big.Int
+	// to call. May be left zero. Zero
+	// means not charged, and the 63/64
+	// rule of EIP150 not applied.
+	CreateBySuicide uint64
+
+	// SuicideRefund is refunded for the first SUICIDE of
+	// a contract in a transaction. May be left nil. Nil
+	// means GasSuicideRefund. Refunds are accounted by
+	// the state, hence the big.Int.
+	SuicideRefund *big.Int
 }
 
-// calcGas returns the actual gas cost of the call.
+// callGas returns the actual gas cost of the call.
 //
 // The cost of gas was changed during the homestead price change HF. To allow for EIP150
-// to be implemented. The returned gas is gas - base * 63 / 64.
-func callGas(gasTable *GasTable, availableGas, base, callCost *big.Int) *big.Int {
-	if gasTable.CreateBySuicide != nil {
-		availableGas = new(big.Int).Sub(availableGas, base)
-		g := new(big.Int).Div(availableGas, n64)
-		g.Sub(availableGas, g)
-
-		if g.Cmp(callCost) < 0 {
-			return g
+// to be implemented. The returned gas is gas - base * 63 / 64. It fails if the
+// base cost already exceeds the available gas or the requested gas doesn't fit
+// in 64 bits without EIP150 capping it.
+func callGas(gasTable *GasTable, availableGas, base uint64, callCost *big.Int) (uint64, error) {
+	if gasTable.CreateBySuicide > 0 {
+		if availableGas < base {
+			return 0, OutOfGasError
 		}
+		availableGas -= base
+		gas := availableGas - availableGas/64
+		if !callCost.IsUint64() || gas < callCost.Uint64() {
+			return gas, nil
+		}
+	}
+	if !callCost.IsUint64() {
+		return 0, OutOfGasError
 	}
-	return callCost
+	return callCost.Uint64(), nil
+}
+
+// safeAdd returns x+y, or an out of gas error if the sum of the gas costs
+// overflows.
+func safeAdd(x, y uint64) (uint64, error) {
+	if x+y < x {
+		return 0, OutOfGasError
+	}
+	return x + y, nil
+}
+
+// safeMul returns x*y, or an out of gas error on overflow.
+func safeMul(x, y uint64) (uint64, error) {
+	if x == 0 || y == 0 {
+		return 0, nil
+	}
+	if x*y/y != x {
+		return 0, OutOfGasError
+	}
+	return x * y, nil
 }
 
 // IsEmpty return true if all values are zero values,
@@ -80,8 +118,8 @@ func (g *GasTable) IsEmpty() bool {
 	return reflect.DeepEqual(g, GasTable{})
 }
 
-// baseCheck checks for any stack error underflows
-func baseCheck(op OpCode, stack *stack, gas *big.Int) error {
+// baseCheck checks for any stack error underflows and returns the base gas of op
+func baseCheck(op OpCode, stack *stack) (uint64, error) {
 	// PUSH and DUP are a bit special. They all cost the same but we do want to have checking on stack push limit
 	// PUSH is also allowed to calculate the same price for all PUSHes
 	// DUP requirements are handled elsewhere (except for the stack limit check)
@@ -95,29 +133,37 @@ func baseCheck(op OpCode, stack *stack, gas *big.Int) error {
 	if r, ok := _baseCheck[op]; ok {
 		err := stack.require(r.stackPop)
 		if err != nil {
-			return err
+			return 0, err
 		}
 
 		if r.stackPush > 0 && stack.len()-r.stackPop+r.stackPush > int(params.StackLimit) {
-			return fmt.Errorf("stack length %d exceed limit %d", stack.len(), params.StackLimit)
+			return 0, fmt.Errorf("stack length %d exceed limit %d", stack.len(), params.StackLimit)
 		}
 
-		gas.Add(gas, r.gas)
+		return r.gas, nil
 	}
-	return nil
+	return 0, nil
 }
 
-// casts a arbitrary number to the amount of words (sets of 32 bytes)
-func toWordSize(size *big.Int) *big.Int {
-	tmp := new(big.Int)
-	tmp.Add(size, u256(31))
-	tmp.Div(tmp, u256(32))
-	return tmp
+// toWordSize returns the amount of words (sets of 32 bytes) of size bytes.
+func toWordSize(size uint64) uint64 {
+	if size > math.MaxUint64-31 {
+		return math.MaxUint64/32 + 1
+	}
+	return (size + 31) / 32
+}
+
+// wordGas returns the gas of perWord for every word of size bytes.
+func wordGas(size *big.Int, perWord uint64) (uint64, error) {
+	if !size.IsUint64() {
+		return 0, OutOfGasError
+	}
+	return safeMul(toWordSize(size.Uint64()), perWord)
 }
 
 type req struct {
 	stackPop  int
-	gas       *big.Int
+	gas       uint64
 	stackPush int
 }
 
@@ -136,6 +182,9 @@ var _baseCheck = map[OpCode]req{
 	XOR:            {2, GasFastestStep, 1},
 	NOT:            {1, GasFastestStep, 1},
 	BYTE:           {2, GasFastestStep, 1},
+	SHL:            {2, GasFastestStep, 1},
+	SHR:            {2, GasFastestStep, 1},
+	SAR:            {2, GasFastestStep, 1},
 	CALLDATALOAD:   {1, GasFastestStep, 1},
 	CALLDATACOPY:   {3, GasFastestStep, 1},
 	MLOAD:          {1, GasFastestStep, 1},
@@ -165,29 +214,32 @@ var _baseCheck = map[OpCode]req{
 	CALLDATASIZE:   {0, GasQuickStep, 1},
 	DIFFICULTY:     {0, GasQuickStep, 1},
 	GASLIMIT:       {0, GasQuickStep, 1},
+	SELFBALANCE:    {0, GasFastStep, 1},
 	POP:            {1, GasQuickStep, 0},
 	PC:             {0, GasQuickStep, 1},
 	MSIZE:          {0, GasQuickStep, 1},
 	GAS:            {0, GasQuickStep, 1},
 	RETURNDATASIZE: {0, GasQuickStep, 1},
 	BLOCKHASH:      {1, GasExtStep, 1},
-	BALANCE:        {1, new(big.Int), 1},
-	EXTCODESIZE:    {1, new(big.Int), 1},
-	EXTCODECOPY:    {4, new(big.Int), 0},
-	SLOAD:          {1, big.NewInt(50), 1},
-	SSTORE:         {2, new(big.Int), 0},
-	SHA3:           {2, big.NewInt(30), 1},
-	CREATE:         {3, big.NewInt(32000), 1},
+	BALANCE:        {1, 0, 1},
+	EXTCODESIZE:    {1, 0, 1},
+	EXTCODECOPY:    {4, 0, 0},
+	EXTCODEHASH:    {1, 400, 1},
+	SLOAD:          {1, 50, 1},
+	SSTORE:         {2, 0, 0},
+	SHA3:           {2, 30, 1},
+	CREATE:         {3, 32000, 1},
+	CREATE2:        {4, 32000, 1},
 	// Zero is calculated in the gasSwitch
-	CALL:           {7, new(big.Int), 1},
-	CALLCODE:       {7, new(big.Int), 1},
-	DELEGATECALL:   {6, new(big.Int), 1},
-	STATICCALL:     {6, new(big.Int), 1},
-	REVERT:         {2, new(big.Int), 0},
-	RETURNDATACOPY: {3, new(big.Int), 0},
-	SUICIDE:        {1, new(big.Int), 0},
-	JUMPDEST:       {0, big.NewInt(1), 0},
-	RETURN:         {2, new(big.Int), 0},
+	CALL:           {7, 0, 1},
+	CALLCODE:       {7, 0, 1},
+	DELEGATECALL:   {6, 0, 1},
+	STATICCALL:     {6, 0, 1},
+	REVERT:         {2, 0, 0},
+	RETURNDATACOPY: {3, 0, 0},
+	SUICIDE:        {1, 0, 0},
+	JUMPDEST:       {0, 1, 0},
+	RETURN:         {2, 0, 0},
 	PUSH1:          {0, GasFastestStep, 1},
-	DUP1:           {0, new(big.Int), 1},
+	DUP1:           {0, 0, 1},
 }
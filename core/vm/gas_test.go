@@ -1,22 +1,57 @@
 package vm
 
 import (
+	"math"
 	"math/big"
 	"testing"
 )
 
 func TestGasIsEmpty(t *testing.T) {
 	var DefaultGasRepriceGasTable = &GasTable{
-		ExtcodeSize:     big.NewInt(700),
-		ExtcodeCopy:     big.NewInt(700),
-		Balance:         big.NewInt(400),
-		SLoad:           big.NewInt(200),
-		Calls:           big.NewInt(700),
-		Suicide:         big.NewInt(5000),
-		ExpByte:         big.NewInt(10),
-		CreateBySuicide: big.NewInt(25000),
+		ExtcodeSize:     700,
+		ExtcodeCopy:     700,
+		Balance:         400,
+		SLoad:           200,
+		Calls:           700,
+		Suicide:         5000,
+		ExpByte:         10,
+		CreateBySuicide: 25000,
 	}
 	if DefaultGasRepriceGasTable.IsEmpty() {
 		t.Error("Unexpected IsEmpty() for nonempty gas table.")
 	}
 }
+
+func TestCallGas(t *testing.T) {
+	var (
+		frontier = new(GasTable)
+		eip150   = &GasTable{CreateBySuicide: 25000}
+		huge     = new(big.Int).Lsh(big.NewInt(1), 100)
+	)
+	tests := []struct {
+		table          *GasTable
+		available, req uint64
+		cost           *big.Int
+		want           uint64
+		fail           bool
+	}{
+		{frontier, 1000, 0, big.NewInt(500), 500, false},
+		{frontier, 1000, 0, huge, 0, true},
+		{eip150, 6500, 100, big.NewInt(10000), 6300, false},
+		{eip150, 6500, 100, huge, 6300, false},
+		{eip150, 6500, 100, big.NewInt(500), 500, false},
+		{eip150, 50, 100, big.NewInt(10), 0, true},
+	}
+	for i, tt := range tests {
+		gas, err := callGas(tt.table, tt.available, tt.req, tt.cost)
+		if (err != nil) != tt.fail || gas != tt.want {
+			t.Errorf("test %d: got %d, %v, want %d", i, gas, err, tt.want)
+		}
+	}
+	if _, err := safeAdd(math.MaxUint64, 1); err == nil {
+		t.Error("no error for overflowing sum")
+	}
+	if _, err := safeMul(math.MaxUint64/2, 3); err == nil {
+		t.Error("no error for overflowing product")
+	}
+}
@@ -24,7 +24,7 @@ import (
 	"github.com/webchain-network/webchaind/crypto"
 )
 
-var callStipend = big.NewInt(2300) // Free gas given at beginning of call.
+const callStipend uint64 = 2300 // Free gas given at beginning of call.
 
 type instrFn func(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error)
 
@@ -58,18 +58,21 @@ func (instr instruction) Op() OpCode {
 func opAdd(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y := stack.pop(), stack.pop()
 	stack.push(U256(x.Add(x, y)))
+	stack.ints.put(y)
 	return nil, nil
 }
 
 func opSub(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y := stack.pop(), stack.pop()
 	stack.push(U256(x.Sub(x, y)))
+	stack.ints.put(y)
 	return nil, nil
 }
 
 func opMul(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y := stack.pop(), stack.pop()
 	stack.push(U256(x.Mul(x, y)))
+	stack.ints.put(y)
 	return nil, nil
 }
 
@@ -78,8 +81,9 @@ func opDiv(pc *uint64, env Environment, contract *Contract, memory *Memory, stac
 	if y.Sign() != 0 {
 		stack.push(U256(x.Div(x, y)))
 	} else {
-		stack.push(new(big.Int))
+		stack.push(x.SetUint64(0))
 	}
+	stack.ints.put(y)
 	return nil, nil
 }
 
@@ -107,10 +111,11 @@ func opSdiv(pc *uint64, env Environment, contract *Contract, memory *Memory, sta
 func opMod(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y := stack.pop(), stack.pop()
 	if y.Sign() == 0 {
-		stack.push(new(big.Int))
+		stack.push(x.SetUint64(0))
 	} else {
 		stack.push(U256(x.Mod(x, y)))
 	}
+	stack.ints.put(y)
 	return nil, nil
 }
 
@@ -168,20 +173,22 @@ func opNot(pc *uint64, env Environment, contract *Contract, memory *Memory, stac
 func opLt(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y := stack.pop(), stack.pop()
 	if x.Cmp(y) < 0 {
-		stack.push(big.NewInt(1))
+		stack.push(x.SetUint64(1))
 	} else {
-		stack.push(new(big.Int))
+		stack.push(x.SetUint64(0))
 	}
+	stack.ints.put(y)
 	return nil, nil
 }
 
 func opGt(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y := stack.pop(), stack.pop()
 	if x.Cmp(y) > 0 {
-		stack.push(big.NewInt(1))
+		stack.push(x.SetUint64(1))
 	} else {
-		stack.push(new(big.Int))
+		stack.push(x.SetUint64(0))
 	}
+	stack.ints.put(y)
 	return nil, nil
 }
 
@@ -208,19 +215,20 @@ func opSgt(pc *uint64, env Environment, contract *Contract, memory *Memory, stac
 func opEq(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y := stack.pop(), stack.pop()
 	if x.Cmp(y) == 0 {
-		stack.push(big.NewInt(1))
+		stack.push(x.SetUint64(1))
 	} else {
-		stack.push(new(big.Int))
+		stack.push(x.SetUint64(0))
 	}
+	stack.ints.put(y)
 	return nil, nil
 }
 
 func opIszero(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x := stack.pop()
 	if x.Sign() != 0 {
-		stack.push(new(big.Int))
+		stack.push(x.SetUint64(0))
 	} else {
-		stack.push(big.NewInt(1))
+		stack.push(x.SetUint64(1))
 	}
 	return nil, nil
 }
@@ -228,16 +236,19 @@ func opIszero(pc *uint64, env Environment, contract *Contract, memory *Memory, s
 func opAnd(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y := stack.pop(), stack.pop()
 	stack.push(x.And(x, y))
+	stack.ints.put(y)
 	return nil, nil
 }
 func opOr(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y := stack.pop(), stack.pop()
 	stack.push(x.Or(x, y))
+	stack.ints.put(y)
 	return nil, nil
 }
 func opXor(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y := stack.pop(), stack.pop()
 	stack.push(x.Xor(x, y))
+	stack.ints.put(y)
 	return nil, nil
 }
 func opByte(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
@@ -250,6 +261,47 @@ func opByte(pc *uint64, env Environment, contract *Contract, memory *Memory, sta
 	}
 	return nil, nil
 }
+
+// opShl shifts the second stack item left by the number of bits given by the
+// first one.
+func opShl(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	shift, value := stack.pop(), stack.pop()
+	if shift.Cmp(big.NewInt(256)) >= 0 {
+		stack.push(new(big.Int))
+	} else {
+		stack.push(U256(value.Lsh(value, uint(shift.Uint64()))))
+	}
+	return nil, nil
+}
+
+// opShr shifts the second stack item right by the number of bits given by the
+// first one, filling in zeroes.
+func opShr(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	shift, value := stack.pop(), stack.pop()
+	if shift.Cmp(big.NewInt(256)) >= 0 {
+		stack.push(new(big.Int))
+	} else {
+		stack.push(value.Rsh(value, uint(shift.Uint64())))
+	}
+	return nil, nil
+}
+
+// opSar shifts the second stack item right by the number of bits given by the
+// first one, extending its sign.
+func opSar(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	shift, value := stack.pop(), S256(stack.pop())
+	if shift.Cmp(big.NewInt(256)) >= 0 {
+		if value.Sign() < 0 {
+			stack.push(U256(big.NewInt(-1)))
+		} else {
+			stack.push(new(big.Int))
+		}
+	} else {
+		stack.push(U256(value.Rsh(value, uint(shift.Uint64()))))
+	}
+	return nil, nil
+}
+
 func opAddmod(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y, z := stack.pop(), stack.pop(), stack.pop()
 	if z.Sign() > 0 {
@@ -277,7 +329,8 @@ func opSha3(pc *uint64, env Environment, contract *Contract, memory *Memory, sta
 	offset, size := stack.pop(), stack.pop()
 	hash := crypto.Keccak256(memory.Get(offset.Int64(), size.Int64()))
 
-	stack.push(new(big.Int).SetBytes(hash))
+	stack.push(offset.SetBytes(hash))
+	stack.ints.put(size)
 	return nil, nil
 }
 
@@ -294,6 +347,13 @@ func opBalance(pc *uint64, env Environment, contract *Contract, memory *Memory,
 	return nil, nil
 }
 
+// opSelfBalance pushes the balance of the executing contract, without the cost
+// of looking up an arbitrary account like BALANCE.
+func opSelfBalance(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	stack.push(new(big.Int).Set(env.Db().GetBalance(contract.Address())))
+	return nil, nil
+}
+
 func opOrigin(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	stack.push(env.Origin().Big())
 	return nil, nil
@@ -336,6 +396,18 @@ func opExtCodeSize(pc *uint64, env Environment, contract *Contract, memory *Memo
 	return nil, nil
 }
 
+// opExtCodeHash pushes the code hash of an account, or zero if the account is
+// empty. Existing accounts without code have the hash of empty code.
+func opExtCodeHash(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	addr := common.BigToAddress(stack.pop())
+	if env.Db().Empty(addr) {
+		stack.push(new(big.Int))
+	} else {
+		stack.push(env.Db().GetCodeHash(addr).Big())
+	}
+	return nil, nil
+}
+
 func opCodeSize(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	l := big.NewInt(int64(len(contract.Code)))
 	stack.push(l)
@@ -410,14 +482,13 @@ func opGasLimit(pc *uint64, env Environment, contract *Contract, memory *Memory,
 }
 
 func opPop(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
-	stack.pop()
+	stack.ints.put(stack.pop())
 	return nil, nil
 }
 
 func opMload(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	offset := stack.pop()
-	val := new(big.Int).SetBytes(memory.Get(offset.Int64(), 32))
-	stack.push(val)
+	stack.push(offset.SetBytes(memory.Get(offset.Int64(), 32)))
 	return nil, nil
 }
 
@@ -425,6 +496,7 @@ func opMstore(pc *uint64, env Environment, contract *Contract, memory *Memory, s
 	// pop value of the stack
 	mStart, val := stack.pop(), stack.pop()
 	memory.Set(mStart.Uint64(), 32, common.BigToBytes(val, 256))
+	stack.ints.put(mStart, val)
 	return nil, nil
 }
 
@@ -455,6 +527,7 @@ func opJump(pc *uint64, env Environment, contract *Contract, memory *Memory, sta
 	}
 
 	*pc = pos.Uint64()
+	stack.ints.put(pos)
 	return nil, nil
 }
 
@@ -466,9 +539,10 @@ func opJumpi(pc *uint64, env Environment, contract *Contract, memory *Memory, st
 		}
 
 		*pc = pos.Uint64()
-		return nil, nil
+	} else {
+		*pc++
 	}
-	*pc++
+	stack.ints.put(pos, cond)
 	return nil, nil
 }
 
@@ -487,7 +561,7 @@ func opMsize(pc *uint64, env Environment, contract *Contract, memory *Memory, st
 }
 
 func opGas(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
-	stack.push(new(big.Int).Set(contract.Gas))
+	stack.push(new(big.Int).SetUint64(contract.Gas))
 	return nil, nil
 }
 
@@ -496,15 +570,15 @@ func opCreate(pc *uint64, env Environment, contract *Contract, memory *Memory, s
 		value        = stack.pop()
 		offset, size = stack.pop(), stack.pop()
 		input        = memory.Get(offset.Int64(), size.Int64())
-		gas          = new(big.Int).Set(contract.Gas)
+		gas          = contract.Gas
 	)
-	if env.RuleSet().GasTable(env.BlockNumber()).CreateBySuicide != nil {
-		gas.Div(gas, n64)
-		gas = gas.Sub(contract.Gas, gas)
+	if env.RuleSet().GasTable(env.BlockNumber()).CreateBySuicide > 0 {
+		gas -= gas / 64
 	}
 
 	contract.UseGas(gas)
-	ret, addr, suberr := env.Create(contract, input, gas, contract.Price, value)
+	ret, addr, returnGas, suberr := env.Create(contract, input, gas, contract.Price, value)
+	contract.Gas += returnGas
 	// Push item on the stack based on the returned error. If the ruleset is
 	// homestead we must check for CodeStoreOutOfGasError (homestead only
 	// rule) and treat as an error, if the ruleset is frontier we must
@@ -523,8 +597,36 @@ func opCreate(pc *uint64, env Environment, contract *Contract, memory *Memory, s
 	return nil, nil
 }
 
+func opCreate2(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	var (
+		value        = stack.pop()
+		offset, size = stack.pop(), stack.pop()
+		salt         = stack.pop()
+		input        = memory.Get(offset.Int64(), size.Int64())
+		gas          = contract.Gas
+	)
+	if env.RuleSet().GasTable(env.BlockNumber()).CreateBySuicide > 0 {
+		gas -= gas / 64
+	}
+
+	contract.UseGas(gas)
+	ret, addr, returnGas, suberr := env.Create2(contract, input, gas, contract.Price, value, salt)
+	contract.Gas += returnGas
+	if suberr != nil {
+		stack.push(new(big.Int))
+	} else {
+		stack.push(addr.Big())
+	}
+
+	if suberr == ErrRevert {
+		return ret, nil
+	}
+	return nil, nil
+}
+
 func opCall(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
-	gas := stack.pop()
+	// The gas was replaced by the amount charged for the call, which fits.
+	gas := stack.pop().Uint64()
 	// pop gas and value of the stack.
 	addr, value := stack.pop(), stack.pop()
 	value = U256(value)
@@ -539,10 +641,11 @@ func opCall(pc *uint64, env Environment, contract *Contract, memory *Memory, sta
 	args := memory.Get(inOffset.Int64(), inSize.Int64())
 
 	if len(value.Bytes()) > 0 {
-		gas.Add(gas, callStipend)
+		gas += callStipend
 	}
 
-	ret, err := env.Call(contract, address, args, gas, contract.Price, value)
+	ret, returnGas, err := env.Call(contract, address, args, gas, contract.Price, value)
+	contract.Gas += returnGas
 
 	if err != nil {
 		stack.push(new(big.Int))
@@ -557,7 +660,7 @@ func opCall(pc *uint64, env Environment, contract *Contract, memory *Memory, sta
 }
 
 func opCallCode(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
-	gas := stack.pop()
+	gas := stack.pop().Uint64()
 	// pop gas and value of the stack.
 	addr, value := stack.pop(), stack.pop()
 	value = U256(value)
@@ -572,10 +675,11 @@ func opCallCode(pc *uint64, env Environment, contract *Contract, memory *Memory,
 	args := memory.Get(inOffset.Int64(), inSize.Int64())
 
 	if len(value.Bytes()) > 0 {
-		gas.Add(gas, callStipend)
+		gas += callStipend
 	}
 
-	ret, err := env.CallCode(contract, address, args, gas, contract.Price, value)
+	ret, returnGas, err := env.CallCode(contract, address, args, gas, contract.Price, value)
+	contract.Gas += returnGas
 
 	if err != nil {
 		stack.push(new(big.Int))
@@ -594,7 +698,8 @@ func opDelegateCall(pc *uint64, env Environment, contract *Contract, memory *Mem
 
 	toAddr := common.BigToAddress(to)
 	args := memory.Get(inOffset.Int64(), inSize.Int64())
-	ret, err := env.DelegateCall(contract, toAddr, args, gas, contract.Price)
+	ret, returnGas, err := env.DelegateCall(contract, toAddr, args, gas.Uint64(), contract.Price)
+	contract.Gas += returnGas
 	if err != nil {
 		stack.push(new(big.Int))
 	} else {
@@ -611,7 +716,8 @@ func opStaticCall(pc *uint64, env Environment, contract *Contract, memory *Memor
 
 	toAddr := common.BigToAddress(addr)
 	args := memory.Get(inOffset.Int64(), inSize.Int64())
-	ret, err := env.StaticCall(contract, toAddr, args, gas, contract.Price)
+	ret, returnGas, err := env.StaticCall(contract, toAddr, args, gas.Uint64(), contract.Price)
+	contract.Gas += returnGas
 	if err != nil {
 		stack.push(new(big.Int))
 	} else {
@@ -625,14 +731,16 @@ func opStaticCall(pc *uint64, env Environment, contract *Contract, memory *Memor
 
 func opReturn(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	offset, size := stack.pop(), stack.pop()
-	ret := memory.GetPtr(offset.Int64(), size.Int64())
+	// The memory is reused once the call is over, the caller gets a copy.
+	ret := memory.Get(offset.Int64(), size.Int64())
 
 	return ret, nil
 }
 
 func opRevert(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	offset, size := stack.pop(), stack.pop()
-	ret := memory.GetPtr(offset.Int64(), size.Int64())
+	// The memory is reused once the call is over, the caller gets a copy.
+	ret := memory.Get(offset.Int64(), size.Int64())
 
 	return ret, nil
 }
@@ -695,7 +803,7 @@ func makeLog(size int) instrFn {
 func makePush(size uint64, bsize *big.Int) instrFn {
 	return func(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 		bytes := getData(contract.Code, new(big.Int).SetUint64(*pc+1), bsize)
-		stack.push(new(big.Int).SetBytes(bytes))
+		stack.push(stack.ints.get().SetBytes(bytes))
 		*pc += size
 		return nil, nil
 	}
@@ -0,0 +1,50 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import "math/big"
+
+// intPoolLimit is the number of spare integers an intPool keeps.
+const intPoolLimit = 256
+
+// intPool is a free list of the integers instructions popped off the stack and
+// no longer reference, reused for the values they push. Only integers nothing
+// else points to may be put back, the pool owns them from then on.
+type intPool struct {
+	ints []*big.Int
+}
+
+// get returns a spare integer, or a new one if there's none. Its value is
+// undefined.
+func (p *intPool) get() *big.Int {
+	if n := len(p.ints); n > 0 {
+		x := p.ints[n-1]
+		p.ints = p.ints[:n-1]
+		return x
+	}
+	return new(big.Int)
+}
+
+// put adds integers to the pool, dropping them once it's full.
+func (p *intPool) put(xs ...*big.Int) {
+	for _, x := range xs {
+		if len(p.ints) == intPoolLimit {
+			return
+		}
+		p.ints = append(p.ints, x)
+	}
+}
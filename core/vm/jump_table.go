@@ -31,35 +31,110 @@ type jumpPtr struct {
 
 type vmJumpTable [256]jumpPtr
 
+// OpcodeScheduler is implemented by rule sets which enable or disable the
+// instructions introduced after Frontier individually, overriding the forks
+// they're enabled with by default.
+type OpcodeScheduler interface {
+	// OpcodeEnabled returns whether op is enabled at the given block, and
+	// whether the rule set schedules op at all.
+	OpcodeEnabled(op OpCode, num *big.Int) (enabled, scheduled bool)
+}
+
+// schedulableInstructions are the instructions introduced after Frontier.
+// Unless scheduled otherwise, they're enabled with Hardfork2.
+var schedulableInstructions = map[OpCode]jumpPtr{
+	DELEGATECALL: {
+		fn:      opDelegateCall,
+		valid:   true,
+		returns: true,
+	},
+	REVERT: {
+		fn:      opRevert,
+		valid:   true,
+		reverts: true,
+		returns: true,
+	},
+	RETURNDATASIZE: {
+		fn:    opReturnDataSize,
+		valid: true,
+	},
+	RETURNDATACOPY: {
+		fn:    opReturnDataCopy,
+		valid: true,
+	},
+	STATICCALL: {
+		fn:      opStaticCall,
+		valid:   true,
+		returns: true,
+	},
+}
+
+// unscheduledInstructions are the instructions introduced after Frontier
+// which aren't part of any built-in fork. They're only enabled when scheduled.
+var unscheduledInstructions = map[OpCode]jumpPtr{
+	CREATE2: {
+		fn:      opCreate2,
+		valid:   true,
+		writes:  true,
+		returns: true,
+	},
+	EXTCODEHASH: {
+		fn:    opExtCodeHash,
+		valid: true,
+	},
+	SHL: {
+		fn:    opShl,
+		valid: true,
+	},
+	SHR: {
+		fn:    opShr,
+		valid: true,
+	},
+	SAR: {
+		fn:    opSar,
+		valid: true,
+	},
+	SELFBALANCE: {
+		fn:    opSelfBalance,
+		valid: true,
+	},
+}
+
+// IsSchedulable returns whether op can be enabled or disabled individually
+// through an OpcodeScheduler.
+func IsSchedulable(op OpCode) bool {
+	_, ok := schedulableInstructions[op]
+	if !ok {
+		_, ok = unscheduledInstructions[op]
+	}
+	return ok
+}
+
 func newJumpTable(ruleset RuleSet, blockNumber *big.Int) vmJumpTable {
 	jumpTable := newFrontierInstructionSet()
 
 	// when initialising a new VM execution we must first check the homestead
-	// changes.
-	if ruleset.IsHardfork2(blockNumber) {
-		jumpTable[DELEGATECALL] = jumpPtr{
-			fn:      opDelegateCall,
-			valid:   true,
-			returns: true,
-		}
-		jumpTable[REVERT] = jumpPtr{
-			fn:      opRevert,
-			valid:   true,
-			reverts: true,
-			returns: true,
-		}
-		jumpTable[RETURNDATASIZE] = jumpPtr{
-			fn:    opReturnDataSize,
-			valid: true,
+	// changes, and then whether the rule set schedules instructions itself.
+	var (
+		hardfork2    = ruleset.IsHardfork2(blockNumber)
+		scheduler, _ = ruleset.(OpcodeScheduler)
+	)
+	for op, instr := range schedulableInstructions {
+		enabled := hardfork2
+		if scheduler != nil {
+			if on, scheduled := scheduler.OpcodeEnabled(op, blockNumber); scheduled {
+				enabled = on
+			}
 		}
-		jumpTable[RETURNDATACOPY] = jumpPtr{
-			fn:    opReturnDataCopy,
-			valid: true,
+		if enabled {
+			jumpTable[op] = instr
 		}
-		jumpTable[STATICCALL] = jumpPtr{
-			fn:      opStaticCall,
-			valid:   true,
-			returns: true,
+	}
+	if scheduler != nil {
+		for op, instr := range unscheduledInstructions {
+			if enabled, _ := scheduler.OpcodeEnabled(op, blockNumber); enabled {
+				jumpTable[op] = instr
+			}
 		}
 	}
 
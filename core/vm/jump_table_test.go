@@ -30,15 +30,17 @@ func (r ruleSet) IsHomestead(n *big.Int) bool { return n.Cmp(r.hs) >= 0 }
 
 func (r ruleSet) IsAtlantis(n *big.Int) bool { return n.Cmp(r.at) >= 0 }
 
+func (r ruleSet) IsHardfork2(n *big.Int) bool { return n.Cmp(r.hs) >= 0 }
+
 func (r ruleSet) GasTable(*big.Int) *GasTable {
 	return &GasTable{
-		ExtcodeSize: big.NewInt(20),
-		ExtcodeCopy: big.NewInt(20),
-		Balance:     big.NewInt(20),
-		SLoad:       big.NewInt(50),
-		Calls:       big.NewInt(40),
-		Suicide:     big.NewInt(0),
-		ExpByte:     big.NewInt(10),
+		ExtcodeSize: 20,
+		ExtcodeCopy: 20,
+		Balance:     20,
+		SLoad:       50,
+		Calls:       40,
+		Suicide:     0,
+		ExpByte:     10,
 	}
 }
 
@@ -55,3 +57,52 @@ func TestInit(t *testing.T) {
 		}
 	}
 }
+
+// scheduledRuleSet enables opcodes from the given blocks, or disables them if
+// the block is negative.
+type scheduledRuleSet struct {
+	ruleSet
+	opcodes map[OpCode]int64
+}
+
+func (r scheduledRuleSet) OpcodeEnabled(op OpCode, n *big.Int) (bool, bool) {
+	block, ok := r.opcodes[op]
+	if !ok {
+		return false, false
+	}
+	return block >= 0 && n.Int64() >= block, true
+}
+
+func TestScheduledOpcodes(t *testing.T) {
+	rules := scheduledRuleSet{
+		ruleSet: ruleSet{big.NewInt(10), big.NewInt(10)},
+		opcodes: map[OpCode]int64{STATICCALL: 5, REVERT: -1},
+	}
+	jumpTable := newJumpTable(rules, big.NewInt(5))
+	if !jumpTable[STATICCALL].valid {
+		t.Error("Expected STATICCALL to be present before Hardfork2")
+	}
+	if jumpTable[RETURNDATASIZE].valid || jumpTable[DELEGATECALL].valid {
+		t.Error("Expected unscheduled opcodes not to be present before Hardfork2")
+	}
+
+	jumpTable = newJumpTable(rules, big.NewInt(10))
+	if jumpTable[REVERT].valid {
+		t.Error("Expected disabled REVERT not to be present")
+	}
+	for _, op := range []OpCode{DELEGATECALL, RETURNDATASIZE, RETURNDATACOPY, STATICCALL} {
+		if !jumpTable[op].valid {
+			t.Errorf("Expected %v to be present after Hardfork2", op)
+		}
+	}
+	if jumpTable[CREATE2].valid {
+		t.Error("Expected unscheduled CREATE2 not to be present after Hardfork2")
+	}
+	rules.opcodes[CREATE2] = 10
+	if jumpTable = newJumpTable(rules, big.NewInt(10)); !jumpTable[CREATE2].valid {
+		t.Error("Expected scheduled CREATE2 to be present")
+	}
+	if IsSchedulable(ADD) || !IsSchedulable(RETURNDATACOPY) || !IsSchedulable(CREATE2) || !IsSchedulable(EXTCODEHASH) || !IsSchedulable(SAR) || !IsSchedulable(SELFBALANCE) {
+		t.Error("Unexpected schedulable opcodes")
+	}
+}
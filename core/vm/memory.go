@@ -16,17 +16,47 @@
 
 package vm
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
+
+// maxPooledMemory is the size of the largest memory kept for reuse. Bigger
+// ones are left to the garbage collector, so a single call expanding its
+// memory doesn't keep the buffer alive.
+const maxPooledMemory = 1024 * 1024
 
 // Memory implements a simple memory model for the ethereum virtual machine.
 type Memory struct {
 	store []byte
 }
 
+var memoryPool = sync.Pool{
+	New: func() interface{} {
+		return NewMemory()
+	},
+}
+
 func NewMemory() *Memory {
 	return &Memory{}
 }
 
+// newPooledMemory returns an empty memory from the pool, to be given back with
+// returnMemory once the call using it is over.
+func newPooledMemory() *Memory {
+	return memoryPool.Get().(*Memory)
+}
+
+// returnMemory puts m back into the pool. Neither it nor slices of its store
+// may be used afterwards.
+func returnMemory(m *Memory) {
+	if cap(m.store) > maxPooledMemory {
+		return
+	}
+	m.store = m.store[:0]
+	memoryPool.Put(m)
+}
+
 // Set sets offset + size to value
 func (m *Memory) Set(offset, size uint64, value []byte) {
 	// length of store may never be less than offset + size.
@@ -44,8 +74,18 @@ func (m *Memory) Set(offset, size uint64, value []byte) {
 
 // Resize resizes the memory to size
 func (m *Memory) Resize(size uint64) {
-	if uint64(m.Len()) < size {
-		m.store = append(m.store, make([]byte, size-uint64(m.Len()))...)
+	n := uint64(m.Len())
+	if n >= size {
+		return
+	}
+	if size > uint64(cap(m.store)) {
+		m.store = append(m.store, make([]byte, size-n)...)
+		return
+	}
+	// A reused store may hold data of a previous call past its length.
+	m.store = m.store[:size]
+	for i := n; i < size; i++ {
+		m.store[i] = 0
 	}
 }
 
@@ -0,0 +1,33 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import "time"
+
+// OpMetrics accumulates the executions of every opcode. Execution time
+// covers the instruction itself, not the gas calculation preceding it.
+type OpMetrics struct {
+	Count [256]uint64
+	Gas   [256]uint64
+	Time  [256]time.Duration
+}
+
+func (m *OpMetrics) record(op OpCode, cost uint64, elapsed time.Duration) {
+	m.Count[op]++
+	m.Gas[op] += cost
+	m.Time[op] += elapsed
+}
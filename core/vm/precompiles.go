@@ -0,0 +1,105 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+// PrecompileScheduler is implemented by rule sets adding precompiled contracts
+// to the ones of the fork, e.g. private chains with domain specific contracts.
+type PrecompileScheduler interface {
+	// Precompiles returns the contracts added at the given block, by address.
+	// They take precedence over the fork's contracts at the same address.
+	Precompiles(num *big.Int) map[common.Address]*PrecompiledAccount
+}
+
+var (
+	builtinsMu sync.RWMutex
+	builtins   = map[string]func(in []byte) ([]byte, error){
+		"ecrecover":      ecrecoverFunc,
+		"sha256":         sha256Func,
+		"ripemd160":      ripemd160Func,
+		"identity":       memCpy,
+		"modexp":         bigModExp,
+		"bn256Add":       bn256Add,
+		"bn256ScalarMul": bn256ScalarMul,
+		"bn256Pairing":   bn256Pairing,
+		"blake2f":        blake2F,
+	}
+)
+
+// RegisterBuiltin makes fn available under name to the precompiled contracts
+// configured by rule sets. Builtins must be registered before the chain
+// configuration using them is loaded, typically from an init function, and
+// can't be replaced.
+func RegisterBuiltin(name string, fn func(in []byte) ([]byte, error)) error {
+	builtinsMu.Lock()
+	defer builtinsMu.Unlock()
+
+	if _, ok := builtins[name]; ok {
+		return fmt.Errorf("builtin %q already registered", name)
+	}
+	builtins[name] = fn
+	return nil
+}
+
+// NewPrecompiledAccount creates a precompiled contract running the named
+// builtin, for a price of baseGas plus wordGas per 32 byte word of input.
+func NewPrecompiledAccount(builtin string, baseGas, wordGas uint64) (*PrecompiledAccount, error) {
+	builtinsMu.RLock()
+	fn, ok := builtins[builtin]
+	builtinsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown builtin %q", builtin)
+	}
+	gas := func(in []byte) *big.Int {
+		n := new(big.Int).SetUint64(uint64(len(in)+31) / 32)
+		n.Mul(n, new(big.Int).SetUint64(wordGas))
+		return n.Add(n, new(big.Int).SetUint64(baseGas))
+	}
+	return &PrecompiledAccount{gas, fn}, nil
+}
+
+// ActivePrecompiles returns the precompiled contracts at the given block,
+// keyed by address string.
+func ActivePrecompiles(ruleset RuleSet, num *big.Int) map[string]*PrecompiledAccount {
+	precompiles := PrecompiledPreAtlantis
+	if ruleset.IsHardfork2(num) {
+		precompiles = PrecompiledAtlantis
+	}
+	scheduler, ok := ruleset.(PrecompileScheduler)
+	if !ok {
+		return precompiles
+	}
+	added := scheduler.Precompiles(num)
+	if len(added) == 0 {
+		return precompiles
+	}
+	res := make(map[string]*PrecompiledAccount, len(precompiles)+len(added))
+	for addr, p := range precompiles {
+		res[addr] = p
+	}
+	for addr, p := range added {
+		res[addr.Str()] = p
+	}
+	return res
+}
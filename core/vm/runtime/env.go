@@ -97,21 +97,25 @@ func (self *Env) Transfer(from, to vm.Account, amount *big.Int) {
 	core.Transfer(from, to, amount)
 }
 
-func (self *Env) Call(caller vm.ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error) {
+func (self *Env) Call(caller vm.ContractRef, addr common.Address, data []byte, gas uint64, price, value *big.Int) ([]byte, uint64, error) {
 	return core.Call(self, caller, addr, data, gas, price, value)
 }
-func (self *Env) CallCode(caller vm.ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error) {
+func (self *Env) CallCode(caller vm.ContractRef, addr common.Address, data []byte, gas uint64, price, value *big.Int) ([]byte, uint64, error) {
 	return core.CallCode(self, caller, addr, data, gas, price, value)
 }
 
-func (self *Env) DelegateCall(me vm.ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error) {
+func (self *Env) DelegateCall(me vm.ContractRef, addr common.Address, data []byte, gas uint64, price *big.Int) ([]byte, uint64, error) {
 	return core.DelegateCall(self, me, addr, data, gas, price)
 }
 
-func (self *Env) StaticCall(me vm.ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error) {
+func (self *Env) StaticCall(me vm.ContractRef, addr common.Address, data []byte, gas uint64, price *big.Int) ([]byte, uint64, error) {
 	return core.StaticCall(self, me, addr, data, gas, price)
 }
 
-func (self *Env) Create(caller vm.ContractRef, data []byte, gas, price, value *big.Int) ([]byte, common.Address, error) {
+func (self *Env) Create(caller vm.ContractRef, data []byte, gas uint64, price, value *big.Int) ([]byte, common.Address, uint64, error) {
 	return core.Create(self, caller, data, gas, price, value)
 }
+
+func (self *Env) Create2(caller vm.ContractRef, data []byte, gas uint64, price, value, salt *big.Int) ([]byte, common.Address, uint64, error) {
+	return core.Create2(self, caller, data, gas, price, value, salt)
+}
@@ -18,6 +18,7 @@
 package runtime
 
 import (
+	"math"
 	"math/big"
 	"time"
 
@@ -35,14 +36,14 @@ func (ruleSet) IsHomestead(*big.Int) bool { return true }
 func (ruleSet) IsAtlantis(*big.Int) bool  { return true }
 func (ruleSet) GasTable(*big.Int) *vm.GasTable {
 	return &vm.GasTable{
-		ExtcodeSize:     big.NewInt(700),
-		ExtcodeCopy:     big.NewInt(700),
-		Balance:         big.NewInt(400),
-		SLoad:           big.NewInt(200),
-		Calls:           big.NewInt(700),
-		Suicide:         big.NewInt(5000),
-		ExpByte:         big.NewInt(10),
-		CreateBySuicide: big.NewInt(25000),
+		ExtcodeSize:     700,
+		ExtcodeCopy:     700,
+		Balance:         400,
+		SLoad:           200,
+		Calls:           700,
+		Suicide:         5000,
+		ExpByte:         10,
+		CreateBySuicide: 25000,
 	}
 }
 
@@ -121,11 +122,11 @@ func Execute(code, input []byte, cfg *Config) ([]byte, *state.StateDB, error) {
 	receiver.SetCode(crypto.Keccak256Hash(code), code)
 
 	// Call the code with the given configuration.
-	ret, err := vmenv.Call(
+	ret, _, err := vmenv.Call(
 		sender,
 		receiver.Address(),
 		input,
-		cfg.GasLimit,
+		callGas(cfg),
 		cfg.GasPrice,
 		cfg.Value,
 	)
@@ -145,14 +146,22 @@ func Call(address common.Address, input []byte, cfg *Config) ([]byte, error) {
 
 	sender := cfg.State.GetOrNewStateObject(cfg.Origin)
 	// Call the code with the given configuration.
-	ret, err := vmenv.Call(
+	ret, _, err := vmenv.Call(
 		sender,
 		address,
 		input,
-		cfg.GasLimit,
+		callGas(cfg),
 		cfg.GasPrice,
 		cfg.Value,
 	)
 
 	return ret, err
 }
+
+// callGas returns the gas limit of cfg, capped to the gas the EVM can account.
+func callGas(cfg *Config) uint64 {
+	if !cfg.GasLimit.IsUint64() {
+		return math.MaxUint64
+	}
+	return cfg.GasLimit.Uint64()
+}
@@ -19,6 +19,7 @@ package vm
 import (
 	"fmt"
 	"math/big"
+	"sync"
 )
 
 // stack is an object for basic stack operations. Items popped to the stack are
@@ -26,10 +27,32 @@ import (
 // initialised objects.
 type stack struct {
 	data []*big.Int
+	ints intPool // Integers popped by the instructions, reused for pushes
 }
 
+// stackPool keeps the stacks of finished calls, so deep call chains and
+// repeated calls reuse their buffers instead of allocating new ones.
+var stackPool = sync.Pool{
+	New: func() interface{} {
+		return &stack{data: make([]*big.Int, 0, 16)}
+	},
+}
+
+// newstack returns an empty stack, to be given back with returnStack once the
+// call using it is over.
 func newstack() *stack {
-	return &stack{}
+	return stackPool.Get().(*stack)
+}
+
+// returnStack puts st back into the pool. It mustn't be used afterwards, nor
+// the integers it held.
+func returnStack(st *stack) {
+	st.ints.put(st.data...)
+	for i := range st.data {
+		st.data[i] = nil
+	}
+	st.data = st.data[:0]
+	stackPool.Put(st)
 }
 
 func (st *stack) Data() []*big.Int {
@@ -58,7 +81,7 @@ func (st *stack) swap(n int) {
 }
 
 func (st *stack) dup(n int) {
-	st.push(new(big.Int).Set(st.data[st.len()-n]))
+	st.push(st.ints.get().Set(st.data[st.len()-n]))
 }
 
 func (st *stack) peek() *big.Int {
@@ -0,0 +1,189 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+// Tracer is notified of every step the EVM executes. Gas is the gas available
+// before the step and cost the gas it is charged. A step which fails is
+// reported with its error. The memory and stack are reused once the call is over,
+// tracers must copy what they keep of them.
+type Tracer interface {
+	CaptureState(env Environment, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack []*big.Int, contract *Contract, err error)
+}
+
+// FrameTracer is a Tracer also notified when the EVM starts and ends running
+// the code of a contract, calls to precompiles and accounts without code left
+// out. Exit is given the output, the gas the code used and the error it ended
+// with.
+type FrameTracer interface {
+	Tracer
+	CaptureEnter(env Environment, contract *Contract, input []byte)
+	CaptureExit(env Environment, output []byte, gasUsed uint64, err error)
+}
+
+// StructLog is a single EVM step, encoded in the standard JSON trace format.
+// Memory and Storage are only set by a StructLogger capturing them, Storage
+// holding the slot an SSTORE writes.
+type StructLog struct {
+	Pc         uint64
+	Op         OpCode
+	Gas        uint64
+	GasCost    uint64
+	Memory     []byte
+	MemorySize int
+	Stack      []*big.Int
+	Storage    map[common.Hash]common.Hash
+	Depth      int
+	Err        error
+}
+
+func (l *StructLog) MarshalJSON() ([]byte, error) {
+	stack := make([]string, len(l.Stack))
+	for i, v := range l.Stack {
+		stack[i] = fmt.Sprintf("%#x", v)
+	}
+	var errString string
+	if l.Err != nil {
+		errString = l.Err.Error()
+	}
+	fields := map[string]interface{}{
+		"pc":      l.Pc,
+		"op":      l.Op,
+		"opName":  l.Op.String(),
+		"gas":     fmt.Sprintf("%#x", l.Gas),
+		"gasCost": fmt.Sprintf("%#x", l.GasCost),
+		"memSize": l.MemorySize,
+		"stack":   stack,
+		"depth":   l.Depth,
+		"error":   errString,
+	}
+	if l.Memory != nil {
+		fields["memory"] = common.ToHex(l.Memory)
+	}
+	if l.Storage != nil {
+		fields["storage"] = l.Storage
+	}
+	return json.Marshal(fields)
+}
+
+// JSONLogger is a Tracer writing every step as a line of standard JSON.
+type JSONLogger struct {
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONLogger creates a tracer writing to w.
+func NewJSONLogger(w io.Writer) *JSONLogger {
+	return &JSONLogger{enc: json.NewEncoder(w)}
+}
+
+func (l *JSONLogger) CaptureState(env Environment, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack []*big.Int, contract *Contract, err error) {
+	if l.err != nil {
+		return
+	}
+	l.err = l.enc.Encode(&StructLog{
+		Pc:         pc,
+		Op:         op,
+		Gas:        gas,
+		GasCost:    cost,
+		MemorySize: memory.Len(),
+		Stack:      stack,
+		Depth:      env.Depth(),
+		Err:        err,
+	})
+}
+
+// Err returns the first error writing the trace.
+func (l *JSONLogger) Err() error {
+	return l.err
+}
+
+// LogConfig selects what a StructLogger records of every step.
+type LogConfig struct {
+	EnableMemory   bool // Record a copy of the memory
+	DisableStack   bool // Don't record the stack
+	DisableStorage bool // Don't record the slots SSTORE writes
+	Limit          int  // Maximum number of steps recorded (unlimited if 0)
+}
+
+// StructLogger is a Tracer recording every step as a StructLog, copying the
+// memory, stack and storage writes as configured.
+type StructLogger struct {
+	cfg       LogConfig
+	logs      []StructLog
+	truncated bool
+}
+
+// NewStructLogger creates a step recorder, with the default configuration if
+// cfg is nil.
+func NewStructLogger(cfg *LogConfig) *StructLogger {
+	l := new(StructLogger)
+	if cfg != nil {
+		l.cfg = *cfg
+	}
+	return l
+}
+
+func (l *StructLogger) CaptureState(env Environment, pc uint64, op OpCode, gas, cost uint64, memory *Memory, stack []*big.Int, contract *Contract, err error) {
+	if l.cfg.Limit != 0 && len(l.logs) >= l.cfg.Limit {
+		l.truncated = true
+		return
+	}
+	log := StructLog{
+		Pc:         pc,
+		Op:         op,
+		Gas:        gas,
+		GasCost:    cost,
+		MemorySize: memory.Len(),
+		Depth:      env.Depth(),
+		Err:        err,
+	}
+	if l.cfg.EnableMemory {
+		log.Memory = make([]byte, memory.Len())
+		copy(log.Memory, memory.Data())
+	}
+	if !l.cfg.DisableStack {
+		log.Stack = make([]*big.Int, len(stack))
+		for i, v := range stack {
+			log.Stack[i] = new(big.Int).Set(v)
+		}
+	}
+	if !l.cfg.DisableStorage && op == SSTORE && len(stack) >= 2 {
+		log.Storage = map[common.Hash]common.Hash{
+			common.BigToHash(stack[len(stack)-1]): common.BigToHash(stack[len(stack)-2]),
+		}
+	}
+	l.logs = append(l.logs, log)
+}
+
+// StructLogs returns the steps recorded.
+func (l *StructLogger) StructLogs() []StructLog {
+	return l.logs
+}
+
+// Truncated reports whether steps were left out for exceeding the limit.
+func (l *StructLogger) Truncated() bool {
+	return l.truncated
+}
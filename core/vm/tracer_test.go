@@ -0,0 +1,70 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+// depthEnv is an Environment only reporting its call depth.
+type depthEnv struct {
+	Environment
+	depth int
+}
+
+func (env *depthEnv) Depth() int { return env.depth }
+
+func TestStructLogger(t *testing.T) {
+	var (
+		env   = &depthEnv{depth: 1}
+		mem   = NewMemory()
+		stack = newstack()
+	)
+	mem.Resize(32)
+	stack.push(big.NewInt(2)) // value
+	stack.push(big.NewInt(1)) // key
+
+	logger := NewStructLogger(&LogConfig{EnableMemory: true, Limit: 2})
+	logger.CaptureState(env, 0, SSTORE, 100, 20, mem, stack.Data(), nil, nil)
+	stack.data[0].SetUint64(3)
+	mem.Set(0, 1, []byte{1})
+	logger.CaptureState(env, 1, STOP, 80, 0, mem, stack.Data(), nil, nil)
+	logger.CaptureState(env, 2, STOP, 80, 0, mem, stack.Data(), nil, nil)
+
+	logs := logger.StructLogs()
+	if len(logs) != 2 || !logger.Truncated() {
+		t.Fatalf("limit not enforced: %d steps, truncated %v", len(logs), logger.Truncated())
+	}
+	if logs[0].Stack[0].Uint64() != 2 || logs[0].Memory[0] != 0 {
+		t.Errorf("step not copied: stack %v, memory %x", logs[0].Stack, logs[0].Memory)
+	}
+	if want := common.BigToHash(big.NewInt(2)); logs[0].Storage[common.BigToHash(big.NewInt(1))] != want {
+		t.Errorf("storage write mismatch: have %v", logs[0].Storage)
+	}
+	if logs[1].Storage != nil || logs[1].Depth != 1 || logs[1].Memory[0] != 1 {
+		t.Errorf("second step mismatch: %+v", logs[1])
+	}
+
+	logger = NewStructLogger(&LogConfig{DisableStack: true, DisableStorage: true})
+	logger.CaptureState(env, 0, SSTORE, 100, 20, mem, stack.Data(), nil, nil)
+	if log := logger.StructLogs()[0]; log.Stack != nil || log.Memory != nil || log.Storage != nil {
+		t.Errorf("disabled capture recorded: %+v", log)
+	}
+}
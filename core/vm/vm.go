@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sync/atomic"
 	"time"
 
 	"github.com/webchain-network/webchaind/common"
@@ -32,8 +33,31 @@ var (
 	OutOfGasError          = errors.New("Out of gas")
 	CodeStoreOutOfGasError = errors.New("Contract creation code storage out of gas")
 	ErrRevert              = errors.New("Execution reverted")
+	ErrDeniedContract      = errors.New("Contract execution denied")
+	ErrCancelled           = errors.New("Execution cancelled")
 )
 
+// OutOfGasAtError is the OutOfGasError of a step of contract code, locating the
+// step that couldn't be paid for.
+type OutOfGasAtError struct {
+	Op   OpCode
+	Pc   uint64
+	Gas  uint64 // Gas left before the step
+	Cost uint64 // Gas the step costs, 0 if it doesn't fit in 64 bits
+}
+
+func (e *OutOfGasAtError) Error() string {
+	return fmt.Sprintf("%v: %v at pc %d costs %d gas, %d left", OutOfGasError, e.Op, e.Pc, e.Cost, e.Gas)
+}
+
+// IsOutOfGas reports whether err is an OutOfGasError, located or not.
+func IsOutOfGas(err error) bool {
+	if _, ok := err.(*OutOfGasAtError); ok {
+		return true
+	}
+	return err == OutOfGasError
+}
+
 // VirtualMachine is an EVM interface
 type VirtualMachine interface {
 	Run(*Contract, []byte) ([]byte, error)
@@ -45,18 +69,87 @@ type VirtualMachine interface {
 // configuration.
 type EVM struct {
 	env       Environment
+	cfg       Config
 	jumpTable vmJumpTable
 	gasTable  GasTable
 	readOnly  bool
+	tracer    Tracer
+	opMetrics *OpMetrics
+
+	precompiles map[string]*PrecompiledAccount // Precompiled contracts active at the block
+	accessList  AccessListDatabase             // Warm addresses and slots, nil before access lists
+
+	denied    map[common.Address]bool // Contracts whose code is never run
+	deniedHit *common.Address         // First denied contract execution was attempted on
+
+	abort int32 // Set to stop all executions, accessed atomically
 }
 
 // New returns a new instance of the EVM.
 func New(env Environment) *EVM {
-	return &EVM{
+	return NewWithConfig(env, Config{})
+}
+
+// NewWithConfig returns a new instance of the EVM with the given debug
+// switches.
+func NewWithConfig(env Environment, cfg Config) *EVM {
+	evm := &EVM{
 		env:       env,
+		cfg:       cfg,
 		jumpTable: newJumpTable(env.RuleSet(), env.BlockNumber()),
 		gasTable:  *env.RuleSet().GasTable(env.BlockNumber()),
+
+		precompiles: ActivePrecompiles(env.RuleSet(), env.BlockNumber()),
+		accessList:  AccessListOf(env),
+	}
+	if cfg.Trace != nil {
+		evm.tracer = NewJSONLogger(cfg.Trace)
 	}
+	return evm
+}
+
+// SetTracer sets the tracer notified of every executed step, nil to disable
+// tracing.
+func (evm *EVM) SetTracer(tracer Tracer) {
+	evm.tracer = tracer
+}
+
+// SetOpMetrics sets the metrics every executed opcode is recorded in, nil to
+// disable recording.
+func (evm *EVM) SetOpMetrics(m *OpMetrics) {
+	evm.opMetrics = m
+}
+
+// SetDenyList sets contracts whose code is refused to run, failing their calls
+// with ErrDeniedContract. It's meant for local simulations, a deny list in
+// consensus code would fork the node off the chain.
+func (evm *EVM) SetDenyList(denied map[common.Address]bool) {
+	evm.denied = denied
+}
+
+// Cancel stops the executions of the EVM, failing them with ErrCancelled at
+// their next step. It's safe to call concurrently with them.
+func (evm *EVM) Cancel() {
+	atomic.StoreInt32(&evm.abort, 1)
+}
+
+// Cancelled reports whether the executions of the EVM were cancelled.
+func (evm *EVM) Cancelled() bool {
+	return atomic.LoadInt32(&evm.abort) != 0
+}
+
+// IsPrecompiled returns whether addr is a precompiled contract at the block
+// the EVM runs on.
+func (evm *EVM) IsPrecompiled(addr common.Address) bool {
+	return evm.precompiles[addr.Str()] != nil
+}
+
+// DeniedContract returns the first denied contract a call was made to, if any.
+func (evm *EVM) DeniedContract() (common.Address, bool) {
+	if evm.deniedHit == nil {
+		return common.Address{}, false
+	}
+	return *evm.deniedHit, true
 }
 
 // Run loops and evaluates the contract's code with the given input data
@@ -76,22 +169,25 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 	evm.env.SetReturnData(nil)
 
 	if contract.CodeAddr != nil {
-		if evm.env.RuleSet().IsHardfork2(evm.env.BlockNumber()) {
-			if p := PrecompiledAtlantis[contract.CodeAddr.Str()]; p != nil {
-				return evm.RunPrecompiled(p, input, contract)
-			}
-		} else {
-			if p := PrecompiledPreAtlantis[contract.CodeAddr.Str()]; p != nil {
-				return evm.RunPrecompiled(p, input, contract)
-			}
+		if p := evm.precompiles[contract.CodeAddr.Str()]; p != nil {
+			return evm.RunPrecompiled(p, input, contract)
 		}
-
 	}
 
 	// Don't bother with the execution if there's no code.
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
+	if evm.cfg.NoRecursion && evm.env.Depth() > 1 {
+		return nil, nil
+	}
+	if contract.CodeAddr != nil && evm.denied[*contract.CodeAddr] {
+		if evm.deniedHit == nil {
+			addr := *contract.CodeAddr
+			evm.deniedHit = &addr
+		}
+		return nil, ErrDeniedContract
+	}
 
 	codehash := contract.CodeHash // codehash is used when doing jump dest caching
 	if codehash == (common.Hash{}) {
@@ -102,21 +198,30 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 		caller     = contract.caller
 		instrCount = 0
 
-		isHardfork2 = evm.env.RuleSet().IsHardfork2(evm.env.BlockNumber())
-
-		op      OpCode         // current opcode
-		mem     = NewMemory()  // bound memory
-		stack   = newstack()   // local stack
-		statedb = evm.env.Db() // current state
+		op      OpCode              // current opcode
+		mem     = newPooledMemory() // bound memory
+		stack   = newstack()        // local stack
+		statedb = evm.env.Db()      // current state
 		// For optimisation reason we're using uint64 as the program counter.
 		// It's theoretically possible to go above 2^64. The YP defines the PC to be uint256. Practically much less so feasible.
 		pc = uint64(0) // program counter
 
-		newMemSize *big.Int
-		cost       *big.Int
+		newMemSize uint64
+		cost       uint64
 	)
 	contract.Input = input
 
+	defer func() {
+		returnMemory(mem)
+		returnStack(stack)
+	}()
+	// Exiting frames are reported before their memory and stack are reused.
+	if tracer, ok := evm.tracer.(FrameTracer); ok {
+		gas := contract.Gas
+		tracer.CaptureEnter(evm.env, contract, input)
+		defer func() { tracer.CaptureExit(evm.env, ret, gas-contract.Gas, err) }()
+	}
+
 	if glog.V(logger.Debug) {
 		glog.Infof("running byte VM %x\n", codehash[:4])
 		tstart := time.Now()
@@ -126,40 +231,66 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 	}
 
 	for ; ; instrCount++ {
+		if atomic.LoadInt32(&evm.abort) != 0 {
+			return nil, ErrCancelled
+		}
 		// Get the memory location of pc
 		op = contract.GetOp(pc)
 		operation := evm.jumpTable[op]
 		// calculate the new memory size and gas price for the current executing opcode
-		newMemSize, cost, err = calculateGasAndSize(&evm.gasTable, evm.env, contract, caller, op, statedb, mem, stack)
+		newMemSize, cost, err = calculateGasAndSize(&evm.gasTable, evm.accessList, evm.env, contract, caller, op, statedb, mem, stack)
+		if err == OutOfGasError {
+			err = &OutOfGasAtError{Op: op, Pc: pc, Gas: contract.Gas}
+		}
 		if err != nil {
+			if evm.tracer != nil {
+				evm.tracer.CaptureState(evm.env, pc, op, contract.Gas, 0, mem, stack.Data(), contract, err)
+			}
 			return nil, err
 		}
 
-		// If the operation is valid, enforce and write restrictions
-		if evm.readOnly && isHardfork2 {
-			// If the interpreter is operating in readonly mode, make sure no
-			// state-modifying operation is performed. The 3rd stack item
-			// for a call operation is the value. Transferring value from one
-			// account to the others means the state is modified and should also
-			// return with an error.
-			if operation.writes || (op == CALL && stack.back(2).Sign() != 0) {
-				return nil, errWriteProtection
+		charge := cost
+		if evm.cfg.DisableGasMetering {
+			// Only the gas handed to calls is charged, they give back what's left.
+			charge = 0
+			switch op {
+			case CALL, CALLCODE, DELEGATECALL, STATICCALL:
+				charge = stack.back(0).Uint64()
 			}
 		}
-
-		// Use the calculated gas. When insufficient gas is present, use all gas and return an
-		// Out Of Gas error
-		if !contract.UseGas(cost) {
-			return nil, OutOfGasError
+		switch {
+		// If the operation is valid, enforce and write restrictions. Only
+		// STATICCALL enters read-only mode, whichever fork enabled it.
+		// Transferring value from one account to the others means the
+		// state is modified and should also return with an error.
+		case evm.readOnly && (operation.writes || (op == CALL && stack.back(2).Sign() != 0)):
+			err = errWriteProtection
+		case charge > contract.Gas:
+			err = &OutOfGasAtError{Op: op, Pc: pc, Gas: contract.Gas, Cost: charge}
+		}
+		if evm.tracer != nil {
+			// The step is reported before it executes.
+			evm.tracer.CaptureState(evm.env, pc, op, contract.Gas, cost, mem, stack.Data(), contract, err)
+		}
+		if err != nil {
+			return nil, err
 		}
+		contract.UseGas(charge)
 
 		// Resize the memory calculated previously
-		mem.Resize(newMemSize.Uint64())
+		mem.Resize(newMemSize)
 		if !operation.valid {
 			return nil, fmt.Errorf("Invalid opcode %x", op)
 		}
 
+		var opStart time.Time
+		if evm.opMetrics != nil {
+			opStart = time.Now()
+		}
 		res, err := operation.fn(&pc, evm.env, contract, mem, stack)
+		if evm.opMetrics != nil {
+			evm.opMetrics.record(op, cost, time.Since(opStart))
+		}
 
 		if operation.returns {
 			evm.env.SetReturnData(res)
@@ -179,16 +310,18 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 }
 
 // calculateGasAndSize calculates the required given the opcode and stack items calculates the new memorysize for
-// the operation. This does not reduce gas or resizes the memory.
-func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract, caller ContractRef, op OpCode, statedb Database, mem *Memory, stack *stack) (*big.Int, *big.Int, error) {
+// the operation. This does not reduce gas or resizes the memory. Costs which
+// don't fit in 64 bits fail with OutOfGasError, as they could never be paid.
+// The accounts and slots accessed are warmed up in al, if set.
+func calculateGasAndSize(gasTable *GasTable, al AccessListDatabase, env Environment, contract *Contract, caller ContractRef, op OpCode, statedb Database, mem *Memory, stack *stack) (uint64, uint64, error) {
 	var (
-		gas                  = new(big.Int)
-		newMemSize  *big.Int = new(big.Int)
-		isHardfork2          = env.RuleSet().IsHardfork2(env.BlockNumber())
+		newMemSize  *big.Int
+		dataGas, cg uint64 // gas of the data copied, hashed or logged, gas given to a call
+		isHardfork2 = env.RuleSet().IsHardfork2(env.BlockNumber())
 	)
-	err := baseCheck(op, stack, gas)
+	gas, err := baseCheck(op, stack)
 	if err != nil {
-		return nil, nil, err
+		return 0, 0, err
 	}
 
 	// stack Check, memory resize & gas phase
@@ -196,224 +329,277 @@ func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract
 	case RETURNDATACOPY:
 		newMemSize = calcMemSize(stack.back(0), stack.back(2))
 
-		words := toWordSize(stack.back(2))
-		gas.Add(gas, GasFastestStep)
-		gas.Add(gas, words.Mul(words, big.NewInt(3)))
+		dataGas, err = wordGas(stack.back(2), 3)
+		if err != nil {
+			return 0, 0, err
+		}
+		gas += GasFastestStep + dataGas
 
-		quadMemGas(mem, newMemSize, gas)
+		gas, err = quadMemGas(mem, newMemSize, gas)
 	case REVERT:
 		newMemSize = calcMemSize(stack.back(0), stack.back(1))
-		quadMemGas(mem, newMemSize, gas)
+		gas, err = quadMemGas(mem, newMemSize, gas)
 	case SUICIDE:
 		address := common.BigToAddress(stack.back(0))
 		// if suicide is not nil: homestead gas fork
-		if gasTable.CreateBySuicide != nil {
-			gas.Set(gasTable.Suicide)
+		if gasTable.CreateBySuicide > 0 {
+			gas = gasTable.Suicide
 			if isHardfork2 {
 				if env.Db().Empty(address) && env.Db().GetBalance(contract.Address()).Sign() != 0 {
-					gas.Add(gas, gasTable.CreateBySuicide)
+					gas += gasTable.CreateBySuicide
 				}
 			} else if !env.Db().Exist(address) {
-				gas.Add(gas, gasTable.CreateBySuicide)
+				gas += gasTable.CreateBySuicide
 			}
 		}
+		if al != nil && !al.AddressInAccessList(address) {
+			al.AddAddressToAccessList(address)
+			gas += ColdAccountAccessCost
+		}
 
 		if !statedb.HasSuicided(contract.Address()) {
-			statedb.AddRefund(big.NewInt(24000))
+			if gasTable.SuicideRefund != nil {
+				statedb.AddRefund(gasTable.SuicideRefund)
+			} else {
+				statedb.AddRefund(GasSuicideRefund)
+			}
 		}
 	case EXTCODESIZE:
-		gas.Set(gasTable.ExtcodeSize)
+		gas = gasTable.ExtcodeSize
+		if al != nil {
+			gas = accountAccessGas(al, common.BigToAddress(stack.back(0)))
+		}
 	case BALANCE:
-		gas.Set(gasTable.Balance)
+		gas = gasTable.Balance
+		if al != nil {
+			gas = accountAccessGas(al, common.BigToAddress(stack.back(0)))
+		}
+	case EXTCODEHASH:
+		if al != nil {
+			gas = accountAccessGas(al, common.BigToAddress(stack.back(0)))
+		}
 	case SLOAD:
-		gas.Set(gasTable.SLoad)
+		gas = gasTable.SLoad
+		if al != nil {
+			if gas = coldSlotGas(al, contract.Address(), common.BigToHash(stack.back(0))); gas == 0 {
+				gas = WarmStorageReadCost
+			}
+		}
 	case SWAP1, SWAP2, SWAP3, SWAP4, SWAP5, SWAP6, SWAP7, SWAP8, SWAP9, SWAP10, SWAP11, SWAP12, SWAP13, SWAP14, SWAP15, SWAP16:
 		n := int(op - SWAP1 + 2)
-		err := stack.require(n)
+		err = stack.require(n)
 		if err != nil {
-			return nil, nil, err
+			return 0, 0, err
 		}
-		gas.Set(GasFastestStep)
+		gas = GasFastestStep
 	case DUP1, DUP2, DUP3, DUP4, DUP5, DUP6, DUP7, DUP8, DUP9, DUP10, DUP11, DUP12, DUP13, DUP14, DUP15, DUP16:
 		n := int(op - DUP1 + 1)
-		err := stack.require(n)
+		err = stack.require(n)
 		if err != nil {
-			return nil, nil, err
+			return 0, 0, err
 		}
-		gas.Set(GasFastestStep)
+		gas = GasFastestStep
 	case LOG0, LOG1, LOG2, LOG3, LOG4:
 		n := int(op - LOG0)
-		err := stack.require(n + 2)
+		err = stack.require(n + 2)
 		if err != nil {
-			return nil, nil, err
+			return 0, 0, err
 		}
 
 		mSize, mStart := stack.back(1), stack.back(0)
-
-		// log gas
-		gas.Add(gas, big.NewInt(375))
-		// log topic gass
-		gas.Add(gas, new(big.Int).Mul(big.NewInt(int64(n)), big.NewInt(375)))
-		// log data gass
-		gas.Add(gas, new(big.Int).Mul(mSize, big.NewInt(8)))
+		if !mSize.IsUint64() {
+			return 0, 0, OutOfGasError
+		}
+		// log gas and log topic gas
+		gas += 375 + uint64(n)*375
+		// log data gas
+		if dataGas, err = safeMul(mSize.Uint64(), 8); err != nil {
+			return 0, 0, err
+		}
+		if gas, err = safeAdd(gas, dataGas); err != nil {
+			return 0, 0, err
+		}
 
 		newMemSize = calcMemSize(mStart, mSize)
 
-		quadMemGas(mem, newMemSize, gas)
+		gas, err = quadMemGas(mem, newMemSize, gas)
 	case EXP:
-		expByteLen := int64(len(stack.back(1).Bytes()))
-		gas.Add(gas, new(big.Int).Mul(big.NewInt(expByteLen), gasTable.ExpByte))
+		expByteLen := uint64(len(stack.back(1).Bytes()))
+		gas += expByteLen * gasTable.ExpByte
 	case SSTORE:
-		err := stack.require(2)
+		err = stack.require(2)
 		if err != nil {
-			return nil, nil, err
+			return 0, 0, err
 		}
 
-		var g *big.Int
 		y, x := stack.back(1), stack.back(0)
 		val := statedb.GetState(contract.Address(), common.BigToHash(x))
 
+		// Cold slots cost the SLOAD surcharge, which is taken off
+		// the cost of changing them.
+		var coldGas, resetGas uint64 = 0, 5000
+		if al != nil {
+			coldGas = coldSlotGas(al, contract.Address(), common.BigToHash(x))
+			resetGas -= ColdSloadCost
+		}
+
 		// This checks for 3 scenario's and calculates gas accordingly
 		// 1. From a zero-value address to a non-zero value         (NEW VALUE)
 		// 2. From a non-zero value address to a zero-value address (DELETE)
 		// 3. From a non-zero to a non-zero                         (CHANGE)
 		if common.EmptyHash(val) && !common.EmptyHash(common.BigToHash(y)) {
 			// 0 => non 0
-			g = big.NewInt(20000) // Once per SLOAD operation.
+			gas = 20000 // Once per SLOAD operation.
 		} else if !common.EmptyHash(val) && common.EmptyHash(common.BigToHash(y)) {
 			statedb.AddRefund(big.NewInt(15000))
-			g = big.NewInt(5000)
+			gas = resetGas
 		} else {
 			// non 0 => non 0 (or 0 => 0)
-			g = big.NewInt(5000)
+			gas = resetGas
 		}
-		gas.Set(g)
+		gas += coldGas
 
 	case MLOAD:
 		newMemSize = calcMemSize(stack.back(0), u256(32))
-		quadMemGas(mem, newMemSize, gas)
+		gas, err = quadMemGas(mem, newMemSize, gas)
 	case MSTORE8:
 		newMemSize = calcMemSize(stack.back(0), u256(1))
-		quadMemGas(mem, newMemSize, gas)
+		gas, err = quadMemGas(mem, newMemSize, gas)
 	case MSTORE:
 		newMemSize = calcMemSize(stack.back(0), u256(32))
-		quadMemGas(mem, newMemSize, gas)
+		gas, err = quadMemGas(mem, newMemSize, gas)
 	case RETURN:
 		newMemSize = calcMemSize(stack.back(0), stack.back(1))
-		quadMemGas(mem, newMemSize, gas)
+		gas, err = quadMemGas(mem, newMemSize, gas)
 	case SHA3:
 		newMemSize = calcMemSize(stack.back(0), stack.back(1))
 
-		words := toWordSize(stack.back(1))
-		gas.Add(gas, words.Mul(words, big.NewInt(6)))
-
-		quadMemGas(mem, newMemSize, gas)
-	case CALLDATACOPY:
-		newMemSize = calcMemSize(stack.back(0), stack.back(2))
-
-		words := toWordSize(stack.back(2))
-		gas.Add(gas, words.Mul(words, big.NewInt(3)))
+		dataGas, err = wordGas(stack.back(1), 6)
+		if err != nil {
+			return 0, 0, err
+		}
+		gas += dataGas
 
-		quadMemGas(mem, newMemSize, gas)
-	case CODECOPY:
+		gas, err = quadMemGas(mem, newMemSize, gas)
+	case CALLDATACOPY, CODECOPY:
 		newMemSize = calcMemSize(stack.back(0), stack.back(2))
 
-		words := toWordSize(stack.back(2))
-		gas.Add(gas, words.Mul(words, big.NewInt(3)))
+		dataGas, err = wordGas(stack.back(2), 3)
+		if err != nil {
+			return 0, 0, err
+		}
+		gas += dataGas
 
-		quadMemGas(mem, newMemSize, gas)
+		gas, err = quadMemGas(mem, newMemSize, gas)
 	case EXTCODECOPY:
-		gas.Set(gasTable.ExtcodeCopy)
-
 		newMemSize = calcMemSize(stack.back(1), stack.back(3))
 
-		words := toWordSize(stack.back(3))
-		gas.Add(gas, words.Mul(words, big.NewInt(3)))
+		dataGas, err = wordGas(stack.back(3), 3)
+		if err != nil {
+			return 0, 0, err
+		}
+		if al != nil {
+			gas = accountAccessGas(al, common.BigToAddress(stack.back(0))) + dataGas
+		} else {
+			gas = gasTable.ExtcodeCopy + dataGas
+		}
 
-		quadMemGas(mem, newMemSize, gas)
+		gas, err = quadMemGas(mem, newMemSize, gas)
 	case CREATE:
 		newMemSize = calcMemSize(stack.back(1), stack.back(2))
 
-		quadMemGas(mem, newMemSize, gas)
+		gas, err = quadMemGas(mem, newMemSize, gas)
+	case CREATE2:
+		newMemSize = calcMemSize(stack.back(1), stack.back(2))
+
+		// the init code is hashed to derive the address
+		dataGas, err = wordGas(stack.back(2), 6)
+		if err != nil {
+			return 0, 0, err
+		}
+		gas += dataGas
+
+		gas, err = quadMemGas(mem, newMemSize, gas)
 	case CALL, CALLCODE:
-		gas.Set(gasTable.Calls)
+		gas = gasTable.Calls
+		if al != nil {
+			gas = accountAccessGas(al, common.BigToAddress(stack.back(1)))
+		}
 
 		if op == CALL {
 			address := common.BigToAddress(stack.back(1))
 			transfersValue := stack.back(2).Sign() != 0
 			if isHardfork2 {
 				if transfersValue && env.Db().Empty(address) {
-					gas.Add(gas, big.NewInt(25000))
+					gas += 25000
 				}
 			} else if !env.Db().Exist(address) {
-				gas.Add(gas, big.NewInt(25000))
+				gas += 25000
 			}
 		}
 		if len(stack.back(2).Bytes()) > 0 {
-			gas.Add(gas, big.NewInt(9000))
+			gas += 9000
 		}
 		x := calcMemSize(stack.back(5), stack.back(6))
 		y := calcMemSize(stack.back(3), stack.back(4))
 
 		newMemSize = common.BigMax(x, y)
 
-		quadMemGas(mem, newMemSize, gas)
-
-		cg := callGas(gasTable, contract.Gas, gas, stack.back(0))
+		if gas, err = quadMemGas(mem, newMemSize, gas); err != nil {
+			return 0, 0, err
+		}
+		cg, err = callGas(gasTable, contract.Gas, gas, stack.back(0))
+		if err != nil {
+			return 0, 0, err
+		}
 		// Replace the stack item with the new gas calculation. This means that
 		// either the original item is left on the stack or the item is replaced by:
 		// (availableGas - gas) * 63 / 64
 		// We replace the stack item so that it's available when the opCall instruction is
 		// called. This information is otherwise lost due to the dependency on *current*
 		// available gas.
-		stack.data[stack.len()-1] = cg
-		gas.Add(gas, cg)
-
-	case DELEGATECALL:
-		gas.Set(gasTable.Calls)
+		stack.data[stack.len()-1] = new(big.Int).SetUint64(cg)
+		gas, err = safeAdd(gas, cg)
 
-		x := calcMemSize(stack.back(4), stack.back(5))
-		y := calcMemSize(stack.back(2), stack.back(3))
-
-		newMemSize = common.BigMax(x, y)
-
-		quadMemGas(mem, newMemSize, gas)
-
-		cg := callGas(gasTable, contract.Gas, gas, stack.back(0))
-		// Replace the stack item with the new gas calculation. This means that
-		// either the original item is left on the stack or the item is replaced by:
-		// (availableGas - gas) * 63 / 64
-		// We replace the stack item so that it's available when the opCall instruction is
-		// called.
-		stack.data[stack.len()-1] = cg
-		gas.Add(gas, cg)
-	case STATICCALL:
-		gas.Set(gasTable.Calls)
+	case DELEGATECALL, STATICCALL:
+		gas = gasTable.Calls
+		if al != nil {
+			gas = accountAccessGas(al, common.BigToAddress(stack.back(1)))
+		}
 
 		x := calcMemSize(stack.back(4), stack.back(5))
 		y := calcMemSize(stack.back(2), stack.back(3))
 
 		newMemSize = common.BigMax(x, y)
 
-		quadMemGas(mem, newMemSize, gas)
-
-		cg := callGas(gasTable, contract.Gas, gas, stack.back(0))
+		if gas, err = quadMemGas(mem, newMemSize, gas); err != nil {
+			return 0, 0, err
+		}
+		cg, err = callGas(gasTable, contract.Gas, gas, stack.back(0))
+		if err != nil {
+			return 0, 0, err
+		}
 		// Replace the stack item with the new gas calculation. This means that
 		// either the original item is left on the stack or the item is replaced by:
 		// (availableGas - gas) * 63 / 64
 		// We replace the stack item so that it's available when the opCall instruction is
 		// called.
-		stack.data[stack.len()-1] = cg
-		gas.Add(gas, cg)
+		stack.data[stack.len()-1] = new(big.Int).SetUint64(cg)
+		gas, err = safeAdd(gas, cg)
 	}
-
-	return newMemSize, gas, nil
+	if err != nil {
+		return 0, 0, err
+	}
+	if newMemSize == nil {
+		return 0, gas, nil
+	}
+	return newMemSize.Uint64(), gas, nil
 }
 
 // RunPrecompile runs and evaluate the output of a precompiled contract defined in contracts.go
 func (evm *EVM) RunPrecompiled(p *PrecompiledAccount, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.Gas(input)
-	if contract.UseGas(gas) {
+	if gas.IsUint64() && contract.UseGas(gas.Uint64()) {
 		return p.Call(input)
 	} else {
 		return nil, OutOfGasError
@@ -0,0 +1,29 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import "bytes"
+
+// WasmMagic starts the code of WebAssembly contracts, the magic number of
+// WebAssembly modules. As it starts with STOP, no EVM code doing anything
+// starts with it.
+var WasmMagic = []byte{0x00, 'a', 's', 'm'}
+
+// IsWasm reports whether code is the code of a WebAssembly contract.
+func IsWasm(code []byte) bool {
+	return bytes.HasPrefix(code, WasmMagic)
+}
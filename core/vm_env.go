@@ -40,13 +40,41 @@ func GetHashFn(ref common.Hash, chain *BlockChain) func(n uint64) common.Hash {
 	}
 }
 
+// externalVM creates the VM running contract code instead of the built-in
+// interpreter, if set.
+var externalVM func(env vm.Environment, evm *vm.EVM) vm.Vm
+
+// SetExternalVM makes new environments execute contract code with the Vm
+// created by factory, e.g. an EVMC implementation. The built-in interpreter is
+// passed along for the precompiled contracts and other executions the
+// external VM leaves to it. A nil factory restores the built-in interpreter.
+func SetExternalVM(factory func(env vm.Environment, evm *vm.EVM) vm.Vm) {
+	externalVM = factory
+}
+
+// wasmVM creates the VM running WebAssembly contracts, if set.
+var wasmVM func(env vm.Environment, evm *vm.EVM) vm.Vm
+
+// SetWasmVM makes new environments execute the contracts whose code starts
+// with vm.WasmMagic with the Vm created by factory, e.g. an EVMC ewasm
+// implementation, and the others as before. This is experimental and meant
+// for private networks: the code of such contracts changes meaning, and they
+// are neither traced nor checked against deny lists. A nil factory disables
+// WebAssembly contracts.
+func SetWasmVM(factory func(env vm.Environment, evm *vm.EVM) vm.Vm) {
+	wasmVM = factory
+}
+
 type VMEnv struct {
 	chainConfig *ChainConfig   // Chain configuration
 	state       *state.StateDB // State to use for executing
 	evm         *vm.EVM        // The Ethereum Virtual Machine
+	vm          vm.Vm          // The VM running contract code, evm unless external
+	wasm        vm.Vm          // The VM running WebAssembly contracts, if enabled
 	depth       int            // Current execution depth
 	returnData  []byte
 	msg         Message // Message applied
+	err         error   // Error the outermost execution ended with
 
 	header    *types.Header            // Header information
 	chain     *BlockChain              // Blockchain handle
@@ -64,17 +92,100 @@ func NewEnv(state *state.StateDB, chainConfig *ChainConfig, chain *BlockChain, m
 	}
 
 	env.evm = vm.New(env)
+	env.vm = env.evm
+	if externalVM != nil {
+		env.vm = externalVM(env, env.evm)
+	}
+	if wasmVM != nil {
+		env.wasm = wasmVM(env, env.evm)
+	}
 	return env
 }
 
+// wasmSwitch runs WebAssembly contracts in the wasm VM of an environment, and
+// the other contracts in its VM.
+type wasmSwitch struct {
+	env *VMEnv
+}
+
+func (s wasmSwitch) Run(contract *vm.Contract, input []byte, readOnly bool) ([]byte, error) {
+	if vm.IsWasm(contract.Code) {
+		return s.env.wasm.Run(contract, input, readOnly)
+	}
+	return s.env.vm.Run(contract, input, readOnly)
+}
+
+// SetVmConfig replaces the EVM with one running with the debug switches of
+// cfg, discarding its tracer and deny list. The environment switches back to
+// the built-in interpreter.
+func (self *VMEnv) SetVmConfig(cfg vm.Config) {
+	self.evm = vm.NewWithConfig(self, cfg)
+	self.vm = self.evm
+}
+
+// SetTracer sets the tracer notified of every step executed by the EVM. The
+// environment switches back to the built-in interpreter to be traced.
+func (self *VMEnv) SetTracer(tracer vm.Tracer) {
+	self.evm.SetTracer(tracer)
+	self.vm = self.evm
+}
+
+// SetOpMetrics sets the metrics the opcodes executed by the EVM are recorded
+// in. Opcodes run by an external VM aren't recorded.
+func (self *VMEnv) SetOpMetrics(m *vm.OpMetrics) {
+	self.evm.SetOpMetrics(m)
+}
+
+// SetDenyList sets the contracts whose code the EVM refuses to run. Like
+// tracing, a deny list requires the built-in interpreter.
+func (self *VMEnv) SetDenyList(denied map[common.Address]bool) {
+	self.evm.SetDenyList(denied)
+	if len(denied) > 0 {
+		self.vm = self.evm
+	}
+}
+
+// Cancel stops the executions of the environment, failing them with
+// vm.ErrCancelled. Executions of an external VM can't be cancelled. It's safe
+// to call concurrently with them.
+func (self *VMEnv) Cancel() {
+	self.evm.Cancel()
+}
+
+// Cancelled reports whether the executions of the environment were cancelled.
+func (self *VMEnv) Cancelled() bool {
+	return self.evm.Cancelled()
+}
+
+// Err returns the error the last outermost call or contract creation of the
+// environment ended with, e.g. an *vm.OutOfGasAtError locating the step that
+// ran out of gas.
+func (self *VMEnv) Err() error {
+	return self.err
+}
+
+// DeniedContract returns the first denied contract execution reached, if any.
+func (self *VMEnv) DeniedContract() (common.Address, bool) {
+	return self.evm.DeniedContract()
+}
+
+// Vm returns the VM running contract code, switching to the wasm VM for
+// WebAssembly contracts if enabled.
+func (self *VMEnv) Vm() vm.Vm {
+	if self.wasm != nil {
+		return wasmSwitch{self}
+	}
+	return self.vm
+}
+
 func (self *VMEnv) RuleSet() vm.RuleSet       { return self.chainConfig }
-func (self *VMEnv) Vm() vm.Vm                 { return self.evm }
 func (self *VMEnv) Origin() common.Address    { f, _ := self.msg.From(); return f }
 func (self *VMEnv) BlockNumber() *big.Int     { return self.header.Number }
 func (self *VMEnv) Coinbase() common.Address  { return self.header.Coinbase }
 func (self *VMEnv) Time() *big.Int            { return self.header.Time }
 func (self *VMEnv) Difficulty() *big.Int      { return self.header.Difficulty }
 func (self *VMEnv) GasLimit() *big.Int        { return self.header.GasLimit }
+func (self *VMEnv) BaseFee() *big.Int         { return self.header.BaseFee }
 func (self *VMEnv) Value() *big.Int           { return self.msg.Value() }
 func (self *VMEnv) Db() vm.Database           { return self.state }
 func (self *VMEnv) Depth() int                { return self.depth }
@@ -104,21 +215,33 @@ func (self *VMEnv) Transfer(from, to vm.Account, amount *big.Int) {
 	Transfer(from, to, amount)
 }
 
-func (self *VMEnv) Call(me vm.ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error) {
-	return Call(self, me, addr, data, gas, price, value)
+func (self *VMEnv) Call(me vm.ContractRef, addr common.Address, data []byte, gas uint64, price, value *big.Int) ([]byte, uint64, error) {
+	ret, gas, err := Call(self, me, addr, data, gas, price, value)
+	if self.depth == 0 {
+		self.err = err
+	}
+	return ret, gas, err
 }
-func (self *VMEnv) CallCode(me vm.ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error) {
+func (self *VMEnv) CallCode(me vm.ContractRef, addr common.Address, data []byte, gas uint64, price, value *big.Int) ([]byte, uint64, error) {
 	return CallCode(self, me, addr, data, gas, price, value)
 }
 
-func (self *VMEnv) DelegateCall(me vm.ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error) {
+func (self *VMEnv) DelegateCall(me vm.ContractRef, addr common.Address, data []byte, gas uint64, price *big.Int) ([]byte, uint64, error) {
 	return DelegateCall(self, me, addr, data, gas, price)
 }
 
-func (self *VMEnv) StaticCall(me vm.ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error) {
+func (self *VMEnv) StaticCall(me vm.ContractRef, addr common.Address, data []byte, gas uint64, price *big.Int) ([]byte, uint64, error) {
 	return StaticCall(self, me, addr, data, gas, price)
 }
 
-func (self *VMEnv) Create(me vm.ContractRef, data []byte, gas, price, value *big.Int) ([]byte, common.Address, error) {
-	return Create(self, me, data, gas, price, value)
+func (self *VMEnv) Create(me vm.ContractRef, data []byte, gas uint64, price, value *big.Int) ([]byte, common.Address, uint64, error) {
+	ret, addr, gas, err := Create(self, me, data, gas, price, value)
+	if self.depth == 0 {
+		self.err = err
+	}
+	return ret, addr, gas, err
+}
+
+func (self *VMEnv) Create2(me vm.ContractRef, data []byte, gas uint64, price, value, salt *big.Int) ([]byte, common.Address, uint64, error) {
+	return Create2(self, me, data, gas, price, value, salt)
 }
@@ -0,0 +1,115 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/core/vm"
+)
+
+// accessListTracer is a vm.Tracer recording the accounts and storage slots an
+// execution accesses, building the access list (EIP-2930) of a transaction
+// running it. Accounts warm without being listed, the sender, recipient and
+// precompiled contracts, are left out unless their slots are accessed.
+type accessListTracer struct {
+	excluded map[common.Address]bool
+	slots    map[common.Address]map[common.Hash]bool
+	list     types.AccessList // In order of first access
+}
+
+// newAccessListTracer creates a tracer starting from the access list al.
+func newAccessListTracer(al types.AccessList, excluded map[common.Address]bool) *accessListTracer {
+	t := &accessListTracer{
+		excluded: excluded,
+		slots:    make(map[common.Address]map[common.Hash]bool),
+	}
+	for _, tuple := range al {
+		t.addAddress(tuple.Address)
+		for _, slot := range tuple.StorageKeys {
+			t.addSlot(tuple.Address, slot)
+		}
+	}
+	return t
+}
+
+func (t *accessListTracer) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack []*big.Int, contract *vm.Contract, err error) {
+	n := len(stack)
+	switch {
+	case (op == vm.SLOAD || op == vm.SSTORE) && n >= 1:
+		t.addSlot(contract.Address(), common.BigToHash(stack[n-1]))
+	case (op == vm.EXTCODECOPY || op == vm.EXTCODEHASH || op == vm.EXTCODESIZE || op == vm.BALANCE || op == vm.SUICIDE) && n >= 1:
+		if addr := common.BigToAddress(stack[n-1]); !t.excluded[addr] {
+			t.addAddress(addr)
+		}
+	case (op == vm.CALL || op == vm.CALLCODE || op == vm.DELEGATECALL || op == vm.STATICCALL) && n >= 2:
+		if addr := common.BigToAddress(stack[n-2]); !t.excluded[addr] {
+			t.addAddress(addr)
+		}
+	}
+}
+
+// addAddress lists addr, if it isn't yet.
+func (t *accessListTracer) addAddress(addr common.Address) {
+	if _, ok := t.slots[addr]; ok {
+		return
+	}
+	t.slots[addr] = make(map[common.Hash]bool)
+	t.list = append(t.list, types.AccessTuple{Address: addr, StorageKeys: []common.Hash{}})
+}
+
+// addSlot lists slot of addr, and addr, if they aren't yet.
+func (t *accessListTracer) addSlot(addr common.Address, slot common.Hash) {
+	t.addAddress(addr)
+	if t.slots[addr][slot] {
+		return
+	}
+	t.slots[addr][slot] = true
+	for i := range t.list {
+		if t.list[i].Address == addr {
+			t.list[i].StorageKeys = append(t.list[i].StorageKeys, slot)
+			return
+		}
+	}
+}
+
+// AccessList returns the access list recorded.
+func (t *accessListTracer) AccessList() types.AccessList {
+	return t.list
+}
+
+// equal returns whether al lists the same accounts and slots as the tracer.
+func (t *accessListTracer) equal(al types.AccessList) bool {
+	other := newAccessListTracer(al, nil)
+	if len(other.slots) != len(t.slots) {
+		return false
+	}
+	for addr, slots := range t.slots {
+		otherSlots, ok := other.slots[addr]
+		if !ok || len(otherSlots) != len(slots) {
+			return false
+		}
+		for slot := range slots {
+			if !otherSlots[slot] {
+				return false
+			}
+		}
+	}
+	return true
+}
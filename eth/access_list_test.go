@@ -0,0 +1,51 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/rpc"
+)
+
+func TestCreateAccessList(t *testing.T) {
+	// PUSH1 5 SLOAD POP PUSH2 0xbeef BALANCE POP PUSH1 1 BALANCE POP STOP
+	api, to := newCallTestAPI(t, []byte{0x60, 0x05, 0x54, 0x50, 0x61, 0xbe, 0xef, 0x31, 0x50, 0x60, 0x01, 0x31, 0x50, 0x00})
+
+	latest := rpc.LatestBlockNumber
+	args := CallArgs{From: testBank.Address, To: &to, Gas: rpc.NewHexNumber(100000), GasPrice: rpc.NewHexNumber(1)}
+	res, err := api.CreateAccessList(context.Background(), args, &latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The precompile at 0x01 and the recipient are left out, bar its slots
+	want := types.AccessList{
+		{Address: to, StorageKeys: []common.Hash{common.BigToHash(big.NewInt(5))}},
+		{Address: common.HexToAddress("0xbeef"), StorageKeys: []common.Hash{}},
+	}
+	tracer := newAccessListTracer(res.AccessList, nil)
+	if len(res.AccessList) != len(want) || !tracer.equal(want) {
+		t.Errorf("access list mismatch: have %+v, want %+v", res.AccessList, want)
+	}
+	if res.GasUsed == nil || res.GasUsed.Uint64() == 0 || res.Error != "" {
+		t.Errorf("unexpected result: gas %v, error %q", res.GasUsed, res.Error)
+	}
+}
@@ -18,6 +18,7 @@
 package eth
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/hex"
@@ -25,14 +26,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 	"math/big"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 	"github.com/webchain-network/webchaind/accounts"
+	"github.com/webchain-network/webchaind/accounts/abi"
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/common/compiler"
 	"github.com/webchain-network/webchaind/common/hexutil"
@@ -41,6 +47,7 @@ import (
 	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/core/vm"
 	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/eth/tracers"
 	"github.com/webchain-network/webchaind/ethdb"
 	"github.com/webchain-network/webchaind/event"
 	"github.com/webchain-network/webchaind/logger"
@@ -54,6 +61,8 @@ import (
 
 const defaultGas = uint64(90000)
 
+var errInsecureUnlock = errors.New("account unlock with HTTP/WS access on a public interface is forbidden, use --allow-insecure-unlock")
+
 // blockByNumber is a commonly used helper function which retrieves and returns
 // the block for the given block number, capable of handling two special blocks:
 // rpc.LatestBlockNumber and rpc.PendingBlockNumber. It returns nil when no block
@@ -86,10 +95,35 @@ func stateAndBlockByNumber(m *miner.Miner, bc *core.BlockChain, blockNr rpc.Bloc
 	if block == nil {
 		return nil, nil, nil
 	}
-	stateDb, err := state.New(block.Root(), state.NewDatabase(chainDb))
+	stateDb, err := bc.StateAtHeader(block.Header())
 	return stateDb, block, err
 }
 
+// stateAndBlockByNumberOrHash is like stateAndBlockByNumber, but also resolves
+// blocks by hash, including non-canonical ones still in the database unless
+// canonical blocks are required, and states by root. The block is nil for
+// states selected by root.
+func stateAndBlockByNumberOrHash(m *miner.Miner, bc *core.BlockChain, blockNrOrHash rpc.BlockNumberOrHash, chainDb ethdb.Database) (*state.StateDB, *types.Block, error) {
+	switch {
+	case blockNrOrHash.BlockNumber != nil:
+		return stateAndBlockByNumber(m, bc, *blockNrOrHash.BlockNumber, chainDb)
+	case blockNrOrHash.BlockHash != nil:
+		block := bc.GetBlock(*blockNrOrHash.BlockHash)
+		if block == nil {
+			return nil, nil, nil
+		}
+		if blockNrOrHash.RequireCanonical && core.GetCanonicalHash(chainDb, block.NumberU64()) != block.Hash() {
+			return nil, nil, fmt.Errorf("block %x is not canonical", block.Hash())
+		}
+		stateDb, err := bc.StateAtHeader(block.Header())
+		return stateDb, block, err
+	case blockNrOrHash.StateRoot != nil:
+		stateDb, err := state.New(*blockNrOrHash.StateRoot, state.NewDatabase(chainDb))
+		return stateDb, nil, err
+	}
+	return nil, nil, errors.New("no block number, block hash or state root given")
+}
+
 // PublicEthereumAPI provides an API to access Ethereum related information.
 // It offers only methods that operate on public data that is freely available to anyone.
 type PublicEthereumAPI struct {
@@ -105,8 +139,15 @@ func NewPublicEthereumAPI(e *Ethereum) *PublicEthereumAPI {
 	}
 }
 
-// GasPrice returns a suggestion for a gas price.
+// GasPrice returns a suggestion for a gas price, the suggested priority fee
+// plus the base fee of the latest block under the fee market.
 func (s *PublicEthereumAPI) GasPrice() *big.Int {
+	return s.gpo.SuggestPriceWithBaseFee()
+}
+
+// MaxPriorityFeePerGas returns a suggestion for the priority fee per gas of
+// dynamic fee transactions.
+func (s *PublicEthereumAPI) MaxPriorityFeePerGas() *big.Int {
 	return s.gpo.SuggestPrice()
 }
 
@@ -161,20 +202,24 @@ func (s *PublicEthereumAPI) Hashrate() *rpc.HexNumber {
 // - highestBlock:  block number of the highest block header this node has received from peers
 // - pulledStates:  number of state entries processed until now
 // - knownStates:   number of known state entries that still need to be pulled
+// - syncMode:      synchronisation mode, FULL, FAST or LIGHT
+// - syncStage:     step of the running sync: ancestor, blocks or state (empty when idle)
 func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
-	origin, current, height, pulled, known := s.e.Downloader().Progress()
+	progress := s.e.Downloader().SyncProgress()
 
 	// Return not syncing if the synchronisation already completed
-	if current >= height {
+	if progress.Current >= progress.Height {
 		return false, nil
 	}
 	// Otherwise gather the block sync stats
 	return map[string]interface{}{
-		"startingBlock": rpc.NewHexNumber(origin),
-		"currentBlock":  rpc.NewHexNumber(current),
-		"highestBlock":  rpc.NewHexNumber(height),
-		"pulledStates":  rpc.NewHexNumber(pulled),
-		"knownStates":   rpc.NewHexNumber(known),
+		"startingBlock": rpc.NewHexNumber(progress.Origin),
+		"currentBlock":  rpc.NewHexNumber(progress.Current),
+		"highestBlock":  rpc.NewHexNumber(progress.Height),
+		"pulledStates":  rpc.NewHexNumber(progress.Pulled),
+		"knownStates":   rpc.NewHexNumber(progress.Known),
+		"syncMode":      progress.Mode,
+		"syncStage":     progress.Stage,
 	}, nil
 }
 
@@ -291,6 +336,80 @@ func (s *PrivateMinerAPI) SetExtra(b hexutil.Bytes) bool {
 	return true
 }
 
+// RPCPendingBlock is the RPC representation of the pending block as the miner
+// would seal it.
+type RPCPendingBlock struct {
+	Number       *rpc.HexNumber                `json:"number"`
+	ParentHash   common.Hash                   `json:"parentHash"`
+	Miner        common.Address                `json:"miner"`
+	StateRoot    common.Hash                   `json:"stateRoot"`
+	Difficulty   *rpc.HexNumber                `json:"difficulty"`
+	GasLimit     *rpc.HexNumber                `json:"gasLimit"`
+	GasUsed      *rpc.HexNumber                `json:"gasUsed"`
+	BaseFee      *rpc.HexNumber                `json:"baseFeePerGas,omitempty"`
+	Timestamp    *rpc.HexNumber                `json:"timestamp"`
+	Uncles       []common.Hash                 `json:"uncles"`
+	BlockReward  *rpc.HexNumber                `json:"blockReward"`
+	Fees         *rpc.HexNumber                `json:"fees"`
+	Reward       *rpc.HexNumber                `json:"reward"`
+	Transactions []*RPCPendingBlockTransaction `json:"transactions"`
+}
+
+// RPCPendingBlockTransaction is a transaction of the pending block, with the
+// gas it uses and the fee the miner earns for it.
+type RPCPendingBlockTransaction struct {
+	*RPCTransaction
+	GasUsed *rpc.HexNumber `json:"gasUsed"`
+	Fee     *rpc.HexNumber `json:"fee"`
+}
+
+// PendingBlock returns the pending block fully assembled, without sealing it,
+// along with the reward its miner would earn: the block reward, including the
+// rewards for the uncles, and the fees of the transactions, less the burnt
+// base fee.
+func (s *PrivateMinerAPI) PendingBlock() (*RPCPendingBlock, error) {
+	block, receipts, blockReward := s.e.Miner().PendingBlock()
+
+	fees := new(big.Int)
+	txs := make([]*RPCPendingBlockTransaction, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		rpcTx, err := newRPCTransactionFromBlockIndex(block, i)
+		if err != nil {
+			return nil, err
+		}
+		fee := new(big.Int).Mul(receipts[i].GasUsed, tx.EffectiveGasTip(block.BaseFee()))
+		fees.Add(fees, fee)
+		txs[i] = &RPCPendingBlockTransaction{
+			RPCTransaction: rpcTx,
+			GasUsed:        rpc.NewHexNumber(receipts[i].GasUsed),
+			Fee:            rpc.NewHexNumber(fee),
+		}
+	}
+	uncles := make([]common.Hash, len(block.Uncles()))
+	for i, uncle := range block.Uncles() {
+		uncles[i] = uncle.Hash()
+	}
+	res := &RPCPendingBlock{
+		Number:       rpc.NewHexNumber(block.Number()),
+		ParentHash:   block.ParentHash(),
+		Miner:        block.Coinbase(),
+		StateRoot:    block.Root(),
+		Difficulty:   rpc.NewHexNumber(block.Difficulty()),
+		GasLimit:     rpc.NewHexNumber(block.GasLimit()),
+		GasUsed:      rpc.NewHexNumber(block.GasUsed()),
+		Timestamp:    rpc.NewHexNumber(block.Time()),
+		Uncles:       uncles,
+		BlockReward:  rpc.NewHexNumber(blockReward),
+		Fees:         rpc.NewHexNumber(fees),
+		Reward:       rpc.NewHexNumber(new(big.Int).Add(blockReward, fees)),
+		Transactions: txs,
+	}
+	if block.BaseFee() != nil {
+		res.BaseFee = rpc.NewHexNumber(block.BaseFee())
+	}
+	return res, nil
+}
+
 // PublicTxPoolAPI offers and API for the transaction pool. It only operates on data that is non confidential.
 type PublicTxPoolAPI struct {
 	e *Ethereum
@@ -343,6 +462,56 @@ func (s *PublicTxPoolAPI) Status() map[string]*rpc.HexNumber {
 	}
 }
 
+// GasPriceFloor returns the minimum gas prices the node enforces: the miner's,
+// below which transactions aren't mined, the pool's price limit, and the
+// effective floor below which remote transactions are neither accepted into
+// the pool nor relayed.
+func (s *PublicTxPoolAPI) GasPriceFloor() map[string]*rpc.HexNumber {
+	return map[string]*rpc.HexNumber{
+		"miner":     rpc.NewHexNumber(s.e.Miner().GasPrice()),
+		"txpool":    rpc.NewHexNumber(s.e.TxPool().PriceLimit()),
+		"effective": rpc.NewHexNumber(s.e.TxPool().GasPriceFloor()),
+	}
+}
+
+// MaxNonceGap returns the maximum number of nonces a transaction may skip ahead
+// of its sender's next nonce to be accepted into the pool, 0 if unlimited.
+func (s *PublicTxPoolAPI) MaxNonceGap() *rpc.HexNumber {
+	return rpc.NewHexNumber(s.e.TxPool().MaxNonceGap())
+}
+
+// RPCQueueGap is the RPC representation of the queued transactions of a sender
+// waiting for a transaction with a missing nonce.
+type RPCQueueGap struct {
+	Nonce        *rpc.HexNumber    `json:"nonce"`
+	MissingNonce *rpc.HexNumber    `json:"missingNonce"`
+	Transactions []*RPCTransaction `json:"transactions"`
+}
+
+// Blocked returns, per sender, the queued transactions that can't be promoted
+// because a transaction with a lower nonce is missing, along with the sender's
+// next nonce and the first missing one. Submitting a transaction with the
+// missing nonce unblocks the ones following it.
+func (s *PublicTxPoolAPI) Blocked() (map[string]*RPCQueueGap, error) {
+	gaps, err := s.e.TxPool().QueueGaps()
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[string]*RPCQueueGap, len(gaps))
+	for _, gap := range gaps {
+		txs := make([]*RPCTransaction, len(gap.Blocked))
+		for i, tx := range gap.Blocked {
+			txs[i] = newRPCPendingTransaction(tx)
+		}
+		blocked[gap.Sender.Hex()] = &RPCQueueGap{
+			Nonce:        rpc.NewHexNumber(gap.Nonce),
+			MissingNonce: rpc.NewHexNumber(gap.Missing),
+			Transactions: txs,
+		}
+	}
+	return blocked, nil
+}
+
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
 func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string][]string {
@@ -384,6 +553,73 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string][]string {
 	return content
 }
 
+// PrivateTxPoolAPI offers methods modifying the contents of the transaction pool.
+// It is served in the admin namespace, so it isn't exposed over HTTP or WS unless
+// explicitly enabled.
+type PrivateTxPoolAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateTxPoolAPI creates a new tx pool service for managing pooled transactions.
+func NewPrivateTxPoolAPI(e *Ethereum) *PrivateTxPoolAPI {
+	return &PrivateTxPoolAPI{e}
+}
+
+// pooled returns the pending or queued transaction with the given hash.
+func (s *PrivateTxPoolAPI) pooled(hash common.Hash) *types.Transaction {
+	for _, txs := range []types.Transactions{s.e.TxPool().GetTransactions(), s.e.TxPool().GetQueuedTransactions()} {
+		for _, tx := range txs {
+			if tx.Hash() == hash {
+				return tx
+			}
+		}
+	}
+	return nil
+}
+
+// DropTransaction removes the transaction with the given hash from the pool.
+func (s *PrivateTxPoolAPI) DropTransaction(hash common.Hash) (bool, error) {
+	if s.pooled(hash) == nil {
+		return false, fmt.Errorf("transaction %x not found in pool", hash)
+	}
+	s.e.TxPool().RemoveTx(hash)
+	glog.V(logger.Info).Infof("Tx(%x) dropped from pool", hash)
+	return true, nil
+}
+
+// ReplaceTransaction swaps the pooled transaction with the given hash for the given
+// signed, RLP encoded transaction. Both must be sent from the same account
+// with the same nonce.
+func (s *PrivateTxPoolAPI) ReplaceTransaction(hash common.Hash, encodedTx string) (common.Hash, error) {
+	old := s.pooled(hash)
+	if old == nil {
+		return common.Hash{}, fmt.Errorf("transaction %x not found in pool", hash)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(common.FromHex(encodedTx)); err != nil {
+		return common.Hash{}, err
+	}
+	oldFrom, _ := old.From()
+	from, err := tx.From()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if from != oldFrom || tx.Nonce() != old.Nonce() {
+		return common.Hash{}, fmt.Errorf("replacement must be sent from %s with nonce %d", oldFrom.Hex(), old.Nonce())
+	}
+
+	pool := s.e.TxPool()
+	pool.RemoveTx(hash)
+	pool.SetLocal(tx)
+	if err := pool.Add(tx); err != nil {
+		// Put the original back, it was valid a moment ago.
+		pool.Add(old)
+		return common.Hash{}, err
+	}
+	glog.V(logger.Info).Infof("Tx(%x) replaced by %x", hash, tx.Hash())
+	return tx.Hash(), nil
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -400,6 +636,11 @@ func (s *PublicAccountAPI) Accounts() []accounts.Account {
 	return s.am.Accounts()
 }
 
+// WatchOnlyAccounts returns the addresses the node follows without a key.
+func (s *PublicAccountAPI) WatchOnlyAccounts() []common.Address {
+	return s.am.WatchOnly()
+}
+
 // PrivateAccountAPI provides an API to access accounts managed by this node.
 // It offers methods to create, (un)lock en list accounts. Some methods accept
 // passwords and are therefore considered private by default.
@@ -409,16 +650,21 @@ type PrivateAccountAPI struct {
 	txPool *core.TxPool
 	txMu   *sync.Mutex
 	gpo    *GasPriceOracle
+	audit  *signAudit
+
+	unlockAllowed bool // whether accounts may be unlocked over RPC
 }
 
 // NewPrivateAccountAPI create a new PrivateAccountAPI.
 func NewPrivateAccountAPI(e *Ethereum) *PrivateAccountAPI {
 	return &PrivateAccountAPI{
-		bc:     e.blockchain,
-		am:     e.accountManager,
-		txPool: e.txPool,
-		txMu:   &e.txMu,
-		gpo:    e.gpo,
+		bc:            e.blockchain,
+		am:            e.accountManager,
+		txPool:        e.txPool,
+		txMu:          &e.txMu,
+		gpo:           e.gpo,
+		audit:         e.signAudit,
+		unlockAllowed: e.unlockAllowed,
 	}
 }
 
@@ -456,7 +702,12 @@ func (s *PrivateAccountAPI) ImportRawKey(privkey string, password string) (commo
 // UnlockAccount will unlock the account associated with the given address with
 // the given password for duration seconds. If duration is nil it will use a
 // default of 300 seconds. It returns an indication if the account was unlocked.
+// Unlocking is refused while RPC is exposed on a public interface, unless the
+// node runs with --allow-insecure-unlock.
 func (s *PrivateAccountAPI) UnlockAccount(addr common.Address, password string, duration *rpc.HexNumber) (bool, error) {
+	if !s.unlockAllowed {
+		return false, errInsecureUnlock
+	}
 	if duration == nil {
 		duration = rpc.NewHexNumber(300)
 	}
@@ -468,6 +719,24 @@ func (s *PrivateAccountAPI) UnlockAccount(addr common.Address, password string,
 	return true, nil
 }
 
+// AddWatchOnly adds an address the node has no key for, e.g. of a cold wallet,
+// to its accounts. It's listed along with the other accounts and included in
+// their balances and transaction history, but can't sign.
+func (s *PrivateAccountAPI) AddWatchOnly(addr common.Address) (bool, error) {
+	if err := s.am.AddWatchOnly(addr); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveWatchOnly removes a watch-only address from the node's accounts.
+func (s *PrivateAccountAPI) RemoveWatchOnly(addr common.Address) (bool, error) {
+	if err := s.am.RemoveWatchOnly(addr); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // LockAccount will lock the account associated with the given address when it's unlocked.
 func (s *PrivateAccountAPI) LockAccount(addr common.Address) bool {
 	return s.am.Lock(addr) == nil
@@ -482,8 +751,10 @@ func (s *PrivateAccountAPI) LockAccount(addr common.Address) bool {
 // The key used to calculate the signature is decrypted with the given password.
 //
 // https://github.com/ethereum/go-ethereum/wiki/Management-APIs#personal_sign
-func (s *PrivateAccountAPI) Sign(data hexutil.Bytes, addr common.Address, passwd string) (hexutil.Bytes, error) {
-	signature, err := s.am.SignWithPassphrase(addr, passwd, signHash(data))
+func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr common.Address, passwd string) (hexutil.Bytes, error) {
+	hash := signHash(data)
+	signature, err := s.am.SignWithPassphrase(addr, passwd, hash)
+	s.audit.recordMessage(ctx, "personal_sign", signApprovalPassphrase, addr, hash, err)
 	if err != nil {
 		return nil, err
 	}
@@ -494,7 +765,10 @@ func (s *PrivateAccountAPI) Sign(data hexutil.Bytes, addr common.Address, passwd
 // SendTransaction will create a transaction from the given arguments and
 // tries to sign it with the key associated with args.To. If the given passwd isn't
 // able to decrypt the key it fails.
-func (s *PrivateAccountAPI) SendTransaction(args SendTxArgs, passwd string) (common.Hash, error) {
+func (s *PrivateAccountAPI) SendTransaction(ctx context.Context, args SendTxArgs, passwd string) (common.Hash, error) {
+	if !s.unlockAllowed {
+		return common.Hash{}, errInsecureUnlock
+	}
 	args = prepareSendTxArgs(args, s.gpo)
 
 	s.txMu.Lock()
@@ -504,16 +778,13 @@ func (s *PrivateAccountAPI) SendTransaction(args SendTxArgs, passwd string) (com
 		args.Nonce = rpc.NewHexNumber(s.txPool.State().GetNonce(args.From))
 	}
 
-	var tx *types.Transaction
-	if args.To == nil {
-		tx = types.NewContractCreation(args.Nonce.Uint64(), args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), common.FromHex(args.Data))
-	} else {
-		tx = types.NewTransaction(args.Nonce.Uint64(), *args.To, args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), common.FromHex(args.Data))
-	}
+	tx := args.toTransaction(s.bc.Config().GetChainID(s.bc.CurrentBlock().Number()))
 
-	tx.SetSigner(s.bc.Config().GetSigner(s.bc.CurrentBlock().Number()))
+	signer := s.bc.Config().GetSigner(s.bc.CurrentBlock().Number())
+	tx.SetSigner(signer)
 
 	signature, err := s.am.SignWithPassphrase(args.From, passwd, tx.SigHash().Bytes())
+	s.audit.recordTx(ctx, "personal_sendTransaction", signApprovalPassphrase, args.From, signer, tx, signature, err)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -523,8 +794,22 @@ func (s *PrivateAccountAPI) SendTransaction(args SendTxArgs, passwd string) (com
 
 // SignAndSendTransaction was renamed to SendTransaction. This method is deprecated
 // and will be removed in the future. It primary goal is to give clients time to update.
-func (s *PrivateAccountAPI) SignAndSendTransaction(args SendTxArgs, passwd string) (common.Hash, error) {
-	return s.SendTransaction(args, passwd)
+func (s *PrivateAccountAPI) SignAndSendTransaction(ctx context.Context, args SendTxArgs, passwd string) (common.Hash, error) {
+	return s.SendTransaction(ctx, args, passwd)
+}
+
+// SigningAudit returns the most recent signing operations recorded in the
+// audit log, optionally only those of the given account. Limit defaults to
+// 100 entries, zero returns the whole log.
+func (s *PrivateAccountAPI) SigningAudit(account *common.Address, limit *int) ([]*SignAuditEntry, error) {
+	if s.audit == nil {
+		return nil, errors.New("signing audit log is disabled")
+	}
+	n := 100
+	if limit != nil {
+		n = *limit
+	}
+	return s.audit.entries(account, n)
 }
 
 // PublicBlockChainAPI provides an API to access the Ethereum blockchain.
@@ -537,13 +822,19 @@ type PublicBlockChainAPI struct {
 	eventMux                *event.TypeMux
 	muNewBlockSubscriptions sync.Mutex                             // protects newBlocksSubscriptions
 	newBlockSubscriptions   map[string]func(core.ChainEvent) error // callbacks for new block subscriptions
+	muBalanceSubscriptions  sync.Mutex                             // protects balanceSubscriptions
+	balanceSubscriptions    map[string]balanceNotifier             // callbacks for balances subscriptions
 	am                      *accounts.Manager
 	miner                   *miner.Miner
 	gpo                     *GasPriceOracle
+	calls                   *callCache // results of eth_call and eth_estimateGas
+	audit                   *signAudit
+	denied                  callDenyList  // contracts eth_call and eth_estimateGas don't run
+	callTimeout             time.Duration // wall-clock limit of eth_call and eth_estimateGas (unlimited if 0)
 }
 
 // NewPublicBlockChainAPI creates a new Etheruem blockchain API.
-func NewPublicBlockChainAPI(config *core.ChainConfig, bc *core.BlockChain, m *miner.Miner, chainDb ethdb.Database, gpo *GasPriceOracle, eventMux *event.TypeMux, am *accounts.Manager) *PublicBlockChainAPI {
+func NewPublicBlockChainAPI(config *core.ChainConfig, bc *core.BlockChain, m *miner.Miner, chainDb ethdb.Database, gpo *GasPriceOracle, eventMux *event.TypeMux, am *accounts.Manager, audit *signAudit, denied callDenyList, callTimeout time.Duration) *PublicBlockChainAPI {
 	api := &PublicBlockChainAPI{
 		config:                config,
 		bc:                    bc,
@@ -552,7 +843,12 @@ func NewPublicBlockChainAPI(config *core.ChainConfig, bc *core.BlockChain, m *mi
 		eventMux:              eventMux,
 		am:                    am,
 		newBlockSubscriptions: make(map[string]func(core.ChainEvent) error),
+		balanceSubscriptions:  make(map[string]balanceNotifier),
 		gpo:                   gpo,
+		calls:                 newCallCache(),
+		audit:                 audit,
+		denied:                denied,
+		callTimeout:           callTimeout,
 	}
 
 	go api.subscriptionLoop()
@@ -562,16 +858,40 @@ func NewPublicBlockChainAPI(config *core.ChainConfig, bc *core.BlockChain, m *mi
 
 // subscriptionLoop reads events from the global event mux and creates notifications for the matched subscriptions.
 func (s *PublicBlockChainAPI) subscriptionLoop() {
-	sub := s.eventMux.Subscribe(core.ChainEvent{})
+	sub := s.eventMux.Subscribe(core.ChainEvent{}, core.ChainHeadEvent{})
 	for event := range sub.Chan() {
-		if chainEvent, ok := event.Data.(core.ChainEvent); ok {
+		switch ev := event.Data.(type) {
+		case core.ChainEvent:
 			s.muNewBlockSubscriptions.Lock()
 			for id, notifyOf := range s.newBlockSubscriptions {
-				if notifyOf(chainEvent) == rpc.ErrNotificationNotFound {
+				if notifyOf(ev) == rpc.ErrNotificationNotFound {
 					delete(s.newBlockSubscriptions, id)
 				}
 			}
 			s.muNewBlockSubscriptions.Unlock()
+		case core.ChainHeadEvent:
+			s.calls.purge()
+			s.notifyBalances(ev.Block)
+		}
+	}
+}
+
+// notifyBalances runs the callbacks of the balances subscriptions on a new head.
+func (s *PublicBlockChainAPI) notifyBalances(block *types.Block) {
+	s.muBalanceSubscriptions.Lock()
+	defer s.muBalanceSubscriptions.Unlock()
+
+	if len(s.balanceSubscriptions) == 0 {
+		return
+	}
+	statedb, err := s.bc.StateAt(block.Root())
+	if err != nil {
+		glog.V(logger.Warn).Infof("unable to open state of block #%d for balance notifications: %v", block.NumberU64(), err)
+		return
+	}
+	for id, notifyOf := range s.balanceSubscriptions {
+		if notifyOf(block, statedb) == rpc.ErrNotificationNotFound {
+			delete(s.balanceSubscriptions, id)
 		}
 	}
 }
@@ -582,16 +902,43 @@ func (s *PublicBlockChainAPI) BlockNumber() *big.Int {
 }
 
 // GetBalance returns the amount of wei for the given address in the state of the
-// given block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta
-// block numbers are also allowed.
-func (s *PublicBlockChainAPI) GetBalance(address common.Address, blockNr rpc.BlockNumber) (*big.Int, error) {
-	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+// given block number, block hash or state root. The rpc.LatestBlockNumber and
+// rpc.PendingBlockNumber meta block numbers are also allowed.
+func (s *PublicBlockChainAPI) GetBalance(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*big.Int, error) {
+	state, _, err := stateAndBlockByNumberOrHash(s.miner, s.bc, blockNrOrHash, s.chainDb)
 	if state == nil || err != nil {
 		return nil, err
 	}
 	return state.GetBalance(address), nil
 }
 
+// AccountBalance is the balance and nonce of one of the node's accounts.
+type AccountBalance struct {
+	Address   common.Address `json:"address"`
+	Balance   *rpc.HexNumber `json:"balance"`
+	Nonce     *rpc.HexNumber `json:"nonce"`
+	WatchOnly bool           `json:"watchOnly"`
+}
+
+// GetAccountBalances returns the balances and nonces of the accounts with keys
+// and the watch-only accounts in the state of the given block.
+func (s *PublicBlockChainAPI) GetAccountBalances(blockNrOrHash rpc.BlockNumberOrHash) ([]*AccountBalance, error) {
+	state, _, err := stateAndBlockByNumberOrHash(s.miner, s.bc, blockNrOrHash, s.chainDb)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	balances := []*AccountBalance{}
+	for _, addr := range s.am.Addresses() {
+		balances = append(balances, &AccountBalance{
+			Address:   addr,
+			Balance:   rpc.NewHexNumber(state.GetBalance(addr)),
+			Nonce:     rpc.NewHexNumber(state.GetNonce(addr)),
+			WatchOnly: s.am.IsWatchOnly(addr),
+		})
+	}
+	return balances, nil
+}
+
 // GetBlockByNumber returns the requested block. When blockNr is -1 the chain head is returned. When fullTx is true all
 // transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetBlockByNumber(blockNr rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
@@ -617,6 +964,42 @@ func (s *PublicBlockChainAPI) GetBlockByHash(blockHash common.Hash, fullTx bool)
 	return nil, nil
 }
 
+// GetHeaderByNumber returns the requested header without the block body. The
+// rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block numbers are also
+// allowed.
+func (s *PublicBlockChainAPI) GetHeaderByNumber(blockNr rpc.BlockNumber) (map[string]interface{}, error) {
+	var header *types.Header
+	switch blockNr {
+	case rpc.PendingBlockNumber:
+		if block, _ := s.miner.Pending(); block != nil {
+			header = block.Header()
+		}
+	case rpc.LatestBlockNumber:
+		header = s.bc.CurrentBlock().Header()
+	default:
+		header = s.bc.GetHeaderByNumber(uint64(blockNr))
+	}
+	if header == nil {
+		return nil, nil
+	}
+	response := s.rpcOutputHeader(header)
+	if blockNr == rpc.PendingBlockNumber {
+		// Pending headers need to nil out a few fields
+		for _, field := range []string{"hash", "nonce", "miner"} {
+			response[field] = nil
+		}
+	}
+	return response, nil
+}
+
+// GetHeaderByHash returns the requested header without the block body.
+func (s *PublicBlockChainAPI) GetHeaderByHash(blockHash common.Hash) map[string]interface{} {
+	if header := s.bc.GetHeader(blockHash); header != nil {
+		return s.rpcOutputHeader(header)
+	}
+	return nil
+}
+
 // GetUncleByBlockNumberAndIndex returns the uncle block for the given block hash and index. When fullTx is true
 // all transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetUncleByBlockNumberAndIndex(blockNr rpc.BlockNumber, index rpc.HexNumber) (map[string]interface{}, error) {
@@ -702,9 +1085,61 @@ func (s *PublicBlockChainAPI) NewBlocks(ctx context.Context, args NewBlocksArgs)
 	return subscription, nil
 }
 
-// GetCode returns the code stored at the given address in the state for the given block number.
-func (s *PublicBlockChainAPI) GetCode(address common.Address, blockNr rpc.BlockNumber) (string, error) {
-	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+// BalancesArgs are the accounts watched by a balances subscription, the node's
+// accounts, watch-only ones included, if none are given.
+type BalancesArgs struct {
+	Addresses []common.Address `json:"addresses"`
+}
+
+// Balances creates a subscription notifying of the changes to the balances and
+// nonces of the given accounts with each new head, one BalanceChange per
+// changed account.
+func (s *PublicBlockChainAPI) Balances(ctx context.Context, args BalancesArgs) (rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	if len(args.Addresses) == 0 {
+		args.Addresses = s.am.Addresses()
+	}
+	if len(args.Addresses) == 0 {
+		return nil, errors.New("no addresses to watch")
+	}
+	if len(args.Addresses) > maxWatchedAccounts {
+		return nil, fmt.Errorf("too many addresses to watch: %d, max %d", len(args.Addresses), maxWatchedAccounts)
+	}
+	statedb, err := s.bc.State()
+	if err != nil {
+		return nil, err
+	}
+	watch := newBalanceWatch(args.Addresses, statedb)
+
+	subscription, err := notifier.NewSubscription(func(subId string) {
+		s.muBalanceSubscriptions.Lock()
+		delete(s.balanceSubscriptions, subId)
+		s.muBalanceSubscriptions.Unlock()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.muBalanceSubscriptions.Lock()
+	s.balanceSubscriptions[subscription.ID()] = func(block *types.Block, statedb *state.StateDB) error {
+		for _, change := range watch.update(block, statedb) {
+			if err := subscription.Notify(change); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	s.muBalanceSubscriptions.Unlock()
+	return subscription, nil
+}
+
+// GetCode returns the code stored at the given address in the state for the given
+// block number, block hash or state root.
+func (s *PublicBlockChainAPI) GetCode(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (string, error) {
+	state, _, err := stateAndBlockByNumberOrHash(s.miner, s.bc, blockNrOrHash, s.chainDb)
 	if state == nil || err != nil {
 		return "", err
 	}
@@ -715,17 +1150,65 @@ func (s *PublicBlockChainAPI) GetCode(address common.Address, blockNr rpc.BlockN
 	return common.ToHex(res), nil
 }
 
+// GetContractCreation returns the canonical transaction which created the
+// contract at the given address and its creator, which is the sender of the
+// transaction or the contract deploying it. Contracts created in blocks
+// imported by fast sync aren't indexed, nil is returned for them as for
+// addresses that aren't contracts.
+func (s *PublicBlockChainAPI) GetContractCreation(address common.Address) (map[string]interface{}, error) {
+	creation := core.GetContractCreation(s.chainDb, address)
+	if creation == nil {
+		return nil, nil
+	}
+	blockHash, blockNumber, index, err := getTransactionBlockData(s.chainDb, creation.TxHash)
+	if err != nil || core.GetCanonicalHash(s.chainDb, blockNumber) != blockHash {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"address":          address,
+		"creator":          creation.Creator,
+		"transactionHash":  creation.TxHash,
+		"transactionIndex": rpc.NewHexNumber(index),
+		"blockHash":        blockHash,
+		"blockNumber":      rpc.NewHexNumber(blockNumber),
+	}, nil
+}
+
 // GetStorageAt returns the storage from the state at the given address, key and
-// block number. The rpc.LatestBlockNumber and rpc.PendingBlockNumber meta block
-// numbers are also allowed.
-func (s *PublicBlockChainAPI) GetStorageAt(address common.Address, key string, blockNr rpc.BlockNumber) (string, error) {
-	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+// block number, block hash or state root. The rpc.LatestBlockNumber and
+// rpc.PendingBlockNumber meta block numbers are also allowed.
+func (s *PublicBlockChainAPI) GetStorageAt(address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (string, error) {
+	state, _, err := stateAndBlockByNumberOrHash(s.miner, s.bc, blockNrOrHash, s.chainDb)
 	if state == nil || err != nil {
 		return "0x", err
 	}
 	return state.GetState(address, common.HexToHash(key)).Hex(), nil
 }
 
+// maxStorageRange is the maximum number of storage slots returned by
+// GetStorageRange.
+const maxStorageRange = 1024
+
+// GetStorageRange returns up to max storage slots of the account at address in
+// the state of the given block, starting at the slot with the hashed key start,
+// together with merkle proofs of the account and of every slot.
+func (s *PublicBlockChainAPI) GetStorageRange(address common.Address, blockNrOrHash rpc.BlockNumberOrHash, start common.Hash, max int) (*state.StorageRange, error) {
+	if blockNrOrHash.BlockNumber != nil && *blockNrOrHash.BlockNumber == rpc.PendingBlockNumber {
+		return nil, errors.New("storage ranges of the pending block are not available")
+	}
+	if max <= 0 || max > maxStorageRange {
+		return nil, fmt.Errorf("invalid range size %d, want 1-%d", max, maxStorageRange)
+	}
+	state, _, err := stateAndBlockByNumberOrHash(s.miner, s.bc, blockNrOrHash, s.chainDb)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		return nil, fmt.Errorf("block %v not found", blockNrOrHash)
+	}
+	return state.StorageRange(address, start, max)
+}
+
 // callmsg is the message type used for call transactions.
 type callmsg struct {
 	from          *state.StateObject
@@ -733,6 +1216,7 @@ type callmsg struct {
 	gas, gasPrice *big.Int
 	value         *big.Int
 	data          []byte
+	accessList    types.AccessList
 }
 
 // accessor boilerplate to implement core.Message
@@ -744,6 +1228,8 @@ func (m callmsg) GasPrice() *big.Int                    { return m.gasPrice }
 func (m callmsg) Gas() *big.Int                         { return m.gas }
 func (m callmsg) Value() *big.Int                       { return m.value }
 func (m callmsg) Data() []byte                          { return m.data }
+func (m callmsg) GasTipCap() *big.Int                   { return m.gasPrice }
+func (m callmsg) AccessList() types.AccessList          { return m.accessList }
 
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
@@ -753,16 +1239,58 @@ type CallArgs struct {
 	GasPrice *rpc.HexNumber  `json:"gasPrice"`
 	Value    rpc.HexNumber   `json:"value"`
 	Data     string          `json:"data"`
+
+	AccessList *types.AccessList `json:"accessList"`
 }
 
-func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (string, *big.Int, error) {
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (string, *big.Int, error) {
 	// Fetch the state associated with the block number
 	stateDb, block, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
 	if stateDb == nil || err != nil {
 		return "0x", nil, err
 	}
 	stateDb = stateDb.Copy()
+	msg := s.newCallMsg(args, stateDb)
+
+	key := newCallKey(block.Hash(), msg)
+	if ret, gas, ok := s.calls.get(key); ok {
+		return ret, gas, nil
+	}
+
+	// Execute the call, aborting it on timeout or when the request goes away
+	vmenv := core.NewEnv(stateDb, s.config, s.bc, msg, callHeader(block, msg.gasPrice))
+	gp := new(core.GasPool).AddGas(common.MaxBig)
+
+	if s.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.callTimeout)
+		defer cancel()
+	}
+	stop := cancelOnDone(ctx, vmenv)
+	res, requiredGas, failed, err := s.denied.applyMessage(vmenv, msg, gp)
+	stop()
+	if vmenv.Cancelled() {
+		return "0x", nil, fmt.Errorf("execution aborted: %v", ctx.Err())
+	}
+	if failed && err == nil {
+		switch e := executionError(vmenv, res).(type) {
+		case *outOfGasError, *revertError:
+			err = e
+		}
+	}
+	ret := "0x"
+	if len(res) > 0 { // backwards compatibility
+		ret = common.ToHex(res)
+	}
+	if err == nil {
+		s.calls.add(key, ret, requiredGas)
+	}
+	return ret, requiredGas, err
+}
 
+// newCallMsg assembles the message of the call args on stateDb, sent by the
+// first account if args has no sender, with an unlimited balance.
+func (s *PublicBlockChainAPI) newCallMsg(args CallArgs, stateDb *state.StateDB) callmsg {
 	// Retrieve the account state object to interact with
 	var from *state.StateObject
 	if args.From == (common.Address{}) {
@@ -786,60 +1314,188 @@ func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (st
 		value:    args.Value.BigInt(),
 		data:     common.FromHex(args.Data),
 	}
+	if args.AccessList != nil {
+		msg.accessList = *args.AccessList
+	}
 	if msg.gas == nil {
 		msg.gas = big.NewInt(50000000)
 	}
 	if msg.gasPrice == nil {
 		msg.gasPrice = s.gpo.SuggestPrice()
 	}
+	return msg
+}
 
-	// Execute the call and return
-	vmenv := core.NewEnv(stateDb, s.config, s.bc, msg, block.Header())
-	gp := new(core.GasPool).AddGas(common.MaxBig)
-
-	res, requiredGas, _, err := core.NewStateTransition(vmenv, msg, gp).TransitionDb()
-	if len(res) == 0 { // backwards compatibility
-		return "0x", requiredGas, err
+// callHeader returns the header of block for a call paying gasPrice. Calls
+// priced below the base fee, like the default suggestion, run as if the block
+// had none.
+func callHeader(block *types.Block, gasPrice *big.Int) *types.Header {
+	header := block.Header()
+	if header.BaseFee != nil && gasPrice.Cmp(header.BaseFee) < 0 {
+		header.BaseFee = nil
 	}
-	return common.ToHex(res), requiredGas, err
+	return header
 }
 
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(args CallArgs, blockNr rpc.BlockNumber) (string, error) {
-	result, _, err := s.doCall(args, blockNr)
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (string, error) {
+	result, _, err := s.doCall(ctx, args, blockNr)
 	return result, err
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the given transaction.
-func (s *PublicBlockChainAPI) EstimateGas(args CallArgs) (*rpc.HexNumber, error) {
-	_, gas, err := s.doCall(args, rpc.PendingBlockNumber)
+func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (*rpc.HexNumber, error) {
+	_, gas, err := s.doCall(ctx, args, rpc.PendingBlockNumber)
 	return rpc.NewHexNumber(gas), err
 }
 
+// accessListResult is the access list created for a call and the gas the call
+// uses with it, along with the error of the call if it fails.
+type accessListResult struct {
+	AccessList types.AccessList `json:"accessList"`
+	GasUsed    *rpc.HexNumber   `json:"gasUsed"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// CreateAccessList returns the access list (EIP-2930) of the call args on the
+// state of the given block, the pending one by default, and the gas the call
+// uses with it. As listed accounts and slots cost less to access, which may
+// change the path the call takes, it is repeated with the list recorded until
+// it accesses nothing more.
+func (s *PublicBlockChainAPI) CreateAccessList(ctx context.Context, args CallArgs, blockNr *rpc.BlockNumber) (*accessListResult, error) {
+	number := rpc.PendingBlockNumber
+	if blockNr != nil {
+		number = *blockNr
+	}
+	stateDb, block, err := stateAndBlockByNumber(s.miner, s.bc, number, s.chainDb)
+	if stateDb == nil || err != nil {
+		return nil, err
+	}
+	if s.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.callTimeout)
+		defer cancel()
+	}
+
+	var list types.AccessList
+	if args.AccessList != nil {
+		list = *args.AccessList
+	}
+	for {
+		statedb := stateDb.Copy()
+		args.AccessList = &list
+		msg := s.newCallMsg(args, statedb)
+		vmenv := core.NewEnv(statedb, s.config, s.bc, msg, callHeader(block, msg.gasPrice))
+
+		// The sender, recipient and precompiles are warm without being listed
+		from := msg.from.Address()
+		excluded := map[common.Address]bool{from: true}
+		if msg.to != nil {
+			excluded[*msg.to] = true
+		} else {
+			excluded[crypto.CreateAddress(from, statedb.GetNonce(from))] = true
+		}
+		for addr := range vm.ActivePrecompiles(vmenv.RuleSet(), vmenv.BlockNumber()) {
+			excluded[common.StringToAddress(addr)] = true
+		}
+		tracer := newAccessListTracer(list, excluded)
+		vmenv.SetTracer(tracer)
+
+		stop := cancelOnDone(ctx, vmenv)
+		res, gas, failed, err := s.denied.applyMessage(vmenv, msg, new(core.GasPool).AddGas(common.MaxBig))
+		stop()
+		if vmenv.Cancelled() {
+			return nil, fmt.Errorf("execution aborted: %v", ctx.Err())
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tracer.equal(list) {
+			result := &accessListResult{AccessList: tracer.AccessList(), GasUsed: rpc.NewHexNumber(gas)}
+			if failed {
+				if err := executionError(vmenv, res); err != nil {
+					result.Error = err.Error()
+				}
+			}
+			return result, nil
+		}
+		list = tracer.AccessList()
+	}
+}
+
+// cancelOnDone cancels the executions of env once ctx is done, until the
+// returned function is called.
+func cancelOnDone(ctx context.Context, env *core.VMEnv) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			env.Cancel()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// executionError returns the error the failed execution of env, which returned
+// ret, ended with in its RPC form.
+func executionError(env *core.VMEnv, ret []byte) error {
+	err := env.Err()
+	if oog, ok := err.(*vm.OutOfGasAtError); ok {
+		return &outOfGasError{oog}
+	}
+	if err == vm.ErrRevert {
+		return newRevertError(ret)
+	}
+	return err
+}
+
+// revertError is returned for calls reverting. It carries the reason given by
+// the contract, if encoded as a Solidity Error(string), and the raw revert data
+// as RPC error data.
+type revertError struct {
+	reason string
+	data   []byte
+}
+
+func newRevertError(data []byte) *revertError {
+	reason, _ := abi.UnpackRevert(data)
+	return &revertError{reason: reason, data: common.CopyBytes(data)}
+}
+
+func (e *revertError) Error() string {
+	if e.reason == "" {
+		return "execution reverted"
+	}
+	return "execution reverted: " + e.reason
+}
+
+func (e *revertError) ErrorData() interface{} {
+	return hexutil.Bytes(e.data)
+}
+
+// outOfGasError is returned for calls running out of gas in contract code. Its
+// RPC error data locates the step that couldn't be paid for.
+type outOfGasError struct {
+	*vm.OutOfGasAtError
+}
+
+func (e *outOfGasError) ErrorData() interface{} {
+	return map[string]interface{}{
+		"op":   e.Op.String(),
+		"pc":   e.Pc,
+		"gas":  e.Gas,
+		"cost": e.Cost,
+	}
+}
+
 // rpcOutputBlock converts the given block to the RPC output which depends on fullTx. If inclTx is true transactions are
 // returned. When fullTx is true the returned block contains full transaction details, otherwise it will only contain
 // transaction hashes.
 func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
-	fields := map[string]interface{}{
-		"number":           rpc.NewHexNumber(b.Number()),
-		"hash":             b.Hash(),
-		"parentHash":       b.ParentHash(),
-		"nonce":            b.Header().Nonce,
-		"sha3Uncles":       b.UncleHash(),
-		"logsBloom":        b.Bloom(),
-		"stateRoot":        b.Root(),
-		"miner":            b.Coinbase(),
-		"difficulty":       rpc.NewHexNumber(b.Difficulty()),
-		"totalDifficulty":  rpc.NewHexNumber(s.bc.GetTd(b.Hash())),
-		"extraData":        fmt.Sprintf("0x%x", b.Extra()),
-		"size":             rpc.NewHexNumber(b.Size().Int64()),
-		"gasLimit":         rpc.NewHexNumber(b.GasLimit()),
-		"gasUsed":          rpc.NewHexNumber(b.GasUsed()),
-		"timestamp":        rpc.NewHexNumber(b.Time()),
-		"transactionsRoot": b.TxHash(),
-		"receiptsRoot":     b.ReceiptHash(),
-	}
+	fields := s.rpcOutputHeader(b.Header())
+	fields["size"] = rpc.NewHexNumber(b.Size().Int64())
 
 	if inclTx {
 		formatTx := func(tx *types.Transaction) (interface{}, error) {
@@ -876,24 +1532,54 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 	return fields, nil
 }
 
+// rpcOutputHeader converts the given header to the RPC output.
+func (s *PublicBlockChainAPI) rpcOutputHeader(h *types.Header) map[string]interface{} {
+	fields := map[string]interface{}{
+		"number":           rpc.NewHexNumber(h.Number),
+		"hash":             h.Hash(),
+		"parentHash":       h.ParentHash,
+		"nonce":            h.Nonce,
+		"sha3Uncles":       h.UncleHash,
+		"logsBloom":        h.Bloom,
+		"stateRoot":        h.Root,
+		"miner":            h.Coinbase,
+		"difficulty":       rpc.NewHexNumber(h.Difficulty),
+		"totalDifficulty":  rpc.NewHexNumber(s.bc.GetTd(h.Hash())),
+		"extraData":        fmt.Sprintf("0x%x", h.Extra),
+		"gasLimit":         rpc.NewHexNumber(h.GasLimit),
+		"gasUsed":          rpc.NewHexNumber(h.GasUsed),
+		"timestamp":        rpc.NewHexNumber(h.Time),
+		"transactionsRoot": h.TxHash,
+		"receiptsRoot":     h.ReceiptHash,
+	}
+	if h.BaseFee != nil {
+		fields["baseFeePerGas"] = rpc.NewHexNumber(h.BaseFee)
+	}
+	return fields
+}
+
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
 type RPCTransaction struct {
-	BlockHash        common.Hash     `json:"blockHash"`
-	BlockNumber      *rpc.HexNumber  `json:"blockNumber"`
-	From             common.Address  `json:"from"`
-	Gas              *rpc.HexNumber  `json:"gas"`
-	GasPrice         *rpc.HexNumber  `json:"gasPrice"`
-	Hash             common.Hash     `json:"hash"`
-	Input            string          `json:"input"`
-	Nonce            *rpc.HexNumber  `json:"nonce"`
-	To               *common.Address `json:"to"`
-	TransactionIndex *rpc.HexNumber  `json:"transactionIndex"`
-	Value            *rpc.HexNumber  `json:"value"`
-	ReplayProtected  bool            `json:"replayProtected"`
-	ChainId          *big.Int        `json:"chainId,omitempty"`
-	V                *rpc.HexNumber  `json:"v"`
-	R                *rpc.HexNumber  `json:"r"`
-	S                *rpc.HexNumber  `json:"s"`
+	BlockHash        common.Hash       `json:"blockHash"`
+	BlockNumber      *rpc.HexNumber    `json:"blockNumber"`
+	From             common.Address    `json:"from"`
+	Gas              *rpc.HexNumber    `json:"gas"`
+	GasPrice         *rpc.HexNumber    `json:"gasPrice"`
+	Hash             common.Hash       `json:"hash"`
+	Input            string            `json:"input"`
+	Nonce            *rpc.HexNumber    `json:"nonce"`
+	To               *common.Address   `json:"to"`
+	TransactionIndex *rpc.HexNumber    `json:"transactionIndex"`
+	Value            *rpc.HexNumber    `json:"value"`
+	ReplayProtected  bool              `json:"replayProtected"`
+	ChainId          *big.Int          `json:"chainId,omitempty"`
+	Type             *rpc.HexNumber    `json:"type"`
+	AccessList       *types.AccessList `json:"accessList,omitempty"`
+	GasFeeCap        *rpc.HexNumber    `json:"maxFeePerGas,omitempty"`
+	GasTipCap        *rpc.HexNumber    `json:"maxPriorityFeePerGas,omitempty"`
+	V                *rpc.HexNumber    `json:"v"`
+	R                *rpc.HexNumber    `json:"r"`
+	S                *rpc.HexNumber    `json:"s"`
 }
 
 // newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
@@ -907,7 +1593,7 @@ func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
 		chainId = tx.ChainId()
 	}
 
-	return &RPCTransaction{
+	res := &RPCTransaction{
 		From:            from,
 		Gas:             rpc.NewHexNumber(tx.Gas()),
 		GasPrice:        rpc.NewHexNumber(tx.GasPrice()),
@@ -918,7 +1604,26 @@ func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
 		Value:           rpc.NewHexNumber(tx.Value()),
 		ReplayProtected: protected,
 		ChainId:         chainId,
+		Type:            rpc.NewHexNumber(tx.Type()),
+		AccessList:      rpcAccessList(tx),
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		res.GasFeeCap = rpc.NewHexNumber(tx.GasFeeCap())
+		res.GasTipCap = rpc.NewHexNumber(tx.GasTipCap())
+	}
+	return res
+}
+
+// rpcAccessList returns the access list of tx, nil for legacy transactions.
+func rpcAccessList(tx *types.Transaction) *types.AccessList {
+	if tx.Type() == types.LegacyTxType {
+		return nil
+	}
+	al := tx.AccessList()
+	if al == nil {
+		al = types.AccessList{}
 	}
+	return &al
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC representation.
@@ -937,7 +1642,7 @@ func newRPCTransactionFromBlockIndex(b *types.Block, txIndex int) (*RPCTransacti
 
 		v, r, s := tx.RawSignatureValues()
 
-		return &RPCTransaction{
+		res := &RPCTransaction{
 			BlockHash:        b.Hash(),
 			BlockNumber:      rpc.NewHexNumber(b.Number()),
 			From:             from,
@@ -951,10 +1656,19 @@ func newRPCTransactionFromBlockIndex(b *types.Block, txIndex int) (*RPCTransacti
 			Value:            rpc.NewHexNumber(tx.Value()),
 			ReplayProtected:  protected,
 			ChainId:          chainId,
+			Type:             rpc.NewHexNumber(tx.Type()),
+			AccessList:       rpcAccessList(tx),
 			V:                rpc.NewHexNumber(v),
 			R:                rpc.NewHexNumber(r),
 			S:                rpc.NewHexNumber(s),
-		}, nil
+		}
+		// Dynamic fee transactions report the price they paid in the block
+		if tx.Type() == types.DynamicFeeTxType {
+			res.GasPrice = rpc.NewHexNumber(tx.EffectiveGasPrice(b.BaseFee()))
+			res.GasFeeCap = rpc.NewHexNumber(tx.GasFeeCap())
+			res.GasTipCap = rpc.NewHexNumber(tx.GasTipCap())
+		}
+		return res, nil
 	}
 
 	return nil, nil
@@ -981,6 +1695,7 @@ type PublicTransactionPoolAPI struct {
 	am              *accounts.Manager
 	txPool          *core.TxPool
 	txMu            *sync.Mutex
+	audit           *signAudit
 	muPendingTxSubs sync.Mutex
 	pendingTxSubs   map[string]rpc.Subscription
 }
@@ -995,6 +1710,7 @@ func NewPublicTransactionPoolAPI(e *Ethereum) *PublicTransactionPoolAPI {
 		am:            e.accountManager,
 		txPool:        e.txPool,
 		txMu:          &e.txMu,
+		audit:         e.signAudit,
 		miner:         e.miner,
 		pendingTxSubs: make(map[string]rpc.Subscription),
 	}
@@ -1072,9 +1788,10 @@ func (s *PublicTransactionPoolAPI) GetTransactionByBlockHashAndIndex(blockHash c
 	return nil, nil
 }
 
-// GetTransactionCount returns the number of transactions the given address has sent for the given block number
-func (s *PublicTransactionPoolAPI) GetTransactionCount(address common.Address, blockNr rpc.BlockNumber) (*rpc.HexNumber, error) {
-	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+// GetTransactionCount returns the number of transactions the given address has sent for the given block number,
+// block hash or state root
+func (s *PublicTransactionPoolAPI) GetTransactionCount(address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*rpc.HexNumber, error) {
+	state, _, err := stateAndBlockByNumberOrHash(s.miner, s.bc, blockNrOrHash, s.chainDb)
 	if state == nil || err != nil {
 		return nil, err
 	}
@@ -1134,6 +1851,8 @@ func (s *PublicTransactionPoolAPI) GetTransactionByHash(txHash common.Hash) (*RP
 }
 
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
+// Receipts of reverted transactions carry the revert return data if the node
+// executed their block, rather than fast syncing it.
 func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (map[string]interface{}, error) {
 	receipt := core.GetReceipt(s.chainDb, txHash)
 	if receipt == nil {
@@ -1156,15 +1875,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 		// all previous transactions has to be executed. Because of that, it is
 		// reasonable to reprocess entire block and update all receipts from
 		// given block.
-		proc := s.bc.Processor()
-		block := s.bc.GetBlock(txBlock)
-		parent := s.bc.GetBlock(block.ParentHash())
-		statedb, err := s.bc.StateAt(parent.Root())
-		if err != nil {
-			return nil, fmt.Errorf("state not found - transaction status is not available for fast synced block: %v", err)
-		}
-
-		receipts, _, _, err := proc.Process(block, statedb)
+		receipts, err := s.reprocessBlock(txBlock)
 		if err != nil {
 			return nil, err
 		}
@@ -1172,10 +1883,12 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 		if err := core.WriteReceipts(s.chainDb, receipts); err != nil {
 			glog.V(logger.Warn).Infof("cannot save updated receipts: %v", err)
 		}
-		if err := core.WriteBlockReceipts(s.chainDb, block.Hash(), receipts); err != nil {
+		if err := core.WriteBlockReceipts(s.chainDb, txBlock, receipts); err != nil {
 			glog.V(logger.Warn).Infof("cannot save updated block receipts: %v", err)
 		}
 		receipt = receipts[index]
+	} else if receipt.Status == types.TxFailure {
+		receipt.RevertData = core.GetRevertData(s.chainDb, txBlock, txHash)
 	}
 
 	var signer types.Signer = types.BasicSigner{}
@@ -1185,7 +1898,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 	from, _ := types.Sender(signer, tx)
 
 	fields := map[string]interface{}{
-		"root":              common.Bytes2Hex(receipt.PostState),
+		"root":              nil,
 		"blockHash":         txBlock,
 		"blockNumber":       rpc.NewHexNumber(blockIndex),
 		"transactionHash":   txHash,
@@ -1196,6 +1909,11 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 		"cumulativeGasUsed": rpc.NewHexNumber(receipt.CumulativeGasUsed),
 		"contractAddress":   nil,
 		"logs":              receipt.Logs,
+		"type":              rpc.NewHexNumber(tx.Type()),
+		"effectiveGasPrice": rpc.NewHexNumber(tx.GasPrice()),
+	}
+	if header := s.bc.GetHeader(txBlock); header != nil && header.BaseFee != nil {
+		fields["effectiveGasPrice"] = rpc.NewHexNumber(tx.EffectiveGasPrice(header.BaseFee))
 	}
 
 	if receipt.Logs == nil {
@@ -1207,23 +1925,126 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 		fields["contractAddress"] = receipt.ContractAddress
 	}
 
-	// We're not fully compatible with EIP-609 - just return status for all blocks.
+	// Receipts carry either the intermediate state root or the status
+	// depending on the fork, but the status is known (and returned) for all
+	// blocks.
+	if len(receipt.PostState) > 0 {
+		fields["root"] = hexutil.Bytes(receipt.PostState)
+	}
 	fields["status"] = nil
 	if receipt.Status != types.TxStatusUnknown {
 		fields["status"] = rpc.NewHexNumber(receipt.Status)
 	}
+	if len(receipt.RevertData) > 0 {
+		fields["revertData"] = hexutil.Bytes(receipt.RevertData)
+		if reason, err := abi.UnpackRevert(receipt.RevertData); err == nil {
+			fields["revertReason"] = reason
+		}
+	}
 
 	return fields, nil
 }
 
-// sign is a helper function that signs a transaction with the private key of the given address.
-func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
-	signer := s.bc.Config().GetSigner(s.bc.CurrentBlock().Number())
+// ReceiptProof is a merkle proof of a transaction receipt's inclusion in its
+// block. The header hashes to BlockHash and its receipts root verifies the
+// proof: trie.VerifyProof(ReceiptsRoot, Key, Proof nodes) returns Receipt.
+type ReceiptProof struct {
+	BlockHash        common.Hash    `json:"blockHash"`
+	BlockNumber      *rpc.HexNumber `json:"blockNumber"`
+	Header           hexutil.Bytes  `json:"header"` // RLP encoded header
+	ReceiptsRoot     common.Hash    `json:"receiptsRoot"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex *rpc.HexNumber `json:"transactionIndex"`
+	Key              hexutil.Bytes  `json:"key"`     // Path of the receipt in the receipt trie
+	Receipt          hexutil.Bytes  `json:"receipt"` // RLP encoded consensus fields of the receipt
+	Proof            []string       `json:"proof"`
+}
 
-	signature, err := s.am.Sign(addr, signer.Hash(tx).Bytes())
-	if err != nil {
-		return nil, err
-	}
+// proofNodes collects the nodes of a merkle proof.
+type proofNodes []string
+
+func (p *proofNodes) Put(key []byte, value []byte) error {
+	*p = append(*p, common.ToHex(value))
+	return nil
+}
+
+// GetReceiptProof returns a merkle proof of the receipt of the given mined
+// transaction against the receipts root of its block, so the receipt and its
+// logs can be verified given only a trusted block hash.
+func (s *PublicTransactionPoolAPI) GetReceiptProof(txHash common.Hash) (*ReceiptProof, error) {
+	blockHash, blockNumber, index, err := getTransactionBlockData(s.chainDb, txHash)
+	if err != nil {
+		// Unknown or not yet mined transaction.
+		return nil, nil
+	}
+	header := core.GetHeader(s.chainDb, blockHash)
+	if header == nil {
+		return nil, fmt.Errorf("block %x not found", blockHash)
+	}
+	receipts := core.GetBlockReceipts(s.chainDb, blockHash)
+	if types.DeriveSha(receipts) != header.ReceiptHash {
+		// Receipts stored without their status don't encode to the consensus
+		// form, recompute them.
+		if receipts, err = s.reprocessBlock(blockHash); err != nil {
+			return nil, err
+		}
+		if types.DeriveSha(receipts) != header.ReceiptHash {
+			return nil, fmt.Errorf("receipts of block %x don't match its receipts root", blockHash)
+		}
+	}
+	if index >= uint64(len(receipts)) {
+		return nil, fmt.Errorf("receipt %d of block %x not found", index, blockHash)
+	}
+	proof := proofNodes{}
+	if _, err := types.DeriveProof(receipts, int(index), &proof); err != nil {
+		return nil, err
+	}
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		return nil, err
+	}
+	return &ReceiptProof{
+		BlockHash:        blockHash,
+		BlockNumber:      rpc.NewHexNumber(blockNumber),
+		Header:           enc,
+		ReceiptsRoot:     header.ReceiptHash,
+		TransactionHash:  txHash,
+		TransactionIndex: rpc.NewHexNumber(index),
+		Key:              types.DeriveKey(int(index)),
+		Receipt:          receipts.GetRlp(int(index)),
+		Proof:            proof,
+	}, nil
+}
+
+// reprocessBlock executes the block with the given hash on top of its parent
+// state and returns the resulting receipts.
+func (s *PublicTransactionPoolAPI) reprocessBlock(hash common.Hash) (types.Receipts, error) {
+	block := s.bc.GetBlock(hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	parent := s.bc.GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	statedb, err := s.bc.StateAtHeader(parent.Header())
+	if err != nil {
+		return nil, fmt.Errorf("state not found - transaction status is not available for fast synced block: %v", err)
+	}
+	receipts, _, _, err := s.bc.Processor().Process(block, statedb)
+	return receipts, err
+}
+
+// sign is a helper function that signs a transaction with the private key of the given address.
+// The operation is recorded in the audit log on behalf of method.
+func (s *PublicTransactionPoolAPI) sign(ctx context.Context, method string, addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	signer := s.bc.Config().GetSigner(s.bc.CurrentBlock().Number())
+
+	signature, err := s.am.Sign(addr, signer.Hash(tx).Bytes())
+	s.audit.recordTx(ctx, method, signApprovalUnlocked, addr, signer, tx, signature, err)
+	if err != nil {
+		return nil, err
+	}
 	return tx.WithSigner(signer).WithSignature(signature)
 }
 
@@ -1236,6 +2057,33 @@ type SendTxArgs struct {
 	Value    *rpc.HexNumber  `json:"value"`
 	Data     string          `json:"data"`
 	Nonce    *rpc.HexNumber  `json:"nonce"`
+
+	// Sends an access list transaction (EIP-2930) if set
+	AccessList *types.AccessList `json:"accessList"`
+
+	// Sends a dynamic fee transaction (EIP-1559) if MaxFeePerGas is set
+	MaxFeePerGas         *rpc.HexNumber `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *rpc.HexNumber `json:"maxPriorityFeePerGas"`
+}
+
+// toTransaction returns the unsigned transaction of args for the chain with
+// the given id.
+func (args *SendTxArgs) toTransaction(chainId *big.Int) *types.Transaction {
+	data := common.FromHex(args.Data)
+	switch {
+	case args.MaxFeePerGas != nil:
+		var al types.AccessList
+		if args.AccessList != nil {
+			al = *args.AccessList
+		}
+		return types.NewDynamicFeeTransaction(chainId, args.Nonce.Uint64(), args.To, args.Value.BigInt(), args.Gas.BigInt(), args.MaxPriorityFeePerGas.BigInt(), args.MaxFeePerGas.BigInt(), data, al)
+	case args.AccessList != nil:
+		return types.NewAccessListTransaction(chainId, args.Nonce.Uint64(), args.To, args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), data, *args.AccessList)
+	case args.To == nil:
+		return types.NewContractCreation(args.Nonce.Uint64(), args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), data)
+	default:
+		return types.NewTransaction(args.Nonce.Uint64(), *args.To, args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), data)
+	}
 }
 
 // prepareSendTxArgs is a helper function that fills in default values for unspecified tx fields.
@@ -1244,7 +2092,10 @@ func prepareSendTxArgs(args SendTxArgs, gpo *GasPriceOracle) SendTxArgs {
 		args.Gas = rpc.NewHexNumber(defaultGas)
 	}
 	if args.GasPrice == nil {
-		args.GasPrice = rpc.NewHexNumber(gpo.SuggestPrice())
+		args.GasPrice = rpc.NewHexNumber(gpo.SuggestPriceWithBaseFee())
+	}
+	if args.MaxFeePerGas != nil && args.MaxPriorityFeePerGas == nil {
+		args.MaxPriorityFeePerGas = rpc.NewHexNumber(gpo.SuggestPrice())
 	}
 	if args.Value == nil {
 		args.Value = rpc.NewHexNumber(0)
@@ -1279,7 +2130,7 @@ func submitTransaction(bc *core.BlockChain, txPool *core.TxPool, tx *types.Trans
 
 // SendTransaction creates a transaction for the given argument, sign it and submit it to the
 // transaction pool.
-func (s *PublicTransactionPoolAPI) SendTransaction(args SendTxArgs) (common.Hash, error) {
+func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args SendTxArgs) (common.Hash, error) {
 	args = prepareSendTxArgs(args, s.gpo)
 
 	s.txMu.Lock()
@@ -1289,17 +2140,13 @@ func (s *PublicTransactionPoolAPI) SendTransaction(args SendTxArgs) (common.Hash
 		args.Nonce = rpc.NewHexNumber(s.txPool.State().GetNonce(args.From))
 	}
 
-	var tx *types.Transaction
-	if args.To == nil {
-		tx = types.NewContractCreation(args.Nonce.Uint64(), args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), common.FromHex(args.Data))
-	} else {
-		tx = types.NewTransaction(args.Nonce.Uint64(), *args.To, args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), common.FromHex(args.Data))
-	}
+	tx := args.toTransaction(s.bc.Config().GetChainID(s.bc.CurrentBlock().Number()))
 
 	signer := s.bc.Config().GetSigner(s.bc.CurrentBlock().Number())
 	tx.SetSigner(signer)
 
 	signature, err := s.am.Sign(args.From, signer.Hash(tx).Bytes())
+	s.audit.recordTx(ctx, "eth_sendTransaction", signApprovalUnlocked, args.From, signer, tx, signature, err)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -1311,7 +2158,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(args SendTxArgs) (common.Hash
 // The sender is responsible for signing the transaction and using the correct nonce.
 func (s *PublicTransactionPoolAPI) SendRawTransaction(encodedTx string) (string, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(common.FromHex(encodedTx), tx); err != nil {
+	if err := tx.UnmarshalBinary(common.FromHex(encodedTx)); err != nil {
 		return "", err
 	}
 
@@ -1334,6 +2181,88 @@ func (s *PublicTransactionPoolAPI) SendRawTransaction(encodedTx string) (string,
 	return tx.Hash().Hex(), nil
 }
 
+// DecodedTransaction is a raw transaction decoded without submitting it, along
+// with every reason the transaction pool would reject it at the current head.
+type DecodedTransaction struct {
+	Tx       *RPCTransaction `json:"tx"`
+	Sender   *common.Address `json:"sender"` // Recovered from the signature, nil if recovery failed
+	Signer   string          `json:"signer"` // Signature scheme the transaction was signed with
+	Valid    bool            `json:"valid"`
+	Problems []string        `json:"problems"`
+}
+
+// DecodeRawTransaction decodes the given RLP encoded transaction and checks it
+// against the current chain rules and state, without submitting it.
+func (s *PublicTransactionPoolAPI) DecodeRawTransaction(encodedTx string) (*DecodedTransaction, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(common.FromHex(encodedTx)); err != nil {
+		return nil, err
+	}
+	v, r, sig := tx.RawSignatureValues()
+	res := &DecodedTransaction{
+		Tx:       newRPCPendingTransaction(tx),
+		Signer:   "homestead",
+		Problems: []string{},
+	}
+	res.Tx.V, res.Tx.R, res.Tx.S = rpc.NewHexNumber(v), rpc.NewHexNumber(r), rpc.NewHexNumber(sig)
+	if tx.Protected() {
+		res.Signer = "eip155"
+	}
+	problem := func(format string, args ...interface{}) {
+		res.Problems = append(res.Problems, fmt.Sprintf(format, args...))
+	}
+
+	// Recover the sender with the scheme the transaction claims, then check the
+	// scheme is acceptable on the current chain.
+	from, err := tx.From()
+	if err != nil {
+		problem("%v: %v", core.ErrInvalidSender, err)
+	} else {
+		res.Sender = &from
+	}
+	head := s.bc.CurrentBlock()
+	switch _, ok := s.bc.Config().GetSigner(head.Number()).(types.ChainIdSigner); {
+	case tx.Protected() && !ok:
+		problem("%v: replay protected transactions are not accepted before EIP-155 activation", core.ErrInvalidSender)
+	case tx.Protected():
+		if chainId := s.bc.Config().GetChainID(head.Number()); tx.ChainId().Cmp(chainId) != 0 {
+			problem("%v: signed for chain id %v, this chain uses %v", core.ErrInvalidSender, tx.ChainId(), chainId)
+		}
+	}
+
+	if _, blockHash, blockNumber, _ := core.GetTransaction(s.chainDb, tx.Hash()); blockHash != (common.Hash{}) {
+		problem("transaction already included in block #%d [%s…]", blockNumber, blockHash.Hex()[:10])
+	}
+	if tx.Value().Sign() < 0 {
+		problem("%v", core.ErrNegativeValue)
+	}
+	if gasLimit := s.bc.GasLimit(); tx.Gas().Cmp(gasLimit) > 0 {
+		problem("%v: gas %v, block gas limit %v", core.ErrGasLimit, tx.Gas(), gasLimit)
+	}
+	if intrGas := core.IntrinsicGas(tx.Data(), tx.AccessList(), tx.To() == nil, s.bc.Config().IsHomestead(head.Number())); tx.Gas().Cmp(intrGas) < 0 {
+		problem("%v: gas %v, intrinsic gas %v", core.ErrIntrinsicGas, tx.Gas(), intrGas)
+	}
+	if res.Sender != nil {
+		statedb, err := s.bc.State()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !statedb.Exist(from):
+			problem("%v: %s", core.ErrNonExistentAccount, from.Hex())
+		default:
+			if nonce := statedb.GetNonce(from); nonce > tx.Nonce() {
+				problem("%v: nonce %d, account nonce %d", core.ErrNonce, tx.Nonce(), nonce)
+			}
+			if balance := statedb.GetBalance(from); balance.Cmp(tx.Cost()) < 0 {
+				problem("%v: cost %v, balance %v", core.ErrInsufficientFunds, tx.Cost(), balance)
+			}
+		}
+	}
+	res.Valid = len(res.Problems) == 0
+	return res, nil
+}
+
 // signHash is a helper function that calculates a hash for the given message that can be
 // safely used to calculate a signature from.
 //
@@ -1376,9 +2305,10 @@ func (s *PrivateAccountAPI) EcRecover(data, sig hexutil.Bytes) (common.Address,
 
 // Sign signs the given hash using the key that matches the address. The key must be
 // unlocked in order to sign the hash.
-func (s *PublicBlockChainAPI) Sign(addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+func (s *PublicBlockChainAPI) Sign(ctx context.Context, addr common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
 	signed := signHash(data)
 	signature, err := s.am.Sign(addr, signed)
+	s.audit.recordMessage(ctx, "eth_sign", signApprovalUnlocked, addr, signed, err)
 	if err != nil {
 		return nil, err
 	}
@@ -1396,9 +2326,33 @@ type SignTransactionArgs struct {
 	GasPrice *rpc.HexNumber
 	Data     string
 
+	AccessList *types.AccessList
+
+	MaxFeePerGas         *rpc.HexNumber
+	MaxPriorityFeePerGas *rpc.HexNumber
+
 	BlockNumber int64
 }
 
+// toTransaction returns the unsigned transaction of args for the chain with
+// the given id.
+func (args *SignTransactionArgs) toTransaction(chainId *big.Int) *types.Transaction {
+	send := SendTxArgs{
+		From:       args.From,
+		To:         args.To,
+		Gas:        args.Gas,
+		GasPrice:   args.GasPrice,
+		Value:      args.Value,
+		Data:       args.Data,
+		Nonce:      args.Nonce,
+		AccessList: args.AccessList,
+
+		MaxFeePerGas:         args.MaxFeePerGas,
+		MaxPriorityFeePerGas: args.MaxPriorityFeePerGas,
+	}
+	return send.toTransaction(chainId)
+}
+
 // Tx is a helper object for argument and return values
 type Tx struct {
 	tx *types.Transaction
@@ -1491,12 +2445,15 @@ func newTx(t *types.Transaction) *Tx {
 // SignTransaction will sign the given transaction with the from account.
 // The node needs to have the private key of the account corresponding with
 // the given from address and it needs to be unlocked.
-func (s *PublicTransactionPoolAPI) SignTransaction(args SignTransactionArgs) (*SignTransactionResult, error) {
+func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args SignTransactionArgs) (*SignTransactionResult, error) {
 	if args.Gas == nil {
 		args.Gas = rpc.NewHexNumber(defaultGas)
 	}
 	if args.GasPrice == nil {
-		args.GasPrice = rpc.NewHexNumber(s.gpo.SuggestPrice())
+		args.GasPrice = rpc.NewHexNumber(s.gpo.SuggestPriceWithBaseFee())
+	}
+	if args.MaxFeePerGas != nil && args.MaxPriorityFeePerGas == nil {
+		args.MaxPriorityFeePerGas = rpc.NewHexNumber(s.gpo.SuggestPrice())
 	}
 	if args.Value == nil {
 		args.Value = rpc.NewHexNumber(0)
@@ -1509,19 +2466,14 @@ func (s *PublicTransactionPoolAPI) SignTransaction(args SignTransactionArgs) (*S
 		args.Nonce = rpc.NewHexNumber(s.txPool.State().GetNonce(args.From))
 	}
 
-	var tx *types.Transaction
-	if args.To == nil {
-		tx = types.NewContractCreation(args.Nonce.Uint64(), args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), common.FromHex(args.Data))
-	} else {
-		tx = types.NewTransaction(args.Nonce.Uint64(), *args.To, args.Value.BigInt(), args.Gas.BigInt(), args.GasPrice.BigInt(), common.FromHex(args.Data))
-	}
+	tx := args.toTransaction(s.bc.Config().GetChainID(s.bc.CurrentBlock().Number()))
 
-	signedTx, err := s.sign(args.From, tx)
+	signedTx, err := s.sign(ctx, "eth_signTransaction", args.From, tx)
 	if err != nil {
 		return nil, err
 	}
 
-	data, err := rlp.EncodeToBytes(signedTx)
+	data, err := signedTx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1574,7 +2526,7 @@ func (s *PublicTransactionPoolAPI) NewPendingTransactions(ctx context.Context) (
 
 // Resend accepts an existing transaction and a new gas price and limit. It will remove the given transaction from the
 // pool and reinsert it with the new gas price and limit.
-func (s *PublicTransactionPoolAPI) Resend(tx Tx, gasPrice, gasLimit *rpc.HexNumber) (common.Hash, error) {
+func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, tx Tx, gasPrice, gasLimit *rpc.HexNumber) (common.Hash, error) {
 
 	pending := s.txPool.GetTransactions()
 	for _, p := range pending {
@@ -1598,7 +2550,7 @@ func (s *PublicTransactionPoolAPI) Resend(tx Tx, gasPrice, gasLimit *rpc.HexNumb
 				newTx = types.NewTransaction(tx.tx.Nonce(), *tx.tx.To(), tx.tx.Value(), gasPrice.BigInt(), gasLimit.BigInt(), tx.tx.Data())
 			}
 
-			signedTx, err := s.sign(tx.From, newTx)
+			signedTx, err := s.sign(ctx, "eth_resend", tx.From, newTx)
 			if err != nil {
 				return common.Hash{}, err
 			}
@@ -1760,6 +2712,90 @@ func (api *PublicGethAPI) GetAddressTransactions(address common.Address, blockSt
 	return list, nil
 }
 
+// GetAccountsTransactions returns the transaction hashes of each of the node's
+// accounts, watch-only ones included, keyed by address. The options are those
+// of GetAddressTransactions.
+func (api *PublicGethAPI) GetAccountsTransactions(blockStartN uint64, blockEndN rpc.BlockNumber, toOrFrom string, txKindOf string, pagStart, pagEnd int, reverse bool) (map[string][]string, error) {
+	txs := make(map[string][]string)
+	for _, addr := range api.eth.AccountManager().Addresses() {
+		list, err := api.GetAddressTransactions(addr, blockStartN, blockEndN, toOrFrom, txKindOf, pagStart, pagEnd, reverse)
+		if err != nil {
+			return nil, err
+		}
+		txs[addr.Hex()] = list
+	}
+	return txs, nil
+}
+
+// GetTokenBalances returns the balances of address in the ERC20 and ERC721
+// tokens it has indexed transfers of. Balances are those of the transfers
+// indexed since the token index was enabled.
+func (api *PublicGethAPI) GetTokenBalances(address common.Address) ([]map[string]interface{}, error) {
+	db := api.eth.BlockChain().GetTokenIndex()
+	if db == nil {
+		return nil, errors.New("token indexing not enabled")
+	}
+	balances, err := core.GetTokenBalances(db, address)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]map[string]interface{}, len(balances))
+	for i, b := range balances {
+		list[i] = map[string]interface{}{
+			"token":   b.Token,
+			"type":    tokenType(b.NFT),
+			"balance": rpc.NewHexNumber(b.Balance),
+		}
+		if b.NFT {
+			ids := make([]*rpc.HexNumber, len(b.TokenIds))
+			for j, id := range b.TokenIds {
+				ids[j] = rpc.NewHexNumber(id)
+			}
+			list[i]["tokenIds"] = ids
+		}
+	}
+	return list, nil
+}
+
+// GetTokenTransfers returns the indexed ERC20 and ERC721 transfers from and to
+// address, of a single token if token is given, ordered by token and block.
+func (api *PublicGethAPI) GetTokenTransfers(address common.Address, token *common.Address) ([]map[string]interface{}, error) {
+	db := api.eth.BlockChain().GetTokenIndex()
+	if db == nil {
+		return nil, errors.New("token indexing not enabled")
+	}
+	transfers, err := core.GetTokenTransfers(db, address, token)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]map[string]interface{}, len(transfers))
+	for i, t := range transfers {
+		list[i] = map[string]interface{}{
+			"token":           t.Token,
+			"type":            tokenType(t.NFT),
+			"from":            t.From,
+			"to":              t.To,
+			"transactionHash": t.TxHash,
+			"blockNumber":     rpc.NewHexNumber(t.BlockNumber),
+			"logIndex":        rpc.NewHexNumber(t.LogIndex),
+		}
+		if t.NFT {
+			list[i]["tokenId"] = rpc.NewHexNumber(t.Value)
+		} else {
+			list[i]["value"] = rpc.NewHexNumber(t.Value)
+		}
+	}
+	return list, nil
+}
+
+// tokenType returns the RPC name of the standard of a token.
+func tokenType(nft bool) string {
+	if nft {
+		return "erc721"
+	}
+	return "erc20"
+}
+
 func (api *PublicGethAPI) BuildATXI(start, stop, step rpc.BlockNumber) (bool, error) {
 	glog.V(logger.Debug).Infof("RPC call: geth_buildATXI %v %v %v", start, stop, step)
 
@@ -1812,6 +2848,63 @@ func (api *PublicGethAPI) GetATXIBuildStatus() (*core.AtxiProgressT, error) {
 	return progress, nil
 }
 
+// emissionEras is the number of upcoming reward eras reported by GetEmission.
+const emissionEras = 5
+
+// resolveBlockNumber maps latest and pending to the current head number.
+func (api *PublicGethAPI) resolveBlockNumber(blockNr rpc.BlockNumber) (*big.Int, error) {
+	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+		return api.eth.BlockChain().CurrentBlock().Number(), nil
+	}
+	if api.eth.BlockChain().GetHeaderByNumber(uint64(blockNr.Int64())) == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr.Int64())
+	}
+	return big.NewInt(blockNr.Int64()), nil
+}
+
+// GetEmission returns the circulating supply and cumulative emission at the given
+// block, computed from the genesis allocation and the reward schedule, as well as
+// the current and upcoming reward eras. Uncle rewards are only counted if uncles
+// is true, which requires reading every block body and is slow.
+func (api *PublicGethAPI) GetEmission(blockNr rpc.BlockNumber, uncles *bool) (*core.Emission, error) {
+	num, err := api.resolveBlockNumber(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return core.GetEmission(api.eth.BlockChain(), num, uncles != nil && *uncles, emissionEras)
+}
+
+// GetRewardEras returns count reward eras (default 1), starting with the era of
+// the given block.
+func (api *PublicGethAPI) GetRewardEras(blockNr rpc.BlockNumber, count *int) ([]*core.RewardEra, error) {
+	num, err := api.resolveBlockNumber(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	n := 1
+	if count != nil {
+		n = *count
+	}
+	if n < 1 || n > 1000 {
+		return nil, errors.New("count must be between 1 and 1000")
+	}
+	return core.GetRewardEras(num, n), nil
+}
+
+// GetGasStats returns the gas utilization and gas price percentiles of the last
+// blocks (default and at most 256) and the contracts whose calls consumed the
+// most gas in them.
+func (api *PublicGethAPI) GetGasStats(blocks *int) (*GasStats, error) {
+	n := gasStatsWindow
+	if blocks != nil {
+		n = *blocks
+	}
+	if n < 1 || n > gasStatsWindow {
+		return nil, fmt.Errorf("blocks must be between 1 and %d", gasStatsWindow)
+	}
+	return api.eth.gasStats.stats(n), nil
+}
+
 // PublicDebugAPI is the collection of Etheruem APIs exposed over the public
 // debugging endpoint.
 type PublicDebugAPI struct {
@@ -1831,7 +2924,7 @@ func (api *PublicDebugAPI) DumpBlock(number uint64) (state.Dump, error) {
 	if block == nil {
 		return state.Dump{}, fmt.Errorf("block #%d not found", number)
 	}
-	stateDb, err := api.eth.BlockChain().StateAt(block.Root())
+	stateDb, err := api.eth.BlockChain().StateAtHeader(block.Header())
 	if err != nil {
 		return state.Dump{}, err
 	}
@@ -1844,13 +2937,22 @@ func (api *PublicDebugAPI) AccountExist(address common.Address, number uint64) (
 	if block == nil {
 		return false, fmt.Errorf("block #%d not found", number)
 	}
-	stateDb, err := api.eth.BlockChain().StateAt(block.Root())
+	stateDb, err := api.eth.BlockChain().StateAtHeader(block.Header())
 	if err != nil {
 		return false, err
 	}
 	return stateDb.Exist(address), nil
 }
 
+// StateAvailability lists the ranges of canonical blocks by the data the node
+// holds of them: the full state, only the headers, bodies and receipts (state
+// never synced or pruned), or only the headers of blocks still being synced.
+// Requests for state outside the "state" ranges fail with a state unavailable
+// error.
+func (api *PublicDebugAPI) StateAvailability() []core.AvailabilityRange {
+	return api.eth.BlockChain().StateAvailability()
+}
+
 // GetBlockRlp retrieves the RLP encoded for of a single block.
 func (api *PublicDebugAPI) GetBlockRlp(number uint64) (string, error) {
 	block := api.eth.BlockChain().GetBlockByNumber(number)
@@ -1986,12 +3088,320 @@ func (api *PublicDebugAPI) Vmodule(s string) (string, error) {
 	return ns, err
 }
 
+// PrivateDebugAPI is the collection of Ethereum APIs exposed over the private
+// debugging endpoint.
+type PrivateDebugAPI struct {
+	eth *Ethereum
+}
+
+// NewPrivateDebugAPI creates a new API definition for the private debug methods
+// of the Ethereum service.
+func NewPrivateDebugAPI(eth *Ethereum) *PrivateDebugAPI {
+	return &PrivateDebugAPI{eth: eth}
+}
+
+// chainLDB returns the LevelDB instance backing the chain database.
+func (api *PrivateDebugAPI) chainLDB() (*leveldb.DB, error) {
+	ldb, ok := api.eth.ChainDb().(interface {
+		LDB() *leveldb.DB
+	})
+	if !ok {
+		return nil, errors.New("chain database is not a LevelDB database")
+	}
+	return ldb.LDB(), nil
+}
+
+// Preimage returns the preimage of a hashed trie key, such as the address of
+// an account or the key of a storage slot.
+func (api *PrivateDebugAPI) Preimage(hash common.Hash) (string, error) {
+	preimage := core.GetPreimage(api.eth.ChainDb(), hash)
+	if preimage == nil {
+		return "", fmt.Errorf("preimage of %x not found", hash)
+	}
+	return common.ToHex(preimage), nil
+}
+
+// ChaindbProperty returns a LevelDB property of the chain database, such as
+// "stats", "sstables" or "num-files-at-level0". The "leveldb." prefix is
+// optional; an empty property returns the database statistics.
+func (api *PrivateDebugAPI) ChaindbProperty(property string) (string, error) {
+	ldb, err := api.chainLDB()
+	if err != nil {
+		return "", err
+	}
+	if property == "" {
+		property = "leveldb.stats"
+	} else if !strings.HasPrefix(property, "leveldb.") {
+		property = "leveldb." + property
+	}
+	return ldb.GetProperty(property)
+}
+
+// ChaindbCompact compacts the whole chain database, one key prefix range at a
+// time so the node stays responsive in between.
+func (api *PrivateDebugAPI) ChaindbCompact() error {
+	ldb, err := api.chainLDB()
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	for b := 0; b < 256; b++ {
+		glog.V(logger.Info).Infof("Compacting chain database range 0x%02x", b)
+		r := util.Range{Start: []byte{byte(b)}}
+		if b < 255 {
+			r.Limit = []byte{byte(b + 1)}
+		}
+		if err := ldb.CompactRange(r); err != nil {
+			glog.V(logger.Error).Errorf("Chain database compaction failed: %v", err)
+			return err
+		}
+	}
+	glog.V(logger.Info).Infof("Compacted chain database in %v", time.Since(start))
+	return nil
+}
+
+// StdTraceConfig holds the options of debug_standardTraceBlockToFile.
+type StdTraceConfig struct {
+	TxHash common.Hash `json:"txHash"` // Trace only this transaction if set
+}
+
+// StandardTraceBlockToFile replays the block with the given hash and writes a
+// standard JSON trace of each transaction, one step per line, to a file in the
+// temporary directory. It returns the names of the files written.
+func (api *PrivateDebugAPI) StandardTraceBlockToFile(hash common.Hash, config *StdTraceConfig) ([]string, error) {
+	bc := api.eth.BlockChain()
+	block := bc.GetBlock(hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	parent := bc.GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	statedb, err := bc.StateAtHeader(parent.Header())
+	if err != nil {
+		return nil, err
+	}
+	var txHash common.Hash
+	if config != nil {
+		txHash = config.TxHash
+	}
+
+	var (
+		chainConfig = api.eth.chainConfig
+		gp          = new(core.GasPool).AddGas(block.GasLimit())
+		files       []string
+	)
+	for i, tx := range block.Transactions() {
+		tx.SetSigner(chainConfig.GetSigner(block.Number()))
+		env := core.NewEnv(statedb, chainConfig, bc, tx, block.Header())
+
+		var (
+			file   *os.File
+			out    *bufio.Writer
+			tracer *vm.JSONLogger
+		)
+		if txHash == (common.Hash{}) || tx.Hash() == txHash {
+			prefix := fmt.Sprintf("block_%#x-%d-%#x-", block.Hash().Bytes()[:4], i, tx.Hash().Bytes()[:4])
+			if file, err = ioutil.TempFile(os.TempDir(), prefix); err != nil {
+				return files, err
+			}
+			files = append(files, file.Name())
+			out = bufio.NewWriter(file)
+			tracer = vm.NewJSONLogger(out)
+			env.SetTracer(tracer)
+		}
+		_, _, _, err := core.ApplyMessage(env, tx, gp)
+		if file != nil {
+			werr := tracer.Err()
+			if werr == nil {
+				werr = out.Flush()
+			}
+			file.Close()
+			if werr != nil {
+				return files, werr
+			}
+		}
+		if err != nil {
+			return files, fmt.Errorf("tx %x failed: %v", tx.Hash(), err)
+		}
+		if tx.Hash() == txHash {
+			return files, nil
+		}
+		if chainConfig.IsReceiptStatus(block.Number()) {
+			statedb.Finalise(chainConfig.IsStateClear(block.Number()))
+		} else {
+			statedb.IntermediateRoot(chainConfig.IsStateClear(block.Number()))
+		}
+	}
+	if txHash != (common.Hash{}) {
+		return nil, fmt.Errorf("transaction %x not found in block %x", txHash, hash)
+	}
+	return files, nil
+}
+
+// ProfileBlock replays the block with the given hash with an instrumented VM
+// and reports the time and gas spent per opcode, contract and transaction.
+func (api *PrivateDebugAPI) ProfileBlock(hash common.Hash) (*core.BlockProfile, error) {
+	block := api.eth.BlockChain().GetBlock(hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	return core.ProfileBlock(api.eth.BlockChain(), block)
+}
+
+// ExecutionWitness replays the block with the given hash and returns the trie
+// nodes, contract code and ancestor headers its execution reads, enough to
+// verify the block without the state.
+func (api *PrivateDebugAPI) ExecutionWitness(hash common.Hash) (*core.BlockWitness, error) {
+	block := api.eth.BlockChain().GetBlock(hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	return core.BuildWitness(api.eth.BlockChain(), block)
+}
+
+// defaultStateStatsTop is the number of contracts with the largest storage
+// reported by StateStats by default.
+const defaultStateStatsTop = 20
+
+// StateStats walks the state of the block with the given number and reports
+// its account, code and storage statistics.
+func (api *PrivateDebugAPI) StateStats(number uint64, top *int) (*state.StateStats, error) {
+	block := api.eth.BlockChain().GetBlockByNumber(number)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	stateDb, err := api.eth.BlockChain().StateAtHeader(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	n := defaultStateStatsTop
+	if top != nil {
+		n = *top
+	}
+	return stateDb.Stats(n)
+}
+
+// IntermediateRoots replays the block with the given hash and returns the state
+// root after each of its transactions. The roots don't include the block and
+// uncle rewards, which are only applied once all transactions have executed.
+func (api *PrivateDebugAPI) IntermediateRoots(hash common.Hash) ([]common.Hash, error) {
+	bc := api.eth.BlockChain()
+	block := bc.GetBlock(hash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", hash)
+	}
+	parent := bc.GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	statedb, err := bc.StateAtHeader(parent.Header())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		chainConfig = api.eth.chainConfig
+		gp          = new(core.GasPool).AddGas(block.GasLimit())
+		roots       = make([]common.Hash, 0, len(block.Transactions()))
+	)
+	for _, tx := range block.Transactions() {
+		tx.SetSigner(chainConfig.GetSigner(block.Number()))
+		env := core.NewEnv(statedb, chainConfig, bc, tx, block.Header())
+		if _, _, _, err := core.ApplyMessage(env, tx, gp); err != nil {
+			return roots, fmt.Errorf("tx %x failed: %v", tx.Hash(), err)
+		}
+		roots = append(roots, statedb.IntermediateRoot(chainConfig.IsStateClear(block.Number())))
+	}
+	return roots, nil
+}
+
+// SideBlock is a non-canonical block known to the node, either received in full
+// or only as an uncle header of a canonical block.
+type SideBlock struct {
+	Hash            common.Hash    `json:"hash"`
+	ParentHash      common.Hash    `json:"parentHash"`
+	Number          *rpc.HexNumber `json:"number"`
+	Miner           common.Address `json:"miner"`
+	Difficulty      *rpc.HexNumber `json:"difficulty"`
+	TotalDifficulty *rpc.HexNumber `json:"totalDifficulty"` // Nil if only the uncle header is known
+	Timestamp       *rpc.HexNumber `json:"timestamp"`
+	Transactions    *rpc.HexNumber `json:"transactions"` // Nil if only the uncle header is known
+	UncleOf         *common.Hash   `json:"uncleOf"`      // Canonical block including it as uncle, if any
+}
+
+// SideBlocks lists the non-canonical blocks with the given number: the side
+// blocks retained by the node (see --sideblocks.retain) and the uncles included
+// by canonical blocks, whether or not the node received the full block.
+func (api *PrivateDebugAPI) SideBlocks(number uint64) ([]*SideBlock, error) {
+	bc := api.eth.BlockChain()
+	if head := bc.CurrentBlock().NumberU64(); number > head {
+		return nil, fmt.Errorf("block #%d is above the head #%d", number, head)
+	}
+	var (
+		res    = []*SideBlock{}
+		byHash = make(map[common.Hash]*SideBlock)
+	)
+	add := func(h *types.Header) *SideBlock {
+		if sb := byHash[h.Hash()]; sb != nil {
+			return sb
+		}
+		sb := &SideBlock{
+			Hash:       h.Hash(),
+			ParentHash: h.ParentHash,
+			Number:     rpc.NewHexNumber(h.Number),
+			Miner:      h.Coinbase,
+			Difficulty: rpc.NewHexNumber(h.Difficulty),
+			Timestamp:  rpc.NewHexNumber(h.Time),
+		}
+		byHash[sb.Hash] = sb
+		res = append(res, sb)
+		return sb
+	}
+	for _, block := range bc.SideBlocks(number) {
+		sb := add(block.Header())
+		sb.Transactions = rpc.NewHexNumber(len(block.Transactions()))
+		if td := bc.GetTd(block.Hash()); td != nil {
+			sb.TotalDifficulty = rpc.NewHexNumber(td)
+		}
+	}
+	// Uncles can only be included by the 7 following blocks.
+	for n := number + 1; n <= number+7; n++ {
+		block := bc.GetBlockByNumber(n)
+		if block == nil {
+			break
+		}
+		for _, uncle := range block.Uncles() {
+			if uncle.Number.Uint64() == number {
+				hash := block.Hash()
+				add(uncle).UncleOf = &hash
+			}
+		}
+	}
+	return res, nil
+}
+
 // ExecutionResult groups all structured logs emitted by the EVM
 // while replaying a transaction in debug mode as well as the amount of
 // gas used and the return value
 type ExecutionResult struct {
-	Gas         *big.Int `json:"gas"`
-	ReturnValue string   `json:"returnValue"`
+	Gas         *big.Int       `json:"gas"`
+	ReturnValue string         `json:"returnValue"`
+	StructLogs  []vm.StructLog `json:"structLogs,omitempty"`
+	Truncated   bool           `json:"truncated,omitempty"` // whether struct logs were left out for the limit
+	Error       string         `json:"error,omitempty"`     // error the execution failed with
+}
+
+// defaultTraceTimeout is the time a JavaScript tracer may run for by default.
+const defaultTraceTimeout = 5 * time.Second
+
+// TraceConfig holds the options of debug_traceTransaction. Without a tracer
+// the steps are returned as struct logs, recorded as configured.
+type TraceConfig struct {
+	vm.LogConfig
+	Tracer  *string `json:"tracer"`  // JavaScript tracer, see package tracers
+	Timeout *string `json:"timeout"` // Time the tracer may run for, e.g. "10s"
 }
 
 // TraceCall executes a call and returns the amount of gas and optionally returned values.
@@ -2026,6 +3436,9 @@ func (s *PublicBlockChainAPI) TraceCall(args CallArgs, blockNr rpc.BlockNumber)
 		value:    args.Value.BigInt(),
 		data:     common.FromHex(args.Data),
 	}
+	if args.AccessList != nil {
+		msg.accessList = *args.AccessList
+	}
 	if msg.gas.Sign() == 0 {
 		msg.gas = big.NewInt(50000000)
 	}
@@ -2037,32 +3450,108 @@ func (s *PublicBlockChainAPI) TraceCall(args CallArgs, blockNr rpc.BlockNumber)
 	vmenv := core.NewEnv(stateDb, s.config, s.bc, msg, block.Header())
 	gp := new(core.GasPool).AddGas(common.MaxBig)
 
-	ret, gas, _, err := core.ApplyMessage(vmenv, msg, gp)
-	return &ExecutionResult{
+	ret, gas, failed, err := s.denied.applyMessage(vmenv, msg, gp)
+	if _, ok := err.(*deniedContractError); ok {
+		return nil, err
+	}
+	result := &ExecutionResult{
 		Gas:         gas,
 		ReturnValue: fmt.Sprintf("%x", ret),
-	}, nil
+	}
+	if err := executionError(vmenv, ret); failed && err != nil {
+		result.Error = err.Error()
+	}
+	return result, nil
 }
 
-// TraceTransaction returns the amount of gas and execution result of the given transaction.
-func (s *PublicDebugAPI) TraceTransaction(txHash common.Hash) (*ExecutionResult, error) {
-	var result *ExecutionResult
+// TraceTransaction returns the amount of gas and execution result of the given
+// transaction. With a config it also returns its steps or, if the config has a
+// tracer, the result of the tracer instead.
+func (s *PublicDebugAPI) TraceTransaction(txHash common.Hash, config *TraceConfig) (interface{}, error) {
 	tx, blockHash, _, txIndex := core.GetTransaction(s.eth.ChainDb(), txHash)
 	if tx == nil {
-		return result, fmt.Errorf("tx '%x' not found", txHash)
+		return nil, fmt.Errorf("tx '%x' not found", txHash)
 	}
 
 	msg, vmenv, err := s.computeTxEnv(blockHash, int(txIndex))
 	if err != nil {
 		return nil, err
 	}
+	if config != nil && config.Tracer != nil {
+		return s.traceWithTracer(tx, msg, vmenv, config)
+	}
+	var logger *vm.StructLogger
+	if config != nil {
+		logger = vm.NewStructLogger(&config.LogConfig)
+		vmenv.SetTracer(logger)
+	}
 
 	gp := new(core.GasPool).AddGas(tx.Gas())
-	ret, gas, _, err := core.ApplyMessage(vmenv, msg, gp)
-	return &ExecutionResult{
+	ret, gas, failed, err := s.eth.callDenyList.applyMessage(vmenv, msg, gp)
+	if _, ok := err.(*deniedContractError); ok {
+		return nil, err
+	}
+	result := &ExecutionResult{
 		Gas:         gas,
 		ReturnValue: fmt.Sprintf("%x", ret),
-	}, nil
+	}
+	if err := executionError(vmenv, ret); failed && err != nil {
+		result.Error = err.Error()
+	}
+	if logger != nil {
+		result.StructLogs = logger.StructLogs()
+		result.Truncated = logger.Truncated()
+	}
+	return result, nil
+}
+
+// traceWithTracer runs the transaction with the JavaScript tracer of the
+// config, returning what the tracer returns.
+func (s *PublicDebugAPI) traceWithTracer(tx *types.Transaction, msg core.Message, vmenv *core.VMEnv, config *TraceConfig) (json.RawMessage, error) {
+	timeout := defaultTraceTimeout
+	if config.Timeout != nil {
+		var err error
+		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
+			return nil, fmt.Errorf("invalid timeout: %v", err)
+		}
+	}
+	tracer, err := tracers.New(*config.Tracer)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.AfterFunc(timeout, func() { tracer.Stop(tracers.ErrTimeout) })
+	defer deadline.Stop()
+	vmenv.SetTracer(tracer)
+
+	gp := new(core.GasPool).AddGas(tx.Gas())
+	ret, gas, failed, err := s.eth.callDenyList.applyMessage(vmenv, msg, gp)
+	if err != nil {
+		return nil, err
+	}
+	from, _ := msg.From()
+	ctx := &tracers.Context{
+		Type:    "CALL",
+		From:    from,
+		Input:   msg.Data(),
+		Gas:     msg.Gas().Uint64(),
+		Value:   msg.Value(),
+		Output:  ret,
+		Block:   vmenv.BlockNumber().Uint64(),
+		GasUsed: gas.Uint64(),
+	}
+	if to := msg.To(); to != nil {
+		ctx.To = *to
+	} else {
+		ctx.Type = "CREATE"
+		ctx.To = crypto.CreateAddress(from, tx.Nonce())
+	}
+	if failed {
+		ctx.Err = executionError(vmenv, ret)
+		if ctx.Err == nil {
+			ctx.Err = errors.New("execution failed")
+		}
+	}
+	return tracer.Result(ctx, vmenv.Db())
 }
 
 // computeTxEnv returns the execution environment of a certain transaction.
@@ -2077,7 +3566,7 @@ func (s *PublicDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int) (core.
 	if parent == nil {
 		return nil, nil, fmt.Errorf("block parent %x not found", block.ParentHash())
 	}
-	statedb, err := s.eth.BlockChain().StateAt(parent.Root())
+	statedb, err := s.eth.BlockChain().StateAtHeader(parent.Header())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -2099,12 +3588,13 @@ func (s *PublicDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int) (core.
 		}
 
 		msg := callmsg{
-			from:     from,
-			to:       tx.To(),
-			gas:      tx.Gas(),
-			gasPrice: tx.GasPrice(),
-			value:    tx.Value(),
-			data:     tx.Data(),
+			from:       from,
+			to:         tx.To(),
+			gas:        tx.Gas(),
+			gasPrice:   tx.GasPrice(),
+			value:      tx.Value(),
+			data:       tx.Data(),
+			accessList: tx.AccessList(),
 		}
 
 		vmenv := core.NewEnv(statedb, s.eth.chainConfig, s.eth.BlockChain(), msg, block.Header())
@@ -2125,12 +3615,13 @@ func (s *PublicDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int) (core.
 // PublicNetAPI offers network related RPC methods
 type PublicNetAPI struct {
 	net            *p2p.Server
+	pm             *ProtocolManager
 	networkVersion int
 }
 
 // NewPublicNetAPI creates a new net API instance.
-func NewPublicNetAPI(net *p2p.Server, networkVersion int) *PublicNetAPI {
-	return &PublicNetAPI{net, networkVersion}
+func NewPublicNetAPI(net *p2p.Server, pm *ProtocolManager, networkVersion int) *PublicNetAPI {
+	return &PublicNetAPI{net, pm, networkVersion}
 }
 
 // Listening returns an indication if the node is listening for network connections.
@@ -2147,3 +3638,53 @@ func (s *PublicNetAPI) PeerCount() *rpc.HexNumber {
 func (s *PublicNetAPI) Version() string {
 	return fmt.Sprintf("%d", s.networkVersion)
 }
+
+// PeerStats breaks the connected Ethereum peers down by negotiated protocol
+// version, connection direction and head difficulty relative to the local chain.
+type PeerStats struct {
+	Total    int            `json:"total"`
+	Inbound  int            `json:"inbound"`
+	Outbound int            `json:"outbound"`
+	Versions map[string]int `json:"versions"` // Peers per negotiated protocol version, e.g. "web/63"
+
+	// Sync direction: peers ahead of the local chain are sync sources, peers
+	// behind it are expected to sync from us.
+	Ahead  int `json:"ahead"`
+	Level  int `json:"level"`
+	Behind int `json:"behind"`
+
+	LocalDifficulty *big.Int `json:"localDifficulty"`
+	BestDifficulty  *big.Int `json:"bestDifficulty"` // Highest head difficulty among peers, nil without peers
+}
+
+// PeerStats returns a breakdown of the connected Ethereum peers.
+func (s *PublicNetAPI) PeerStats() *PeerStats {
+	bc := s.pm.blockchain
+	stats := &PeerStats{
+		Versions:        make(map[string]int),
+		LocalDifficulty: bc.GetTd(bc.CurrentBlock().Hash()),
+	}
+	for _, p := range s.pm.peers.Peers() {
+		stats.Total++
+		if p.Inbound() {
+			stats.Inbound++
+		} else {
+			stats.Outbound++
+		}
+		stats.Versions[fmt.Sprintf("%s/%d", ProtocolName, p.version)]++
+
+		_, td := p.Head()
+		switch td.Cmp(stats.LocalDifficulty) {
+		case 1:
+			stats.Ahead++
+		case 0:
+			stats.Level++
+		default:
+			stats.Behind++
+		}
+		if stats.BestDifficulty == nil || td.Cmp(stats.BestDifficulty) > 0 {
+			stats.BestDifficulty = new(big.Int).Set(td)
+		}
+	}
+	return stats
+}
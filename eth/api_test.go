@@ -0,0 +1,532 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/webchain-network/webchaind/accounts"
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/eth/downloader"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+	"github.com/webchain-network/webchaind/miner"
+	"github.com/webchain-network/webchaind/rlp"
+	"github.com/webchain-network/webchaind/rpc"
+	"github.com/webchain-network/webchaind/trie"
+)
+
+func TestDecodeRawTransaction(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	core.WriteGenesisBlockForTesting(db, testBank)
+	chainConfig := &core.ChainConfig{Forks: []*core.Fork{{Name: "Homestead", Block: big.NewInt(0)}}}
+	blockchain, err := core.NewBlockChain(db, chainConfig, new(core.FakePow), new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := &PublicTransactionPoolAPI{bc: blockchain, chainDb: db}
+
+	unknownKey, _ := crypto.GenerateKey()
+	encode := func(signer types.Signer, key *ecdsa.PrivateKey, gas int64) string {
+		tx := types.NewTransaction(0, common.HexToAddress("0x01"), big.NewInt(1), big.NewInt(gas), big.NewInt(1), nil)
+		signed, err := signer.SignECDSA(tx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := rlp.EncodeToBytes(signed)
+		return common.ToHex(raw)
+	}
+
+	tests := []struct {
+		raw      string
+		sender   common.Address
+		problems []string
+	}{
+		{encode(types.BasicSigner{}, testBankKey, 21000), testBank.Address, nil},
+		{encode(types.BasicSigner{}, testBankKey, 20000), testBank.Address, []string{core.ErrIntrinsicGas.Error()}},
+		{encode(types.BasicSigner{}, unknownKey, 21000), crypto.PubkeyToAddress(unknownKey.PublicKey), []string{core.ErrNonExistentAccount.Error()}},
+		{encode(types.NewChainIdSigner(big.NewInt(62)), testBankKey, 21000), testBank.Address, []string{"EIP-155"}},
+	}
+	for i, tt := range tests {
+		res, err := api.DecodeRawTransaction(tt.raw)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if res.Sender == nil || *res.Sender != tt.sender {
+			t.Errorf("test %d: sender mismatch: got %v, want %x", i, res.Sender, tt.sender)
+		}
+		if res.Valid != (len(tt.problems) == 0) || len(res.Problems) != len(tt.problems) {
+			t.Errorf("test %d: got valid %v, problems %q", i, res.Valid, res.Problems)
+			continue
+		}
+		for j, want := range tt.problems {
+			if !strings.Contains(res.Problems[j], want) {
+				t.Errorf("test %d: problem %d: got %q, want it to mention %q", i, j, res.Problems[j], want)
+			}
+		}
+	}
+	if _, err := api.DecodeRawTransaction("0xdeadbeef"); err == nil {
+		t.Error("expected error decoding malformed transaction")
+	}
+}
+
+func TestIntermediateRoots(t *testing.T) {
+	acc1Key, _ := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	acc1Addr := crypto.PubkeyToAddress(acc1Key.PublicKey)
+
+	generator := func(i int, block *core.BlockGen) {
+		for _, value := range []int64{10000, 20000} {
+			tx, _ := types.NewTransaction(block.TxNonce(testBank.Address), acc1Addr, big.NewInt(value), core.TxGas, nil, nil).SignECDSA(testBankKey)
+			block.AddTx(tx)
+		}
+	}
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 2, generator, nil)
+	defer pm.Stop()
+
+	api := NewPrivateDebugAPI(&Ethereum{blockchain: pm.blockchain, chainConfig: pm.chainConfig})
+	block := pm.blockchain.CurrentBlock()
+
+	roots, err := api.IntermediateRoots(block.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Before Atlantis every receipt carries the state root after its transaction.
+	receipts := core.GetBlockReceipts(db, block.Hash())
+	if len(roots) != len(receipts) {
+		t.Fatalf("roots: got %d, want %d", len(roots), len(receipts))
+	}
+	for i, receipt := range receipts {
+		if want := common.BytesToHash(receipt.PostState); roots[i] != want {
+			t.Errorf("root %d: got %x, want %x", i, roots[i], want)
+		}
+	}
+	if _, err := api.IntermediateRoots(common.Hash{1}); err == nil {
+		t.Error("expected error for unknown block")
+	}
+}
+
+func TestGetBalanceByHashAndRoot(t *testing.T) {
+	acc := common.HexToAddress("0x1000")
+	generator := func(i int, block *core.BlockGen) {
+		tx, _ := types.NewTransaction(block.TxNonce(testBank.Address), acc, big.NewInt(1000), core.TxGas, nil, nil).SignECDSA(testBankKey)
+		block.AddTx(tx)
+	}
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 2, generator, nil)
+	defer pm.Stop()
+
+	// A shorter side chain stays in the database without becoming canonical.
+	coinbase := common.HexToAddress("0x2000")
+	side, _ := core.GenerateChain(core.DefaultConfigMorden.ChainConfig, pm.blockchain.Genesis(), db, 1, func(i int, block *core.BlockGen) {
+		block.SetCoinbase(coinbase)
+	})
+	if res := pm.blockchain.InsertChain(side); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	api := &PublicBlockChainAPI{bc: pm.blockchain, chainDb: db}
+	block := pm.blockchain.GetBlockByNumber(1)
+
+	for _, sel := range []rpc.BlockNumberOrHash{
+		rpc.BlockNumberOrHashWithNumber(1),
+		rpc.BlockNumberOrHashWithHash(block.Hash(), true),
+		rpc.BlockNumberOrHashWithRoot(block.Root()),
+	} {
+		balance, err := api.GetBalance(acc, sel)
+		if err != nil || balance.Int64() != 1000 {
+			t.Errorf("%v: got %v, %v, want 1000", sel, balance, err)
+		}
+	}
+	balance, err := api.GetBalance(coinbase, rpc.BlockNumberOrHashWithHash(side[0].Hash(), false))
+	if err != nil || balance.Sign() == 0 {
+		t.Errorf("side block: got %v, %v", balance, err)
+	}
+	if _, err := api.GetBalance(coinbase, rpc.BlockNumberOrHashWithHash(side[0].Hash(), true)); err == nil {
+		t.Error("expected error for non-canonical block")
+	}
+	if _, err := api.GetBalance(acc, rpc.BlockNumberOrHashWithRoot(common.Hash{1})); err == nil {
+		t.Error("expected error for unknown state root")
+	}
+	if balance, err := api.GetBalance(acc, rpc.BlockNumberOrHashWithHash(common.Hash{1}, false)); balance != nil || err != nil {
+		t.Errorf("unknown block: got %v, %v", balance, err)
+	}
+}
+
+func TestGetReceiptProof(t *testing.T) {
+	var txs []common.Hash
+	generator := func(i int, block *core.BlockGen) {
+		for j := 0; j < 20; j++ {
+			tx, _ := types.NewTransaction(block.TxNonce(testBank.Address), common.Address{byte(j)}, big.NewInt(1), core.TxGas, nil, nil).SignECDSA(testBankKey)
+			block.AddTx(tx)
+			txs = append(txs, tx.Hash())
+		}
+	}
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 1, generator, nil)
+	defer pm.Stop()
+
+	api := &PublicTransactionPoolAPI{bc: pm.blockchain, chainDb: db}
+	for i, hash := range txs {
+		proof, err := api.GetReceiptProof(hash)
+		if err != nil {
+			t.Fatalf("tx %d: %v", i, err)
+		}
+		var header types.Header
+		if err := rlp.DecodeBytes(proof.Header, &header); err != nil {
+			t.Fatalf("tx %d: invalid header: %v", i, err)
+		}
+		if header.Hash() != proof.BlockHash || header.ReceiptHash != proof.ReceiptsRoot {
+			t.Errorf("tx %d: header mismatch", i)
+		}
+		nodes, _ := ethdb.NewMemDatabase()
+		for _, node := range proof.Proof {
+			enc := common.FromHex(node)
+			nodes.Put(crypto.Keccak256(enc), enc)
+		}
+		value, err, _ := trie.VerifyProof(proof.ReceiptsRoot, proof.Key, nodes)
+		if err != nil {
+			t.Fatalf("tx %d: invalid proof: %v", i, err)
+		}
+		if !bytes.Equal(value, proof.Receipt) {
+			t.Errorf("tx %d: proven receipt mismatch", i)
+		}
+		var receipt types.Receipt
+		if err := rlp.DecodeBytes(value, &receipt); err != nil || receipt.CumulativeGasUsed.Int64() != int64(i+1)*core.TxGas.Int64() {
+			t.Errorf("tx %d: receipt mismatch: %v", i, err)
+		}
+	}
+	if proof, err := api.GetReceiptProof(common.Hash{1}); proof != nil || err != nil {
+		t.Errorf("unknown tx: got %v, %v", proof, err)
+	}
+}
+
+func TestRevertData(t *testing.T) {
+	var (
+		contract = crypto.CreateAddress(testBank.Address, 0)
+		// Reverts with the word 42: PUSH1 42 PUSH1 0 MSTORE PUSH1 32 PUSH1 0 REVERT
+		reverting = []byte{0x60, 0x2a, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xfd}
+		call      common.Hash
+	)
+	generator := func(i int, block *core.BlockGen) {
+		tx, _ := types.NewContractCreation(block.TxNonce(testBank.Address), new(big.Int), big.NewInt(200000), new(big.Int), deployCode(reverting)).SignECDSA(testBankKey)
+		block.AddTx(tx)
+		tx, _ = types.NewTransaction(block.TxNonce(testBank.Address), contract, new(big.Int), big.NewInt(100000), new(big.Int), nil).SignECDSA(testBankKey)
+		block.AddTx(tx)
+		call = tx.Hash()
+	}
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db, testBank)
+	config := &core.ChainConfig{Forks: []*core.Fork{{Name: "Homestead", Block: big.NewInt(0)}, {Name: "Hardfork2", Block: big.NewInt(0)}}}
+	blockchain, err := core.NewBlockChain(db, config, new(core.FakePow), new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, _ := core.GenerateChain(config, genesis, db, 1, generator)
+	if res := blockchain.InsertChain(chain); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	api := &PublicTransactionPoolAPI{bc: blockchain, chainDb: db}
+
+	receipt, err := api.GetTransactionReceipt(call)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := hexutil.Bytes(common.LeftPadBytes([]byte{42}, 32))
+	if have, _ := receipt["revertData"].(hexutil.Bytes); !bytes.Equal(have, want) {
+		t.Errorf("revert data mismatch: have %v, want %v", receipt["revertData"], want)
+	}
+
+	// Without stored revert data, as for fast synced blocks, the block isn't
+	// executed again
+	db.Delete(append([]byte("revert-data-"), append(chain[0].Hash().Bytes(), call.Bytes()...)...))
+	if receipt, err = api.GetTransactionReceipt(call); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := receipt["revertData"]; ok {
+		t.Errorf("unexpected revert data %v", data)
+	}
+}
+
+func TestSideBlocks(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db, testBank)
+	config := core.DefaultConfigMorden.ChainConfig
+	blockchain, err := core.NewBlockChain(db, config, new(core.FakePow), new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockchain.SetSideBlockRetention(10)
+
+	side, _ := core.GenerateChain(config, genesis, db, 1, func(i int, block *core.BlockGen) {
+		block.SetCoinbase(common.Address{1})
+	})
+	chain, _ := core.GenerateChain(config, genesis, db, 3, func(i int, block *core.BlockGen) {
+		if i == 2 {
+			block.AddUncle(side[0].Header())
+		}
+	})
+	if res := blockchain.InsertChain(chain); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	api := NewPrivateDebugAPI(&Ethereum{blockchain: blockchain, chainConfig: config})
+
+	// Only the uncle header is known before the side block is received.
+	blocks, err := api.SideBlocks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || blocks[0].Hash != side[0].Hash() || blocks[0].Transactions != nil {
+		t.Fatalf("side blocks mismatch: got %+v", blocks)
+	}
+	if blocks[0].UncleOf == nil || *blocks[0].UncleOf != chain[2].Hash() {
+		t.Errorf("uncle inclusion mismatch: got %v", blocks[0].UncleOf)
+	}
+	if res := blockchain.InsertChain(side); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	blocks, _ = api.SideBlocks(1)
+	if len(blocks) != 1 || blocks[0].Transactions == nil || blocks[0].TotalDifficulty == nil || blocks[0].UncleOf == nil {
+		t.Errorf("side blocks mismatch: got %+v", blocks)
+	}
+	if blocks, _ := api.SideBlocks(2); len(blocks) != 0 {
+		t.Errorf("unexpected side blocks: %+v", blocks)
+	}
+	if _, err := api.SideBlocks(4); err == nil {
+		t.Error("expected error above the head")
+	}
+}
+
+func TestProfileBlock(t *testing.T) {
+	// Contract code: PUSH1 1 PUSH1 0 SSTORE STOP
+	code := common.FromHex("0x6001600055" + "00")
+	var contract common.Address
+	generator := func(i int, block *core.BlockGen) {
+		switch i {
+		case 0:
+			// Deploy the contract, returning the code from memory.
+			// PUSH6 code PUSH1 0 MSTORE PUSH1 6 PUSH1 26 RETURN
+			initCode := append(append([]byte{0x65}, code...), common.FromHex("0x600052600660"+"1af3")...)
+			tx, _ := types.NewContractCreation(block.TxNonce(testBank.Address), new(big.Int), big.NewInt(100000), new(big.Int), initCode).SignECDSA(testBankKey)
+			block.AddTx(tx)
+			contract = crypto.CreateAddress(testBank.Address, tx.Nonce())
+		case 1:
+			tx, _ := types.NewTransaction(block.TxNonce(testBank.Address), contract, new(big.Int), big.NewInt(100000), nil, nil).SignECDSA(testBankKey)
+			block.AddTx(tx)
+		}
+	}
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db, testBank)
+	config := core.DefaultConfigMorden.ChainConfig
+	blockchain, err := core.NewBlockChain(db, config, new(core.FakePow), new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, _ := core.GenerateChain(config, genesis, db, 2, generator)
+	if res := blockchain.InsertChain(chain); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	api := NewPrivateDebugAPI(&Ethereum{blockchain: blockchain, chainConfig: config})
+	block := blockchain.CurrentBlock()
+
+	profile, err := api.ProfileBlock(block.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profile.Transactions) != 1 || profile.Transactions[0].Steps != 4 {
+		t.Fatalf("transactions: got %+v", profile.Transactions)
+	}
+	if profile.GasUsed.Cmp(block.GasUsed()) != 0 {
+		t.Errorf("gas used: got %v, want %v", profile.GasUsed, block.GasUsed())
+	}
+	if len(profile.Contracts) != 1 || profile.Contracts[0].Address != contract || profile.Contracts[0].Steps != 4 {
+		t.Errorf("contracts: got %+v", profile.Contracts)
+	}
+	ops := make(map[string]int)
+	for _, op := range profile.Ops {
+		ops[op.Op] = op.Count
+	}
+	if ops["PUSH1"] != 2 || ops["SSTORE"] != 1 || ops["STOP"] != 1 {
+		t.Errorf("ops: got %v", ops)
+	}
+}
+
+func TestExecutionWitness(t *testing.T) {
+	// Contract code: PUSH1 1 PUSH1 0 SSTORE STOP
+	code := common.FromHex("0x600160005500")
+	var contract common.Address
+	generator := func(i int, block *core.BlockGen) {
+		switch i {
+		case 0:
+			// PUSH6 code PUSH1 0 MSTORE PUSH1 6 PUSH1 26 RETURN
+			initCode := append(append([]byte{0x65}, code...), common.FromHex("0x6000526006601af3")...)
+			tx, _ := types.NewContractCreation(block.TxNonce(testBank.Address), new(big.Int), big.NewInt(100000), new(big.Int), initCode).SignECDSA(testBankKey)
+			block.AddTx(tx)
+			contract = crypto.CreateAddress(testBank.Address, tx.Nonce())
+		case 1:
+			tx, _ := types.NewTransaction(block.TxNonce(testBank.Address), contract, new(big.Int), big.NewInt(100000), nil, nil).SignECDSA(testBankKey)
+			block.AddTx(tx)
+		}
+	}
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db, testBank)
+	config := core.DefaultConfigMorden.ChainConfig
+	blockchain, err := core.NewBlockChain(db, config, new(core.FakePow), new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, _ := core.GenerateChain(config, genesis, db, 2, generator)
+	if res := blockchain.InsertChain(chain); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	api := NewPrivateDebugAPI(&Ethereum{blockchain: blockchain, chainConfig: config})
+	block := blockchain.CurrentBlock()
+
+	witness, err := api.ExecutionWitness(block.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(witness.Headers) != 1 || witness.Headers[0].Hash() != chain[0].Hash() {
+		t.Fatalf("headers: got %d", len(witness.Headers))
+	}
+	if len(witness.Codes) != 1 || !bytes.Equal(witness.Codes[0], code) {
+		t.Errorf("codes: got %x", witness.Codes)
+	}
+	if err := core.VerifyWitness(config, block, witness); err != nil {
+		t.Fatalf("witness verification failed: %v", err)
+	}
+	// Nothing of the witness can be left out
+	incomplete := *witness
+	incomplete.Nodes = incomplete.Nodes[1:]
+	if err := core.VerifyWitness(config, block, &incomplete); err == nil {
+		t.Error("expected missing node to fail verification")
+	}
+	incomplete = *witness
+	incomplete.Codes = nil
+	if err := core.VerifyWitness(config, block, &incomplete); err == nil {
+		t.Error("expected missing code to fail verification")
+	}
+}
+
+func TestPrivateTxPoolAPI(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	core.WriteGenesisBlockForTesting(db, testBank)
+	config := core.DefaultConfigMorden.ChainConfig
+	mux := new(event.TypeMux)
+	blockchain, err := core.NewBlockChain(db, config, new(core.FakePow), mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := core.NewTxPool(config, mux, blockchain.State, blockchain.GasLimit)
+	defer pool.Stop()
+	api := NewPrivateTxPoolAPI(&Ethereum{txPool: pool})
+
+	sign := func(nonce uint64, price int64) *types.Transaction {
+		tx, _ := types.NewTransaction(nonce, common.HexToAddress("0x01"), big.NewInt(1), core.TxGas, big.NewInt(price), nil).SignECDSA(testBankKey)
+		return tx
+	}
+	encode := func(tx *types.Transaction) string {
+		raw, _ := rlp.EncodeToBytes(tx)
+		return common.ToHex(raw)
+	}
+	orig := sign(0, 1)
+	if err := pool.Add(orig); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := api.ReplaceTransaction(orig.Hash(), encode(sign(1, 2))); err == nil {
+		t.Error("expected error replacing with a different nonce")
+	}
+	replacement := sign(0, 2)
+	hash, err := api.ReplaceTransaction(orig.Hash(), encode(replacement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != replacement.Hash() || api.pooled(orig.Hash()) != nil || api.pooled(hash) == nil {
+		t.Fatalf("replacement not pooled in place of original")
+	}
+
+	if ok, err := api.DropTransaction(hash); !ok || err != nil {
+		t.Fatalf("drop: got %v, %v", ok, err)
+	}
+	if api.pooled(hash) != nil {
+		t.Error("transaction still pooled after drop")
+	}
+	if _, err := api.DropTransaction(hash); err == nil {
+		t.Error("expected error dropping unknown transaction")
+	}
+}
+
+func TestPendingBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eth-pending-block-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	am, err := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, _ := ethdb.NewMemDatabase()
+	core.WriteGenesisBlockForTesting(db, testBank)
+	config := core.DefaultConfigMorden.ChainConfig
+	mux := new(event.TypeMux)
+	defer mux.Stop()
+	blockchain, err := core.NewBlockChain(db, config, new(core.FakePow), mux)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := core.NewTxPool(config, mux, blockchain.State, blockchain.GasLimit)
+	defer pool.Stop()
+
+	tx, _ := types.NewTransaction(0, common.HexToAddress("0x01"), big.NewInt(1), core.TxGas, big.NewInt(3), nil).SignECDSA(testBankKey)
+	if err := pool.Add(tx); err != nil {
+		t.Fatal(err)
+	}
+	e := &Ethereum{chainDb: db, dappDb: db, blockchain: blockchain, txPool: pool, accountManager: am, eventMux: mux}
+	e.miner = miner.New(e, config, mux, nil)
+
+	res, err := NewPrivateMinerAPI(e).PendingBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Number.Int64() != 1 || res.ParentHash != blockchain.Genesis().Hash() {
+		t.Fatalf("pending block #%v on %x, want #1 on genesis", res.Number, res.ParentHash)
+	}
+	if len(res.Transactions) != 1 || res.Transactions[0].Hash != tx.Hash() {
+		t.Fatalf("transactions: got %d, want %x", len(res.Transactions), tx.Hash())
+	}
+	if gas, fee := res.Transactions[0].GasUsed.Int64(), res.Transactions[0].Fee.Int64(); gas != core.TxGas.Int64() || fee != 3*gas {
+		t.Errorf("gas used %d, fee %d", gas, fee)
+	}
+	era := core.GetBlockEra(big.NewInt(1), core.EraLength)
+	if want := core.GetBlockWinnerRewardByEra(era); res.BlockReward.BigInt().Cmp(want) != 0 {
+		t.Errorf("block reward: got %v, want %v", res.BlockReward.BigInt(), want)
+	}
+	if want := new(big.Int).Add(res.BlockReward.BigInt(), res.Fees.BigInt()); res.Reward.BigInt().Cmp(want) != 0 || res.Fees.Int64() != res.Transactions[0].Fee.Int64() {
+		t.Errorf("reward: got %v, fees %v", res.Reward.BigInt(), res.Fees.BigInt())
+	}
+}
@@ -34,6 +34,8 @@ import (
 	"github.com/webchain-network/webchaind/common/httpclient"
 	"github.com/webchain-network/webchaind/common/registrar/ethreg"
 	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/forkid"
+	"github.com/webchain-network/webchaind/core/state"
 	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/eth/downloader"
 	"github.com/webchain-network/webchaind/eth/filters"
@@ -60,10 +62,18 @@ type Config struct {
 	SyncMode  downloader.SyncMode // Enables the state download based fast synchronisation algorithm
 	MaxPeers  int
 
+	Checkpoint *core.Checkpoint // Trusted sync checkpoint (nil for fully trustless sync)
+
 	BlockChainVersion  int
 	SkipBcVersionCheck bool // e.g. blockchain export
-	DatabaseCache      int
+	DatabaseCache      int  // Megabytes of LevelDB block cache and write buffers
 	DatabaseHandles    int
+	AncientDir         string // Database of ancient chain data, kept in the chain database if empty
+	TrieCleanCache     int    // Megabytes of recently read trie nodes kept in memory
+	TrieDirtyCache     int    // Megabytes of state and chain data buffered before flushing during sync
+	SnapshotCache      int    // Megabytes of flat account and storage values kept for recent states
+
+	StatePrefetchWorkers int // Goroutines loading state ahead of transaction execution, 0 disables prefetching
 
 	NatSpec   bool
 	DocRoot   string
@@ -76,7 +86,30 @@ type Config struct {
 	MinerThreads   int
 	SolcPath       string
 
+	MinerEmptyBlocks miner.EmptyBlockPolicy // Whether the miner waits for transactions before sealing empty blocks
+
 	UseAddrTxIndex bool
+	UseTokenIndex  bool // Whether token transfers are indexed by address
+
+	MinProtocolVersion uint // Lowest eth protocol version peers are accepted with (any if 0)
+	StrictForkID       bool // Whether peers not announcing the next scheduled fork are refused
+
+	SideBlockRetention uint64 // Number of blocks non-canonical blocks are kept and indexed for (not indexed if 0)
+
+	CallDenyList []common.Address // Contracts whose code local calls and traces refuse to run
+	CallTimeout  time.Duration    // Wall-clock limit of eth_call and eth_estimateGas executions (unlimited if 0)
+
+	SignAuditLog  string // File every signing operation is appended to (disabled if empty)
+	TxPoolJournal string // File the transaction pool is saved to on shutdown and restored from (disabled if empty)
+	NewBlockExec  string // Shell command run on every new canonical head (disabled if empty)
+
+	TxPoolPriceLimit  *big.Int // Minimum gas price of remote transactions accepted into the pool (none if nil)
+	TxPoolMaxNonceGap uint64   // Maximum number of nonces a transaction may skip (unlimited if 0)
+
+	TxPropagation TxPropagationPolicy // How transactions are relayed to peers (unset fields default)
+
+	FilterTimeout time.Duration // Time after which an unpolled filter is uninstalled (default if 0)
+	FilterPersist bool          // Whether installed log filters are kept across a restart
 
 	GpoMinGasPrice          *big.Int
 	GpoMaxGasPrice          *big.Int
@@ -103,6 +136,7 @@ type Ethereum struct {
 	// Handlers
 	txPool          *core.TxPool
 	txMu            sync.Mutex
+	unlockAllowed   bool // Whether account unlocking over RPC is permitted
 	blockchain      *core.BlockChain
 	accountManager  *accounts.Manager
 	pow             *cryptonight.Cryptonight
@@ -130,14 +164,37 @@ type Ethereum struct {
 	etherbase     common.Address
 	netVersionId  int
 	netRPCService *PublicNetAPI
+	gasStats      *gasTracker
+	signAudit     *signAudit
+	callDenyList  callDenyList
+	headHook      *headHook
 }
 
 func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
+	state.SetSnapshotCacheSize(config.SnapshotCache * 1024 * 1024)
+	state.SetPrefetchWorkers(config.StatePrefetchWorkers)
+	cacheConfig := &core.CacheConfig{
+		TrieCleanLimit: config.TrieCleanCache * 1024 * 1024,
+		ImportBatch:    ethdb.ImportBatchSize,
+	}
+	if config.TrieDirtyCache > 0 {
+		cacheConfig.ImportBatch = config.TrieDirtyCache * 1024 * 1024
+	}
+
 	// Open the chain database and perform any upgrades needed
 	chainDb, err := ctx.OpenDatabase("chaindata", config.DatabaseCache, config.DatabaseHandles)
 	if err != nil {
 		return nil, err
 	}
+	if config.AncientDir != "" {
+		ancientDb, err := ethdb.NewLDBDatabase(config.AncientDir, config.DatabaseCache, config.DatabaseHandles)
+		if err != nil {
+			return nil, err
+		}
+		chainDb = ethdb.NewAncientDatabase(chainDb, ancientDb, core.IsAncientKey)
+	} else if _, frozen := core.GetFrozenBlock(chainDb); frozen {
+		return nil, errors.New("chain data was moved to an ancient store, but no ancient data directory is configured")
+	}
 	if err := upgradeChainDatabase(chainDb); err != nil {
 		return nil, err
 	}
@@ -207,6 +264,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		GpobaseStepUp:           config.GpobaseStepUp,
 		GpobaseCorrectionFactor: config.GpobaseCorrectionFactor,
 		httpclient:              httpclient.New(config.DocRoot),
+		unlockAllowed:           ctx.AccountUnlockAllowed(),
 	}
 	switch {
 	case config.PowTest:
@@ -226,7 +284,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	// Initialize indexes db if enabled
 	// Blockchain will be assigned the db and atx enabled after blockchain is initialized below.
 	var indexesDb ethdb.Database
-	if config.UseAddrTxIndex {
+	if config.UseAddrTxIndex || config.UseTokenIndex {
 		// TODO: these are arbitrary numbers I just made up. Optimize?
 		// The reason these numbers are different than the atxi-build command is because for "appending" (vs. building)
 		// the atxi database should require far fewer resources since application performance is limited primarily by block import (chaindata db).
@@ -271,32 +329,71 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 
 	eth.chainConfig = config.ChainConfig
 
-	eth.blockchain, err = core.NewBlockChain(chainDb, eth.chainConfig, eth.pow, eth.EventMux())
+	eth.blockchain, err = core.NewBlockChainWithCache(chainDb, eth.chainConfig, cacheConfig, eth.pow, eth.EventMux())
 	if err != nil {
 		if err == core.ErrNoGenesis {
 			return nil, fmt.Errorf(`No chain found. Please initialise a new chain using the "init" subcommand.`)
 		}
 		return nil, err
 	}
+	eth.blockchain.SetSideBlockRetention(config.SideBlockRetention)
+	eth.callDenyList = newCallDenyList(config.CallDenyList)
 	// Configure enabled atxi for blockchain
 	if config.UseAddrTxIndex {
 		eth.blockchain.SetAtxi(&core.AtxiT{
 			Db: eth.indexesDb,
 		})
 	}
+	if config.UseTokenIndex {
+		eth.blockchain.SetTokenIndex(eth.indexesDb)
+	}
 
 	eth.gpo = NewGasPriceOracle(eth)
 
 	newPool := core.NewTxPool(eth.chainConfig, eth.EventMux(), eth.blockchain.State, eth.blockchain.GasLimit)
 	eth.txPool = newPool
+	if config.TxPoolPriceLimit != nil {
+		eth.txPool.SetPriceLimit(config.TxPoolPriceLimit)
+	}
+	eth.txPool.SetMaxNonceGap(config.TxPoolMaxNonceGap)
+	if config.TxPoolJournal != "" {
+		n, err := eth.txPool.LoadJournal(config.TxPoolJournal)
+		if err != nil {
+			glog.V(logger.Warn).Infof("Failed to restore transaction pool from %s: %v", config.TxPoolJournal, err)
+		} else if n > 0 {
+			glog.V(logger.Info).Infof("Restored %d transactions from %s", n, config.TxPoolJournal)
+		}
+	}
 
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.SyncMode, uint64(config.NetworkId), eth.eventMux, eth.txPool, eth.pow, eth.blockchain, chainDb); err != nil {
 		return nil, err
 	}
+	eth.protocolManager.downloader.SetImportBatchSize(cacheConfig.ImportBatch)
+	eth.protocolManager.txPolicy = config.TxPropagation.withDefaults()
+	eth.protocolManager.minVersion = int(config.MinProtocolVersion)
+	if config.StrictForkID {
+		eth.protocolManager.forkFilter = forkid.NewStrictFilter(eth.blockchain)
+	}
+	if cp := config.Checkpoint; cp != nil {
+		glog.V(logger.Info).Infof("Using trusted sync checkpoint: #%v [%s…]", cp.Number, cp.Hash.Hex()[:10])
+		eth.protocolManager.downloader.SetCheckpoint(cp)
+	}
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.pow)
 	if err = eth.miner.SetGasPrice(config.GasPrice); err != nil {
 		return nil, err
 	}
+	eth.miner.SetEmptyBlockPolicy(config.MinerEmptyBlocks)
+	eth.gasStats = newGasTracker(eth.blockchain, chainDb, eth.eventMux)
+	if config.NewBlockExec != "" {
+		eth.headHook = newHeadHook(config.NewBlockExec, eth.eventMux)
+	}
+
+	if config.SignAuditLog != "" {
+		if eth.signAudit, err = newSignAudit(config.SignAuditLog); err != nil {
+			return nil, err
+		}
+		glog.V(logger.Info).Infof("Recording signing operations to %s", config.SignAuditLog)
+	}
 
 	return eth, nil
 }
@@ -304,6 +401,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 // APIs returns the collection of RPC services the ethereum package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *Ethereum) APIs() []rpc.API {
+	filterAPI := filters.NewPublicFilterAPI(s.chainDb, s.eventMux, s.config.FilterTimeout, s.config.FilterPersist)
 	return []rpc.API{
 		{
 			Namespace: "eth",
@@ -323,7 +421,7 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   NewPublicBlockChainAPI(s.chainConfig, s.blockchain, s.miner, s.chainDb, s.gpo, s.eventMux, s.accountManager),
+			Service:   NewPublicBlockChainAPI(s.chainConfig, s.blockchain, s.miner, s.chainDb, s.gpo, s.eventMux, s.accountManager, s.signAudit, s.callDenyList, s.config.CallTimeout),
 			Public:    true,
 		}, {
 			Namespace: "eth",
@@ -353,17 +451,29 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.chainDb, s.eventMux),
+			Service:   filterAPI,
 			Public:    true,
+		}, {
+			Namespace: "debug",
+			Version:   "1.0",
+			Service:   filters.NewPrivateFilterAPI(filterAPI),
 		}, {
 			Namespace: "admin",
 			Version:   "1.0",
 			Service:   NewPrivateAdminAPI(s),
+		}, {
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   NewPrivateTxPoolAPI(s),
 		}, {
 			Namespace: "debug",
 			Version:   "1.0",
 			Service:   NewPublicDebugAPI(s),
 			Public:    true,
+		}, {
+			Namespace: "debug",
+			Version:   "1.0",
+			Service:   NewPrivateDebugAPI(s),
 		}, {
 			Namespace: "net",
 			Version:   "1.0",
@@ -430,7 +540,8 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 // Ethereum protocol implementation.
 func (s *Ethereum) Start(srvr *p2p.Server) error {
 	s.protocolManager.Start(s.config.MaxPeers)
-	s.netRPCService = NewPublicNetAPI(srvr, s.NetVersion())
+	srvr.SetDialRank(s.protocolManager.knownTD)
+	s.netRPCService = NewPublicNetAPI(srvr, s.protocolManager, s.NetVersion())
 	return nil
 }
 
@@ -439,12 +550,21 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 func (s *Ethereum) Stop() error {
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
+	if s.config.TxPoolJournal != "" {
+		if n, err := s.txPool.SaveJournal(s.config.TxPoolJournal); err != nil {
+			glog.V(logger.Error).Errorf("Failed to save transaction pool to %s: %v", s.config.TxPoolJournal, err)
+		} else {
+			glog.V(logger.Info).Infof("Saved %d transactions to %s", n, s.config.TxPoolJournal)
+		}
+	}
 	s.txPool.Stop()
 	s.miner.Stop()
+	s.headHook.close()
 	s.eventMux.Stop()
 
 	s.chainDb.Close()
 	s.dappDb.Close()
+	s.signAudit.close()
 	close(s.shutdownChan)
 
 	return nil
@@ -492,9 +612,9 @@ func upgradeChainDatabase(db ethdb.Database) error {
 	// At least some of the database is still the old format, upgrade (skip the head block!)
 	glog.V(logger.Info).Info("Old database detected, upgrading...")
 
-	if db, ok := db.(*ethdb.LDBDatabase); ok {
+	if iteratee, ok := db.(ethdb.Iteratee); ok {
 		blockPrefix := []byte("block-hash-")
-		for it := db.NewIterator(); it.Next(); {
+		for it := iteratee.NewIterator(); it.Next(); {
 			// Skip anything other than a combined block
 			if !bytes.HasPrefix(it.Key(), blockPrefix) {
 				continue
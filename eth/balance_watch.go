@@ -0,0 +1,93 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/rpc"
+)
+
+// maxWatchedAccounts is the number of addresses a balances subscription may
+// watch.
+const maxWatchedAccounts = 1000
+
+// BalanceChange is the notification of a balances subscription, sent when the
+// balance or nonce of a watched account differs in a new head's state.
+type BalanceChange struct {
+	Address     common.Address `json:"address"`
+	Balance     *rpc.HexNumber `json:"balance"`
+	Nonce       *rpc.HexNumber `json:"nonce"`
+	BlockNumber *rpc.HexNumber `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+}
+
+// balanceNotifier sends the changes of a balances subscription in the state of
+// a new head.
+type balanceNotifier func(head *types.Block, statedb *state.StateDB) error
+
+type accountSnapshot struct {
+	balance *big.Int
+	nonce   uint64
+}
+
+// balanceWatch tracks the balances and nonces of a set of accounts from head
+// to head. As the states are compared, reorgs are reported like other changes.
+type balanceWatch struct {
+	addrs []common.Address
+	last  map[common.Address]accountSnapshot
+}
+
+// newBalanceWatch starts watching addrs from their values in statedb.
+func newBalanceWatch(addrs []common.Address, statedb *state.StateDB) *balanceWatch {
+	w := &balanceWatch{last: make(map[common.Address]accountSnapshot, len(addrs))}
+	for _, addr := range addrs {
+		if _, ok := w.last[addr]; ok {
+			continue
+		}
+		w.addrs = append(w.addrs, addr)
+		w.last[addr] = snapshotAccount(statedb, addr)
+	}
+	return w
+}
+
+func snapshotAccount(statedb *state.StateDB, addr common.Address) accountSnapshot {
+	return accountSnapshot{new(big.Int).Set(statedb.GetBalance(addr)), statedb.GetNonce(addr)}
+}
+
+// update returns the accounts which changed in statedb, the state of block.
+func (w *balanceWatch) update(block *types.Block, statedb *state.StateDB) []*BalanceChange {
+	var changes []*BalanceChange
+	for _, addr := range w.addrs {
+		snap := snapshotAccount(statedb, addr)
+		if last := w.last[addr]; last.nonce == snap.nonce && last.balance.Cmp(snap.balance) == 0 {
+			continue
+		}
+		w.last[addr] = snap
+		changes = append(changes, &BalanceChange{
+			Address:     addr,
+			Balance:     rpc.NewHexNumber(snap.balance),
+			Nonce:       rpc.NewHexNumber(snap.nonce),
+			BlockNumber: rpc.NewHexNumber(block.Number()),
+			BlockHash:   block.Hash(),
+		})
+	}
+	return changes
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/eth/downloader"
+)
+
+func TestBalanceWatch(t *testing.T) {
+	acc := common.HexToAddress("0x1000")
+	generator := func(i int, block *core.BlockGen) {
+		if i == 0 {
+			tx, _ := types.NewTransaction(block.TxNonce(testBank.Address), acc, big.NewInt(1000), core.TxGas, nil, nil).SignECDSA(testBankKey)
+			block.AddTx(tx)
+		}
+	}
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 2, generator, nil)
+	defer pm.Stop()
+
+	bc := pm.blockchain
+	stateAt := func(n uint64) (*types.Block, *state.StateDB) {
+		block := bc.GetBlockByNumber(n)
+		statedb, err := bc.StateAt(block.Root())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return block, statedb
+	}
+	_, genesisState := stateAt(0)
+	watch := newBalanceWatch([]common.Address{acc, testBank.Address, acc, common.HexToAddress("0x2000")}, genesisState)
+
+	block, statedb := stateAt(1)
+	changes := watch.update(block, statedb)
+	if len(changes) != 2 {
+		t.Fatalf("block 1: got %d changes, want 2", len(changes))
+	}
+	if c := changes[0]; c.Address != acc || c.Balance.BigInt().Int64() != 1000 || c.Nonce.Int64() != 0 || c.BlockHash != block.Hash() {
+		t.Errorf("recipient change mismatch: %+v", c)
+	}
+	if c := changes[1]; c.Address != testBank.Address || c.Nonce.Int64() != 1 {
+		t.Errorf("sender change mismatch: %+v", c)
+	}
+	if changes := watch.update(stateAt(2)); len(changes) != 0 {
+		t.Errorf("block 2: got %d changes, want none", len(changes))
+	}
+	// Going back, e.g. on a reorg, reverts the changes.
+	if changes := watch.update(stateAt(0)); len(changes) != 2 || changes[0].Balance.BigInt().Sign() != 0 {
+		t.Errorf("reverted changes mismatch: %v", changes)
+	}
+}
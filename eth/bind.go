@@ -17,11 +17,11 @@
 package eth
 
 import (
+	"context"
 	"math/big"
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core/types"
-	"github.com/webchain-network/webchaind/rlp"
 	"github.com/webchain-network/webchaind/rpc"
 )
 
@@ -43,7 +43,7 @@ type ContractBackend struct {
 func NewContractBackend(eth *Ethereum) *ContractBackend {
 	return &ContractBackend{
 		eapi:  NewPublicEthereumAPI(eth),
-		bcapi: NewPublicBlockChainAPI(eth.chainConfig, eth.blockchain, eth.miner, eth.chainDb, eth.gpo, eth.eventMux, eth.accountManager),
+		bcapi: NewPublicBlockChainAPI(eth.chainConfig, eth.blockchain, eth.miner, eth.chainDb, eth.gpo, eth.eventMux, eth.accountManager, eth.signAudit, eth.callDenyList, eth.config.CallTimeout),
 		txapi: NewPublicTransactionPoolAPI(eth),
 	}
 }
@@ -55,7 +55,7 @@ func (b *ContractBackend) HasCode(contract common.Address, pending bool) (bool,
 	if pending {
 		block = rpc.PendingBlockNumber
 	}
-	out, err := b.bcapi.GetCode(contract, block)
+	out, err := b.bcapi.GetCode(contract, rpc.BlockNumberOrHashWithNumber(block))
 	return len(common.FromHex(out)) > 0, err
 }
 
@@ -73,14 +73,14 @@ func (b *ContractBackend) ContractCall(contract common.Address, data []byte, pen
 		block = rpc.PendingBlockNumber
 	}
 	// Execute the call and convert the output back to Go types
-	out, err := b.bcapi.Call(args, block)
+	out, err := b.bcapi.Call(context.Background(), args, block)
 	return common.FromHex(out), err
 }
 
 // PendingAccountNonce implements bind.ContractTransactor retrieving the current
 // pending nonce associated with an account.
 func (b *ContractBackend) PendingAccountNonce(account common.Address) (uint64, error) {
-	out, err := b.txapi.GetTransactionCount(account, rpc.PendingBlockNumber)
+	out, err := b.txapi.GetTransactionCount(account, rpc.BlockNumberOrHashWithNumber(rpc.PendingBlockNumber))
 	return out.Uint64(), err
 }
 
@@ -96,7 +96,7 @@ func (b *ContractBackend) SuggestGasPrice() (*big.Int, error) {
 // requirement as other transactions may be added or removed by miners, but it
 // should provide a basis for setting a reasonable default.
 func (b *ContractBackend) EstimateGasLimit(sender common.Address, contract *common.Address, value *big.Int, data []byte) (*big.Int, error) {
-	out, err := b.bcapi.EstimateGas(CallArgs{
+	out, err := b.bcapi.EstimateGas(context.Background(), CallArgs{
 		From:  sender,
 		To:    contract,
 		Value: *rpc.NewHexNumber(value),
@@ -108,7 +108,7 @@ func (b *ContractBackend) EstimateGasLimit(sender common.Address, contract *comm
 // SendTransaction implements bind.ContractTransactor injects the transaction
 // into the pending pool for execution.
 func (b *ContractBackend) SendTransaction(tx *types.Transaction) error {
-	raw, _ := rlp.EncodeToBytes(tx)
+	raw, _ := tx.MarshalBinary()
 	_, err := b.txapi.SendRawTransaction(common.ToHex(raw))
 	return err
 }
@@ -0,0 +1,97 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+// callCacheLimit is the number of call results kept.
+const callCacheLimit = 4096
+
+// callKey identifies a read-only call: the block it executes on and its
+// parameters, with defaults already filled in.
+type callKey struct {
+	block    common.Hash
+	from     common.Address
+	to       common.Address
+	create   bool
+	gas      string
+	gasPrice string
+	value    string
+	data     string
+
+	accessList common.Hash // hash of the access list, if any
+}
+
+func newCallKey(block common.Hash, msg callmsg) callKey {
+	key := callKey{
+		block:    block,
+		from:     msg.from.Address(),
+		create:   msg.to == nil,
+		gas:      msg.gas.String(),
+		gasPrice: msg.gasPrice.String(),
+		value:    msg.value.String(),
+		data:     string(msg.data),
+	}
+	if msg.to != nil {
+		key.to = *msg.to
+	}
+	if msg.accessList != nil {
+		enc, _ := rlp.EncodeToBytes(msg.accessList)
+		key.accessList = crypto.Keccak256Hash(enc)
+	}
+	return key
+}
+
+type callResult struct {
+	ret string
+	gas *big.Int
+}
+
+// callCache holds the results of eth_call and eth_estimateGas. Results are
+// keyed by block hash so they never go stale, but are dropped on every new
+// head as calls mostly target the latest and pending blocks.
+type callCache struct {
+	results *lru.Cache
+}
+
+func newCallCache() *callCache {
+	results, _ := lru.New(callCacheLimit)
+	return &callCache{results: results}
+}
+
+func (c *callCache) get(key callKey) (string, *big.Int, bool) {
+	if v, ok := c.results.Get(key); ok {
+		res := v.(callResult)
+		return res.ret, new(big.Int).Set(res.gas), true
+	}
+	return "", nil, false
+}
+
+func (c *callCache) add(key callKey, ret string, gas *big.Int) {
+	c.results.Add(key, callResult{ret: ret, gas: new(big.Int).Set(gas)})
+}
+
+func (c *callCache) purge() {
+	c.results.Purge()
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func TestCallCache(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	to := common.HexToAddress("0x01")
+	msg := callmsg{
+		from:     statedb.GetOrNewStateObject(common.HexToAddress("0xaa")),
+		to:       &to,
+		gas:      big.NewInt(50000),
+		gasPrice: big.NewInt(1),
+		value:    new(big.Int),
+		data:     []byte{0x01},
+	}
+	block1, block2 := common.HexToHash("0x01"), common.HexToHash("0x02")
+
+	cache := newCallCache()
+	cache.add(newCallKey(block1, msg), "0x2a", big.NewInt(21000))
+
+	if ret, gas, ok := cache.get(newCallKey(block1, msg)); !ok || ret != "0x2a" || gas.Cmp(big.NewInt(21000)) != 0 {
+		t.Errorf("cached call: got %v %v %v", ret, gas, ok)
+	}
+	if _, _, ok := cache.get(newCallKey(block2, msg)); ok {
+		t.Error("result served for a different block")
+	}
+	other := msg
+	other.data = []byte{0x02}
+	if _, _, ok := cache.get(newCallKey(block1, other)); ok {
+		t.Error("result served for different call data")
+	}
+	create := msg
+	create.to = nil
+	if _, _, ok := cache.get(newCallKey(block1, create)); ok {
+		t.Error("result served for a contract creation")
+	}
+
+	cache.purge()
+	if _, _, ok := cache.get(newCallKey(block1, msg)); ok {
+		t.Error("result served after purge")
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+)
+
+// callDenyList is the set of contracts whose code is never run by the RPC
+// methods simulating executions locally (calls, gas estimations and traces),
+// e.g. known gas bombs a public node shouldn't spend its CPU on. A nil list
+// denies nothing.
+type callDenyList map[common.Address]bool
+
+func newCallDenyList(addrs []common.Address) callDenyList {
+	if len(addrs) == 0 {
+		return nil
+	}
+	l := make(callDenyList, len(addrs))
+	for _, addr := range addrs {
+		l[addr] = true
+	}
+	return l
+}
+
+// applyMessage executes msg in env, refusing to run the denied contracts. A
+// call reaching one of them fails, even if the caller would have recovered.
+func (l callDenyList) applyMessage(env *core.VMEnv, msg core.Message, gp *core.GasPool) ([]byte, *big.Int, bool, error) {
+	env.SetDenyList(l)
+	ret, gas, failed, err := core.ApplyMessage(env, msg, gp)
+	if addr, ok := env.DeniedContract(); ok {
+		return nil, nil, true, &deniedContractError{addr}
+	}
+	return ret, gas, failed, err
+}
+
+// deniedContractError is returned for executions reaching a denied contract.
+type deniedContractError struct {
+	addr common.Address
+}
+
+func (e *deniedContractError) Error() string {
+	return fmt.Sprintf("execution of contract %s denied by node configuration", e.addr.Hex())
+}
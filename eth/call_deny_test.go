@@ -0,0 +1,215 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+	"github.com/webchain-network/webchaind/rpc"
+)
+
+// deployCode returns init code deploying the given runtime code.
+func deployCode(code []byte) []byte {
+	return append([]byte{0x60, byte(len(code)), 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, byte(len(code)), 0x60, 0x00, 0xf3}, code...)
+}
+
+func TestCallDenyList(t *testing.T) {
+	var (
+		denied = crypto.CreateAddress(testBank.Address, 0)
+		caller = crypto.CreateAddress(testBank.Address, 1)
+		other  = crypto.CreateAddress(testBank.Address, 2)
+
+		// Returns 1
+		returnOne = []byte{0x60, 0x01, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+		// Calls the denied contract, ignoring the result
+		callDenied = append(append([]byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x73}, denied.Bytes()...), 0x5a, 0xf1, 0x00)
+	)
+	generator := func(i int, block *core.BlockGen) {
+		for _, code := range [][]byte{returnOne, callDenied, returnOne} {
+			tx, _ := types.NewContractCreation(block.TxNonce(testBank.Address), new(big.Int), big.NewInt(200000), new(big.Int), deployCode(code)).SignECDSA(testBankKey)
+			block.AddTx(tx)
+		}
+	}
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db, testBank)
+	config := core.DefaultConfigMorden.ChainConfig
+	blockchain, err := core.NewBlockChain(db, config, new(core.FakePow), new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, _ := core.GenerateChain(config, genesis, db, 1, generator)
+	if res := blockchain.InsertChain(chain); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+
+	api := &PublicBlockChainAPI{
+		config:  config,
+		bc:      blockchain,
+		chainDb: db,
+		calls:   newCallCache(),
+		denied:  newCallDenyList([]common.Address{denied}),
+	}
+	args := func(to common.Address) CallArgs {
+		return CallArgs{From: testBank.Address, To: &to, Gas: rpc.NewHexNumber(100000), GasPrice: rpc.NewHexNumber(1)}
+	}
+	call := func(to common.Address) (string, error) {
+		return api.Call(context.Background(), args(to), rpc.LatestBlockNumber)
+	}
+	if ret, err := call(other); err != nil || ret != common.ToHex(common.LeftPadBytes([]byte{1}, 32)) {
+		t.Errorf("allowed contract: got %s, %v", ret, err)
+	}
+	for _, to := range []common.Address{denied, caller} {
+		if _, err := call(to); err == nil {
+			t.Errorf("call to %x: expected error", to)
+		}
+		if _, err := api.TraceCall(args(to), rpc.LatestBlockNumber); err == nil {
+			t.Errorf("trace of %x: expected error", to)
+		}
+	}
+	// Without a deny list the calls go through.
+	api.denied = nil
+	if _, err := call(caller); err != nil {
+		t.Errorf("call without deny list: %v", err)
+	}
+}
+
+// newCallTestAPI returns an API on a chain with the given runtime code deployed
+// at the returned address.
+func newCallTestAPI(t *testing.T, code []byte) (*PublicBlockChainAPI, common.Address) {
+	generator := func(i int, block *core.BlockGen) {
+		tx, _ := types.NewContractCreation(block.TxNonce(testBank.Address), new(big.Int), big.NewInt(200000), new(big.Int), deployCode(code)).SignECDSA(testBankKey)
+		block.AddTx(tx)
+	}
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db, testBank)
+	config := core.DefaultConfigMorden.ChainConfig
+	blockchain, err := core.NewBlockChain(db, config, new(core.FakePow), new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain, _ := core.GenerateChain(config, genesis, db, 1, generator)
+	if res := blockchain.InsertChain(chain); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	api := &PublicBlockChainAPI{
+		config:  config,
+		bc:      blockchain,
+		chainDb: db,
+		calls:   newCallCache(),
+	}
+	return api, crypto.CreateAddress(testBank.Address, 0)
+}
+
+func TestCallTimeout(t *testing.T) {
+	// Loops forever: JUMPDEST PUSH1 0 JUMP
+	api, to := newCallTestAPI(t, []byte{0x5b, 0x60, 0x00, 0x56})
+	api.callTimeout = 50 * time.Millisecond
+
+	args := CallArgs{From: testBank.Address, To: &to, Gas: rpc.NewHexNumber(uint64(1) << 62), GasPrice: rpc.NewHexNumber(1)}
+
+	start := time.Now()
+	if _, err := api.Call(context.Background(), args, rpc.LatestBlockNumber); err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("call took %v to time out", elapsed)
+	}
+	// Requests going away abort their executions too
+	api.callTimeout = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := api.doCall(ctx, args, rpc.LatestBlockNumber); err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+}
+
+func TestCallOutOfGas(t *testing.T) {
+	// PUSH1 1 PUSH1 0 SSTORE, the SSTORE at pc 4 costing 20000 gas
+	api, to := newCallTestAPI(t, []byte{0x60, 0x01, 0x60, 0x00, 0x55})
+	args := CallArgs{From: testBank.Address, To: &to, Gas: rpc.NewHexNumber(30000), GasPrice: rpc.NewHexNumber(1)}
+
+	_, err := api.Call(context.Background(), args, rpc.LatestBlockNumber)
+	oog, ok := err.(*outOfGasError)
+	if !ok {
+		t.Fatalf("expected out of gas error, got %v", err)
+	}
+	want := map[string]interface{}{"op": "SSTORE", "pc": uint64(4), "gas": uint64(30000 - 21000 - 6), "cost": uint64(20000)}
+	if data := oog.ErrorData(); !reflect.DeepEqual(data, want) {
+		t.Errorf("error data mismatch: have %v, want %v", data, want)
+	}
+
+	res, err := api.TraceCall(args, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != oog.Error() {
+		t.Errorf("trace error mismatch: have %q, want %q", res.Error, oog.Error())
+	}
+
+	// Calls with enough gas don't fail
+	args.Gas = rpc.NewHexNumber(50000)
+	if _, err := api.Call(context.Background(), args, rpc.LatestBlockNumber); err != nil {
+		t.Errorf("call failed: %v", err)
+	}
+}
+
+func TestCallRevert(t *testing.T) {
+	// revert("nope")
+	data := common.FromHex("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"6e6f706500000000000000000000000000000000000000000000000000000000")
+	code := append([]byte{0x60, byte(len(data)), 0x60, 0x0c, 0x60, 0x00, 0x39, 0x60, byte(len(data)), 0x60, 0x00, 0xfd}, data...)
+	api, to := newCallTestAPI(t, code)
+	args := CallArgs{From: testBank.Address, To: &to, Gas: rpc.NewHexNumber(100000), GasPrice: rpc.NewHexNumber(1)}
+
+	_, err := api.Call(context.Background(), args, rpc.LatestBlockNumber)
+	revert, ok := err.(*revertError)
+	if !ok {
+		t.Fatalf("expected revert error, got %v", err)
+	}
+	if want := "execution reverted: nope"; revert.Error() != want {
+		t.Errorf("error mismatch: have %q, want %q", revert.Error(), want)
+	}
+	if have := revert.ErrorData().(hexutil.Bytes); !bytes.Equal(have, data) {
+		t.Errorf("error data mismatch: have %x, want %x", have, data)
+	}
+	if _, _, err := api.doCall(context.Background(), args, rpc.LatestBlockNumber); err == nil {
+		t.Error("gas estimated for reverting call")
+	}
+
+	res, err := api.TraceCall(args, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != revert.Error() {
+		t.Errorf("trace error mismatch: have %q, want %q", res.Error, revert.Error())
+	}
+}
@@ -68,9 +68,7 @@ func (api *PublicDownloaderAPI) run() {
 
 		switch event.Data.(type) {
 		case StartEvent:
-			result := &SyncingResult{Syncing: true}
-			result.Status.Origin, result.Status.Current, result.Status.Height, result.Status.Pulled, result.Status.Known = api.d.Progress()
-			notification = result
+			notification = &SyncingResult{Syncing: true, Status: api.d.SyncProgress()}
 		case DoneEvent, FailedEvent:
 			notification = false
 		}
@@ -87,11 +85,13 @@ func (api *PublicDownloaderAPI) run() {
 
 // Progress gives progress indications when the node is synchronising with the Ethereum network.
 type Progress struct {
-	Origin  uint64 `json:"startingBlock"`
-	Current uint64 `json:"currentBlock"`
-	Height  uint64 `json:"highestBlock"`
-	Pulled  uint64 `json:"pulledStates"`
-	Known   uint64 `json:"knownStates"`
+	Origin  uint64    `json:"startingBlock"`
+	Current uint64    `json:"currentBlock"`
+	Height  uint64    `json:"highestBlock"`
+	Pulled  uint64    `json:"pulledStates"`
+	Known   uint64    `json:"knownStates"`
+	Mode    string    `json:"syncMode"`
+	Stage   SyncStage `json:"syncStage"`
 }
 
 // SyncingResult provides information about the current synchronisation status for this node.
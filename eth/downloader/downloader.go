@@ -61,6 +61,7 @@ var (
 	maxQueuedHeaders  = 32 * 1024 // [eth/62] Maximum number of headers to queue for import (DOS protection)
 	maxHeadersProcess = 2048      // Number of header download results to import at once into the chain
 	maxResultsProcess = 2048      // Number of content download results to import at once into the chain
+	maxQueuedImports  = 2         // Number of result batches to queue for execution while one is being imported
 
 	fsHeaderCheckFrequency = 100             // Verification frequency of the downloaded headers during fast sync
 	fsHeaderSafetyNet      = 2048            // Number of headers to discard in case a chain violation is detected
@@ -136,6 +137,16 @@ func (m SyncMode) String() string {
 	return ""
 }
 
+// SyncStage describes the step a synchronisation cycle is at.
+type SyncStage string
+
+const (
+	StageIdle     SyncStage = ""         // No synchronisation running
+	StageAncestor SyncStage = "ancestor" // Looking up the sync boundaries with the remote peer
+	StageBlocks   SyncStage = "blocks"   // Retrieving and importing headers, bodies and receipts
+	StageState    SyncStage = "state"    // Retrieving the state of the fast sync pivot block
+)
+
 type Downloader struct {
 	mode SyncMode       // Synchronisation mode defining the strategy used (per sync cycle)
 	mux  *event.TypeMux // Event multiplexer to announce sync operation events
@@ -144,6 +155,8 @@ type Downloader struct {
 	peers   *peerSet // Set of active peers from which download can proceed
 	stateDB ethdb.Database
 
+	importBatch int // Bytes of state data buffered before flushing during sync
+
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
@@ -151,11 +164,14 @@ type Downloader struct {
 	syncStatsChainOrigin uint64 // Origin block number where syncing started at
 	syncStatsChainHeight uint64 // Highest block number known when syncing started
 	syncStatsState       stateSyncStats
+	syncStatsStage       SyncStage    // Step the current synchronisation is at
 	syncStatsLock        sync.RWMutex // Lock protecting the sync stats fields
 
 	lightchain LightChain
 	blockchain BlockChain
 
+	checkpoint *core.Checkpoint // Trusted block below which header seals are not verified (nil = trustless)
+
 	// Callbacks
 	dropPeer peerDropFn // Drops a peer for misbehaving
 
@@ -236,6 +252,10 @@ type BlockChain interface {
 	// FastSyncCommitHead directly commits the head block to a certain entity.
 	FastSyncCommitHead(common.Hash) error
 
+	// ValidateHeaderChain verifies a batch of headers attached to a parent
+	// header without importing them, checking a sample of the seals.
+	ValidateHeaderChain(*types.Header, []*types.Header, int) (int, error)
+
 	// InsertChain inserts a batch of blocks into the local chain.
 	InsertChain(types.Blocks) *core.ChainInsertResult
 
@@ -252,6 +272,7 @@ func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, chain BlockC
 	dl := &Downloader{
 		mode:           mode,
 		stateDB:        stateDb,
+		importBatch:    ethdb.ImportBatchSize,
 		mux:            mux, // inherited from protocolManager, which inherits from Ethereum
 		queue:          newQueue(),
 		peers:          newPeerSet(),
@@ -276,6 +297,19 @@ func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, chain BlockC
 	return dl
 }
 
+// SetCheckpoint configures a trusted checkpoint for fast and light sync. Headers at
+// or below the checkpoint skip proof-of-work verification, and the header at the
+// checkpoint height must match it. A nil checkpoint restores fully trustless sync.
+func (d *Downloader) SetCheckpoint(cp *core.Checkpoint) {
+	d.checkpoint = cp
+}
+
+// SetImportBatchSize sets the amount of synced state data buffered in memory
+// before it's written to the database.
+func (d *Downloader) SetImportBatchSize(size int) {
+	d.importBatch = size
+}
+
 func (d *Downloader) currentLocalChainHeight() (current uint64) {
 	current = d.lightchain.CurrentHeader().Number.Uint64() // "LightSync"
 	switch d.mode {
@@ -302,6 +336,30 @@ func (d *Downloader) Progress() (uint64, uint64, uint64, uint64, uint64) {
 	return d.syncStatsChainOrigin, d.currentLocalChainHeight(), d.syncStatsChainHeight, d.syncStatsState.processed, d.syncStatsState.processed + d.syncStatsState.pending
 }
 
+// SyncProgress retrieves the synchronisation boundaries and state download
+// counters like Progress, along with the mode and stage of the running sync.
+func (d *Downloader) SyncProgress() Progress {
+	d.syncStatsLock.RLock()
+	defer d.syncStatsLock.RUnlock()
+
+	return Progress{
+		Origin:  d.syncStatsChainOrigin,
+		Current: d.currentLocalChainHeight(),
+		Height:  d.syncStatsChainHeight,
+		Pulled:  d.syncStatsState.processed,
+		Known:   d.syncStatsState.processed + d.syncStatsState.pending,
+		Mode:    d.mode.String(),
+		Stage:   d.syncStatsStage,
+	}
+}
+
+// setSyncStage records the step the current synchronisation is at.
+func (d *Downloader) setSyncStage(stage SyncStage) {
+	d.syncStatsLock.Lock()
+	d.syncStatsStage = stage
+	d.syncStatsLock.Unlock()
+}
+
 func (d *Downloader) Qos() (rtt time.Duration, ttl time.Duration, conf float64) {
 	rtt = d.requestRTT()
 	ttl = d.requestTTL()
@@ -472,8 +530,10 @@ func (d *Downloader) synchronise(id string, hash common.Hash, td *big.Int, mode
 // syncWithPeer starts a block synchronization based on the hash chain from the
 // specified peer and head hash.
 func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err error) {
+	d.setSyncStage(StageAncestor)
 	d.mux.Post(StartEvent{p, hash, td})
 	defer func() {
+		d.setSyncStage(StageIdle)
 		// reset on error
 		if err != nil {
 			d.mux.Post(FailedEvent{p, err})
@@ -536,6 +596,7 @@ func (d *Downloader) syncWithPeer(p *peer, hash common.Hash, td *big.Int) (err e
 		d.syncStatsChainOrigin = origin
 	}
 	d.syncStatsChainHeight = height
+	d.syncStatsStage = StageBlocks
 	d.syncStatsLock.Unlock()
 
 	// Ensure our origin point is below any fast sync pivot point
@@ -1272,6 +1333,9 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 	// Wait for batches of headers to process
 	gotHeaders := false
 
+	// Last header scheduled during full sync, which the next batch attaches to
+	var parent *types.Header
+
 	for {
 		select {
 		case <-d.cancelCh:
@@ -1338,6 +1402,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 					limit = len(headers)
 				}
 				chunk := headers[:limit]
+				start := time.Now()
 
 				// In case of header only syncing, validate the chunk immediately
 				if d.mode == FastSync || d.mode == LightSync {
@@ -1353,6 +1418,14 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 					if chunk[len(chunk)-1].Number.Uint64()+uint64(fsHeaderForceVerify) > pivot {
 						frequency = 1
 					}
+					// Headers behind a trusted checkpoint only need their chunk tail verified
+					if cp := d.checkpoint; cp != nil && cp.Covers(chunk[len(chunk)-1].Number) {
+						frequency = len(chunk) + 1
+					}
+					if err := d.checkCheckpoint(chunk); err != nil {
+						glog.V(logger.Debug).Infoln("Checkpoint violation", "err", err)
+						return errInvalidChain
+					}
 					res := d.lightchain.InsertHeaderChain(chunk, frequency)
 					// TODO(whilei): again, send error to events
 					if res.Error != nil {
@@ -1363,6 +1436,11 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 						glog.V(logger.Debug).Infoln("Invalid header encountered", "number", chunk[res.Index].Number, "hash", chunk[res.Index].Hash(), "err", res.Error)
 						return errInvalidChain
 					}
+					if err := d.checkCheckpointTd(chunk); err != nil {
+						rollback = append(rollback, unknown...)
+						glog.V(logger.Debug).Infoln("Checkpoint violation", "err", err)
+						return errInvalidChain
+					}
 					go d.mux.Post(InsertHeaderChainEvent{res.HeaderChainInsertEvent})
 					// All verifications passed, store newly found uncertain headers
 					rollback = append(rollback, unknown...)
@@ -1370,6 +1448,15 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 						rollback = append(rollback[:0], rollback[len(rollback)-fsHeaderSafetyNet:]...)
 					}
 				}
+				// During full sync, headers are only imported along with their blocks. Verify
+				// a sample of their seals up front to avoid fetching the content of junk.
+				if d.mode == FullSync {
+					if index, err := d.blockchain.ValidateHeaderChain(parent, chunk, fsHeaderCheckFrequency); err != nil {
+						glog.V(logger.Debug).Infoln("Invalid header encountered", "number", chunk[index].Number, "hash", chunk[index].Hash(), "err", err)
+						return errInvalidChain
+					}
+					parent = chunk[len(chunk)-1]
+				}
 				// Unless we're doing light chains, schedule the headers for associated content retrieval
 				if d.mode == FullSync || d.mode == FastSync {
 					// If we've reached the allowed number of pending headers, stall a bit
@@ -1387,6 +1474,9 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 						return errBadPeer
 					}
 				}
+				metrics.DLHeaderProcessTimer.UpdateSince(start)
+				d.updateQueueMetrics()
+
 				headers = headers[limit:]
 				origin += uint64(limit)
 			}
@@ -1409,20 +1499,78 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 	}
 }
 
-// processFullSyncContent takes fetch results from the queue and imports them into the chain.
+// checkCheckpoint verifies that a header batch spanning the trusted checkpoint
+// contains the checkpoint block itself.
+func (d *Downloader) checkCheckpoint(headers []*types.Header) error {
+	cp := d.checkpoint
+	if cp == nil {
+		return nil
+	}
+	for _, header := range headers {
+		if err := cp.CheckHeader(header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCheckpointTd verifies the locally computed total difficulty of the
+// checkpoint block once it has been imported as part of the given header batch.
+func (d *Downloader) checkCheckpointTd(headers []*types.Header) error {
+	cp := d.checkpoint
+	if cp == nil || len(headers) == 0 {
+		return nil
+	}
+	if headers[0].Number.Cmp(cp.Number) > 0 || headers[len(headers)-1].Number.Cmp(cp.Number) < 0 {
+		return nil
+	}
+	if td := d.lightchain.GetTd(cp.Hash); td != nil && td.Cmp(cp.TD) != 0 {
+		return fmt.Errorf("%v: td %v, want %v", core.ErrCheckpointMismatch, td, cp.TD)
+	}
+	return nil
+}
+
+// processFullSyncContent takes fetch results from the queue and imports them into
+// the chain. Results are handed to a separate execution stage through a bounded
+// queue, so their slots in the result cache are freed for the content fetchers
+// while earlier blocks are still being executed.
 func (d *Downloader) processFullSyncContent() error {
+	var (
+		imports = make(chan []*fetchResult, maxQueuedImports)
+		errc    = make(chan error, 1)
+	)
+	go func() { errc <- d.executeFullSyncContent(imports) }()
+
 	for {
 		results := d.queue.Results(true)
+		d.updateQueueMetrics()
 		if len(results) == 0 {
-			return nil
+			close(imports)
+			return <-errc
 		}
 		if d.chainInsertHook != nil {
 			d.chainInsertHook(results)
 		}
+		select {
+		case imports <- results:
+			metrics.DLImportQueue.Update(int64(len(imports)))
+		case err := <-errc:
+			return err
+		}
+	}
+}
+
+// executeFullSyncContent imports the batches of results queued by
+// processFullSyncContent until the queue is closed or an import fails.
+func (d *Downloader) executeFullSyncContent(imports chan []*fetchResult) error {
+	for results := range imports {
+		metrics.DLImportQueue.Update(int64(len(imports)))
 		if err := d.importBlockResults(results); err != nil {
+			d.queue.Close() // wake up the result collector
 			return err
 		}
 	}
+	return nil
 }
 
 func (d *Downloader) importBlockResults(results []*fetchResult) error {
@@ -1446,15 +1594,28 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 		blocks[i] = types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
 	}
 
+	start := time.Now()
 	res := d.blockchain.InsertChain(blocks)
+	metrics.DLImportTimer.UpdateSince(start)
 	if res.Error != nil {
 		glog.V(logger.Debug).Infoln("Downloaded item processing failed", "number", results[res.Index].Header.Number, "hash", results[res.Index].Header.Hash(), "err", res.Error)
 		return errInvalidChain
 	}
+	metrics.DLImports.Mark(int64(len(blocks)))
 	go d.mux.Post(InsertChainEvent{res.ChainInsertEvent})
 	return nil
 }
 
+// updateQueueMetrics reports the number of items waiting in each stage of the
+// download queue.
+func (d *Downloader) updateQueueMetrics() {
+	headers, bodies, receipts, results := d.queue.Stats()
+	metrics.DLHeaderQueue.Update(int64(headers))
+	metrics.DLBodyQueue.Update(int64(bodies))
+	metrics.DLReceiptQueue.Update(int64(receipts))
+	metrics.DLResultQueue.Update(int64(results))
+}
+
 // processFastSyncContent takes fetch results from the queue and writes them to the
 // database. It also controls the synchronisation of state nodes of the pivot block.
 func (d *Downloader) processFastSyncContent(latest *types.Header) error {
@@ -1483,6 +1644,7 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 		// Wait for the next batch of downloaded data to be available, and if the pivot
 		// block became stale, move the goalpost
 		results := d.queue.Results(oldPivot == nil) // Block if we're not monitoring pivot staleness
+		d.updateQueueMetrics()
 		if len(results) == 0 {
 			// If pivot sync is done, stop
 			if oldPivot == nil {
@@ -1528,6 +1690,7 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 				oldPivot = P
 			}
 			// Wait for completion, occasionally checking for pivot staleness
+			d.setSyncStage(StageState)
 			select {
 			case <-stateSync.done:
 				if stateSync.err != nil {
@@ -1537,6 +1700,7 @@ func (d *Downloader) processFastSyncContent(latest *types.Header) error {
 					return err
 				}
 				oldPivot = nil
+				d.setSyncStage(StageBlocks)
 
 			case <-time.After(time.Second):
 				oldTail = afterP
@@ -351,6 +351,26 @@ func (dl *downloadTester) InsertHeaderChain(headers []*types.Header, checkFreq i
 	return
 }
 
+// ValidateHeaderChain checks that the headers attach to the parent header or
+// to a known one, as the tester doesn't verify seals.
+func (dl *downloadTester) ValidateHeaderChain(parent *types.Header, headers []*types.Header, checkFreq int) (int, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if parent == nil {
+		if parent = dl.ownHeaders[headers[0].ParentHash]; parent == nil {
+			return 0, errors.New("unknown parent")
+		}
+	}
+	for i, header := range headers {
+		if header.ParentHash != parent.Hash() {
+			return i, errors.New("broken chain")
+		}
+		parent = header
+	}
+	return 0, nil
+}
+
 // InsertChain injects a new batch of blocks into the simulated chain.
 func (dl *downloadTester) InsertChain(blocks types.Blocks) (res *core.ChainInsertResult) {
 	res = &core.ChainInsertResult{}
@@ -817,6 +837,56 @@ func testThrottling(t *testing.T, protocol int, mode SyncMode) {
 	}
 }
 
+// blockingChain wraps the tester's chain, holding block imports until released.
+type blockingChain struct {
+	*downloadTester
+	release chan struct{}
+}
+
+func (c *blockingChain) InsertChain(blocks types.Blocks) *core.ChainInsertResult {
+	<-c.release
+	return c.downloadTester.InsertChain(blocks)
+}
+
+// Tests that block execution doesn't hold up the download: while a batch is
+// being imported, further results are taken from the cache and queued for
+// execution, up to the import queue limit.
+func TestPipelinedImport(t *testing.T) {
+	t.Parallel()
+	tester := newTester()
+	defer tester.terminate()
+
+	targetBlocks := 4 * blockCacheItems
+	hashes, headers, blocks, receipts := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+	tester.newPeer("peer", 63, hashes, headers, blocks, receipts)
+
+	chain := &blockingChain{downloadTester: tester, release: make(chan struct{})}
+	tester.downloader.blockchain = chain
+
+	var batches int32
+	tester.downloader.chainInsertHook = func([]*fetchResult) { atomic.AddInt32(&batches, 1) }
+
+	errc := make(chan error)
+	go func() {
+		errc <- tester.sync("peer", nil, FullSync)
+	}()
+	// One batch is executing, the queue is full and one more is waiting to be
+	// queued. Nothing beyond that may be taken from the cache.
+	want := int32(maxQueuedImports + 2)
+	for start := time.Now(); atomic.LoadInt32(&batches) < want && time.Since(start) < 5*time.Second; {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if n := atomic.LoadInt32(&batches); n != want {
+		t.Fatalf("batches taken while import blocked: have %d, want %d", n, want)
+	}
+	close(chain.release)
+	if err := <-errc; err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, targetBlocks+1)
+}
+
 // Tests that simple synchronization against a forked chain works correctly. In
 // this test common ancestor lookup should *not* be short circuited, and a full
 // binary search should be executed.
@@ -1446,6 +1516,9 @@ func testSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	if start, current, height, _, _ := tester.downloader.Progress(); start != 0 || current != 0 || height != uint64(targetBlocks/2+1) {
 		t.Fatalf("Initial progress mismatch: have %v/%v/%v, want %v/%v/%v", start, current, height, 0, 0, targetBlocks/2+1)
 	}
+	if p := tester.downloader.SyncProgress(); p.Stage != StageBlocks || p.Mode != mode.String() {
+		t.Fatalf("Initial stage mismatch: have %v/%q, want %v/%q", p.Mode, p.Stage, mode, StageBlocks)
+	}
 	progress <- struct{}{}
 	pending.Wait()
 
@@ -1470,6 +1543,9 @@ func testSyncProgress(t *testing.T, protocol int, mode SyncMode) {
 	if start, current, height, _, _ := tester.downloader.Progress(); start != uint64(targetBlocks/2+1) || current != uint64(targetBlocks) || height != uint64(targetBlocks) {
 		t.Fatalf("Final progress mismatch: have %v/%v/%v, want %v/%v/%v", start, current, height, targetBlocks/2+1, targetBlocks, targetBlocks)
 	}
+	if stage := tester.downloader.SyncProgress().Stage; stage != StageIdle {
+		t.Fatalf("Final stage mismatch: have %q, want idle", stage)
+	}
 }
 
 // Tests that synchronisation progress (origin block number and highest block
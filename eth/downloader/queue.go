@@ -178,6 +178,18 @@ func (q *queue) PendingReceipts() int {
 	return q.receiptTaskQueue.Size()
 }
 
+// Stats retrieves the number of header, body and receipt retrievals pending and
+// the number of completed results waiting to be processed.
+func (q *queue) Stats() (headers, bodies, receipts, results int) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if q.headerTaskQueue != nil {
+		headers = q.headerTaskQueue.Size()
+	}
+	return headers, q.blockTaskQueue.Size(), q.receiptTaskQueue.Size(), q.countProcessableItems()
+}
+
 // InFlightHeaders retrieves whether there are header fetch requests currently
 // in flight.
 func (q *queue) InFlightHeaders() bool {
@@ -25,7 +25,6 @@ import (
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core/state"
 	"github.com/webchain-network/webchaind/crypto/sha3"
-	"github.com/webchain-network/webchaind/ethdb"
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
 	"github.com/webchain-network/webchaind/trie"
@@ -324,7 +323,7 @@ func (s *stateSync) loop() (err error) {
 }
 
 func (s *stateSync) commit(force bool) error {
-	if !force && s.bytesUncommitted < ethdb.IdealBatchSize {
+	if !force && s.bytesUncommitted < s.d.importBatch {
 		return nil
 	}
 	start := time.Now()
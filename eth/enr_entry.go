@@ -0,0 +1,49 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"github.com/webchain-network/webchaind/core/forkid"
+	"github.com/webchain-network/webchaind/p2p/enr"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+// ethEntry is the "eth" entry of the node record, announcing the fork ID of
+// the local chain so peers can be filtered before connecting.
+type ethEntry struct {
+	ForkID forkid.ID
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e ethEntry) ENRKey() string {
+	return "eth"
+}
+
+// nodeRecordEntries returns the entries of the local node record describing
+// the eth protocol.
+func (pm *ProtocolManager) nodeRecordEntries() []enr.Entry {
+	return []enr.Entry{&ethEntry{ForkID: pm.currentForkID()}}
+}
+
+// currentForkID calculates the fork ID of the local chain at its current head.
+func (pm *ProtocolManager) currentForkID() forkid.ID {
+	genesis := pm.blockchain.Genesis().Hash()
+	return forkid.NewID(pm.blockchain.Config(), genesis, pm.blockchain.CurrentHeader().Number.Uint64())
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/webchain-network/webchaind/common"
@@ -41,6 +42,7 @@ const (
 	maxQueueDist  = 32                     // Maximum allowed distance from the chain head to queue
 	hashLimit     = 256                    // Maximum number of unique blocks a peer may have announced
 	blockLimit    = 64                     // Maximum number of unique blocks a peer may have delivered
+	failLimit     = 5                      // Maximum number of announced blocks in a row a peer may fail to deliver
 )
 
 var (
@@ -78,6 +80,7 @@ type announce struct {
 	number uint64        // Number of the block being announced (0 = unknown | old protocol)
 	header *types.Header // Header of the block partially reassembled (new protocol)
 	time   time.Time     // Timestamp of the announcement
+	issued time.Time     // Timestamp of the body retrieval request
 
 	origin string // Identifier of the peer originating the notification
 
@@ -131,6 +134,10 @@ type Fetcher struct {
 	fetched    map[common.Hash][]*announce // Blocks with headers fetched, scheduled for body retrieval
 	completing map[common.Hash]*announce   // Blocks with headers, currently body-completing
 
+	// Delivery failures, also reset from outside the loop as peers disconnect
+	failures map[string]int // Per peer count of announced blocks not delivered in a row
+	failMu   sync.Mutex     // Lock protecting the failure counts
+
 	// Block cache
 	queue  *prque.Prque            // Queue containing the import operations (block number sorted)
 	queues map[string]int          // Per peer block counts to prevent memory exhaustion
@@ -168,6 +175,7 @@ func New(mux *event.TypeMux, getBlock blockRetrievalFn, verifyHeader headerVerif
 		fetching:       make(map[common.Hash]*announce),
 		fetched:        make(map[common.Hash][]*announce),
 		completing:     make(map[common.Hash]*announce),
+		failures:       make(map[string]int),
 		queue:          prque.New(),
 		queues:         make(map[string]int),
 		queued:         make(map[common.Hash]*inject),
@@ -290,10 +298,18 @@ func (f *Fetcher) loop() {
 	completeTimer := time.NewTimer(0)
 
 	for {
-		// Clean up any expired block fetches
+		// Clean up any expired block fetches, penalising the peers that failed to
+		// deliver the headers or bodies of the blocks they announced
 		for hash, announce := range f.fetching {
 			if time.Since(announce.time) > fetchTimeout {
 				f.forgetHash(hash)
+				f.fail(announce.origin)
+			}
+		}
+		for hash, announce := range f.completing {
+			if f.queued[hash] == nil && time.Since(announce.issued) > fetchTimeout {
+				f.forgetHash(hash)
+				f.fail(announce.origin)
 			}
 		}
 		// Import any queued blocks that could potentially fit
@@ -356,9 +372,20 @@ func (f *Fetcher) loop() {
 			if _, ok := f.fetching[notification.hash]; ok {
 				break
 			}
+			if _, ok := f.fetched[notification.hash]; ok {
+				break
+			}
 			if _, ok := f.completing[notification.hash]; ok {
 				break
 			}
+			if _, ok := f.queued[notification.hash]; ok {
+				break
+			}
+			// Ignore repeated announces, which would only eat into the peer's allowance
+			if f.hasAnnounced(notification.origin, notification.hash) {
+				metrics.FetchAnnounceDups.Mark(1)
+				break
+			}
 			f.announces[notification.origin] = count
 			f.announced[notification.hash] = append(f.announced[notification.hash], notification)
 			if f.announceChangeHook != nil && len(f.announced[notification.hash]) == 1 {
@@ -432,6 +459,7 @@ func (f *Fetcher) loop() {
 				// If the block still didn't arrive, queue for completion
 				if f.getBlock(hash) == nil {
 					request[announce.origin] = append(request[announce.origin], hash)
+					announce.issued = time.Now()
 					f.completing[hash] = announce
 				}
 			}
@@ -489,6 +517,8 @@ func (f *Fetcher) loop() {
 						f.forgetHash(hash)
 						continue
 					}
+					f.ForgetPeer(announce.origin)
+
 					// Only keep if not imported by other means
 					if f.getBlock(hash) == nil {
 						announce.header = header
@@ -504,6 +534,7 @@ func (f *Fetcher) loop() {
 							block.ReceivedAt = task.time
 
 							complete = append(complete, block)
+							announce.issued = task.time
 							f.completing[hash] = announce
 							continue
 						}
@@ -565,6 +596,7 @@ func (f *Fetcher) loop() {
 						if txnHash == announce.header.TxHash && uncleHash == announce.header.UncleHash && announce.origin == task.peer {
 							// Mark the body matched, reassemble if still unknown
 							matched = true
+							f.ForgetPeer(announce.origin)
 
 							if f.getBlock(hash) == nil {
 								block := types.NewBlockWithHeader(announce.header).WithBody(task.transactions[i], task.uncles[i])
@@ -601,6 +633,43 @@ func (f *Fetcher) loop() {
 	}
 }
 
+// hasAnnounced checks whether peer has a pending announcement of hash.
+func (f *Fetcher) hasAnnounced(peer string, hash common.Hash) bool {
+	for _, announce := range f.announced[hash] {
+		if announce.origin == peer {
+			return true
+		}
+	}
+	return false
+}
+
+// fail records that peer didn't deliver a block it announced, dropping it if
+// it keeps doing so.
+func (f *Fetcher) fail(peer string) {
+	metrics.FetchAnnounceFails.Mark(1)
+
+	f.failMu.Lock()
+	f.failures[peer]++
+	failures := f.failures[peer]
+	f.failMu.Unlock()
+
+	if failures < failLimit {
+		return
+	}
+	glog.V(logger.Debug).Infof("Peer %s: failed to deliver %d announced blocks", peer, failures)
+	f.ForgetPeer(peer)
+	f.dropPeer(peer)
+}
+
+// ForgetPeer clears the record of blocks the peer failed to deliver, as it
+// delivered one or disconnected.
+func (f *Fetcher) ForgetPeer(peer string) {
+	f.failMu.Lock()
+	defer f.failMu.Unlock()
+
+	delete(f.failures, peer)
+}
+
 // rescheduleFetch resets the specified fetch timer to the next announce timeout.
 func (f *Fetcher) rescheduleFetch(fetch *time.Timer) {
 	// Short circuit if no blocks are announced
@@ -777,6 +777,134 @@ func testHashMemoryExhaustionAttack(t *testing.T, protocol int) {
 	verifyImportDone(t, imported)
 }
 
+// Tests that a peer repeating the same announcement doesn't use up its
+// announcement allowance.
+func TestDuplicateAnnouncements62(t *testing.T) { testDuplicateAnnouncements(t, 62) }
+func TestDuplicateAnnouncements63(t *testing.T) { testDuplicateAnnouncements(t, 63) }
+func TestDuplicateAnnouncements64(t *testing.T) { testDuplicateAnnouncements(t, 64) }
+
+func testDuplicateAnnouncements(t *testing.T, protocol int) {
+	tester := newTester()
+
+	announced := make(chan common.Hash, 2)
+	tester.fetcher.announceChangeHook = func(hash common.Hash, added bool) {
+		if added {
+			announced <- hash
+		}
+	}
+	hashes, _ := makeChain(2, 0, unknownBlock)
+	headerFetcher := tester.makeHeaderFetcher("repeater", nil, -gatherSlack)
+	bodyFetcher := tester.makeBodyFetcher("repeater", nil, 0)
+
+	for i := 0; i <= hashLimit; i++ {
+		tester.fetcher.Notify("repeater", hashes[0], 1, time.Now(), headerFetcher, bodyFetcher)
+	}
+	tester.fetcher.Notify("repeater", hashes[1], 1, time.Now(), headerFetcher, bodyFetcher)
+
+	for _, want := range hashes[:2] {
+		select {
+		case hash := <-announced:
+			if hash != want {
+				t.Fatalf("announced hash mismatch: have %x, want %x", hash, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("announcement of %x not scheduled", want)
+		}
+	}
+}
+
+// Tests that peers repeatedly announcing blocks they never deliver get dropped,
+// but that a few failed deliveries are tolerated.
+func TestUndeliveredAnnouncements62(t *testing.T) { testUndeliveredAnnouncements(t, 62) }
+func TestUndeliveredAnnouncements63(t *testing.T) { testUndeliveredAnnouncements(t, 63) }
+func TestUndeliveredAnnouncements64(t *testing.T) { testUndeliveredAnnouncements(t, 64) }
+
+func testUndeliveredAnnouncements(t *testing.T, protocol int) {
+	tester := newTester()
+
+	fetching := make(chan []common.Hash)
+	tester.fetcher.fetchingHook = func(hashes []common.Hash) { fetching <- hashes }
+
+	dropped := func() bool {
+		tester.lock.RLock()
+		defer tester.lock.RUnlock()
+		return tester.drops["liar"]
+	}
+	hashes, _ := makeChain(failLimit, 0, unknownBlock)
+	headerFetcher := tester.makeHeaderFetcher("liar", nil, -gatherSlack)
+	bodyFetcher := tester.makeBodyFetcher("liar", nil, 0)
+
+	// Announce blocks which are already overdue, so their fetches expire as soon
+	// as they are made.
+	for i := 0; i < failLimit; i++ {
+		tester.fetcher.Notify("liar", hashes[i], 1, time.Now().Add(-fetchTimeout), headerFetcher, bodyFetcher)
+		verifyFetchingEvent(t, fetching, true)
+		if i < failLimit-1 && dropped() {
+			t.Fatalf("peer dropped after %d undelivered blocks", i+1)
+		}
+	}
+	for start := time.Now(); !dropped(); time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("peer not dropped after %d undelivered blocks", failLimit)
+		}
+	}
+}
+
+// Tests that the failed deliveries of a peer are forgotten once it's removed,
+// so that a peer reconnecting under the same id starts afresh.
+func TestForgetPeer(t *testing.T) {
+	tester := newTester()
+
+	for i := 0; i < failLimit-1; i++ {
+		tester.fetcher.fail("flaky")
+	}
+	tester.fetcher.ForgetPeer("flaky")
+
+	tester.fetcher.failMu.Lock()
+	_, ok := tester.fetcher.failures["flaky"]
+	tester.fetcher.failMu.Unlock()
+	if ok {
+		t.Fatal("failures of removed peer still tracked")
+	}
+	tester.fetcher.fail("flaky")
+
+	tester.lock.RLock()
+	defer tester.lock.RUnlock()
+	if tester.drops["flaky"] {
+		t.Error("peer dropped for failures before it was removed")
+	}
+}
+
+// Tests that block body retrievals time out counting from their request, not
+// from the arrival of the header.
+func TestDelayedBodyRequest62(t *testing.T) { testDelayedBodyRequest(t, 62) }
+func TestDelayedBodyRequest63(t *testing.T) { testDelayedBodyRequest(t, 63) }
+func TestDelayedBodyRequest64(t *testing.T) { testDelayedBodyRequest(t, 64) }
+
+func testDelayedBodyRequest(t *testing.T, protocol int) {
+	hashes, blocks := makeChain(1, 0, genesis)
+
+	tester := newTester()
+	// The header arrives shortly before the fetch timeout would pass counting from
+	// it, while the body takes a while longer
+	headerFetcher := tester.makeHeaderFetcher("valid", blocks, -fetchTimeout+100*time.Millisecond)
+	bodyFetcher := tester.makeBodyFetcher("valid", blocks, 0)
+	slowBodyFetcher := func(hashes []common.Hash) error {
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			// Wake the fetcher up to check for expired retrievals before delivering
+			tester.fetcher.Notify("other", common.Hash{1}, 0, time.Now(), tester.makeHeaderFetcher("other", nil, 0), tester.makeBodyFetcher("other", nil, 0))
+			bodyFetcher(hashes)
+		}()
+		return nil
+	}
+	imported := make(chan *types.Block)
+	tester.fetcher.importedHook = func(block *types.Block) { imported <- block }
+
+	tester.fetcher.Notify("valid", hashes[0], 1, time.Now().Add(-arriveTimeout), headerFetcher, slowBodyFetcher)
+	verifyImportEvent(t, imported, true)
+}
+
 // Tests that blocks sent to the fetcher (either through propagation or via hash
 // announces and retrievals) don't pile up indefinitely, exhausting available
 // system memory.
@@ -27,15 +27,28 @@ import (
 	"time"
 
 	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
 	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/core/vm"
 	"github.com/webchain-network/webchaind/ethdb"
 	"github.com/webchain-network/webchaind/event"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
 	"github.com/webchain-network/webchaind/rpc"
 )
 
-var (
-	filterTickerTime = 5 * time.Minute
+const (
+	// DefaultFilterTimeout is the time after which a filter that isn't polled
+	// is uninstalled, unless configured otherwise.
+	DefaultFilterTimeout = 5 * time.Minute
+
+	// streamChunkSize is the maximum number of logs per log stream notification.
+	streamChunkSize = 1000
+	// streamWindow is the number of blocks a log stream searches at a time.
+	streamWindow = 10000
+	// streamMaxQueued is the number of notifications a log stream lets queue
+	// up on the connection before waiting for the client to catch up.
+	streamMaxQueued = 16
 )
 
 // byte will be inferred
@@ -46,6 +59,9 @@ const (
 	logFilterTy
 )
 
+// persistedFiltersKey is the database key of the log filters kept across a restart.
+var persistedFiltersKey = []byte("rpc-log-filters")
+
 // PublicFilterAPI offers support to create and manage filters. This will allow external clients to retrieve various
 // information related to the Ethereum protocol such als blocks, transactions and logs.
 type PublicFilterAPI struct {
@@ -67,18 +83,39 @@ type PublicFilterAPI struct {
 
 	transactionMu    sync.RWMutex
 	transactionQueue map[int]*hashQueue
+
+	timeout time.Duration // time after which an unpolled filter is uninstalled
+
+	persist   bool
+	persistMu sync.Mutex
+	persisted map[string]*persistedFilter // log filters kept across a restart, by external id
 }
 
-// NewPublicFilterAPI returns a new PublicFilterAPI instance.
-func NewPublicFilterAPI(chainDb ethdb.Database, mux *event.TypeMux) *PublicFilterAPI {
+// NewPublicFilterAPI returns a new PublicFilterAPI instance. Filters that aren't
+// polled for the given timeout are uninstalled, DefaultFilterTimeout is used if
+// it is zero. If persist is set, log filters are stored in the chain database
+// and reinstalled with the same ids when the node is restarted.
+func NewPublicFilterAPI(chainDb ethdb.Database, mux *event.TypeMux, timeout time.Duration, persist bool) *PublicFilterAPI {
+	if timeout <= 0 {
+		timeout = DefaultFilterTimeout
+	}
 	svc := &PublicFilterAPI{
 		mux:              mux,
+		quit:             make(chan struct{}),
 		chainDb:          chainDb,
 		filterManager:    NewFilterSystem(mux),
 		filterMapping:    make(map[string]int),
 		logQueue:         make(map[int]*logQueue),
 		blockQueue:       make(map[int]*hashQueue),
 		transactionQueue: make(map[int]*hashQueue),
+		timeout:          timeout,
+		persist:          persist,
+		persisted:        make(map[string]*persistedFilter),
+	}
+	if persist {
+		svc.restoreFilters()
+	} else if err := chainDb.Delete(persistedFiltersKey); err != nil {
+		glog.V(logger.Warn).Infof("Failed to remove persisted filters: %v", err)
 	}
 	go svc.start()
 	return svc
@@ -97,34 +134,7 @@ done:
 	for {
 		select {
 		case <-timer.C:
-			s.filterManager.Lock() // lock order like filterLoop()
-			s.logMu.Lock()
-			for id, filter := range s.logQueue {
-				if time.Since(filter.timeout) > filterTickerTime {
-					s.filterManager.Remove(id)
-					delete(s.logQueue, id)
-				}
-			}
-			s.logMu.Unlock()
-
-			s.blockMu.Lock()
-			for id, filter := range s.blockQueue {
-				if time.Since(filter.timeout) > filterTickerTime {
-					s.filterManager.Remove(id)
-					delete(s.blockQueue, id)
-				}
-			}
-			s.blockMu.Unlock()
-
-			s.transactionMu.Lock()
-			for id, filter := range s.transactionQueue {
-				if time.Since(filter.timeout) > filterTickerTime {
-					s.filterManager.Remove(id)
-					delete(s.transactionQueue, id)
-				}
-			}
-			s.transactionMu.Unlock()
-			s.filterManager.Unlock()
+			s.expire()
 		case <-s.quit:
 			break done
 		}
@@ -132,6 +142,57 @@ done:
 
 }
 
+// expire uninstalls the filters that haven't been polled within the timeout.
+// Log subscriptions aren't polled and stay until they are unsubscribed.
+func (s *PublicFilterAPI) expire() {
+	s.filterManager.Lock() // lock order like filterLoop()
+	defer s.filterManager.Unlock()
+
+	expired := make(map[int]bool)
+	s.logMu.Lock()
+	for id, filter := range s.logQueue {
+		if !filter.subscription && time.Since(filter.timeout) > s.timeout {
+			expired[id] = true
+			delete(s.logQueue, id)
+		}
+	}
+	s.logMu.Unlock()
+
+	s.blockMu.Lock()
+	for id, filter := range s.blockQueue {
+		if time.Since(filter.timeout) > s.timeout {
+			expired[id] = true
+			delete(s.blockQueue, id)
+		}
+	}
+	s.blockMu.Unlock()
+
+	s.transactionMu.Lock()
+	for id, filter := range s.transactionQueue {
+		if time.Since(filter.timeout) > s.timeout {
+			expired[id] = true
+			delete(s.transactionQueue, id)
+		}
+	}
+	s.transactionMu.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+	for id := range expired {
+		s.filterManager.Remove(id)
+	}
+	s.filterMapMu.Lock()
+	for externalId, id := range s.filterMapping {
+		if expired[id] {
+			delete(s.filterMapping, externalId)
+			s.unpersistFilter(externalId)
+			glog.V(logger.Debug).Infof("Uninstalled filter %s, not polled for %v", externalId, s.timeout)
+		}
+	}
+	s.filterMapMu.Unlock()
+}
+
 // NewBlockFilter create a new filter that returns blocks that are included into the canonical chain.
 func (s *PublicFilterAPI) NewBlockFilter() (string, error) {
 	// protect filterManager.Add() and setting of filter fields
@@ -219,7 +280,7 @@ func (s *PublicFilterAPI) newLogFilter(earliest, latest int64, addresses []commo
 	}
 
 	s.logMu.Lock()
-	s.logQueue[id] = &logQueue{timeout: time.Now()}
+	s.logQueue[id] = &logQueue{timeout: time.Now(), subscription: callback != nil}
 	s.logMu.Unlock()
 
 	filter.SetBeginBlock(earliest)
@@ -292,6 +353,94 @@ func (s *PublicFilterAPI) Logs(ctx context.Context, args NewFilterArgs) (rpc.Sub
 	return subscription, err
 }
 
+// logChunk is a notification of a log stream, holding the matching logs of a
+// block range. The final chunk is marked done.
+type logChunk struct {
+	Logs      []vmlog `json:"logs"`
+	FromBlock uint64  `json:"fromBlock"`
+	ToBlock   uint64  `json:"toBlock"`
+	Done      bool    `json:"done"`
+}
+
+// StreamLogs creates a subscription which sends the logs matching the given
+// filter criteria in chunks as they are found, so the node doesn't buffer
+// large results in memory. The subscription ends after the chunk marked done.
+func (s *PublicFilterAPI) StreamLogs(ctx context.Context, args NewFilterArgs) (rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	head := core.GetHeader(s.chainDb, core.GetHeadBlockHash(s.chainDb))
+	if head == nil {
+		return nil, errors.New("no head block")
+	}
+	resolve := func(num rpc.BlockNumber) uint64 {
+		if num < 0 || uint64(num) > head.Number.Uint64() {
+			return head.Number.Uint64()
+		}
+		return uint64(num)
+	}
+	begin, end := resolve(args.FromBlock), resolve(args.ToBlock)
+
+	cancelled := make(chan struct{})
+	subscription, err := notifier.NewSubscription(func(string) {
+		close(cancelled)
+	})
+	if err != nil {
+		return nil, err
+	}
+	go s.streamLogs(subscription, cancelled, begin, end, args.Addresses, args.Topics)
+	return subscription, nil
+}
+
+// streamLogs searches the block range window by window and sends the matches.
+func (s *PublicFilterAPI) streamLogs(sub rpc.Subscription, cancelled chan struct{}, begin, end uint64, addresses []common.Address, topics [][]common.Hash) {
+	throttler, _ := sub.(rpc.Throttler)
+	send := func(chunk *logChunk) bool {
+		if throttler != nil {
+			if err := throttler.Throttle(streamMaxQueued); err != nil {
+				return false
+			}
+		}
+		return sub.Notify(chunk) == nil
+	}
+
+	if begin > end {
+		send(&logChunk{Logs: []vmlog{}, FromBlock: begin, ToBlock: end, Done: true})
+	}
+	for from := begin; from <= end; from += streamWindow {
+		select {
+		case <-cancelled:
+			return
+		default:
+		}
+		to := from + streamWindow - 1
+		if to > end || to < from {
+			to = end
+		}
+		filter := New(s.chainDb)
+		filter.SetBeginBlock(int64(from))
+		filter.SetEndBlock(int64(to))
+		filter.SetAddresses(addresses)
+		filter.SetTopics(topics)
+
+		logs := toRPCLogs(filter.Find(), false)
+		for len(logs) > streamChunkSize {
+			if !send(&logChunk{Logs: logs[:streamChunkSize], FromBlock: from, ToBlock: to}) {
+				return
+			}
+			logs = logs[streamChunkSize:]
+		}
+		if !send(&logChunk{Logs: logs, FromBlock: from, ToBlock: to, Done: to == end}) {
+			return
+		}
+		if to == end {
+			break
+		}
+	}
+	sub.Cancel()
+}
+
 // NewFilterArgs represents a request to create a new filter.
 type NewFilterArgs struct {
 	FromBlock rpc.BlockNumber
@@ -420,22 +569,36 @@ func (s *PublicFilterAPI) NewFilter(args NewFilterArgs) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	criteria := &FilterCriteria{
+		FromBlock: args.FromBlock.Int64(),
+		ToBlock:   args.ToBlock.Int64(),
+		Addresses: args.Addresses,
+		Topics:    args.Topics,
+	}
+	if _, err := s.installLogFilter(externalId, criteria); err != nil {
+		return "", err
+	}
+	s.persistFilter(externalId, &persistedFilter{Criteria: criteria, Created: time.Now()})
 
-	var id int
-	if len(args.Addresses) > 0 {
-		id, err = s.newLogFilter(args.FromBlock.Int64(), args.ToBlock.Int64(), args.Addresses, args.Topics, nil)
-	} else {
-		id, err = s.newLogFilter(args.FromBlock.Int64(), args.ToBlock.Int64(), nil, args.Topics, nil)
+	return externalId, nil
+}
+
+// installLogFilter installs a polled log filter under the given external id.
+func (s *PublicFilterAPI) installLogFilter(externalId string, criteria *FilterCriteria) (int, error) {
+	var addresses []common.Address
+	if len(criteria.Addresses) > 0 {
+		addresses = criteria.Addresses
 	}
+	id, err := s.newLogFilter(criteria.FromBlock, criteria.ToBlock, addresses, criteria.Topics, nil)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
 
 	s.filterMapMu.Lock()
 	s.filterMapping[externalId] = id
 	s.filterMapMu.Unlock()
 
-	return externalId, nil
+	return id, nil
 }
 
 // GetLogs returns the logs matching the given argument.
@@ -463,6 +626,8 @@ func (s *PublicFilterAPI) UninstallFilter(filterId string) bool {
 	delete(s.filterMapping, filterId)
 	s.filterMapMu.Unlock()
 
+	s.unpersistFilter(filterId)
+
 	s.filterManager.Remove(id)
 
 	s.logMu.Lock()
@@ -586,8 +751,9 @@ type vmlog struct {
 type logQueue struct {
 	mu sync.Mutex
 
-	logs    []vmlog
-	timeout time.Time
+	logs         []vmlog
+	timeout      time.Time
+	subscription bool // logs are sent to a subscription instead of being polled
 }
 
 func (l *logQueue) add(logs ...vmlog) {
@@ -25,8 +25,41 @@ import (
 	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/core/vm"
 	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
 )
 
+// Log search strategies chosen by the query planner.
+//
+// The address transaction index isn't a candidate: it only records the
+// top-level sender and recipient of transactions, while logs are also
+// emitted by contracts reached through internal calls.
+const (
+	planReceipts = "receipts" // read the receipts of every block
+	planHeaders  = "headers"  // read the receipts of blocks whose header bloom matches
+	planMipmap   = "mipmap"   // descend the address mipmap blooms, then check header blooms
+)
+
+const (
+	// mipmapMinRange is the smallest block range worth descending the mipmap
+	// blooms for, the size of their lowest level.
+	mipmapMinRange = 1000
+
+	// mipmapMaxAddresses is the number of addresses above which mipmap blooms
+	// are too likely to match for the descent to pay off.
+	mipmapMaxAddresses = 64
+)
+
+// queryStats records the work done by a log query.
+type queryStats struct {
+	plan           string
+	mipmaps        int // mipmap blooms read
+	headers        int // header blooms checked
+	receipts       int // blocks whose receipts were read
+	falsePositives int // blocks read without matching logs
+	logs           int
+}
+
 type AccountChange struct {
 	Address, StateAddress []byte
 }
@@ -85,22 +118,65 @@ func (self *Filter) Find() vm.Logs {
 		endBlockNo = latestBlock.NumberU64()
 	}
 
-	// if no addresses are present we can't make use of fast search which
-	// uses the mipmap bloom filters to check for fast inclusion and uses
-	// higher range probability in order to ensure at least a false positive
-	if len(self.addresses) == 0 {
-		return self.getLogs(beginBlockNo, endBlockNo)
+	var (
+		start = time.Now()
+		stats = &queryStats{plan: self.plan(beginBlockNo, endBlockNo)}
+		logs  vm.Logs
+	)
+	switch stats.plan {
+	case planMipmap:
+		logs = self.mipFind(beginBlockNo, endBlockNo, 0, stats)
+	default:
+		logs = self.getLogs(beginBlockNo, endBlockNo, stats)
+	}
+	stats.logs = len(logs)
+
+	glog.V(logger.Debug).Infof("Log query #%d-#%d (%d addresses, %d topics) planned as %s: %d mipmap blooms, %d header blooms, %d receipt reads (%d false positives), %d logs in %v",
+		beginBlockNo, endBlockNo, len(self.addresses), len(self.topics), stats.plan, stats.mipmaps, stats.headers, stats.receipts, stats.falsePositives, stats.logs, time.Since(start))
+	return logs
+}
+
+// plan chooses the search strategy for the given block range.
+func (self *Filter) plan(begin, end uint64) string {
+	switch {
+	case len(self.addresses) == 0 && !self.hasTopics():
+		// Every log matches, blooms can't rule out any block with logs.
+		return planReceipts
+	case len(self.addresses) == 0 || len(self.addresses) > mipmapMaxAddresses:
+		// Mipmap blooms only hold addresses, and too many of them match everywhere.
+		return planHeaders
+	case end < begin || end-begin+1 < mipmapMinRange:
+		return planHeaders
+	default:
+		return planMipmap
+	}
+}
+
+// hasTopics reports whether any topic position restricts the matched logs.
+func (self *Filter) hasTopics() bool {
+	for _, sub := range self.topics {
+		var wildcard bool
+		for _, topic := range sub {
+			if (topic == common.Hash{}) {
+				wildcard = true
+				break
+			}
+		}
+		if !wildcard {
+			return true
+		}
 	}
-	return self.mipFind(beginBlockNo, endBlockNo, 0)
+	return false
 }
 
-func (self *Filter) mipFind(start, end uint64, depth int) (logs vm.Logs) {
+func (self *Filter) mipFind(start, end uint64, depth int, stats *queryStats) (logs vm.Logs) {
 	level := core.MIPMapLevels[depth]
 	// normalise numerator so we can work in level specific batches and
 	// work with the proper range checks
 	for num := start / level * level; num <= end; num += level {
 		// find addresses in bloom filters
 		bloom := core.GetMipmapBloom(self.db, num, level)
+		stats.mipmaps++
 		for _, addr := range self.addresses {
 			if types.BloomLookup(bloom, addr[:]) {
 				// range check normalised values and make sure that
@@ -109,9 +185,9 @@ func (self *Filter) mipFind(start, end uint64, depth int) (logs vm.Logs) {
 				start := uint64(math.Max(float64(num), float64(start)))
 				end := uint64(math.Min(float64(num+level-1), float64(end)))
 				if depth+1 == len(core.MIPMapLevels) {
-					logs = append(logs, self.getLogs(start, end)...)
+					logs = append(logs, self.getLogs(start, end, stats)...)
 				} else {
-					logs = append(logs, self.mipFind(start, end, depth+1)...)
+					logs = append(logs, self.mipFind(start, end, depth+1, stats)...)
 				}
 				// break so we don't check the same range for each
 				// possible address. Checks on multiple addresses
@@ -124,30 +200,40 @@ func (self *Filter) mipFind(start, end uint64, depth int) (logs vm.Logs) {
 	return logs
 }
 
-func (self *Filter) getLogs(start, end uint64) (logs vm.Logs) {
+// getLogs scans the given range block by block. Unless planned to read all
+// receipts, only blocks whose header bloom matches the filter are read.
+func (self *Filter) getLogs(start, end uint64, stats *queryStats) (logs vm.Logs) {
 	for i := start; i <= end; i++ {
-		var block *types.Block
 		hash := core.GetCanonicalHash(self.db, i)
-		if hash != (common.Hash{}) {
-			block = core.GetBlock(self.db, hash)
-		}
-		if block == nil { // block not found/written
+		if hash == (common.Hash{}) { // block not found/written
 			return logs
 		}
-
-		// Use bloom filtering to see if this block is interesting given the
-		// current parameters
-		if self.bloomFilter(block) {
-			// Get the logs of the block
-			var (
-				receipts   = core.GetBlockReceipts(self.db, block.Hash())
-				unfiltered vm.Logs
-			)
-			for _, receipt := range receipts {
-				unfiltered = append(unfiltered, receipt.Logs...)
+		if stats.plan != planReceipts {
+			header := core.GetHeader(self.db, hash)
+			if header == nil {
+				return logs
+			}
+			// Use bloom filtering to see if this block is interesting given the
+			// current parameters
+			stats.headers++
+			if !self.bloomFilter(header.Bloom) {
+				continue
 			}
-			logs = append(logs, self.FilterLogs(unfiltered)...)
 		}
+		// Get the logs of the block
+		var (
+			receipts   = core.GetBlockReceipts(self.db, hash)
+			unfiltered vm.Logs
+		)
+		for _, receipt := range receipts {
+			unfiltered = append(unfiltered, receipt.Logs...)
+		}
+		matched := self.FilterLogs(unfiltered)
+		stats.receipts++
+		if len(matched) == 0 {
+			stats.falsePositives++
+		}
+		logs = append(logs, matched...)
 	}
 
 	return logs
@@ -204,11 +290,11 @@ Logs:
 	return ret
 }
 
-func (self *Filter) bloomFilter(block *types.Block) bool {
+func (self *Filter) bloomFilter(bloom types.Bloom) bool {
 	if len(self.addresses) > 0 {
 		var included bool
 		for _, addr := range self.addresses {
-			if types.BloomLookup(block.Bloom(), addr[:]) {
+			if types.BloomLookup(bloom, addr[:]) {
 				included = true
 				break
 			}
@@ -222,7 +308,7 @@ func (self *Filter) bloomFilter(block *types.Block) bool {
 	for _, sub := range self.topics {
 		var included bool
 		for _, topic := range sub {
-			if (topic == common.Hash{}) || types.BloomLookup(block.Bloom(), topic[:]) {
+			if (topic == common.Hash{}) || types.BloomLookup(bloom, topic[:]) {
 				included = true
 				break
 			}
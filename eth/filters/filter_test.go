@@ -286,3 +286,33 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+func TestFilterPlan(t *testing.T) {
+	var (
+		addr  = common.BytesToAddress([]byte("addr"))
+		topic = common.BytesToHash([]byte("topic"))
+		many  = make([]common.Address, mipmapMaxAddresses+1)
+	)
+	tests := []struct {
+		addresses  []common.Address
+		topics     [][]common.Hash
+		begin, end uint64
+		want       string
+	}{
+		{nil, nil, 0, 100000, planReceipts},
+		{nil, [][]common.Hash{{common.Hash{}}}, 0, 100000, planReceipts},
+		{nil, [][]common.Hash{{topic}}, 0, 100000, planHeaders},
+		{[]common.Address{addr}, nil, 0, 100, planHeaders},
+		{[]common.Address{addr}, nil, 0, 100000, planMipmap},
+		{[]common.Address{addr}, [][]common.Hash{{topic}}, 5000, 5999, planMipmap},
+		{many, nil, 0, 100000, planHeaders},
+	}
+	for i, test := range tests {
+		filter := New(nil)
+		filter.SetAddresses(test.addresses)
+		filter.SetTopics(test.topics)
+		if plan := filter.plan(test.begin, test.end); plan != test.want {
+			t.Errorf("test %d: got plan %s, want %s", i, plan, test.want)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+)
+
+// FilterCriteria are the criteria of a log filter. Block numbers are -1 for
+// the latest block.
+type FilterCriteria struct {
+	FromBlock int64            `json:"fromBlock"`
+	ToBlock   int64            `json:"toBlock"`
+	Addresses []common.Address `json:"addresses"`
+	Topics    [][]common.Hash  `json:"topics"`
+}
+
+// persistedFilter is a log filter stored to be reinstalled after a restart.
+type persistedFilter struct {
+	Criteria *FilterCriteria `json:"criteria"`
+	Created  time.Time       `json:"created"`
+}
+
+// FilterInfo describes an installed filter.
+type FilterInfo struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"` // "block", "pendingTransaction", "log" or "logSubscription"
+	Created   time.Time       `json:"created"`
+	Age       uint64          `json:"age"`      // Seconds since the filter was installed
+	Idle      uint64          `json:"idle"`     // Seconds since the filter was last polled
+	Expires   uint64          `json:"expires"`  // Seconds until the filter is uninstalled unless polled, zero for subscriptions
+	Criteria  *FilterCriteria `json:"criteria"` // Nil unless a log filter
+	Persisted bool            `json:"persisted"`
+}
+
+// persistFilter stores a log filter if filters are kept across restarts.
+func (s *PublicFilterAPI) persistFilter(externalId string, filter *persistedFilter) {
+	if !s.persist {
+		return
+	}
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+
+	s.persisted[externalId] = filter
+	s.writePersisted()
+}
+
+// unpersistFilter removes a log filter from the stored ones, if present.
+func (s *PublicFilterAPI) unpersistFilter(externalId string) {
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+
+	if _, ok := s.persisted[externalId]; ok {
+		delete(s.persisted, externalId)
+		s.writePersisted()
+	}
+}
+
+// writePersisted stores the persisted filters. Expects persistMu to be locked.
+func (s *PublicFilterAPI) writePersisted() {
+	blob, err := json.Marshal(s.persisted)
+	if err == nil {
+		err = s.chainDb.Put(persistedFiltersKey, blob)
+	}
+	if err != nil {
+		glog.V(logger.Warn).Infof("Failed to persist filters: %v", err)
+	}
+}
+
+// restoreFilters reinstalls the log filters stored before a restart under
+// their previous ids. Logs emitted while the node was down aren't delivered
+// by GetFilterChanges, but can be found with GetFilterLogs.
+func (s *PublicFilterAPI) restoreFilters() {
+	blob, err := s.chainDb.Get(persistedFiltersKey)
+	if err != nil {
+		return
+	}
+	stored := make(map[string]*persistedFilter)
+	if err := json.Unmarshal(blob, &stored); err != nil {
+		glog.V(logger.Warn).Infof("Discarding corrupt persisted filters: %v", err)
+		s.chainDb.Delete(persistedFiltersKey)
+		return
+	}
+	for externalId, filter := range stored {
+		if filter.Criteria == nil {
+			continue
+		}
+		id, err := s.installLogFilter(externalId, filter.Criteria)
+		if err != nil {
+			glog.V(logger.Warn).Infof("Failed to restore filter %s: %v", externalId, err)
+			continue
+		}
+		s.filterManager.Lock()
+		if f := s.filterManager.generic[id]; f != nil {
+			f.created = filter.Created
+		}
+		s.filterManager.Unlock()
+		s.persisted[externalId] = filter
+	}
+	s.persistMu.Lock()
+	s.writePersisted()
+	s.persistMu.Unlock()
+
+	if len(s.persisted) > 0 {
+		glog.V(logger.Info).Infof("Restored %d log filters", len(s.persisted))
+	}
+}
+
+// filters describes all installed filters, oldest first.
+func (s *PublicFilterAPI) filters() []*FilterInfo {
+	s.filterManager.Lock() // lock order like UninstallFilter()
+	defer s.filterManager.Unlock()
+	s.filterMapMu.RLock()
+	defer s.filterMapMu.RUnlock()
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+
+	now := time.Now()
+	infos := make([]*FilterInfo, 0, len(s.filterMapping))
+	for externalId, id := range s.filterMapping {
+		filter := s.filterManager.generic[id]
+		if filter == nil {
+			continue
+		}
+		info := &FilterInfo{ID: externalId, Created: filter.created}
+		_, info.Persisted = s.persisted[externalId]
+
+		var polled time.Time
+		switch s.getFilterType(id) {
+		case blockFilterTy:
+			info.Type = "block"
+			polled = s.blockQueue[id].timeout
+		case transactionFilterTy:
+			info.Type = "pendingTransaction"
+			polled = s.transactionQueue[id].timeout
+		case logFilterTy:
+			queue := s.logQueue[id]
+			if queue.subscription {
+				info.Type = "logSubscription"
+			} else {
+				info.Type = "log"
+				polled = queue.timeout
+			}
+			info.Criteria = &FilterCriteria{
+				FromBlock: filter.begin,
+				ToBlock:   filter.end,
+				Addresses: filter.addresses,
+				Topics:    filter.topics,
+			}
+		default:
+			continue
+		}
+		info.Age = uint64(now.Sub(filter.created) / time.Second)
+		if !polled.IsZero() {
+			idle := now.Sub(polled)
+			info.Idle = uint64(idle / time.Second)
+			if idle < s.timeout {
+				info.Expires = uint64((s.timeout - idle) / time.Second)
+			}
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Created.Before(infos[j].Created) })
+	return infos
+}
+
+// PrivateFilterAPI offers the node operator an overview of the filters installed
+// by all clients, so it isn't part of the public API.
+type PrivateFilterAPI struct {
+	api *PublicFilterAPI
+}
+
+// NewPrivateFilterAPI creates a new API for inspecting the filters of api.
+func NewPrivateFilterAPI(api *PublicFilterAPI) *PrivateFilterAPI {
+	return &PrivateFilterAPI{api: api}
+}
+
+// Filters lists the installed filters and log subscriptions with their
+// criteria, age and time left until they expire, oldest first.
+func (api *PrivateFilterAPI) Filters() []*FilterInfo {
+	return api.api.filters()
+}
@@ -0,0 +1,96 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+	"github.com/webchain-network/webchaind/rpc"
+)
+
+func TestFilterExpiry(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	api := NewPublicFilterAPI(db, new(event.TypeMux), time.Minute, false)
+	defer api.Stop()
+
+	blockId, _ := api.NewBlockFilter()
+	logId, _ := api.NewFilter(NewFilterArgs{FromBlock: rpc.LatestBlockNumber, ToBlock: rpc.LatestBlockNumber})
+
+	infos := NewPrivateFilterAPI(api).Filters()
+	if len(infos) != 2 {
+		t.Fatalf("filter count mismatch: have %d, want 2", len(infos))
+	}
+	for _, info := range infos {
+		if info.Expires < 59 || info.Expires > 60 {
+			t.Errorf("filter %s expires in %ds, want 60s", info.ID, info.Expires)
+		}
+	}
+
+	// Let the block filter time out, keeping the log filter polled.
+	api.blockQueue[api.filterMapping[blockId]].timeout = time.Now().Add(-2 * time.Minute)
+	api.expire()
+
+	if api.UninstallFilter(blockId) {
+		t.Error("expired filter still installed")
+	}
+	infos = NewPrivateFilterAPI(api).Filters()
+	if len(infos) != 1 || infos[0].ID != logId || infos[0].Type != "log" {
+		t.Fatalf("filters mismatch after expiry: %+v", infos)
+	}
+}
+
+func TestFilterPersistence(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	api := NewPublicFilterAPI(db, new(event.TypeMux), 0, true)
+
+	addr := common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")
+	topics := [][]common.Hash{{common.HexToHash("0x01")}}
+	kept, _ := api.NewFilter(NewFilterArgs{FromBlock: 5, ToBlock: rpc.LatestBlockNumber, Addresses: []common.Address{addr}, Topics: topics})
+	removed, _ := api.NewFilter(NewFilterArgs{FromBlock: rpc.LatestBlockNumber, ToBlock: rpc.LatestBlockNumber})
+	api.NewBlockFilter()
+	api.UninstallFilter(removed)
+	api.Stop()
+
+	// Restart, only the installed log filter comes back.
+	api = NewPublicFilterAPI(db, new(event.TypeMux), 0, true)
+	infos := NewPrivateFilterAPI(api).Filters()
+	if len(infos) != 1 || infos[0].ID != kept || !infos[0].Persisted {
+		t.Fatalf("restored filters mismatch: %+v", infos)
+	}
+	want := &FilterCriteria{FromBlock: 5, ToBlock: -1, Addresses: []common.Address{addr}, Topics: topics}
+	if !reflect.DeepEqual(infos[0].Criteria, want) {
+		t.Errorf("criteria mismatch: have %+v, want %+v", infos[0].Criteria, want)
+	}
+	if changes := api.GetFilterChanges(kept); changes == nil {
+		t.Error("restored filter can't be polled")
+	}
+	api.Stop()
+
+	// Restarting without persistence drops the stored filters.
+	api = NewPublicFilterAPI(db, new(event.TypeMux), 0, false)
+	api.Stop()
+	api = NewPublicFilterAPI(db, new(event.TypeMux), 0, true)
+	defer api.Stop()
+	if infos := NewPrivateFilterAPI(api).Filters(); len(infos) != 0 {
+		t.Errorf("filters restored after persistence was disabled: %+v", infos)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+)
+
+const (
+	gasStatsWindow       = 256 // Number of recent blocks analysed
+	gasStatsTopContracts = 10  // Number of contracts reported by gas used
+)
+
+// GasPricePercentiles summarises the gas prices paid by a set of transactions.
+type GasPricePercentiles struct {
+	Min    *big.Int `json:"min"`
+	P25    *big.Int `json:"p25"`
+	Median *big.Int `json:"median"`
+	P75    *big.Int `json:"p75"`
+	Max    *big.Int `json:"max"`
+}
+
+func newGasPricePercentiles(sorted []*big.Int) *GasPricePercentiles {
+	if len(sorted) == 0 {
+		return nil
+	}
+	at := func(p int) *big.Int {
+		return new(big.Int).Set(sorted[(len(sorted)-1)*p/100])
+	}
+	return &GasPricePercentiles{Min: at(0), P25: at(25), Median: at(50), P75: at(75), Max: at(100)}
+}
+
+// BlockGasStats is the gas usage of a single block.
+type BlockGasStats struct {
+	Number       uint64               `json:"number"`
+	Hash         common.Hash          `json:"hash"`
+	GasUsed      *big.Int             `json:"gasUsed"`
+	GasLimit     *big.Int             `json:"gasLimit"`
+	Utilization  float64              `json:"utilization"` // Gas used as a fraction of the gas limit
+	Transactions int                  `json:"transactions"`
+	GasPrices    *GasPricePercentiles `json:"gasPrices"` // Nil for empty blocks
+
+	prices    []*big.Int // Sorted gas prices of the transactions
+	contracts map[common.Address]*ContractGasStats
+}
+
+// ContractGasStats is the gas spent by transactions calling a contract.
+type ContractGasStats struct {
+	Address      common.Address `json:"address"`
+	GasUsed      *big.Int       `json:"gasUsed"`
+	Transactions int            `json:"transactions"`
+}
+
+// GasStats summarises the gas usage of a range of recent blocks.
+type GasStats struct {
+	FirstBlock         uint64               `json:"firstBlock"`
+	LastBlock          uint64               `json:"lastBlock"`
+	AverageUtilization float64              `json:"averageUtilization"`
+	GasPrices          *GasPricePercentiles `json:"gasPrices"`
+	TopContracts       []*ContractGasStats  `json:"topContracts"`
+	Blocks             []*BlockGasStats     `json:"blocks"`
+}
+
+// gasTracker keeps gas usage statistics of the most recent canonical blocks,
+// updated as blocks are imported.
+type gasTracker struct {
+	db ethdb.Database
+
+	mu     sync.RWMutex
+	blocks []*BlockGasStats // Ascending by number, at most gasStatsWindow
+}
+
+// newGasTracker creates a tracker seeded with the blocks leading up to the
+// current head, following the chain as it's extended.
+func newGasTracker(bc *core.BlockChain, db ethdb.Database, mux *event.TypeMux) *gasTracker {
+	t := &gasTracker{db: db}
+	sub := mux.Subscribe(core.ChainEvent{})
+
+	head := bc.CurrentBlock().NumberU64()
+	from := uint64(1)
+	if head >= gasStatsWindow {
+		from = head - gasStatsWindow + 1
+	}
+	go func() {
+		for n := from; n <= head; n++ {
+			if block := bc.GetBlockByNumber(n); block != nil {
+				t.add(block)
+			}
+		}
+		for ev := range sub.Chan() {
+			if ev, ok := ev.Data.(core.ChainEvent); ok {
+				t.add(ev.Block)
+			}
+		}
+	}()
+	return t
+}
+
+// add records the statistics of a new canonical block, dropping any blocks it
+// replaces in a reorganisation.
+func (t *gasTracker) add(block *types.Block) {
+	stats := &BlockGasStats{
+		Number:       block.NumberU64(),
+		Hash:         block.Hash(),
+		GasUsed:      block.GasUsed(),
+		GasLimit:     block.GasLimit(),
+		Transactions: len(block.Transactions()),
+		contracts:    make(map[common.Address]*ContractGasStats),
+	}
+	if block.GasLimit().Sign() > 0 {
+		stats.Utilization, _ = new(big.Rat).SetFrac(block.GasUsed(), block.GasLimit()).Float64()
+	}
+	receipts := core.GetBlockReceipts(t.db, block.Hash())
+	for i, tx := range block.Transactions() {
+		stats.prices = append(stats.prices, tx.GasPrice())
+
+		// Only calls carrying data and creations count as contract usage.
+		if i >= len(receipts) || receipts[i].GasUsed == nil {
+			continue
+		}
+		var addr common.Address
+		switch {
+		case tx.To() == nil:
+			addr = receipts[i].ContractAddress
+		case len(tx.Data()) > 0:
+			addr = *tx.To()
+		default:
+			continue
+		}
+		c := stats.contracts[addr]
+		if c == nil {
+			c = &ContractGasStats{Address: addr, GasUsed: new(big.Int)}
+			stats.contracts[addr] = c
+		}
+		c.GasUsed.Add(c.GasUsed, receipts[i].GasUsed)
+		c.Transactions++
+	}
+	sortBigInts(stats.prices)
+	stats.GasPrices = newGasPricePercentiles(stats.prices)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for len(t.blocks) > 0 && t.blocks[len(t.blocks)-1].Number >= stats.Number {
+		t.blocks = t.blocks[:len(t.blocks)-1]
+	}
+	t.blocks = append(t.blocks, stats)
+	if len(t.blocks) > gasStatsWindow {
+		t.blocks = t.blocks[len(t.blocks)-gasStatsWindow:]
+	}
+}
+
+// stats summarises the last n tracked blocks, or all of them if n is zero.
+func (t *gasTracker) stats(n int) *GasStats {
+	t.mu.RLock()
+	blocks := t.blocks
+	t.mu.RUnlock()
+
+	if n > 0 && n < len(blocks) {
+		blocks = blocks[len(blocks)-n:]
+	}
+	res := &GasStats{Blocks: blocks, TopContracts: []*ContractGasStats{}}
+	if len(blocks) == 0 {
+		return res
+	}
+	res.FirstBlock, res.LastBlock = blocks[0].Number, blocks[len(blocks)-1].Number
+
+	var (
+		prices    []*big.Int
+		contracts = make(map[common.Address]*ContractGasStats)
+	)
+	for _, b := range blocks {
+		res.AverageUtilization += b.Utilization / float64(len(blocks))
+		prices = append(prices, b.prices...)
+		for addr, c := range b.contracts {
+			total := contracts[addr]
+			if total == nil {
+				total = &ContractGasStats{Address: addr, GasUsed: new(big.Int)}
+				contracts[addr] = total
+			}
+			total.GasUsed.Add(total.GasUsed, c.GasUsed)
+			total.Transactions += c.Transactions
+		}
+	}
+	sortBigInts(prices)
+	res.GasPrices = newGasPricePercentiles(prices)
+
+	for _, c := range contracts {
+		res.TopContracts = append(res.TopContracts, c)
+	}
+	sort.Slice(res.TopContracts, func(i, j int) bool {
+		return res.TopContracts[i].GasUsed.Cmp(res.TopContracts[j].GasUsed) > 0
+	})
+	if len(res.TopContracts) > gasStatsTopContracts {
+		res.TopContracts = res.TopContracts[:gasStatsTopContracts]
+	}
+	return res
+}
+
+func sortBigInts(s []*big.Int) {
+	sort.Slice(s, func(i, j int) bool { return s[i].Cmp(s[j]) < 0 })
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+func TestGasTracker(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	tracker := &gasTracker{db: db}
+
+	contract, user := common.HexToAddress("0xc0"), common.HexToAddress("0xaa")
+	makeBlock := func(number int64, extra byte, prices ...int64) *types.Block {
+		var (
+			txs      []*types.Transaction
+			receipts []*types.Receipt
+			used     = new(big.Int)
+		)
+		for i, price := range prices {
+			to, data := user, []byte(nil)
+			if i%2 == 0 {
+				to, data = contract, []byte{0x01}
+			}
+			tx := types.NewTransaction(uint64(i), to, new(big.Int), big.NewInt(50000), big.NewInt(price), data)
+			gas := big.NewInt(21000 + int64(len(data))*1000)
+			used.Add(used, gas)
+			receipt := types.NewReceipt(nil, used)
+			receipt.GasUsed = gas
+			txs, receipts = append(txs, tx), append(receipts, receipt)
+		}
+		header := &types.Header{Number: big.NewInt(number), GasLimit: big.NewInt(100000), GasUsed: used, Extra: []byte{extra}}
+		block := types.NewBlock(header, txs, nil, receipts)
+		core.WriteBlockReceipts(db, block.Hash(), receipts)
+		return block
+	}
+
+	tracker.add(makeBlock(1, 0, 1, 2, 3))
+	tracker.add(makeBlock(2, 0, 4))
+	// A reorg replaces block 2.
+	tracker.add(makeBlock(2, 1, 10, 20))
+
+	stats := tracker.stats(0)
+	if stats.FirstBlock != 1 || stats.LastBlock != 2 || len(stats.Blocks) != 2 {
+		t.Fatalf("range: got %d-%d in %d blocks", stats.FirstBlock, stats.LastBlock, len(stats.Blocks))
+	}
+	if p := stats.GasPrices; p.Min.Int64() != 1 || p.Median.Int64() != 3 || p.Max.Int64() != 20 {
+		t.Errorf("gas prices: got min %v median %v max %v", p.Min, p.Median, p.Max)
+	}
+	if len(stats.TopContracts) != 1 || stats.TopContracts[0].Address != contract || stats.TopContracts[0].Transactions != 3 {
+		t.Fatalf("top contracts: got %+v", stats.TopContracts)
+	}
+	if gas := stats.TopContracts[0].GasUsed; gas.Int64() != 3*22000 {
+		t.Errorf("contract gas: got %v", gas)
+	}
+	if u := stats.Blocks[1].Utilization; u != 0.43 {
+		t.Errorf("utilization: got %v, want 0.43", u)
+	}
+
+	if last := tracker.stats(1); last.FirstBlock != 2 || len(last.Blocks) != 1 {
+		t.Errorf("last block: got %d in %d blocks", last.FirstBlock, len(last.Blocks))
+	}
+}
@@ -181,10 +181,12 @@ func (self *GasPriceOracle) lowestPrice(block *types.Block) *big.Int {
 	if len(txs) == 0 {
 		return big.NewInt(0)
 	}
-	// block is full, find smallest gasPrice
-	minPrice := txs[0].GasPrice()
+	// block is full, find smallest gasPrice, the tip above the base fee once
+	// the block has one
+	baseFee := block.BaseFee()
+	minPrice := txs[0].EffectiveGasTip(baseFee)
 	for i := 1; i < len(txs); i++ {
-		price := txs[i].GasPrice()
+		price := txs[i].EffectiveGasTip(baseFee)
 		if price.Cmp(minPrice) < 0 {
 			minPrice = price
 		}
@@ -208,3 +210,14 @@ func (self *GasPriceOracle) SuggestPrice() *big.Int {
 	}
 	return price
 }
+
+// SuggestPriceWithBaseFee returns the recommended gas price for transactions
+// paying a fixed price, which pay the base fee of the head block on top of the
+// suggested price once the fee market is active.
+func (self *GasPriceOracle) SuggestPriceWithBaseFee() *big.Int {
+	price := self.SuggestPrice()
+	if baseFee := self.eth.BlockChain().CurrentBlock().BaseFee(); baseFee != nil {
+		price.Add(price, baseFee)
+	}
+	return price
+}
@@ -26,8 +26,10 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/hashicorp/golang-lru"
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/forkid"
 	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/eth/downloader"
 	"github.com/webchain-network/webchaind/eth/fetcher"
@@ -35,6 +37,7 @@ import (
 	"github.com/webchain-network/webchaind/event"
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/metrics"
 	"github.com/webchain-network/webchaind/p2p"
 	"github.com/webchain-network/webchaind/p2p/discover"
 	"github.com/webchain-network/webchaind/pow"
@@ -48,6 +51,10 @@ const (
 	// txChanSize is the size of channel listening to NewTxsEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
+
+	// peerTDLimit is the number of nodes whose last advertised total
+	// difficulty is remembered to prioritise dials.
+	peerTDLimit = 1024
 )
 
 var (
@@ -77,6 +84,12 @@ type ProtocolManager struct {
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
 	peers      *peerSet
+	peerTDs    *lru.Cache // Last total difficulty advertised by recently seen nodes
+	forkFilter forkid.Filter
+	minVersion int // Lowest protocol version peers are accepted with, set before Start
+
+	txPolicy   TxPropagationPolicy // How transactions are relayed, set before Start
+	txRequests *txRequestSet       // Announced transactions being fetched
 
 	SubProtocols []p2p.Protocol
 
@@ -99,6 +112,7 @@ type ProtocolManager struct {
 // with the ethereum network.
 func NewProtocolManager(config *core.ChainConfig, mode downloader.SyncMode, networkId uint64, mux *event.TypeMux, txpool txPool, pow pow.PoW, blockchain *core.BlockChain, chaindb ethdb.Database) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
+	peerTDs, _ := lru.New(peerTDLimit)
 	manager := &ProtocolManager{
 		networkId:   networkId,
 		eventMux:    mux,
@@ -107,6 +121,10 @@ func NewProtocolManager(config *core.ChainConfig, mode downloader.SyncMode, netw
 		chaindb:     chaindb,
 		chainConfig: config,
 		peers:       newPeerSet(),
+		peerTDs:     peerTDs,
+		forkFilter:  forkid.NewFilter(blockchain),
+		txPolicy:    DefaultTxPropagation,
+		txRequests:  newTxRequestSet(),
 		newPeerCh:   make(chan *peer),
 		noMorePeers: make(chan struct{}),
 		txsyncCh:    make(chan *txsync),
@@ -170,6 +188,7 @@ func NewProtocolManager(config *core.ChainConfig, mode downloader.SyncMode, netw
 				}
 				return nil
 			},
+			Attributes: manager.nodeRecordEntries,
 		})
 	}
 	if len(manager.SubProtocols) == 0 {
@@ -205,14 +224,16 @@ func (pm *ProtocolManager) removePeer(id string) {
 		return
 	}
 	glog.V(logger.Debug).Infoln("Removing peer", id)
+	pm.rememberTD(peer)
 	pm.eventMux.Post(PMHandlerRemoveEvent{
 		PMPeersLen: pm.peers.Len(),
 		PMBestPeer: pm.peers.BestPeer(),
 		Peer:       peer,
 	})
 
-	// Unregister the peer from the downloader and Ethereum peer set
+	// Unregister the peer from the downloader, fetcher and Ethereum peer set
 	pm.downloader.UnregisterPeer(id)
+	pm.fetcher.ForgetPeer(id)
 	if err := pm.peers.Unregister(id); err != nil {
 		glog.V(logger.Error).Infoln("Removal failed:", err)
 	}
@@ -222,6 +243,22 @@ func (pm *ProtocolManager) removePeer(id string) {
 	}
 }
 
+// rememberTD records the total difficulty last advertised by p.
+func (pm *ProtocolManager) rememberTD(p *peer) {
+	_, td := p.Head()
+	pm.peerTDs.Add(p.Peer.ID(), td)
+}
+
+// knownTD returns the total difficulty last advertised by the node with the
+// given ID, or nil if it hasn't been seen recently. It ranks nodes for
+// dialing, so that peers ahead of us are connected first.
+func (pm *ProtocolManager) knownTD(id discover.NodeID) *big.Int {
+	if td, ok := pm.peerTDs.Get(id); ok {
+		return td.(*big.Int)
+	}
+	return nil
+}
+
 func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.maxPeers = maxPeers
 
@@ -276,15 +313,21 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	}
 	glog.V(logger.Debug).Infof("handler: %s ->connected", p)
 
+	if p.version < pm.minVersion {
+		metrics.HandshakeVersionRejects.Mark(1)
+		glog.V(logger.Debug).Infof("handler: %s ->obsolete version, minimum=%d", p, pm.minVersion)
+		return errResp(ErrProtocolVersionMismatch, "%d (< minimum %d)", p.version, pm.minVersion)
+	}
 	// Execute the Ethereum handshake
 	td, head, genesis := pm.blockchain.Status()
-	if err := p.Handshake(pm.networkId, td, head, genesis); err != nil {
+	if err := p.Handshake(pm.networkId, td, head, genesis, pm.currentForkID(), pm.forkFilter); err != nil {
 		glog.V(logger.Debug).Infof("handler: %s ->handshakefailed err=%v", p, err)
 		return err
 	}
 	if rw, ok := p.rw.(*meteredMsgReadWriter); ok {
 		rw.Init(p.version)
 	}
+	pm.rememberTD(p)
 	// Register the peer locally
 	glog.V(logger.Debug).Infof("handler: %s ->addpeer", p)
 	if err := pm.peers.Register(p); err != nil {
@@ -787,6 +830,80 @@ func (pm *ProtocolManager) handleMsg(p *peer) (err error) {
 		}
 		pm.txpool.AddTransactions(txs)
 
+	case p.version >= eth65 && msg.Code == NewPooledTransactionHashesMsg:
+		// Transactions were announced, fetch the ones we don't know yet
+		if atomic.LoadUint32(&pm.acceptsTxs) == 0 {
+			break
+		}
+		var hashes []common.Hash
+		if e := msg.Decode(&hashes); e != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, e)
+		}
+		var unknown []common.Hash
+		for _, hash := range hashes {
+			p.MarkTransaction(hash)
+			if pm.txpool.Get(hash) == nil {
+				unknown = append(unknown, hash)
+			}
+		}
+		unknown = pm.txRequests.claim(unknown)
+		for len(unknown) > 0 {
+			n := len(unknown)
+			if n > maxTxAnnounceFetch {
+				n = maxTxAnnounceFetch
+			}
+			if err := p.RequestTxs(unknown[:n]); err != nil {
+				return err
+			}
+			unknown = unknown[n:]
+		}
+
+	case p.version >= eth65 && msg.Code == GetPooledTransactionsMsg:
+		// Decode the retrieval message
+		msgStream := rlp.NewStream(msg.Payload, uint64(msg.Size))
+		if _, err = msgStream.List(); err != nil {
+			return err
+		}
+		// Gather transactions until the fetch or network limits is reached
+		var (
+			hash  common.Hash
+			bytes common.StorageSize
+			txs   []*types.Transaction
+		)
+		for bytes < softResponseLimit && len(txs) < maxTxAnnounceFetch {
+			if e := msgStream.Decode(&hash); e == rlp.EOL {
+				break
+			} else if e != nil {
+				return errResp(ErrDecode, "msg %v: %v", msg, e)
+			}
+			tx := pm.txpool.Get(hash)
+			if tx == nil || !pm.relayable(p, hash) {
+				continue
+			}
+			txs = append(txs, tx)
+			bytes += tx.Size()
+		}
+		return p.SendPooledTransactions(txs)
+
+	case p.version >= eth65 && msg.Code == PooledTransactionsMsg:
+		// Requested transactions arrived, deliver them to the pool
+		var txs []*types.Transaction
+		if e := msg.Decode(&txs); e != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, e)
+		}
+		hashes := make([]common.Hash, len(txs))
+		for i, tx := range txs {
+			if tx == nil {
+				return errResp(ErrDecode, "transaction %d is nil", i)
+			}
+			hashes[i] = tx.Hash()
+			p.MarkTransaction(hashes[i])
+		}
+		pm.txRequests.delivered(hashes)
+		if atomic.LoadUint32(&pm.acceptsTxs) == 1 {
+			pm.txpool.AddTransactions(txs)
+		}
+
 	default:
 		err = errResp(ErrInvalidMsgCode, "%v", msg.Code)
 		mlogWireDelegate(p, "receive", unknownMessageCode, intSize, nil, err)
@@ -827,16 +944,56 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 	}
 }
 
-// BroadcastTx will propagate a transaction to all peers which are not known to
+// BroadcastTx will propagate a transaction to the peers which are not known to
 // already have the given transaction.
 func (pm *ProtocolManager) BroadcastTx(hash common.Hash, tx *types.Transaction) {
-	// Broadcast transaction to a batch of peers not knowing about it
-	peers := pm.peers.PeersWithoutTx(hash)
-	//FIXME include this again: peers = peers[:int(math.Sqrt(float64(len(peers))))]
-	for _, peer := range peers {
-		peer.AsyncSendTransactions(types.Transactions{tx})
+	pm.BroadcastTxs(types.Transactions{tx})
+}
+
+// BroadcastTxs propagates a batch of transactions to the peers which are not
+// known to already have them, following the transaction propagation policy:
+// a fraction of the peers receives the full transactions, the remaining ones
+// supporting eth/65 only get their hashes announced.
+func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
+	var (
+		txset = make(map[*peer]types.Transactions)
+		annos = make(map[*peer][]common.Hash)
+	)
+	for _, tx := range txs {
+		hash := tx.Hash()
+
+		var announce []*peer
+		for _, peer := range pm.peers.PeersWithoutTx(hash) {
+			switch {
+			case !pm.relayable(peer, hash):
+				// Withheld local transaction in private mode
+			case peer.version < eth65:
+				txset[peer] = append(txset[peer], tx)
+			default:
+				announce = append(announce, peer)
+			}
+		}
+		direct := pm.txPolicy.direct(len(announce))
+		for _, peer := range announce[:direct] {
+			txset[peer] = append(txset[peer], tx)
+		}
+		for _, peer := range announce[direct:] {
+			annos[peer] = append(annos[peer], hash)
+		}
+	}
+	for peer, txs := range txset {
+		peer.AsyncSendTransactions(txs)
+	}
+	for peer, hashes := range annos {
+		peer.AsyncSendPooledTransactionHashes(hashes)
 	}
-	glog.V(logger.Detail).Infof("broadcast tx [%s] to %d peers", tx.Hash().Hex(), len(peers))
+	glog.V(logger.Detail).Infof("broadcast %d txs to %d peers, announced to %d peers", len(txs), len(txset), len(annos))
+}
+
+// relayable reports whether a transaction may be handed to the peer. In private
+// mode, local transactions are only relayed to trusted peers.
+func (pm *ProtocolManager) relayable(p *peer, hash common.Hash) bool {
+	return !pm.txPolicy.Private || p.Trusted() || !pm.txpool.IsLocal(hash)
 }
 
 // Mined broadcast loop
@@ -853,9 +1010,32 @@ func (self *ProtocolManager) minedBroadcastLoop() {
 
 func (self *ProtocolManager) txBroadcastLoop() {
 	// automatically stops if unsubscribe
-	for obj := range self.txSub.Chan() {
-		event := obj.Data.(core.TxPreEvent)
-		self.BroadcastTx(event.Tx.Hash(), event.Tx)
+	if self.txPolicy.Interval == 0 {
+		for obj := range self.txSub.Chan() {
+			event := obj.Data.(core.TxPreEvent)
+			self.BroadcastTx(event.Tx.Hash(), event.Tx)
+		}
+		return
+	}
+	// Batch transactions arriving within the broadcast interval
+	ticker := time.NewTicker(self.txPolicy.Interval)
+	defer ticker.Stop()
+
+	var batch types.Transactions
+	for {
+		select {
+		case obj, ok := <-self.txSub.Chan():
+			if !ok {
+				return
+			}
+			batch = append(batch, obj.Data.(core.TxPreEvent).Tx)
+
+		case <-ticker.C:
+			if len(batch) > 0 {
+				self.BroadcastTxs(batch)
+				batch = nil
+			}
+		}
 	}
 }
 
@@ -0,0 +1,134 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/event"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+)
+
+// headHookTimeout is how long a head hook command may run before it's killed.
+const headHookTimeout = time.Minute
+
+// headHook runs a shell command on every new canonical head, passing the block
+// in environment variables. Heads arriving while the command runs are
+// coalesced, the next run only gets the latest one. A nil hook does nothing.
+type headHook struct {
+	command string
+	sub     event.Subscription
+	pending chan *types.Block // latest head not yet passed to the command
+
+	ctx    context.Context // cancelled on close, killing a running command
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newHeadHook starts running command on the chain head events of mux.
+func newHeadHook(command string, mux *event.TypeMux) *headHook {
+	h := &headHook{
+		command: command,
+		sub:     mux.Subscribe(core.ChainHeadEvent{}),
+		pending: make(chan *types.Block, 1),
+	}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	h.wg.Add(2)
+	go h.loop()
+	go h.runner()
+	return h
+}
+
+// close stops the hook, killing the command if it's running.
+func (h *headHook) close() {
+	if h == nil {
+		return
+	}
+	h.sub.Unsubscribe()
+	h.cancel()
+	h.wg.Wait()
+}
+
+// loop queues new heads, replacing the one waiting if the command is busy.
+func (h *headHook) loop() {
+	defer h.wg.Done()
+
+	for {
+		select {
+		case ev, ok := <-h.sub.Chan():
+			if !ok {
+				return
+			}
+			head, ok := ev.Data.(core.ChainHeadEvent)
+			if !ok {
+				continue
+			}
+			select {
+			case <-h.pending:
+			default:
+			}
+			h.pending <- head.Block
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+func (h *headHook) runner() {
+	defer h.wg.Done()
+
+	for {
+		select {
+		case block := <-h.pending:
+			if out, err := h.run(block); err != nil {
+				glog.V(logger.Warn).Warnf("New block hook failed on block #%d: %v: %s", block.NumberU64(), err, out)
+			}
+		case <-h.ctx.Done():
+			return
+		}
+	}
+}
+
+// run executes the command for block, returning its output.
+func (h *headHook) run(block *types.Block) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(h.ctx, headHookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", h.command)
+	} else {
+		cmd = exec.CommandContext(ctx, "/bin/sh", "-c", h.command)
+	}
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("WEBCHAIN_BLOCK_NUMBER=%d", block.NumberU64()),
+		fmt.Sprintf("WEBCHAIN_BLOCK_HASH=%s", block.Hash().Hex()),
+		fmt.Sprintf("WEBCHAIN_BLOCK_PARENT_HASH=%s", block.ParentHash().Hex()),
+		fmt.Sprintf("WEBCHAIN_BLOCK_TIME=%d", block.Time().Uint64()),
+		fmt.Sprintf("WEBCHAIN_BLOCK_TXS=%d", len(block.Transactions())),
+	)
+	return cmd.CombinedOutput()
+}
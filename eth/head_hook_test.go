@@ -0,0 +1,110 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/event"
+)
+
+func TestHeadHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook command uses a POSIX shell")
+	}
+	dir, err := ioutil.TempDir("", "head-hook-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "heads")
+
+	mux := new(event.TypeMux)
+	hook := newHeadHook(fmt.Sprintf(`echo "$WEBCHAIN_BLOCK_NUMBER $WEBCHAIN_BLOCK_HASH" >> %s`, path), mux)
+	defer hook.close()
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(42), Time: big.NewInt(1)})
+	mux.Post(core.ChainHeadEvent{Block: block})
+
+	want := fmt.Sprintf("42 %s\n", block.Hash().Hex())
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		out, _ := ioutil.ReadFile(path)
+		if string(out) == want {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("hook output mismatch: have %q, want %q", out, want)
+		}
+	}
+}
+
+func TestHeadHookCoalesces(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook command uses a POSIX shell")
+	}
+	dir, err := ioutil.TempDir("", "head-hook-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "heads")
+
+	// The first run blocks until the release file exists, while further heads
+	// arrive.
+	release := filepath.Join(dir, "release")
+	mux := new(event.TypeMux)
+	hook := newHeadHook(fmt.Sprintf(`echo $WEBCHAIN_BLOCK_NUMBER >> %s; while [ ! -e %s ]; do sleep 0.01; done`, path, release), mux)
+	defer hook.close()
+
+	for i := int64(1); i <= 10; i++ {
+		mux.Post(core.ChainHeadEvent{Block: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(i), Time: big.NewInt(1)})})
+		if i == 1 {
+			for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+				if out, _ := ioutil.ReadFile(path); len(out) > 0 {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("hook not run")
+				}
+			}
+		}
+	}
+	ioutil.WriteFile(release, nil, 0600)
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		out, _ := ioutil.ReadFile(path)
+		if runs := strings.Fields(string(out)); len(runs) == 2 {
+			if runs[0] != "1" || runs[1] != "10" {
+				t.Fatalf("runs mismatch: have %v, want [1 10]", runs)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("runs mismatch: have %q", out)
+		}
+	}
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/forkid"
 	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/crypto"
 	"github.com/webchain-network/webchaind/eth/downloader"
@@ -95,6 +96,7 @@ type testTxPool struct {
 	txFeed event.Feed
 	pool   []*types.Transaction        // Collection of all transactions
 	added  chan<- []*types.Transaction // Notification channel for new transactions
+	locals map[common.Hash]bool        // Transactions submitted locally
 
 	lock sync.RWMutex // Protects the transaction pool
 }
@@ -122,6 +124,27 @@ func (p *testTxPool) GetTransactions() types.Transactions {
 	return txs
 }
 
+// Get returns a transaction of the pool by hash
+func (p *testTxPool) Get(hash common.Hash) *types.Transaction {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	for _, tx := range p.pool {
+		if tx.Hash() == hash {
+			return tx
+		}
+	}
+	return nil
+}
+
+// IsLocal reports whether the transaction was marked local
+func (p *testTxPool) IsLocal(hash common.Hash) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.locals[hash]
+}
+
 // newTestTransaction create a new dummy transaction.
 func newTestTransaction(from *ecdsa.PrivateKey, nonce uint64, datasize int) *types.Transaction {
 	tx := types.NewTransaction(nonce, common.Address{}, big.NewInt(0), big.NewInt(100000), big.NewInt(0), make([]byte, datasize))
@@ -161,21 +184,32 @@ func newTestPeer(name string, version int, pm *ProtocolManager, shake bool) (*te
 	// Execute any implicitly requested handshakes and return
 	if shake {
 		td, head, genesis := pm.blockchain.Status()
-		tp.handshake(nil, td, head, genesis)
+		forkID := forkid.NewID(pm.blockchain.Config(), genesis, pm.blockchain.CurrentHeader().Number.Uint64())
+		tp.handshake(nil, td, head, genesis, forkID)
 	}
 	return tp, errc
 }
 
 // handshake simulates a trivial handshake that expects the same state from the
 // remote side as we are simulating locally.
-func (p *testPeer) handshake(t *testing.T, td *big.Int, head common.Hash, genesis common.Hash) {
-	msg := &statusData{
+func (p *testPeer) handshake(t *testing.T, td *big.Int, head common.Hash, genesis common.Hash, forkID forkid.ID) {
+	var msg interface{} = &statusData{
 		ProtocolVersion: uint32(p.version),
 		NetworkId:       uint32(NetworkId),
 		TD:              td,
 		CurrentBlock:    head,
 		GenesisBlock:    genesis,
 	}
+	if p.version >= eth64 {
+		msg = &statusData64{
+			ProtocolVersion: uint32(p.version),
+			NetworkId:       uint32(NetworkId),
+			TD:              td,
+			CurrentBlock:    head,
+			GenesisBlock:    genesis,
+			ForkID:          forkID,
+		}
+	}
 	if err := p2p.ExpectMsg(p.app, StatusMsg, msg); err != nil {
 		t.Fatalf("status recv: %v", err)
 	}
@@ -24,9 +24,11 @@ import (
 	"time"
 
 	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/forkid"
 	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/metrics"
 	"github.com/webchain-network/webchaind/p2p"
 	"github.com/webchain-network/webchaind/rlp"
 	"gopkg.in/fatih/set.v0"
@@ -47,6 +49,10 @@ const (
 	// contain a single transaction, or thousands.
 	maxQueuedTxs = 128
 
+	// maxQueuedTxAnns is the maximum number of transaction hash announcement
+	// lists to queue up before dropping them, like maxQueuedTxs.
+	maxQueuedTxAnns = 128
+
 	// maxQueuedProps is the maximum number of block propagations to queue up before
 	// dropping broadcasts. There's not much point in queueing stale blocks, so a few
 	// that might cover uncles should be enough.
@@ -91,6 +97,7 @@ type peer struct {
 	knownBlocks *set.Set // Set of block hashes known to be known by this peer
 
 	queuedTxs   chan []*types.Transaction // Queue of transactions to broadcast to the peer
+	queuedTxAnn chan []common.Hash        // Queue of transaction hashes to announce to the peer
 	queuedProps chan *propEvent           // Queue of blocks to broadcast to the peer
 	queuedAnns  chan *types.Block         // Queue of blocks to announce to the peer
 	term        chan struct{}             // Termination channel to stop the broadcaster
@@ -107,6 +114,7 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 		knownTxs:    set.New(),
 		knownBlocks: set.New(),
 		queuedTxs:   make(chan []*types.Transaction, maxQueuedTxs),
+		queuedTxAnn: make(chan []common.Hash, maxQueuedTxAnns),
 		queuedProps: make(chan *propEvent, maxQueuedProps),
 		queuedAnns:  make(chan *types.Block, maxQueuedAnns),
 		term:        make(chan struct{}),
@@ -125,6 +133,12 @@ func (p *peer) broadcast() {
 			}
 			glog.V(logger.Detail).Infoln("Broadcast transactions", "count", len(txs))
 
+		case hashes := <-p.queuedTxAnn:
+			if err := p.SendPooledTransactionHashes(hashes); err != nil {
+				return
+			}
+			glog.V(logger.Detail).Infoln("Announced transactions", "count", len(hashes))
+
 		case prop := <-p.queuedProps:
 			if err := p.SendNewBlock(prop.block, prop.td); err != nil {
 				return
@@ -222,6 +236,48 @@ func (p *peer) AsyncSendTransactions(txs []*types.Transaction) {
 	}
 }
 
+// SendPooledTransactionHashes announces the availability of a number of
+// transactions through a hash notification, letting the peer fetch the ones it
+// doesn't know yet. Available since eth/65.
+func (p *peer) SendPooledTransactionHashes(hashes []common.Hash) error {
+	for _, hash := range hashes {
+		p.knownTxs.Add(hash)
+	}
+	_, err := p2p.Send(p.rw, NewPooledTransactionHashesMsg, hashes)
+	return err
+}
+
+// AsyncSendPooledTransactionHashes queues a list of transaction hashes to be
+// announced to a remote peer. If the peer's announcement queue is full, the
+// event is silently dropped.
+func (p *peer) AsyncSendPooledTransactionHashes(hashes []common.Hash) {
+	select {
+	case p.queuedTxAnn <- hashes:
+		for _, hash := range hashes {
+			p.knownTxs.Add(hash)
+		}
+	default:
+		glog.V(logger.Debug).Infoln("Dropping transaction announcement", "count", len(hashes))
+	}
+}
+
+// SendPooledTransactions sends the transactions requested by the peer with a
+// GetPooledTransactions message.
+func (p *peer) SendPooledTransactions(txs types.Transactions) error {
+	for _, tx := range txs {
+		p.knownTxs.Add(tx.Hash())
+	}
+	_, err := p2p.Send(p.rw, PooledTransactionsMsg, txs)
+	return err
+}
+
+// RequestTxs fetches a batch of announced transactions from the remote peer.
+func (p *peer) RequestTxs(hashes []common.Hash) error {
+	glog.V(logger.Debug).Infof("fetching from: %v req=pooledtxs n=%d", p, len(hashes))
+	_, err := p2p.Send(p.rw, GetPooledTransactionsMsg, hashes)
+	return err
+}
+
 // SendNewBlockHashes announces the availability of a number of blocks through
 // a hash notification.
 func (p *peer) SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error {
@@ -364,8 +420,9 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 }
 
 // Handshake executes the eth protocol handshake, negotiating version number,
-// network IDs, difficulties, head and genesis blocks.
-func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash) error {
+// network IDs, difficulties, head and genesis blocks. Since eth/64 the fork IDs
+// are exchanged too, rejecting peers whose fork ID doesn't pass forkFilter.
+func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash, forkID forkid.ID, forkFilter forkid.Filter) error {
 	// Send out own handshake in a new thread
 	sendErrc := make(chan error, 1)
 	recErrc := make(chan error, 1)
@@ -381,15 +438,26 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 		GenesisBlock:    genesis,
 	}
 
+	var send interface{} = d
+	if p.version >= eth64 {
+		send = &statusData64{
+			ProtocolVersion: d.ProtocolVersion,
+			NetworkId:       d.NetworkId,
+			TD:              td,
+			CurrentBlock:    head,
+			GenesisBlock:    genesis,
+			ForkID:          forkID,
+		}
+	}
 	go func() {
 		var e error
-		sendSize, e = p2p.Send(p.rw, StatusMsg, d)
+		sendSize, e = p2p.Send(p.rw, StatusMsg, send)
 		sendErrc <- e
 	}()
 	go func() {
 		var e error
 		var s uint32
-		s, e = p.readStatusReturnSize(network, &status, genesis)
+		s, e = p.readStatusReturnSize(network, &status, genesis, forkFilter)
 		recSize = int(s)
 		recErrc <- e
 	}()
@@ -420,7 +488,7 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 	return nil
 }
 
-func (p *peer) readStatusReturnSize(network uint64, status *statusData, genesis common.Hash) (size uint32, err error) {
+func (p *peer) readStatusReturnSize(network uint64, status *statusData, genesis common.Hash, forkFilter forkid.Filter) (size uint32, err error) {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
 		return msg.Size, err
@@ -432,26 +500,44 @@ func (p *peer) readStatusReturnSize(network uint64, status *statusData, genesis
 		return msg.Size, errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
 	}
 	// Decode the handshake and make sure everything matches
-	if err := msg.Decode(&status); err != nil {
+	var forkID forkid.ID
+	if p.version >= eth64 {
+		var status64 statusData64
+		if err := msg.Decode(&status64); err != nil {
+			return msg.Size, errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		*status = statusData{
+			ProtocolVersion: status64.ProtocolVersion,
+			NetworkId:       status64.NetworkId,
+			TD:              status64.TD,
+			CurrentBlock:    status64.CurrentBlock,
+			GenesisBlock:    status64.GenesisBlock,
+		}
+		forkID = status64.ForkID
+	} else if err := msg.Decode(&status); err != nil {
 		return msg.Size, errResp(ErrDecode, "msg %v: %v", msg, err)
 	}
 	if status.GenesisBlock != genesis {
+		metrics.HandshakeNetworkRejects.Mark(1)
 		return msg.Size, errResp(ErrGenesisBlockMismatch, "%x (!= %x…)", status.GenesisBlock, genesis.Bytes()[:8])
 	}
 	if status.NetworkId != uint32(network) {
+		metrics.HandshakeNetworkRejects.Mark(1)
 		return msg.Size, errResp(ErrNetworkIdMismatch, "%d (!= %d)", status.NetworkId, network)
 	}
 	if int(status.ProtocolVersion) != p.version {
+		metrics.HandshakeVersionRejects.Mark(1)
 		return msg.Size, errResp(ErrProtocolVersionMismatch, "%d (!= %d)", status.ProtocolVersion, p.version)
 	}
+	if p.version >= eth64 {
+		if err := forkFilter(forkID); err != nil {
+			metrics.HandshakeForkRejects.Mark(1)
+			return msg.Size, errResp(ErrForkIDRejected, "%v", err)
+		}
+	}
 	return msg.Size, nil
 }
 
-func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash) (err error) {
-	_, err = p.readStatusReturnSize(network, status, genesis)
-	return
-}
-
 // String implements fmt.Stringer.
 func (p *peer) String() string {
 	// id is %x[:8]
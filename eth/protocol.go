@@ -23,6 +23,7 @@ import (
 	"math/big"
 
 	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/forkid"
 	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/rlp"
 )
@@ -31,16 +32,18 @@ import (
 const (
 	eth62 = 62
 	eth63 = 63
+	eth64 = 64
+	eth65 = 65
 )
 
 // Official short name of the protocol used during capability negotiation.
 var ProtocolName = "web"
 
 // Supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth63, eth62}
+var ProtocolVersions = []uint{eth65, eth64, eth63, eth62}
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{17, 8}
+var ProtocolLengths = []uint64{17, 17, 17, 8}
 
 const (
 	NetworkId          = 37129
@@ -59,6 +62,11 @@ const (
 	BlockBodiesMsg     = 0x06
 	NewBlockMsg        = 0x07
 
+	// Protocol messages belonging to eth/65
+	NewPooledTransactionHashesMsg = 0x08
+	GetPooledTransactionsMsg      = 0x09
+	PooledTransactionsMsg         = 0x0a
+
 	// Protocol messages belonging to eth/63
 	GetNodeDataMsg = 0x0d
 	NodeDataMsg    = 0x0e
@@ -84,6 +92,12 @@ func ProtocolMessageStringer(m uint) string {
 		return "BlockBodies"
 	case NewBlockMsg:
 		return "NewBlock"
+	case NewPooledTransactionHashesMsg:
+		return "NewPooledTransactionHashes"
+	case GetPooledTransactionsMsg:
+		return "GetPooledTransactions"
+	case PooledTransactionsMsg:
+		return "PooledTransactions"
 	case GetNodeDataMsg:
 		return "GetNodeData"
 	case NodeDataMsg:
@@ -109,6 +123,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrForkIDRejected
 )
 
 func (e errCode) String() string {
@@ -126,6 +141,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrForkIDRejected:          "Fork ID rejected",
 }
 
 type txPool interface {
@@ -135,6 +151,12 @@ type txPool interface {
 	// GetTransactions should return pending transactions.
 	// The slice should be modifiable by the caller.
 	GetTransactions() types.Transactions
+
+	// Get should return a pending or queued transaction, or nil if not found.
+	Get(hash common.Hash) *types.Transaction
+
+	// IsLocal should report whether a transaction was submitted locally.
+	IsLocal(hash common.Hash) bool
 }
 
 // statusData is the network packet for the status message.
@@ -146,6 +168,17 @@ type statusData struct {
 	GenesisBlock    common.Hash
 }
 
+// statusData64 is the network packet for the status message since eth/64,
+// adding the EIP-2124 fork ID.
+type statusData64 struct {
+	ProtocolVersion uint32
+	NetworkId       uint32
+	TD              *big.Int
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+	ForkID          forkid.ID
+}
+
 // newBlockData is the network packet for the block propagation message.
 type newBlockData struct {
 	Block *types.Block
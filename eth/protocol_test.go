@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/forkid"
 	"github.com/webchain-network/webchaind/core/types"
 	"github.com/webchain-network/webchaind/crypto"
 	"github.com/webchain-network/webchaind/eth/downloader"
@@ -90,6 +91,57 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that eth/64 peers exchange fork IDs in the handshake and peers on an
+// incompatible fork are disconnected.
+func TestForkIDHandshake(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	td, currentBlock, genesis := pm.blockchain.Status()
+	defer pm.Stop()
+
+	// A compatible peer completes the handshake.
+	p, _ := newTestPeer("compatible", eth64, pm, true)
+	p.close()
+
+	// A peer on another fork is rejected.
+	p, errc := newTestPeer("incompatible", eth64, pm, false)
+	defer p.close()
+	bad := forkid.ID{Hash: [4]byte{0xde, 0xad, 0xbe, 0xef}}
+	go p2p.Send(p.app, StatusMsg, &statusData64{eth64, NetworkId, td, currentBlock, genesis, bad})
+
+	select {
+	case err := <-errc:
+		want := errResp(ErrForkIDRejected, "%v", forkid.ErrLocalIncompatibleOrStale)
+		if err == nil || err.Error() != want.Error() {
+			t.Errorf("wrong error: got %v, want %v", err, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("protocol did not shut down withing 2 seconds")
+	}
+}
+
+// Tests that peers below the minimum protocol version are disconnected before
+// the handshake.
+func TestMinProtocolVersion(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	pm.minVersion = eth64
+	defer pm.Stop()
+
+	p, _ := newTestPeer("current", eth64, pm, true)
+	p.close()
+
+	p, errc := newTestPeer("obsolete", eth63, pm, false)
+	defer p.close()
+	select {
+	case err := <-errc:
+		want := errResp(ErrProtocolVersionMismatch, "%d (< minimum %d)", eth63, eth64)
+		if err == nil || err.Error() != want.Error() {
+			t.Errorf("wrong error: got %v, want %v", err, want)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("protocol did not shut down withing 2 seconds")
+	}
+}
+
 // This test checks that received transactions are added to the local pool.
 func TestRecvTransactions61(t *testing.T) { testRecvTransactions(t, 61) }
 func TestRecvTransactions62(t *testing.T) { testRecvTransactions(t, 62) }
@@ -223,3 +275,82 @@ func TestGetBlockHeadersDataEncodeDecode(t *testing.T) {
 		}
 	}
 }
+
+// Tests that only a fraction of eth/65 peers receives full transactions, the
+// others get them announced and can fetch them on request.
+func TestTransactionAnnouncement(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	pm.txPolicy = TxPropagationPolicy{Fraction: 0.1}
+	defer pm.Stop()
+
+	peers := make([]*testPeer, 2)
+	for i := range peers {
+		peers[i], _ = newTestPeer(fmt.Sprintf("peer #%d", i), eth65, pm, true)
+		defer peers[i].close()
+	}
+	for deadline := time.Now().Add(time.Second); pm.peers.Len() < len(peers); {
+		if time.Now().After(deadline) {
+			t.Fatal("peers not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	tx := newTestTransaction(testAccount, 0, 0)
+	pm.txpool.AddTransactions([]*types.Transaction{tx})
+	pm.BroadcastTxs(types.Transactions{tx})
+
+	codes := make(chan uint64, len(peers))
+	var announced *testPeer
+	for _, p := range peers {
+		msg, err := p.app.ReadMsg()
+		if err != nil {
+			t.Fatalf("%v: read error: %v", p.Peer, err)
+		}
+		codes <- msg.Code
+		if msg.Code == NewPooledTransactionHashesMsg {
+			var hashes []common.Hash
+			if err := msg.Decode(&hashes); err != nil || len(hashes) != 1 || hashes[0] != tx.Hash() {
+				t.Errorf("%v: announcement mismatch: %x (%v)", p.Peer, hashes, err)
+			}
+			announced = p
+		} else {
+			msg.Discard()
+		}
+	}
+	close(codes)
+	have := make(map[uint64]int)
+	for code := range codes {
+		have[code]++
+	}
+	if have[TxMsg] != 1 || have[NewPooledTransactionHashesMsg] != 1 {
+		t.Fatalf("message mismatch: have %v, want one TxMsg and one announcement", have)
+	}
+	// The peer the transaction was announced to fetches it.
+	p2p.Send(announced.app, GetPooledTransactionsMsg, []common.Hash{tx.Hash()})
+	if err := p2p.ExpectMsg(announced.app, PooledTransactionsMsg, []*types.Transaction{tx}); err != nil {
+		t.Errorf("pooled transactions mismatch: %v", err)
+	}
+}
+
+// Tests that local transactions aren't handed to untrusted peers in private mode.
+func TestPrivateTransactions(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	pm.txPolicy = TxPropagationPolicy{Fraction: 1, Private: true}
+	defer pm.Stop()
+
+	local, remote := newTestTransaction(testAccount, 0, 0), newTestTransaction(testAccount, 1, 0)
+	pool := pm.txpool.(*testTxPool)
+	pool.locals = map[common.Hash]bool{local.Hash(): true}
+	pool.AddTransactions([]*types.Transaction{local, remote})
+
+	// The initial transaction sync only contains the remote transaction.
+	p, _ := newTestPeer("peer", eth65, pm, true)
+	defer p.close()
+	if err := p2p.ExpectMsg(p.app, TxMsg, []*types.Transaction{remote}); err != nil {
+		t.Fatalf("initial sync mismatch: %v", err)
+	}
+	// Requesting the local transaction explicitly doesn't reveal it either.
+	p2p.Send(p.app, GetPooledTransactionsMsg, []common.Hash{local.Hash(), remote.Hash()})
+	if err := p2p.ExpectMsg(p.app, PooledTransactionsMsg, []*types.Transaction{remote}); err != nil {
+		t.Errorf("pooled transactions mismatch: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/rpc"
+)
+
+// Approval paths of a signing operation.
+const (
+	signApprovalPassphrase = "passphrase" // Key decrypted with a passphrase given in the request
+	signApprovalUnlocked   = "unlocked"   // Key previously unlocked on the node
+)
+
+// SignAuditEntry is a single signing operation in the audit log.
+type SignAuditEntry struct {
+	Time     time.Time      `json:"time"`
+	Account  common.Address `json:"account"`
+	Origin   string         `json:"origin"`   // Transport and remote address of the request
+	Method   string         `json:"method"`   // RPC method asking for the signature
+	Approval string         `json:"approval"` // How the key was made available
+	Digest   common.Hash    `json:"digest"`   // Hash that was signed
+	TxHash   *common.Hash   `json:"txHash,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// signAudit appends signing operations as JSON lines to a file. A nil audit
+// records nothing.
+type signAudit struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// newSignAudit opens the audit log at path, creating it if necessary.
+func newSignAudit(path string) (*signAudit, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &signAudit{path: path, file: file}, nil
+}
+
+// recordMessage records the signing of a message digest.
+func (a *signAudit) recordMessage(ctx context.Context, method, approval string, account common.Address, digest []byte, err error) {
+	a.record(ctx, method, approval, account, common.BytesToHash(digest), nil, err)
+}
+
+// recordTx records the signing of tx, including the hash of the signed
+// transaction if signing succeeded.
+func (a *signAudit) recordTx(ctx context.Context, method, approval string, account common.Address, signer types.Signer, tx *types.Transaction, signature []byte, err error) {
+	if a == nil {
+		return
+	}
+	var txHash *common.Hash
+	if err == nil {
+		if signed, err := tx.WithSigner(signer).WithSignature(signature); err == nil {
+			hash := signed.Hash()
+			txHash = &hash
+		}
+	}
+	a.record(ctx, method, approval, account, signer.Hash(tx), txHash, err)
+}
+
+func (a *signAudit) record(ctx context.Context, method, approval string, account common.Address, digest common.Hash, txHash *common.Hash, err error) {
+	if a == nil {
+		return
+	}
+	entry := &SignAuditEntry{
+		Time:     time.Now().UTC(),
+		Account:  account,
+		Origin:   rpc.OriginFromContext(ctx),
+		Method:   method,
+		Approval: approval,
+		Digest:   digest,
+		TxHash:   txHash,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	line, jerr := json.Marshal(entry)
+	if jerr != nil {
+		glog.V(logger.Error).Errorf("Failed to encode signing audit entry: %v", jerr)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, werr := a.file.Write(append(line, '\n')); werr != nil {
+		glog.V(logger.Error).Errorf("Failed to write signing audit entry: %v", werr)
+	}
+}
+
+// entries reads back the most recent limit entries, oldest first, optionally
+// only those of account. A limit of zero returns all matching entries.
+func (a *signAudit) entries(account *common.Address, limit int) ([]*SignAuditEntry, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	file, err := os.Open(a.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []*SignAuditEntry{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := new(SignAuditEntry)
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			return nil, err
+		}
+		if account != nil && entry.Account != *account {
+			continue
+		}
+		entries = append(entries, entry)
+		if limit > 0 && len(entries) > limit {
+			entries = entries[1:]
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// close closes the audit log file.
+func (a *signAudit) close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.file.Close()
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+func TestSignAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sign-audit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	acc1, acc2 := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	ctx := context.Background()
+
+	audit, err := newSignAudit(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.recordMessage(ctx, "eth_sign", signApprovalUnlocked, acc1, common.HexToHash("0xa1").Bytes(), nil)
+	audit.recordMessage(ctx, "personal_sign", signApprovalPassphrase, acc2, common.HexToHash("0xa2").Bytes(), errors.New("could not decrypt key"))
+	audit.close()
+
+	// Entries must survive reopening the log.
+	if audit, err = newSignAudit(path); err != nil {
+		t.Fatal(err)
+	}
+	defer audit.close()
+	audit.recordMessage(ctx, "eth_sign", signApprovalUnlocked, acc1, common.HexToHash("0xa3").Bytes(), nil)
+
+	all, err := audit.entries(nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("entries: got %d, want 3", len(all))
+	}
+	if e := all[1]; e.Account != acc2 || e.Method != "personal_sign" || e.Approval != signApprovalPassphrase || e.Digest != common.HexToHash("0xa2") || e.Error != "could not decrypt key" {
+		t.Errorf("entry mismatch: %+v", e)
+	}
+
+	own, err := audit.entries(&acc1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(own) != 1 || own[0].Digest != common.HexToHash("0xa3") {
+		t.Errorf("limited entries of account: got %+v", own)
+	}
+
+	// A disabled audit records nothing.
+	var disabled *signAudit
+	disabled.recordMessage(ctx, "eth_sign", signApprovalUnlocked, acc1, nil, nil)
+}
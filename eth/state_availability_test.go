@@ -0,0 +1,80 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/eth/downloader"
+	"github.com/webchain-network/webchaind/rpc"
+)
+
+func TestStateAvailability(t *testing.T) {
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 8, nil, nil)
+	defer pm.Stop()
+
+	// Drop the states below block 5 as if the chain was fast synced with
+	// pivot 2 and the states of blocks 2 to 4 were lost.
+	bc := pm.blockchain
+	for n := uint64(1); n < 5; n++ {
+		db.Delete(bc.GetBlockByNumber(n).Root().Bytes())
+	}
+	core.WriteFastSyncPivot(db, 2)
+	bc.SetHead(8)
+
+	want := []core.AvailabilityRange{
+		{From: 0, To: 0, Availability: core.AvailableState},
+		{From: 1, To: 1, Availability: core.AvailableBlocks},
+		{From: 2, To: 4, Availability: core.AvailablePruned},
+		{From: 5, To: 8, Availability: core.AvailableState},
+	}
+	if have := bc.StateAvailability(); !reflect.DeepEqual(have, want) {
+		t.Errorf("availability mismatch:\nhave %+v\nwant %+v", have, want)
+	}
+	if oldest := bc.OldestState(); oldest != 5 {
+		t.Errorf("oldest state mismatch: have %d, want 5", oldest)
+	}
+
+	tests := []struct {
+		number       rpc.BlockNumber
+		availability core.Availability
+	}{
+		{0, ""},
+		{1, core.AvailableBlocks},
+		{3, core.AvailablePruned},
+		{5, ""},
+	}
+	for _, tt := range tests {
+		_, _, err := stateAndBlockByNumber(nil, bc, tt.number, db)
+		if tt.availability == "" {
+			if err != nil {
+				t.Errorf("block %d: unexpected error %v", tt.number, err)
+			}
+			continue
+		}
+		serr, ok := err.(*core.StateUnavailableError)
+		if !ok {
+			t.Errorf("block %d: got error %v, want state unavailable", tt.number, err)
+			continue
+		}
+		if serr.Availability != tt.availability || serr.OldestState != 5 {
+			t.Errorf("block %d: error mismatch: %+v", tt.number, serr)
+		}
+	}
+}
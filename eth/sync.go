@@ -45,7 +45,12 @@ type txsync struct {
 
 // syncTransactions starts sending all currently pending transactions to the given peer.
 func (pm *ProtocolManager) syncTransactions(p *peer) {
-	txs := pm.txpool.GetTransactions()
+	var txs types.Transactions
+	for _, tx := range pm.txpool.GetTransactions() {
+		if pm.relayable(p, tx.Hash()) {
+			txs = append(txs, tx)
+		}
+	}
 	if len(txs) == 0 {
 		return
 	}
@@ -0,0 +1,419 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+// Package tracers runs tracers written in JavaScript over the execution of a
+// transaction, letting users analyse it without changing the node.
+//
+// A tracer is an object literal with the functions
+//
+//	step(log, db)     called before every step of the EVM
+//	result(ctx, db)   returning the result of the trace, encoded as JSON
+//
+// and optionally
+//
+//	fault(log, db)    called when a step fails instead of step
+//	enter(frame)      called when a call or creation starts running code
+//	exit(frame)       called when it's over
+//
+// log has the methods getPC(), getGas(), getCost(), getDepth(), getError()
+// and the objects op (toNumber(), toString(), isPush()), stack (peek(i),
+// length()), memory (slice(start, end), getUint(offset), length()) and
+// contract (getCaller(), getAddress(), getValue(), getInput()). db has the
+// methods getBalance(addr), getNonce(addr), getCode(addr), getState(addr,
+// hash) and exists(addr). An enter frame has getType(), getFrom(), getTo(),
+// getInput(), getGas() and getValue(), an exit frame getOutput(), getGasUsed()
+// and getError(). ctx holds type, from, to, input, gas, gasUsed, value,
+// output, block and, if the transaction failed, error.
+//
+// Addresses, hashes and byte arrays are hex encoded strings, 256 bit numbers
+// BigNumber objects.
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/robertkrimen/otto"
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/internal/jsre"
+)
+
+// ErrTimeout is the reason a tracer stopped for taking too long.
+var ErrTimeout = errors.New("trace timed out")
+
+// errInterrupt unwinds the JavaScript runtime of a stopped tracer.
+var errInterrupt = errors.New("tracer interrupted")
+
+// Context describes the traced transaction to the result function.
+type Context struct {
+	Type    string // CALL or CREATE
+	From    common.Address
+	To      common.Address
+	Input   []byte
+	Gas     uint64
+	GasUsed uint64
+	Value   *big.Int
+	Output  []byte
+	Block   uint64
+	Err     error
+}
+
+// Tracer is a vm.FrameTracer running a JavaScript tracer. It isn't safe for
+// concurrent use, except for Stop.
+type Tracer struct {
+	vm     *otto.Otto
+	tracer *otto.Object
+
+	log, db  otto.Value
+	hasFault bool
+	hasEnter bool
+	hasExit  bool
+
+	// The step reported to the tracer. The memory and stack are only read
+	// while the step is.
+	state    vm.Database
+	pc       uint64
+	op       vm.OpCode
+	gas      uint64
+	cost     uint64
+	depth    int
+	memory   *vm.Memory
+	stack    []*big.Int
+	contract *vm.Contract
+	stepErr  error
+	faulted  bool // whether the failure of the frame was reported
+
+	err       error  // first error running the tracer
+	interrupt uint32 // set by Stop
+	reason    error  // why the tracer was stopped
+}
+
+// New compiles a tracer.
+func New(code string) (*Tracer, error) {
+	t := &Tracer{vm: otto.New()}
+	t.vm.Interrupt = make(chan func(), 1)
+
+	if _, err := t.vm.Run(jsre.BigNumber_JS); err != nil {
+		return nil, err
+	}
+	v, err := t.vm.Run("(" + code + ")")
+	if err != nil {
+		return nil, fmt.Errorf("invalid tracer: %v", err)
+	}
+	if !v.IsObject() {
+		return nil, errors.New("invalid tracer: not an object")
+	}
+	t.tracer = v.Object()
+	for _, fn := range []string{"step", "result"} {
+		if !t.isFunction(fn) {
+			return nil, fmt.Errorf("invalid tracer: no %s function", fn)
+		}
+	}
+	t.hasFault, t.hasEnter, t.hasExit = t.isFunction("fault"), t.isFunction("enter"), t.isFunction("exit")
+
+	if t.log, err = t.newLog(); err != nil {
+		return nil, err
+	}
+	if t.db, err = t.newDB(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Tracer) isFunction(name string) bool {
+	v, err := t.tracer.Get(name)
+	return err == nil && v.IsFunction()
+}
+
+// Stop makes the tracer fail with the given reason, interrupting the
+// JavaScript it's running. The execution traced isn't interrupted, the steps
+// following are ignored.
+func (t *Tracer) Stop(reason error) {
+	t.reason = reason
+	atomic.StoreUint32(&t.interrupt, 1)
+	select {
+	case t.vm.Interrupt <- func() { panic(errInterrupt) }:
+	default:
+	}
+}
+
+// call runs a function of the tracer object.
+func (t *Tracer) call(name string, args ...interface{}) (v otto.Value, err error) {
+	defer func() {
+		if caught := recover(); caught != nil {
+			if caught != errInterrupt {
+				panic(caught)
+			}
+			err = t.reason
+		}
+	}()
+	if v, err = t.tracer.Call(name, args...); err != nil {
+		err = fmt.Errorf("tracer %s: %v", name, err)
+	}
+	return v, err
+}
+
+// active reports whether the tracer is still to be run.
+func (t *Tracer) active() bool {
+	return t.err == nil && atomic.LoadUint32(&t.interrupt) == 0
+}
+
+func (t *Tracer) CaptureState(env vm.Environment, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack []*big.Int, contract *vm.Contract, err error) {
+	if !t.active() {
+		return
+	}
+	t.state, t.pc, t.op, t.gas, t.cost, t.depth = env.Db(), pc, op, gas, cost, env.Depth()
+	t.memory, t.stack, t.contract, t.stepErr = memory, stack, contract, err
+
+	if err == nil {
+		_, t.err = t.call("step", t.log, t.db)
+		return
+	}
+	t.fault()
+}
+
+// fault reports the failure of the step, to step if the tracer has no fault
+// function.
+func (t *Tracer) fault() {
+	t.faulted = true
+	if t.hasFault {
+		_, t.err = t.call("fault", t.log, t.db)
+	} else {
+		_, t.err = t.call("step", t.log, t.db)
+	}
+}
+
+func (t *Tracer) CaptureEnter(env vm.Environment, contract *vm.Contract, input []byte) {
+	t.state = env.Db()
+	if !t.active() || !t.hasEnter || env.Depth() == 1 {
+		return
+	}
+	// The last step is the call or creation of the parent frame.
+	typ := t.op.String()
+	to := contract.Address()
+	if contract.CodeAddr != nil {
+		to = *contract.CodeAddr
+	}
+	frame, err := t.object(map[string]interface{}{
+		"getType":  typ,
+		"getFrom":  contract.Caller().Hex(),
+		"getTo":    to.Hex(),
+		"getInput": common.ToHex(input),
+		"getGas":   contract.Gas,
+		"getValue": t.bigNumber(contract.Value()),
+	})
+	if err == nil {
+		_, err = t.call("enter", frame)
+	}
+	t.err = err
+}
+
+func (t *Tracer) CaptureExit(env vm.Environment, output []byte, gasUsed uint64, err error) {
+	if !t.active() {
+		return
+	}
+	// Failures past the step reported, running out of gas or an invalid
+	// opcode, are reported with the frame's last step.
+	if err != nil && err != vm.ErrRevert && !t.faulted && t.depth == env.Depth() {
+		t.stepErr = err
+		t.fault()
+		if !t.active() {
+			return
+		}
+	}
+	t.faulted = false
+	if !t.hasExit || env.Depth() == 1 {
+		return
+	}
+	fields := map[string]interface{}{
+		"getOutput":  common.ToHex(output),
+		"getGasUsed": gasUsed,
+		"getError":   otto.UndefinedValue(),
+	}
+	if err != nil {
+		fields["getError"] = err.Error()
+	}
+	frame, err := t.object(fields)
+	if err == nil {
+		_, err = t.call("exit", frame)
+	}
+	t.err = err
+}
+
+// Result runs the result function of the tracer, returning what it returns
+// encoded as JSON.
+func (t *Tracer) Result(ctx *Context, db vm.Database) (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if atomic.LoadUint32(&t.interrupt) != 0 {
+		return nil, t.reason
+	}
+	t.state = db
+
+	obj, err := t.vm.Object("({})")
+	if err != nil {
+		return nil, err
+	}
+	obj.Set("type", ctx.Type)
+	obj.Set("from", ctx.From.Hex())
+	obj.Set("to", ctx.To.Hex())
+	obj.Set("input", common.ToHex(ctx.Input))
+	obj.Set("gas", ctx.Gas)
+	obj.Set("gasUsed", ctx.GasUsed)
+	obj.Set("value", t.bigNumber(ctx.Value))
+	obj.Set("output", common.ToHex(ctx.Output))
+	obj.Set("block", ctx.Block)
+	if ctx.Err != nil {
+		obj.Set("error", ctx.Err.Error())
+	}
+
+	res, err := t.call("result", obj, t.db)
+	if err != nil {
+		return nil, err
+	}
+	if res.IsUndefined() {
+		return json.RawMessage("null"), nil
+	}
+	enc, err := t.vm.Call("JSON.stringify", nil, res)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(enc.String()), nil
+}
+
+// object creates a JavaScript object with methods returning the given values.
+func (t *Tracer) object(methods map[string]interface{}) (otto.Value, error) {
+	obj, err := t.vm.Object("({})")
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	for name, v := range methods {
+		value, err := t.vm.ToValue(v)
+		if err != nil {
+			return otto.UndefinedValue(), err
+		}
+		obj.Set(name, func(otto.FunctionCall) otto.Value { return value })
+	}
+	return obj.Value(), nil
+}
+
+// newLog creates the log object, its methods reading the step reported.
+func (t *Tracer) newLog() (otto.Value, error) {
+	log, err := t.vm.Object(`({op: {}, stack: {}, memory: {}, contract: {}})`)
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	log.Set("getPC", func(otto.FunctionCall) otto.Value { return t.value(t.pc) })
+	log.Set("getGas", func(otto.FunctionCall) otto.Value { return t.value(t.gas) })
+	log.Set("getCost", func(otto.FunctionCall) otto.Value { return t.value(t.cost) })
+	log.Set("getDepth", func(otto.FunctionCall) otto.Value { return t.value(t.depth) })
+	log.Set("getError", func(otto.FunctionCall) otto.Value {
+		if t.stepErr == nil {
+			return otto.UndefinedValue()
+		}
+		return t.value(t.stepErr.Error())
+	})
+
+	op := t.member(log, "op")
+	op.Set("toNumber", func(otto.FunctionCall) otto.Value { return t.value(int(t.op)) })
+	op.Set("toString", func(otto.FunctionCall) otto.Value { return t.value(t.op.String()) })
+	op.Set("isPush", func(otto.FunctionCall) otto.Value { return t.value(t.op >= vm.PUSH1 && t.op <= vm.PUSH32) })
+
+	stack := t.member(log, "stack")
+	stack.Set("length", func(otto.FunctionCall) otto.Value { return t.value(len(t.stack)) })
+	stack.Set("peek", func(call otto.FunctionCall) otto.Value {
+		i, _ := call.Argument(0).ToInteger()
+		if i < 0 || int(i) >= len(t.stack) {
+			panic(t.vm.MakeRangeError(fmt.Sprintf("stack item %d out of %d", i, len(t.stack))))
+		}
+		return t.bigNumber(t.stack[len(t.stack)-1-int(i)])
+	})
+
+	memory := t.member(log, "memory")
+	memory.Set("length", func(otto.FunctionCall) otto.Value { return t.value(t.memory.Len()) })
+	memory.Set("slice", func(call otto.FunctionCall) otto.Value {
+		start, _ := call.Argument(0).ToInteger()
+		end, _ := call.Argument(1).ToInteger()
+		return t.value(common.ToHex(t.memorySlice(start, end)))
+	})
+	memory.Set("getUint", func(call otto.FunctionCall) otto.Value {
+		offset, _ := call.Argument(0).ToInteger()
+		return t.bigNumber(new(big.Int).SetBytes(t.memorySlice(offset, offset+32)))
+	})
+
+	contract := t.member(log, "contract")
+	contract.Set("getCaller", func(otto.FunctionCall) otto.Value { return t.value(t.contract.Caller().Hex()) })
+	contract.Set("getAddress", func(otto.FunctionCall) otto.Value { return t.value(t.contract.Address().Hex()) })
+	contract.Set("getValue", func(otto.FunctionCall) otto.Value { return t.bigNumber(t.contract.Value()) })
+	contract.Set("getInput", func(otto.FunctionCall) otto.Value { return t.value(common.ToHex(t.contract.Input)) })
+
+	return log.Value(), nil
+}
+
+// memorySlice returns the memory from start to end, throwing a range error if
+// it's outside the memory.
+func (t *Tracer) memorySlice(start, end int64) []byte {
+	if start < 0 || start > end || end > int64(t.memory.Len()) {
+		panic(t.vm.MakeRangeError(fmt.Sprintf("memory [%d:%d] out of %d bytes", start, end, t.memory.Len())))
+	}
+	return t.memory.Data()[start:end]
+}
+
+// newDB creates the db object, reading the state of the traced execution.
+func (t *Tracer) newDB() (otto.Value, error) {
+	db, err := t.vm.Object("({})")
+	if err != nil {
+		return otto.UndefinedValue(), err
+	}
+	addr := func(call otto.FunctionCall) common.Address {
+		return common.HexToAddress(call.Argument(0).String())
+	}
+	db.Set("getBalance", func(call otto.FunctionCall) otto.Value { return t.bigNumber(t.state.GetBalance(addr(call))) })
+	db.Set("getNonce", func(call otto.FunctionCall) otto.Value { return t.value(t.state.GetNonce(addr(call))) })
+	db.Set("getCode", func(call otto.FunctionCall) otto.Value { return t.value(common.ToHex(t.state.GetCode(addr(call)))) })
+	db.Set("getState", func(call otto.FunctionCall) otto.Value {
+		key := common.HexToHash(call.Argument(1).String())
+		return t.value(t.state.GetState(addr(call), key).Hex())
+	})
+	db.Set("exists", func(call otto.FunctionCall) otto.Value { return t.value(t.state.Exist(addr(call))) })
+
+	return db.Value(), nil
+}
+
+// member returns the object held by a property of obj.
+func (t *Tracer) member(obj *otto.Object, name string) *otto.Object {
+	v, _ := obj.Get(name)
+	return v.Object()
+}
+
+func (t *Tracer) value(v interface{}) otto.Value {
+	value, _ := t.vm.ToValue(v)
+	return value
+}
+
+// bigNumber converts x to a BigNumber object.
+func (t *Tracer) bigNumber(x *big.Int) otto.Value {
+	if x == nil {
+		x = new(big.Int)
+	}
+	v, _ := t.vm.Call("new BigNumber", nil, x.String())
+	return v
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/vm"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+// testEnv is an Environment only providing a state and a call depth.
+type testEnv struct {
+	vm.Environment
+	db    *state.StateDB
+	depth int
+}
+
+func (env *testEnv) Db() vm.Database { return env.db }
+func (env *testEnv) Depth() int      { return env.depth }
+
+// account is a contract reference to an address.
+type account common.Address
+
+func (a account) Address() common.Address                              { return common.Address(a) }
+func (account) Value() *big.Int                                        { return nil }
+func (account) SetCode(common.Hash, []byte)                            {}
+func (account) ForEachStorage(func(key, value common.Hash) bool) error { return nil }
+
+func newTestEnv(t *testing.T) *testEnv {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testEnv{db: statedb, depth: 1}
+}
+
+func TestTracer(t *testing.T) {
+	env := newTestEnv(t)
+	caller, callee := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	env.db.AddBalance(caller, big.NewInt(1000))
+
+	tracer, err := New(`{
+		steps: [], frames: [],
+		step: function(log, db) {
+			this.steps.push(log.op.toString() + " " + log.getPC() + " " + log.stack.peek(0) + " " + log.memory.slice(0, 2));
+		},
+		fault: function(log, db) { this.fault = log.op.toString() + ": " + log.getError(); },
+		enter: function(frame) { this.frames.push(frame.getType() + " " + frame.getTo() + " " + frame.getValue()); },
+		exit: function(frame) { this.frames.push("exit " + frame.getGasUsed() + " " + frame.getError()); },
+		result: function(ctx, db) {
+			return {steps: this.steps, frames: this.frames, fault: this.fault, type: ctx.type, balance: db.getBalance(ctx.from).toString(10)};
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mem   = vm.NewMemory()
+		outer = vm.NewContract(account(caller), account(callee), big.NewInt(0), 100000, big.NewInt(1))
+		inner = vm.NewContract(outer, account(caller), big.NewInt(5), 1000, big.NewInt(1))
+	)
+	mem.Resize(32)
+	mem.Set(0, 2, []byte{0xab, 0xcd})
+
+	tracer.CaptureEnter(env, outer, nil)
+	tracer.CaptureState(env, 0, vm.PUSH1, 100000, 3, mem, []*big.Int{big.NewInt(7)}, outer, nil)
+	tracer.CaptureState(env, 2, vm.CALL, 99997, 700, mem, []*big.Int{big.NewInt(8)}, outer, nil)
+	env.depth++
+	tracer.CaptureEnter(env, inner, nil)
+	tracer.CaptureState(env, 0, vm.MSTORE, 1000, 3, vm.NewMemory(), nil, inner, vm.OutOfGasError)
+	tracer.CaptureExit(env, nil, 1000, vm.OutOfGasError)
+	env.depth--
+	tracer.CaptureExit(env, nil, 2000, nil)
+
+	res, err := tracer.Result(&Context{Type: "CALL", From: caller, To: callee}, env.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have struct {
+		Steps, Frames []string
+		Fault, Type   string
+		Balance       string
+	}
+	if err := json.Unmarshal(res, &have); err != nil {
+		t.Fatalf("invalid result %s: %v", res, err)
+	}
+	want := []string{"PUSH1 0 7 0xabcd", "CALL 2 8 0xabcd"}
+	if strings.Join(have.Steps, ",") != strings.Join(want, ",") {
+		t.Errorf("steps mismatch: have %q, want %q", have.Steps, want)
+	}
+	// Only the nested frame is entered, reported as the call opening it.
+	want = []string{"CALL " + caller.Hex() + " 5", "exit 1000 " + vm.OutOfGasError.Error()}
+	if strings.Join(have.Frames, ",") != strings.Join(want, ",") {
+		t.Errorf("frames mismatch: have %q, want %q", have.Frames, want)
+	}
+	if want := "MSTORE: " + vm.OutOfGasError.Error(); have.Fault != want {
+		t.Errorf("fault mismatch: have %q, want %q", have.Fault, want)
+	}
+	if have.Type != "CALL" || have.Balance != "1000" {
+		t.Errorf("context mismatch: %s", res)
+	}
+}
+
+func TestTracerErrors(t *testing.T) {
+	if _, err := New(`{step: function() {}}`); err == nil {
+		t.Error("tracer without result compiled")
+	}
+	if _, err := New(`{step: function() {`); err == nil {
+		t.Error("invalid tracer compiled")
+	}
+
+	env := newTestEnv(t)
+	contract := vm.NewContract(account{}, account{}, big.NewInt(0), 0, big.NewInt(0))
+
+	// Errors thrown by the tracer are returned instead of the result.
+	tracer, err := New(`{step: function(log) { log.stack.peek(1); }, result: function() { return 1; }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer.CaptureState(env, 0, vm.STOP, 0, 0, vm.NewMemory(), nil, contract, nil)
+	if _, err := tracer.Result(&Context{}, env.db); err == nil || !strings.Contains(err.Error(), "RangeError") {
+		t.Errorf("thrown error not returned: %v", err)
+	}
+
+	// A tracer running too long is interrupted.
+	tracer, err = New(`{step: function() { for (var i = 0; ; i++) {} }, result: function() { return 1; }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(50*time.Millisecond, func() { tracer.Stop(ErrTimeout) })
+	tracer.CaptureState(env, 0, vm.STOP, 0, 0, vm.NewMemory(), nil, contract, nil)
+	if _, err := tracer.Result(&Context{}, env.db); err != ErrTimeout {
+		t.Errorf("interrupted tracer: have error %v, want %v", err, ErrTimeout)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+const (
+	// txRequestTimeout is the time after which an announced transaction is
+	// requested again from another peer if the first one didn't deliver it.
+	txRequestTimeout = 5 * time.Second
+
+	// maxTxAnnounceFetch is the maximum number of announced transactions
+	// requested from a peer at once.
+	maxTxAnnounceFetch = 256
+)
+
+// TxPropagationPolicy controls how transactions entering the pool are relayed
+// to peers.
+type TxPropagationPolicy struct {
+	// Fraction of the peers without a transaction that receive its full body,
+	// between 0 and 1. The remaining eth/65 peers only get the hash announced
+	// and fetch the body if they don't know it. Older peers can't fetch
+	// announced transactions and always receive full bodies. Zero defaults
+	// to all peers.
+	Fraction float64
+
+	// Interval to collect transactions for before broadcasting them in one
+	// batch per peer. Zero broadcasts every transaction immediately.
+	Interval time.Duration
+
+	// Private relays locally submitted transactions to trusted peers only,
+	// and never hands them to other peers, neither on broadcast nor request.
+	Private bool
+}
+
+// DefaultTxPropagation broadcasts every transaction immediately to all peers.
+var DefaultTxPropagation = TxPropagationPolicy{Fraction: 1}
+
+// withDefaults fills in the defaults of unset or invalid fields.
+func (policy TxPropagationPolicy) withDefaults() TxPropagationPolicy {
+	if policy.Fraction <= 0 || policy.Fraction > 1 {
+		policy.Fraction = DefaultTxPropagation.Fraction
+	}
+	if policy.Interval < 0 {
+		policy.Interval = 0
+	}
+	return policy
+}
+
+// direct returns how many of n peers receive full transaction bodies, at
+// least one if there are any peers.
+func (policy TxPropagationPolicy) direct(n int) int {
+	return int(math.Ceil(policy.Fraction * float64(n)))
+}
+
+// txRequestSet tracks announced transactions being fetched, so each one is
+// only requested from a single peer at a time.
+type txRequestSet struct {
+	lock      sync.Mutex
+	requested map[common.Hash]time.Time
+}
+
+func newTxRequestSet() *txRequestSet {
+	return &txRequestSet{requested: make(map[common.Hash]time.Time)}
+}
+
+// claim returns the hashes which aren't being fetched yet, marking them as
+// requested.
+func (s *txRequestSet) claim(hashes []common.Hash) []common.Hash {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for hash, requested := range s.requested {
+		if now.Sub(requested) > txRequestTimeout {
+			delete(s.requested, hash)
+		}
+	}
+	var claimed []common.Hash
+	for _, hash := range hashes {
+		if _, ok := s.requested[hash]; !ok {
+			s.requested[hash] = now
+			claimed = append(claimed, hash)
+		}
+	}
+	return claimed
+}
+
+// delivered stops tracking the requests of the given transactions.
+func (s *txRequestSet) delivered(hashes []common.Hash) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, hash := range hashes {
+		delete(s.requested, hash)
+	}
+}
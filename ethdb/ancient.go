@@ -0,0 +1,139 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"errors"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var errNotIterable = errors.New("hot store of the ancient database is not iterable")
+
+// AncientDatabase splits a chain database between a hot store, receiving all
+// writes, and a cold store holding ancient chain data that's been frozen and
+// won't change anymore. The cold store is meant to live on a cheaper, larger
+// volume than the hot one. Reads of frozen keys fall back to the cold store if
+// the hot one doesn't have them.
+type AncientDatabase struct {
+	Database
+	cold   Database
+	frozen func(key []byte) bool // reports whether a key may have been frozen
+}
+
+// NewAncientDatabase wraps hot and cold into a single database. Only reads of
+// keys that frozen reports as frozen data go to the cold store.
+func NewAncientDatabase(hot, cold Database, frozen func(key []byte) bool) *AncientDatabase {
+	return &AncientDatabase{Database: hot, cold: cold, frozen: frozen}
+}
+
+// Cold returns the store holding the frozen data.
+func (db *AncientDatabase) Cold() Database {
+	return db.cold
+}
+
+func (db *AncientDatabase) Get(key []byte) ([]byte, error) {
+	data, err := db.Database.Get(key)
+	if err == leveldb.ErrNotFound && db.frozen(key) {
+		return db.cold.Get(key)
+	}
+	return data, err
+}
+
+func (db *AncientDatabase) Has(key []byte) (bool, error) {
+	if ok, err := db.Database.Has(key); ok || err != nil || !db.frozen(key) {
+		return ok, err
+	}
+	return db.cold.Has(key)
+}
+
+func (db *AncientDatabase) Delete(key []byte) error {
+	if err := db.Database.Delete(key); err != nil {
+		return err
+	}
+	return db.cold.Delete(key)
+}
+
+func (db *AncientDatabase) Close() {
+	db.Database.Close()
+	db.cold.Close()
+}
+
+// LDB returns the LevelDB instance of the hot store, nil if it isn't a LevelDB
+// database. Maintenance such as compactions thus leaves the cold store alone.
+func (db *AncientDatabase) LDB() *leveldb.DB {
+	if ldb, ok := db.Database.(interface {
+		LDB() *leveldb.DB
+	}); ok {
+		return ldb.LDB()
+	}
+	return nil
+}
+
+// NewIterator iterates over both stores, in key order.
+func (db *AncientDatabase) NewIterator() iterator.Iterator {
+	return db.NewIteratorRange(nil)
+}
+
+// NewIteratorRange iterates over the given range of both stores, in key order.
+// A key being frozen may be visited twice. The cold store is skipped if it
+// isn't iterable, the hot store must be.
+func (db *AncientDatabase) NewIteratorRange(slice *util.Range) iterator.Iterator {
+	hot, ok := db.Database.(Iteratee)
+	if !ok {
+		return iterator.NewEmptyIterator(errNotIterable)
+	}
+	cold, ok := db.cold.(Iteratee)
+	if !ok {
+		return hot.NewIteratorRange(slice)
+	}
+	iters := []iterator.Iterator{hot.NewIteratorRange(slice), cold.NewIteratorRange(slice)}
+	return iterator.NewMergedIterator(iters, comparer.DefaultComparer, false)
+}
+
+// Freeze moves the given keys from the hot store to the cold one. Data is
+// written to the cold store before it's removed from the hot one, so it stays
+// readable throughout. Keys missing from the hot store are skipped.
+func (db *AncientDatabase) Freeze(keys ...[]byte) error {
+	batch := db.cold.NewBatch()
+	var moved [][]byte
+	for _, key := range keys {
+		data, err := db.Database.Get(key)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		if err := batch.Put(key, data); err != nil {
+			return err
+		}
+		moved = append(moved, key)
+	}
+	if len(moved) == 0 {
+		return nil
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	for _, key := range moved {
+		if err := db.Database.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
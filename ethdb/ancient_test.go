@@ -0,0 +1,156 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// frozenA treats the keys starting with an a as frozen.
+func frozenA(key []byte) bool {
+	return len(key) > 0 && key[0] == 'a'
+}
+
+// failingDatabase fails all reads.
+type failingDatabase struct {
+	*MemDatabase
+}
+
+func (db failingDatabase) Get(key []byte) ([]byte, error) {
+	return nil, errors.New("read failure")
+}
+
+func (db failingDatabase) Has(key []byte) (bool, error) {
+	return false, errors.New("read failure")
+}
+
+func TestAncientDatabaseFallback(t *testing.T) {
+	hot, _ := NewMemDatabase()
+	cold, _ := NewMemDatabase()
+	cold.Put([]byte("a1"), []byte("va1"))
+	cold.Put([]byte("b1"), []byte("vb1"))
+	db := NewAncientDatabase(hot, cold, frozenA)
+
+	// Only frozen keys missing from the hot store are read from the cold one
+	if data, err := db.Get([]byte("a1")); err != nil || string(data) != "va1" {
+		t.Errorf("get frozen key: have %q, %v", data, err)
+	}
+	if ok, err := db.Has([]byte("a1")); !ok || err != nil {
+		t.Errorf("has frozen key: have %v, %v", ok, err)
+	}
+	if _, err := db.Get([]byte("b1")); err != leveldb.ErrNotFound {
+		t.Errorf("get unfrozen key: have %v, want %v", err, leveldb.ErrNotFound)
+	}
+	if ok, err := db.Has([]byte("b1")); ok || err != nil {
+		t.Errorf("has unfrozen key: have %v, %v", ok, err)
+	}
+
+	// Failures of the hot store are returned, not hidden by the cold store
+	db = NewAncientDatabase(failingDatabase{hot}, cold, frozenA)
+	if _, err := db.Get([]byte("a1")); err == nil || err == leveldb.ErrNotFound {
+		t.Errorf("get with failing hot store: have %v", err)
+	}
+	if ok, err := db.Has([]byte("a1")); ok || err == nil {
+		t.Errorf("has with failing hot store: have %v, %v", ok, err)
+	}
+}
+
+func TestAncientDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ancient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	hot, err := NewLDBDatabase(filepath.Join(dir, "hot"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cold, err := NewLDBDatabase(filepath.Join(dir, "cold"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := NewAncientDatabase(hot, cold, frozenA)
+	defer db.Close()
+
+	for _, key := range []string{"a1", "b1", "a2", "b2", "a3"} {
+		if err := db.Put([]byte(key), []byte("v"+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Freeze([]byte("a1"), []byte("a2"), []byte("missing")); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := hot.Has([]byte("a1")); ok {
+		t.Error("frozen key still in the hot store")
+	}
+	for _, key := range []string{"a1", "a2", "b2"} {
+		if data, err := db.Get([]byte(key)); err != nil || string(data) != "v"+key {
+			t.Errorf("get %s: have %q, %v", key, data, err)
+		}
+	}
+
+	// Iterators cover both stores, in key order
+	var keys []string
+	it := db.NewIterator()
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	it.Release()
+	if want := []string{"a1", "a2", "a3", "b1", "b2"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("iterated keys: have %v, want %v", keys, want)
+	}
+	keys = nil
+	it = db.NewIteratorRange(NewBytesPrefix([]byte("b")))
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	it.Release()
+	if want := []string{"b1", "b2"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("iterated range: have %v, want %v", keys, want)
+	}
+
+	// Maintenance goes to the hot store
+	if db.LDB() != hot.LDB() {
+		t.Error("LDB isn't the hot store's")
+	}
+	if _, err := db.LDB().GetProperty("leveldb.stats"); err != nil {
+		t.Errorf("hot store property: %v", err)
+	}
+}
+
+func TestAncientDatabaseNotIterable(t *testing.T) {
+	hot, _ := NewMemDatabase()
+	cold, _ := NewMemDatabase()
+	db := NewAncientDatabase(hot, cold, frozenA)
+
+	if db.LDB() != nil {
+		t.Error("memory database has a LevelDB instance")
+	}
+	it := db.NewIterator()
+	if it.Next() || it.Error() != errNotIterable {
+		t.Errorf("iterator error: have %v, want %v", it.Error(), errNotIterable)
+	}
+	it.Release()
+}
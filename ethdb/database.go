@@ -23,6 +23,7 @@ import (
 
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/metrics"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
 	"github.com/syndtr/goleveldb/leveldb/filter"
@@ -92,6 +93,40 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	if err != nil {
 		return nil, err
 	}
+	metrics.RegisterGaugeFunc("cache/database/"+filepath.Base(file), func() int64 {
+		size, err := db.GetProperty("leveldb.cachedblock")
+		if err != nil {
+			return 0
+		}
+		n, _ := strconv.ParseInt(size, 10, 64)
+		return n
+	})
+	return &LDBDatabase{
+		file: file,
+		db:   db,
+	}, nil
+}
+
+// NewReadOnlyLDBDatabase opens an existing LevelDB database for reading only.
+// Opening fails while another process, e.g. a running node, has the database
+// open.
+func NewReadOnlyLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
+	if cache < 16 {
+		cache = 16
+	}
+	if handles < 16 {
+		handles = 16
+	}
+	db, err := leveldb.OpenFile(file, &opt.Options{
+		OpenFilesCacheCapacity: handles,
+		BlockCacheCapacity:     cache / 2 * opt.MiB,
+		Filter:                 filter.NewBloomFilter(10),
+		ErrorIfMissing:         true,
+		ReadOnly:               true,
+	})
+	if err != nil {
+		return nil, err
+	}
 	return &LDBDatabase{
 		file: file,
 		db:   db,
@@ -16,10 +16,20 @@
 
 package ethdb
 
+import (
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
 // Code using batches should try to add this much data to the batch.
 // The value was determined empirically.
 const IdealBatchSize = 100 * 1024
 
+// ImportBatchSize is the default amount of data chain import buffers in a batch
+// before flushing it to the database. Larger batches reduce leveldb write
+// amplification during sync at the cost of memory.
+const ImportBatchSize = 4 * 1024 * 1024
+
 // Putter wraps the database write operation supported by both batches and regular databases.
 type Putter interface {
 	Put(key []byte, value []byte) error
@@ -34,6 +44,12 @@ type Database interface {
 	NewBatch() Batch
 }
 
+// Iteratee is a database whose content can be iterated over, in key order.
+type Iteratee interface {
+	NewIterator() iterator.Iterator
+	NewIteratorRange(slice *util.Range) iterator.Iterator
+}
+
 type Batch interface {
 	Putter
 	ValueSize() int // amount of data in the batch
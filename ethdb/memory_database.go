@@ -17,9 +17,9 @@
 package ethdb
 
 import (
-	"errors"
 	"sync"
 
+	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/webchain-network/webchaind/common"
 )
 
@@ -59,7 +59,7 @@ func (db *MemDatabase) Get(key []byte) ([]byte, error) {
 	if entry, ok := db.db[string(key)]; ok {
 		return entry, nil
 	}
-	return nil, errors.New("not found")
+	return nil, leveldb.ErrNotFound
 }
 
 func (db *MemDatabase) Has(key []byte) (bool, error) {
@@ -0,0 +1,335 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+// Package faucet implements a rate-limited HTTP service dispensing test network
+// coins from a local, unlocked account.
+package faucet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webchain-network/webchaind/accounts"
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/p2p"
+	"github.com/webchain-network/webchaind/rpc"
+)
+
+var (
+	ErrInvalidAddress = errors.New("invalid address")
+	ErrRateLimited    = errors.New("address or client already funded recently")
+	ErrVerification   = errors.New("request verification failed")
+)
+
+var (
+	// DefaultAmount is dispensed per request if not configured (1 coin).
+	DefaultAmount = new(big.Int).Mul(big.NewInt(1), common.Ether)
+	// DefaultInterval is the minimum time between two payouts to the same address or client.
+	DefaultInterval = 24 * time.Hour
+
+	faucetGas = big.NewInt(21000)
+)
+
+// Backend is the subset of the Ethereum service required to fund accounts.
+type Backend interface {
+	AccountManager() *accounts.Manager
+	BlockChain() *core.BlockChain
+	TxPool() *core.TxPool
+}
+
+// Config holds the faucet settings.
+type Config struct {
+	ListenAddr string         // HTTP listening interface (host:port)
+	Account    common.Address // Funding account; it must be unlocked
+	Amount     *big.Int       // Wei dispensed per request
+	GasPrice   *big.Int       // Gas price of funding transactions
+	Interval   time.Duration  // Minimum time between payouts per address and per client IP
+	Verifiers  []Verifier     // Optional request verification hooks (captcha, webhook)
+
+	// TrustedProxies are the networks of the reverse proxies in front of the
+	// faucet. X-Forwarded-For is only honoured for requests coming from them.
+	TrustedProxies []net.IPNet
+}
+
+// Faucet is a node.Service serving funding requests over HTTP.
+type Faucet struct {
+	config Config
+	send   func(to common.Address, amount *big.Int) (common.Hash, error)
+
+	mu      sync.Mutex
+	funded  map[string]time.Time // Last payout time by address and client IP
+	swept   time.Time            // Last time payouts older than the interval were dropped
+	now     func() time.Time
+	txMu    sync.Mutex
+	backend Backend
+
+	listener net.Listener
+}
+
+// New creates a faucet funding requests from the given backend.
+func New(backend Backend, config Config) (*Faucet, error) {
+	if config.Account.IsEmpty() {
+		return nil, errors.New("faucet account not configured")
+	}
+	if !backend.AccountManager().HasAddress(config.Account) {
+		return nil, fmt.Errorf("faucet account %s not found in keystore", config.Account.Hex())
+	}
+	if config.Amount == nil || config.Amount.Sign() <= 0 {
+		config.Amount = DefaultAmount
+	}
+	if config.GasPrice == nil {
+		config.GasPrice = new(big.Int)
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+	f := newFaucet(config)
+	f.backend = backend
+	f.send = f.fund
+	return f, nil
+}
+
+func newFaucet(config Config) *Faucet {
+	return &Faucet{
+		config: config,
+		funded: make(map[string]time.Time),
+		now:    time.Now,
+	}
+}
+
+// Protocols implements node.Service.
+func (f *Faucet) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service.
+func (f *Faucet) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, starting the HTTP endpoint.
+func (f *Faucet) Start(*p2p.Server) error {
+	listener, err := net.Listen("tcp", f.config.ListenAddr)
+	if err != nil {
+		return err
+	}
+	f.listener = listener
+	go http.Serve(listener, f)
+
+	glog.V(logger.Info).Infof("Faucet started at http://%s, dispensing %v wei from %s", listener.Addr(), f.config.Amount, f.config.Account.Hex())
+	return nil
+}
+
+// Stop implements node.Service.
+func (f *Faucet) Stop() error {
+	if f.listener != nil {
+		return f.listener.Close()
+	}
+	return nil
+}
+
+// fundRequest is the body of a funding request. Captcha is the client-side
+// captcha response token, if a captcha verifier is configured.
+type fundRequest struct {
+	Address string `json:"address"`
+	Captcha string `json:"captcha"`
+}
+
+type fundResponse struct {
+	Tx    *common.Hash `json:"tx,omitempty"`
+	Error string       `json:"error,omitempty"`
+}
+
+type infoResponse struct {
+	Account  common.Address `json:"account"`
+	Amount   *big.Int       `json:"amount"`
+	Interval string         `json:"interval"`
+}
+
+// ServeHTTP serves GET requests with the faucet settings and POST requests
+// (JSON or form encoded) to fund an address.
+func (f *Faucet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(infoResponse{f.config.Account, f.config.Amount, f.config.Interval.String()})
+	case http.MethodPost:
+		var req fundRequest
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				f.reply(w, http.StatusBadRequest, nil, err)
+				return
+			}
+		} else {
+			req.Address, req.Captcha = r.FormValue("address"), r.FormValue("captcha")
+		}
+		hash, err := f.handle(r, req)
+		switch err {
+		case nil:
+			f.reply(w, http.StatusOK, &hash, nil)
+		case ErrInvalidAddress:
+			f.reply(w, http.StatusBadRequest, nil, err)
+		case ErrRateLimited:
+			f.reply(w, http.StatusTooManyRequests, nil, err)
+		case ErrVerification:
+			f.reply(w, http.StatusForbidden, nil, err)
+		default:
+			f.reply(w, http.StatusInternalServerError, nil, err)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *Faucet) reply(w http.ResponseWriter, code int, hash *common.Hash, err error) {
+	resp := fundResponse{Tx: hash}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handle verifies and rate limits a funding request, then funds the address.
+func (f *Faucet) handle(r *http.Request, req fundRequest) (common.Hash, error) {
+	if !common.IsHexAddress(req.Address) {
+		return common.Hash{}, ErrInvalidAddress
+	}
+	addr := common.HexToAddress(req.Address)
+	ip := f.clientIP(r)
+
+	for _, v := range f.config.Verifiers {
+		if err := v.Verify(addr, ip, req.Captcha); err != nil {
+			glog.V(logger.Debug).Infof("Faucet request for %s from %s rejected: %v", addr.Hex(), ip, err)
+			return common.Hash{}, ErrVerification
+		}
+	}
+
+	f.mu.Lock()
+	now := f.now()
+	f.expire(now)
+	for _, key := range []string{addr.Hex(), ip} {
+		if last, ok := f.funded[key]; ok && now.Sub(last) < f.config.Interval {
+			f.mu.Unlock()
+			return common.Hash{}, ErrRateLimited
+		}
+	}
+	f.funded[addr.Hex()], f.funded[ip] = now, now
+	f.mu.Unlock()
+
+	hash, err := f.send(addr, f.config.Amount)
+	if err != nil {
+		// Don't penalise the requester for our own failure.
+		f.mu.Lock()
+		delete(f.funded, addr.Hex())
+		delete(f.funded, ip)
+		f.mu.Unlock()
+		return common.Hash{}, err
+	}
+	glog.V(logger.Info).Infof("Faucet funded %s (client %s): tx %s", addr.Hex(), ip, hash.Hex())
+	return hash, nil
+}
+
+// expire drops the payouts older than the interval, which don't limit requests
+// anymore, so the payouts kept are bounded by the requests of two intervals.
+// It sweeps at most once per interval. f.mu must be held.
+func (f *Faucet) expire(now time.Time) {
+	if now.Sub(f.swept) < f.config.Interval {
+		return
+	}
+	for key, last := range f.funded {
+		if now.Sub(last) >= f.config.Interval {
+			delete(f.funded, key)
+		}
+	}
+	f.swept = now
+}
+
+// fund signs and submits a transfer from the faucet account to the pool.
+func (f *Faucet) fund(to common.Address, amount *big.Int) (common.Hash, error) {
+	f.txMu.Lock()
+	defer f.txMu.Unlock()
+
+	pool, bc := f.backend.TxPool(), f.backend.BlockChain()
+
+	nonce := pool.State().GetNonce(f.config.Account)
+	tx := types.NewTransaction(nonce, to, amount, faucetGas, f.config.GasPrice, nil)
+
+	signer := bc.Config().GetSigner(bc.CurrentBlock().Number())
+	tx.SetSigner(signer)
+	sig, err := f.backend.AccountManager().Sign(f.config.Account, signer.Hash(tx).Bytes())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signed, err := tx.WithSigner(signer).WithSignature(sig)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	pool.SetLocal(signed)
+	if err := pool.Add(signed); err != nil {
+		return common.Hash{}, err
+	}
+	return signed.Hash(), nil
+}
+
+// clientIP returns the requesting client's IP. For requests relayed by trusted
+// proxies it is the right-most X-Forwarded-For hop that isn't a trusted proxy,
+// the hops left of it are set by the client and can't be relied upon.
+func (f *Faucet) clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	if !f.trusted(ip) {
+		return ip
+	}
+	var hops []string
+	for _, fwd := range r.Header["X-Forwarded-For"] {
+		hops = append(hops, strings.Split(fwd, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		ip = hop
+		if !f.trusted(ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// trusted reports whether ip belongs to one of the trusted proxy networks.
+func (f *Faucet) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range f.config.TrustedProxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
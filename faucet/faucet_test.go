@@ -0,0 +1,174 @@
+package faucet
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+func newTestFaucet(verifiers ...Verifier) (*Faucet, *[]common.Address) {
+	var funded []common.Address
+	f := newFaucet(Config{Amount: big.NewInt(1), Interval: time.Hour, Verifiers: verifiers})
+	f.send = func(to common.Address, amount *big.Int) (common.Hash, error) {
+		funded = append(funded, to)
+		return common.BytesToHash(to.Bytes()), nil
+	}
+	return f, &funded
+}
+
+func request(f *Faucet, addr, ip string) int {
+	form := url.Values{"address": {addr}}
+	req := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = ip + ":1234"
+	rec := httptest.NewRecorder()
+	f.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestFaucetRateLimit(t *testing.T) {
+	f, funded := newTestFaucet()
+	now := time.Now()
+	f.now = func() time.Time { return now }
+
+	addr1 := "0x0000000000000000000000000000000000000001"
+	addr2 := "0x0000000000000000000000000000000000000002"
+
+	if code := request(f, "nonsense", "1.1.1.1"); code != http.StatusBadRequest {
+		t.Errorf("invalid address: got status %d", code)
+	}
+	if code := request(f, addr1, "1.1.1.1"); code != http.StatusOK {
+		t.Errorf("first request: got status %d", code)
+	}
+	if code := request(f, addr1, "2.2.2.2"); code != http.StatusTooManyRequests {
+		t.Errorf("same address: got status %d", code)
+	}
+	if code := request(f, addr2, "1.1.1.1"); code != http.StatusTooManyRequests {
+		t.Errorf("same client: got status %d", code)
+	}
+	if code := request(f, addr2, "2.2.2.2"); code != http.StatusOK {
+		t.Errorf("new address and client: got status %d", code)
+	}
+
+	now = now.Add(time.Hour)
+	if code := request(f, addr1, "1.1.1.1"); code != http.StatusOK {
+		t.Errorf("after interval: got status %d", code)
+	}
+	if len(*funded) != 3 {
+		t.Errorf("funded %d times, want 3", len(*funded))
+	}
+}
+
+func TestFaucetExpirePayouts(t *testing.T) {
+	f, _ := newTestFaucet()
+	now := time.Now()
+	f.now = func() time.Time { return now }
+
+	addr1 := "0x0000000000000000000000000000000000000001"
+	addr2 := "0x0000000000000000000000000000000000000002"
+
+	request(f, addr1, "1.1.1.1")
+	now = now.Add(30 * time.Minute)
+	request(f, addr2, "2.2.2.2")
+	if len(f.funded) != 4 {
+		t.Fatalf("tracking %d payouts, want 4", len(f.funded))
+	}
+	// Payouts older than the interval are dropped by the next request.
+	now = now.Add(45 * time.Minute)
+	if code := request(f, addr2, "2.2.2.2"); code != http.StatusTooManyRequests {
+		t.Errorf("within interval: got status %d", code)
+	}
+	if _, ok := f.funded[common.HexToAddress(addr1).Hex()]; ok || len(f.funded) != 2 {
+		t.Errorf("tracking %d payouts after the interval, want 2", len(f.funded))
+	}
+}
+
+func TestFaucetWebhookVerifier(t *testing.T) {
+	allowed := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var body struct{ Address string }
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || common.HexToAddress(body.Address) != allowed {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer hook.Close()
+
+	f, funded := newTestFaucet(&WebhookVerifier{URL: hook.URL})
+	if code := request(f, "0x0000000000000000000000000000000000000002", "1.1.1.1"); code != http.StatusForbidden {
+		t.Errorf("rejected by webhook: got status %d", code)
+	}
+	if code := request(f, allowed.Hex(), "1.1.1.1"); code != http.StatusOK {
+		t.Errorf("approved by webhook: got status %d", code)
+	}
+	if len(*funded) != 1 || (*funded)[0] != allowed {
+		t.Errorf("unexpected funded addresses: %v", *funded)
+	}
+}
+
+func TestFaucetCaptchaVerifier(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("secret") == "secret" && r.FormValue("response") == "token" {
+			w.Write([]byte(`{"success": true}`))
+			return
+		}
+		w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+	}))
+	defer site.Close()
+
+	v := &CaptchaVerifier{URL: site.URL, Secret: "secret"}
+	addr := common.HexToAddress("0x01")
+	if err := v.Verify(addr, "1.1.1.1", ""); err == nil {
+		t.Error("expected error for missing captcha")
+	}
+	if err := v.Verify(addr, "1.1.1.1", "wrong"); err == nil {
+		t.Error("expected error for wrong captcha")
+	}
+	if err := v.Verify(addr, "1.1.1.1", "token"); err != nil {
+		t.Errorf("valid captcha: %v", err)
+	}
+}
+
+func TestFaucetClientIP(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	f := newFaucet(Config{TrustedProxies: []net.IPNet{*proxies}})
+
+	tests := []struct {
+		remote string
+		fwd    []string
+		want   string
+	}{
+		// Forwarded headers of untrusted peers are ignored
+		{"1.1.1.1:1234", nil, "1.1.1.1"},
+		{"1.1.1.1:1234", []string{"2.2.2.2"}, "1.1.1.1"},
+		// Requests relayed by a trusted proxy come from the right-most untrusted hop
+		{"10.0.0.1:1234", []string{"2.2.2.2"}, "2.2.2.2"},
+		{"10.0.0.1:1234", []string{"3.3.3.3, 2.2.2.2"}, "2.2.2.2"},
+		{"10.0.0.1:1234", []string{"3.3.3.3, 2.2.2.2, 10.0.0.2"}, "2.2.2.2"},
+		{"10.0.0.1:1234", []string{"3.3.3.3", "2.2.2.2, 10.0.0.2"}, "2.2.2.2"},
+		// Only trusted hops, the left-most is the client
+		{"10.0.0.1:1234", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"10.0.0.1:1234", nil, "10.0.0.1"},
+	}
+	for i, tt := range tests {
+		req := httptest.NewRequest("POST", "/", nil)
+		req.RemoteAddr = tt.remote
+		for _, fwd := range tt.fwd {
+			req.Header.Add("X-Forwarded-For", fwd)
+		}
+		if ip := f.clientIP(req); ip != tt.want {
+			t.Errorf("test %d: got %s, want %s", i, ip, tt.want)
+		}
+	}
+}
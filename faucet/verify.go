@@ -0,0 +1,103 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package faucet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+)
+
+// DefaultCaptchaURL is the reCAPTCHA verification endpoint. hCaptcha and other
+// services implementing the same siteverify protocol may be used instead.
+const DefaultCaptchaURL = "https://www.google.com/recaptcha/api/siteverify"
+
+var verifyClient = &http.Client{Timeout: 10 * time.Second}
+
+// Verifier decides whether a funding request may be served.
+type Verifier interface {
+	Verify(addr common.Address, ip string, captcha string) error
+}
+
+// CaptchaVerifier checks the request's captcha token against a siteverify endpoint.
+type CaptchaVerifier struct {
+	URL    string
+	Secret string
+}
+
+func (v *CaptchaVerifier) Verify(addr common.Address, ip string, captcha string) error {
+	if captcha == "" {
+		return errors.New("missing captcha response")
+	}
+	endpoint := v.URL
+	if endpoint == "" {
+		endpoint = DefaultCaptchaURL
+	}
+	res, err := verifyClient.PostForm(endpoint, url.Values{
+		"secret":   {v.Secret},
+		"response": {captcha},
+		"remoteip": {ip},
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	var result struct {
+		Success bool     `json:"success"`
+		Errors  []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return fmt.Errorf("captcha rejected: %v", result.Errors)
+	}
+	return nil
+}
+
+// WebhookVerifier posts each request's address and client IP as JSON to an
+// external service, which approves it by responding with a 2xx status.
+type WebhookVerifier struct {
+	URL string
+}
+
+func (v *WebhookVerifier) Verify(addr common.Address, ip string, captcha string) error {
+	body, err := json.Marshal(map[string]string{
+		"address": addr.Hex(),
+		"ip":      ip,
+	})
+	if err != nil {
+		return err
+	}
+	res, err := verifyClient.Post(v.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook rejected request: %s", res.Status)
+	}
+	return nil
+}
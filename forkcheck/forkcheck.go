@@ -0,0 +1,221 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+// Package forkcheck periodically fetches a signed manifest of the scheduled
+// hard forks and warns when the node won't be able to follow one of them,
+// because it runs a version too old to implement the fork or because its
+// chain configuration doesn't activate the fork at the scheduled block.
+//
+// The manifest is a JSON document such as
+//
+//	{
+//		"manifest": {"latest": "v4.2.0", "forks": [{"name": "Orion", "chain": "mainnet", "block": 2500000, "minVersion": "v4.2.0"}]},
+//		"signature": "0x..."
+//	}
+//
+// signed by a key the node is configured to trust, see SignedManifest.
+package forkcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/metrics"
+	"github.com/webchain-network/webchaind/p2p"
+	"github.com/webchain-network/webchaind/rpc"
+)
+
+// DefaultInterval is the time between two checks if not configured.
+var DefaultInterval = 6 * time.Hour
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Backend is the subset of the Ethereum service required to check forks.
+type Backend interface {
+	BlockChain() *core.BlockChain
+	ChainConfig() *core.ChainConfig
+}
+
+// Config holds the checker settings.
+type Config struct {
+	URL      string           // Location of the signed manifest
+	Signers  []common.Address // Keys trusted to sign the manifest
+	Interval time.Duration    // Time between two checks
+	Webhook  string           // URL the problems are posted to as JSON when they change (optional)
+	Version  string           // Running client version
+	Chain    string           // Identity of the chain the node runs
+}
+
+// Checker is a node.Service checking the manifest in the background.
+type Checker struct {
+	config  Config
+	backend Backend
+
+	mu       sync.Mutex
+	problems []Problem // Problems found by the last successful check
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a checker of the given backend's configuration.
+func New(backend Backend, config Config) (*Checker, error) {
+	if config.URL == "" {
+		return nil, errors.New("fork manifest URL not configured")
+	}
+	if len(config.Signers) == 0 {
+		return nil, errors.New("no trusted fork manifest signers configured")
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+	return &Checker{config: config, backend: backend, quit: make(chan struct{})}, nil
+}
+
+// Protocols implements node.Service.
+func (c *Checker) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service.
+func (c *Checker) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, starting the periodic checks.
+func (c *Checker) Start(*p2p.Server) error {
+	c.wg.Add(1)
+	go c.loop()
+	return nil
+}
+
+// Stop implements node.Service.
+func (c *Checker) Stop() error {
+	close(c.quit)
+	c.wg.Wait()
+	return nil
+}
+
+func (c *Checker) loop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := c.check(); err != nil {
+			metrics.ForkCheckFailures.Mark(1)
+			glog.V(logger.Warn).Warnf("Fork readiness check failed: %v", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// check fetches the manifest and reports the problems found in it.
+func (c *Checker) check() error {
+	m, err := c.fetch()
+	if err != nil {
+		return err
+	}
+	head := c.backend.BlockChain().CurrentBlock().NumberU64()
+	problems := m.Check(c.config.Version, c.config.Chain, c.backend.ChainConfig(), head)
+	metrics.ForkCheckProblems.Update(int64(len(problems)))
+
+	if cmp, err := compareVersions(c.config.Version, m.Latest); err == nil && cmp < 0 {
+		glog.V(logger.Info).Infof("A newer version of webchaind is available: %s (running %s)", m.Latest, c.config.Version)
+	}
+	for _, p := range problems {
+		glog.V(logger.Error).Errorf("!!! NODE NOT READY FOR UPCOMING HARD FORK !!! %v", p)
+		glog.D(logger.Error).Errorf("Node not ready for upcoming hard fork: %s", logger.ColorRed(p.String()))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if reflect.DeepEqual(problems, c.problems) {
+		return nil
+	}
+	if len(problems) == 0 {
+		glog.V(logger.Info).Infof("Node ready for all scheduled hard forks")
+	}
+	// Keep the old problems if the webhook fails, so it's retried next time.
+	if c.config.Webhook != "" {
+		if err := c.post(problems); err != nil {
+			return err
+		}
+	}
+	c.problems = problems
+	return nil
+}
+
+// Problems returns the problems found by the last successful check.
+func (c *Checker) Problems() []Problem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.problems
+}
+
+func (c *Checker) fetch() (*Manifest, error) {
+	res, err := httpClient.Get(c.config.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching manifest: %s", res.Status)
+	}
+	var sm SignedManifest
+	if err := json.NewDecoder(res.Body).Decode(&sm); err != nil {
+		return nil, fmt.Errorf("invalid manifest document: %v", err)
+	}
+	return sm.Verify(c.config.Signers)
+}
+
+// post sends the problems to the webhook, an empty list once they're resolved.
+func (c *Checker) post(problems []Problem) error {
+	if problems == nil {
+		problems = []Problem{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"version":  c.config.Version,
+		"chain":    c.config.Chain,
+		"problems": problems,
+	})
+	if err != nil {
+		return err
+	}
+	res, err := httpClient.Post(c.config.Webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook rejected problems: %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package forkcheck
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+)
+
+var testManifest = &Manifest{
+	Latest: "v4.2.0",
+	Forks: []ScheduledFork{
+		{Name: "Homestead", Chain: "mainnet", Block: 0, MinVersion: "v1.0.0"},
+		{Name: "Orion", Chain: "mainnet", Block: 100, MinVersion: "v4.2.0"},
+		{Name: "Orion", Chain: "morden", Block: 50, MinVersion: "v4.1.0"},
+	},
+}
+
+func signWith(key *ecdsa.PrivateKey) func([]byte) ([]byte, error) {
+	return func(hash []byte) ([]byte, error) { return crypto.Sign(hash, key) }
+}
+
+func TestManifestVerify(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+
+	sm, err := Sign(testManifest, signWith(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The document survives a JSON round trip.
+	enc, _ := json.Marshal(sm)
+	var decoded SignedManifest
+	if err := json.Unmarshal(enc, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	m, err := decoded.Verify([]common.Address{crypto.PubkeyToAddress(other.PublicKey), signer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Latest != testManifest.Latest || len(m.Forks) != len(testManifest.Forks) {
+		t.Errorf("manifest mismatch: got %+v", m)
+	}
+	if _, err := decoded.Verify([]common.Address{crypto.PubkeyToAddress(other.PublicKey)}); err != ErrUntrustedSigner {
+		t.Errorf("untrusted signer: got error %v", err)
+	}
+	decoded.Manifest = []byte(`{"latest": "v9.9.9"}`)
+	if _, err := decoded.Verify([]common.Address{signer}); err == nil {
+		t.Error("tampered manifest verified")
+	}
+}
+
+func TestManifestCheck(t *testing.T) {
+	config := func(orion int64) *core.ChainConfig {
+		c := &core.ChainConfig{Forks: []*core.Fork{{Name: "Homestead", Block: big.NewInt(0)}}}
+		if orion >= 0 {
+			c.Forks = append(c.Forks, &core.Fork{Name: "Orion", Block: big.NewInt(orion)})
+		}
+		return c
+	}
+	tests := []struct {
+		version  string
+		chain    string
+		config   *core.ChainConfig
+		head     uint64
+		problems int
+	}{
+		{"v4.2.0", "mainnet", config(100), 10, 0},
+		{"v4.2.0-12-gabcdef", "mainnet", config(100), 10, 0},
+		{"source", "mainnet", config(100), 10, 0},        // Unknown versions are assumed recent
+		{"v4.1.9", "mainnet", config(100), 10, 1},        // Too old
+		{"v4.1.9", "mainnet", config(100), 100, 0},       // Fork already passed
+		{"v4.2.0", "mainnet", config(200), 10, 1},        // Wrong block
+		{"v4.2.0", "mainnet", config(-1), 10, 1},         // Not configured
+		{"v4.0.0", "mainnet", config(-1), 10, 2},         // Both
+		{"v4.1.0", "morden", config(50), 10, 0},          // Other chain
+		{"v4.2.0", "testnet-unknown", config(-1), 10, 0}, // No forks scheduled
+	}
+	for i, tt := range tests {
+		problems := testManifest.Check(tt.version, tt.chain, tt.config, tt.head)
+		if len(problems) != tt.problems {
+			t.Errorf("test %d: got problems %v, want %d", i, problems, tt.problems)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v4.1.0", "v4.1.0", 0},
+		{"4.1", "v4.1.0", 0},
+		{"v4.1.0", "v4.10.0", -1},
+		{"v5.0.0", "v4.9.9", 1},
+		{"v4.1.1+build", "v4.1.0", 1},
+	}
+	for _, tt := range tests {
+		if have, err := compareVersions(tt.a, tt.b); err != nil || have != tt.want {
+			t.Errorf("compare %s %s: got %d, %v, want %d", tt.a, tt.b, have, err, tt.want)
+		}
+	}
+	for _, v := range []string{"source", "v1.2.3.4", "v1.x"} {
+		if _, err := compareVersions(v, "v1.0.0"); err == nil {
+			t.Errorf("no error for %q", v)
+		}
+	}
+}
+
+type testBackend struct {
+	bc     *core.BlockChain
+	config *core.ChainConfig
+}
+
+func (b *testBackend) BlockChain() *core.BlockChain   { return b.bc }
+func (b *testBackend) ChainConfig() *core.ChainConfig { return b.config }
+
+func TestCheckerWebhook(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sm, _ := Sign(testManifest, signWith(key))
+	manifests := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(sm)
+	}))
+	defer manifests.Close()
+
+	var posted [][]Problem
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Problems []Problem }
+		json.NewDecoder(r.Body).Decode(&body)
+		posted = append(posted, body.Problems)
+	}))
+	defer webhook.Close()
+
+	db, _ := ethdb.NewMemDatabase()
+	core.WriteGenesisBlockForTesting(db)
+	config := &core.ChainConfig{Forks: []*core.Fork{{Name: "Homestead", Block: big.NewInt(0)}}}
+	bc, err := core.NewBlockChain(db, config, core.FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(&testBackend{bc, config}, Config{
+		URL:     manifests.URL,
+		Signers: []common.Address{crypto.PubkeyToAddress(key.PublicKey)},
+		Webhook: webhook.URL,
+		Version: "v4.2.0",
+		Chain:   "mainnet",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The problems are posted once, until they change.
+	for i := 0; i < 2; i++ {
+		if err := c.check(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(posted) != 1 || len(posted[0]) != 1 || posted[0][0].Fork != "Orion" {
+		t.Fatalf("posted problems mismatch: got %v", posted)
+	}
+	config.Forks = append(config.Forks, &core.Fork{Name: "Orion", Block: big.NewInt(100)})
+	if err := c.check(); err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 2 || len(posted[1]) != 0 || len(c.Problems()) != 0 {
+		t.Errorf("resolved problems not posted: got %v", posted)
+	}
+}
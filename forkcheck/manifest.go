@@ -0,0 +1,172 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package forkcheck
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/common/hexutil"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/crypto"
+)
+
+var (
+	ErrUntrustedSigner = errors.New("manifest not signed by a trusted signer")
+	ErrInvalidVersion  = errors.New("invalid version")
+)
+
+// Manifest lists the scheduled hard forks of the known chains and the client
+// versions able to follow them.
+type Manifest struct {
+	Latest string          `json:"latest"` // Latest released client version
+	Forks  []ScheduledFork `json:"forks"`
+}
+
+// ScheduledFork is a hard fork scheduled on a chain.
+type ScheduledFork struct {
+	Name       string `json:"name"`       // Fork name, as in the chain configuration
+	Chain      string `json:"chain"`      // Chain identity, e.g. "mainnet"
+	Block      uint64 `json:"block"`      // Activation block
+	MinVersion string `json:"minVersion"` // Oldest client version implementing the fork
+}
+
+// SignedManifest is the document served at the manifest URL. The signature is
+// the 65 byte secp256k1 signature of the Keccak256 hash of the manifest as it
+// appears in the document.
+type SignedManifest struct {
+	Manifest  json.RawMessage `json:"manifest"`
+	Signature hexutil.Bytes   `json:"signature"`
+}
+
+// Sign creates a manifest document signed by signer.
+func Sign(m *Manifest, signer func(hash []byte) ([]byte, error)) (*SignedManifest, error) {
+	enc, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := signer(crypto.Keccak256(enc))
+	if err != nil {
+		return nil, err
+	}
+	return &SignedManifest{Manifest: enc, Signature: sig}, nil
+}
+
+// Verify checks that the manifest is signed by one of the given signers and
+// decodes it.
+func (sm *SignedManifest) Verify(signers []common.Address) (*Manifest, error) {
+	pub, err := crypto.SigToPub(crypto.Keccak256(sm.Manifest), sm.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest signature: %v", err)
+	}
+	signer := crypto.PubkeyToAddress(*pub)
+	trusted := false
+	for _, addr := range signers {
+		if addr == signer {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return nil, ErrUntrustedSigner
+	}
+	m := new(Manifest)
+	if err := json.Unmarshal(sm.Manifest, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %v", err)
+	}
+	return m, nil
+}
+
+// Problem is a reason the node can't follow an upcoming fork.
+type Problem struct {
+	Fork   string `json:"fork"`
+	Block  uint64 `json:"block"`
+	Reason string `json:"reason"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("fork %s at block %d: %s", p.Fork, p.Block, p.Reason)
+}
+
+// Check returns the problems preventing a node running the given version with
+// the given configuration of chain from following the forks of the manifest
+// scheduled above head. Versions which can't be compared, such as development
+// builds, are assumed to be recent enough.
+func (m *Manifest) Check(version, chain string, config *core.ChainConfig, head uint64) []Problem {
+	var problems []Problem
+	for _, fork := range m.Forks {
+		if fork.Chain != chain || fork.Block <= head {
+			continue
+		}
+		if c, err := compareVersions(version, fork.MinVersion); err == nil && c < 0 {
+			problems = append(problems, Problem{fork.Name, fork.Block, fmt.Sprintf("version %s required, running %s", fork.MinVersion, version)})
+		}
+		switch configured := config.ForkByName(fork.Name); {
+		case configured.Block == nil:
+			problems = append(problems, Problem{fork.Name, fork.Block, "fork not in the chain configuration"})
+		case !configured.Block.IsUint64() || configured.Block.Uint64() != fork.Block:
+			problems = append(problems, Problem{fork.Name, fork.Block, fmt.Sprintf("chain configuration activates the fork at block %v", configured.Block)})
+		}
+	}
+	return problems
+}
+
+// compareVersions compares two versions like v4.1.0, ignoring any suffix after
+// the patch number such as the commit count and hash of git describe.
+func compareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range va {
+		switch {
+		case va[i] < vb[i]:
+			return -1, nil
+		case va[i] > vb[i]:
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func parseVersion(s string) ([3]int, error) {
+	var v [3]int
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, ErrInvalidVersion
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, ErrInvalidVersion
+		}
+		v[i] = n
+	}
+	return v, nil
+}
@@ -41,6 +41,36 @@ web3._extend({
 			call: 'admin_addPeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'dropTransaction',
+			call: 'admin_dropTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'replaceTransaction',
+			call: 'admin_replaceTransaction',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'denyPeer',
+			call: 'admin_denyPeer',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'allowPeer',
+			call: 'admin_allowPeer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'exportDenyList',
+			call: 'admin_exportDenyList'
+		}),
+		new web3._extend.Method({
+			name: 'importDenyList',
+			call: 'admin_importDenyList',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -168,6 +198,24 @@ web3._extend({
 			params: 8,
 			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null, null, null, null]
 		}),
+		new web3._extend.Method({
+			name: 'getAccountsTransactions',
+			call: 'geth_getAccountsTransactions',
+			params: 7,
+			inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter, null, null, null, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getTokenBalances',
+			call: 'geth_getTokenBalances',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getTokenTransfers',
+			call: 'geth_getTokenTransfers',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'buildATXI',
 			call: 'geth_buildATXI',
@@ -177,7 +225,24 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'getATXIBuildStatus',
 			call: 'geth_getATXIBuildStatus',
-			params: 0,
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getEmission',
+			call: 'geth_getEmission',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getRewardEras',
+			call: 'geth_getRewardEras',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getGasStats',
+			call: 'geth_getGasStats',
+			params: 1
 		})
 	],
 	properties: []
@@ -230,12 +295,69 @@ web3._extend({
 		new web3._extend.Method({
 			name: 'traceTransaction',
 			call: 'debug_traceTransaction',
-			params: 1
+			params: 2,
+			inputFormatter: [null, null]
 		}),
 		new web3._extend.Method({
 			name: 'accountExist',
 			call: 'debug_accountExist',
 			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'chaindbProperty',
+			call: 'debug_chaindbProperty',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'chaindbCompact',
+			call: 'debug_chaindbCompact',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'standardTraceBlockToFile',
+			call: 'debug_standardTraceBlockToFile',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'sideBlocks',
+			call: 'debug_sideBlocks',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'stateAvailability',
+			call: 'debug_stateAvailability',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'intermediateRoots',
+			call: 'debug_intermediateRoots',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'profileBlock',
+			call: 'debug_profileBlock',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'executionWitness',
+			call: 'debug_executionWitness',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'stateStats',
+			call: 'debug_stateStats',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'preimage',
+			call: 'debug_preimage',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'filters',
+			call: 'debug_filters',
+			params: 0
 		})
 	],
 	properties: []
@@ -277,14 +399,68 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'decodeRawTransaction',
+			call: 'eth_decodeRawTransaction',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'chainId',
 			call: 'eth_chainId',
 			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getHeaderByNumber',
+			call: 'eth_getHeaderByNumber',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getHeaderByHash',
+			call: 'eth_getHeaderByHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getStorageRange',
+			call: 'eth_getStorageRange',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getContractCreation',
+			call: 'eth_getContractCreation',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getReceiptProof',
+			call: 'eth_getReceiptProof',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getAccountBalances',
+			call: 'eth_getAccountBalances',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'createAccessList',
+			call: 'eth_createAccessList',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputCallFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		})
 	],
 	properties:
 	[
+		new web3._extend.Property({
+			name: 'watchOnlyAccounts',
+			getter: 'eth_watchOnlyAccounts'
+		}),
+		new web3._extend.Property({
+			name: 'maxPriorityFeePerGas',
+			getter: 'eth_maxPriorityFeePerGas',
+			outputFormatter: web3._extend.utils.toBigNumber
+		}),
 		new web3._extend.Property({
 			name: 'pendingTransactions',
 			getter: 'eth_pendingTransactions',
@@ -332,6 +508,10 @@ web3._extend({
 			call: 'miner_setGasPrice',
 			params: 1,
 			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'pendingBlock',
+			call: 'miner_pendingBlock'
 		})
 	],
 	properties: []
@@ -347,6 +527,10 @@ web3._extend({
 		new web3._extend.Property({
 			name: 'version',
 			getter: 'net_version'
+		}),
+		new web3._extend.Property({
+			name: 'peerStats',
+			getter: 'net_peerStats'
 		})
 	]
 });
@@ -379,6 +563,24 @@ web3._extend({
 			call: 'personal_ecRecover',
 			params: 2,
 			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'signingAudit',
+			call: 'personal_signingAudit',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'addWatchOnly',
+			call: 'personal_addWatchOnly',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'removeWatchOnly',
+			call: 'personal_removeWatchOnly',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
 		})
 	]
 });
@@ -401,13 +603,24 @@ web3._extend({
 const Shh_JS = `
 web3._extend({
 	property: 'shh',
-	methods: [],
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'setMinPoW',
+			call: 'shh_setMinPoW',
+			params: 1
+		})
+	],
 	properties:
 	[
 		new web3._extend.Property({
 			name: 'version',
 			getter: 'shh_version',
 			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Property({
+			name: 'info',
+			getter: 'shh_info'
 		})
 	]
 });
@@ -435,6 +648,25 @@ web3._extend({
 				status.queued = web3._extend.utils.toDecimal(status.queued);
 				return status;
 			}
+		}),
+		new web3._extend.Property({
+			name: 'gasPriceFloor',
+			getter: 'txpool_gasPriceFloor',
+			outputFormatter: function(floor) {
+				floor.miner = web3._extend.utils.toBigNumber(floor.miner);
+				floor.txpool = web3._extend.utils.toBigNumber(floor.txpool);
+				floor.effective = web3._extend.utils.toBigNumber(floor.effective);
+				return floor;
+			}
+		}),
+		new web3._extend.Property({
+			name: 'maxNonceGap',
+			getter: 'txpool_maxNonceGap',
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Property({
+			name: 'blocked',
+			getter: 'txpool_blocked'
 		})
 	]
 });
@@ -0,0 +1,50 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package web3ext
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/webchain-network/webchaind/internal/jsre"
+)
+
+// TestModules loads every module into web3.js, as the console does.
+func TestModules(t *testing.T) {
+	re := jsre.New("", ioutil.Discard)
+	defer re.Stop(false)
+
+	if err := re.Compile("bignumber.js", jsre.BigNumber_JS); err != nil {
+		t.Fatalf("bignumber.js: %v", err)
+	}
+	if err := re.Compile("web3.js", jsre.Web3_JS); err != nil {
+		t.Fatalf("web3.js: %v", err)
+	}
+	if _, err := re.Run("var Web3 = require('web3'); var web3 = new Web3();"); err != nil {
+		t.Fatalf("web3: %v", err)
+	}
+	for api, file := range Modules {
+		if err := re.Compile(fmt.Sprintf("%s.js", api), file); err != nil {
+			t.Errorf("%s.js: %v", api, err)
+			continue
+		}
+		if v, err := re.Run(fmt.Sprintf("web3.%s", api)); err != nil || !v.IsObject() {
+			t.Errorf("%s.js: web3.%s not defined (%v)", api, api, err)
+		}
+	}
+}
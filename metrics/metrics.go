@@ -88,6 +88,16 @@ var (
 	DLStateTimer    = metrics.NewRegisteredTimer("download/state", reg)
 	DLStateDrops    = metrics.NewRegisteredMeter("download/state/drop", reg)
 	DLStateTimeouts = metrics.NewRegisteredMeter("download/state/timeout", reg)
+
+	DLHeaderProcessTimer = metrics.NewRegisteredTimer("download/header/process", reg)
+	DLImports            = metrics.NewRegisteredMeter("download/import", reg)
+	DLImportTimer        = metrics.NewRegisteredTimer("download/import", reg)
+
+	DLHeaderQueue  = metrics.NewRegisteredGauge("download/queue/header", reg)
+	DLBodyQueue    = metrics.NewRegisteredGauge("download/queue/body", reg)
+	DLReceiptQueue = metrics.NewRegisteredGauge("download/queue/receipt", reg)
+	DLResultQueue  = metrics.NewRegisteredGauge("download/queue/result", reg)
+	DLImportQueue  = metrics.NewRegisteredGauge("download/queue/import", reg)
 )
 
 var (
@@ -106,6 +116,8 @@ var (
 	FetchAnnounceTimer = metrics.NewRegisteredTimer("fetch/announce", reg)
 	FetchAnnounceDrops = metrics.NewRegisteredMeter("fetch/announce/drop", reg)
 	FetchAnnounceDOS   = metrics.NewRegisteredMeter("fetch/announce/dos", reg)
+	FetchAnnounceDups  = metrics.NewRegisteredMeter("fetch/announce/dup", reg)
+	FetchAnnounceFails = metrics.NewRegisteredMeter("fetch/announce/fail", reg)
 
 	FetchBroadcasts     = metrics.NewRegisteredMeter("fetch/broadcast", reg)
 	FetchBroadcastTimer = metrics.NewRegisteredTimer("fetch/broadcast", reg)
@@ -113,6 +125,23 @@ var (
 	FetchBroadcastDOS   = metrics.NewRegisteredMeter("fetch/broadcast/dos", reg)
 )
 
+var (
+	ForkCheckProblems = metrics.GetOrRegisterGauge("forkcheck/problems", reg)
+	ForkCheckFailures = metrics.NewRegisteredMeter("forkcheck/failures", reg)
+)
+
+var (
+	HandshakeVersionRejects = metrics.NewRegisteredMeter("handshake/reject/version", reg)
+	HandshakeNetworkRejects = metrics.NewRegisteredMeter("handshake/reject/network", reg)
+	HandshakeForkRejects    = metrics.NewRegisteredMeter("handshake/reject/fork", reg)
+)
+
+var (
+	MinerEmptyImmediate = metrics.NewRegisteredMeter("miner/empty/immediate", reg)
+	MinerEmptyFilled    = metrics.NewRegisteredMeter("miner/empty/filled", reg)
+	MinerEmptyDeadline  = metrics.NewRegisteredMeter("miner/empty/deadline", reg)
+)
+
 var (
 	P2PIn       = metrics.NewRegisteredMeter("p2p/in", reg)
 	P2PInBytes  = metrics.NewRegisteredMeter("p2p/in/bytes", reg)
@@ -120,6 +149,20 @@ var (
 	P2POutBytes = metrics.NewRegisteredMeter("p2p/out/bytes", reg)
 )
 
+var (
+	CacheTrieCleanHits   = metrics.NewRegisteredMeter("cache/trie/clean/hit", reg)
+	CacheTrieCleanMisses = metrics.NewRegisteredMeter("cache/trie/clean/miss", reg)
+	CacheTrieCleanSize   = metrics.GetOrRegisterGauge("cache/trie/clean/size", reg)
+
+	TrieCommitDedupHits   = metrics.NewRegisteredMeter("trie/commit/dedup/hit", reg)
+	TrieCommitDedupMisses = metrics.NewRegisteredMeter("trie/commit/dedup/miss", reg)
+	TrieCommitDedupSize   = metrics.GetOrRegisterGauge("trie/commit/dedup/size", reg)
+
+	CacheSnapshotHits   = metrics.NewRegisteredMeter("cache/snapshot/hit", reg)
+	CacheSnapshotMisses = metrics.NewRegisteredMeter("cache/snapshot/miss", reg)
+	CacheSnapshotSize   = metrics.GetOrRegisterGauge("cache/snapshot/size", reg)
+)
+
 var (
 	MemAllocs = metrics.GetOrRegisterGauge("memory/allocs", reg)
 	MemFrees  = metrics.GetOrRegisterGauge("memory/frees", reg)
@@ -148,6 +191,25 @@ func UpdateSysMetrics() {
 	NumGoRoutines.Update(int64(runtime.NumGoroutine()))
 }
 
+// RegisterGaugeFunc registers a gauge reporting the value of f, replacing any
+// gauge previously registered under the same name.
+func RegisterGaugeFunc(name string, f func() int64) {
+	reg.Unregister(name)
+	metrics.NewRegisteredFunctionalGauge(name, reg, f)
+}
+
+// GetOrRegisterMeter returns the meter registered under name, registering a
+// new one if there is none.
+func GetOrRegisterMeter(name string) metrics.Meter {
+	return metrics.GetOrRegisterMeter(name, reg)
+}
+
+// GetOrRegisterTimer returns the timer registered under name, registering a
+// new one if there is none.
+func GetOrRegisterTimer(name string) metrics.Timer {
+	return metrics.GetOrRegisterTimer(name, reg)
+}
+
 func CollectToJSON() ([]byte, error) {
 	UpdateSysMetrics()
 
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"math/big"
 	"sync/atomic"
+	"time"
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/core"
@@ -109,6 +110,27 @@ func (m *Miner) SetGasPrice(price *big.Int) error {
 	return nil
 }
 
+// GasPrice returns the minimum gas price of the transactions the miner includes.
+func (m *Miner) GasPrice() *big.Int {
+	return m.worker.getGasPrice()
+}
+
+// EmptyBlockPolicy decides how the miner seals blocks without transactions.
+// Sealing them immediately keeps the chain moving, waiting for transactions
+// earns their fees.
+type EmptyBlockPolicy struct {
+	Wait     bool          // Hold back empty blocks waiting for transactions
+	Deadline time.Duration // Maximum time to wait before sealing an empty block
+}
+
+// SetEmptyBlockPolicy sets the policy applied to work committed from now on.
+func (m *Miner) SetEmptyBlockPolicy(policy EmptyBlockPolicy) {
+	m.worker.currentMu.Lock()
+	defer m.worker.currentMu.Unlock()
+
+	m.worker.emptyBlocks = policy
+}
+
 func (self *Miner) Start(coinbase common.Address, threads int) {
 	atomic.StoreInt32(&self.shouldStart, 1)
 	self.threads = threads
@@ -174,6 +196,13 @@ func (self *Miner) Pending() (*types.Block, *state.StateDB) {
 	return self.worker.pending()
 }
 
+// PendingBlock returns the pending block assembled as it would be sealed, the
+// receipts of its transactions, and the reward for the block and its uncles,
+// which doesn't include the transaction fees.
+func (self *Miner) PendingBlock() (*types.Block, types.Receipts, *big.Int) {
+	return self.worker.assemble()
+}
+
 func (self *Miner) SetEtherbase(addr common.Address) {
 	self.coinbase = addr
 	self.worker.setEtherbase(addr)
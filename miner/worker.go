@@ -17,7 +17,6 @@
 package miner
 
 import (
-	"fmt"
 	"log"
 	"math/big"
 	"sync"
@@ -34,6 +33,7 @@ import (
 	"github.com/webchain-network/webchaind/event"
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/metrics"
 	"gopkg.in/fatih/set.v0"
 )
 
@@ -61,12 +61,13 @@ type uint64RingBuffer struct {
 type Work struct {
 	config             *core.ChainConfig
 	signer             types.Signer
-	state              *state.StateDB // apply state changes here
-	ancestors          *set.Set       // ancestor set (used for checking uncle parent validity)
-	family             *set.Set       // family set (used for checking uncle invalidity)
-	uncles             *set.Set       // uncle set
-	remove             *set.Set       // tx which will be removed
-	tcount             int            // tx count in cycle
+	state              *state.StateDB  // apply state changes here
+	ancestors          *set.Set        // ancestor set (used for checking uncle parent validity)
+	family             *set.Set        // family set (used for checking uncle invalidity)
+	uncles             *set.Set        // uncle set
+	uncleHeaders       []*types.Header // uncles in the order they were committed
+	remove             *set.Set        // tx which will be removed
+	tcount             int             // tx count in cycle
 	ignoredTransactors *set.Set
 	lowGasTransactors  *set.Set
 	ownedAccounts      *set.Set
@@ -78,6 +79,7 @@ type Work struct {
 	header   *types.Header
 	txs      []*types.Transaction
 	receipts []*types.Receipt
+	gasPool  *core.GasPool // gas available to further transactions in the block
 
 	createdAt time.Time
 }
@@ -111,6 +113,17 @@ type worker struct {
 
 	currentMu sync.Mutex
 	current   *Work
+	// pendingWork is the continuously updated pending block environment, with
+	// incoming transactions applied as they arrive. It is the current work
+	// unless mining, when it's a copy. pendingBlock caches its block.
+	pendingWork  *Work
+	pendingBlock *types.Block
+
+	// emptyBlocks decides whether work without transactions is held back,
+	// held is the work waiting for transactions until heldTimer fires.
+	emptyBlocks EmptyBlockPolicy
+	held        *Work
+	heldTimer   *time.Timer
 
 	uncleMu        sync.Mutex
 	possibleUncles map[common.Hash]*types.Block
@@ -155,19 +168,49 @@ func (self *worker) setEtherbase(addr common.Address) {
 	self.coinbase = addr
 }
 
+// pending returns the pre-executed pending block and a copy of its state.
 func (self *worker) pending() (*types.Block, *state.StateDB) {
 	self.currentMu.Lock()
 	defer self.currentMu.Unlock()
 
-	if atomic.LoadInt32(&self.mining) == 0 {
-		return types.NewBlock(
-			self.current.header,
-			self.current.txs,
-			nil,
-			self.current.receipts,
-		), self.current.state
+	work := self.pendingWork
+	if self.pendingBlock == nil {
+		self.pendingBlock = types.NewBlock(work.header, work.txs, nil, work.receipts)
 	}
-	return self.current.Block, self.current.state.Copy()
+	return self.pendingBlock, work.state.Copy()
+}
+
+// assemble returns the pending block as it would be sealed, with its uncles and
+// state root, along with the receipts of its transactions and the reward its
+// coinbase is credited for the block and the uncles it includes.
+func (self *worker) assemble() (*types.Block, types.Receipts, *big.Int) {
+	self.currentMu.Lock()
+	defer self.currentMu.Unlock()
+
+	work := self.pendingWork
+	uncles := work.uncleHeaders
+	header := types.CopyHeader(work.header)
+	statedb := work.state.Copy()
+
+	era := core.GetBlockEra(header.Number, core.EraLength)
+	reward := core.GetBlockWinnerRewardByEra(era)
+	reward.Add(reward, core.GetBlockWinnerRewardForUnclesByEra(era, uncles))
+
+	core.AccumulateRewards(work.config, statedb, header, uncles)
+	header.Root = statedb.IntermediateRoot(self.config.IsStateClear(header.Number))
+
+	receipts := append(types.Receipts(nil), work.receipts...)
+	return types.NewBlock(header, work.txs, uncles, receipts), receipts, reward
+}
+
+// setPending sets the work which incoming transactions are applied to. It must
+// be called before block rewards are applied. The mined work must not change,
+// so the pending environment is a copy while mining.
+func (self *worker) setPending(work *Work) {
+	if atomic.LoadInt32(&self.mining) == 1 {
+		work = work.copy()
+	}
+	self.pendingWork, self.pendingBlock = work, nil
 }
 
 func (self *worker) start() {
@@ -227,11 +270,16 @@ func (self *worker) update() {
 			self.possibleUncles[ev.Block.Hash()] = ev.Block
 			self.uncleMu.Unlock()
 		case core.TxPreEvent:
-			// Apply transaction to the pending state if we're not mining
-			if atomic.LoadInt32(&self.mining) == 0 {
-				self.currentMu.Lock()
-				self.current.commitTransactions(self.mux, types.Transactions{ev.Tx}, self.gasPrice, self.chain)
-				self.currentMu.Unlock()
+			// Apply transaction to the pending state
+			self.currentMu.Lock()
+			self.pendingWork.commitTransactions(self.mux, types.Transactions{ev.Tx}, self.gasPrice, self.chain)
+			self.pendingBlock = nil
+			fill := self.held != nil && len(self.pendingWork.txs) > 0
+			self.currentMu.Unlock()
+
+			// Replace the empty work held back with one including the transaction
+			if fill {
+				self.commitNewWork()
 			}
 		}
 	}
@@ -267,7 +315,7 @@ func (self *worker) wait() {
 				}
 				go self.mux.Post(core.NewMinedBlockEvent{Block: block})
 			} else {
-				work.state.CommitTo(self.chainDb, work.config.IsAtlantis(block.Number()))
+				work.state.CommitTo(self.chainDb, work.config.IsStateClear(block.Number()))
 				parent := self.chain.GetBlock(block.ParentHash())
 				if parent == nil {
 					glog.V(logger.Error).Infoln("Invalid block found during mining")
@@ -296,6 +344,14 @@ func (self *worker) wait() {
 					log.BlockHash = block.Hash()
 				}
 
+				// index the contracts created
+				if err := core.WriteBlockContractCreations(self.chainDb, block, work.state); err != nil {
+					glog.V(logger.Warn).Infoln("error writing contract creations:", err)
+				}
+				if err := core.WriteBlockRevertData(self.chainDb, block.Hash(), work.receipts); err != nil {
+					glog.V(logger.Warn).Infoln("error writing revert data:", err)
+				}
+
 				// check if canon block and write transactions
 				if stat == core.CanonStatTy {
 					// This puts transactions in a extra db for rpc
@@ -374,6 +430,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 		family:    set.New(),
 		uncles:    set.New(),
 		header:    header,
+		gasPool:   new(core.GasPool).AddGas(header.GasLimit),
 		createdAt: time.Now(),
 	}
 
@@ -404,13 +461,20 @@ func (w *worker) setGasPrice(p *big.Int) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	// calculate the minimal gas price the miner accepts when sorting out transactions.
-	const pct = int64(90)
-	w.gasPrice = gasprice(p, pct)
+	// the miner doesn't include transactions paying less than the price, apart
+	// from those of owned accounts.
+	w.gasPrice = new(big.Int).Set(p)
 
 	w.mux.Post(core.GasPriceChanged{Price: w.gasPrice})
 }
 
+func (w *worker) getGasPrice() *big.Int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return new(big.Int).Set(w.gasPrice)
+}
+
 func (self *worker) isBlockLocallyMined(current *Work, deepBlockNum uint64) bool {
 	//Did this instance mine a block at {deepBlockNum} ?
 	var isLocal = false
@@ -469,16 +533,32 @@ func (self *worker) commitNewWork() {
 		time.Sleep(wait)
 	}
 
+	// Reuse the pre-executed pending environment if it's still on top of the chain,
+	// so only transactions which arrived in the meantime need to be applied. Its
+	// transactions ran with the header's timestamp and difficulty, so once any
+	// were applied it's only reused while those are current.
+	if pending := self.pendingWork; pending != nil && pending.header.ParentHash == parent.Hash() && pending.header.Coinbase == self.coinbase &&
+		(len(pending.txs) == 0 || pending.header.Time.Int64() == tstamp) {
+		pending.header.Time = big.NewInt(tstamp)
+		pending.header.Difficulty = core.CalcDifficulty(self.config, uint64(tstamp), parent.Header())
+		pending.uncles, pending.uncleHeaders = set.New(), nil
+		pending.lowGasTxs = nil
+		pending.createdAt = time.Now()
+		self.commitWork(pending, self.current, tstart)
+		return
+	}
+
 	num := parent.Number()
 	header := &types.Header{
 		ParentHash: parent.Hash(),
 		Number:     num.Add(num, common.Big1),
 		Difficulty: core.CalcDifficulty(self.config, uint64(tstamp), parent.Header()),
-		GasLimit:   core.CalcGasLimit(parent),
+		GasLimit:   core.CalcGasLimit(self.config, parent),
 		GasUsed:    new(big.Int),
 		Coinbase:   self.coinbase,
 		Extra:      HeaderExtra,
 		Time:       big.NewInt(tstamp),
+		BaseFee:    core.CalcBaseFee(self.config, parent.Header()),
 	}
 	previous := self.current
 	// Could potentially happen if starting to mine in an odd state.
@@ -487,8 +567,15 @@ func (self *worker) commitNewWork() {
 		glog.V(logger.Info).Infoln("Could not create new env for mining, retrying on next block.")
 		return
 	}
-	// Create the current work task and check any fork transitions needed
-	work := self.current
+	self.commitWork(self.current, previous, tstart)
+}
+
+// commitWork fills the current work with the pool's transactions and uncles,
+// and pushes it to the mining agents.
+func (self *worker) commitWork(work *Work, previous *Work, tstart time.Time) {
+	held := self.release()
+	self.current = work
+	header := work.header
 
 	/* //approach 1
 	transactions := self.eth.TxPool().GetTransactions()
@@ -526,7 +613,7 @@ func (self *worker) commitNewWork() {
 	transactions := append(singleTxOwner, multiTxOwner...)
 	*/
 
-	work.commitTransactions(self.mux, transactions, self.gasPrice, self.chain)
+	work.commitTransactions(self.mux, work.unapplied(transactions), self.gasPrice, self.chain)
 	self.eth.TxPool().RemoveTransactions(work.lowGasTxs)
 
 	// compute uncles for the new block.
@@ -552,11 +639,14 @@ func (self *worker) commitNewWork() {
 	for _, hash := range badUncles {
 		delete(self.possibleUncles, hash)
 	}
+	work.uncleHeaders = uncles
+
+	self.setPending(work)
 
 	if atomic.LoadInt32(&self.mining) == 1 {
 		// commit state root after all state transitions.
 		core.AccumulateRewards(work.config, work.state, header, uncles)
-		header.Root = work.state.IntermediateRoot(self.config.IsAtlantis(header.Number))
+		header.Root = work.state.IntermediateRoot(self.config.IsStateClear(header.Number))
 	}
 
 	// create the new block whose nonce will be mined.
@@ -575,10 +665,92 @@ func (self *worker) commitNewWork() {
 		}
 		glog.V(logger.Info).Infof("commit new work on block %v with %d txs & %d uncles. Took %v\n", work.Block.Number(), work.tcount, len(uncles), elapsed)
 		self.logLocalMinedBlocks(work, previous)
+
+		if len(work.txs) == 0 {
+			if self.emptyBlocks.Wait && self.emptyBlocks.Deadline > 0 {
+				self.hold(work)
+				return
+			}
+			metrics.MinerEmptyImmediate.Mark(1)
+		} else if held != nil && held.header.ParentHash == header.ParentHash {
+			metrics.MinerEmptyFilled.Mark(1)
+		}
 	}
 	self.push(work)
 }
 
+// hold keeps back work without transactions until transactions arrive or the
+// deadline of the empty block policy passes, when it's sealed empty.
+func (self *worker) hold(work *Work) {
+	glog.V(logger.Debug).Infof("holding back empty block %v for up to %v", work.Block.Number(), self.emptyBlocks.Deadline)
+	self.held = work
+	self.heldTimer = time.AfterFunc(self.emptyBlocks.Deadline, func() {
+		// The agents may block receiving work, which mustn't hold up pending
+		// state updates. Holding mu keeps newer work from being pushed first.
+		self.mu.Lock()
+		defer self.mu.Unlock()
+
+		self.currentMu.Lock()
+		if self.held != work {
+			self.currentMu.Unlock()
+			return
+		}
+		self.held, self.heldTimer = nil, nil
+		self.currentMu.Unlock()
+
+		metrics.MinerEmptyDeadline.Mark(1)
+		self.push(work)
+	})
+}
+
+// release drops the work held back, returning it.
+func (self *worker) release() *Work {
+	held := self.held
+	if self.heldTimer != nil {
+		self.heldTimer.Stop()
+	}
+	self.held, self.heldTimer = nil, nil
+	return held
+}
+
+// copy returns a copy of the work whose state and transactions can be extended
+// independently.
+func (env *Work) copy() *Work {
+	cpy := *env
+	cpy.state = env.state.Copy()
+	cpy.header = types.CopyHeader(env.header)
+	cpy.txs = append([]*types.Transaction(nil), env.txs...)
+	cpy.receipts = append([]*types.Receipt(nil), env.receipts...)
+	cpy.lowGasTxs = append(types.Transactions(nil), env.lowGasTxs...)
+	cpy.uncles = env.uncles.Copy().(*set.Set)
+	cpy.uncleHeaders = append([]*types.Header(nil), env.uncleHeaders...)
+	cpy.remove = env.remove.Copy().(*set.Set)
+	cpy.ignoredTransactors = env.ignoredTransactors.Copy().(*set.Set)
+	cpy.lowGasTransactors = env.lowGasTransactors.Copy().(*set.Set)
+	gp := *env.gasPool
+	cpy.gasPool = &gp
+	cpy.Block = nil
+	return &cpy
+}
+
+// unapplied filters the transactions already applied to the work.
+func (env *Work) unapplied(transactions types.Transactions) types.Transactions {
+	if len(env.txs) == 0 {
+		return transactions
+	}
+	applied := make(map[common.Hash]bool, len(env.txs))
+	for _, tx := range env.txs {
+		applied[tx.Hash()] = true
+	}
+	var remaining types.Transactions
+	for _, tx := range transactions {
+		if !applied[tx.Hash()] {
+			remaining = append(remaining, tx)
+		}
+	}
+	return remaining
+}
+
 func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 	hash := uncle.Hash()
 	var e error
@@ -596,11 +768,11 @@ func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 		return e
 	}
 	if !work.ancestors.Has(uncle.ParentHash) {
-		e = core.UncleError(fmt.Sprintf("Uncle's parent unknown (%x)", uncle.ParentHash[0:4]))
+		e = core.UncleError("Uncle's parent unknown (%x)", uncle.ParentHash[0:4])
 		return e
 	}
 	if work.family.Has(hash) {
-		e = core.UncleError(fmt.Sprintf("Uncle already in family (%x)", hash))
+		e = core.UncleError("Uncle already in family (%x)", hash)
 		return e
 	}
 	work.uncles.Add(uncle.Hash())
@@ -608,7 +780,7 @@ func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 }
 
 func (env *Work) commitTransactions(mux *event.TypeMux, transactions types.Transactions, gasPrice *big.Int, bc *core.BlockChain) {
-	gp := new(core.GasPool).AddGas(env.header.GasLimit)
+	gp := env.gasPool
 
 	var coalescedLogs vm.Logs
 	for _, tx := range transactions {
@@ -624,14 +796,23 @@ func (env *Work) commitTransactions(mux *event.TypeMux, transactions types.Trans
 			continue
 		}
 
-		// Check if it falls within margin. Txs from owned accounts are always processed.
-		if tx.GasPrice().Cmp(gasPrice) < 0 && !env.ownedAccounts.Has(from) {
+		// Transactions not covering the base fee may be included once it drops,
+		// skip their transactor for this block.
+		tip := tx.EffectiveGasTip(env.header.BaseFee)
+		if tip == nil {
+			env.ignoredTransactors.Add(from)
+			continue
+		}
+
+		// Check if it falls within margin, under the fee market the miner's gas
+		// price is the minimum priority fee. Txs from owned accounts are always processed.
+		if tip.Cmp(gasPrice) < 0 && !env.ownedAccounts.Has(from) {
 			// ignore the transaction and transactor. We ignore the transactor
 			// because nonce will fail after ignoring this transaction so there's
 			// no point
 			env.lowGasTransactors.Add(from)
 
-			glog.V(logger.Info).Infof("transaction(%x) below gas price (tx=%v ask=%v). All sequential txs from this address(%x) will be ignored\n", tx.Hash().Bytes()[:4], common.CurrencyToString(tx.GasPrice()), common.CurrencyToString(gasPrice), from[:4])
+			glog.V(logger.Info).Infof("transaction(%x) below gas price (tx=%v ask=%v). All sequential txs from this address(%x) will be ignored\n", tx.Hash().Bytes()[:4], common.CurrencyToString(tip), common.CurrencyToString(gasPrice), from[:4])
 		}
 
 		// Continue with the next transaction if the transaction sender is included in
@@ -721,13 +902,6 @@ func (self *worker) HashRate() int64 {
 
 // gasprice calculates a reduced gas price based on the pct
 // XXX Use big.Rat?
-func gasprice(price *big.Int, pct int64) *big.Int {
-	p := new(big.Int).Set(price)
-	p.Div(p, big.NewInt(100))
-	p.Mul(p, big.NewInt(pct))
-	return p
-}
-
 func accountAddressesSet(accounts []accounts.Account) *set.Set {
 	accountSet := set.New()
 	for _, account := range accounts {
@@ -0,0 +1,340 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/webchain-network/webchaind/accounts"
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/event"
+)
+
+var (
+	testBankKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testBankAddress = crypto.PubkeyToAddress(testBankKey.PublicKey)
+	testCoinbase    = common.HexToAddress("0x0000000000000000000000000000000000c0ffee")
+)
+
+// testBackend is the core.Backend the worker of the tests runs on.
+type testBackend struct {
+	am    *accounts.Manager
+	chain *core.BlockChain
+	pool  *core.TxPool
+	db    ethdb.Database
+	mux   *event.TypeMux
+	dir   string // keystore directory
+}
+
+func (b *testBackend) AccountManager() *accounts.Manager { return b.am }
+func (b *testBackend) BlockChain() *core.BlockChain      { return b.chain }
+func (b *testBackend) TxPool() *core.TxPool              { return b.pool }
+func (b *testBackend) ChainDb() ethdb.Database           { return b.db }
+func (b *testBackend) DappDb() ethdb.Database            { return b.db }
+func (b *testBackend) EventMux() *event.TypeMux          { return b.mux }
+
+// newTestBackend creates a backend whose chain has n blocks on top of a genesis
+// funding the test bank. The chain posts its events to a mux of its own, so
+// the worker only sees the events a test posts.
+func newTestBackend(t *testing.T, n int) *testBackend {
+	dir, err := ioutil.TempDir("", "worker-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	am, err := accounts.NewManager(dir, accounts.LightScryptN, accounts.LightScryptP, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, _ := ethdb.NewMemDatabase()
+	genesis := core.WriteGenesisBlockForTesting(db, core.GenesisAccount{Address: testBankAddress, Balance: big.NewInt(1e18)})
+	config := core.DefaultConfigMorden.ChainConfig
+	chain, err := core.NewBlockChain(db, config, new(core.FakePow), new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, _ := core.GenerateChain(config, genesis, db, n, nil)
+	if res := chain.InsertChain(blocks); res.Error != nil {
+		t.Fatal(res.Error)
+	}
+	mux := new(event.TypeMux)
+	pool := core.NewTxPool(config, mux, chain.State, chain.GasLimit)
+	return &testBackend{am: am, chain: chain, pool: pool, db: db, mux: mux, dir: dir}
+}
+
+func (b *testBackend) close() {
+	b.mux.Stop()
+	b.pool.Stop()
+	os.RemoveAll(b.dir)
+}
+
+// newTestWorker creates a worker mining for the test coinbase on the backend.
+func newTestWorker(t *testing.T, backend *testBackend) *worker {
+	return newWorker(backend.chain.Config(), testCoinbase, backend)
+}
+
+// sideBlock creates a block on top of the genesis, which is not part of the
+// canonical chain and qualifies as an uncle.
+func sideBlock(backend *testBackend, coinbase common.Address, extra string) *types.Block {
+	genesis := backend.chain.Genesis()
+	blocks, _ := core.GenerateChain(backend.chain.Config(), genesis, backend.db, 1, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(coinbase)
+		b.SetExtra([]byte(extra))
+	})
+	return blocks[0]
+}
+
+func signTx(t *testing.T, nonce uint64) *types.Transaction {
+	tx, err := types.NewTransaction(nonce, common.HexToAddress("0x01"), big.NewInt(1), core.TxGas, big.NewInt(1), nil).SignECDSA(testBankKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+func TestAssemblePendingBlock(t *testing.T) {
+	backend := newTestBackend(t, 2)
+	defer backend.close()
+	w := newTestWorker(t, backend)
+
+	if err := backend.pool.Add(signTx(t, 0)); err != nil {
+		t.Fatal(err)
+	}
+	// The uncles pay the coinbase as well, which mustn't count as block reward.
+	for _, uncle := range []*types.Block{sideBlock(backend, testCoinbase, "a"), sideBlock(backend, testCoinbase, "b")} {
+		w.possibleUncles[uncle.Hash()] = uncle
+	}
+	w.commitNewWork()
+
+	w.currentMu.Lock()
+	committed := w.current.Block
+	w.currentMu.Unlock()
+	if len(committed.Uncles()) != 2 {
+		t.Fatalf("committed uncles: got %d, want 2", len(committed.Uncles()))
+	}
+
+	for i := 0; i < 10; i++ {
+		block, receipts, reward := w.assemble()
+		if block.UncleHash() != committed.UncleHash() {
+			t.Fatalf("uncles assembled in another order than committed")
+		}
+		if len(block.Transactions()) != 1 || len(receipts) != 1 {
+			t.Fatalf("transactions: got %d, receipts %d, want 1", len(block.Transactions()), len(receipts))
+		}
+		era := core.GetBlockEra(block.Number(), core.EraLength)
+		want := core.GetBlockWinnerRewardByEra(era)
+		want.Add(want, core.GetBlockWinnerRewardForUnclesByEra(era, block.Uncles()))
+		if reward.Cmp(want) != 0 {
+			t.Fatalf("reward: got %v, want %v", reward, want)
+		}
+	}
+
+	// The assembled block is the one the worker would seal, so it's valid.
+	block, _, _ := w.assemble()
+	if res := backend.chain.InsertChain(types.Blocks{block}); res.Error != nil {
+		t.Fatalf("assembled block invalid: %v", res.Error)
+	}
+}
+
+// waitPending waits for the pending work to include n transactions.
+func waitPending(t *testing.T, w *worker, n int) {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		w.currentMu.Lock()
+		have := len(w.pendingWork.txs)
+		w.currentMu.Unlock()
+		if have == n {
+			return
+		}
+	}
+	t.Fatalf("pending work doesn't include %d transactions", n)
+}
+
+// staleWork backdates the header of the pending work, as if it was created
+// some time ago, and returns the work.
+func staleWork(w *worker) *Work {
+	w.currentMu.Lock()
+	defer w.currentMu.Unlock()
+
+	parent := w.chain.CurrentBlock()
+	w.pendingWork.header.Time = new(big.Int).Add(parent.Time(), common.Big1)
+	w.pendingWork.header.Difficulty = core.CalcDifficulty(w.config, w.pendingWork.header.Time.Uint64(), parent.Header())
+	return w.pendingWork
+}
+
+// checkFresh checks that the pending work has a current timestamp and the
+// difficulty of it.
+func checkFresh(t *testing.T, w *worker, start time.Time) {
+	w.currentMu.Lock()
+	defer w.currentMu.Unlock()
+
+	header := w.pendingWork.header
+	if now := time.Now().Unix(); header.Time.Int64() < start.Unix() || header.Time.Int64() > now {
+		t.Errorf("timestamp %v not within %d-%d", header.Time, start.Unix(), now)
+	}
+	if want := core.CalcDifficulty(w.config, header.Time.Uint64(), w.chain.CurrentHeader()); header.Difficulty.Cmp(want) != 0 {
+		t.Errorf("difficulty: got %v, want %v", header.Difficulty, want)
+	}
+}
+
+func TestCommitNewWorkReusesPending(t *testing.T) {
+	backend := newTestBackend(t, 2)
+	defer backend.close()
+	w := newTestWorker(t, backend)
+
+	// Work without transactions is reused with a refreshed header.
+	stale := staleWork(w)
+	start := time.Now()
+	w.commitNewWork()
+	if w.pendingWork != stale {
+		t.Fatal("pending work not reused")
+	}
+	checkFresh(t, w, start)
+
+	// Transactions applied with a stale header need another run.
+	if err := backend.pool.Add(signTx(t, 0)); err != nil {
+		t.Fatal(err)
+	}
+	waitPending(t, w, 1)
+	stale = staleWork(w)
+	start = time.Now()
+	w.commitNewWork()
+	if w.pendingWork == stale {
+		t.Fatal("pending work with transactions of a stale header reused")
+	}
+	checkFresh(t, w, start)
+	waitPending(t, w, 1)
+}
+
+// testAgent collects the work pushed to it.
+type testAgent struct {
+	work chan *Work
+}
+
+func (a *testAgent) Work() chan<- *Work         { return a.work }
+func (a *testAgent) SetReturnCh(chan<- *Result) {}
+func (a *testAgent) Stop()                      {}
+func (a *testAgent) Start()                     {}
+func (a *testAgent) GetHashRate() int64         { return 0 }
+
+// newMiningWorker creates a worker mining with the given empty block policy,
+// pushing its work to the returned agent.
+func newMiningWorker(t *testing.T, backend *testBackend, policy EmptyBlockPolicy) (*worker, *testAgent) {
+	w := newTestWorker(t, backend)
+	w.emptyBlocks = policy
+	agent := &testAgent{work: make(chan *Work, 10)}
+	w.register(agent)
+	w.start()
+	return w, agent
+}
+
+// holding reports whether the worker holds back work.
+func holding(w *worker) bool {
+	w.currentMu.Lock()
+	defer w.currentMu.Unlock()
+	return w.held != nil
+}
+
+func TestHoldEmptyWorkFill(t *testing.T) {
+	backend := newTestBackend(t, 2)
+	defer backend.close()
+	w, agent := newMiningWorker(t, backend, EmptyBlockPolicy{Wait: true, Deadline: time.Minute})
+
+	w.commitNewWork()
+	select {
+	case <-agent.work:
+		t.Fatal("empty work pushed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if !holding(w) {
+		t.Fatal("empty work not held")
+	}
+
+	// A transaction arriving replaces the held work with work including it.
+	if err := backend.pool.Add(signTx(t, 0)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case work := <-agent.work:
+		if len(work.txs) != 1 {
+			t.Errorf("pushed work with %d transactions, want 1", len(work.txs))
+		}
+		if now := time.Now().Unix(); work.header.Time.Int64() < now-1 {
+			t.Errorf("pushed work with stale timestamp %v", work.header.Time)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("work not pushed after transaction arrived")
+	}
+	if holding(w) {
+		t.Error("work still held after filling")
+	}
+}
+
+func TestHoldEmptyWorkDeadline(t *testing.T) {
+	backend := newTestBackend(t, 2)
+	defer backend.close()
+	deadline := 50 * time.Millisecond
+	w, agent := newMiningWorker(t, backend, EmptyBlockPolicy{Wait: true, Deadline: deadline})
+
+	// Work replacing held work restarts the deadline, the replaced work is dropped.
+	w.commitNewWork()
+	w.commitNewWork()
+	start := time.Now()
+
+	select {
+	case work := <-agent.work:
+		if elapsed := time.Since(start); elapsed < deadline {
+			t.Errorf("empty work pushed after %v, before the deadline", elapsed)
+		}
+		if len(work.txs) != 0 {
+			t.Errorf("pushed work with %d transactions, want 0", len(work.txs))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("empty work not pushed at the deadline")
+	}
+	select {
+	case <-agent.work:
+		t.Error("replaced work pushed")
+	case <-time.After(2 * deadline):
+	}
+	if holding(w) {
+		t.Error("work still held after the deadline")
+	}
+}
+
+func TestEmptyWorkImmediate(t *testing.T) {
+	backend := newTestBackend(t, 2)
+	defer backend.close()
+	w, agent := newMiningWorker(t, backend, EmptyBlockPolicy{})
+
+	w.commitNewWork()
+	select {
+	case <-agent.work:
+	case <-time.After(time.Second):
+		t.Fatal("empty work not pushed")
+	}
+	if holding(w) {
+		t.Error("empty work held without policy")
+	}
+}
@@ -17,6 +17,7 @@
 package node
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -27,6 +28,8 @@ import (
 	"github.com/webchain-network/webchaind/rpc"
 )
 
+var errDenyListDisabled = errors.New("deny list not configured")
+
 // PrivateAdminAPI is the collection of administrative API methods exposed only
 // over a secure RPC channel.
 type PrivateAdminAPI struct {
@@ -56,6 +59,69 @@ func (api *PrivateAdminAPI) AddPeer(url string) (bool, error) {
 	return true, nil
 }
 
+// DenyPeer bans a node, given as an enode URL or node ID, or an IP address or
+// CIDR range, disconnecting any matching peers. The ban is persisted.
+func (api *PrivateAdminAPI) DenyPeer(target string, reason *string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	var why string
+	if reason != nil {
+		why = *reason
+	}
+	entry, err := p2p.NewDenyEntry(target, why)
+	if err != nil {
+		return false, err
+	}
+	if err := server.Deny(entry); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// AllowPeer lifts a ban placed by DenyPeer, reporting whether there was one.
+func (api *PrivateAdminAPI) AllowPeer(target string) (bool, error) {
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	if server.DenyList == nil {
+		return false, errDenyListDisabled
+	}
+	return server.DenyList.Remove(target)
+}
+
+// ExportDenyList returns all bans, in the format accepted by ImportDenyList.
+func (api *PrivateAdminAPI) ExportDenyList() ([]*p2p.DenyEntry, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	if server.DenyList == nil {
+		return nil, errDenyListDisabled
+	}
+	return server.DenyList.Entries(), nil
+}
+
+// ImportDenyList adds bans exported by another node, disconnecting any
+// matching peers, and returns the number of entries imported.
+func (api *PrivateAdminAPI) ImportDenyList(entries []*p2p.DenyEntry) (int, error) {
+	server := api.node.Server()
+	if server == nil {
+		return 0, ErrNodeStopped
+	}
+	for _, e := range entries {
+		if e == nil {
+			return 0, errors.New("invalid deny list entry")
+		}
+	}
+	if err := server.Deny(entries...); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
 // StartRPC starts the HTTP RPC API server.
 func (api *PrivateAdminAPI) StartRPC(host *string, port *rpc.HexNumber, cors *string, apis *string) (bool, error) {
 	api.node.lock.Lock()
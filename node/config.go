@@ -25,13 +25,16 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/crypto"
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/p2p"
 	"github.com/webchain-network/webchaind/p2p/discover"
 	"github.com/webchain-network/webchaind/p2p/nat"
+	"github.com/webchain-network/webchaind/rpc"
 	"github.com/spf13/afero"
 )
 
@@ -39,7 +42,9 @@ var (
 	datadirPrivateKey   = "nodekey"            // Path within the datadir to the node's private key
 	datadirStaticNodes  = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes = "trusted-nodes.json" // Path within the datadir to the trusted node list
+	datadirDenyList     = "denylist.json"      // Path within the datadir to the banned node list
 	datadirNodeDatabase = "nodes"              // Path within the datadir to store the node infos
+	datadirAPIKeyUsage  = "apikey-usage.json"  // Path within the datadir to the daily requests of the RPC API keys
 )
 
 // fs wraps afero.FS, used as a type of it's own so that we can take it's address
@@ -65,6 +70,10 @@ type Config struct {
 	// relative), then that specific path is enforced. An empty path disables IPC.
 	IPCPath string
 
+	// IPCMessageLimits caps the size of the requests and responses exchanged over
+	// IPC. Zero fields use the defaults of rpc.DefaultMessageLimits.
+	IPCMessageLimits rpc.MessageLimits
+
 	// fs is an abstracted file system.
 	// In normal use, it points to a thin wrapper around the standard os FS package,
 	// and can be swapped for an abstracted in-mem map during tests, which helps
@@ -111,6 +120,14 @@ type Config struct {
 	// Zero defaults to preset values.
 	MaxPendingPeers int
 
+	// DialRatio is the ratio of all peer slots to those filled by dialing out.
+	// Zero defaults to preset values.
+	DialRatio int
+
+	// DialHistoryExpiry is the time to wait before redialing a node. Zero
+	// defaults to preset values.
+	DialHistoryExpiry time.Duration
+
 	// HTTPHost is the host interface on which to start the HTTP RPC server. If this
 	// field is empty, no HTTP API endpoint will be started.
 	HTTPHost string
@@ -130,6 +147,10 @@ type Config struct {
 	// exposed.
 	HTTPModules []string
 
+	// HTTPTimeouts limits how long HTTP RPC clients may take to send requests and
+	// receive responses, and how long idle keep-alive connections are kept.
+	HTTPTimeouts rpc.HTTPTimeouts
+
 	// WSHost is the host interface on which to start the websocket RPC server. If
 	// this field is empty, no websocket API endpoint will be started.
 	WSHost string
@@ -148,6 +169,40 @@ type Config struct {
 	// If the module list is empty, all RPC API endpoints designated public will be
 	// exposed.
 	WSModules []string
+
+	// WSSubscriptionBuffer is the number of notifications a websocket subscription
+	// may have queued before WSSubscriptionPolicy applies. Zero uses the default.
+	WSSubscriptionBuffer int
+
+	// WSSubscriptionPolicy decides whether a websocket subscriber that can't keep up
+	// is disconnected or has notifications dropped.
+	WSSubscriptionPolicy rpc.SubscriptionPolicy
+
+	// WSMessageLimits caps the size of the requests and responses exchanged over
+	// websocket connections. Zero fields use the defaults of rpc.DefaultMessageLimits.
+	WSMessageLimits rpc.MessageLimits
+
+	// RPCConcurrencyLimits caps the number of concurrently executing IPC, HTTP and
+	// websocket requests per namespace or method, e.g. {"debug": 2, "eth_call": 8},
+	// so heavyweight requests can't starve the rest of the node.
+	RPCConcurrencyLimits map[string]int
+
+	// RPCConcurrencyQueue is the number of requests per cap waiting for a free
+	// slot before further ones are rejected as busy.
+	RPCConcurrencyQueue int
+
+	// RPCConcurrencyTimeout is how long a request waits for a free slot before
+	// it's rejected as busy. Zero uses the default.
+	RPCConcurrencyTimeout time.Duration
+
+	// RPCAPIKeys, if any, are required from HTTP and websocket clients, which may
+	// only call the methods of their key up to its daily quota. IPC clients are
+	// trusted.
+	RPCAPIKeys []rpc.APIKey
+
+	// InsecureUnlockAllowed permits account unlocking while the HTTP or websocket
+	// interface is bound to a non-loopback address.
+	InsecureUnlockAllowed bool
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
@@ -212,6 +267,26 @@ func (c *Config) WSEndpoint() string {
 	return fmt.Sprintf("%s:%d", c.WSHost, c.WSPort)
 }
 
+// ExtRPCEnabled reports whether the HTTP or websocket RPC interface is bound to
+// an address reachable from other hosts.
+func (c *Config) ExtRPCEnabled() bool {
+	return isExternalHost(c.HTTPHost) || isExternalHost(c.WSHost)
+}
+
+// AccountUnlockAllowed reports whether accounts may be unlocked, which is
+// refused on externally reachable RPC interfaces unless explicitly allowed.
+func (c *Config) AccountUnlockAllowed() bool {
+	return c.InsecureUnlockAllowed || !c.ExtRPCEnabled()
+}
+
+func isExternalHost(host string) bool {
+	if host == "" || host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
+
 // NodeKey retrieves the currently configured private key of the node, checking
 // first any manually set key, falling back to the one found in the configured
 // data folder. If no key can be found, a new one is generated.
@@ -279,6 +354,21 @@ func (c *Config) TrusterNodes() []*discover.Node {
 	return c.parsePersistentNodes(datadirTrustedNodes)
 }
 
+// DenyList loads the list of banned nodes and IP ranges from the data
+// directory, or creates one kept in memory if there is none.
+func (c *Config) DenyList() *p2p.DenyList {
+	path := ""
+	if c.DataDir != "" {
+		path = filepath.Join(c.DataDir, datadirDenyList)
+	}
+	list, err := p2p.NewDenyList(path)
+	if err != nil {
+		glog.V(logger.Error).Errorf("Failed to load deny list: %v", err)
+		return nil
+	}
+	return list
+}
+
 // parsePersistentNodes parses a list of discovery node URLs loaded from a .json
 // file from within the data directory.
 func (c *Config) parsePersistentNodes(file string) []*discover.Node {
@@ -18,13 +18,16 @@
 package node
 
 import (
+	"context"
 	"errors"
 	"github.com/spf13/afero"
 	"net"
+	"net/http"
 	"path/filepath"
 	"reflect"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/webchain-network/webchaind/event"
 	"github.com/webchain-network/webchaind/logger"
@@ -42,6 +45,10 @@ var (
 	datadirInUseErrnos = map[uint]bool{11: true, 32: true, 35: true}
 )
 
+// httpShutdownTimeout is how long in-flight HTTP RPC requests are given to
+// complete when the endpoint is stopped.
+const httpShutdownTimeout = 5 * time.Second
+
 // Node represents a P2P node into which arbitrary (uniquely typed) services might
 // be registered.
 type Node struct {
@@ -57,25 +64,36 @@ type Node struct {
 	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
 	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
 
-	ipcEndpoint string       // IPC endpoint to listen at (empty = IPC disabled)
-	ipcListener net.Listener // IPC RPC listener socket to serve API requests
-	ipcHandler  *rpc.Server  // IPC RPC request handler to process the API requests
-
-	httpHost      string       // HTTP hostname
-	httpPort      int          // HTTP post
-	httpEndpoint  string       // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
-	httpWhitelist []string     // HTTP RPC modules to allow through this endpoint
-	httpCors      string       // HTTP RPC Cross-Origin Resource Sharing header
-	httpListener  net.Listener // HTTP RPC listener socket to server API requests
-	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
-
-	wsHost      string       // Websocket host
-	wsPort      int          // Websocket post
-	wsEndpoint  string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
-	wsWhitelist []string     // Websocket RPC modules to allow through this endpoint
-	wsOrigins   string       // Websocket RPC allowed origin domains
-	wsListener  net.Listener // Websocket RPC listener socket to server API requests
-	wsHandler   *rpc.Server  // Websocket RPC request handler to process the API requests
+	ipcEndpoint string            // IPC endpoint to listen at (empty = IPC disabled)
+	ipcLimits   rpc.MessageLimits // IPC RPC message size limits
+	ipcListener net.Listener      // IPC RPC listener socket to serve API requests
+	ipcHandler  *rpc.Server       // IPC RPC request handler to process the API requests
+
+	httpHost      string           // HTTP hostname
+	httpPort      int              // HTTP post
+	httpEndpoint  string           // HTTP endpoint (interface + port) to listen at (empty = HTTP disabled)
+	httpWhitelist []string         // HTTP RPC modules to allow through this endpoint
+	httpCors      string           // HTTP RPC Cross-Origin Resource Sharing header
+	httpTimeouts  rpc.HTTPTimeouts // HTTP RPC client connection limits
+	httpListener  net.Listener     // HTTP RPC listener socket to server API requests
+	httpServer    *http.Server     // HTTP RPC server serving the listener
+	httpHandler   *rpc.Server      // HTTP RPC request handler to process the API requests
+
+	wsHost      string                 // Websocket host
+	wsPort      int                    // Websocket post
+	wsEndpoint  string                 // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
+	wsWhitelist []string               // Websocket RPC modules to allow through this endpoint
+	wsOrigins   string                 // Websocket RPC allowed origin domains
+	wsSubBuffer int                    // Websocket RPC notifications queued per subscription
+	wsSubPolicy rpc.SubscriptionPolicy // Websocket RPC handling of slow subscribers
+	wsLimits    rpc.MessageLimits      // Websocket RPC message size limits
+	wsListener  net.Listener           // Websocket RPC listener socket to server API requests
+	wsHandler   *rpc.Server            // Websocket RPC request handler to process the API requests
+
+	rpcLimits *rpc.ConcurrencyLimits // Caps on concurrent IPC, HTTP and websocket requests (none if nil)
+	rpcKeys   *rpc.APIKeys           // API keys required from HTTP and websocket clients (none if nil)
+
+	unlockDenied bool // Whether services must refuse account unlocking (RPC exposed)
 
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
@@ -93,6 +111,24 @@ func New(conf *Config) (*Node, error) {
 			return nil, err
 		}
 	}
+	var limits *rpc.ConcurrencyLimits
+	if len(conf.RPCConcurrencyLimits) > 0 {
+		var err error
+		if limits, err = rpc.NewConcurrencyLimits(conf.RPCConcurrencyLimits, conf.RPCConcurrencyQueue, conf.RPCConcurrencyTimeout); err != nil {
+			return nil, err
+		}
+	}
+	var keys *rpc.APIKeys
+	if len(conf.RPCAPIKeys) > 0 {
+		usagePath := ""
+		if conf.DataDir != "" {
+			usagePath = filepath.Join(conf.DataDir, datadirAPIKeyUsage)
+		}
+		var err error
+		if keys, err = rpc.NewAPIKeys(conf.RPCAPIKeys, usagePath); err != nil {
+			return nil, err
+		}
+	}
 	// Assemble the networking layer and the node itself
 	nodeDbPath := ""
 	if conf.DataDir != "" {
@@ -101,32 +137,43 @@ func New(conf *Config) (*Node, error) {
 	return &Node{
 		datadir: conf.DataDir,
 		serverConfig: p2p.Config{
-			PrivateKey:      conf.NodeKey(),
-			Name:            conf.Name,
-			Discovery:       !conf.NoDiscovery,
-			BootstrapNodes:  conf.BootstrapNodes,
-			StaticNodes:     conf.StaticNodes(),
-			TrustedNodes:    conf.TrusterNodes(),
-			NodeDatabase:    nodeDbPath,
-			ListenAddr:      conf.ListenAddr,
-			NAT:             conf.NAT,
-			Dialer:          conf.Dialer,
-			NoDial:          conf.NoDial,
-			MaxPeers:        conf.MaxPeers,
-			MaxPendingPeers: conf.MaxPendingPeers,
+			PrivateKey:        conf.NodeKey(),
+			Name:              conf.Name,
+			Discovery:         !conf.NoDiscovery,
+			BootstrapNodes:    conf.BootstrapNodes,
+			StaticNodes:       conf.StaticNodes(),
+			TrustedNodes:      conf.TrusterNodes(),
+			DenyList:          conf.DenyList(),
+			NodeDatabase:      nodeDbPath,
+			ListenAddr:        conf.ListenAddr,
+			NAT:               conf.NAT,
+			Dialer:            conf.Dialer,
+			NoDial:            conf.NoDial,
+			MaxPeers:          conf.MaxPeers,
+			MaxPendingPeers:   conf.MaxPendingPeers,
+			DialRatio:         conf.DialRatio,
+			DialHistoryExpiry: conf.DialHistoryExpiry,
 		},
 		serviceFuncs:  []ServiceConstructor{},
 		ipcEndpoint:   conf.IPCEndpoint(),
+		ipcLimits:     conf.IPCMessageLimits,
 		httpHost:      conf.HTTPHost,
 		httpPort:      conf.HTTPPort,
 		httpEndpoint:  conf.HTTPEndpoint(),
 		httpWhitelist: conf.HTTPModules,
 		httpCors:      conf.HTTPCors,
+		httpTimeouts:  conf.HTTPTimeouts,
 		wsHost:        conf.WSHost,
 		wsPort:        conf.WSPort,
 		wsEndpoint:    conf.WSEndpoint(),
 		wsWhitelist:   conf.WSModules,
 		wsOrigins:     conf.WSOrigins,
+		wsSubBuffer:   conf.WSSubscriptionBuffer,
+		wsSubPolicy:   conf.WSSubscriptionPolicy,
+		wsLimits:      conf.WSMessageLimits,
+		rpcLimits:     limits,
+		rpcKeys:       keys,
+		unlockDenied:  !conf.AccountUnlockAllowed(),
 		eventmux:      new(event.TypeMux),
 	}, nil
 }
@@ -159,9 +206,10 @@ func (n *Node) Start() error {
 	for _, constructor := range n.serviceFuncs {
 		// Create a new context for the particular service
 		ctx := &ServiceContext{
-			datadir:  n.datadir,
-			services: make(map[reflect.Type]Service),
-			EventMux: n.eventmux,
+			datadir:      n.datadir,
+			services:     make(map[reflect.Type]Service),
+			unlockDenied: n.unlockDenied,
+			EventMux:     n.eventmux,
 		}
 		for kind, s := range services { // copy needed for threaded access
 			ctx.services[kind] = s
@@ -281,6 +329,7 @@ func (n *Node) startIPC(apis []rpc.API) error {
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetConcurrencyLimits(n.rpcLimits)
 	for _, api := range apis {
 		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
 			return err
@@ -313,7 +362,7 @@ func (n *Node) startIPC(apis []rpc.API) error {
 				glog.V(logger.Error).Infof("IPC accept failed: %v", err)
 				continue
 			}
-			go handler.ServeCodec(rpc.NewJSONCodec(conn), rpc.OptionMethodInvocation|rpc.OptionSubscriptions)
+			go handler.ServeCodec(rpc.NewJSONCodecWithLimits(conn, n.ipcLimits), rpc.OptionMethodInvocation|rpc.OptionSubscriptions)
 		}
 	}()
 	// All listeners booted successfully
@@ -351,6 +400,8 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetConcurrencyLimits(n.rpcLimits)
+	handler.SetAPIKeys(n.rpcKeys)
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -367,13 +418,15 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, handler).Serve(listener)
+	server := rpc.NewHTTPServer(cors, n.httpTimeouts, handler)
+	go server.Serve(listener)
 	glog.V(logger.Info).Infof("HTTP endpoint opened: http://%s", endpoint)
 	glog.D(logger.Warn).Infof("HTTP endpoint: http://%s", logger.ColorGreen(endpoint))
 
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
 	n.httpListener = listener
+	n.httpServer = server
 	n.httpHandler = handler
 	n.httpCors = cors
 
@@ -383,7 +436,14 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 // stopHTTP terminates the HTTP RPC endpoint.
 func (n *Node) stopHTTP() {
 	if n.httpListener != nil {
-		n.httpListener.Close()
+		// Let in-flight requests finish and close idle keep-alive connections,
+		// cutting off the requests still running after the grace period.
+		ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		if err := n.httpServer.Shutdown(ctx); err != nil {
+			n.httpServer.Close()
+		}
+		cancel()
+		n.httpServer = nil
 		n.httpListener = nil
 
 		glog.V(logger.Info).Infof("HTTP endpoint closed: http://%s", n.httpEndpoint)
@@ -408,6 +468,11 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetConcurrencyLimits(n.rpcLimits)
+	handler.SetAPIKeys(n.rpcKeys)
+	if n.wsSubBuffer > 0 {
+		handler.SetSubscriptionLimits(n.wsSubBuffer, n.wsSubPolicy)
+	}
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -424,7 +489,7 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewWSServer(wsOrigins, handler).Serve(listener)
+	go rpc.NewWSServer(wsOrigins, n.wsLimits, handler).Serve(listener)
 	glog.V(logger.Info).Infof("WebSocket endpoint opened: ws://%s", endpoint)
 	glog.D(logger.Warn).Infof("WebSocket endpoint opened: ws://%s", logger.ColorGreen(endpoint))
 
@@ -467,6 +532,11 @@ func (n *Node) Stop() error {
 	n.stopHTTP()
 	n.stopIPC()
 	n.rpcAPIs = nil
+	if n.rpcKeys != nil {
+		if err := n.rpcKeys.Flush(); err != nil {
+			glog.V(logger.Error).Infof("Failed to save API key usage: %v", err)
+		}
+	}
 
 	failure := &StopError{
 		Services: make(map[reflect.Type]error),
@@ -30,9 +30,10 @@ import (
 // the protocol stack, that is passed to all constructors to be optionally used;
 // as well as utility methods to operate on the service environment.
 type ServiceContext struct {
-	datadir  string                   // Data directory for protocol persistence
-	services map[reflect.Type]Service // Index of the already constructed services
-	EventMux *event.TypeMux           // Event multiplexer used for decoupled notifications
+	datadir      string                   // Data directory for protocol persistence
+	services     map[reflect.Type]Service // Index of the already constructed services
+	unlockDenied bool                     // Whether account unlocking is refused on exposed RPC interfaces
+	EventMux     *event.TypeMux           // Event multiplexer used for decoupled notifications
 }
 
 // OpenDatabase opens an existing database with the given name (or creates one
@@ -45,6 +46,13 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (et
 	return ethdb.NewLDBDatabase(filepath.Join(ctx.datadir, name), cache, handles)
 }
 
+// AccountUnlockAllowed reports whether services may unlock accounts on request.
+// Unlocking is refused while HTTP or websocket RPC is reachable from other hosts,
+// unless the node was configured to allow it.
+func (ctx *ServiceContext) AccountUnlockAllowed() bool {
+	return !ctx.unlockDenied
+}
+
 // Service retrieves a currently running service registered of a specific type.
 func (ctx *ServiceContext) Service(service interface{}) error {
 	element := reflect.ValueOf(service).Elem()
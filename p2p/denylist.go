@@ -0,0 +1,229 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webchain-network/webchaind/p2p/discover"
+)
+
+// DenyEntry bans either a single node or an IP address range.
+type DenyEntry struct {
+	Target string    `json:"target"` // Node ID in hex, IP address or CIDR range
+	Reason string    `json:"reason,omitempty"`
+	Added  time.Time `json:"added"`
+
+	id  *discover.NodeID
+	net *net.IPNet
+}
+
+// NewDenyEntry creates an entry banning target, which may be an enode URL, a
+// node ID, an IP address or a CIDR range.
+func NewDenyEntry(target, reason string) (*DenyEntry, error) {
+	e := &DenyEntry{Target: strings.TrimSpace(target), Reason: reason, Added: time.Now().UTC()}
+	if err := e.parse(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// parse interprets the target, normalising it so equal bans share a target.
+func (e *DenyEntry) parse() error {
+	target := e.Target
+	if strings.HasPrefix(target, "enode://") {
+		n, err := discover.ParseNode(target)
+		if err != nil {
+			return err
+		}
+		target = n.ID.String()
+	}
+	if id, err := discover.HexID(target); err == nil {
+		e.Target, e.id = id.String(), &id
+		return nil
+	}
+	if !strings.Contains(target, "/") {
+		ip := net.ParseIP(target)
+		if ip == nil {
+			return fmt.Errorf("invalid deny list target %q: want enode URL, node ID, IP address or CIDR range", e.Target)
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		target = fmt.Sprintf("%s/%d", ip, bits)
+	}
+	_, ipnet, err := net.ParseCIDR(target)
+	if err != nil {
+		return fmt.Errorf("invalid deny list target %q: %v", e.Target, err)
+	}
+	e.Target, e.net = ipnet.String(), ipnet
+	if ones, bits := ipnet.Mask.Size(); ones == bits {
+		e.Target = ipnet.IP.String()
+	}
+	return nil
+}
+
+func (e *DenyEntry) matches(id discover.NodeID, ip net.IP) bool {
+	if e.id != nil {
+		return *e.id == id
+	}
+	return ip != nil && e.net.Contains(ip)
+}
+
+// DenyList is a set of banned nodes and IP ranges, saved to a JSON file
+// whenever it changes. A nil *DenyList bans nothing.
+type DenyList struct {
+	path string // Empty for a list kept in memory only
+
+	mu      sync.RWMutex
+	entries map[string]*DenyEntry
+}
+
+// NewDenyList loads the deny list stored at path, which doesn't have to exist
+// yet. An empty path creates a list which isn't persisted.
+func NewDenyList(path string) (*DenyList, error) {
+	l := &DenyList{path: path, entries: make(map[string]*DenyEntry)}
+	if path == "" {
+		return l, nil
+	}
+	blob, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []*DenyEntry
+	if err := json.Unmarshal(blob, &entries); err != nil {
+		return nil, fmt.Errorf("invalid deny list %s: %v", path, err)
+	}
+	for _, e := range entries {
+		if err := e.parse(); err != nil {
+			return nil, err
+		}
+		l.entries[e.Target] = e
+	}
+	return l, nil
+}
+
+// Denied returns the entry banning the node with the given ID and IP address,
+// or nil if it isn't banned.
+func (l *DenyList) Denied(id discover.NodeID, ip net.IP) *DenyEntry {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, e := range l.entries {
+		if e.matches(id, ip) {
+			return e
+		}
+	}
+	return nil
+}
+
+// Add inserts the given entries, replacing any banning the same target, and
+// saves the list.
+func (l *DenyList) Add(entries ...*DenyEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, e := range entries {
+		if e.id == nil && e.net == nil {
+			if err := e.parse(); err != nil {
+				return err
+			}
+		}
+	}
+	for _, e := range entries {
+		if e.Added.IsZero() {
+			e.Added = time.Now().UTC()
+		}
+		l.entries[e.Target] = e
+	}
+	return l.save()
+}
+
+// Remove lifts the ban on target, reporting whether it was banned.
+func (l *DenyList) Remove(target string) (bool, error) {
+	e, err := NewDenyEntry(target, "")
+	if err != nil {
+		return false, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.entries[e.Target]; !ok {
+		return false, nil
+	}
+	delete(l.entries, e.Target)
+	return true, l.save()
+}
+
+// Entries returns all entries, oldest first.
+func (l *DenyList) Entries() []*DenyEntry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	entries := make([]*DenyEntry, 0, len(l.entries))
+	for _, e := range l.entries {
+		entries = append(entries, e)
+	}
+	sortDenyEntries(entries)
+	return entries
+}
+
+// save writes the list to its file. The lock must be held.
+func (l *DenyList) save() error {
+	if l.path == "" {
+		return nil
+	}
+	entries := make([]*DenyEntry, 0, len(l.entries))
+	for _, e := range l.entries {
+		entries = append(entries, e)
+	}
+	sortDenyEntries(entries)
+
+	blob, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := l.path + ".new"
+	if err := ioutil.WriteFile(tmp, blob, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+func sortDenyEntries(entries []*DenyEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Added.Equal(entries[j].Added) {
+			return entries[i].Added.Before(entries[j].Added)
+		}
+		return entries[i].Target < entries[j].Target
+	})
+}
@@ -0,0 +1,155 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDenyEntryParse(t *testing.T) {
+	id := randomID()
+	tests := []struct {
+		target, want string
+		fail         bool
+	}{
+		{target: fmt.Sprintf("enode://%x@10.0.0.1:30303", id[:]), want: id.String()},
+		{target: id.String(), want: id.String()},
+		{target: fmt.Sprintf("0x%x", id[:]), want: id.String()},
+		{target: " 10.0.0.1 ", want: "10.0.0.1"},
+		{target: "10.0.0.1/32", want: "10.0.0.1"},
+		{target: "10.1.2.3/16", want: "10.1.0.0/16"},
+		{target: "::1", want: "::1"},
+		{target: "fe80::1/64", want: "fe80::/64"},
+		{target: "not-a-node", fail: true},
+		{target: "10.0.0.1/33", fail: true},
+	}
+	for _, tt := range tests {
+		e, err := NewDenyEntry(tt.target, "")
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%q: expected error, got %q", tt.target, e.Target)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.target, err)
+			continue
+		}
+		if e.Target != tt.want {
+			t.Errorf("%q: target mismatch: have %q, want %q", tt.target, e.Target, tt.want)
+		}
+	}
+}
+
+func TestDenyListPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "denylist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "denylist.json")
+
+	list, err := NewDenyList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		banned = randomID()
+		other  = randomID()
+	)
+	byID, _ := NewDenyEntry(banned.String(), "spam")
+	byNet, _ := NewDenyEntry("192.168.0.0/24", "")
+	if err := list.Add(byID, byNet); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the list from disk and check both bans are still applied.
+	list, err = NewDenyList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := list.Denied(banned, net.ParseIP("10.0.0.1")); e == nil || e.Reason != "spam" {
+		t.Errorf("banned node not denied: %v", e)
+	}
+	if e := list.Denied(other, net.ParseIP("192.168.0.77")); e == nil {
+		t.Error("node in banned range not denied")
+	}
+	if e := list.Denied(other, net.ParseIP("192.168.1.1")); e != nil {
+		t.Errorf("unbanned node denied by %q", e.Target)
+	}
+	if e := list.Denied(other, nil); e != nil {
+		t.Errorf("node without address denied by %q", e.Target)
+	}
+
+	if removed, err := list.Remove("192.168.0.0/24"); !removed || err != nil {
+		t.Fatalf("remove failed: %v %v", removed, err)
+	}
+	if removed, _ := list.Remove("192.168.0.0/24"); removed {
+		t.Error("removed the same entry twice")
+	}
+	list, err = NewDenyList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := list.Entries(); len(entries) != 1 || entries[0].Target != banned.String() {
+		t.Errorf("unexpected entries after removal: %v", entries)
+	}
+
+	// A nil list denies nothing.
+	var none *DenyList
+	if none.Denied(banned, nil) != nil {
+		t.Error("nil deny list denied a node")
+	}
+}
+
+func TestServerSetupConnDenied(t *testing.T) {
+	list, _ := NewDenyList("")
+	id := randomID()
+	entry, _ := NewDenyEntry(id.String(), "")
+	if err := list.Add(entry); err != nil {
+		t.Fatal(err)
+	}
+	tt := &setupTransport{id: id}
+	srv := &Server{
+		Config: Config{
+			PrivateKey: newkey(),
+			MaxPeers:   10,
+			NoDial:     true,
+			DenyList:   list,
+		},
+		newTransport: func(fd net.Conn) transport { return tt },
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("couldn't start server: %v", err)
+	}
+	defer srv.Stop()
+
+	p1, _ := net.Pipe()
+	defer p1.Close()
+	srv.setupConn(p1, inboundConn, nil)
+	if tt.closeErr != DiscUselessPeer {
+		t.Errorf("close error mismatch: got %q, want %q", tt.closeErr, DiscUselessPeer)
+	}
+	if tt.calls != "doEncHandshake,close," {
+		t.Errorf("calls mismatch: got %q", tt.calls)
+	}
+}
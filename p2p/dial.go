@@ -20,7 +20,9 @@ import (
 	"container/heap"
 	"crypto/rand"
 	"fmt"
+	"math/big"
 	"net"
+	"sort"
 	"time"
 
 	"github.com/webchain-network/webchaind/logger"
@@ -29,7 +31,7 @@ import (
 )
 
 const (
-	// This is the amount of time spent waiting in between
+	// This is the default amount of time spent waiting in between
 	// redialing a certain node.
 	dialHistoryExpiration = 30 * time.Second
 
@@ -55,6 +57,9 @@ type dialstate struct {
 	randomNodes   []*discover.Node // filled from Table
 	static        map[discover.NodeID]*dialTask
 	hist          *dialHistory
+	histExpiry    time.Duration                  // time before a node may be redialed
+	deny          *DenyList                      // nodes which must not be dialed
+	rank          func(discover.NodeID) *big.Int // dial priority of a node, nil if unknown
 }
 
 type discoverTable interface {
@@ -106,8 +111,9 @@ func newDialState(static []*discover.Node, ntab discoverTable, maxdyn int) *dial
 		ntab:        ntab,
 		static:      make(map[discover.NodeID]*dialTask),
 		dialing:     make(map[discover.NodeID]connFlag),
-		randomNodes: make([]*discover.Node, maxdyn/2),
+		randomNodes: make([]*discover.Node, maxdyn),
 		hist:        new(dialHistory),
+		histExpiry:  dialHistoryExpiration,
 	}
 	for _, n := range static {
 		s.addStatic(n)
@@ -136,7 +142,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 		return found || peers[id] != nil || s.hist.contains(id)
 	}
 	addDial := func(flag connFlag, n *discover.Node) bool {
-		if isDialing(n.ID) {
+		if isDialing(n.ID) || s.deny.Denied(n.ID, n.IP) != nil {
 			return false
 		}
 		s.dialing[n.ID] = flag
@@ -162,7 +168,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 
 	// Create dials for static nodes if they are not connected.
 	for id, t := range s.static {
-		if !isDialing(id) {
+		if !isDialing(id) && s.deny.Denied(id, t.dest.IP) == nil {
 			s.dialing[id] = t.flags
 			newtasks = append(newtasks, t)
 		}
@@ -173,6 +179,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	randomCandidates := needDynDials / 2
 	if randomCandidates > 0 {
 		n := s.ntab.ReadRandomNodes(s.randomNodes)
+		s.prioritize(s.randomNodes[:n])
 		for i := 0; i < randomCandidates && i < n; i++ {
 			if addDial(dynDialedConn, s.randomNodes[i]) {
 				needDynDials--
@@ -208,14 +215,31 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 func (s *dialstate) taskDone(t task, now time.Time) {
 	switch t := t.(type) {
 	case *dialTask:
-		s.hist.add(t.dest.ID, now.Add(dialHistoryExpiration))
+		s.hist.add(t.dest.ID, now.Add(s.histExpiry))
 		delete(s.dialing, t.dest.ID)
 	case *discoverTask:
 		s.lookupRunning = false
 		s.lookupBuf = append(s.lookupBuf, t.results...)
+		s.prioritize(s.lookupBuf)
 	}
 }
 
+// prioritize sorts nodes by descending rank, so that the best candidates are
+// dialed first. Nodes of equal or unknown rank keep their relative order.
+func (s *dialstate) prioritize(nodes []*discover.Node) {
+	if s.rank == nil || len(nodes) < 2 {
+		return
+	}
+	ranks := make(map[discover.NodeID]*big.Int, len(nodes))
+	for _, n := range nodes {
+		ranks[n.ID] = s.rank(n.ID)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := ranks[nodes[i].ID], ranks[nodes[j].ID]
+		return a != nil && (b == nil || a.Cmp(b) > 0)
+	})
+}
+
 func (t *dialTask) Do(srv *Server) {
 	if t.dest.Incomplete() {
 		if !t.resolve(srv) {
@@ -18,6 +18,7 @@ package p2p
 
 import (
 	"encoding/binary"
+	"math/big"
 	"net"
 	"reflect"
 	"testing"
@@ -313,6 +314,56 @@ func TestDialStateDynDialFromTable(t *testing.T) {
 	})
 }
 
+// This test checks that the highest ranked candidates are dialed first.
+func TestDialStateRankedDial(t *testing.T) {
+	table := fakeTable{
+		{ID: uintID(1)},
+		{ID: uintID(2)},
+		{ID: uintID(3)},
+		{ID: uintID(4)},
+		{ID: uintID(5)},
+	}
+	state := newDialState(nil, table, 4)
+	state.rank = func(id discover.NodeID) *big.Int {
+		if id == uintID(2) {
+			return nil
+		}
+		return new(big.Int).SetBytes(id[:4])
+	}
+
+	runDialTest(t, dialtest{
+		init: state,
+		rounds: []round{
+			// The best two of the four random nodes are dialed.
+			{
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(4)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(3)}},
+					&discoverTask{},
+				},
+			},
+			// Lookup results are dialed best first, unranked nodes last.
+			{
+				done: []task{
+					&discoverTask{results: []*discover.Node{
+						{ID: uintID(2)},
+						{ID: uintID(10)},
+						{ID: uintID(12)},
+						{ID: uintID(11)},
+					}},
+				},
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(12)}},
+					&dialTask{flags: dynDialedConn, dest: &discover.Node{ID: uintID(11)}},
+				},
+			},
+		},
+	})
+	if len(state.lookupBuf) != 2 || state.lookupBuf[0].ID != uintID(10) || state.lookupBuf[1].ID != uintID(2) {
+		t.Errorf("unexpected lookup buffer: %v", state.lookupBuf)
+	}
+}
+
 // This test checks that static dials are launched.
 func TestDialStateStaticDial(t *testing.T) {
 	wantStatic := []*discover.Node{
@@ -34,6 +34,7 @@ import (
 	"github.com/webchain-network/webchaind/common"
 	"github.com/webchain-network/webchaind/crypto"
 	"github.com/webchain-network/webchaind/crypto/secp256k1"
+	"github.com/webchain-network/webchaind/p2p/enr"
 )
 
 const nodeIDBits = 512
@@ -146,7 +147,17 @@ var incompleteNodeURL = regexp.MustCompile("(?i)^(?:enode://)?([0-9a-f]+)$")
 // and UDP discovery port 30301.
 //
 //    enode://<hex node id>@10.3.58.6:30303?discport=30301
+//
+// Signed node records in their text form (enr:...) are accepted as well,
+// see NodeFromRecord.
 func ParseNode(rawurl string) (*Node, error) {
+	if enr.IsText(rawurl) {
+		r, err := enr.ParseText(rawurl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node record (%v)", err)
+		}
+		return NodeFromRecord(r)
+	}
 	if m := incompleteNodeURL.FindStringSubmatch(rawurl); m != nil {
 		id, err := HexID(m[1])
 		if err != nil {
@@ -157,6 +168,37 @@ func ParseNode(rawurl string) (*Node, error) {
 	return parseComplete(rawurl)
 }
 
+// NodeFromRecord creates a node from a verified node record. The record must
+// contain the public key, the IP address and ports are optional. A missing UDP
+// port defaults to the TCP port.
+func NodeFromRecord(r *enr.Record) (*Node, error) {
+	pk, err := r.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ip4 enr.IPv4
+		ip6 enr.IPv6
+		ip  net.IP
+		tcp enr.TCP
+		udp enr.UDP
+	)
+	if err := r.Load(&ip4); err == nil {
+		ip = net.IP(ip4)
+	} else if err := r.Load(&ip6); err == nil {
+		ip = net.IP(ip6)
+	}
+	for _, e := range []enr.Entry{&tcp, &udp} {
+		if err := r.Load(e); err != nil && !enr.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	if udp == 0 {
+		udp = enr.UDP(tcp)
+	}
+	return NewNode(PubkeyID(pk), ip, uint16(udp), uint16(tcp)), nil
+}
+
 func parseComplete(rawurl string) (*Node, error) {
 	var (
 		id               NodeID
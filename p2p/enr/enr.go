@@ -0,0 +1,259 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+// Package enr implements Ethereum Node Records as defined in EIP-778.
+//
+// A node record holds arbitrary key/value pairs describing a node, such as its
+// public key, IP address and ports. Every change to a record increments its
+// sequence number and requires it to be signed again, so peers can always tell
+// which of two records of the same node is newer.
+//
+// Only the "v4" identity scheme is supported, signing records with the
+// secp256k1 key which is also the node's discovery identity.
+package enr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+// SizeLimit is the maximum encoded size of a node record in bytes.
+const SizeLimit = 300
+
+// textPrefix precedes the base64 encoded record in its text form.
+const textPrefix = "enr:"
+
+var (
+	errNoID           = errors.New("unknown or unspecified identity scheme")
+	errInvalidSig     = errors.New("invalid signature")
+	errNotSorted      = errors.New("record key/value pairs are not sorted by key")
+	errDuplicateKey   = errors.New("record contains duplicate key")
+	errIncompletePair = errors.New("record contains incomplete k/v pair")
+	errTooBig         = fmt.Errorf("record bigger than %d bytes", SizeLimit)
+	errEncodeUnsigned = errors.New("can't encode unsigned record")
+	errNotFound       = errors.New("no such key in record")
+)
+
+// pair is a key/value pair of a record.
+type pair struct {
+	k string
+	v rlp.RawValue
+}
+
+// Record represents a node record. The zero value is an empty, unsigned record.
+type Record struct {
+	seq       uint64 // sequence number
+	signature []byte // the signature, nil if the record is unsigned
+	raw       []byte // RLP encoding of the signed record
+	pairs     []pair // sorted list of all key/value pairs
+}
+
+// Seq returns the sequence number.
+func (r *Record) Seq() uint64 {
+	return r.seq
+}
+
+// SetSeq updates the sequence number. This invalidates any signature on the
+// record. Calling SetSeq is usually not required because setting any key in a
+// signed record increments the sequence number.
+func (r *Record) SetSeq(s uint64) {
+	r.signature = nil
+	r.raw = nil
+	r.seq = s
+}
+
+// Signed reports whether the record carries a valid signature.
+func (r *Record) Signed() bool {
+	return r.signature != nil
+}
+
+// Load retrieves the value of a key/value pair. The given Entry must be a
+// pointer and will be set to the value of the entry in the record.
+//
+// Errors returned by Load are wrapped in KeyError. You can distinguish decoding
+// errors from missing keys using the IsNotFound function.
+func (r *Record) Load(e Entry) error {
+	i := sort.Search(len(r.pairs), func(i int) bool { return r.pairs[i].k >= e.ENRKey() })
+	if i < len(r.pairs) && r.pairs[i].k == e.ENRKey() {
+		if err := rlp.DecodeBytes(r.pairs[i].v, e); err != nil {
+			return &KeyError{Key: e.ENRKey(), Err: err}
+		}
+		return nil
+	}
+	return &KeyError{Key: e.ENRKey(), Err: errNotFound}
+}
+
+// Set adds or updates the given entry in the record. It panics if the value
+// can't be encoded. If the record is signed, Set increments the sequence
+// number and invalidates the signature.
+func (r *Record) Set(e Entry) {
+	blob, err := rlp.EncodeToBytes(e)
+	if err != nil {
+		panic(fmt.Errorf("enr: can't encode %s: %v", e.ENRKey(), err))
+	}
+	r.invalidate()
+
+	pairs := make([]pair, len(r.pairs))
+	copy(pairs, r.pairs)
+	i := sort.Search(len(pairs), func(i int) bool { return pairs[i].k >= e.ENRKey() })
+	switch {
+	case i < len(pairs) && pairs[i].k == e.ENRKey():
+		// Element is present at r.pairs[i].
+		pairs[i].v = blob
+	case i < len(r.pairs):
+		// Insert pair before i-th element.
+		el := pair{e.ENRKey(), blob}
+		pairs = append(pairs, pair{})
+		copy(pairs[i+1:], pairs[i:])
+		pairs[i] = el
+	default:
+		// Element should be placed at the end of r.pairs.
+		pairs = append(pairs, pair{e.ENRKey(), blob})
+	}
+	r.pairs = pairs
+}
+
+// Keys returns the keys of all entries in the record, in ascending order.
+func (r *Record) Keys() []string {
+	keys := make([]string, len(r.pairs))
+	for i, p := range r.pairs {
+		keys[i] = p.k
+	}
+	return keys
+}
+
+func (r *Record) invalidate() {
+	if r.signature != nil {
+		r.seq++
+	}
+	r.signature = nil
+	r.raw = nil
+}
+
+// EncodeRLP implements rlp.Encoder. Encoding fails if the record is unsigned.
+func (r Record) EncodeRLP(w io.Writer) error {
+	if r.signature == nil {
+		return errEncodeUnsigned
+	}
+	_, err := w.Write(r.raw)
+	return err
+}
+
+// DecodeRLP implements rlp.Decoder. Decoding verifies the signature.
+func (r *Record) DecodeRLP(s *rlp.Stream) error {
+	raw, err := s.Raw()
+	if err != nil {
+		return err
+	}
+	if len(raw) > SizeLimit {
+		return errTooBig
+	}
+
+	// Decode the RLP container.
+	dec := Record{raw: raw}
+	s = rlp.NewStream(bytes.NewReader(raw), 0)
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	if err = s.Decode(&dec.signature); err != nil {
+		return err
+	}
+	if err = s.Decode(&dec.seq); err != nil {
+		return err
+	}
+	// The rest of the record contains sorted k/v pairs.
+	var prevkey string
+	for i := 0; ; i++ {
+		var kv pair
+		if err := s.Decode(&kv.k); err != nil {
+			if err == rlp.EOL {
+				break
+			}
+			return err
+		}
+		if err := s.Decode(&kv.v); err != nil {
+			if err == rlp.EOL {
+				return errIncompletePair
+			}
+			return err
+		}
+		if i > 0 {
+			if kv.k == prevkey {
+				return errDuplicateKey
+			}
+			if kv.k < prevkey {
+				return errNotSorted
+			}
+		}
+		dec.pairs = append(dec.pairs, kv)
+		prevkey = kv.k
+	}
+	if err := s.ListEnd(); err != nil {
+		return err
+	}
+	if err := dec.verifySignature(); err != nil {
+		return err
+	}
+	*r = dec
+	return nil
+}
+
+// appendPairs appends the sequence number and key/value pairs to list, as
+// they are signed and encoded.
+func (r *Record) appendPairs(list []interface{}) []interface{} {
+	list = append(list, r.seq)
+	for _, p := range r.pairs {
+		list = append(list, p.k, p.v)
+	}
+	return list
+}
+
+// Text returns the text form of a signed record, "enr:" followed by the URL-safe
+// base64 encoding of the record without padding.
+func (r *Record) Text() (string, error) {
+	if r.signature == nil {
+		return "", errEncodeUnsigned
+	}
+	return textPrefix + base64.RawURLEncoding.EncodeToString(r.raw), nil
+}
+
+// IsText reports whether s looks like the text form of a record.
+func IsText(s string) bool {
+	return strings.HasPrefix(s, textPrefix)
+}
+
+// ParseText decodes and verifies a record in text form.
+func ParseText(s string) (*Record, error) {
+	if !IsText(s) {
+		return nil, fmt.Errorf("missing %q prefix", textPrefix)
+	}
+	blob, err := base64.RawURLEncoding.DecodeString(s[len(textPrefix):])
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %v", err)
+	}
+	r := new(Record)
+	if err := rlp.DecodeBytes(blob, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package enr
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+// The example record of EIP-778.
+var (
+	testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testRecord = "enr:-IS4QHCYrYZbAKWCBRlAy5zzaDZXJBGkcnh4MHcBFZntXNFrdvJjX04jRzjzCBOonrkTfj499SZuOh8R33Ls8RRcy5wBgmlkgnY0gmlwhH8AAAGJc2VjcDI1NmsxoQPKY0yuDUmstAHYpMa2_oxVtw0RW_QAdpzBQA8yWM0xOIN1ZHCCdl8"
+)
+
+func TestSignV4(t *testing.T) {
+	var r Record
+	r.SetSeq(1)
+	r.Set(IPv4(net.ParseIP("127.0.0.1")))
+	r.Set(UDP(30303))
+	if err := SignV4(&r, testKey); err != nil {
+		t.Fatal(err)
+	}
+	// Signatures aren't deterministic, compare the signed content and make
+	// sure the signature verifies.
+	text, err := r.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := ParseText(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := ParseText(testRecord)
+	haveContent, _ := rlp.EncodeToBytes(dec.appendPairs(nil))
+	wantContent, _ := rlp.EncodeToBytes(want.appendPairs(nil))
+	if !bytes.Equal(haveContent, wantContent) {
+		t.Errorf("content mismatch:\nhave %x\nwant %x", haveContent, wantContent)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	r, err := ParseText(testRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Seq() != 1 {
+		t.Errorf("seq mismatch: have %d, want 1", r.Seq())
+	}
+	var (
+		ip  IPv4
+		udp UDP
+		tcp TCP
+	)
+	if err := r.Load(&ip); err != nil || !net.IP(ip).Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("ip mismatch: have %v (%v)", net.IP(ip), err)
+	}
+	if err := r.Load(&udp); err != nil || udp != 30303 {
+		t.Errorf("udp mismatch: have %d (%v)", udp, err)
+	}
+	if err := r.Load(&tcp); !IsNotFound(err) {
+		t.Errorf("tcp load error mismatch: have %v, want not found", err)
+	}
+	pk, err := r.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(crypto.FromECDSAPub(pk), crypto.FromECDSAPub(&testKey.PublicKey)) {
+		t.Errorf("public key mismatch")
+	}
+	if keys := r.Keys(); !reflect.DeepEqual(keys, []string{"id", "ip", "secp256k1", "udp"}) {
+		t.Errorf("keys mismatch: %v", keys)
+	}
+}
+
+func TestSetIncrementsSeq(t *testing.T) {
+	var r Record
+	r.Set(TCP(30303))
+	if err := SignV4(&r, testKey); err != nil {
+		t.Fatal(err)
+	}
+	if r.Seq() != 0 || !r.Signed() {
+		t.Fatalf("fresh record: seq %d, signed %t", r.Seq(), r.Signed())
+	}
+	r.Set(TCP(30304))
+	if r.Seq() != 1 || r.Signed() {
+		t.Fatalf("updated record: seq %d, signed %t", r.Seq(), r.Signed())
+	}
+	if _, err := rlp.EncodeToBytes(r); err != errEncodeUnsigned {
+		t.Errorf("unsigned encoding error mismatch: have %v, want %v", err, errEncodeUnsigned)
+	}
+}
+
+func TestGenericEntry(t *testing.T) {
+	type capability struct {
+		Hash [4]byte
+		Next uint64
+	}
+	var r Record
+	want := capability{Hash: [4]byte{1, 2, 3, 4}, Next: 100}
+	r.Set(WithEntry("eth", []interface{}{want}))
+	if err := SignV4(&r, testKey); err != nil {
+		t.Fatal(err)
+	}
+	blob, err := rlp.EncodeToBytes(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dec Record
+	if err := rlp.DecodeBytes(blob, &dec); err != nil {
+		t.Fatal(err)
+	}
+	var have []capability
+	if err := dec.Load(WithEntry("eth", &have)); err != nil {
+		t.Fatal(err)
+	}
+	if len(have) != 1 || have[0] != want {
+		t.Errorf("entry mismatch: have %+v, want %+v", have, want)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	var r Record
+	r.Set(UDP(30303))
+	if err := SignV4(&r, testKey); err != nil {
+		t.Fatal(err)
+	}
+	blob, _ := rlp.EncodeToBytes(r)
+
+	// Tampering with the content breaks the signature.
+	tampered := append([]byte{}, blob...)
+	tampered[len(tampered)-1]++
+	if err := rlp.DecodeBytes(tampered, new(Record)); err != errInvalidSig {
+		t.Errorf("tampered record error mismatch: have %v, want %v", err, errInvalidSig)
+	}
+	// Oversized records are rejected.
+	r.Set(WithEntry("pad", strings.Repeat("x", SizeLimit)))
+	if err := SignV4(&r, testKey); err != errTooBig {
+		t.Errorf("oversized record error mismatch: have %v, want %v", err, errTooBig)
+	}
+	if _, err := ParseText("enode://1234"); err == nil {
+		t.Error("parsed record without prefix")
+	}
+}
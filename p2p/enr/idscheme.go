@@ -0,0 +1,128 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package enr
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/crypto/secp256k1"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+// SignV4 signs a record using the v4 identity scheme, which also sets the
+// "id" and "secp256k1" keys of the record.
+func SignV4(r *Record, key *ecdsa.PrivateKey) error {
+	// Copy r to avoid modifying it if signing fails.
+	cpy := *r
+	cpy.Set(ID("v4"))
+	cpy.Set(Secp256k1(key.PublicKey))
+
+	content, err := rlp.EncodeToBytes(cpy.appendPairs(nil))
+	if err != nil {
+		return err
+	}
+	sig, err := crypto.Sign(crypto.Keccak256(content), key)
+	if err != nil {
+		return err
+	}
+	sig = sig[:len(sig)-1] // remove the recovery id
+	raw, err := rlp.EncodeToBytes(cpy.appendPairs([]interface{}{sig}))
+	if err != nil {
+		return err
+	}
+	if len(raw) > SizeLimit {
+		return errTooBig
+	}
+	cpy.signature, cpy.raw = sig, raw
+	*r = cpy
+	return nil
+}
+
+// PublicKey returns the secp256k1 public key of a v4 record.
+func (r *Record) PublicKey() (*ecdsa.PublicKey, error) {
+	var pk Secp256k1
+	if err := r.Load(&pk); err != nil {
+		return nil, err
+	}
+	return (*ecdsa.PublicKey)(&pk), nil
+}
+
+// verifySignature checks the signature of a decoded record against the public
+// key it contains.
+func (r *Record) verifySignature() error {
+	var id ID
+	if err := r.Load(&id); err != nil || id != "v4" {
+		return errNoID
+	}
+	pk, err := r.PublicKey()
+	if err != nil {
+		return err
+	}
+	if len(r.signature) != 64 {
+		return errInvalidSig
+	}
+	content, err := rlp.EncodeToBytes(r.appendPairs(nil))
+	if err != nil {
+		return err
+	}
+	// The v4 scheme drops the recovery id, try both.
+	hash, want := crypto.Keccak256(content), crypto.FromECDSAPub(pk)
+	for v := byte(0); v < 2; v++ {
+		sig := append(append([]byte{}, r.signature...), v)
+		if pub, err := crypto.Ecrecover(hash, sig); err == nil && bytes.Equal(pub, want) {
+			return nil
+		}
+	}
+	return errInvalidSig
+}
+
+// compressPubkey encodes a public key in the 33-byte compressed format.
+func compressPubkey(pk *ecdsa.PublicKey) []byte {
+	blob := make([]byte, 33)
+	blob[0] = 2 | byte(pk.Y.Bit(0))
+	x := pk.X.Bytes()
+	copy(blob[33-len(x):], x)
+	return blob
+}
+
+// decompressPubkey parses a public key in the 33-byte compressed format.
+func decompressPubkey(blob []byte) (*ecdsa.PublicKey, error) {
+	if len(blob) != 33 || (blob[0] != 2 && blob[0] != 3) {
+		return nil, errors.New("invalid compressed public key")
+	}
+	curve := secp256k1.S256()
+	x := new(big.Int).SetBytes(blob[1:])
+	if x.Cmp(curve.P) >= 0 {
+		return nil, errors.New("invalid compressed public key")
+	}
+	// y² = x³ + b
+	y := new(big.Int).Mul(x, x)
+	y.Mul(y, x)
+	y.Add(y, curve.B)
+	y.Mod(y, curve.P)
+	if y.ModSqrt(y, curve.P) == nil {
+		return nil, errors.New("compressed public key not on curve")
+	}
+	if y.Bit(0) != uint(blob[0]&1) {
+		y.Sub(curve.P, y)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -42,12 +43,20 @@ type Msg struct {
 	ReceivedAt time.Time
 }
 
+// msgStreams holds decoding streams for reuse across messages.
+var msgStreams = sync.Pool{
+	New: func() interface{} { return new(rlp.Stream) },
+}
+
 // Decode parses the RLP content of a message into
 // the given value, which must be a pointer.
 //
 // For the decoding rules, please see package rlp.
 func (msg Msg) Decode(val interface{}) error {
-	s := rlp.NewStream(msg.Payload, uint64(msg.Size))
+	s := msgStreams.Get().(*rlp.Stream)
+	defer msgStreams.Put(s)
+
+	s.Reset(msg.Payload, uint64(msg.Size))
 	if err := s.Decode(val); err != nil {
 		return newPeerError(errInvalidMsg, "(code %x) (size %d) %v", msg.Code, msg.Size, err)
 	}
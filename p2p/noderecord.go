@@ -0,0 +1,101 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+	"github.com/webchain-network/webchaind/p2p/discover"
+	"github.com/webchain-network/webchaind/p2p/enr"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+// NodeRecord returns the signed node record (EIP-778) of the local node, or
+// nil if the server isn't running.
+//
+// The record holds the endpoint of the local node and the entries of all
+// protocols with Attributes. Whenever any of them changes, e.g. because NAT
+// discovered the external IP, the sequence number is increased and the record
+// signed again.
+func (srv *Server) NodeRecord() *enr.Record {
+	self := srv.Self()
+	if self.ID == (discover.NodeID{}) {
+		return nil
+	}
+	entries := srv.recordEntries(self.IP, self.TCP, self.UDP)
+
+	var content bytes.Buffer
+	for _, e := range entries {
+		if err := rlp.Encode(&content, []interface{}{e.ENRKey(), e}); err != nil {
+			glog.V(logger.Error).Errorf("Invalid node record entry %q: %v", e.ENRKey(), err)
+			return nil
+		}
+	}
+
+	srv.recordMu.Lock()
+	defer srv.recordMu.Unlock()
+
+	if srv.record != nil && bytes.Equal(content.Bytes(), srv.recordContent) {
+		cpy := *srv.record
+		return &cpy
+	}
+	seq := srv.recordSeq
+	if srv.record != nil {
+		seq = srv.record.Seq() + 1
+	}
+	record := new(enr.Record)
+	record.SetSeq(seq)
+	for _, e := range entries {
+		record.Set(e)
+	}
+	if err := enr.SignV4(record, srv.PrivateKey); err != nil {
+		glog.V(logger.Error).Errorf("Failed to sign node record: %v", err)
+		return nil
+	}
+	srv.record, srv.recordContent = record, content.Bytes()
+	glog.V(logger.Debug).Infof("Updated local node record, seq=%d", seq)
+
+	cpy := *record
+	return &cpy
+}
+
+// recordEntries assembles the entries of the local node record.
+func (srv *Server) recordEntries(ip net.IP, tcp, udp uint16) []enr.Entry {
+	var entries []enr.Entry
+	if ip != nil && !ip.IsUnspecified() {
+		if ip4 := ip.To4(); ip4 != nil {
+			entries = append(entries, enr.IPv4(ip4))
+		} else {
+			entries = append(entries, enr.IPv6(ip))
+		}
+	}
+	if tcp != 0 {
+		entries = append(entries, enr.TCP(tcp))
+	}
+	if udp != 0 {
+		entries = append(entries, enr.UDP(udp))
+	}
+	for _, proto := range srv.Protocols {
+		if proto.Attributes != nil {
+			entries = append(entries, proto.Attributes()...)
+		}
+	}
+	return entries
+}
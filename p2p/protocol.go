@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/webchain-network/webchaind/p2p/discover"
+	"github.com/webchain-network/webchaind/p2p/enr"
 )
 
 // Protocol represents a P2P subprotocol implementation.
@@ -52,6 +53,11 @@ type Protocol struct {
 	// about a certain peer in the network. If an info retrieval function is set,
 	// but returns nil, it is assumed that the protocol handshake is still running.
 	PeerInfo func(id discover.NodeID) interface{}
+
+	// Attributes is an optional helper method returning protocol specific
+	// entries of the local node record. It is called whenever the record is
+	// assembled, so the entries may change while the server is running.
+	Attributes func() []enr.Entry
 }
 
 func (p Protocol) cap() Cap {
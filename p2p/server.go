@@ -21,6 +21,7 @@ import (
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"math/big"
 	"net"
 	"sync"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"github.com/webchain-network/webchaind/logger"
 	"github.com/webchain-network/webchaind/logger/glog"
 	"github.com/webchain-network/webchaind/p2p/discover"
+	"github.com/webchain-network/webchaind/p2p/enr"
 	"github.com/webchain-network/webchaind/p2p/nat"
 )
 
@@ -71,6 +73,10 @@ type Config struct {
 	// Setting DialRatio to zero defaults it to 3.
 	DialRatio int
 
+	// DialHistoryExpiry is the time to wait before redialing a node after a
+	// dial attempt. Zero defaults to 30 seconds.
+	DialHistoryExpiry time.Duration
+
 	// Discovery specifies whether the peer discovery mechanism should be started
 	// or not. Disabling is usually useful for protocol debugging (manual topology).
 	Discovery bool
@@ -90,6 +96,10 @@ type Config struct {
 	// allowed to connect, even above the peer limit.
 	TrustedNodes []*discover.Node
 
+	// DenyList holds the nodes and IP ranges which are never dialed nor
+	// accepted, even if trusted or static.
+	DenyList *DenyList
+
 	// NodeDatabase is the path to the database containing the previously seen
 	// live nodes in the network.
 	NodeDatabase string
@@ -133,11 +143,19 @@ type Server struct {
 	lock    sync.Mutex // protects running
 	running bool
 
+	rankMu   sync.RWMutex
+	dialRank func(discover.NodeID) *big.Int
+
 	ntab         discoverTable
 	listener     net.Listener
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
 
+	recordMu      sync.Mutex  // protects the local node record
+	record        *enr.Record // signed record of the local node, nil until assembled
+	recordSeq     uint64      // sequence number of the first record
+	recordContent []byte      // encoded entries of record, to detect changes
+
 	// These are for Peers, PeerCount (and nothing else).
 	peerOp     chan peerOpFunc
 	peerOpDone chan struct{}
@@ -355,6 +373,13 @@ func (srv *Server) Start() (err error) {
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
+	// Start the record sequence at the current time, so records of this run
+	// supersede those published before a restart.
+	srv.recordMu.Lock()
+	srv.record, srv.recordContent = nil, nil
+	srv.recordSeq = uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	srv.recordMu.Unlock()
+
 	// node table
 	if srv.Discovery {
 		ntab, err := discover.ListenUDP(srv.PrivateKey, srv.ListenAddr, srv.NAT, srv.NodeDatabase)
@@ -369,6 +394,11 @@ func (srv *Server) Start() (err error) {
 
 	dynPeers := srv.maxDialedConns()
 	dialer := newDialState(srv.StaticNodes, srv.ntab, dynPeers)
+	dialer.deny = srv.DenyList
+	dialer.rank = srv.nodeRank
+	if srv.DialHistoryExpiry > 0 {
+		dialer.histExpiry = srv.DialHistoryExpiry
+	}
 
 	// handshake
 	srv.ourHandshake = &protoHandshake{Version: baseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
@@ -590,11 +620,64 @@ func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, inboundCo
 		return DiscAlreadyConnected
 	case c.id == srv.Self().ID:
 		return DiscSelf
+	case srv.DenyList.Denied(c.id, remoteIP(c.fd)) != nil:
+		return DiscUselessPeer
 	default:
 		return nil
 	}
 }
 
+// SetDialRank sets the function ranking discovered nodes for dialing, such as
+// by the total difficulty a node last advertised. Higher ranked nodes are
+// dialed first, nodes ranked nil last. It may be called while the server is
+// running.
+func (srv *Server) SetDialRank(rank func(discover.NodeID) *big.Int) {
+	srv.rankMu.Lock()
+	defer srv.rankMu.Unlock()
+
+	srv.dialRank = rank
+}
+
+func (srv *Server) nodeRank(id discover.NodeID) *big.Int {
+	srv.rankMu.RLock()
+	rank := srv.dialRank
+	srv.rankMu.RUnlock()
+
+	if rank == nil {
+		return nil
+	}
+	return rank(id)
+}
+
+// Deny bans the given nodes or IP ranges, disconnecting any connected peers
+// they cover.
+func (srv *Server) Deny(entries ...*DenyEntry) error {
+	if srv.DenyList == nil {
+		return errors.New("deny list not configured")
+	}
+	if err := srv.DenyList.Add(entries...); err != nil {
+		return err
+	}
+	for _, p := range srv.Peers() {
+		for _, e := range entries {
+			if e.matches(p.ID(), remoteIP(p.rw.fd)) {
+				glog.V(logger.Info).Infof("Disconnecting denied peer %v", p)
+				p.Disconnect(DiscUselessPeer)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// remoteIP returns the IP address of the remote end of fd, if it has one.
+func remoteIP(fd net.Conn) net.IP {
+	if addr, ok := fd.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
+
 func (srv *Server) maxInboundConns() int {
 	return srv.MaxPeers - srv.maxDialedConns()
 }
@@ -787,6 +870,7 @@ type NodeInfo struct {
 	ID    string `json:"id"`    // Unique node identifier (also the encryption key)
 	Name  string `json:"name"`  // Name of the node, including client type, version, OS, custom data
 	Enode string `json:"enode"` // Enode URL for adding this peer from remote peers
+	ENR   string `json:"enr"`   // Ethereum Node Record of the node (EIP-778)
 	IP    string `json:"ip"`    // IP address of the node
 	Ports struct {
 		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
@@ -811,6 +895,9 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	}
 	info.Ports.Discovery = int(node.UDP)
 	info.Ports.Listener = int(node.TCP)
+	if record := srv.NodeRecord(); record != nil {
+		info.ENR, _ = record.Text()
+	}
 
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
@@ -28,6 +28,7 @@ import (
 	"github.com/webchain-network/webchaind/crypto"
 	"github.com/webchain-network/webchaind/crypto/sha3"
 	"github.com/webchain-network/webchaind/p2p/discover"
+	"github.com/webchain-network/webchaind/p2p/enr"
 )
 
 func init() {
@@ -495,3 +496,55 @@ func randomID() (id discover.NodeID) {
 	}
 	return id
 }
+
+func TestServerNodeRecord(t *testing.T) {
+	version := uint64(1)
+	srv := &Server{Config: Config{
+		Name:       "test",
+		MaxPeers:   10,
+		ListenAddr: "127.0.0.1:0",
+		PrivateKey: newkey(),
+		NoDial:     true,
+		Protocols: []Protocol{{
+			Name:       "test",
+			Attributes: func() []enr.Entry { return []enr.Entry{enr.WithEntry("test", version)} },
+		}},
+	}}
+	if srv.NodeRecord() != nil {
+		t.Fatal("node record of stopped server")
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv.Stop()
+
+	record := srv.NodeRecord()
+	if record == nil || !record.Signed() {
+		t.Fatalf("invalid node record: %v", record)
+	}
+	text, _ := record.Text()
+	node, err := discover.ParseNode(text)
+	if err != nil {
+		t.Fatalf("can't parse node record: %v", err)
+	}
+	if self := srv.Self(); node.ID != self.ID || !node.IP.Equal(self.IP) || node.TCP != self.TCP {
+		t.Errorf("node mismatch: have %v, want %v", node, self)
+	}
+	if info := srv.NodeInfo(); info.ENR != text {
+		t.Errorf("node info record mismatch: have %s, want %s", info.ENR, text)
+	}
+
+	// Unchanged entries keep the record, changed ones increase the sequence number.
+	if again := srv.NodeRecord(); again.Seq() != record.Seq() {
+		t.Errorf("seq changed without update: have %d, want %d", again.Seq(), record.Seq())
+	}
+	version = 2
+	updated := srv.NodeRecord()
+	if updated.Seq() != record.Seq()+1 {
+		t.Errorf("seq mismatch after update: have %d, want %d", updated.Seq(), record.Seq()+1)
+	}
+	var have uint64
+	if err := updated.Load(enr.WithEntry("test", &have)); err != nil || have != 2 {
+		t.Errorf("protocol entry mismatch: have %d (%v), want 2", have, err)
+	}
+}
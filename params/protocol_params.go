@@ -7,11 +7,12 @@ const (
 
 	StackLimit uint64 = 1024 // Maximum size of VM stack allowed.
 	MemoryGas  uint64 = 3    // Times the address of the (highest referenced byte in memory + 1). NOTE: referencing happens on read, write and in instructions such as RETURN and CALL.
+
+	CreateDataGas uint64 = 200 // Paid per byte of the code of a created contract.
 )
 
 var (
 	DifficultyBoundDivisor = big.NewInt(200) // The bound divisor of the difficulty, used in the update calculations.
 
 	MinimumDifficulty = big.NewInt(10000) // The minimum that the difficulty may ever be.
-	CreateDataGas     = big.NewInt(200)
 )
@@ -0,0 +1,80 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package release
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+
+	"github.com/webchain-network/webchaind/crypto"
+)
+
+// Module is a dependency compiled into the build, with the go.sum hash of
+// its source.
+type Module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Sum     string `json:"sum"`
+}
+
+// Info is the metadata embedded in a build. The commit and flags are set with
+// the linker by the release builds, the dependencies are recorded by the Go
+// toolchain.
+type Info struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	Flags     string   `json:"flags"`
+	GoVersion string   `json:"goVersion"`
+	Deps      []Module `json:"deps"`
+}
+
+// ReadInfo returns the metadata of the running build.
+func ReadInfo(version, commit, flags string) *Info {
+	info := &Info{
+		Version:   version,
+		Commit:    commit,
+		Flags:     flags,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range bi.Deps {
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			info.Deps = append(info.Deps, Module{dep.Path, dep.Version, dep.Sum})
+		}
+		sort.Slice(info.Deps, func(i, j int) bool { return info.Deps[i].Path < info.Deps[j].Path })
+	}
+	return info
+}
+
+// Release reports whether the build carries the metadata of a release build.
+func (info *Info) Release() bool {
+	return info.Commit != ""
+}
+
+// DepsHash returns a hash of the dependencies, equal for two builds compiled
+// from the same dependency sources.
+func (info *Info) DepsHash() string {
+	var manifest []byte
+	for _, dep := range info.Deps {
+		manifest = append(manifest, fmt.Sprintf("%s %s %s\n", dep.Path, dep.Version, dep.Sum)...)
+	}
+	return fmt.Sprintf("%x", crypto.Keccak256(manifest))
+}
@@ -0,0 +1,168 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+// Package release verifies release binaries against the checksums published
+// with them and reports the metadata embedded in the running build.
+//
+// The checksums are published as a file in the format of sha256sum
+//
+//	3f4e...9a1c  webchaind-linux-amd64
+//	b25c...07d2  webchaind-windows-amd64.exe
+//
+// along with a signature file holding the hex encoded 65 byte secp256k1
+// signature of the Keccak256 hash of the checksum file, made by one of the
+// release signing keys.
+package release
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/crypto"
+)
+
+var (
+	ErrUntrustedSigner = errors.New("checksums not signed by a trusted signer")
+	ErrUnknownBinary   = errors.New("binary not in the release checksums")
+)
+
+var httpClient = &http.Client{Timeout: time.Minute}
+
+// Checksums maps the file names of a release to their hex encoded SHA256.
+type Checksums map[string]string
+
+// ParseChecksums decodes a checksum file in the format of sha256sum.
+func ParseChecksums(data []byte) (Checksums, error) {
+	sums := make(Checksums)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("checksums line %d: invalid format", line)
+		}
+		sum, name := strings.ToLower(fields[0]), strings.TrimPrefix(fields[1], "*")
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("checksums line %d: invalid SHA256 %q", line, fields[0])
+		}
+		sums[name] = sum
+	}
+	return sums, scanner.Err()
+}
+
+// Sign signs a checksum file, returning the content of its signature file.
+func Sign(data []byte, signer func(hash []byte) ([]byte, error)) ([]byte, error) {
+	sig, err := signer(crypto.Keccak256(data))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(common.ToHex(sig) + "\n"), nil
+}
+
+// VerifyChecksums checks that the checksum file data is signed by one of the
+// given signers and decodes it.
+func VerifyChecksums(data, signature []byte, signers []common.Address) (Checksums, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(signature)), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksums signature: %v", err)
+	}
+	pub, err := crypto.SigToPub(crypto.Keccak256(data), sig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksums signature: %v", err)
+	}
+	signer := crypto.PubkeyToAddress(*pub)
+	trusted := false
+	for _, addr := range signers {
+		if addr == signer {
+			trusted = true
+			break
+		}
+	}
+	if !trusted {
+		return nil, ErrUntrustedSigner
+	}
+	return ParseChecksums(data)
+}
+
+// FileSHA256 returns the hex encoded SHA256 of a file.
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify checks the binary at path against the release checksums, returning
+// the name it's released under. Binaries renamed after the download are found
+// by their checksum.
+func (sums Checksums) Verify(path string) (string, error) {
+	sum, err := FileSHA256(path)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(path)
+	if want, ok := sums[name]; ok {
+		if sum != want {
+			return "", fmt.Errorf("checksum mismatch for %s: have %s, want %s", name, sum, want)
+		}
+		return name, nil
+	}
+	for name, want := range sums {
+		if sum == want {
+			return name, nil
+		}
+	}
+	return "", ErrUnknownBinary
+}
+
+// Fetch reads a checksum or signature file from a local path or an HTTP(S) URL.
+func Fetch(location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return ioutil.ReadFile(location)
+	}
+	res, err := httpClient.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", location, res.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(res.Body, 1024*1024))
+}
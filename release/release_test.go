@@ -0,0 +1,98 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package release
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/crypto"
+)
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	binary := []byte("webchaind release binary")
+	path := filepath.Join(dir, "webchaind-linux-amd64")
+	if err := ioutil.WriteFile(path, binary, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(fmt.Sprintf("%x  webchaind-linux-amd64\n%x *webchaind-windows-amd64.exe\n", sha256.Sum256(binary), sha256.Sum256(nil)))
+
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	sig, err := Sign(data, func(hash []byte) ([]byte, error) { return crypto.Sign(hash, key) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums, err := VerifyChecksums(data, sig, []common.Address{crypto.PubkeyToAddress(other.PublicKey), signer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sums) != 2 {
+		t.Fatalf("checksums mismatch: got %v", sums)
+	}
+	if _, err := VerifyChecksums(data, sig, []common.Address{crypto.PubkeyToAddress(other.PublicKey)}); err != ErrUntrustedSigner {
+		t.Errorf("untrusted signer: got error %v", err)
+	}
+	if _, err := VerifyChecksums(append(data, '\n'), sig, []common.Address{signer}); err == nil {
+		t.Error("tampered checksums verified")
+	}
+
+	if name, err := sums.Verify(path); err != nil || name != "webchaind-linux-amd64" {
+		t.Errorf("released binary: got %q, %v", name, err)
+	}
+	// Renamed binaries are found by checksum.
+	renamed := filepath.Join(dir, "webchaind")
+	os.Rename(path, renamed)
+	if name, err := sums.Verify(renamed); err != nil || name != "webchaind-linux-amd64" {
+		t.Errorf("renamed binary: got %q, %v", name, err)
+	}
+	ioutil.WriteFile(path, []byte("modified binary"), 0755)
+	if _, err := sums.Verify(path); err == nil {
+		t.Error("modified binary verified")
+	}
+	if _, err := sums.Verify(renamed + ".old"); err == nil {
+		t.Error("missing binary verified")
+	}
+	ioutil.WriteFile(renamed, []byte("unknown binary"), 0755)
+	if _, err := sums.Verify(renamed); err != ErrUnknownBinary {
+		t.Errorf("unknown binary: got error %v", err)
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	for _, data := range []string{
+		"abcd  webchaind\n",
+		"3f4e webchaind extra\n",
+		fmt.Sprintf("%x\n", sha256.Sum256(nil)),
+	} {
+		if _, err := ParseChecksums([]byte(data)); err == nil {
+			t.Errorf("no error for %q", data)
+		}
+	}
+}
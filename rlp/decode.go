@@ -26,11 +26,16 @@ import (
 	"math/big"
 	"reflect"
 	"strings"
+	"sync"
 )
 
 var (
 	errNoPointer     = errors.New("rlp: interface given to Decode must be a pointer")
 	errDecodeIntoNil = errors.New("rlp: pointer given to Decode must not be nil")
+
+	streamPool = sync.Pool{
+		New: func() interface{} { return new(Stream) },
+	}
 )
 
 // Decoder is implemented by types that require custom RLP
@@ -105,20 +110,27 @@ type Decoder interface {
 //
 //     NewStream(r, limit).Decode(val)
 func Decode(r io.Reader, val interface{}) error {
-	// TODO: this could use a Stream from a pool.
-	return NewStream(r, 0).Decode(val)
+	stream := streamPool.Get().(*Stream)
+	defer streamPool.Put(stream)
+
+	stream.Reset(r, 0)
+	return stream.Decode(val)
 }
 
 // DecodeBytes parses RLP data from b into val.
 // Please see the documentation of Decode for the decoding rules.
 // The input must contain exactly one value and no trailing data.
 func DecodeBytes(b []byte, val interface{}) error {
-	// TODO: this could use a Stream from a pool.
-	r := bytes.NewReader(b)
-	if err := NewStream(r, uint64(len(b))).Decode(val); err != nil {
+	r := (*sliceReader)(&b)
+
+	stream := streamPool.Get().(*Stream)
+	defer streamPool.Put(stream)
+
+	stream.Reset(r, uint64(len(b)))
+	if err := stream.Decode(val); err != nil {
 		return err
 	}
-	if r.Len() > 0 {
+	if len(b) > 0 {
 		return ErrMoreThanOneValue
 	}
 	return nil
@@ -248,20 +260,14 @@ func decodeBigIntNoPtr(s *Stream, val reflect.Value) error {
 }
 
 func decodeBigInt(s *Stream, val reflect.Value) error {
-	b, err := s.Bytes()
-	if err != nil {
-		return wrapStreamError(err, val.Type())
-	}
 	i := val.Interface().(*big.Int)
 	if i == nil {
 		i = new(big.Int)
 		val.Set(reflect.ValueOf(i))
 	}
-	// Reject leading zero bytes
-	if len(b) > 0 && b[0] == 0 {
-		return wrapStreamError(ErrCanonInt, val.Type())
+	if err := s.decodeBigInt(i); err != nil {
+		return wrapStreamError(err, val.Type())
 	}
-	i.SetBytes(b)
 	return nil
 }
 
@@ -601,7 +607,7 @@ type Stream struct {
 	limited   bool
 
 	// auxiliary buffer for integer decoding
-	uintbuf []byte
+	uintbuf [32]byte
 
 	kind    Kind   // kind of value ahead
 	size    uint64 // size of value ahead
@@ -661,6 +667,51 @@ func (s *Stream) Bytes() ([]byte, error) {
 	}
 }
 
+// BigInt decodes an arbitrary-size integer value.
+func (s *Stream) BigInt() (*big.Int, error) {
+	i := new(big.Int)
+	if err := s.decodeBigInt(i); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// decodeBigInt decodes an integer value into dst. Values of up to 256 bits are
+// read through the stream's auxiliary buffer and don't allocate.
+func (s *Stream) decodeBigInt(dst *big.Int) error {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	var b []byte
+	switch kind {
+	case Byte:
+		s.kind = -1 // rearm Kind
+		b = s.uintbuf[:1]
+		b[0] = s.byteval
+	case String:
+		if size > uint64(len(s.uintbuf)) {
+			b = make([]byte, size)
+		} else {
+			b = s.uintbuf[:size]
+		}
+		if err := s.readFull(b); err != nil {
+			return err
+		}
+		if size == 1 && b[0] < 128 {
+			return ErrCanonSize
+		}
+	default:
+		return ErrExpectedString
+	}
+	// Reject leading zero bytes
+	if len(b) > 0 && b[0] == 0 {
+		return ErrCanonInt
+	}
+	dst.SetBytes(b)
+	return nil
+}
+
 // Raw reads a raw encoded value including RLP type information.
 func (s *Stream) Raw() ([]byte, error) {
 	kind, size, err := s.Kind()
@@ -828,6 +879,9 @@ func (s *Stream) Reset(r io.Reader, inputLimit uint64) {
 		case *strings.Reader:
 			s.remaining = uint64(br.Len())
 			s.limited = true
+		case *sliceReader:
+			s.remaining = uint64(len(*br))
+			s.limited = true
 		default:
 			s.limited = false
 		}
@@ -843,9 +897,7 @@ func (s *Stream) Reset(r io.Reader, inputLimit uint64) {
 	s.size = 0
 	s.kind = -1
 	s.kinderr = nil
-	if s.uintbuf == nil {
-		s.uintbuf = make([]byte, 8)
-	}
+	s.byteval = 0
 }
 
 // Kind returns the kind and size of the next value in the
@@ -963,20 +1015,21 @@ func (s *Stream) readUint(size byte) (uint64, error) {
 		b, err := s.readByte()
 		return uint64(b), err
 	default:
+		buf := s.uintbuf[:8]
 		start := int(8 - size)
 		for i := 0; i < start; i++ {
-			s.uintbuf[i] = 0
+			buf[i] = 0
 		}
-		if err := s.readFull(s.uintbuf[start:]); err != nil {
+		if err := s.readFull(buf[start:]); err != nil {
 			return 0, err
 		}
-		if s.uintbuf[start] == 0 {
+		if buf[start] == 0 {
 			// Note: readUint is also used to decode integer
 			// values. The error needs to be adjusted to become
 			// ErrCanonInt in this case.
 			return 0, ErrCanonSize
 		}
-		return binary.BigEndian.Uint64(s.uintbuf), nil
+		return binary.BigEndian.Uint64(buf), nil
 	}
 }
 
@@ -1025,3 +1078,25 @@ func (s *Stream) willRead(n uint64) error {
 	}
 	return nil
 }
+
+// sliceReader reads from a byte slice, consuming it as it goes. Unlike
+// bytes.Reader it needs no allocation when decoding with DecodeBytes.
+type sliceReader []byte
+
+func (sr *sliceReader) Read(b []byte) (int, error) {
+	if len(*sr) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, *sr)
+	*sr = (*sr)[n:]
+	return n, nil
+}
+
+func (sr *sliceReader) ReadByte() (byte, error) {
+	if len(*sr) == 0 {
+		return 0, io.EOF
+	}
+	b := (*sr)[0]
+	*sr = (*sr)[1:]
+	return b, nil
+}
@@ -771,3 +771,55 @@ func unhex(str string) []byte {
 	}
 	return b
 }
+
+func TestStreamBigInt(t *testing.T) {
+	large := new(big.Int).Lsh(big.NewInt(1), 300)
+	enc, _ := EncodeToBytes([]*big.Int{big.NewInt(0), big.NewInt(5), big.NewInt(0xffffff), large})
+
+	s := NewStream(bytes.NewReader(enc), 0)
+	if _, err := s.List(); err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []*big.Int{big.NewInt(0), big.NewInt(5), big.NewInt(0xffffff), large} {
+		v, err := s.BigInt()
+		if err != nil {
+			t.Fatalf("value %d: %v", i, err)
+		}
+		if v.Cmp(want) != 0 {
+			t.Errorf("value %d: got %v, want %v", i, v, want)
+		}
+	}
+	if _, err := s.BigInt(); err != EOL {
+		t.Errorf("expected EOL, got %v", err)
+	}
+
+	// Integers decoded through the auxiliary buffer must not alias it.
+	var vals []*big.Int
+	if err := DecodeBytes(unhex("C88301020383040506"), &vals); err != nil {
+		t.Fatal(err)
+	}
+	if vals[0].Cmp(big.NewInt(0x010203)) != 0 || vals[1].Cmp(big.NewInt(0x040506)) != 0 {
+		t.Errorf("wrong values: %v", vals)
+	}
+}
+
+func BenchmarkDecodeBigInts(b *testing.B) {
+	ints := make([]*big.Int, 200)
+	for i := range ints {
+		ints[i] = new(big.Int).Lsh(big.NewInt(int64(i)), 200)
+	}
+	enc, err := EncodeToBytes(ints)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(enc)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var out []*big.Int
+	for i := 0; i < b.N; i++ {
+		if err := DecodeBytes(enc, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webchain-network/webchaind/logger"
+	"github.com/webchain-network/webchaind/logger/glog"
+)
+
+// apiKeyUsageSaveInterval is how often the request counts of the API keys are
+// written to their file at most while requests are served. Requests don't wait
+// for the counts to be written.
+const apiKeyUsageSaveInterval = 10 * time.Second
+
+// APIKey grants a client of the HTTP and websocket interfaces access to some
+// methods, up to a daily number of requests.
+type APIKey struct {
+	Key     string   `json:"key"`
+	Name    string   `json:"name,omitempty"`    // Description of the client or tier
+	Methods []string `json:"methods,omitempty"` // Namespaces (e.g. "eth") or methods (e.g. "eth_call") allowed, all if empty
+	Quota   uint64   `json:"quota,omitempty"`   // Requests allowed per UTC day, unlimited if 0
+}
+
+// allows reports whether the key may call the given method.
+func (k *APIKey) allows(service, method string) bool {
+	if len(k.Methods) == 0 {
+		return true
+	}
+	for _, name := range k.Methods {
+		if name == service || name == service+serviceMethodSeparator+method {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadAPIKeys reads the API keys stored as a JSON array in the file at path.
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(blob, &keys); err != nil {
+		return nil, fmt.Errorf("invalid API keys %s: %v", path, err)
+	}
+	return keys, nil
+}
+
+// apiKeyUsage is the number of requests made with each API key in a day.
+type apiKeyUsage struct {
+	Day      string            `json:"day"` // UTC day, e.g. "2018-06-01"
+	Requests map[string]uint64 `json:"requests"`
+}
+
+// APIKeys authenticates the clients of a server by API key, enforcing the
+// methods and daily quota of their key. Clients pass their key as a bearer
+// token of the Authorization header of their HTTP requests or websocket
+// handshake. Keys aren't accepted in URLs, which end up in logs and histories.
+//
+// The request counts are saved to a JSON file, so quotas hold across restarts.
+// A single APIKeys can be shared by several servers, the quotas then apply
+// across them.
+type APIKeys struct {
+	keys map[string]*APIKey
+	path string // Empty for counts kept in memory only
+
+	mu     sync.Mutex
+	usage  apiKeyUsage
+	dirty  bool      // whether the counts changed since they were saved
+	saving bool      // whether the counts are being saved in the background
+	saved  time.Time // when the counts were last scheduled to be saved
+	now    func() time.Time
+
+	fileMu sync.Mutex // Lock serialising writes of the counts file
+}
+
+// NewAPIKeys creates an authenticator accepting the given keys, counting their
+// requests in the file at path, which doesn't have to exist yet. An empty path
+// keeps the counts in memory only.
+func NewAPIKeys(keys []APIKey, path string) (*APIKeys, error) {
+	k := &APIKeys{
+		keys:  make(map[string]*APIKey, len(keys)),
+		path:  path,
+		usage: apiKeyUsage{Requests: make(map[string]uint64)},
+		now:   time.Now,
+	}
+	for i := range keys {
+		key := keys[i]
+		if key.Key == "" {
+			return nil, fmt.Errorf("API key %d is empty", i)
+		}
+		if _, ok := k.keys[key.Key]; ok {
+			return nil, fmt.Errorf("API key %d duplicates an earlier one", i)
+		}
+		k.keys[key.Key] = &key
+	}
+	if path == "" {
+		return k, nil
+	}
+	blob, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(blob, &k.usage); err != nil {
+		return nil, fmt.Errorf("invalid API key usage %s: %v", path, err)
+	}
+	if k.usage.Requests == nil {
+		k.usage.Requests = make(map[string]uint64)
+	}
+	return k, nil
+}
+
+// Usage returns the number of requests made with key today.
+func (k *APIKeys) Usage(key string) uint64 {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.rollover()
+	return k.usage.Requests[key]
+}
+
+// authorize checks that key may call the given method and counts the request
+// against its quota.
+func (k *APIKeys) authorize(key, service, method string) RPCError {
+	if key == "" {
+		return &unauthorizedError{"missing API key"}
+	}
+	grant, ok := k.keys[key]
+	if !ok {
+		return &unauthorizedError{"invalid API key"}
+	}
+	if !grant.allows(service, method) {
+		return &unauthorizedError{fmt.Sprintf("API key not allowed to call %s%s%s", service, serviceMethodSeparator, method)}
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.rollover()
+	if grant.Quota > 0 && k.usage.Requests[key] >= grant.Quota {
+		return &quotaExceededError{grant.Quota}
+	}
+	k.usage.Requests[key]++
+	k.dirty = true
+
+	if k.path != "" && !k.saving && k.now().Sub(k.saved) >= apiKeyUsageSaveInterval {
+		k.saving, k.saved = true, k.now()
+		go func() {
+			if err := k.Flush(); err != nil {
+				glog.V(logger.Error).Infof("Failed to save API key usage: %v", err)
+			}
+			k.mu.Lock()
+			k.saving = false
+			k.mu.Unlock()
+		}()
+	}
+	return nil
+}
+
+// rollover resets the counts once the day they were made on is over. The lock
+// must be held.
+func (k *APIKeys) rollover() {
+	if day := k.now().UTC().Format("2006-01-02"); day != k.usage.Day {
+		k.usage = apiKeyUsage{Day: day, Requests: make(map[string]uint64)}
+		k.dirty = true
+	}
+}
+
+// Flush saves the request counts not saved yet. The counts are only locked
+// while they're encoded, requests are served while the file is written.
+func (k *APIKeys) Flush() error {
+	k.fileMu.Lock()
+	defer k.fileMu.Unlock()
+
+	k.mu.Lock()
+	if k.path == "" || !k.dirty {
+		k.mu.Unlock()
+		return nil
+	}
+	blob, err := json.MarshalIndent(k.usage, "", "  ")
+	k.dirty = err != nil
+	k.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := k.write(blob); err != nil {
+		k.mu.Lock()
+		k.dirty = true
+		k.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// write replaces the counts file with blob.
+func (k *APIKeys) write(blob []byte) error {
+	tmp := k.path + ".new"
+	if err := ioutil.WriteFile(tmp, blob, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, k.path)
+}
+
+// requestAPIKey returns the API key the Authorization header of an HTTP
+// request or websocket handshake carries, if any.
+func requestAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return ""
+}
@@ -0,0 +1,251 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// postRequest calls method over HTTP, passing the given Authorization header.
+func postRequest(t *testing.T, url, method, auth string) *JSONResponse {
+	body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":["x"]}`
+	req, _ := http.NewRequest("POST", url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	res := new(JSONResponse)
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestAPIKeysHTTP(t *testing.T) {
+	keys, err := NewAPIKeys([]APIKey{
+		{Key: "free", Methods: []string{"test_echo"}, Quota: 2},
+		{Key: "paid", Methods: []string{"test", "rpc"}},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	server.SetAPIKeys(keys)
+	if err := server.RegisterName("test", new(EchoService)); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterName("other", new(BlockingService)); err != nil {
+		t.Fatal(err)
+	}
+	httpsrv := httptest.NewServer(NewHTTPServer("*", HTTPTimeouts{}, server).Handler)
+	defer httpsrv.Close()
+
+	tests := []struct {
+		url, method, auth string
+		code              int // expected error code, 0 for success
+	}{
+		{httpsrv.URL, "test_echo", "", -32002},
+		{httpsrv.URL, "test_echo", "Bearer unknown", -32002},
+		{httpsrv.URL, "test_echo", "Bearer free", 0},
+		{httpsrv.URL + "/?apikey=free", "test_echo", "", -32002}, // keys aren't taken from URLs
+		{httpsrv.URL, "test_echo", "Bearer free", 0},
+		{httpsrv.URL, "test_echo", "Bearer free", -32005}, // quota used up
+		{httpsrv.URL, "rpc_modules", "Bearer free", -32002},
+		{httpsrv.URL, "rpc_modules", "Bearer paid", 0},
+		{httpsrv.URL, "other_quick", "Bearer paid", -32002},
+		{httpsrv.URL + "/?apikey=paid", "test_echo", "", -32002},
+	}
+	for i, tt := range tests {
+		res := postRequest(t, tt.url, tt.method, tt.auth)
+		switch {
+		case tt.code == 0 && res.Error != nil:
+			t.Errorf("test %d: %s failed: %s", i, tt.method, res.Error.Message)
+		case tt.code != 0 && (res.Error == nil || res.Error.Code != tt.code):
+			t.Errorf("test %d: %s error mismatch: have %+v, want code %d", i, tt.method, res.Error, tt.code)
+		}
+	}
+	if n := keys.Usage("free"); n != 2 {
+		t.Errorf("usage mismatch: have %d, want 2", n)
+	}
+}
+
+func TestAPIKeysQuotaPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apikeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "usage.json")
+
+	day := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	grants := []APIKey{{Key: "k", Quota: 3}}
+	keys, err := NewAPIKeys(grants, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.now = func() time.Time { return day }
+	for i := 0; i < 2; i++ {
+		if err := keys.authorize("k", "eth", "call"); err != nil {
+			t.Fatalf("request %d refused: %v", i, err)
+		}
+	}
+	if err := keys.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The count survives a restart, so the quota runs out after one more request
+	keys, err = NewAPIKeys(grants, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.now = func() time.Time { return day }
+	if err := keys.authorize("k", "eth", "call"); err != nil {
+		t.Fatalf("request within quota refused: %v", err)
+	}
+	if err := keys.authorize("k", "eth", "call"); err == nil {
+		t.Fatal("request above quota accepted")
+	}
+	// and is reset the next day
+	keys.now = func() time.Time { return day.Add(12 * time.Hour) }
+	if err := keys.authorize("k", "eth", "call"); err != nil {
+		t.Fatalf("request on the next day refused: %v", err)
+	}
+	if n := keys.Usage("k"); n != 1 {
+		t.Errorf("usage mismatch: have %d, want 1", n)
+	}
+}
+
+// keyedConn is a connection carrying an API key, as websocket connections do.
+type keyedConn struct {
+	net.Conn
+	key string
+}
+
+func (c *keyedConn) APIKey() string { return c.key }
+
+func TestAPIKeysUnsubscribe(t *testing.T) {
+	keys, err := NewAPIKeys([]APIKey{
+		{Key: "eth", Methods: []string{"eth"}},
+		{Key: "test", Methods: []string{"test"}},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	server.SetAPIKeys(keys)
+	if err := server.RegisterName("eth", new(NotificationTestService)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key  string
+		code int // expected error code
+	}{
+		{"", -32002},
+		{"test", -32002},
+		{"eth", -32000}, // authorized, but no such subscription
+	}
+	for i, tt := range tests {
+		clientConn, serverConn := net.Pipe()
+		go server.ServeCodec(NewJSONCodec(&keyedConn{serverConn, tt.key}), OptionMethodInvocation|OptionSubscriptions)
+
+		request := map[string]interface{}{
+			"id":      1,
+			"method":  "eth_unsubscribe",
+			"version": "2.0",
+			"params":  []interface{}{"0x1"},
+		}
+		if err := json.NewEncoder(clientConn).Encode(request); err != nil {
+			t.Fatal(err)
+		}
+		var res JSONResponse
+		if err := json.NewDecoder(clientConn).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Error == nil || res.Error.Code != tt.code {
+			t.Errorf("test %d: error mismatch: have %+v, want code %d", i, res.Error, tt.code)
+		}
+		clientConn.Close()
+	}
+	if n := keys.Usage("eth"); n != 1 {
+		t.Errorf("usage mismatch: have %d, want 1", n)
+	}
+}
+
+func TestAPIKeysBackgroundSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "apikeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "usage.json")
+
+	keys, err := NewAPIKeys([]APIKey{{Key: "k"}}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Hold the file lock, the request is served all the same
+	keys.fileMu.Lock()
+	if err := keys.authorize("k", "eth", "call"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("counts saved on the request path: %v", err)
+	}
+	keys.fileMu.Unlock()
+
+	// and the counts are written once the lock is released
+	for i := 0; ; i++ {
+		if blob, err := ioutil.ReadFile(path); err == nil {
+			var usage apiKeyUsage
+			if err := json.Unmarshal(blob, &usage); err != nil {
+				t.Fatal(err)
+			}
+			if usage.Requests["k"] != 1 {
+				t.Fatalf("saved usage mismatch: have %d, want 1", usage.Requests["k"])
+			}
+			break
+		}
+		if i == 100 {
+			t.Fatal("counts not saved in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewAPIKeysInvalid(t *testing.T) {
+	if _, err := NewAPIKeys([]APIKey{{Key: ""}}, ""); err == nil {
+		t.Error("empty key accepted")
+	}
+	if _, err := NewAPIKeys([]APIKey{{Key: "a"}, {Key: "a"}}, ""); err == nil {
+		t.Error("duplicate key accepted")
+	}
+}
@@ -95,3 +95,71 @@ func (e *shutdownError) Code() int {
 func (e *shutdownError) Error() string {
 	return "server is shutting down"
 }
+
+// issued when a request can't get an execution slot because too many requests
+// of its namespace or method are being served.
+type serverBusyError struct {
+	name  string
+	limit int
+}
+
+func (e *serverBusyError) Code() int {
+	return -32005
+}
+
+func (e *serverBusyError) Error() string {
+	return fmt.Sprintf("server busy: too many concurrent %s requests (limit %d), try again later", e.name, e.limit)
+}
+
+// issued when a request exceeds the size limit of the connection.
+type requestTooLargeError struct {
+	limit int
+}
+
+func (e *requestTooLargeError) Code() int {
+	return -32600
+}
+
+func (e *requestTooLargeError) Error() string {
+	return fmt.Sprintf("request too large: exceeds limit of %d bytes", e.limit)
+}
+
+// issued in place of a response exceeding the size limit of the connection.
+type responseTooLargeError struct {
+	size, limit int
+}
+
+func (e *responseTooLargeError) Code() int {
+	return -32003
+}
+
+func (e *responseTooLargeError) Error() string {
+	return fmt.Sprintf("response too large: %d bytes exceeds limit of %d bytes", e.size, e.limit)
+}
+
+// issued when a request lacks a valid API key or calls a method its key doesn't
+// allow.
+type unauthorizedError struct {
+	message string
+}
+
+func (e *unauthorizedError) Code() int {
+	return -32002
+}
+
+func (e *unauthorizedError) Error() string {
+	return "unauthorized: " + e.message
+}
+
+// issued when the API key of a request used up its daily quota.
+type quotaExceededError struct {
+	quota uint64
+}
+
+func (e *quotaExceededError) Code() int {
+	return -32005
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("quota exceeded: API key limited to %d requests a day", e.quota)
+}
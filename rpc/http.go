@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/rs/cors"
 )
@@ -32,6 +33,51 @@ const (
 	maxHTTPRequestContentLength = 1024 * 128
 )
 
+// HTTPTimeouts limits the time and header size an HTTP RPC client may take, so
+// slow clients can't hold on to server connections. Zero fields use the
+// values of DefaultHTTPTimeouts.
+type HTTPTimeouts struct {
+	// ReadTimeout is the maximum duration for reading an entire request,
+	// including the body.
+	ReadTimeout time.Duration
+
+	// WriteTimeout is the maximum duration before timing out writes of the
+	// response. It is reset whenever a new request's header is read.
+	WriteTimeout time.Duration
+
+	// IdleTimeout is the maximum time to wait for the next request on a
+	// keep-alive connection.
+	IdleTimeout time.Duration
+
+	// MaxHeaderBytes is the maximum size of request headers.
+	MaxHeaderBytes int
+}
+
+// DefaultHTTPTimeouts are the timeouts used by the HTTP RPC server by default.
+var DefaultHTTPTimeouts = HTTPTimeouts{
+	ReadTimeout:    30 * time.Second,
+	WriteTimeout:   30 * time.Second,
+	IdleTimeout:    120 * time.Second,
+	MaxHeaderBytes: 1 << 20,
+}
+
+// withDefaults returns the timeouts with unset fields taken from DefaultHTTPTimeouts.
+func (t HTTPTimeouts) withDefaults() HTTPTimeouts {
+	if t.ReadTimeout <= 0 {
+		t.ReadTimeout = DefaultHTTPTimeouts.ReadTimeout
+	}
+	if t.WriteTimeout <= 0 {
+		t.WriteTimeout = DefaultHTTPTimeouts.WriteTimeout
+	}
+	if t.IdleTimeout <= 0 {
+		t.IdleTimeout = DefaultHTTPTimeouts.IdleTimeout
+	}
+	if t.MaxHeaderBytes <= 0 {
+		t.MaxHeaderBytes = DefaultHTTPTimeouts.MaxHeaderBytes
+	}
+	return t
+}
+
 // httpClient connects to a geth RPC server over HTTP.
 type httpClient struct {
 	endpoint   string      // HTTP-RPC server endpoint
@@ -82,6 +128,8 @@ func (client *httpClient) SupportedModules() (map[string]string, error) {
 type httpReadWriteNopCloser struct {
 	io.Reader
 	io.Writer
+	remoteAddr string
+	apiKey     string
 }
 
 // Close does nothing and returns always nil
@@ -89,6 +137,16 @@ func (t *httpReadWriteNopCloser) Close() error {
 	return nil
 }
 
+// Origin returns the address of the HTTP client.
+func (t *httpReadWriteNopCloser) Origin() string {
+	return "http " + t.remoteAddr
+}
+
+// APIKey returns the API key the HTTP client passed.
+func (t *httpReadWriteNopCloser) APIKey() string {
+	return t.apiKey
+}
+
 // newJSONHTTPHandler creates a HTTP handler that will parse incoming JSON requests,
 // send the request to the given API provider and sends the response back to the caller.
 func newJSONHTTPHandler(srv *Server) http.HandlerFunc {
@@ -105,14 +163,15 @@ func newJSONHTTPHandler(srv *Server) http.HandlerFunc {
 		// create a codec that reads direct from the request body until
 		// EOF and writes the response to w and order the server to process
 		// a single request.
-		codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w})
+		codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w, r.RemoteAddr, requestAPIKey(r)})
 		defer codec.Close()
 		srv.ServeSingleRequest(codec, OptionMethodInvocation)
 	}
 }
 
-// NewHTTPServer creates a new HTTP RPC server around an API provider.
-func NewHTTPServer(corsString string, srv *Server) *http.Server {
+// NewHTTPServer creates a new HTTP RPC server around an API provider, applying
+// the given timeouts to client connections.
+func NewHTTPServer(corsString string, timeouts HTTPTimeouts, srv *Server) *http.Server {
 	var allowedOrigins []string
 	for _, domain := range strings.Split(corsString, ",") {
 		allowedOrigins = append(allowedOrigins, strings.TrimSpace(domain))
@@ -125,7 +184,12 @@ func NewHTTPServer(corsString string, srv *Server) *http.Server {
 
 	handler := c.Handler(newJSONHTTPHandler(srv))
 
+	timeouts = timeouts.withDefaults()
 	return &http.Server{
-		Handler: handler,
+		Handler:        handler,
+		ReadTimeout:    timeouts.ReadTimeout,
+		WriteTimeout:   timeouts.WriteTimeout,
+		IdleTimeout:    timeouts.IdleTimeout,
+		MaxHeaderBytes: timeouts.MaxHeaderBytes,
 	}
 }
@@ -0,0 +1,88 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPTimeoutDefaults(t *testing.T) {
+	srv := NewHTTPServer("*", HTTPTimeouts{ReadTimeout: time.Second}, NewServer())
+	if srv.ReadTimeout != time.Second {
+		t.Errorf("read timeout mismatch: have %v, want %v", srv.ReadTimeout, time.Second)
+	}
+	if srv.WriteTimeout != DefaultHTTPTimeouts.WriteTimeout || srv.IdleTimeout != DefaultHTTPTimeouts.IdleTimeout || srv.MaxHeaderBytes != DefaultHTTPTimeouts.MaxHeaderBytes {
+		t.Errorf("unset limits not defaulted: write %v, idle %v, header %d", srv.WriteTimeout, srv.IdleTimeout, srv.MaxHeaderBytes)
+	}
+}
+
+func TestHTTPSlowClient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewHTTPServer("*", HTTPTimeouts{ReadTimeout: 100 * time.Millisecond}, NewServer())
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	// A client trickling its request header is disconnected.
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("POST / HTTP/1.1\r\nHost: localhost\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := ioutil.ReadAll(conn); err != nil {
+		t.Fatalf("connection not closed by the server: %v", err)
+	}
+}
+
+func TestHTTPMaxHeaderBytes(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewHTTPServer("*", HTTPTimeouts{MaxHeaderBytes: 1024}, NewServer())
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	req := "POST / HTTP/1.1\r\nHost: localhost\r\nX-Padding: " + strings.Repeat("a", 8192) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(req)); err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("status mismatch: have %d, want %d", res.StatusCode, http.StatusRequestHeaderFieldsTooLarge)
+	}
+}
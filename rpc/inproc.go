@@ -30,6 +30,16 @@ type inProcClient struct {
 	dec    *json.Decoder
 }
 
+// inProcConn is the server end of an in-process client's stream.
+type inProcConn struct {
+	net.Conn
+}
+
+// Origin marks requests as coming from within the process.
+func (inProcConn) Origin() string {
+	return "inproc"
+}
+
 // Close tears down the request channel of the in-proc client.
 func (c *inProcClient) Close() {
 	c.cl.Close()
@@ -39,7 +49,7 @@ func (c *inProcClient) Close() {
 // RPC server.
 func NewInProcRPCClient(handler *Server) Client {
 	p1, p2 := net.Pipe()
-	go handler.ServeCodec(NewJSONCodec(p1), OptionMethodInvocation|OptionSubscriptions)
+	go handler.ServeCodec(NewJSONCodec(inProcConn{p1}), OptionMethodInvocation|OptionSubscriptions)
 	return &inProcClient{handler, p2, json.NewEncoder(p2), json.NewDecoder(p2)}
 }
 
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"reflect"
 	"strconv"
 	"strings"
@@ -83,6 +84,9 @@ type jsonCodec struct {
 	encMu  sync.Mutex         // guards e
 	e      *json.Encoder      // encodes responses
 	rw     io.ReadWriteCloser // connection
+
+	limits MessageLimits  // message size limits (none if not positive)
+	lr     *limitedReader // enforces the request size limit (nil if none)
 }
 
 // NewJSONCodec creates a new RPC server codec with support for JSON-RPC 2.0
@@ -92,6 +96,50 @@ func NewJSONCodec(rwc io.ReadWriteCloser) ServerCodec {
 	return &jsonCodec{closed: make(chan interface{}), d: d, e: json.NewEncoder(rwc), rw: rwc}
 }
 
+// NewJSONCodecWithLimits creates a new RPC server codec with support for
+// JSON-RPC 2.0, exchanging messages no larger than the given limits.
+func NewJSONCodecWithLimits(rwc io.ReadWriteCloser, limits MessageLimits) ServerCodec {
+	limits = limits.withDefaults()
+	c := &jsonCodec{closed: make(chan interface{}), e: json.NewEncoder(rwc), rw: rwc, limits: limits}
+	if limits.Request > 0 {
+		c.lr = newLimitedReader(rwc, limits.Request)
+		c.d = json.NewDecoder(c.lr)
+	} else {
+		c.d = json.NewDecoder(rwc)
+	}
+	c.d.UseNumber()
+	return c
+}
+
+// APIKey returns the API key the client of the connection passed, if any.
+func (c *jsonCodec) APIKey() string {
+	if k, ok := c.rw.(interface {
+		APIKey() string
+	}); ok {
+		return k.APIKey()
+	}
+	return ""
+}
+
+// Origin describes the remote end of the connection: the transport and, where
+// known, the client address.
+func (c *jsonCodec) Origin() string {
+	if o, ok := c.rw.(interface {
+		Origin() string
+	}); ok {
+		return o.Origin()
+	}
+	if conn, ok := c.rw.(net.Conn); ok {
+		switch addr := conn.RemoteAddr(); addr.Network() {
+		case "unix", "pipe":
+			return "ipc"
+		default:
+			return addr.Network() + " " + addr.String()
+		}
+	}
+	return ""
+}
+
 // isBatch returns true when the first non-whitespace characters is '['
 func isBatch(msg json.RawMessage) bool {
 	for _, c := range msg {
@@ -111,8 +159,14 @@ func (c *jsonCodec) ReadRequestHeaders() ([]rpcRequest, bool, RPCError) {
 	c.decMu.Lock()
 	defer c.decMu.Unlock()
 
+	if c.lr != nil {
+		c.lr.next(c.d.InputOffset())
+	}
 	var incomingMsg json.RawMessage
 	if err := c.d.Decode(&incomingMsg); err != nil {
+		if tooLarge, ok := err.(*requestTooLargeError); ok {
+			return nil, false, tooLarge
+		}
 		return nil, false, &invalidRequestError{err.Error()}
 	}
 
@@ -172,7 +226,7 @@ func parseRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, RPCError) {
 	}
 
 	if in.Method == unsubscribeMethod {
-		return []rpcRequest{{id: &in.Id, isPubSub: true,
+		return []rpcRequest{{id: &in.Id, isPubSub: true, service: "eth",
 			method: unsubscribeMethod, params: in.Payload}}, false, nil
 	}
 
@@ -226,7 +280,7 @@ func parseBatchRequest(incomingMsg json.RawMessage) ([]rpcRequest, bool, RPCErro
 		}
 
 		if r.Method == unsubscribeMethod {
-			requests[i] = rpcRequest{id: id, isPubSub: true, method: unsubscribeMethod, params: r.Payload}
+			requests[i] = rpcRequest{id: id, isPubSub: true, service: "eth", method: unsubscribeMethod, params: r.Payload}
 			continue
 		}
 
@@ -329,7 +383,26 @@ func (c *jsonCodec) Write(res interface{}) error {
 	c.encMu.Lock()
 	defer c.encMu.Unlock()
 
-	return c.e.Encode(res)
+	if c.limits.Response <= 0 {
+		return c.e.Encode(res)
+	}
+	enc, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	if len(enc) > c.limits.Response {
+		tooLarge := &responseTooLargeError{len(enc), c.limits.Response}
+		replaced, ok := errorResponses(res, tooLarge)
+		if !ok {
+			return tooLarge
+		}
+		glog.V(logger.Debug).Infof("%s: %v", c.Origin(), tooLarge)
+		if enc, err = json.Marshal(replaced); err != nil {
+			return err
+		}
+	}
+	_, err = c.rw.Write(append(enc, '\n'))
+	return err
 }
 
 // Close the underlying connection
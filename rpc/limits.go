@@ -0,0 +1,235 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultConcurrencyQueueTimeout is how long a request waits for a free
+// execution slot before it's rejected, unless configured otherwise.
+const DefaultConcurrencyQueueTimeout = 10 * time.Second
+
+// ConcurrencyLimits caps how many requests of a namespace (e.g. "debug") or a
+// single method (e.g. "eth_call") execute at the same time. Requests above a
+// cap wait for a free slot in a bounded queue and fail with a server busy error
+// if the queue is full or they time out.
+//
+// A single ConcurrencyLimits can be shared by several servers, so the caps
+// apply across all RPC transports.
+type ConcurrencyLimits struct {
+	slots   map[string]*execSlots // keyed by namespace or namespace_method
+	queue   int32                 // maximum number of requests waiting per cap
+	timeout time.Duration         // maximum time a request waits
+}
+
+// execSlots limits the concurrent executions of a namespace or method.
+type execSlots struct {
+	name    string
+	sem     chan struct{}
+	waiting int32 // number of requests queued for a slot, atomically accessed
+}
+
+// NewConcurrencyLimits creates the caps given as name to maximum concurrent
+// executions. Up to queue requests per cap wait for at most timeout for a
+// slot, zero timeout defaults to DefaultConcurrencyQueueTimeout.
+func NewConcurrencyLimits(limits map[string]int, queue int, timeout time.Duration) (*ConcurrencyLimits, error) {
+	if queue < 0 {
+		return nil, fmt.Errorf("invalid queue size %d", queue)
+	}
+	if timeout <= 0 {
+		timeout = DefaultConcurrencyQueueTimeout
+	}
+	l := &ConcurrencyLimits{
+		slots:   make(map[string]*execSlots, len(limits)),
+		queue:   int32(queue),
+		timeout: timeout,
+	}
+	for name, limit := range limits {
+		if limit <= 0 {
+			return nil, fmt.Errorf("invalid concurrency limit %d for %s", limit, name)
+		}
+		l.slots[name] = &execSlots{name: name, sem: make(chan struct{}, limit)}
+	}
+	return l, nil
+}
+
+// ParseConcurrencyLimits parses caps given as a comma separated list of
+// name=limit pairs, e.g. "debug=2,eth_call=8".
+func ParseConcurrencyLimits(spec string) (map[string]int, error) {
+	limits := make(map[string]int)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid concurrency limit %q, want name=limit", entry)
+		}
+		limit, err := strconv.Atoi(parts[1])
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid concurrency limit %q, want a positive number", entry)
+		}
+		limits[parts[0]] = limit
+	}
+	return limits, nil
+}
+
+// String returns the caps in the format accepted by ParseConcurrencyLimits.
+func (l *ConcurrencyLimits) String() string {
+	entries := make([]string, 0, len(l.slots))
+	for name, slots := range l.slots {
+		entries = append(entries, fmt.Sprintf("%s=%d", name, cap(slots.sem)))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// acquire waits for an execution slot of the given method, preferring a cap
+// of the method over one of its namespace. The returned function releases the
+// slot, it's nil if the method isn't capped.
+func (l *ConcurrencyLimits) acquire(ctx context.Context, service, method string) (func(), RPCError) {
+	slots := l.slots[service+serviceMethodSeparator+method]
+	if slots == nil {
+		if slots = l.slots[service]; slots == nil {
+			return nil, nil
+		}
+	}
+	release := func() { <-slots.sem }
+
+	// Take a free slot right away if possible
+	select {
+	case slots.sem <- struct{}{}:
+		return release, nil
+	default:
+	}
+	// Otherwise queue up, unless too many requests are waiting already
+	if atomic.AddInt32(&slots.waiting, 1) > l.queue {
+		atomic.AddInt32(&slots.waiting, -1)
+		return nil, &serverBusyError{slots.name, cap(slots.sem)}
+	}
+	defer atomic.AddInt32(&slots.waiting, -1)
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+
+	select {
+	case slots.sem <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, &serverBusyError{slots.name, cap(slots.sem)}
+	case <-ctx.Done():
+		return nil, &serverBusyError{slots.name, cap(slots.sem)}
+	}
+}
+
+// DefaultMessageLimits are the message sizes accepted and sent over IPC and
+// websocket connections unless configured otherwise.
+var DefaultMessageLimits = MessageLimits{
+	Request:  16 * 1024 * 1024,
+	Response: 128 * 1024 * 1024,
+}
+
+// MessageLimits caps the size in bytes of the JSON messages exchanged over a
+// stream connection. Zero uses the default limit, a negative size disables it.
+//
+// A request above the limit fails and the connection is closed, since the rest
+// of the stream can't be parsed. A response above the limit is replaced by an
+// error response with the same id, so clients tell it apart from a broken
+// connection. A notification above the limit ends the subscriptions of the
+// connection, like any failure to deliver one.
+type MessageLimits struct {
+	Request  int // Largest request read, including the requests of a batch
+	Response int // Largest response written, including the responses of a batch
+}
+
+// withDefaults returns the limits with unset fields taken from DefaultMessageLimits.
+func (l MessageLimits) withDefaults() MessageLimits {
+	if l.Request == 0 {
+		l.Request = DefaultMessageLimits.Request
+	}
+	if l.Response == 0 {
+		l.Response = DefaultMessageLimits.Response
+	}
+	return l
+}
+
+// limitedReader fails reads beyond the size limit of the message being read.
+// The limit counts from the stream offset where the decoder finished the
+// previous message, so bytes the decoder reads ahead of a message are charged
+// to the message they belong to and pipelined requests are measured exactly.
+type limitedReader struct {
+	r     io.Reader
+	limit int
+	read  int64 // bytes read from r so far
+	end   int64 // stream offset the current message must end by
+}
+
+func newLimitedReader(r io.Reader, limit int) *limitedReader {
+	return &limitedReader{r: r, limit: limit, end: int64(limit)}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	left := l.end - l.read
+	if left <= 0 {
+		return 0, &requestTooLargeError{l.limit}
+	}
+	if int64(len(p)) > left {
+		p = p[:left]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
+
+// next allows the message starting at the given stream offset, as reported by
+// json.Decoder.InputOffset, to be read.
+func (l *limitedReader) next(offset int64) {
+	l.end = offset + int64(l.limit)
+}
+
+// errorResponses replaces the response or batch of responses res by error
+// responses with the same ids. It returns false if res isn't a response, like a
+// notification.
+func errorResponses(res interface{}, err RPCError) (interface{}, bool) {
+	replace := func(res interface{}) (interface{}, bool) {
+		r, ok := res.(*JSONResponse)
+		if !ok {
+			return nil, false
+		}
+		return &JSONResponse{Version: JSONRPCVersion, Id: r.Id, Error: &JSONError{Code: err.Code(), Message: err.Error()}}, true
+	}
+	batch, ok := res.([]interface{})
+	if !ok {
+		return replace(res)
+	}
+	replaced := make([]interface{}, len(batch))
+	for i, r := range batch {
+		if replaced[i], ok = replace(r); !ok {
+			return nil, false
+		}
+	}
+	return replaced, true
+}
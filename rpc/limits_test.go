@@ -0,0 +1,233 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// BlockingService has a method which blocks until released.
+type BlockingService struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *BlockingService) Block() bool {
+	s.started <- struct{}{}
+	<-s.release
+	return true
+}
+
+func (s *BlockingService) Quick() bool {
+	return true
+}
+
+// startLimitedServer serves a blocking service under the "test" namespace.
+func startLimitedServer(t *testing.T, limits map[string]int, queue int, timeout time.Duration) (*Server, *BlockingService) {
+	l, err := NewConcurrencyLimits(limits, queue, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	server.SetConcurrencyLimits(l)
+	service := &BlockingService{started: make(chan struct{}, 10), release: make(chan struct{})}
+	if err := server.RegisterName("test", service); err != nil {
+		t.Fatal(err)
+	}
+	return server, service
+}
+
+// callAsync sends a request over a new connection, delivering the response.
+func callAsync(server *Server, method string) <-chan *JSONResponse {
+	clientConn, serverConn := net.Pipe()
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	resc := make(chan *JSONResponse, 1)
+	go func() {
+		defer clientConn.Close()
+		request := map[string]interface{}{"id": 1, "method": method, "version": "2.0", "params": []interface{}{}}
+		res := new(JSONResponse)
+		if err := json.NewEncoder(clientConn).Encode(request); err == nil {
+			json.NewDecoder(clientConn).Decode(res)
+		}
+		resc <- res
+	}()
+	return resc
+}
+
+func TestConcurrencyLimitQueue(t *testing.T) {
+	server, service := startLimitedServer(t, map[string]int{"test": 1}, 1, 5*time.Second)
+	defer server.Stop()
+
+	// The first request executes, the second one is queued.
+	first := callAsync(server, "test_block")
+	<-service.started
+	second := callAsync(server, "test_block")
+	slots := server.limits.slots["test"]
+	for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&slots.waiting) != 1; {
+		if time.Now().After(deadline) {
+			t.Fatal("second request not queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// With the queue full, further requests of the namespace are rejected.
+	res := <-callAsync(server, "test_quick")
+	if res.Error == nil || res.Error.Code != -32005 {
+		t.Fatalf("expected server busy error, got %+v", res)
+	}
+	// Releasing the executing request lets the queued one run.
+	close(service.release)
+	for _, resc := range []<-chan *JSONResponse{first, second} {
+		if res := <-resc; res.Error != nil || res.Result != true {
+			t.Errorf("request failed: %+v", res.Error)
+		}
+	}
+}
+
+func TestConcurrencyLimitTimeout(t *testing.T) {
+	server, service := startLimitedServer(t, map[string]int{"test_block": 1}, 1, 50*time.Millisecond)
+	defer server.Stop()
+	defer close(service.release)
+
+	callAsync(server, "test_block")
+	<-service.started
+
+	// The queued request times out, methods without a cap aren't affected.
+	if res := <-callAsync(server, "test_block"); res.Error == nil || res.Error.Code != -32005 {
+		t.Errorf("expected server busy error, got %+v", res)
+	}
+	if res := <-callAsync(server, "test_quick"); res.Error != nil {
+		t.Errorf("uncapped request failed: %+v", res.Error)
+	}
+}
+
+func TestParseConcurrencyLimits(t *testing.T) {
+	limits, err := ParseConcurrencyLimits(" debug=2, eth_call=8,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewConcurrencyLimits(limits, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := l.String(), "debug=2,eth_call=8"; have != want {
+		t.Errorf("limits mismatch: have %q, want %q", have, want)
+	}
+	for _, spec := range []string{"debug", "debug=0", "=2", "debug=x"} {
+		if _, err := ParseConcurrencyLimits(spec); err == nil {
+			t.Errorf("no error for invalid limits %q", spec)
+		}
+	}
+}
+
+// EchoService returns its argument.
+type EchoService struct{}
+
+func (s *EchoService) Echo(str string) string {
+	return str
+}
+
+func TestMessageLimits(t *testing.T) {
+	server := NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("test", new(EchoService)); err != nil {
+		t.Fatal(err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeCodec(NewJSONCodecWithLimits(serverConn, MessageLimits{Request: 200, Response: 100}), OptionMethodInvocation)
+
+	in, out := json.NewDecoder(clientConn), json.NewEncoder(clientConn)
+	call := func(str string) *JSONResponse {
+		res := new(JSONResponse)
+		request := map[string]interface{}{"id": 1, "method": "test_echo", "version": "2.0", "params": []string{str}}
+		go out.Encode(request)
+		if err := in.Decode(res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	// Responses above the limit are replaced by an error, keeping the connection.
+	if res := call("hello"); res.Error != nil || res.Result != "hello" {
+		t.Errorf("small response mismatch: %+v", res)
+	}
+	if res := call(strings.Repeat("x", 100)); res.Error == nil || res.Error.Code != -32003 {
+		t.Errorf("expected response too large error, got %+v", res)
+	}
+	if res := call("hello"); res.Error != nil || res.Result != "hello" {
+		t.Errorf("response after error mismatch: %+v", res)
+	}
+	// Requests above the limit fail and close the connection.
+	if res := call(strings.Repeat("x", 200)); res.Error == nil || res.Error.Code != -32600 {
+		t.Errorf("expected request too large error, got %+v", res)
+	}
+	if err := in.Decode(new(JSONResponse)); err == nil {
+		t.Error("connection not closed")
+	}
+}
+
+func TestMessageLimitsPipelined(t *testing.T) {
+	server := NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("test", new(EchoService)); err != nil {
+		t.Fatal(err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeCodec(NewJSONCodecWithLimits(serverConn, MessageLimits{Request: 200}), OptionMethodInvocation)
+
+	request := func(id int, str string) []byte {
+		msg, _ := json.Marshal(map[string]interface{}{"id": id, "method": "test_echo", "version": "2.0", "params": []string{str}})
+		return msg
+	}
+	// Requests sent in one go are each measured on their own, so three
+	// requests below the limit pass although together they exceed it.
+	var stream []byte
+	for i := 1; i <= 3; i++ {
+		stream = append(stream, request(i, strings.Repeat("x", 100))...)
+	}
+	// The read ahead of the previous requests doesn't extend the limit of
+	// the next one.
+	stream = append(stream, request(4, strings.Repeat("x", 200))...)
+	go clientConn.Write(stream)
+
+	// Requests run concurrently, so their responses arrive in any order.
+	in := json.NewDecoder(clientConn)
+	var ok, tooLarge int
+	for i := 0; i < 4; i++ {
+		res := new(JSONResponse)
+		if err := in.Decode(res); err != nil {
+			t.Fatalf("response %d: %v", i, err)
+		}
+		switch {
+		case res.Error == nil:
+			ok++
+		case res.Id == nil && res.Error.Code == -32600:
+			tooLarge++
+		default:
+			t.Errorf("unexpected response %+v", res)
+		}
+	}
+	if ok != 3 || tooLarge != 1 {
+		t.Errorf("got %d results and %d request too large errors, want 3 and 1", ok, tooLarge)
+	}
+}
@@ -19,6 +19,7 @@ package rpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -38,8 +39,55 @@ var (
 
 	// errNotificationQueueFull is returns when there are too many notifications in the queue
 	errNotificationQueueFull = errors.New("too many pending notifications")
+
+	// errSubscriptionBufferFull is returned when a subscription has too many notifications
+	// queued and the connection is dropped
+	errSubscriptionBufferFull = errors.New("too many pending notifications for subscription")
+)
+
+// SubscriptionPolicy decides what happens to a subscription whose consumer can't keep up
+// with its notifications.
+type SubscriptionPolicy int
+
+const (
+	// SubscriptionDisconnect closes the connection once a subscription's buffer is full.
+	SubscriptionDisconnect SubscriptionPolicy = iota
+	// SubscriptionDrop discards notifications while a subscription's buffer is full and
+	// tells the subscriber how many were lost once it has room again.
+	SubscriptionDrop
 )
 
+// DefaultSubscriptionBuffer is the default number of notifications a subscription may
+// have queued.
+const DefaultSubscriptionBuffer = notificationBufferSize
+
+// ParseSubscriptionPolicy parses a policy name, "disconnect" or "drop".
+func ParseSubscriptionPolicy(name string) (SubscriptionPolicy, error) {
+	switch name {
+	case "disconnect":
+		return SubscriptionDisconnect, nil
+	case "drop":
+		return SubscriptionDrop, nil
+	}
+	return 0, fmt.Errorf("unknown subscription policy %q, want \"disconnect\" or \"drop\"", name)
+}
+
+func (p SubscriptionPolicy) String() string {
+	switch p {
+	case SubscriptionDisconnect:
+		return "disconnect"
+	case SubscriptionDrop:
+		return "drop"
+	}
+	return fmt.Sprintf("SubscriptionPolicy(%d)", int(p))
+}
+
+// NotificationsDropped is sent on a subscription under the SubscriptionDrop policy before
+// the first notification accepted after others had to be discarded.
+type NotificationsDropped struct {
+	Dropped int `json:"droppedNotifications"`
+}
+
 // unsubSignal is a signal that the subscription is unsubscribed. It is used to flush buffered
 // notifications that might be pending in the internal queue.
 var unsubSignal = new(struct{})
@@ -81,6 +129,18 @@ type Subscription interface {
 	Cancel() error
 }
 
+// Throttler is implemented by subscriptions which let the producer of a long
+// notification stream wait for the connection to catch up, rather than
+// overflowing the notification queue.
+type Throttler interface {
+	// Throttle blocks until at most n notifications are queued on the
+	// subscription's connection. It fails once the subscription has ended.
+	Throttle(n int) error
+}
+
+// throttleInterval is the interval at which throttled producers check the queue.
+const throttleInterval = 10 * time.Millisecond
+
 // bufferedSubscription is a subscription that uses a bufferedNotifier to send
 // notifications to subscribers.
 type bufferedSubscription struct {
@@ -91,6 +151,8 @@ type bufferedSubscription struct {
 	pending          chan interface{}    // closed when active
 	flushed          chan interface{}    // closed when all buffered notifications are send
 	lastNotification time.Time           // last time a notification was send
+	queued           int                 // notifications in the notifier queue
+	dropped          int                 // notifications discarded since the last accepted one
 }
 
 // ID returns the subscription identifier that the client uses to refer to this instance.
@@ -108,6 +170,28 @@ func (s *bufferedSubscription) Notify(data interface{}) error {
 	return s.notifier.send(s.id, data)
 }
 
+// Throttle waits until at most n notifications are queued on the connection, and the
+// subscription has room in its own buffer.
+func (s *bufferedSubscription) Throttle(n int) error {
+	for {
+		s.notifier.mu.Lock()
+		_, active := s.notifier.subscriptions[s.id]
+		stopped, queued := s.notifier.stopped, len(s.notifier.queue)
+		full := s.queued >= s.notifier.subBufferSize
+		s.notifier.mu.Unlock()
+
+		switch {
+		case stopped:
+			return errNotifierStopped
+		case !active:
+			return ErrNotificationNotFound
+		case queued <= n && !full:
+			return nil
+		}
+		time.Sleep(throttleInterval)
+	}
+}
+
 // bufferedNotifier is a notifier that queues notifications in an internal queue and
 // send them as fast as possible to the client from this queue. It will stop if the
 // queue grows past a given size.
@@ -117,18 +201,23 @@ type bufferedNotifier struct {
 	subscriptions map[string]*bufferedSubscription // keep track of subscriptions associated with codec
 	queueSize     int                              // max number of items in queue
 	queue         chan *notification               // notification queue
+	subBufferSize int                              // max number of items in queue per subscription
+	subPolicy     SubscriptionPolicy               // what to do when a subscription's buffer is full
 	stopped       bool                             // indication if this notifier is ordered to stop
 }
 
 // newBufferedNotifier returns a notifier that queues notifications in an internal queue
 // from which notifications are send as fast as possible to the client. If the queue size
-// limit is reached (client is unable to keep up) it will stop and closes the codec.
-func newBufferedNotifier(codec ServerCodec, size int) *bufferedNotifier {
+// limit is reached (client is unable to keep up) it will stop and closes the codec. A
+// subscription with subSize notifications queued is handled according to policy.
+func newBufferedNotifier(codec ServerCodec, size, subSize int, policy SubscriptionPolicy) *bufferedNotifier {
 	notifier := &bufferedNotifier{
 		codec:         codec,
 		subscriptions: make(map[string]*bufferedSubscription),
 		queue:         make(chan *notification, size),
 		queueSize:     size,
+		subBufferSize: subSize,
+		subPolicy:     policy,
 	}
 
 	go notifier.run()
@@ -226,12 +315,35 @@ func (n *bufferedNotifier) send(id string, data interface{}) error {
 
 	subscription.lastNotification = time.Now()
 
-	if len(n.queue) >= n.queueSize {
+	if data != unsubSignal && subscription.queued >= n.subBufferSize {
+		if n.subPolicy == SubscriptionDrop {
+			subscription.dropped++
+			return nil
+		}
+		glog.V(logger.Warn).Infof("too many buffered notifications for subscription %s -> close connection\n", id)
+		n.codec.Close()
+		return errSubscriptionBufferFull
+	}
+	// make room for telling the subscriber about discarded notifications
+	required := 1
+	if data != unsubSignal && subscription.dropped > 0 {
+		required++
+	}
+	if len(n.queue)+required > n.queueSize {
 		glog.V(logger.Warn).Infoln("too many buffered notifications -> close connection")
 		n.codec.Close()
 		return errNotificationQueueFull
 	}
 
+	if data != unsubSignal {
+		if subscription.dropped > 0 {
+			glog.V(logger.Debug).Infof("dropped %d notifications for subscription %s\n", subscription.dropped, id)
+			n.queue <- &notification{subscription, &NotificationsDropped{Dropped: subscription.dropped}}
+			subscription.queued++
+			subscription.dropped = 0
+		}
+		subscription.queued++
+	}
 	n.queue <- &notification{subscription, data}
 	return nil
 }
@@ -277,6 +389,9 @@ func (n *bufferedNotifier) run() {
 					glog.V(logger.Warn).Infof("unable to send notification - %v\n", err)
 					return
 				}
+				n.mu.Lock()
+				notification.sub.queued--
+				n.mu.Unlock()
 			}
 		case <-n.codec.Closed(): // connection was closed
 			glog.V(logger.Debug).Infoln("codec closed, stop subscriptions")
@@ -20,11 +20,14 @@ import (
 	"context"
 	"encoding/json"
 	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
-type NotificationTestService struct{}
+type NotificationTestService struct {
+	sent int32 // notifications sent by ThrottledSubscription
+}
 
 var (
 	unsubCallbackCalled = false
@@ -59,6 +62,54 @@ func (s *NotificationTestService) SomeSubscription(ctx context.Context, n, val i
 	return subscription, nil
 }
 
+func (s *NotificationTestService) ThrottledSubscription(ctx context.Context, n, limit int) (Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	subscription, err := notifier.NewSubscription(nil)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := subscription.(Throttler).Throttle(limit); err != nil {
+				return
+			}
+			if err := subscription.Notify(i); err != nil {
+				return
+			}
+			atomic.StoreInt32(&s.sent, int32(i+1))
+		}
+	}()
+	return subscription, nil
+}
+
+// BurstSubscription sends n notifications at once, then val after its buffer
+// has drained.
+func (s *NotificationTestService) BurstSubscription(ctx context.Context, n, val int) (Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+	subscription, err := notifier.NewSubscription(nil)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := subscription.Notify(i); err != nil {
+				return
+			}
+		}
+		if err := subscription.(Throttler).Throttle(0); err != nil {
+			return
+		}
+		subscription.Notify(val)
+	}()
+	return subscription, nil
+}
+
 func TestNotifications(t *testing.T) {
 	server := NewServer()
 	service := &NotificationTestService{}
@@ -117,3 +168,141 @@ func TestNotifications(t *testing.T) {
 		t.Error("unsubscribe callback not called after closing connection")
 	}
 }
+
+func TestThrottledNotifications(t *testing.T) {
+	server := NewServer()
+	service := &NotificationTestService{}
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation|OptionSubscriptions)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	n, limit := 50, 2
+	request := map[string]interface{}{
+		"id":      1,
+		"method":  "eth_subscribe",
+		"version": "2.0",
+		"params":  []interface{}{"throttledSubscription", n, limit},
+	}
+	if err := out.Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	var response JSONResponse
+	if err := in.Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+
+	// While the client doesn't read, the producer may only fill the queue and
+	// hand one notification to the blocked writer.
+	time.Sleep(100 * time.Millisecond)
+	if sent := atomic.LoadInt32(&service.sent); sent > int32(limit+2) {
+		t.Errorf("producer not throttled: sent %d notifications", sent)
+	}
+	for i := 0; i < n; i++ {
+		var notification jsonNotification
+		if err := in.Decode(&notification); err != nil {
+			t.Fatal(err)
+		}
+		if int(notification.Params.Result.(float64)) != i {
+			t.Fatalf("expected %d, got %v", i, notification.Params.Result)
+		}
+	}
+}
+
+func TestDroppedNotifications(t *testing.T) {
+	server := NewServer()
+	server.SetSubscriptionLimits(3, SubscriptionDrop)
+	if err := server.RegisterName("eth", &NotificationTestService{}); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation|OptionSubscriptions)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	n, val := 10, 12345
+	request := map[string]interface{}{
+		"id":      1,
+		"method":  "eth_subscribe",
+		"version": "2.0",
+		"params":  []interface{}{"burstSubscription", n, val},
+	}
+	if err := out.Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	var response JSONResponse
+	if err := in.Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	// Let the burst overflow the buffer while the client isn't reading.
+	time.Sleep(100 * time.Millisecond)
+
+	var notification jsonNotification
+	for i := 0; i < 3; i++ {
+		if err := in.Decode(&notification); err != nil {
+			t.Fatal(err)
+		}
+		if int(notification.Params.Result.(float64)) != i {
+			t.Fatalf("expected %d, got %v", i, notification.Params.Result)
+		}
+	}
+	if err := in.Decode(&notification); err != nil {
+		t.Fatal(err)
+	}
+	dropped, ok := notification.Params.Result.(map[string]interface{})
+	if !ok || dropped["droppedNotifications"] != float64(n-3) {
+		t.Fatalf("expected %d dropped notifications, got %v", n-3, notification.Params.Result)
+	}
+	if err := in.Decode(&notification); err != nil {
+		t.Fatal(err)
+	}
+	if int(notification.Params.Result.(float64)) != val {
+		t.Fatalf("expected %d, got %v", val, notification.Params.Result)
+	}
+}
+
+func TestSubscriptionBufferDisconnect(t *testing.T) {
+	server := NewServer()
+	server.SetSubscriptionLimits(3, SubscriptionDisconnect)
+	if err := server.RegisterName("eth", &NotificationTestService{}); err != nil {
+		t.Fatalf("unable to register test service %v", err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation|OptionSubscriptions)
+
+	out := json.NewEncoder(clientConn)
+	in := json.NewDecoder(clientConn)
+
+	n := 10
+	request := map[string]interface{}{
+		"id":      1,
+		"method":  "eth_subscribe",
+		"version": "2.0",
+		"params":  []interface{}{"burstSubscription", n, 0},
+	}
+	if err := out.Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	// The connection may be closed before the subscription id is even read.
+	var response JSONResponse
+	if err := in.Decode(&response); err != nil {
+		return
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	for i := 0; i < n; i++ {
+		var notification jsonNotification
+		if err := in.Decode(&notification); err != nil {
+			return
+		}
+	}
+	t.Fatal("slow subscriber not disconnected")
+}
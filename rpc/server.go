@@ -59,6 +59,8 @@ func NewServer() *Server {
 		subscriptions: make(subscriptionRegistry),
 		codecs:        set.New(),
 		run:           1,
+		subBufferSize: DefaultSubscriptionBuffer,
+		subPolicy:     SubscriptionDisconnect,
 	}
 
 	// register a default service which will provide meta information about the RPC service such as the services and
@@ -69,6 +71,37 @@ func NewServer() *Server {
 	return server
 }
 
+// SetSubscriptionLimits sets the number of notifications a subscription may have
+// queued on its connection and what happens once a slow subscriber exceeds it. It
+// only applies to connections served afterwards.
+func (s *Server) SetSubscriptionLimits(buffer int, policy SubscriptionPolicy) {
+	s.subBufferSize = buffer
+	s.subPolicy = policy
+}
+
+// SetConcurrencyLimits caps the number of concurrently executing requests per
+// namespace or method. It must be set before the server serves any requests.
+func (s *Server) SetConcurrencyLimits(limits *ConcurrencyLimits) {
+	s.limits = limits
+}
+
+// SetAPIKeys requires clients to authenticate with one of the given API keys,
+// enforcing the methods and quota of their key. It must be set before the
+// server serves any requests, and only on HTTP and websocket servers.
+func (s *Server) SetAPIKeys(keys *APIKeys) {
+	s.apiKeys = keys
+}
+
+type originKey struct{}
+
+// OriginFromContext describes where the request being served came from, e.g.
+// "http 10.0.0.1:52100", "ws 10.0.0.1:52101", "ipc" or "inproc". It's empty if
+// the connection can't tell.
+func OriginFromContext(ctx context.Context) string {
+	origin, _ := ctx.Value(originKey{}).(string)
+	return origin
+}
+
 // RPCService gives meta information about the server.
 // e.g. gives information about the loaded modules.
 type RPCService struct {
@@ -162,7 +195,12 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 	// to send notification to clients. It is thight to the codec/connection. If the
 	// connection is closed the notifier will stop and cancels all active subscriptions.
 	if options&OptionSubscriptions == OptionSubscriptions {
-		ctx = context.WithValue(ctx, notifierKey{}, newBufferedNotifier(codec, notificationBufferSize))
+		ctx = context.WithValue(ctx, notifierKey{}, newBufferedNotifier(codec, notificationBufferSize, s.subBufferSize, s.subPolicy))
+	}
+	if o, ok := codec.(interface {
+		Origin() string
+	}); ok {
+		ctx = context.WithValue(ctx, originKey{}, o.Origin())
 	}
 	s.codecsMu.Lock()
 	if atomic.LoadInt32(&s.run) != 1 { // server stopped
@@ -256,6 +294,16 @@ func (s *Server) Stop() {
 	}
 }
 
+// codecAPIKey returns the API key the client of codec authenticated with.
+func codecAPIKey(codec ServerCodec) string {
+	if k, ok := codec.(interface {
+		APIKey() string
+	}); ok {
+		return k.APIKey()
+	}
+	return ""
+}
+
 // createSubscription will call the subscription callback and returns the subscription id or error.
 func (s *Server) createSubscription(ctx context.Context, c ServerCodec, req *serverRequest) (string, error) {
 	// subscription have as first argument the context following optional arguments
@@ -277,6 +325,11 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	if req.isUnsubscribe { // cancel subscription, first param must be the subscription id
+		if s.apiKeys != nil {
+			if err := s.apiKeys.authorize(codecAPIKey(codec), req.svcname, "unsubscribe"); err != nil {
+				return codec.CreateErrorResponse(&req.id, err), nil
+			}
+		}
 		if len(req.args) >= 1 && req.args[0].Kind() == reflect.String {
 			notifier, supported := NotifierFromContext(ctx)
 			if !supported { // interface doesn't support subscriptions (e.g. http)
@@ -293,6 +346,16 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, &invalidParamsError{"Expected subscription id as first argument"}), nil
 	}
 
+	if s.apiKeys != nil {
+		method := formatName(req.callb.method.Name)
+		if req.callb.isSubscribe {
+			method = "subscribe"
+		}
+		if err := s.apiKeys.authorize(codecAPIKey(codec), req.svcname, method); err != nil {
+			return codec.CreateErrorResponse(&req.id, err), nil
+		}
+	}
+
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
@@ -324,6 +387,17 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		arguments = append(arguments, req.args...)
 	}
 
+	// wait for an execution slot if the method is capped
+	if s.limits != nil {
+		release, err := s.limits.acquire(ctx, req.svcname, formatName(req.callb.method.Name))
+		if err != nil {
+			return codec.CreateErrorResponse(&req.id, err), nil
+		}
+		if release != nil {
+			defer release()
+		}
+	}
+
 	// execute RPC method and return result
 	reply := req.callb.method.Func.Call(arguments)
 	if len(reply) == 0 {
@@ -333,6 +407,9 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
+			if de, ok := e.(DataError); ok {
+				return codec.CreateErrorResponseWithInfo(&req.id, &callbackError{e.Error()}, de.ErrorData()), nil
+			}
 			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
 			return res, nil
 		}
@@ -405,7 +482,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, RPCErro
 		var svc *service
 
 		if r.isPubSub && r.method == unsubscribeMethod {
-			requests[i] = &serverRequest{id: r.id, isUnsubscribe: true}
+			requests[i] = &serverRequest{id: r.id, svcname: r.service, isUnsubscribe: true}
 			argTypes := []reflect.Type{reflect.TypeOf("")} // expect subscription id as first arg
 			if args, err := codec.ParseRequestArguments(argTypes, r.params); err == nil {
 				requests[i].args = args
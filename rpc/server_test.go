@@ -159,3 +159,40 @@ func TestServerMethodExecution(t *testing.T) {
 func TestServerMethodWithCtx(t *testing.T) {
 	testServerMethodExecution(t, "echoWithCtx")
 }
+
+type dataError struct{}
+
+func (dataError) Error() string          { return "failed" }
+func (dataError) ErrorData() interface{} { return map[string]int{"pc": 7} }
+
+type DataErrorService struct{}
+
+func (s *DataErrorService) Fail() (string, error) {
+	return "", dataError{}
+}
+
+func TestServerErrorData(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(DataErrorService)); err != nil {
+		t.Fatal(err)
+	}
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+
+	go server.ServeCodec(NewJSONCodec(serverConn), OptionMethodInvocation)
+
+	request := map[string]interface{}{"id": 1, "method": "test_fail", "version": "2.0"}
+	if err := json.NewEncoder(clientConn).Encode(request); err != nil {
+		t.Fatal(err)
+	}
+	var response JSONResponse
+	if err := json.NewDecoder(clientConn).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Error == nil || response.Error.Message != "failed" {
+		t.Fatalf("unexpected error: %+v", response.Error)
+	}
+	if want := map[string]interface{}{"pc": float64(7)}; !reflect.DeepEqual(response.Error.Data, want) {
+		t.Errorf("error data mismatch: have %v, want %v", response.Error.Data, want)
+	}
+}
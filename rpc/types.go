@@ -17,6 +17,8 @@
 package rpc
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/big"
@@ -24,6 +26,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/webchain-network/webchaind/common"
 	"gopkg.in/fatih/set.v0"
 )
 
@@ -76,6 +79,12 @@ type Server struct {
 	run      int32
 	codecsMu sync.Mutex
 	codecs   *set.Set
+
+	subBufferSize int                // max queued notifications per subscription
+	subPolicy     SubscriptionPolicy // handling of subscriptions exceeding subBufferSize
+
+	limits  *ConcurrencyLimits // caps on concurrently executing requests (none if nil)
+	apiKeys *APIKeys           // authentication of clients (none if nil)
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -95,6 +104,13 @@ type RPCError interface {
 	Error() string
 }
 
+// DataError is implemented by errors of RPC methods carrying additional
+// information, returned as the data member of the error response.
+type DataError interface {
+	Error() string
+	ErrorData() interface{}
+}
+
 // ServerCodec implements reading, parsing and writing RPC messages for the server side of
 // a RPC session. Implementations must be go-routine safe since the codec can be called in
 // multiple go-routines concurrently.
@@ -271,6 +287,95 @@ func (bn *BlockNumber) Int64() int64 {
 	return (int64)(*bn)
 }
 
+// BlockNumberOrHash selects the state queried by an API method, either by
+// block number, by block hash or directly by state root. Exactly one of the
+// three is set.
+//
+// It unmarshals from a block number or tag like BlockNumber, from a 32 byte
+// hex string which is taken as a block hash, or from an object such as
+// {"blockHash": "0x...", "requireCanonical": true} or {"stateRoot": "0x..."}.
+type BlockNumberOrHash struct {
+	BlockNumber *BlockNumber `json:"blockNumber,omitempty"`
+	BlockHash   *common.Hash `json:"blockHash,omitempty"`
+	StateRoot   *common.Hash `json:"stateRoot,omitempty"`
+
+	// RequireCanonical rejects block hashes not in the canonical chain.
+	RequireCanonical bool `json:"requireCanonical,omitempty"`
+}
+
+// BlockNumberOrHashWithNumber selects the state of the given block number.
+func BlockNumberOrHashWithNumber(bn BlockNumber) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockNumber: &bn}
+}
+
+// BlockNumberOrHashWithHash selects the state of the block with the given hash.
+func BlockNumberOrHashWithHash(hash common.Hash, canonical bool) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockHash: &hash, RequireCanonical: canonical}
+}
+
+// BlockNumberOrHashWithRoot selects the state with the given root.
+func BlockNumberOrHashWithRoot(root common.Hash) BlockNumberOrHash {
+	return BlockNumberOrHash{StateRoot: &root}
+}
+
+// UnmarshalJSON parses the given JSON fragment into a BlockNumberOrHash.
+func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
+	input := strings.TrimSpace(string(data))
+
+	if strings.HasPrefix(input, "{") {
+		var obj struct {
+			BlockNumber      *BlockNumber `json:"blockNumber"`
+			BlockHash        *common.Hash `json:"blockHash"`
+			StateRoot        *common.Hash `json:"stateRoot"`
+			RequireCanonical bool         `json:"requireCanonical"`
+		}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		set := 0
+		for _, ok := range []bool{obj.BlockNumber != nil, obj.BlockHash != nil, obj.StateRoot != nil} {
+			if ok {
+				set++
+			}
+		}
+		if set != 1 {
+			return errors.New("exactly one of blockNumber, blockHash and stateRoot must be given")
+		}
+		if obj.RequireCanonical && obj.BlockHash == nil {
+			return errors.New("requireCanonical is only allowed with blockHash")
+		}
+		*bnh = BlockNumberOrHash(obj)
+		return nil
+	}
+	if len(input) == 2+2*common.HashLength+2 && strings.HasPrefix(input, `"0x`) {
+		var hash common.Hash
+		if err := json.Unmarshal(data, &hash); err != nil {
+			return err
+		}
+		*bnh = BlockNumberOrHashWithHash(hash, false)
+		return nil
+	}
+	var bn BlockNumber
+	if err := bn.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	*bnh = BlockNumberOrHashWithNumber(bn)
+	return nil
+}
+
+// String returns a description of the selected state for error messages.
+func (bnh BlockNumberOrHash) String() string {
+	switch {
+	case bnh.BlockHash != nil:
+		return bnh.BlockHash.Hex()
+	case bnh.StateRoot != nil:
+		return "state " + bnh.StateRoot.Hex()
+	case bnh.BlockNumber != nil:
+		return fmt.Sprint(bnh.BlockNumber.Int64())
+	}
+	return "nil"
+}
+
 // Client defines the interface for go client that wants to connect to a geth RPC endpoint
 type Client interface {
 	// SupportedModules returns the collection of API's the server offers
@@ -21,6 +21,8 @@ import (
 	"encoding/json"
 	"math/big"
 	"testing"
+
+	"github.com/webchain-network/webchaind/common"
 )
 
 func TestNewHexNumber(t *testing.T) {
@@ -71,3 +73,42 @@ func TestHexNumberMarshalJSON(t *testing.T) {
 		t.Fatalf("Invalid json.Marshal, expected '%s', got '%s'", exp, got)
 	}
 }
+
+func TestBlockNumberOrHashUnmarshalJSON(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	tests := []struct {
+		input string
+		want  BlockNumberOrHash
+	}{
+		{`"0x10"`, BlockNumberOrHashWithNumber(16)},
+		{`"latest"`, BlockNumberOrHashWithNumber(LatestBlockNumber)},
+		{`"pending"`, BlockNumberOrHashWithNumber(PendingBlockNumber)},
+		{`"` + hash.Hex() + `"`, BlockNumberOrHashWithHash(hash, false)},
+		{`{"blockNumber": "0x10"}`, BlockNumberOrHashWithNumber(16)},
+		{`{"blockHash": "` + hash.Hex() + `", "requireCanonical": true}`, BlockNumberOrHashWithHash(hash, true)},
+		{`{"stateRoot": "` + hash.Hex() + `"}`, BlockNumberOrHashWithRoot(hash)},
+	}
+	for i, tt := range tests {
+		var have BlockNumberOrHash
+		if err := json.Unmarshal([]byte(tt.input), &have); err != nil {
+			t.Errorf("test %d: %v", i, err)
+			continue
+		}
+		if have.String() != tt.want.String() || have.RequireCanonical != tt.want.RequireCanonical {
+			t.Errorf("test %d: have %v (canonical %t), want %v", i, have, have.RequireCanonical, tt.want)
+		}
+	}
+
+	failures := []string{
+		`"foo"`,
+		`{}`,
+		`{"blockNumber": "0x10", "blockHash": "` + hash.Hex() + `"}`,
+		`{"stateRoot": "` + hash.Hex() + `", "requireCanonical": true}`,
+	}
+	for i, input := range failures {
+		var have BlockNumberOrHash
+		if err := json.Unmarshal([]byte(input), &have); err == nil {
+			t.Errorf("failure %d: no error, have %v", i, have)
+		}
+	}
+}
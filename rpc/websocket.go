@@ -49,6 +49,16 @@ func (rw *wsReaderWriterCloser) Close() error {
 	return rw.c.Close()
 }
 
+// Origin returns the address of the websocket client.
+func (rw *wsReaderWriterCloser) Origin() string {
+	return "ws " + rw.c.Request().RemoteAddr
+}
+
+// APIKey returns the API key the websocket client passed in the handshake.
+func (rw *wsReaderWriterCloser) APIKey() string {
+	return requestAPIKey(rw.c.Request())
+}
+
 // wsHandshakeValidator returns a handler that verifies the origin during the
 // websocket upgrade process. When a '*' is specified as an allowed origins all
 // connections are accepted.
@@ -87,13 +97,14 @@ func wsHandshakeValidator(allowedOrigins []string) func(*websocket.Config, *http
 	return f
 }
 
-// NewWSServer creates a new websocket RPC server around an API provider.
-func NewWSServer(allowedOrigins string, handler *Server) *http.Server {
+// NewWSServer creates a new websocket RPC server around an API provider,
+// exchanging messages no larger than limits.
+func NewWSServer(allowedOrigins string, limits MessageLimits, handler *Server) *http.Server {
 	return &http.Server{
 		Handler: websocket.Server{
 			Handshake: wsHandshakeValidator(strings.Split(allowedOrigins, ",")),
 			Handler: func(conn *websocket.Conn) {
-				handler.ServeCodec(NewJSONCodec(&wsReaderWriterCloser{conn}),
+				handler.ServeCodec(NewJSONCodecWithLimits(&wsReaderWriterCloser{conn}, limits),
 					OptionMethodInvocation|OptionSubscriptions)
 			},
 		},
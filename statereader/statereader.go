@@ -0,0 +1,210 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+// Package statereader gives external tools read-only access to the accounts,
+// storage and code held in a webchaind database, at the head block or any
+// older state root still retained in the database.
+//
+// A typical use opens the chain data directory of a stopped node:
+//
+//	r, err := statereader.Open("/home/user/.webchain/mainnet")
+//	if err != nil {
+//		...
+//	}
+//	defer r.Close()
+//	root, err := r.StateRoot(1000000)
+//	balance, err := r.Balance(root, addr)
+package statereader
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"path/filepath"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/crypto"
+	"github.com/webchain-network/webchaind/ethdb"
+	"github.com/webchain-network/webchaind/rlp"
+)
+
+var (
+	// ErrUnknownBlock is returned for blocks not in the canonical chain.
+	ErrUnknownBlock = errors.New("unknown block")
+
+	emptyCodeHash = crypto.Keccak256(nil)
+)
+
+// Account is the state of an account at a state root.
+type Account struct {
+	Nonce       uint64
+	Balance     *big.Int
+	StorageRoot common.Hash
+	CodeHash    common.Hash
+}
+
+// Reader reads state from a chain database. It is safe for concurrent use.
+type Reader struct {
+	db    ethdb.Database
+	state state.Database
+	owned *ethdb.LDBDatabase // database opened by Open, closed by Close
+}
+
+// Open opens the database of a chain data directory, i.e. the directory of a
+// chain within the data directory such as ~/.webchain/mainnet, for reading.
+// The node using the directory must not be running.
+func Open(chainDir string) (*Reader, error) {
+	db, err := ethdb.NewReadOnlyLDBDatabase(filepath.Join(chainDir, "chaindata"), 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	r := New(db)
+	r.owned = db
+	return r, nil
+}
+
+// New creates a reader of the given chain database, which the caller keeps
+// ownership of.
+func New(db ethdb.Database) *Reader {
+	return &Reader{db: db, state: state.NewDatabase(db)}
+}
+
+// Close closes the database if it was opened by Open.
+func (r *Reader) Close() {
+	if r.owned != nil {
+		r.owned.Close()
+	}
+}
+
+// Head returns the header of the head block.
+func (r *Reader) Head() (*types.Header, error) {
+	header := core.GetHeader(r.db, core.GetHeadBlockHash(r.db))
+	if header == nil {
+		return nil, ErrUnknownBlock
+	}
+	return header, nil
+}
+
+// Header returns the header of the canonical block with the given number.
+func (r *Reader) Header(number uint64) (*types.Header, error) {
+	hash := core.GetCanonicalHash(r.db, number)
+	if hash == (common.Hash{}) {
+		return nil, ErrUnknownBlock
+	}
+	header := core.GetHeader(r.db, hash)
+	if header == nil {
+		return nil, ErrUnknownBlock
+	}
+	return header, nil
+}
+
+// StateRoot returns the state root of the canonical block with the given
+// number. The state itself may be missing, see HasState.
+func (r *Reader) StateRoot(number uint64) (common.Hash, error) {
+	header, err := r.Header(number)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Root, nil
+}
+
+// HasState reports whether the state with the given root is retained in the
+// database. Fast synced nodes only have the states from the pivot block on.
+func (r *Reader) HasState(root common.Hash) bool {
+	_, err := r.state.OpenTrie(root)
+	return err == nil
+}
+
+// Account returns the account of addr at the given state root, or nil if it
+// doesn't exist.
+func (r *Reader) Account(root common.Hash, addr common.Address) (*Account, error) {
+	data, err := r.account(root, addr)
+	if data == nil || err != nil {
+		return nil, err
+	}
+	return &Account{
+		Nonce:       data.Nonce,
+		Balance:     data.Balance,
+		StorageRoot: data.Root,
+		CodeHash:    common.BytesToHash(data.CodeHash),
+	}, nil
+}
+
+// Balance returns the balance of addr at the given state root, zero for
+// missing accounts.
+func (r *Reader) Balance(root common.Hash, addr common.Address) (*big.Int, error) {
+	data, err := r.account(root, addr)
+	if data == nil || err != nil {
+		return new(big.Int), err
+	}
+	return data.Balance, nil
+}
+
+// Storage returns the value of a storage slot of addr at the given state root,
+// zero for unset slots and missing accounts.
+func (r *Reader) Storage(root common.Hash, addr common.Address, key common.Hash) (common.Hash, error) {
+	data, err := r.account(root, addr)
+	if data == nil || err != nil {
+		return common.Hash{}, err
+	}
+	storage, err := r.state.OpenStorageTrie(crypto.Keccak256Hash(addr[:]), data.Root)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	enc, err := storage.TryGet(key[:])
+	if err != nil || len(enc) == 0 {
+		return common.Hash{}, err
+	}
+	_, content, _, err := rlp.Split(enc)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(content), nil
+}
+
+// Code returns the code of addr at the given state root, nil for accounts
+// without code.
+func (r *Reader) Code(root common.Hash, addr common.Address) ([]byte, error) {
+	data, err := r.account(root, addr)
+	if data == nil || err != nil {
+		return nil, err
+	}
+	if bytes.Equal(data.CodeHash, emptyCodeHash) {
+		return nil, nil
+	}
+	return r.state.ContractCode(crypto.Keccak256Hash(addr[:]), common.BytesToHash(data.CodeHash))
+}
+
+// account loads the account of addr from the account trie, returning nil if
+// it doesn't exist.
+func (r *Reader) account(root common.Hash, addr common.Address) (*state.Account, error) {
+	tr, err := r.state.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := tr.TryGet(addr[:])
+	if err != nil || len(enc) == 0 {
+		return nil, err
+	}
+	data := new(state.Account)
+	if err := rlp.DecodeBytes(enc, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2018 Webchain project
+// This file is part of Webchain.
+//
+// Webchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Webchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Webchain. If not, see <http://www.gnu.org/licenses/>.
+
+package statereader
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/webchain-network/webchaind/common"
+	"github.com/webchain-network/webchaind/core"
+	"github.com/webchain-network/webchaind/core/state"
+	"github.com/webchain-network/webchaind/core/types"
+	"github.com/webchain-network/webchaind/ethdb"
+)
+
+var (
+	contract = common.HexToAddress("0x1000")
+	code     = []byte{0x60, 0x00, 0x54}
+)
+
+// writeChain writes two blocks with different states of the contract.
+func writeChain(t *testing.T, db ethdb.Database) (roots []common.Hash) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	for number := uint64(0); number < 2; number++ {
+		statedb.SetBalance(contract, big.NewInt(int64(number+1)))
+		statedb.SetCode(contract, code)
+		statedb.SetState(contract, common.Hash{1}, common.BigToHash(big.NewInt(int64(number+10))))
+		root, err := statedb.CommitTo(db, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := &types.Header{Number: new(big.Int).SetUint64(number), Root: root}
+		core.WriteHeader(db, header)
+		core.WriteCanonicalHash(db, header.Hash(), number)
+		core.WriteHeadBlockHash(db, header.Hash())
+		roots = append(roots, root)
+	}
+	return roots
+}
+
+func TestReader(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	roots := writeChain(t, db)
+	r := New(db)
+
+	head, err := r.Head()
+	if err != nil || head.Number.Uint64() != 1 {
+		t.Fatalf("head mismatch: %v %v", head, err)
+	}
+	if _, err := r.StateRoot(2); err != ErrUnknownBlock {
+		t.Errorf("unknown block error mismatch: %v", err)
+	}
+	// Older states can be read as long as they're retained.
+	for i, root := range roots {
+		if have, _ := r.StateRoot(uint64(i)); have != root {
+			t.Errorf("block %d: root mismatch: have %x, want %x", i, have, root)
+		}
+		if !r.HasState(root) {
+			t.Errorf("block %d: state missing", i)
+		}
+		account, err := r.Account(root, contract)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if account.Balance.Int64() != int64(i+1) {
+			t.Errorf("block %d: balance mismatch: have %v", i, account.Balance)
+		}
+		value, err := r.Storage(root, contract, common.Hash{1})
+		if err != nil || value != common.BigToHash(big.NewInt(int64(i+10))) {
+			t.Errorf("block %d: storage mismatch: have %x, %v", i, value, err)
+		}
+		if have, err := r.Code(root, contract); err != nil || !bytes.Equal(have, code) {
+			t.Errorf("block %d: code mismatch: have %x, %v", i, have, err)
+		}
+	}
+	// Missing accounts are empty, missing states fail.
+	other := common.HexToAddress("0x2000")
+	if account, err := r.Account(roots[1], other); account != nil || err != nil {
+		t.Errorf("missing account: have %v, %v", account, err)
+	}
+	if balance, err := r.Balance(roots[1], other); balance.Sign() != 0 || err != nil {
+		t.Errorf("missing account balance: have %v, %v", balance, err)
+	}
+	if r.HasState(common.Hash{1}) {
+		t.Error("unknown state reported")
+	}
+	if _, err := r.Balance(common.Hash{1}, contract); err == nil {
+		t.Error("no error for unknown state")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statereader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := ethdb.NewLDBDatabase(filepath.Join(dir, "chaindata"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := writeChain(t, db)
+	db.Close()
+
+	r, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if have, err := r.Code(roots[0], contract); err != nil || !bytes.Equal(have, code) {
+		t.Errorf("code mismatch: have %x, %v", have, err)
+	}
+	if _, err := Open(filepath.Join(dir, "missing")); err == nil {
+		t.Error("no error for missing database")
+	}
+}
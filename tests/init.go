@@ -50,6 +50,11 @@ var (
 	TransSkipTests = []string{"TransactionWithHihghNonce256"}
 	StateSkipTests = []string{}
 	VmSkipTests    = []string{}
+
+	/* The VM accounts gas in 64 bits. These tests pass calls more gas than that,
+	which is far above any block gas limit.
+	*/
+	BigGasSkipTests = []string{"CallRecursiveBombPreCall", "Call1024PreCalls"}
 )
 
 func initBlockSkipTests() []string {
@@ -159,7 +159,7 @@ func TestCallCreateCallCode(t *testing.T) {
 	}
 
 	fn := filepath.Join(stateTestDir, "stCallCreateCallCodeTest.json")
-	if err := RunStateTest(ruleSet, fn, StateSkipTests); err != nil {
+	if err := RunStateTest(ruleSet, fn, append(StateSkipTests, BigGasSkipTests...)); err != nil {
 		t.Error(err)
 	}
 }
@@ -181,7 +181,7 @@ func TestDelegateCall(t *testing.T) {
 	}
 
 	fn := filepath.Join(stateTestDir, "stDelegatecallTest.json")
-	if err := RunStateTest(ruleSet, fn, StateSkipTests); err != nil {
+	if err := RunStateTest(ruleSet, fn, append(StateSkipTests, BigGasSkipTests...)); err != nil {
 		t.Error(err)
 	}
 }
@@ -344,7 +344,7 @@ func TestHomesteadCallCreateCallCode(t *testing.T) {
 	}
 
 	fn := filepath.Join(stateTestDir, "Homestead", "stCallCreateCallCodeTest.json")
-	if err := RunStateTest(ruleSet, fn, StateSkipTests); err != nil {
+	if err := RunStateTest(ruleSet, fn, append(StateSkipTests, BigGasSkipTests...)); err != nil {
 		t.Error(err)
 	}
 }
@@ -211,38 +211,37 @@ func (r RuleSet) IsAtlantis(n *big.Int) bool {
 func (r RuleSet) GasTable(num *big.Int) *vm.GasTable {
 	if r.HomesteadGasRepriceBlock == nil || num == nil || num.Cmp(r.HomesteadGasRepriceBlock) < 0 {
 		return &vm.GasTable{
-			ExtcodeSize:     big.NewInt(20),
-			ExtcodeCopy:     big.NewInt(20),
-			Balance:         big.NewInt(20),
-			SLoad:           big.NewInt(50),
-			Calls:           big.NewInt(40),
-			Suicide:         big.NewInt(0),
-			ExpByte:         big.NewInt(10),
-			CreateBySuicide: nil,
+			ExtcodeSize: 20,
+			ExtcodeCopy: 20,
+			Balance:     20,
+			SLoad:       50,
+			Calls:       40,
+			Suicide:     0,
+			ExpByte:     10,
 		}
 	}
 	if r.DiehardBlock == nil || num == nil || num.Cmp(r.DiehardBlock) < 0 {
 		return &vm.GasTable{
-			ExtcodeSize:     big.NewInt(700),
-			ExtcodeCopy:     big.NewInt(700),
-			Balance:         big.NewInt(400),
-			SLoad:           big.NewInt(200),
-			Calls:           big.NewInt(700),
-			Suicide:         big.NewInt(5000),
-			ExpByte:         big.NewInt(10),
-			CreateBySuicide: big.NewInt(25000),
+			ExtcodeSize:     700,
+			ExtcodeCopy:     700,
+			Balance:         400,
+			SLoad:           200,
+			Calls:           700,
+			Suicide:         5000,
+			ExpByte:         10,
+			CreateBySuicide: 25000,
 		}
 	}
 
 	return &vm.GasTable{
-		ExtcodeSize:     big.NewInt(700),
-		ExtcodeCopy:     big.NewInt(700),
-		Balance:         big.NewInt(400),
-		SLoad:           big.NewInt(200),
-		Calls:           big.NewInt(700),
-		Suicide:         big.NewInt(5000),
-		ExpByte:         big.NewInt(50),
-		CreateBySuicide: big.NewInt(25000),
+		ExtcodeSize:     700,
+		ExtcodeCopy:     700,
+		Balance:         400,
+		SLoad:           200,
+		Calls:           700,
+		Suicide:         5000,
+		ExpByte:         50,
+		CreateBySuicide: 25000,
 	}
 }
 
@@ -350,58 +349,58 @@ func (self *Env) Transfer(from, to vm.Account, amount *big.Int) {
 	core.Transfer(from, to, amount)
 }
 
-func (self *Env) Call(caller vm.ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error) {
+func (self *Env) Call(caller vm.ContractRef, addr common.Address, data []byte, gas uint64, price, value *big.Int) ([]byte, uint64, error) {
 	if self.vmTest && self.depth > 0 {
-		caller.ReturnGas(gas, price)
-
-		return nil, nil
+		return nil, gas, nil
 	}
-	ret, err := core.Call(self, caller, addr, data, gas, price, value)
-	self.Gas = gas
+	ret, gas, err := core.Call(self, caller, addr, data, gas, price, value)
+	self.Gas = new(big.Int).SetUint64(gas)
 
-	return ret, err
+	return ret, gas, err
 
 }
-func (self *Env) CallCode(caller vm.ContractRef, addr common.Address, data []byte, gas, price, value *big.Int) ([]byte, error) {
+func (self *Env) CallCode(caller vm.ContractRef, addr common.Address, data []byte, gas uint64, price, value *big.Int) ([]byte, uint64, error) {
 	if self.vmTest && self.depth > 0 {
-		caller.ReturnGas(gas, price)
-
-		return nil, nil
+		return nil, gas, nil
 	}
 	return core.CallCode(self, caller, addr, data, gas, price, value)
 }
 
-func (self *Env) DelegateCall(caller vm.ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error) {
+func (self *Env) DelegateCall(caller vm.ContractRef, addr common.Address, data []byte, gas uint64, price *big.Int) ([]byte, uint64, error) {
 	if self.vmTest && self.depth > 0 {
-		caller.ReturnGas(gas, price)
-
-		return nil, nil
+		return nil, gas, nil
 	}
 	return core.DelegateCall(self, caller, addr, data, gas, price)
 }
 
-func (self *Env) StaticCall(caller vm.ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error) {
+func (self *Env) StaticCall(caller vm.ContractRef, addr common.Address, data []byte, gas uint64, price *big.Int) ([]byte, uint64, error) {
 	if self.vmTest && self.depth > 0 {
-		caller.ReturnGas(gas, price)
-
-		return nil, nil
+		return nil, gas, nil
 	}
 	return core.StaticCall(self, caller, addr, data, gas, price)
 }
 
-func (self *Env) Create(caller vm.ContractRef, data []byte, gas, price, value *big.Int) ([]byte, common.Address, error) {
+func (self *Env) Create(caller vm.ContractRef, data []byte, gas uint64, price, value *big.Int) ([]byte, common.Address, uint64, error) {
 	if self.vmTest {
-		caller.ReturnGas(gas, price)
-
 		nonce := self.state.GetNonce(caller.Address())
 		obj := self.state.GetOrNewStateObject(crypto.CreateAddress(caller.Address(), nonce))
 
-		return nil, obj.Address(), nil
+		return nil, obj.Address(), gas, nil
 	} else {
 		return core.Create(self, caller, data, gas, price, value)
 	}
 }
 
+func (self *Env) Create2(caller vm.ContractRef, data []byte, gas uint64, price, value, salt *big.Int) ([]byte, common.Address, uint64, error) {
+	if self.vmTest {
+		obj := self.state.GetOrNewStateObject(crypto.CreateAddress2(caller.Address(), common.BigToHash(salt), crypto.Keccak256(data)))
+
+		return nil, obj.Address(), gas, nil
+	} else {
+		return core.Create2(self, caller, data, gas, price, value, salt)
+	}
+}
+
 type Message struct {
 	from              common.Address
 	to                *common.Address
@@ -423,3 +422,5 @@ func (self Message) Gas() *big.Int                         { return self.gas }
 func (self Message) Value() *big.Int                       { return self.value }
 func (self Message) Nonce() uint64                         { return self.nonce }
 func (self Message) Data() []byte                          { return self.data }
+func (self Message) GasTipCap() *big.Int                   { return self.price }
+func (self Message) AccessList() types.AccessList          { return nil }
@@ -207,7 +207,7 @@ func RunVm(state *state.StateDB, env, exec map[string]string) ([]byte, vm.Logs,
 		price, _ = new(big.Int).SetString(exec["gasPrice"], 0)
 		value, _ = new(big.Int).SetString(exec["value"], 0)
 	)
-	if gas == nil || price == nil || value == nil {
+	if gas == nil || !gas.IsUint64() || price == nil || value == nil {
 		panic("malformed gas, price or value")
 	}
 	// Reset the pre-compiled contracts for VM tests.
@@ -224,7 +224,7 @@ func RunVm(state *state.StateDB, env, exec map[string]string) ([]byte, vm.Logs,
 	vmenv.vmTest = true
 	vmenv.skipTransfer = true
 	vmenv.initial = true
-	ret, err := vmenv.Call(caller, to, data, gas, price, value)
+	ret, _, err := vmenv.Call(caller, to, data, gas.Uint64(), price, value)
 
 	return ret, vmenv.state.Logs(), vmenv.Gas, err
 }
@@ -138,6 +138,12 @@ func (t *SecureTrie) GetKey(shaKey []byte) []byte {
 	return key
 }
 
+// ProveHashed constructs a merkle proof for the already hashed key shaKey, as
+// returned by the trie's iterators. See Trie.Prove.
+func (t *SecureTrie) ProveHashed(shaKey []byte, fromLevel uint, proofDb DatabaseWriter) error {
+	return t.trie.Prove(shaKey, fromLevel, proofDb)
+}
+
 // Commit writes all nodes and the secure hash pre-images to the trie's database.
 // Nodes are stored with their sha3 hash as the key.
 //
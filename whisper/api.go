@@ -77,10 +77,50 @@ func (s *PublicWhisperAPI) NewIdentity() (string, error) {
 	return common.ToHex(crypto.FromECDSAPub(&identity.PublicKey)), nil
 }
 
+// Info returns diagnostic information about the whisper node.
+func (s *PublicWhisperAPI) Info() (*WhisperInfo, error) {
+	if s.w == nil {
+		return nil, whisperOffLineErr
+	}
+	s.w.peerMu.RLock()
+	peers := len(s.w.peers)
+	s.w.peerMu.RUnlock()
+
+	return &WhisperInfo{
+		Version:        s.w.Version(),
+		MinPoW:         s.w.MinPoW(),
+		MaxMessageSize: DefaultMaxMessageSize,
+		Messages:       len(s.w.envelopes()),
+		Peers:          peers,
+	}, nil
+}
+
+// SetMinPoW sets the minimum proof of work required of envelopes accepted by
+// the node, announcing it to connected peers.
+func (s *PublicWhisperAPI) SetMinPoW(pow float64) (bool, error) {
+	if s.w == nil {
+		return false, whisperOffLineErr
+	}
+	if err := s.w.SetMinimumPoW(pow); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WhisperInfo is the RPC representation of the whisper node state.
+type WhisperInfo struct {
+	Version        uint    `json:"version"`
+	MinPoW         float64 `json:"minPow"`
+	MaxMessageSize int     `json:"maxMessageSize"`
+	Messages       int     `json:"messages"`
+	Peers          int     `json:"peers"`
+}
+
 type NewFilterArgs struct {
 	To     string
 	From   string
 	Topics [][][]byte
+	MinPoW float64
 }
 
 // NewWhisperFilter creates and registers a new message filter to watch for inbound whisper messages.
@@ -94,6 +134,7 @@ func (s *PublicWhisperAPI) NewFilter(args NewFilterArgs) (*rpc.HexNumber, error)
 		To:     crypto.ToECDSAPub(common.FromHex(args.To)),
 		From:   crypto.ToECDSAPub(common.FromHex(args.From)),
 		Topics: NewFilterTopics(args.Topics...),
+		PoW:    args.MinPoW,
 		Fn: func(message *Message) {
 			wmsg := NewWhisperMessage(message)
 			s.messagesMu.RLock() // Only read lock to the filter pool
@@ -207,12 +248,13 @@ func (s *PublicWhisperAPI) Post(args PostArgs) (bool, error) {
 type WhisperMessage struct {
 	ref *Message
 
-	Payload string `json:"payload"`
-	To      string `json:"to"`
-	From    string `json:"from"`
-	Sent    int64  `json:"sent"`
-	TTL     int64  `json:"ttl"`
-	Hash    string `json:"hash"`
+	Payload string  `json:"payload"`
+	To      string  `json:"to"`
+	From    string  `json:"from"`
+	Sent    int64   `json:"sent"`
+	TTL     int64   `json:"ttl"`
+	Hash    string  `json:"hash"`
+	PoW     float64 `json:"pow"`
 }
 
 func (args *PostArgs) UnmarshalJSON(data []byte) (err error) {
@@ -252,10 +294,15 @@ func (args *NewFilterArgs) UnmarshalJSON(b []byte) (err error) {
 		To     interface{} `json:"to"`
 		From   interface{} `json:"from"`
 		Topics interface{} `json:"topics"`
+		MinPoW float64     `json:"minPow"`
 	}
 	if err := json.Unmarshal(b, &obj); err != nil {
 		return err
 	}
+	if obj.MinPoW < 0 {
+		return fmt.Errorf("minPow is negative")
+	}
+	args.MinPoW = obj.MinPoW
 
 	// Retrieve the simple data contents of the filter arguments
 	if obj.To == nil {
@@ -400,5 +447,6 @@ func NewWhisperMessage(message *Message) WhisperMessage {
 		Sent:    message.Sent.Unix(),
 		TTL:     int64(message.TTL / time.Second),
 		Hash:    common.ToHex(message.Hash.Bytes()),
+		PoW:     message.PoW,
 	}
 }
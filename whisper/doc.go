@@ -15,10 +15,13 @@
 // along with Webchain. If not, see <http://www.gnu.org/licenses/>.
 
 /*
-Package whisper implements the Whisper PoC-1.
+Package whisper implements version 6 of the Whisper protocol, based on PoC-1.
 
 (https://github.com/ethereum/wiki/wiki/Whisper-PoC-1-Protocol-Spec)
 
+Each envelope carries a proof of work, and nodes drop envelopes with less work
+than their configured minimum, announcing that minimum to their peers.
+
 Whisper combines aspects of both DHTs and datagram messaging systems (e.g. UDP).
 As such it may be likened and compared to both, not dissimilar to the
 matter/energy duality (apologies to physicists for the blatant abuse of a
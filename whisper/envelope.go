@@ -23,6 +23,7 @@ import (
 	"crypto/ecdsa"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"math/big"
 	"time"
 
@@ -42,6 +43,7 @@ type Envelope struct {
 	Nonce  uint32
 
 	hash common.Hash // Cached hash of the envelope to avoid rehashing every time
+	pow  float64     // Cached proof of work of the envelope, zero if not yet computed
 }
 
 // NewEnvelope wraps a Whisper message with expiration and destination data
@@ -59,8 +61,7 @@ func NewEnvelope(ttl time.Duration, topics []Topic, msg *Message) *Envelope {
 // Seal closes the envelope by spending the requested amount of time as a proof
 // of work on hashing the data.
 func (self *Envelope) Seal(pow time.Duration) {
-	d := make([]byte, 64)
-	copy(d[:32], self.rlpWithoutNonce())
+	d := self.powData()
 
 	finish, bestBit := time.Now().Add(pow).UnixNano(), 0
 	for nonce := uint32(0); time.Now().UnixNano() < finish; {
@@ -74,6 +75,33 @@ func (self *Envelope) Seal(pow time.Duration) {
 			nonce++
 		}
 	}
+	self.pow = 0
+}
+
+// PoW returns the proof of work of the sealed envelope: two to the power of
+// the zero bits found by sealing, per byte of envelope and second of lifetime.
+func (self *Envelope) PoW() float64 {
+	if self.pow == 0 {
+		d := self.powData()
+		binary.BigEndian.PutUint32(d[60:], self.Nonce)
+		bits := common.FirstBitSet(new(big.Int).SetBytes(crypto.Keccak256(d)))
+
+		enc, _ := rlp.EncodeToBytes(self)
+		ttl := self.TTL
+		if ttl == 0 {
+			ttl = 1
+		}
+		self.pow = math.Pow(2, float64(bits)) / (float64(len(enc)) * float64(ttl))
+	}
+	return self.pow
+}
+
+// powData returns the buffer hashed by the proof of work, the nonce being
+// placed into its last four bytes.
+func (self *Envelope) powData() []byte {
+	d := make([]byte, 64)
+	copy(d[:32], crypto.Keccak256(self.rlpWithoutNonce()))
+	return d
 }
 
 // rlpWithoutNonce returns the RLP encoded envelope contents, except the nonce.
@@ -92,6 +120,7 @@ func (self *Envelope) Open(key *ecdsa.PrivateKey) (msg *Message, err error) {
 		Sent:  time.Unix(int64(self.Expiry-self.TTL), 0),
 		TTL:   time.Duration(self.TTL) * time.Second,
 		Hash:  self.Hash(),
+		PoW:   self.PoW(),
 	}
 	data = data[1:]
 
@@ -29,6 +29,7 @@ type Filter struct {
 	To     *ecdsa.PublicKey   // Recipient of the message
 	From   *ecdsa.PublicKey   // Sender of the message
 	Topics [][]Topic          // Topics to filter messages with
+	PoW    float64            // Minimum proof of work of matched messages
 	Fn     func(msg *Message) // Handler in case of a match
 }
 
@@ -54,6 +55,7 @@ type filterer struct {
 	to      string                 // Recipient of the message
 	from    string                 // Sender of the message
 	matcher *topicMatcher          // Topics to filter messages with
+	pow     float64                // Minimum proof of work of the message
 	fn      func(data interface{}) // Handler in case of a match
 }
 
@@ -68,6 +70,9 @@ func (self filterer) Compare(f filter.Filter) bool {
 	if len(self.from) > 0 && self.from != filter.from {
 		return false
 	}
+	if filter.pow < self.pow {
+		return false
+	}
 	// Check the topic filtering
 	topics := make([]Topic, len(filter.matcher.conditions))
 	for i, group := range filter.matcher.conditions {
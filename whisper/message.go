@@ -43,6 +43,7 @@ type Message struct {
 
 	To   *ecdsa.PublicKey // Message recipient (identity used to decode the message)
 	Hash common.Hash      // Message envelope hash to act as a unique id
+	PoW  float64          // Proof of work of the message envelope
 }
 
 // Options specifies the exact way a message should be wrapped into an Envelope.
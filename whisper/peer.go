@@ -18,6 +18,8 @@ package whisper
 
 import (
 	"fmt"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/webchain-network/webchaind/common"
@@ -36,6 +38,9 @@ type peer struct {
 
 	known *set.Set // Messages already known by the peer to avoid wasting bandwidth
 
+	powRequirement float64    // Minimum PoW of the envelopes the peer accepts
+	powMu          sync.Mutex // Mutex protecting the PoW requirement
+
 	quit chan struct{}
 }
 
@@ -69,7 +74,7 @@ func (self *peer) handshake() error {
 	// Send the handshake status message asynchronously
 	errc := make(chan error, 1)
 	go func() {
-		errc <- p2p.SendItems(self.ws, statusCode, protocolVersion)
+		errc <- p2p.SendItems(self.ws, statusCode, protocolVersion, math.Float64bits(self.host.MinPoW()))
 	}()
 	// Fetch the remote status packet and verify protocol match
 	packet, err := self.ws.ReadMsg()
@@ -90,6 +95,14 @@ func (self *peer) handshake() error {
 	if peerVersion != protocolVersion {
 		return fmt.Errorf("protocol version mismatch %d != %d", peerVersion, protocolVersion)
 	}
+	// The PoW requirement is optional, peers omitting it accept anything
+	powBits, err := s.Uint()
+	if err != nil && err != rlp.EOL {
+		return fmt.Errorf("bad status message: %v", err)
+	}
+	if err := self.setPoWRequirement(math.Float64frombits(powBits)); err != nil {
+		return err
+	}
 	// Wait until out own status is consumed too
 	if err := <-errc; err != nil {
 		return fmt.Errorf("failed to send status packet: %v", err)
@@ -122,6 +135,25 @@ func (self *peer) update() {
 	}
 }
 
+// setPoWRequirement stores the minimum PoW announced by the remote peer.
+func (self *peer) setPoWRequirement(pow float64) error {
+	if pow < 0 || math.IsNaN(pow) || math.IsInf(pow, 0) {
+		return fmt.Errorf("invalid PoW requirement: %v", pow)
+	}
+	self.powMu.Lock()
+	self.powRequirement = pow
+	self.powMu.Unlock()
+	return nil
+}
+
+// accepts checks whether the envelope satisfies the peer's PoW requirement.
+func (self *peer) accepts(envelope *Envelope) bool {
+	self.powMu.Lock()
+	defer self.powMu.Unlock()
+
+	return envelope.PoW() >= self.powRequirement
+}
+
 // mark marks an envelope known to the peer so that it won't be sent back.
 func (self *peer) mark(envelope *Envelope) {
 	self.known.Add(envelope.Hash())
@@ -161,7 +193,7 @@ func (self *peer) broadcast() error {
 	envelopes := self.host.envelopes()
 	transmit := make([]*Envelope, 0, len(envelopes))
 	for _, envelope := range envelopes {
-		if !self.marked(envelope) {
+		if !self.marked(envelope) && self.accepts(envelope) {
 			transmit = append(transmit, envelope)
 			self.mark(envelope)
 		}
@@ -17,6 +17,7 @@
 package whisper
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -24,6 +25,9 @@ import (
 	"github.com/webchain-network/webchaind/p2p/discover"
 )
 
+// testStatus is the handshake status sent by a freshly created whisper node.
+var testStatus = []uint64{protocolVersion, math.Float64bits(DefaultMinimumPoW)}
+
 type testPeer struct {
 	client *Whisper
 	stream *p2p.MsgPipeRW
@@ -58,7 +62,7 @@ func startTestPeer() *testPeer {
 func startTestPeerInited() (*testPeer, error) {
 	peer := startTestPeer()
 
-	if err := p2p.ExpectMsg(peer.stream, statusCode, []uint64{protocolVersion}); err != nil {
+	if err := p2p.ExpectMsg(peer.stream, statusCode, testStatus); err != nil {
 		peer.stream.Close()
 		return nil, err
 	}
@@ -73,7 +77,7 @@ func TestPeerStatusMessage(t *testing.T) {
 	tester := startTestPeer()
 
 	// Wait for the handshake status message and check it
-	if err := p2p.ExpectMsg(tester.stream, statusCode, []uint64{protocolVersion}); err != nil {
+	if err := p2p.ExpectMsg(tester.stream, statusCode, testStatus); err != nil {
 		t.Fatalf("status message mismatch: %v", err)
 	}
 	// Terminate the node
@@ -90,7 +94,7 @@ func TestPeerHandshakeFail(t *testing.T) {
 	tester := startTestPeer()
 
 	// Wait for and check the handshake
-	if err := p2p.ExpectMsg(tester.stream, statusCode, []uint64{protocolVersion}); err != nil {
+	if err := p2p.ExpectMsg(tester.stream, statusCode, testStatus); err != nil {
 		t.Fatalf("status message mismatch: %v", err)
 	}
 	// Send an invalid handshake status and verify disconnect
@@ -108,7 +112,7 @@ func TestPeerHandshakeSuccess(t *testing.T) {
 	tester := startTestPeer()
 
 	// Wait for and check the handshake
-	if err := p2p.ExpectMsg(tester.stream, statusCode, []uint64{protocolVersion}); err != nil {
+	if err := p2p.ExpectMsg(tester.stream, statusCode, testStatus); err != nil {
 		t.Fatalf("status message mismatch: %v", err)
 	}
 	// Send a valid handshake status and make sure connection stays live
@@ -164,6 +168,43 @@ func TestPeerSend(t *testing.T) {
 	}
 }
 
+func TestPeerPoWRequirement(t *testing.T) {
+	tester := startTestPeer()
+	defer tester.stream.Close()
+
+	// Announce a requirement no envelope can satisfy during the handshake
+	if err := p2p.ExpectMsg(tester.stream, statusCode, testStatus); err != nil {
+		t.Fatalf("status message mismatch: %v", err)
+	}
+	if err := p2p.SendItems(tester.stream, statusCode, protocolVersion, math.Float64bits(math.MaxFloat64)); err != nil {
+		t.Fatalf("failed to send status: %v", err)
+	}
+	message := NewMessage([]byte("peer pow test message"))
+	envelope, err := message.Wrap(DefaultPoW, Options{TTL: DefaultTTL})
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	if err := tester.client.Send(envelope); err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+	// The envelope must be withheld until the requirement is lowered
+	for i := 0; i < 2; i++ {
+		if err := p2p.ExpectMsg(tester.stream, messagesCode, []interface{}{}); err != nil {
+			t.Fatalf("message mismatch: %v", err)
+		}
+	}
+	if _, err := p2p.Send(tester.stream, powRequirementCode, math.Float64bits(0)); err != nil {
+		t.Fatalf("failed to send PoW requirement: %v", err)
+	}
+	payload := []interface{}{envelope}
+	if err := p2p.ExpectMsg(tester.stream, messagesCode, payload); err != nil {
+		// The requirement may have been processed after the next broadcast
+		if err := p2p.ExpectMsg(tester.stream, messagesCode, payload); err != nil {
+			t.Fatalf("message mismatch: %v", err)
+		}
+	}
+}
+
 func TestPeerDeliver(t *testing.T) {
 	// Start a tester and execute the handshake
 	tester, err := startTestPeerInited()
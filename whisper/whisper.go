@@ -18,6 +18,8 @@ package whisper
 
 import (
 	"crypto/ecdsa"
+	"fmt"
+	"math"
 	"sync"
 	"time"
 
@@ -34,10 +36,11 @@ import (
 )
 
 const (
-	statusCode   = 0x00
-	messagesCode = 0x01
+	statusCode         = 0x00
+	messagesCode       = 0x01
+	powRequirementCode = 0x02 // Announces the minimum PoW the sender accepts
 
-	protocolVersion uint64 = 0x02
+	protocolVersion uint64 = 0x06
 	protocolName           = "shh"
 
 	signatureFlag   = byte(1 << 7)
@@ -50,6 +53,9 @@ const (
 const (
 	DefaultTTL = 50 * time.Second
 	DefaultPoW = 50 * time.Millisecond
+
+	DefaultMinimumPoW     = 0.2         // Minimum PoW of envelopes accepted into the pool
+	DefaultMaxMessageSize = 1024 * 1024 // Maximum size of an envelope's data
 )
 
 type MessageEvent struct {
@@ -73,6 +79,9 @@ type Whisper struct {
 	peers  map[*peer]struct{} // Set of currently active peers
 	peerMu sync.RWMutex       // Mutex to sync the active peer set
 
+	minPoW   float64      // Envelopes with less proof of work are rejected
+	minPoWMu sync.RWMutex // Mutex protecting the PoW requirement
+
 	quit chan struct{}
 }
 
@@ -85,6 +94,7 @@ func New() *Whisper {
 		messages:    make(map[common.Hash]*Envelope),
 		expirations: make(map[uint32]*set.SetNonTS),
 		peers:       make(map[*peer]struct{}),
+		minPoW:      DefaultMinimumPoW,
 		quit:        make(chan struct{}),
 	}
 	whisper.filters.Start()
@@ -93,7 +103,7 @@ func New() *Whisper {
 	whisper.protocol = p2p.Protocol{
 		Name:    protocolName,
 		Version: uint(protocolVersion),
-		Length:  2,
+		Length:  3,
 		Run:     whisper.handlePeer,
 	}
 
@@ -122,6 +132,34 @@ func (self *Whisper) Version() uint {
 	return self.protocol.Version
 }
 
+// MinPoW returns the minimum proof of work an envelope needs to be accepted.
+func (self *Whisper) MinPoW() float64 {
+	self.minPoWMu.RLock()
+	defer self.minPoWMu.RUnlock()
+
+	return self.minPoW
+}
+
+// SetMinimumPoW changes the minimum proof of work of accepted envelopes and
+// announces the new requirement to all connected peers.
+func (self *Whisper) SetMinimumPoW(pow float64) error {
+	if pow < 0 || math.IsNaN(pow) || math.IsInf(pow, 0) {
+		return fmt.Errorf("invalid minimum PoW: %v", pow)
+	}
+	self.minPoWMu.Lock()
+	self.minPoW = pow
+	self.minPoWMu.Unlock()
+
+	self.peerMu.RLock()
+	defer self.peerMu.RUnlock()
+	for p := range self.peers {
+		if _, err := p2p.Send(p.ws, powRequirementCode, math.Float64bits(pow)); err != nil {
+			glog.V(logger.Debug).Infof("%v: failed to announce PoW requirement: %v", p.peer, err)
+		}
+	}
+	return nil
+}
+
 // NewIdentity generates a new cryptographic identity for the client, and injects
 // it into the known identities for message decryption.
 func (self *Whisper) NewIdentity() *ecdsa.PrivateKey {
@@ -152,6 +190,7 @@ func (self *Whisper) Watch(options Filter) int {
 		to:      string(crypto.FromECDSAPub(options.To)),
 		from:    string(crypto.FromECDSAPub(options.From)),
 		matcher: newTopicMatcher(options.Topics...),
+		pow:     options.PoW,
 		fn: func(data interface{}) {
 			options.Fn(data.(*Message))
 		},
@@ -226,23 +265,39 @@ func (self *Whisper) handlePeer(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
 
 	// Read and process inbound messages directly to merge into client-global state
 	for {
-		// Fetch the next packet and decode the contained envelopes
+		// Fetch the next packet and handle it according to its type
 		packet, err := rw.ReadMsg()
 		if err != nil {
 			return err
 		}
-		var envelopes []*Envelope
-		if err := packet.Decode(&envelopes); err != nil {
-			glog.V(logger.Info).Infof("%v: failed to decode envelope: %v", peer, err)
-			continue
-		}
-		// Inject all envelopes into the internal pool
-		for _, envelope := range envelopes {
-			if err := self.add(envelope); err != nil {
-				// TODO Punish peer here. Invalid envelope.
-				glog.V(logger.Debug).Infof("%v: failed to pool envelope: %v", peer, err)
+		switch packet.Code {
+		case messagesCode:
+			var envelopes []*Envelope
+			if err := packet.Decode(&envelopes); err != nil {
+				glog.V(logger.Info).Infof("%v: failed to decode envelope: %v", peer, err)
+				continue
+			}
+			// Inject all envelopes into the internal pool
+			for _, envelope := range envelopes {
+				if err := self.add(envelope); err != nil {
+					// TODO Punish peer here. Invalid envelope.
+					glog.V(logger.Debug).Infof("%v: failed to pool envelope: %v", peer, err)
+				}
+				whisperPeer.mark(envelope)
+			}
+
+		case powRequirementCode:
+			var bits uint64
+			if err := packet.Decode(&bits); err != nil {
+				return fmt.Errorf("bad PoW requirement: %v", err)
+			}
+			if err := whisperPeer.setPoWRequirement(math.Float64frombits(bits)); err != nil {
+				return err
 			}
-			whisperPeer.mark(envelope)
+
+		default:
+			// New message types might be added in future versions, skip them
+			packet.Discard()
 		}
 	}
 }
@@ -258,6 +313,13 @@ func (self *Whisper) add(envelope *Envelope) error {
 	if envelope.Expiry < uint32(time.Now().Unix()) {
 		return nil
 	}
+	// Reject oversized envelopes and those without enough work spent on them
+	if len(envelope.Data) > DefaultMaxMessageSize {
+		return fmt.Errorf("envelope too large: %d bytes > %d", len(envelope.Data), DefaultMaxMessageSize)
+	}
+	if pow, min := envelope.PoW(), self.MinPoW(); pow < min {
+		return fmt.Errorf("envelope PoW too low: %f < %f", pow, min)
+	}
 
 	// Insert the message into the tracked pool
 	hash := envelope.Hash()
@@ -323,6 +385,7 @@ func createFilter(message *Message, topics []Topic) filter.Filter {
 		to:      string(crypto.FromECDSAPub(message.To)),
 		from:    string(crypto.FromECDSAPub(message.Recover())),
 		matcher: newTopicMatcher(matcher...),
+		pow:     message.PoW,
 	}
 }
 
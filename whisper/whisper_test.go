@@ -176,6 +176,30 @@ func testBroadcast(anonymous bool, t *testing.T) {
 	}
 }
 
+func TestMinimumPoW(t *testing.T) {
+	node := startTestCluster(1)[0]
+
+	envelope, err := NewMessage([]byte("low work message")).Wrap(DefaultPoW, Options{TTL: DefaultTTL})
+	if err != nil {
+		t.Fatalf("failed to wrap message: %v", err)
+	}
+	if err := node.SetMinimumPoW(-1); err == nil {
+		t.Fatalf("negative PoW requirement accepted")
+	}
+	if err := node.SetMinimumPoW(2 * envelope.PoW()); err != nil {
+		t.Fatalf("failed to set PoW requirement: %v", err)
+	}
+	if err := node.Send(envelope); err == nil {
+		t.Fatalf("envelope with insufficient PoW accepted")
+	}
+	if err := node.SetMinimumPoW(envelope.PoW()); err != nil {
+		t.Fatalf("failed to set PoW requirement: %v", err)
+	}
+	if err := node.Send(envelope); err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+}
+
 func TestMessageExpiration(t *testing.T) {
 	// Start the single node cluster and inject a dummy message
 	node := startTestCluster(1)[0]